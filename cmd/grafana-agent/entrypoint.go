@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -18,6 +19,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/pkg/config"
+	"github.com/grafana/agent/pkg/flowconvert"
 	"github.com/grafana/agent/pkg/logs"
 	"github.com/grafana/agent/pkg/metrics"
 	"github.com/grafana/agent/pkg/metrics/instance"
@@ -233,11 +235,48 @@ func (ep *Entrypoint) wire(mux *mux.Router, grpc *grpc.Server) {
 		}
 	})
 
+	mux.HandleFunc("/-/config/flow", ep.flowConfigHandler).Methods("GET")
+
 	mux.HandleFunc("/-/reload", ep.reloadHandler).Methods("GET", "POST")
 
 	mux.HandleFunc("/-/support", ep.supportHandler).Methods("GET")
 }
 
+// flowConfigHandler converts the currently loaded configuration into an
+// equivalent Flow configuration file.
+func (ep *Entrypoint) flowConfigHandler(rw http.ResponseWriter, r *http.Request) {
+	ep.mut.Lock()
+	cfg := ep.cfg
+	ep.mut.Unlock()
+
+	if !cfg.EnableConfigEndpoints {
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte("404 - config endpoint is disabled"))
+		return
+	}
+
+	res := flowconvert.Convert(flowconvert.Input{
+		Metrics:      cfg.Metrics,
+		Logs:         cfg.Logs,
+		Traces:       cfg.Traces,
+		Integrations: cfg.Integrations.EnabledIntegrations(),
+	})
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "river":
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = rw.Write(res.Bytes())
+	case "json":
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(struct {
+			River       string                  `json:"river"`
+			Diagnostics flowconvert.Diagnostics `json:"diagnostics"`
+		}{string(res.River), res.Diagnostics})
+	default:
+		http.Error(rw, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+	}
+}
+
 func (ep *Entrypoint) reloadHandler(rw http.ResponseWriter, r *http.Request) {
 	success := ep.TriggerReload()
 	if success {
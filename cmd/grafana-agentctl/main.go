@@ -4,8 +4,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -72,6 +75,7 @@ func main() {
 		cloudConfigCmd(),
 		templateDryRunCmd(),
 		testLogs(),
+		testScrapeCmd(),
 	)
 
 	_ = cmd.Execute()
@@ -560,6 +564,65 @@ func testLogs() *cobra.Command {
 	return cmd
 }
 
+func testScrapeCmd() *cobra.Command {
+	var agentAddr string
+
+	cmd := &cobra.Command{
+		Use:   "test-scrape [component] [target]",
+		Short: "Perform a one-off scrape of a target from a Flow prometheus.scrape component",
+		Long: `test-scrape asks a running Grafana Agent Flow instance to scrape a single
+target of the given prometheus.scrape component and prints the series it
+returns, both as exposed by the target and after the target labels have been
+applied. No samples are sent to the components the scrape component forwards
+to.
+
+The target may be given as the scrape URL, the value of the instance label, or
+the discovered __address__ of the target.`,
+		Args: cobra.ExactArgs(2),
+
+		Run: func(_ *cobra.Command, args []string) {
+			componentID, target := args[0], args[1]
+
+			u := fmt.Sprintf("%s/api/v0/component/%s/scrape?target=%s",
+				strings.TrimSuffix(agentAddr, "/"), url.PathEscape(componentID), url.QueryEscape(target))
+
+			resp, err := http.Get(u)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to request test scrape: %s\n", err)
+				os.Exit(1)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				msg, _ := io.ReadAll(resp.Body)
+				fmt.Fprintf(os.Stderr, "test scrape failed (%s): %s\n", resp.Status, strings.TrimSpace(string(msg)))
+				os.Exit(1)
+			}
+
+			var res struct {
+				Target string `json:"target"`
+				Series []struct {
+					Before string `json:"before"`
+					After  string `json:"after"`
+					Value  string `json:"value"`
+				} `json:"series"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to decode response: %s\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("# Scraped %d series from %s\n", len(res.Series), res.Target)
+			for _, s := range res.Series {
+				fmt.Printf("%s %s\n  => %s\n", s.Before, s.Value, s.After)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentAddr, "addr", "a", "http://localhost:12345", "address of the agent to connect to")
+	return cmd
+}
+
 func must(err error) {
 	if err != nil {
 		panic(err)
@@ -0,0 +1,140 @@
+package flowmode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/rfratto/ckit"
+	"github.com/rfratto/ckit/peer"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+)
+
+// clusterOptions holds the --cluster.* flags which configure clustering of
+// agents through gossip.
+type clusterOptions struct {
+	enabled             bool
+	listenAddr          string
+	advertiseInterfaces []string
+	joinAddresses       []string
+	gossip              cluster.GossipConfig
+}
+
+func newClusterOptions() clusterOptions {
+	return clusterOptions{
+		listenAddr:          "0.0.0.0:12346",
+		advertiseInterfaces: cluster.DefaultGossipConfig.AdvertiseInterfaces,
+		gossip:              cluster.DefaultGossipConfig,
+	}
+}
+
+func (o *clusterOptions) registerFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.enabled, "cluster.enabled", o.enabled, "Join a cluster of agents which gossip with each other.")
+	fs.StringVar(&o.listenAddr, "cluster.listen-addr", o.listenAddr, "Address to listen for gossip traffic from peers on.")
+	fs.StringVar(&o.gossip.NodeName, "cluster.node-name", o.gossip.NodeName, "Name of the node within the cluster. Defaults to the hostname.")
+	fs.StringVar(&o.gossip.AdvertiseAddr, "cluster.advertise-address", o.gossip.AdvertiseAddr, "Address to advertise to peers. Inferred from --cluster.advertise-interfaces when unset.")
+	fs.StringSliceVar(&o.advertiseInterfaces, "cluster.advertise-interfaces", o.advertiseInterfaces, "Comma-separated list of interfaces to infer the advertise address from, in order of preference.")
+	fs.StringSliceVar(&o.joinAddresses, "cluster.join-addresses", o.joinAddresses, "Comma-separated list of addresses of peers to join. Mutually exclusive with --cluster.discover-peers.")
+	fs.StringVar(&o.gossip.DiscoverPeers, "cluster.discover-peers", o.gossip.DiscoverPeers, "go-discover query to find peers to join with, such as \"provider=dnssrv name=_gossip._tcp.agent.default.svc.cluster.local\". Mutually exclusive with --cluster.join-addresses.")
+
+	fs.StringVar(&o.gossip.TLS.CertFile, "cluster.tls-cert-file", o.gossip.TLS.CertFile, "Certificate to present to peers. Enables TLS for gossip traffic when set.")
+	fs.StringVar(&o.gossip.TLS.KeyFile, "cluster.tls-key-file", o.gossip.TLS.KeyFile, "Key of --cluster.tls-cert-file.")
+	fs.StringVar(&o.gossip.TLS.CAFile, "cluster.tls-ca-file", o.gossip.TLS.CAFile, "CA to verify the certificates of peers against. Uses the system roots when unset.")
+	fs.BoolVar(&o.gossip.TLS.RequireClientCert, "cluster.tls-require-client-cert", o.gossip.TLS.RequireClientCert, "Require peers to present a certificate signed by --cluster.tls-ca-file.")
+	fs.StringVar(&o.gossip.TLS.ServerName, "cluster.tls-server-name", o.gossip.TLS.ServerName, "Name to verify the certificates of peers against instead of their addresses.")
+	fs.BoolVar(&o.gossip.TLS.InsecureSkipVerify, "cluster.tls-insecure-skip-verify", o.gossip.TLS.InsecureSkipVerify, "Don't verify the certificates of peers.")
+}
+
+// gossipConfig validates o and returns the GossipConfig to create the node
+// with. Addresses without a port use the port of --cluster.listen-addr.
+func (o *clusterOptions) gossipConfig() (*cluster.GossipConfig, error) {
+	_, portStr, err := net.SplitHostPort(o.listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --cluster.listen-addr: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --cluster.listen-addr port %q", portStr)
+	}
+
+	cfg := o.gossip
+	cfg.AdvertiseInterfaces = append([]string(nil), o.advertiseInterfaces...)
+	cfg.JoinPeers = append([]string(nil), o.joinAddresses...)
+	if err := cfg.ApplyDefaults(port); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// runCluster starts a gossip node for o, joins its peers, and keeps it
+// running until ctx is canceled. started is closed once the node has joined
+// the cluster, or runCluster returns without closing it if the node failed to
+// start.
+func runCluster(ctx context.Context, l log.Logger, o clusterOptions, started chan<- struct{}) error {
+	cfg, err := o.gossipConfig()
+	if err != nil {
+		return err
+	}
+
+	var srvOpts []grpc.ServerOption
+	if cfg.TLS.Enabled() {
+		srvOpts = append(srvOpts, cfg.TLS.ServerOption())
+	}
+	srv := grpc.NewServer(srvOpts...)
+
+	l = log.With(l, "component", "cluster")
+	node, err := cluster.NewGossipNode(l, srv, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create cluster node: %w", err)
+	}
+	node.Observe(ckit.FuncObserver(func(peers []peer.Peer) (reregister bool) {
+		level.Info(l).Log("msg", "cluster peers changed", "peers", len(peers))
+		return true
+	}))
+
+	lis, err := net.Listen("tcp", o.listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", o.listenAddr, err)
+	}
+	go func() {
+		level.Info(l).Log("msg", "now listening for gossip traffic", "addr", o.listenAddr, "tls", cfg.TLS.Enabled())
+		if err := srv.Serve(lis); err != nil {
+			level.Info(l).Log("msg", "gossip server closed", "err", err)
+		}
+	}()
+	defer srv.GracefulStop()
+
+	if err := node.Start(); err != nil {
+		return fmt.Errorf("failed to join cluster: %w", err)
+	}
+	defer func() {
+		if err := node.Stop(); err != nil {
+			level.Error(l).Log("msg", "failed to leave cluster", "err", err)
+		}
+	}()
+
+	changeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	err = node.ChangeState(changeCtx, peer.StateParticipant)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to become a cluster participant: %w", err)
+	}
+	level.Info(l).Log("msg", "joined cluster", "node", cfg.NodeName, "advertise_addr", cfg.AdvertiseAddr, "peers", len(cfg.JoinPeers))
+	close(started)
+
+	<-ctx.Done()
+
+	// Give peers a chance to learn that this node is leaving.
+	leaveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := node.ChangeState(leaveCtx, peer.StateTerminating); err != nil {
+		level.Warn(l).Log("msg", "failed to announce leaving the cluster", "err", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package flowmode
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterOptions_GossipConfig(t *testing.T) {
+	o := newClusterOptions()
+	o.listenAddr = "0.0.0.0:7946"
+	o.gossip.NodeName = "node-a"
+	o.gossip.AdvertiseAddr = "10.0.0.1"
+	o.joinAddresses = []string{"10.0.0.2", "10.0.0.3:8000"}
+
+	cfg, err := o.gossipConfig()
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:7946", cfg.AdvertiseAddr)
+	require.Equal(t, []string{"10.0.0.2:7946", "10.0.0.3:8000"}, []string(cfg.JoinPeers))
+
+	// Defaults must not modify the flags.
+	require.Equal(t, []string{"10.0.0.2", "10.0.0.3:8000"}, o.joinAddresses)
+
+	// Peers can be discovered instead of listed.
+	o.gossip.DiscoverPeers = "provider=dnssrv"
+	_, err = o.gossipConfig()
+	require.EqualError(t, err, "at most one of join peers and discover peers may be set")
+
+	o.joinAddresses = nil
+	_, err = o.gossipConfig()
+	require.EqualError(t, err, "discovering peers: discover-dnssrv: name must be set")
+
+	o.gossip.DiscoverPeers = ""
+	o.gossip.TLS.KeyFile = "key.pem"
+	_, err = o.gossipConfig()
+	require.EqualError(t, err, "invalid TLS settings: TLS certificate file must be set to enable TLS")
+}
+
+func TestRunCluster(t *testing.T) {
+	l := util.TestFlowLogger(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newNode := func(name string, join ...string) (addr string, started chan struct{}, done chan error) {
+		addr = freeAddr(t)
+
+		o := newClusterOptions()
+		o.enabled = true
+		o.listenAddr = addr
+		o.gossip.NodeName = name
+		o.gossip.AdvertiseAddr = addr
+		o.joinAddresses = join
+
+		started, done = make(chan struct{}), make(chan error, 1)
+		go func() { done <- runCluster(ctx, l, o, started) }()
+		return addr, started, done
+	}
+	waitStarted := func(started chan struct{}, done chan error) {
+		select {
+		case <-started:
+		case err := <-done:
+			require.FailNow(t, "node failed to start", err)
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "node didn't start")
+		}
+	}
+
+	addrA, startedA, doneA := newNode("a")
+	waitStarted(startedA, doneA)
+
+	// Joining fails if the peer can't be reached, so a started node has
+	// joined a.
+	_, startedB, doneB := newNode("b", addrA)
+	waitStarted(startedB, doneB)
+
+	cancel()
+	require.NoError(t, <-doneA)
+	require.NoError(t, <-doneB)
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	return lis.Addr().String()
+}
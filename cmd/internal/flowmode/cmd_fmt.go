@@ -27,7 +27,9 @@ River configuration file.
 
 If the file argument is not supplied or if the file argument is "-", then fmt will read from stdin.
 
-The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.`,
+The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.
+
+The -s flag can be used to sort attributes and blocks into a canonical order while preserving comments. Nested blocks keep their relative order, as the order of blocks such as rule or stage blocks is significant.`,
 		Args:         cobra.RangeArgs(0, 1),
 		SilenceUsage: true,
 		Aliases:      []string{"format"},
@@ -55,11 +57,13 @@ The -w flag can be used to write the formatted file back to disk. -w can not be
 	}
 
 	cmd.Flags().BoolVarP(&f.write, "write", "w", f.write, "write result to (source) file instead of stdout")
+	cmd.Flags().BoolVarP(&f.sort, "sort", "s", f.sort, "sort attributes and blocks into a canonical order")
 	return cmd
 }
 
 type flowFmt struct {
 	write bool
+	sort  bool
 }
 
 func (ff *flowFmt) Run(configFile string) error {
@@ -68,7 +72,7 @@ func (ff *flowFmt) Run(configFile string) error {
 		if ff.write {
 			return fmt.Errorf("cannot use -w with standard input")
 		}
-		return format("<stdin>", nil, os.Stdin, false)
+		return format("<stdin>", nil, os.Stdin, false, ff.sort)
 
 	default:
 		fi, err := os.Stat(configFile)
@@ -84,11 +88,11 @@ func (ff *flowFmt) Run(configFile string) error {
 			return err
 		}
 		defer f.Close()
-		return format(configFile, fi, f, ff.write)
+		return format(configFile, fi, f, ff.write, ff.sort)
 	}
 }
 
-func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
+func format(filename string, fi os.FileInfo, r io.Reader, write bool, sort bool) error {
 	bb, err := io.ReadAll(r)
 	if err != nil {
 		return err
@@ -99,6 +103,15 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
 		return err
 	}
 
+	if sort {
+		// Sorting rearranges the source text, so it must be parsed again to
+		// get accurate positions for printing.
+		bb = printer.Sort(f, bb)
+		if f, err = parser.ParseFile(filename, bb); err != nil {
+			return err
+		}
+	}
+
 	var buf bytes.Buffer
 	if err := printer.Fprint(&buf, f); err != nil {
 		return err
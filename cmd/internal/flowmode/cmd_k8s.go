@@ -0,0 +1,383 @@
+package flowmode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
+)
+
+func k8sCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "k8s",
+		Short: "Generate Kubernetes manifests for Grafana Agent Flow",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(k8sRenderCommand())
+	return cmd
+}
+
+func k8sRenderCommand() *cobra.Command {
+	r := &flowK8sRender{
+		topology:   topologyDaemonSet,
+		name:       "grafana-agent",
+		namespace:  "default",
+		image:      defaultImage(),
+		replicas:   1,
+		listenPort: 80,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "render [flags] file",
+		Short: "Render Kubernetes manifests which run a River file",
+		Long: `The render subcommand prints the recommended Kubernetes manifests to run
+Grafana Agent Flow with the specified River configuration file, for use where
+the Helm chart can't be used.
+
+The manifests include a ServiceAccount, a ClusterRole and ClusterRoleBinding, a
+ConfigMap holding the River file, and a DaemonSet or StatefulSet depending on
+the --topology flag.
+
+If the file argument is not supplied or if the file argument is "-", then
+render will read from stdin.`,
+		Args:         cobra.RangeArgs(0, 1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			file := "-"
+			if len(args) > 0 {
+				file = args[0]
+			}
+
+			err := r.Run(file, os.Stdout)
+
+			var diags diag.Diagnostics
+			if errors.As(err, &diags) {
+				for _, diag := range diags {
+					fmt.Fprintln(os.Stderr, diag)
+				}
+				return fmt.Errorf("encountered errors during parsing")
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&r.topology, "topology", r.topology, "Controller to run the agent with (daemonset, statefulset)")
+	cmd.Flags().StringVar(&r.name, "name", r.name, "Name of the generated resources")
+	cmd.Flags().StringVarP(&r.namespace, "namespace", "n", r.namespace, "Namespace of the generated resources")
+	cmd.Flags().StringVar(&r.image, "image", r.image, "Image of the agent container")
+	cmd.Flags().Int32Var(&r.replicas, "replicas", r.replicas, "Number of replicas of the StatefulSet topology")
+	cmd.Flags().Int32Var(&r.listenPort, "listen-port", r.listenPort, "Port the agent listens for HTTP traffic on")
+	cmd.Flags().BoolVar(&r.clustering, "clustering", r.clustering, "Add a headless Service which agents can discover their peers from")
+	cmd.Flags().StringSliceVar(&r.hostPaths, "host-path", r.hostPaths, "Directory of the node to mount read-only at the same path, such as /var/log. Can be repeated.")
+	return cmd
+}
+
+// Supported topologies of the render subcommand.
+const (
+	topologyDaemonSet   = "daemonset"
+	topologyStatefulSet = "statefulset"
+)
+
+// configKey is the key of the River file in the generated ConfigMap.
+const configKey = "config.river"
+
+type flowK8sRender struct {
+	topology   string
+	name       string
+	namespace  string
+	image      string
+	replicas   int32
+	listenPort int32
+	clustering bool
+	hostPaths  []string
+}
+
+func defaultImage() string {
+	if build.Version == "" {
+		return "grafana/agent:latest"
+	}
+	return "grafana/agent:" + build.Version
+}
+
+// Run writes the manifests for configFile to w as a multi-document YAML
+// stream.
+func (fr *flowK8sRender) Run(configFile string, w io.Writer) error {
+	if fr.topology != topologyDaemonSet && fr.topology != topologyStatefulSet {
+		return fmt.Errorf("unsupported topology %q", fr.topology)
+	}
+	if fr.replicas < 1 {
+		return fmt.Errorf("replicas must be at least 1")
+	}
+
+	var (
+		filename = configFile
+		bb       []byte
+		err      error
+	)
+	if configFile == "-" {
+		filename = "<stdin>"
+		bb, err = io.ReadAll(os.Stdin)
+	} else {
+		bb, err = os.ReadFile(configFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Catch syntax errors before they're deployed.
+	if _, err := parser.ParseFile(filename, bb); err != nil {
+		return err
+	}
+
+	objects, err := fr.objects(string(bb))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for i, obj := range objects {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		out, err := marshalManifest(obj)
+		if err != nil {
+			return err
+		}
+		buf.Write(out)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// objects returns the Kubernetes objects to run config with.
+func (fr *flowK8sRender) objects(config string) ([]runtime.Object, error) {
+	var (
+		labels       = map[string]string{"app.kubernetes.io/name": "grafana-agent", "app.kubernetes.io/instance": fr.name}
+		meta         = metav1.ObjectMeta{Name: fr.name, Namespace: fr.namespace, Labels: labels}
+		clusterMeta  = metav1.ObjectMeta{Name: fr.name, Labels: labels}
+		storagePath  = "/tmp/agent"
+		objects      []runtime.Object
+		volumes      = []corev1.Volume{{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: fr.name}}}}}
+		volumeMounts = []corev1.VolumeMount{{Name: "config", MountPath: "/etc/agent"}}
+	)
+
+	for _, p := range fr.hostPaths {
+		name, err := hostPathVolumeName(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range volumes {
+			if v.Name == name {
+				return nil, fmt.Errorf("host path %q is mounted more than once", p)
+			}
+		}
+		volumes = append(volumes, corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: p}}})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: name, MountPath: p, ReadOnly: true})
+	}
+
+	objects = append(objects,
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta,
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: clusterMeta,
+			Rules:      clusterRoleRules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: clusterMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: fr.name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: fr.name, Namespace: fr.namespace}},
+		},
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: meta,
+			Data:       map[string]string{configKey: config},
+		},
+	)
+
+	if fr.clustering {
+		// Peers must be able to find each other before they're ready, so the
+		// headless service publishes addresses which aren't ready yet.
+		objects = append(objects, &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: fr.name, Namespace: fr.namespace, Labels: labels},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:                corev1.ClusterIPNone,
+				PublishNotReadyAddresses: true,
+				Selector:                 labels,
+				Ports: []corev1.ServicePort{{
+					Name:       "http",
+					Port:       fr.listenPort,
+					TargetPort: intstr.FromInt(int(fr.listenPort)),
+					Protocol:   corev1.ProtocolTCP,
+				}},
+			},
+		})
+	}
+
+	pod := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: fr.name,
+			Containers: []corev1.Container{{
+				Name:            "grafana-agent",
+				Image:           fr.image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Args: []string{
+					"run",
+					"/etc/agent/" + configKey,
+					"--storage.path=" + storagePath,
+					fmt.Sprintf("--server.http.listen-addr=0.0.0.0:%d", fr.listenPort),
+				},
+				Env: []corev1.EnvVar{
+					{Name: "AGENT_MODE", Value: "flow"},
+					{Name: "HOSTNAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}},
+				},
+				Ports: []corev1.ContainerPort{{Name: "http-metrics", ContainerPort: fr.listenPort}},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{Path: "/-/ready", Port: intstr.FromInt(int(fr.listenPort))},
+					},
+					InitialDelaySeconds: 10,
+					TimeoutSeconds:      1,
+				},
+				VolumeMounts: volumeMounts,
+			}},
+			DNSPolicy: corev1.DNSClusterFirst,
+			Volumes:   volumes,
+		},
+	}
+	selector := &metav1.LabelSelector{MatchLabels: labels}
+
+	switch fr.topology {
+	case topologyDaemonSet:
+		objects = append(objects, &appsv1.DaemonSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+			ObjectMeta: meta,
+			Spec: appsv1.DaemonSetSpec{
+				MinReadySeconds: 10,
+				Selector:        selector,
+				Template:        pod,
+			},
+		})
+	case topologyStatefulSet:
+		replicas := fr.replicas
+		objects = append(objects, &appsv1.StatefulSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+			ObjectMeta: meta,
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:        &replicas,
+				MinReadySeconds: 10,
+				ServiceName:     fr.name,
+				Selector:        selector,
+				Template:        pod,
+			},
+		})
+	}
+	return objects, nil
+}
+
+// clusterRoleRules are the permissions needed by the components which talk
+// to the Kubernetes API. They match the rules of the Helm chart.
+var clusterRoleRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{"", "discovery.k8s.io", "networking.k8s.io"},
+		Resources: []string{"endpoints", "endpointslices", "ingresses", "nodes", "nodes/proxy", "pods", "services"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods", "pods/log", "namespaces"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{"monitoring.grafana.com"},
+		Resources: []string{"podlogs"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{"monitoring.coreos.com"},
+		Resources: []string{"prometheusrules", "podmonitors", "servicemonitors", "probes"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		NonResourceURLs: []string{"/metrics"},
+		Verbs:           []string{"get"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+}
+
+var invalidVolumeChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// hostPathVolumeName returns the name of the volume for the host path p, such
+// as "hostpath-var-log" for /var/log.
+func hostPathVolumeName(p string) (string, error) {
+	if !path.IsAbs(p) {
+		return "", fmt.Errorf("host path %q must be absolute", p)
+	}
+	name := strings.Trim(invalidVolumeChars.ReplaceAllString(strings.ToLower(path.Clean(p)), "-"), "-")
+	if name == "" {
+		return "", fmt.Errorf("mounting the root of the node is not supported")
+	}
+	name = "hostpath-" + name
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.TrimRight(name, "-"), nil
+}
+
+// marshalManifest marshals obj to YAML, omitting its status and the creation
+// timestamps which typed objects always marshal.
+func marshalManifest(obj runtime.Object) ([]byte, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	delete(u, "status")
+	pruneCreationTimestamps(u)
+	return yaml.Marshal(u)
+}
+
+func pruneCreationTimestamps(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ts, ok := v["creationTimestamp"]; ok && ts == nil {
+			delete(v, "creationTimestamp")
+		}
+		for _, child := range v {
+			pruneCreationTimestamps(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			pruneCreationTimestamps(child)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package flowmode
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestK8sRender(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.river")
+	require.NoError(t, os.WriteFile(config, []byte(`logging { level = "debug" }`), 0644))
+
+	render := func(fr flowK8sRender) []string {
+		var buf bytes.Buffer
+		require.NoError(t, fr.Run(config, &buf))
+		return strings.Split(buf.String(), "---\n")
+	}
+	kinds := func(docs []string) []string {
+		var res []string
+		for _, doc := range docs {
+			var obj struct{ Kind string }
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &obj))
+			res = append(res, obj.Kind)
+		}
+		return res
+	}
+
+	t.Run("daemonset", func(t *testing.T) {
+		docs := render(flowK8sRender{
+			topology:   topologyDaemonSet,
+			name:       "agent",
+			namespace:  "monitoring",
+			image:      "grafana/agent:test",
+			replicas:   1,
+			listenPort: 80,
+			hostPaths:  []string{"/var/log"},
+		})
+		require.Equal(t, []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "ConfigMap", "DaemonSet"}, kinds(docs))
+		require.NotContains(t, strings.Join(docs, ""), "creationTimestamp")
+
+		var cm corev1.ConfigMap
+		require.NoError(t, yaml.Unmarshal([]byte(docs[3]), &cm))
+		require.Equal(t, `logging { level = "debug" }`, cm.Data[configKey])
+
+		var ds appsv1.DaemonSet
+		require.NoError(t, yaml.Unmarshal([]byte(docs[4]), &ds))
+		require.Equal(t, "monitoring", ds.Namespace)
+		pod := ds.Spec.Template.Spec
+		require.Equal(t, "agent", pod.ServiceAccountName)
+		require.Equal(t, "grafana/agent:test", pod.Containers[0].Image)
+		require.Equal(t, []string{"run", "/etc/agent/config.river"}, pod.Containers[0].Args[:2])
+		require.Equal(t, corev1.Volume{
+			Name:         "hostpath-var-log",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}},
+		}, pod.Volumes[1])
+		require.Equal(t, corev1.VolumeMount{Name: "hostpath-var-log", MountPath: "/var/log", ReadOnly: true}, pod.Containers[0].VolumeMounts[1])
+	})
+
+	t.Run("statefulset with clustering", func(t *testing.T) {
+		docs := render(flowK8sRender{
+			topology:   topologyStatefulSet,
+			name:       "agent",
+			namespace:  "monitoring",
+			image:      "grafana/agent:test",
+			replicas:   3,
+			listenPort: 12345,
+			clustering: true,
+		})
+		require.Equal(t, []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "ConfigMap", "Service", "StatefulSet"}, kinds(docs))
+
+		var svc corev1.Service
+		require.NoError(t, yaml.Unmarshal([]byte(docs[4]), &svc))
+		require.Equal(t, corev1.ClusterIPNone, svc.Spec.ClusterIP)
+		require.True(t, svc.Spec.PublishNotReadyAddresses)
+		require.Equal(t, int32(12345), svc.Spec.Ports[0].Port)
+
+		var ss appsv1.StatefulSet
+		require.NoError(t, yaml.Unmarshal([]byte(docs[5]), &ss))
+		require.Equal(t, int32(3), *ss.Spec.Replicas)
+		require.Equal(t, svc.Name, ss.Spec.ServiceName)
+		require.Equal(t, svc.Spec.Selector, ss.Spec.Template.Labels)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fr := flowK8sRender{topology: "deployment", replicas: 1}
+		require.ErrorContains(t, fr.Run(config, &bytes.Buffer{}), `unsupported topology "deployment"`)
+
+		fr = flowK8sRender{topology: topologyDaemonSet, replicas: 1, hostPaths: []string{"var/log"}}
+		require.ErrorContains(t, fr.Run(config, &bytes.Buffer{}), "must be absolute")
+
+		fr = flowK8sRender{topology: topologyDaemonSet, replicas: 1, hostPaths: []string{"/var/log", "/var/log/"}}
+		require.ErrorContains(t, fr.Run(config, &bytes.Buffer{}), "mounted more than once")
+
+		bad := filepath.Join(t.TempDir(), "bad.river")
+		require.NoError(t, os.WriteFile(bad, []byte(`logging {`), 0644))
+		fr = flowK8sRender{topology: topologyDaemonSet, replicas: 1}
+		require.Error(t, fr.Run(bad, &bytes.Buffer{}))
+	})
+}
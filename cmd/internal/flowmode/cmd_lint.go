@@ -0,0 +1,112 @@
+package flowmode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/flow/lint"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
+)
+
+func lintCommand() *cobra.Command {
+	l := &flowLint{
+		format: "text",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lint [flags] file",
+		Short: "Check a River file for common mistakes",
+		Long: `The lint subcommand performs semantic checks against the specified River
+configuration file, such as finding components whose exports are never used,
+components which forward data nowhere, and suspicious relabeling regexes.
+
+If the file argument is not supplied or if the file argument is "-", then lint will read from stdin.
+
+The --format flag can be used to change the output format to json or sarif for use in CI pipelines.
+
+lint exits with a non-zero exit code if any errors or warnings were found.`,
+		Args:         cobra.RangeArgs(0, 1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			file := "-"
+			if len(args) > 0 {
+				file = args[0]
+			}
+
+			err := l.Run(file)
+
+			var diags diag.Diagnostics
+			if errors.As(err, &diags) {
+				for _, diag := range diags {
+					fmt.Fprintln(os.Stderr, diag)
+				}
+				return fmt.Errorf("encountered errors during parsing")
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&l.format, "format", "f", l.format, "output format (text, json, sarif)")
+	return cmd
+}
+
+type flowLint struct {
+	format string
+}
+
+func (fl *flowLint) Run(configFile string) error {
+	var write func(io.Writer, []lint.Finding) error
+	switch fl.format {
+	case "text":
+		write = lint.WriteText
+	case "json":
+		write = lint.WriteJSON
+	case "sarif":
+		write = lint.WriteSARIF
+	default:
+		return fmt.Errorf("unsupported output format %q", fl.format)
+	}
+
+	var (
+		filename = configFile
+		bb       []byte
+		err      error
+	)
+	if configFile == "-" {
+		filename = "<stdin>"
+		bb, err = io.ReadAll(os.Stdin)
+	} else {
+		bb, err = os.ReadFile(configFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := parser.ParseFile(filename, bb)
+	if err != nil {
+		return err
+	}
+
+	findings := lint.Lint(f)
+	if err := write(os.Stdout, findings); err != nil {
+		return err
+	}
+
+	var problems int
+	for _, finding := range findings {
+		if finding.Severity != lint.SeverityInfo {
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("found %d problems", problems)
+	}
+	return nil
+}
@@ -1,31 +1,40 @@
 package flowmode
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path"
+	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/grafana/agent/web/api"
 	"github.com/grafana/agent/web/ui"
 	"go.opentelemetry.io/otel"
 	"golang.org/x/exp/maps"
 
+	"github.com/alecthomas/units"
 	"github.com/fatih/color"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/pkg/config/instrumentation"
 	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/grafana/agent/pkg/usagestats"
+	"github.com/grafana/agent/pkg/util/memwatch"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
@@ -39,18 +48,26 @@ func runCommand() *cobra.Command {
 	r := &flowRun{
 		httpListenAddr:   "127.0.0.1:12345",
 		storagePath:      "data-agent/",
+		dataRetention:    0,
+		profiles:         profilesFromEnv(),
+		memoryThreshold:  memwatch.DefaultThreshold,
 		uiPrefix:         "/",
 		disableReporting: false,
+		cluster:          newClusterOptions(),
 	}
 
 	cmd := &cobra.Command{
-		Use:   "run [flags] file",
+		Use:   "run [flags] path",
 		Short: "Run Grafana Agent Flow",
 		Long: `The run subcommand runs Grafana Agent Flow in the foreground until an interrupt
 is received.
 
 run must be provided an argument pointing at the River file to use. If the
-River file wasn't specified, can't be loaded, or contains errors, run will exit
+argument is a directory, every .river file in the directory is loaded and
+merged, in order of file name, into a single configuration. Blocks inside
+profile blocks are only loaded when their profile is enabled with --profiles
+or the AGENT_PROFILES environment variable. If the River file
+wasn't specified, can't be loaded, or contains errors, run will exit
 immediately.
 
 run starts an HTTP server which can be used to debug Grafana Agent Flow or
@@ -64,6 +81,12 @@ debugging UI can be changed by providing a different value to
 Additionally, the HTTP server exposes the following debug endpoints:
 
   /debug/pprof   Go performance profiling tools
+  /-/support     Support bundle with the redacted config, component health,
+                 recent logs, metrics, and profiles
+
+When --cluster.enabled is set, run also listens for gossip traffic on
+--cluster.listen-addr and joins the peers given by --cluster.join-addresses.
+Gossip traffic uses TLS when --cluster.tls-cert-file is set.
 
 If reloading the config file fails, Grafana Agent Flow will continue running in
 its last valid state. Components which failed may be be listed as unhealthy,
@@ -80,17 +103,57 @@ depending on the nature of the reload error.
 	cmd.Flags().
 		StringVar(&r.httpListenAddr, "server.http.listen-addr", r.httpListenAddr, "address to listen for HTTP traffic on")
 	cmd.Flags().StringVar(&r.storagePath, "storage.path", r.storagePath, "Base directory where components can store data")
+	cmd.Flags().
+		StringVar(&r.auditLogPath, "audit-log.path", r.auditLogPath, "File to append an audit log of config changes to. Config changes are only kept in memory when unset.")
+	cmd.Flags().
+		DurationVar(&r.dataRetention, "storage.component-data-retention", r.dataRetention, "How long to keep the data of components removed from the config. 0 disables deleting it.")
+	cmd.Flags().
+		StringSliceVar(&r.profiles, "profiles", r.profiles, "Comma-separated list of config profiles to enable. Defaults to the value of the AGENT_PROFILES environment variable.")
+	cmd.Flags().
+		StringVar(&r.memoryCeiling, "memory.ceiling", r.memoryCeiling, "Memory usage, such as 2GiB, which components apply backpressure to stay below. Disabled when unset.")
+	cmd.Flags().
+		Float64Var(&r.memoryThreshold, "memory.backpressure-threshold", r.memoryThreshold, "Fraction of --memory.ceiling at which components start applying backpressure.")
 	cmd.Flags().StringVar(&r.uiPrefix, "server.http.ui-path-prefix", r.uiPrefix, "Prefix to serve the HTTP UI at")
 	cmd.Flags().
 		BoolVar(&r.disableReporting, "disable-reporting", r.disableReporting, "Disable reporting of enabled components to Grafana.")
+	cmd.Flags().
+		BoolVar(&r.detailedReporting, "enable-detailed-reporting", r.detailedReporting, "Include the number of each enabled component in usage reports.")
+	r.cluster.registerFlags(cmd.Flags())
 	return cmd
 }
 
+// configureMemoryWatchdog sets the ceiling of the memory watchdog which
+// components use to apply backpressure.
+func (fr *flowRun) configureMemoryWatchdog() error {
+	if fr.memoryThreshold <= 0 || fr.memoryThreshold > 1 {
+		return fmt.Errorf("--memory.backpressure-threshold must be greater than 0 and at most 1")
+	}
+	if fr.memoryCeiling == "" {
+		return nil
+	}
+
+	ceiling, err := units.ParseBase2Bytes(fr.memoryCeiling)
+	if err != nil {
+		return fmt.Errorf("invalid --memory.ceiling: %w", err)
+	} else if ceiling < 0 {
+		return fmt.Errorf("--memory.ceiling must not be negative")
+	}
+	memwatch.Default().SetCeiling(uint64(ceiling), fr.memoryThreshold)
+	return nil
+}
+
 type flowRun struct {
-	httpListenAddr   string
-	storagePath      string
-	uiPrefix         string
-	disableReporting bool
+	httpListenAddr    string
+	storagePath       string
+	auditLogPath      string
+	dataRetention     time.Duration
+	profiles          []string
+	memoryCeiling     string
+	memoryThreshold   float64
+	uiPrefix          string
+	disableReporting  bool
+	detailedReporting bool
+	cluster           clusterOptions
 }
 
 func (fr *flowRun) Run(configFile string) error {
@@ -103,12 +166,21 @@ func (fr *flowRun) Run(configFile string) error {
 	if configFile == "" {
 		return fmt.Errorf("file argument not provided")
 	}
+	if err := fr.configureMemoryWatchdog(); err != nil {
+		return err
+	}
 
-	logSink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	// Retain recent log lines so they can be included in support bundles.
+	recentLogs := newLogRing(1000)
+
+	logSink, err := logging.WriterSink(io.MultiWriter(os.Stderr, recentLogs), logging.DefaultSinkOptions)
 	if err != nil {
 		return fmt.Errorf("building logger: %w", err)
 	}
 	l := logging.New(logSink)
+	if len(fr.profiles) > 0 {
+		level.Info(l).Log("msg", "enabling config profiles", "profiles", strings.Join(fr.profiles, ","))
+	}
 
 	t, err := tracing.New(tracing.DefaultOptions)
 	if err != nil {
@@ -137,30 +209,51 @@ func (fr *flowRun) Run(configFile string) error {
 	// metrics are still exposed.
 	reg := prometheus.DefaultRegisterer
 	reg.MustRegister(newResourcesCollector(l))
+	reg.MustRegister(memwatch.Default())
+
+	auditLog, err := auditlog.New(fr.auditLogPath, auditlog.DefaultCapacity)
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
 
 	f := flow.New(flow.Options{
 		LogSink:        logSink,
 		Tracer:         t,
 		DataPath:       fr.storagePath,
+		DataRetention:  fr.dataRetention,
 		Reg:            reg,
 		HTTPPathPrefix: "/api/v0/component/",
 		HTTPListenAddr: fr.httpListenAddr,
+		AuditLog:       auditLog,
 	})
 
-	reload := func() error {
-		flowCfg, err := loadFlowFile(configFile)
+	reload := func(trigger auditlog.Trigger) error {
+		flowCfg, err := loadFlowFile(configFile, fr.profiles)
 		defer instrumentation.InstrumentLoad(err == nil)
 
 		if err != nil {
 			return fmt.Errorf("reading config file %q: %w", configFile, err)
 		}
-		if err := f.LoadFile(flowCfg, nil); err != nil {
+		if err := f.LoadFileWithTrigger(flowCfg, nil, trigger); err != nil {
 			return fmt.Errorf("error during the initial gragent load: %w", err)
 		}
 
 		return nil
 	}
 
+	// Cluster
+	if fr.cluster.enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runCluster(ctx, l, fr.cluster, make(chan struct{})); err != nil {
+				level.Error(l).Log("msg", "clustering failed, shutting down", "err", err)
+				cancel()
+			}
+		}()
+	}
+
 	// Flow controller
 	{
 		wg.Add(1)
@@ -170,6 +263,34 @@ func (fr *flowRun) Run(configFile string) error {
 		}()
 	}
 
+	var reporter *usagestats.Reporter
+	if !fr.disableReporting {
+		reporter, err = usagestats.NewReporter(l)
+		if err != nil {
+			return fmt.Errorf("failed to create reporter: %w", err)
+		}
+	}
+	usageMetrics := getEnabledComponentsFunc(f, fr.detailedReporting)
+
+	bundler := &supportBundler{
+		flow:       f,
+		configFile: configFile,
+		gatherer:   prometheus.DefaultGatherer,
+		logs:       recentLogs,
+		startTime:  time.Now(),
+	}
+
+	// Sample the metrics of write components so that their recent history
+	// can be shown in the UI.
+	sampler := api.NewMetricsSampler(prometheus.DefaultGatherer, 15*time.Second, 60)
+	{
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sampler.Run(ctx)
+		}()
+	}
+
 	// HTTP server
 	{
 		lis, err := net.Listen("tcp", fr.httpListenAddr)
@@ -197,11 +318,11 @@ func (fr *flowRun) Run(configFile string) error {
 			}
 		})
 
-		r.HandleFunc("/-/reload", func(w http.ResponseWriter, _ *http.Request) {
+		r.HandleFunc("/-/reload", func(w http.ResponseWriter, req *http.Request) {
 			level.Info(l).Log("msg", "reload requested via /-/reload endpoint")
 			defer level.Info(l).Log("msg", "config reloaded")
 
-			err := reload()
+			err := reload(auditlog.Trigger{Kind: auditlog.TriggerAPI, Caller: req.RemoteAddr})
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -209,8 +330,29 @@ func (fr *flowRun) Run(configFile string) error {
 			fmt.Fprintln(w, "config reloaded")
 		}).Methods(http.MethodGet, http.MethodPost)
 
+		r.Handle("/-/support", bundler).Methods(http.MethodGet)
+		r.Handle("/-/audit-log", auditLog).Methods(http.MethodGet)
+
+		r.HandleFunc("/-/usage-stats", func(w http.ResponseWriter, _ *http.Request) {
+			if reporter == nil {
+				http.Error(w, "usage reporting is disabled", http.StatusNotFound)
+				return
+			}
+
+			report, err := reporter.Preview(usageMetrics())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(report)
+		}).Methods(http.MethodGet)
+
 		// Register Routes must be the last
 		fa := api.NewFlowAPI(f, r)
+		fa.SetMetricsSampler(sampler)
 		fa.RegisterRoutes(path.Join(fr.uiPrefix, "/api/v0/web"), r)
 
 		// NOTE(rfratto): keep this at the bottom of all other routes, otherwise it
@@ -234,13 +376,9 @@ func (fr *flowRun) Run(configFile string) error {
 	}
 
 	// Report usage of enabled components
-	if !fr.disableReporting {
-		reporter, err := usagestats.NewReporter(l)
-		if err != nil {
-			return fmt.Errorf("failed to create reporter: %w", err)
-		}
+	if reporter != nil {
 		go func() {
-			err := reporter.Start(ctx, getEnabledComponentsFunc(f))
+			err := reporter.Start(ctx, usageMetrics)
 			if err != nil {
 				level.Error(l).Log("msg", "failed to start reporter", "err", err)
 			}
@@ -250,17 +388,17 @@ func (fr *flowRun) Run(configFile string) error {
 	// Perform the initial reload. This is done after starting the HTTP server so
 	// that /metric and pprof endpoints are available while the Flow controller
 	// is loading.
-	if err := reload(); err != nil {
+	if err := reload(auditlog.Trigger{Kind: auditlog.TriggerStartup}); err != nil {
 		var diags diag.Diagnostics
 		if errors.As(err, &diags) {
-			bb, _ := os.ReadFile(configFile)
+			_, sources, _ := readFlowSources(configFile)
 
 			p := diag.NewPrinter(diag.PrinterConfig{
 				Color:              !color.NoColor,
 				ContextLinesBefore: 1,
 				ContextLinesAfter:  1,
 			})
-			_ = p.Fprint(os.Stderr, map[string][]byte{configFile: bb}, diags)
+			_ = p.Fprint(os.Stderr, sources, diags)
 
 			// Print newline after the diagnostics.
 			fmt.Println()
@@ -281,7 +419,7 @@ func (fr *flowRun) Run(configFile string) error {
 		case <-ctx.Done():
 			return nil
 		case <-reloadSignal:
-			if err := reload(); err != nil {
+			if err := reload(auditlog.Trigger{Kind: auditlog.TriggerSignal}); err != nil {
 				level.Error(l).Log("msg", "failed to reload config", "err", err)
 			} else {
 				level.Info(l).Log("msg", "config reloaded")
@@ -290,27 +428,107 @@ func (fr *flowRun) Run(configFile string) error {
 	}
 }
 
-// getEnabledComponentsFunc returns a function that gets the current enabled components
-func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
+// getEnabledComponentsFunc returns a function which reports the names of the
+// enabled components. When detailed is true, the number of instances of each
+// component is also reported.
+func getEnabledComponentsFunc(f *flow.Flow, detailed bool) func() map[string]interface{} {
 	return func() map[string]interface{} {
 		infos := f.ComponentInfos()
-		components := map[string]struct{}{}
+		components := map[string]int{}
 		for _, info := range infos {
-			components[info.Name] = struct{}{}
+			components[info.Name]++
+		}
+
+		metrics := map[string]interface{}{"enabled-components": maps.Keys(components)}
+		if detailed {
+			metrics["component-counts"] = components
+		}
+		return metrics
+	}
+}
+
+// profilesFromEnv returns the profiles listed in the AGENT_PROFILES
+// environment variable.
+func profilesFromEnv() []string {
+	var profiles []string
+	for _, p := range strings.Split(os.Getenv("AGENT_PROFILES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			profiles = append(profiles, p)
 		}
-		return map[string]interface{}{"enabled-components": maps.Keys(components)}
 	}
+	return profiles
 }
 
-func loadFlowFile(filename string) (*flow.File, error) {
-	bb, err := os.ReadFile(filename)
+// loadFlowFile loads the River file at path with the given profiles enabled.
+// If path is a directory, every River file in the directory is loaded and
+// merged into a single file.
+func loadFlowFile(path string, profiles []string) (*flow.File, error) {
+	names, sources, err := readFlowSources(path)
 	if err != nil {
 		return nil, err
 	}
 
-	instrumentation.InstrumentConfig(bb)
+	var all bytes.Buffer
+	for _, name := range names {
+		all.Write(sources[name])
+	}
+	instrumentation.InstrumentConfig(all.Bytes())
+
+	files := make([]*flow.File, 0, len(names))
+	for _, name := range names {
+		f, err := flow.ReadFileWithProfiles(name, sources[name], profiles)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) == 1 && names[0] == path {
+		return files[0], nil
+	}
+	return flow.MergeFiles(path, files)
+}
 
-	return flow.ReadFile(filename, bb)
+// readFlowSources reads the River file at path. If path is a directory, every
+// file in the directory with a .river extension is read; subdirectories are
+// ignored. The names of the files read are returned sorted by file name, so
+// files are always merged in the same order.
+func readFlowSources(path string) (names []string, sources map[string][]byte, err error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !fi.IsDir() {
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{path}, map[string][]byte{path: bb}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources = make(map[string][]byte)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".river" {
+			continue
+		}
+
+		name := filepath.Join(path, e.Name())
+		bb, err := os.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		sources[name] = bb
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("no .river files found in directory %s", path)
+	}
+	return names, sources, nil
 }
 
 func interruptContext() (context.Context, context.CancelFunc) {
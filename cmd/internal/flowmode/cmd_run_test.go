@@ -0,0 +1,53 @@
+package flowmode
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFlowFile_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	writeFile("b.river", `prometheus.remote_write "default" {}`)
+	writeFile("a.river", `
+prometheus.scrape "default" {
+  targets    = []
+  forward_to = [prometheus.remote_write.default.receiver]
+}`)
+	writeFile("README.md", `not river`)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.river"), 0755))
+
+	f, err := loadFlowFile(dir, nil)
+	require.NoError(t, err)
+	require.Equal(t, dir, f.Name)
+
+	var ids []string
+	for _, c := range f.Components {
+		ids = append(ids, blockID(c))
+	}
+	require.Equal(t, []string{"prometheus.scrape.default", "prometheus.remote_write.default"}, ids)
+
+	// A single file is loaded as before.
+	f, err = loadFlowFile(filepath.Join(dir, "b.river"), nil)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "b.river"), f.Name)
+	require.Len(t, f.Components, 1)
+
+	_, err = loadFlowFile(t.TempDir(), nil)
+	require.ErrorContains(t, err, "no .river files found")
+}
+
+func blockID(b *ast.BlockStmt) string {
+	id := strings.Join(b.Name, ".")
+	if b.Label != "" {
+		id += "." + b.Label
+	}
+	return id
+}
@@ -25,6 +25,8 @@ func Run() {
 
 	cmd.AddCommand(
 		fmtCommand(),
+		k8sCommand(),
+		lintCommand(),
 		runCommand(),
 	)
 
@@ -0,0 +1,456 @@
+package flowmode
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultBundleDuration is how long CPU, block, and mutex profiles are
+// collected for when a support bundle is requested without a duration.
+const defaultBundleDuration = 30 * time.Second
+
+// supportBundler gathers the state of a running Flow controller into a zip
+// archive for support escalations.
+type supportBundler struct {
+	flow       *flow.Flow
+	configFile string
+	gatherer   prometheus.Gatherer
+	logs       *logRing
+	startTime  time.Time
+
+	// mut enforces single-flight requests, as only one CPU profile can be
+	// collected at a time.
+	mut sync.Mutex
+}
+
+// bundleMetadata contains general runtime information about the running
+// agent.
+type bundleMetadata struct {
+	BuildVersion string  `yaml:"build_version"`
+	OS           string  `yaml:"os"`
+	Architecture string  `yaml:"architecture"`
+	Uptime       float64 `yaml:"uptime"`
+}
+
+// ServeHTTP serves a support bundle. The optional duration query parameter
+// sets how many seconds profiles are collected for.
+func (sb *supportBundler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	duration := defaultBundleDuration
+	if s := r.URL.Query().Get("duration"); s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil || d < 1 {
+			http.Error(w, "duration value (in seconds) should be a positive integer", http.StatusBadRequest)
+			return
+		}
+		duration = time.Duration(d) * time.Second
+	}
+
+	var buf bytes.Buffer
+	if err := sb.Write(r.Context(), &buf, duration); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"agent-support-bundle.zip\"")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// Write writes a support bundle to buf, collecting profiles for duration.
+func (sb *supportBundler) Write(ctx context.Context, buf *bytes.Buffer, duration time.Duration) error {
+	sb.mut.Lock()
+	defer sb.mut.Unlock()
+
+	files := make(map[string][]byte)
+
+	meta, err := yaml.Marshal(bundleMetadata{
+		BuildVersion: build.Version,
+		OS:           runtime.GOOS,
+		Architecture: runtime.GOARCH,
+		Uptime:       time.Since(sb.startTime).Seconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal support bundle metadata: %w", err)
+	}
+	files["agent-metadata.yaml"] = meta
+
+	if files["agent-config.river"], err = sb.redactedConfig(); err != nil {
+		// The config file may have been removed or become invalid since it was
+		// loaded; include the error rather than failing the whole bundle.
+		files["agent-config.river"] = []byte(fmt.Sprintf("// failed to read config file: %s\n", err))
+	}
+	if files["agent-components.json"], err = sb.components(); err != nil {
+		return fmt.Errorf("failed to collect components: %w", err)
+	}
+	if files["agent-metrics.txt"], err = sb.metrics(); err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	files["agent-logs.txt"] = sb.logs.Bytes()
+
+	profiles, err := collectProfiles(ctx, duration)
+	if err != nil {
+		return fmt.Errorf("failed to collect profiles: %w", err)
+	}
+	for name, bb := range profiles {
+		files["pprof/"+name+".pprof"] = bb
+	}
+
+	zw := zip.NewWriter(buf)
+	for name, bb := range files {
+		fw, err := zw.Create("agent-support-bundle/" + name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(bb); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to flush the zip writer: %w", err)
+	}
+	return nil
+}
+
+// components returns the JSON representation of every component, including
+// its health, evaluated arguments, exports, and debug info. Secrets in
+// arguments and exports are redacted.
+func (sb *supportBundler) components() ([]byte, error) {
+	infos := sb.flow.ComponentInfos()
+
+	out := make([]json.RawMessage, 0, len(infos))
+	for _, info := range infos {
+		var buf bytes.Buffer
+		if err := sb.flow.ComponentJSON(&buf, info); err != nil {
+			return nil, err
+		}
+		out = append(out, buf.Bytes())
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// metrics returns the current values of the agent's own metrics in the text
+// exposition format.
+func (sb *supportBundler) metrics() ([]byte, error) {
+	families, err := sb.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// redactedConfig returns the config file with the values of secret arguments
+// replaced. If the config is a directory, every file is redacted on its own and
+// the results are concatenated, each preceded by a comment with its name. A
+// file which fails to parse is replaced by a comment with the error so that
+// its content is never included unredacted.
+func (sb *supportBundler) redactedConfig() ([]byte, error) {
+	names, sources, err := readFlowSources(sb.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for i, name := range names {
+		if len(names) > 1 {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(&buf, "// File: %s\n", filepath.Base(name))
+		}
+
+		bb, err := redactFile(name, sources[name])
+		if err != nil {
+			fmt.Fprintf(&buf, "// failed to redact file: %s\n", err)
+			continue
+		}
+		buf.Write(bb)
+	}
+	return buf.Bytes(), nil
+}
+
+// redactFile returns the River file src with the string literals assigned to
+// arguments of type rivertypes.Secret replaced.
+func redactFile(name string, src []byte) ([]byte, error) {
+	f, err := parser.ParseFile(name, src)
+	if err != nil {
+		return nil, err
+	}
+
+	redactComponents(f.Body)
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// redactComponents redacts the component blocks in body, including those
+// declared in profile blocks. The arguments of a component are found from its
+// registered Arguments type.
+func redactComponents(body ast.Body) {
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+		if block.GetBlockName() == "profile" {
+			redactComponents(block.Body)
+			continue
+		}
+		if reg, ok := component.Get(block.GetBlockName()); ok {
+			redactBody(block.Body, reflect.TypeOf(reg.Args))
+		}
+	}
+}
+
+var secretType = reflect.TypeOf(rivertypes.Secret(""))
+
+// redactBody redacts the attributes and blocks of body, which is decoded
+// into the River struct type t.
+func redactBody(body ast.Body, t reflect.Type) {
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if field, ok := riverField(t, stmt.Name.Name, "attr"); ok {
+				redactValue(stmt.Value, field.Type)
+			}
+		case *ast.BlockStmt:
+			if field, ok := riverBlockField(t, stmt.GetBlockName()); ok {
+				redactBody(stmt.Body, field.Type)
+			}
+		}
+	}
+}
+
+// redactValue redacts the string literals of e which are decoded into a
+// rivertypes.Secret within the Go type t.
+func redactValue(e ast.Expr, t reflect.Type) {
+	t = indirect(t)
+	switch {
+	case t == secretType:
+		if lit, ok := e.(*ast.LiteralExpr); ok && lit.Kind == token.STRING {
+			lit.Value = strconv.Quote("(secret)")
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		if arr, ok := e.(*ast.ArrayExpr); ok {
+			for _, elem := range arr.Elements {
+				redactValue(elem, t.Elem())
+			}
+		}
+	case t.Kind() == reflect.Map:
+		if obj, ok := e.(*ast.ObjectExpr); ok {
+			for _, field := range obj.Fields {
+				redactValue(field.Value, t.Elem())
+			}
+		}
+	case t.Kind() == reflect.Struct:
+		if obj, ok := e.(*ast.ObjectExpr); ok {
+			for _, field := range obj.Fields {
+				if sf, ok := riverField(t, field.Name.Name, "attr"); ok {
+					redactValue(field.Value, sf.Type)
+				}
+			}
+		}
+	}
+}
+
+// riverField returns the field of the River struct type t named name with
+// the given kind of river tag, such as "attr" or "block". Fields of squashed
+// structs are included.
+func riverField(t reflect.Type, name, kind string) (reflect.StructField, bool) {
+	t = blockType(t)
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("river")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		for _, opt := range parts[1:] {
+			switch {
+			case opt == kind && parts[0] == name:
+				return field, true
+			case opt == "squash":
+				if f, ok := riverField(field.Type, name, kind); ok {
+					return f, true
+				}
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// riverBlockField returns the field of the River struct type t which decodes
+// the block named name, including blocks of enum fields.
+func riverBlockField(t reflect.Type, name string) (reflect.StructField, bool) {
+	if field, ok := riverField(t, name, "block"); ok {
+		return field, true
+	}
+
+	t = blockType(t)
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("river")
+		if !strings.Contains(tag, ",enum") {
+			continue
+		}
+		if f, ok := riverField(field.Type, name, "block"); ok {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// indirect returns the type pointed to by t, if t is a pointer.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// blockType returns the struct type decoding a single block of a field of
+// type t, which may be a pointer to or a slice of the struct type.
+func blockType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// blockProfileRate is the block profile rate last set through
+// setBlockProfileRate. The runtime doesn't expose the current rate, so it's
+// tracked here to be restored after collecting a support bundle.
+var blockProfileRate atomic.Int64
+
+// setBlockProfileRate sets the block profile rate, returning the previous
+// rate.
+func setBlockProfileRate(rate int) int {
+	runtime.SetBlockProfileRate(rate)
+	return int(blockProfileRate.Swap(int64(rate)))
+}
+
+// collectProfiles collects CPU, block, and mutex profiles for duration,
+// followed by heap and goroutine profiles.
+func collectProfiles(ctx context.Context, duration time.Duration) (map[string][]byte, error) {
+	// Temporarily record all blocking events and mutex contentions, restoring
+	// the previous rates afterwards.
+	oldBlock := setBlockProfileRate(1)
+	oldMutex := runtime.SetMutexProfileFraction(1)
+	defer func() {
+		setBlockProfileRate(oldBlock)
+		runtime.SetMutexProfileFraction(oldMutex)
+	}()
+
+	var cpu bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		return nil, err
+	}
+	t := time.NewTimer(duration)
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	t.Stop()
+	pprof.StopCPUProfile()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	profiles := map[string][]byte{"cpu": cpu.Bytes()}
+	for _, name := range []string{"heap", "goroutine", "block", "mutex"} {
+		var buf bytes.Buffer
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+		profiles[name] = buf.Bytes()
+	}
+	return profiles, nil
+}
+
+// logRing is an io.Writer which retains the most recent log lines written to
+// it.
+type logRing struct {
+	mut   sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+// newLogRing returns a logRing which retains up to size lines.
+func newLogRing(size int) *logRing {
+	return &logRing{lines: make([][]byte, size)}
+}
+
+// Write implements io.Writer. Every call to Write is expected to hold a
+// single log line.
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.lines[r.next] = append(r.lines[r.next][:0], p...)
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns the retained log lines, oldest first.
+func (r *logRing) Bytes() []byte {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	var buf bytes.Buffer
+	if r.full {
+		for _, line := range r.lines[r.next:] {
+			buf.Write(line)
+		}
+	}
+	for _, line := range r.lines[:r.next] {
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,101 @@
+package flowmode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportBundler_RedactedConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.river")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+prometheus.remote_write "default" {
+  endpoint {
+    url = "https://prometheus.example.com/api/v1/write"
+
+    basic_auth {
+      username = "admin"
+      password = "hunter2"
+    }
+    headers = { "X-Tenant" = "tenant" }
+  }
+}
+
+profile "dev" {
+  otelcol.auth.bearer "creds" {
+    token = "abc123"
+  }
+}
+
+unknown.component "default" {
+  password = "unknown"
+}
+`), 0644))
+
+	sb := &supportBundler{configFile: configFile}
+	bb, err := sb.redactedConfig()
+	require.NoError(t, err)
+
+	// Only values of secret arguments are redacted, no matter their names.
+	out := string(bb)
+	require.Contains(t, out, `url = "https://prometheus.example.com/api/v1/write"`)
+	require.Contains(t, out, `username = "admin"`)
+	require.Contains(t, out, `password = "(secret)"`)
+	require.Contains(t, out, `"X-Tenant" = "tenant"`)
+	require.Contains(t, out, `token = "(secret)"`)
+	require.Contains(t, out, `password = "unknown"`)
+	require.NotContains(t, out, "hunter2")
+	require.NotContains(t, out, "abc123")
+}
+
+func TestCollectProfiles_RestoresBlockProfileRate(t *testing.T) {
+	prev := setBlockProfileRate(100)
+	defer setBlockProfileRate(prev)
+
+	profiles, err := collectProfiles(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Contains(t, profiles, "block")
+	require.Equal(t, 100, setBlockProfileRate(100))
+}
+
+func TestLogRing(t *testing.T) {
+	r := newLogRing(3)
+	require.Empty(t, r.Bytes())
+
+	for _, line := range []string{"a\n", "b\n"} {
+		_, _ = r.Write([]byte(line))
+	}
+	require.Equal(t, "a\nb\n", string(r.Bytes()))
+
+	for _, line := range []string{"c\n", "d\n", "e\n"} {
+		_, _ = r.Write([]byte(line))
+	}
+	require.Equal(t, "c\nd\ne\n", string(r.Bytes()))
+}
+
+func TestSupportBundler_RedactedConfig_Directory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.river"), []byte(`logging {}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.river"), []byte(`local.file "token" { filename = "/tmp/token" }`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.river"), []byte(`remote.http "api" {
+  url = "https://example.com"
+  client { bearer_token = "hunter2" }
+}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "d.river"), []byte(`broken { password = "hunter3"`), 0644))
+
+	sb := &supportBundler{configFile: dir}
+	bb, err := sb.redactedConfig()
+	require.NoError(t, err)
+
+	out := string(bb)
+	require.Contains(t, out, "// File: a.river\nlogging { }\n")
+	require.Contains(t, out, "\n// File: b.river\n")
+	require.Contains(t, out, `bearer_token = "(secret)"`)
+	require.Contains(t, out, "\n// File: d.river\n// failed to redact file: ")
+	require.NotContains(t, out, "hunter2")
+	require.NotContains(t, out, "hunter3")
+}
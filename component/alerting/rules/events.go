@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Event is a change of the state of an alert.
+type Event struct {
+	State         AlertState        `json:"state"`
+	PreviousState AlertState        `json:"previous_state"`
+	Labels        map[string]string `json:"labels"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	Value         float64           `json:"value"`
+	ActiveAt      time.Time         `json:"active_at"`
+	Timestamp     time.Time         `json:"timestamp"`
+}
+
+// logLine returns the logfmt log line of e.
+func (e Event) logLine() ([]byte, error) {
+	kvs := []interface{}{
+		"alertname", e.Labels[model.AlertNameLabel],
+		"state", e.State,
+		"previous_state", e.PreviousState,
+		"value", strconv.FormatFloat(e.Value, 'g', -1, 64),
+		"active_at", e.ActiveAt.Format(time.RFC3339),
+		"labels", labels.FromMap(e.Labels).String(),
+	}
+
+	names := make([]string, 0, len(e.Annotations))
+	for name := range e.Annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kvs = append(kvs, "annotation_"+name, e.Annotations[name])
+	}
+	return logfmt.MarshalKeyvals(kvs...)
+}
+
+// sendLogs forwards events as log entries.
+func (c *Component) sendLogs(ctx context.Context, receivers []loki.LogsReceiver, events []Event) {
+	if len(receivers) == 0 {
+		return
+	}
+
+	for _, e := range events {
+		line, err := e.logLine()
+		if err != nil {
+			level.Error(c.log).Log("msg", "failed to encode alert event", "err", err)
+			continue
+		}
+
+		entry := loki.Entry{
+			Labels: model.LabelSet{
+				model.AlertNameLabel: model.LabelValue(e.Labels[model.AlertNameLabel]),
+				"state":              model.LabelValue(e.State),
+			},
+			Entry: logproto.Entry{Timestamp: e.Timestamp, Line: string(line)},
+		}
+		for _, receiver := range receivers {
+			select {
+			case <-ctx.Done():
+				return
+			case receiver <- entry:
+			}
+		}
+	}
+}
+
+// webhookPayload is the body of webhook requests.
+type webhookPayload struct {
+	Events []Event `json:"events"`
+}
+
+// sendWebhooks sends events to every webhook.
+func (c *Component) sendWebhooks(ctx context.Context, webhooks []WebhookArguments, events []Event) {
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{Events: events})
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to encode alert events", "err", err)
+		return
+	}
+
+	for _, wh := range webhooks {
+		if err := c.sendWebhook(ctx, wh, body); err != nil {
+			c.metrics.webhookErrors.Inc()
+			level.Error(c.log).Log("msg", "failed to send alert events to webhook", "url", wh.URL, "err", err)
+		}
+	}
+}
+
+func (c *Component) sendWebhook(ctx context.Context, wh WebhookArguments, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, wh.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return nil
+}
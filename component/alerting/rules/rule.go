@@ -0,0 +1,279 @@
+package rules
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// AlertState is the state of an alert.
+type AlertState string
+
+// Supported states of alerts.
+const (
+	StateInactive AlertState = "inactive"
+	StatePending  AlertState = "pending"
+	StateFiring   AlertState = "firing"
+	StateResolved AlertState = "resolved"
+)
+
+// rule is a compiled alerting rule, holding the state of its alerts.
+type rule struct {
+	args        RuleArguments
+	selector    *parser.VectorSelector
+	op          parser.ItemType
+	threshold   float64
+	annotations map[string]*template.Template
+
+	mut    sync.Mutex
+	alerts map[uint64]*alert
+}
+
+// alert is an active alert of a rule.
+type alert struct {
+	labels   labels.Labels
+	state    AlertState
+	value    float64
+	activeAt time.Time
+}
+
+// compileRules compiles rules, returning an error if any rule is invalid or
+// if two rules have the same name.
+func compileRules(args []RuleArguments) ([]*rule, error) {
+	names := make(map[string]struct{}, len(args))
+	res := make([]*rule, 0, len(args))
+
+	for _, ra := range args {
+		if ra.Name == "" {
+			return nil, fmt.Errorf("rule name must not be empty")
+		}
+		if _, ok := names[ra.Name]; ok {
+			return nil, fmt.Errorf("duplicate rule name %q", ra.Name)
+		}
+		names[ra.Name] = struct{}{}
+
+		r, err := compileRule(ra)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", ra.Name, err)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func compileRule(args RuleArguments) (*rule, error) {
+	if args.For < 0 {
+		return nil, fmt.Errorf("for must not be negative")
+	}
+	for name := range args.Labels {
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+	}
+
+	expr, err := parser.ParseExpr(args.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expr: %w", err)
+	}
+	errUnsupported := fmt.Errorf("expr must compare a series selector to a number, such as `up == 0`")
+
+	bin, ok := unwrapParens(expr).(*parser.BinaryExpr)
+	if !ok || !bin.Op.IsComparisonOperator() || bin.ReturnBool {
+		return nil, errUnsupported
+	}
+	selector, ok := unwrapParens(bin.LHS).(*parser.VectorSelector)
+	if !ok || selector.OriginalOffset != 0 || selector.Timestamp != nil || selector.StartOrEnd != 0 {
+		return nil, errUnsupported
+	}
+	threshold, ok := unwrapParens(bin.RHS).(*parser.NumberLiteral)
+	if !ok {
+		return nil, errUnsupported
+	}
+
+	annotations := make(map[string]*template.Template, len(args.Annotations))
+	for name, text := range args.Annotations {
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(templatePrefix + text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation %q: %w", name, err)
+		}
+		annotations[name] = tmpl
+	}
+
+	return &rule{
+		args:        args,
+		selector:    selector,
+		op:          bin.Op,
+		threshold:   threshold.Val,
+		annotations: annotations,
+		alerts:      make(map[uint64]*alert),
+	}, nil
+}
+
+func unwrapParens(e parser.Expr) parser.Expr {
+	for {
+		p, ok := e.(*parser.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.Expr
+	}
+}
+
+// templatePrefix defines the $labels and $value variables of annotation
+// templates, like Prometheus does.
+const templatePrefix = "{{$labels := .Labels}}{{$value := .Value}}"
+
+// equal reports whether r and o are defined by the same arguments.
+func (r *rule) equal(o *rule) bool {
+	return reflect.DeepEqual(r.args, o.args)
+}
+
+// compare reports whether v passes the threshold of the rule.
+func (r *rule) compare(v float64) bool {
+	switch r.op {
+	case parser.EQLC:
+		return v == r.threshold
+	case parser.NEQ:
+		return v != r.threshold
+	case parser.GTR:
+		return v > r.threshold
+	case parser.LSS:
+		return v < r.threshold
+	case parser.GTE:
+		return v >= r.threshold
+	case parser.LTE:
+		return v <= r.threshold
+	}
+	return false
+}
+
+// alertLabels returns the labels of the alert for a series.
+func (r *rule) alertLabels(series labels.Labels) labels.Labels {
+	b := labels.NewBuilder(series)
+	b.Del(labels.MetricName)
+	for k, v := range r.args.Labels {
+		b.Set(k, v)
+	}
+	b.Set(model.AlertNameLabel, r.args.Name)
+	return b.Labels(nil)
+}
+
+// eval updates the alerts of the rule from the latest samples of the series
+// it selects, returning the events of alerts which changed state.
+func (r *rule) eval(samples []sample, now time.Time) []Event {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	var events []Event
+	seen := make(map[uint64]struct{}, len(samples))
+
+	for _, s := range samples {
+		if !r.compare(s.v) {
+			continue
+		}
+
+		lbls := r.alertLabels(s.labels)
+		h := lbls.Hash()
+		seen[h] = struct{}{}
+
+		a, ok := r.alerts[h]
+		if !ok {
+			a = &alert{labels: lbls, state: StatePending, activeAt: now}
+			r.alerts[h] = a
+		}
+		a.value = s.v
+
+		switch {
+		case !ok && r.args.For == 0:
+			a.state = StateFiring
+			events = append(events, r.event(a, StateInactive, now))
+		case !ok:
+			events = append(events, r.event(a, StateInactive, now))
+		case a.state == StatePending && now.Sub(a.activeAt) >= r.args.For:
+			a.state = StateFiring
+			events = append(events, r.event(a, StatePending, now))
+		}
+	}
+
+	for h, a := range r.alerts {
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		delete(r.alerts, h)
+
+		// Like in Prometheus, pending alerts which stop matching are dropped
+		// silently.
+		if a.state == StateFiring {
+			a.state = StateResolved
+			events = append(events, r.event(a, StateFiring, now))
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return labels.Compare(labels.FromMap(events[i].Labels), labels.FromMap(events[j].Labels)) < 0
+	})
+	return events
+}
+
+// event returns the event of a changing to its current state.
+func (r *rule) event(a *alert, previous AlertState, now time.Time) Event {
+	return Event{
+		State:         a.state,
+		PreviousState: previous,
+		Labels:        a.labels.Map(),
+		Annotations:   r.expandAnnotations(a),
+		Value:         a.value,
+		ActiveAt:      a.activeAt,
+		Timestamp:     now,
+	}
+}
+
+func (r *rule) expandAnnotations(a *alert) map[string]string {
+	if len(r.annotations) == 0 {
+		return nil
+	}
+
+	data := struct {
+		Labels map[string]string
+		Value  float64
+	}{a.labels.Map(), a.value}
+
+	res := make(map[string]string, len(r.annotations))
+	for name, tmpl := range r.annotations {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			res[name] = fmt.Sprintf("error expanding annotation: %s", err)
+			continue
+		}
+		res[name] = sb.String()
+	}
+	return res
+}
+
+func (r *rule) debugInfo() []alertDebugInfo {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	res := make([]alertDebugInfo, 0, len(r.alerts))
+	for _, a := range r.alerts {
+		res = append(res, alertDebugInfo{
+			Name:     r.args.Name,
+			State:    string(a.state),
+			Labels:   a.labels.Map(),
+			Value:    a.value,
+			ActiveAt: a.activeAt,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return labels.Compare(labels.FromMap(res[i].Labels), labels.FromMap(res[j].Labels)) < 0
+	})
+	return res
+}
@@ -0,0 +1,316 @@
+// Package rules implements the alerting.rules component.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "alerting.rules",
+		Args:    Arguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the alerting.rules
+// component.
+type Arguments struct {
+	ForwardTo          []storage.Appendable `river:"forward_to,attr,optional"`
+	LogsForwardTo      []loki.LogsReceiver  `river:"logs_forward_to,attr,optional"`
+	EvaluationInterval time.Duration        `river:"evaluation_interval,attr,optional"`
+	LookbackDelta      time.Duration        `river:"lookback_delta,attr,optional"`
+
+	Rules    []RuleArguments    `river:"rule,block,optional"`
+	Webhooks []WebhookArguments `river:"webhook,block,optional"`
+}
+
+// RuleArguments defines an alerting rule.
+type RuleArguments struct {
+	Name        string            `river:"name,attr"`
+	Expr        string            `river:"expr,attr"`
+	For         time.Duration     `river:"for,attr,optional"`
+	Labels      map[string]string `river:"labels,attr,optional"`
+	Annotations map[string]string `river:"annotations,attr,optional"`
+}
+
+// WebhookArguments configures a webhook which alert state changes are sent
+// to.
+type WebhookArguments struct {
+	URL     string            `river:"url,attr"`
+	Headers map[string]string `river:"headers,attr,optional"`
+	Timeout time.Duration     `river:"timeout,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	EvaluationInterval: 30 * time.Second,
+	LookbackDelta:      5 * time.Minute,
+}
+
+// DefaultWebhookArguments holds default settings for WebhookArguments.
+var DefaultWebhookArguments = WebhookArguments{
+	Timeout: 10 * time.Second,
+}
+
+var (
+	_ river.Unmarshaler = (*Arguments)(nil)
+	_ river.Unmarshaler = (*WebhookArguments)(nil)
+)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.EvaluationInterval <= 0:
+		return fmt.Errorf("evaluation_interval must be greater than 0")
+	case args.LookbackDelta <= 0:
+		return fmt.Errorf("lookback_delta must be greater than 0")
+	}
+	_, err := compileRules(args.Rules)
+	return err
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *WebhookArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultWebhookArguments
+
+	type arguments WebhookArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.URL == "":
+		return fmt.Errorf("webhook url must not be empty")
+	case args.Timeout <= 0:
+		return fmt.Errorf("webhook timeout must be greater than 0")
+	}
+	_, err := http.NewRequest(http.MethodPost, args.URL, nil)
+	return err
+}
+
+// Exports holds values which are exported by the alerting.rules component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// Component implements the alerting.rules component.
+type Component struct {
+	log      log.Logger
+	opts     component.Options
+	fanout   *prometheus.Fanout
+	receiver *prometheus.Interceptor
+	series   *seriesStore
+	metrics  *metrics
+	cli      *http.Client
+
+	mut   sync.Mutex
+	args  Arguments
+	rules []*rule
+
+	// Updated is written to whenever args updates.
+	updated chan struct{}
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new alerting.rules component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := newMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		log:     o.Logger,
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		series:  newSeriesStore(),
+		metrics: m,
+		cli:     &http.Client{},
+		updated: make(chan struct{}, 1),
+	}
+	c.receiver = prometheus.NewInterceptor(
+		c.fanout,
+		prometheus.WithAppendHook(func(_ storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+			c.series.add(l, t, v)
+			return next.Append(0, l, t, v)
+		}),
+	)
+
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		c.mut.Lock()
+		interval := c.args.EvaluationInterval
+		c.mut.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+			c.evaluate(ctx, time.Now())
+		case <-c.updated:
+			// no-op; force the interval to be reread.
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	rules, err := compileRules(newArgs.Rules)
+	if err != nil {
+		return err
+	}
+
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	c.mut.Lock()
+	// Keep the state of rules which are unchanged, so that updating the
+	// component doesn't reset pending and firing alerts.
+	for i, r := range rules {
+		for _, old := range c.rules {
+			if r.equal(old) {
+				rules[i] = old
+				break
+			}
+		}
+	}
+	c.args = newArgs
+	c.rules = rules
+	c.metrics.rules.Set(float64(len(rules)))
+	c.mut.Unlock()
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evaluate evaluates every rule against the latest samples and sends the
+// events of alerts which changed state.
+func (c *Component) evaluate(ctx context.Context, now time.Time) {
+	c.mut.Lock()
+	args, rules := c.args, c.rules
+	c.mut.Unlock()
+
+	c.series.gc(now.Add(-args.LookbackDelta))
+
+	var events []Event
+	for _, r := range rules {
+		samples := c.series.matching(r.selector.LabelMatchers)
+		events = append(events, r.eval(samples, now)...)
+	}
+	c.metrics.evaluations.Inc()
+	if len(events) == 0 {
+		return
+	}
+
+	for _, e := range events {
+		c.metrics.events.WithLabelValues(string(e.State)).Inc()
+		level.Debug(c.log).Log("msg", "alert changed state", "alertname", e.Labels["alertname"], "state", e.State, "previous_state", e.PreviousState)
+	}
+	c.sendLogs(ctx, args.LogsForwardTo, events)
+	c.sendWebhooks(ctx, args.Webhooks, events)
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var info debugInfo
+	for _, r := range c.rules {
+		info.Alerts = append(info.Alerts, r.debugInfo()...)
+	}
+	info.Series = c.series.len()
+	return info
+}
+
+type debugInfo struct {
+	Series int              `river:"series,attr"`
+	Alerts []alertDebugInfo `river:"alert,block,optional"`
+}
+
+type alertDebugInfo struct {
+	Name     string            `river:"name,attr"`
+	State    string            `river:"state,attr"`
+	Labels   map[string]string `river:"labels,attr"`
+	Value    float64           `river:"value,attr"`
+	ActiveAt time.Time         `river:"active_at,attr"`
+}
+
+type metrics struct {
+	rules         prometheus_client.Gauge
+	evaluations   prometheus_client.Counter
+	events        *prometheus_client.CounterVec
+	webhookErrors prometheus_client.Counter
+}
+
+func newMetrics(reg prometheus_client.Registerer) (*metrics, error) {
+	m := &metrics{
+		rules: prometheus_client.NewGauge(prometheus_client.GaugeOpts{
+			Name: "agent_alerting_rules_rules",
+			Help: "Number of configured alerting rules.",
+		}),
+		evaluations: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_alerting_rules_evaluations_total",
+			Help: "Total number of evaluations of the alerting rules.",
+		}),
+		events: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_alerting_rules_events_total",
+			Help: "Total number of alert state changes, by new state.",
+		}, []string{"state"}),
+		webhookErrors: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_alerting_rules_webhook_errors_total",
+			Help: "Total number of failed webhook requests.",
+		}),
+	}
+
+	for _, c := range []prometheus_client.Collector{m.rules, m.evaluations, m.events, m.webhookErrors} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,199 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		rule {
+			name = "InstanceDown"
+			expr = "up{job=\"node\"} == 0"
+			for  = "1m"
+		}
+		webhook {
+			url = "http://localhost:8080/alerts"
+		}
+	`), &args))
+	require.Equal(t, 30*time.Second, args.EvaluationInterval)
+	require.Equal(t, 5*time.Minute, args.LookbackDelta)
+	require.Equal(t, 10*time.Second, args.Webhooks[0].Timeout)
+
+	tt := []struct {
+		cfg    string
+		expect string
+	}{
+		{`evaluation_interval = "0s"`, "evaluation_interval must be greater than 0"},
+		{`rule {
+			name = "a"
+			expr = "up == 0"
+		}
+		rule {
+			name = "a"
+			expr = "up == 1"
+		}`, `duplicate rule name "a"`},
+		{`rule {
+			name = "a"
+			expr = "rate(errors_total[5m]) > 1"
+		}`, "rule \"a\": expr must compare a series selector to a number, such as `up == 0`"},
+		{`rule {
+			name = "a"
+			expr = "up == bool 0"
+		}`, "rule \"a\": expr must compare a series selector to a number, such as `up == 0`"},
+		{`rule {
+			name = "a"
+			expr = "up == 0"
+			for  = "-1m"
+		}`, `rule "a": for must not be negative`},
+		{`webhook { timeout = "1s" }`, `missing required attribute "url"`},
+	}
+	for _, tc := range tt {
+		err := river.Unmarshal([]byte(tc.cfg), &args)
+		require.ErrorContains(t, err, tc.expect)
+	}
+}
+
+func TestRuleEval(t *testing.T) {
+	rules, err := compileRules([]RuleArguments{{
+		Name:        "HighLoad",
+		Expr:        `(load1 > 4)`,
+		For:         time.Minute,
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": `{{ $labels.instance }} has a load of {{ $value }}`},
+	}})
+	require.NoError(t, err)
+	r := rules[0]
+
+	series := func(instance string, v float64) sample {
+		return sample{labels: labels.FromStrings("__name__", "load1", "instance", instance), v: v}
+	}
+	start := time.Unix(1000, 0)
+
+	events := r.eval([]sample{series("a", 5), series("b", 1)}, start)
+	require.Len(t, events, 1)
+	require.Equal(t, StatePending, events[0].State)
+	require.Equal(t, StateInactive, events[0].PreviousState)
+	require.Equal(t, map[string]string{"alertname": "HighLoad", "instance": "a", "severity": "warning"}, events[0].Labels)
+	require.Equal(t, "a has a load of 5", events[0].Annotations["summary"])
+
+	// Still pending.
+	require.Empty(t, r.eval([]sample{series("a", 6)}, start.Add(30*time.Second)))
+
+	events = r.eval([]sample{series("a", 7)}, start.Add(time.Minute))
+	require.Len(t, events, 1)
+	require.Equal(t, StateFiring, events[0].State)
+	require.Equal(t, StatePending, events[0].PreviousState)
+	require.Equal(t, float64(7), events[0].Value)
+	require.Equal(t, start, events[0].ActiveAt)
+
+	// Pending alerts are dropped silently, firing ones are resolved.
+	require.Len(t, r.eval([]sample{series("a", 7), series("b", 5)}, start.Add(2*time.Minute)), 1)
+	events = r.eval([]sample{series("a", 1)}, start.Add(3*time.Minute))
+	require.Len(t, events, 1)
+	require.Equal(t, StateResolved, events[0].State)
+	require.Equal(t, StateFiring, events[0].PreviousState)
+	require.Equal(t, "a", events[0].Labels["instance"])
+	require.Empty(t, r.debugInfo())
+}
+
+func TestComponent(t *testing.T) {
+	webhookEvents := make(chan []Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token", r.Header.Get("X-Token"))
+		var payload webhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		webhookEvents <- payload.Events
+	}))
+	defer srv.Close()
+
+	logs := make(loki.LogsReceiver, 10)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		rule {
+			name        = "InstanceDown"
+			expr        = "up{job=\"node\"} == 0"
+			annotations = {"summary" = "{{ $labels.instance }} is down"}
+		}
+		webhook {
+			url     = "`+srv.URL+`"
+			headers = {"X-Token" = "token"}
+		}
+	`), &args))
+	args.LogsForwardTo = []loki.LogsReceiver{logs}
+
+	var exports Exports
+	c, err := New(component.Options{
+		ID:            "alerting.rules.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prom.NewRegistry(),
+		OnStateChange: func(e component.Exports) { exports = e.(Exports) },
+	}, args)
+	require.NoError(t, err)
+
+	now := time.Now()
+	appendSample := func(instance string, t int64, v float64) error {
+		app := exports.Receiver.Appender(context.Background())
+		_, err := app.Append(0, labels.FromStrings("__name__", "up", "job", "node", "instance", instance), t, v)
+		if err != nil {
+			return err
+		}
+		return app.Commit()
+	}
+	require.NoError(t, appendSample("a", now.UnixMilli(), 0))
+	require.NoError(t, appendSample("b", now.UnixMilli(), 1))
+
+	c.evaluate(context.Background(), now)
+
+	select {
+	case entry := <-logs:
+		require.Equal(t, "InstanceDown", string(entry.Labels["alertname"]))
+		require.Equal(t, "firing", string(entry.Labels["state"]))
+		require.Contains(t, entry.Line, `alertname=InstanceDown state=firing previous_state=inactive value=0`)
+		require.Contains(t, entry.Line, `annotation_summary="a is down"`)
+	default:
+		t.Fatal("expected a log entry")
+	}
+	events := <-webhookEvents
+	require.Len(t, events, 1)
+	require.Equal(t, StateFiring, events[0].State)
+	require.Equal(t, "a", events[0].Labels["instance"])
+
+	info := c.DebugInfo().(debugInfo)
+	require.Equal(t, 2, info.Series)
+	require.Len(t, info.Alerts, 1)
+
+	// Updating the component with the same rules keeps their alerts.
+	require.NoError(t, c.Update(args))
+	c.evaluate(context.Background(), now.Add(time.Second))
+	require.Empty(t, logs)
+
+	// A staleness marker removes the series, resolving its alert.
+	require.NoError(t, appendSample("a", now.UnixMilli()+2000, math.Float64frombits(value.StaleNaN)))
+	c.evaluate(context.Background(), now.Add(2*time.Second))
+
+	entry := <-logs
+	require.Equal(t, "resolved", string(entry.Labels["state"]))
+	events = <-webhookEvents
+	require.Equal(t, StateResolved, events[0].State)
+
+	// Series which weren't updated within the lookback delta are removed.
+	c.evaluate(context.Background(), now.Add(10*time.Minute))
+	require.Equal(t, 0, c.DebugInfo().(debugInfo).Series)
+}
@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+)
+
+// seriesStore holds the latest sample of every series received by the
+// component.
+type seriesStore struct {
+	mut    sync.RWMutex
+	series map[uint64]sample
+}
+
+// sample is the latest sample of a series.
+type sample struct {
+	labels labels.Labels
+	t      int64
+	v      float64
+}
+
+func newSeriesStore() *seriesStore {
+	return &seriesStore{series: make(map[uint64]sample)}
+}
+
+// add stores a sample if it's newer than the stored sample of its series.
+// Staleness markers remove the series.
+func (s *seriesStore) add(l labels.Labels, t int64, v float64) {
+	h := l.Hash()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if value.IsStaleNaN(v) {
+		delete(s.series, h)
+		return
+	}
+	if cur, ok := s.series[h]; ok && cur.t > t {
+		return
+	}
+	s.series[h] = sample{labels: l.Copy(), t: t, v: v}
+}
+
+// gc removes series whose latest sample is older than minTime.
+func (s *seriesStore) gc(minTime time.Time) {
+	min := minTime.UnixMilli()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for h, smpl := range s.series {
+		if smpl.t < min {
+			delete(s.series, h)
+		}
+	}
+}
+
+// matching returns the samples of the series matching all matchers.
+func (s *seriesStore) matching(matchers []*labels.Matcher) []sample {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	var res []sample
+outer:
+	for _, smpl := range s.series {
+		for _, m := range matchers {
+			if !m.Matches(smpl.labels.Get(m.Name)) {
+				continue outer
+			}
+		}
+		res = append(res, smpl)
+	}
+	return res
+}
+
+func (s *seriesStore) len() int {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return len(s.series)
+}
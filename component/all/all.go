@@ -2,38 +2,51 @@
 package all
 
 import (
+	_ "github.com/grafana/agent/component/alerting/rules"                           // Import alerting.rules
 	_ "github.com/grafana/agent/component/discovery/aws"                            // Import discovery.aws.ec2 and discovery.aws.lightsail
 	_ "github.com/grafana/agent/component/discovery/docker"                         // Import discovery.docker
 	_ "github.com/grafana/agent/component/discovery/file"                           // Import discovery.file
 	_ "github.com/grafana/agent/component/discovery/kubernetes"                     // Import discovery.kubernetes
 	_ "github.com/grafana/agent/component/discovery/relabel"                        // Import discovery.relabel
+	_ "github.com/grafana/agent/component/faro/receive"                             // Import faro.receive
 	_ "github.com/grafana/agent/component/local/file"                               // Import local.file
 	_ "github.com/grafana/agent/component/loki/echo"                                // Import loki.echo
 	_ "github.com/grafana/agent/component/loki/process"                             // Import loki.process
 	_ "github.com/grafana/agent/component/loki/relabel"                             // Import loki.relabel
+	_ "github.com/grafana/agent/component/loki/route"                               // Import loki.route
+	_ "github.com/grafana/agent/component/loki/rules/kubernetes"                    // Import loki.rules.kubernetes
+	_ "github.com/grafana/agent/component/loki/source/api"                          // Import loki.source.api
+	_ "github.com/grafana/agent/component/loki/source/aws_cloudwatch_logs"          // Import loki.source.aws_cloudwatch_logs
 	_ "github.com/grafana/agent/component/loki/source/azure_event_hubs"             // Import loki.source.azure_event_hubs
 	_ "github.com/grafana/agent/component/loki/source/cloudflare"                   // Import loki.source.cloudflare
 	_ "github.com/grafana/agent/component/loki/source/docker"                       // Import loki.source.docker
 	_ "github.com/grafana/agent/component/loki/source/file"                         // Import loki.source.file
 	_ "github.com/grafana/agent/component/loki/source/gcplog"                       // Import loki.source.gcplog
 	_ "github.com/grafana/agent/component/loki/source/gelf"                         // Import loki.source.gelf
+	_ "github.com/grafana/agent/component/loki/source/google_workspace"             // Import loki.source.google_workspace
 	_ "github.com/grafana/agent/component/loki/source/heroku"                       // Import loki.source.heroku
+	_ "github.com/grafana/agent/component/loki/source/http_poll"                    // Import loki.source.http_poll
 	_ "github.com/grafana/agent/component/loki/source/journal"                      // Import loki.source.journal
 	_ "github.com/grafana/agent/component/loki/source/kafka"                        // Import loki.source.kafka
 	_ "github.com/grafana/agent/component/loki/source/kubernetes"                   // Import loki.source.kubernetes
 	_ "github.com/grafana/agent/component/loki/source/kubernetes_events"            // Import loki.source.kubernetes_events
+	_ "github.com/grafana/agent/component/loki/source/microsoft365"                 // Import loki.source.microsoft365
+	_ "github.com/grafana/agent/component/loki/source/okta"                         // Import loki.source.okta
 	_ "github.com/grafana/agent/component/loki/source/podlogs"                      // Import loki.source.podlogs
 	_ "github.com/grafana/agent/component/loki/source/syslog"                       // Import loki.source.syslog
 	_ "github.com/grafana/agent/component/loki/source/windowsevent"                 // Import loki.source.windowsevent
 	_ "github.com/grafana/agent/component/loki/write"                               // Import loki.write
 	_ "github.com/grafana/agent/component/mimir/rules/kubernetes"                   // Import mimir.rules.kubernetes
+	_ "github.com/grafana/agent/component/module/agent_management"                  // Import module.agent_management
 	_ "github.com/grafana/agent/component/module/file"                              // Import module.file
+	_ "github.com/grafana/agent/component/module/foreach"                           // Import module.foreach
 	_ "github.com/grafana/agent/component/module/string"                            // Import module.string
 	_ "github.com/grafana/agent/component/otelcol/auth/basic"                       // Import otelcol.auth.basic
 	_ "github.com/grafana/agent/component/otelcol/auth/bearer"                      // Import otelcol.auth.bearer
 	_ "github.com/grafana/agent/component/otelcol/auth/headers"                     // Import otelcol.auth.headers
 	_ "github.com/grafana/agent/component/otelcol/auth/oauth2"                      // Import otelcol.auth.oauth2
 	_ "github.com/grafana/agent/component/otelcol/auth/sigv4"                       // Import otelcol.auth.sigv4
+	_ "github.com/grafana/agent/component/otelcol/connector/spanmetrics"            // Import otelcol.connector.spanmetrics
 	_ "github.com/grafana/agent/component/otelcol/exporter/jaeger"                  // Import otelcol.exporter.jaeger
 	_ "github.com/grafana/agent/component/otelcol/exporter/loki"                    // Import otelcol.exporter.loki
 	_ "github.com/grafana/agent/component/otelcol/exporter/otlp"                    // Import otelcol.exporter.otlp
@@ -41,8 +54,14 @@ import (
 	_ "github.com/grafana/agent/component/otelcol/exporter/prometheus"              // Import otelcol.exporter.prometheus
 	_ "github.com/grafana/agent/component/otelcol/extension/jaeger_remote_sampling" // Import otelcol.extension.jaeger_remote_sampling
 	_ "github.com/grafana/agent/component/otelcol/processor/batch"                  // Import otelcol.processor.batch
+	_ "github.com/grafana/agent/component/otelcol/processor/cumulativetodelta"      // Import otelcol.processor.cumulativetodelta
+	_ "github.com/grafana/agent/component/otelcol/processor/deltatocumulative"      // Import otelcol.processor.deltatocumulative
 	_ "github.com/grafana/agent/component/otelcol/processor/memorylimiter"          // Import otelcol.processor.memory_limiter
+	_ "github.com/grafana/agent/component/otelcol/processor/probabilistic_sampler"  // Import otelcol.processor.probabilistic_sampler
+	_ "github.com/grafana/agent/component/otelcol/processor/resourcedetection"      // Import otelcol.processor.resourcedetection
 	_ "github.com/grafana/agent/component/otelcol/processor/tail_sampling"          // Import otelcol.processor.tail_sampling
+	_ "github.com/grafana/agent/component/otelcol/receiver/awsxray"                 // Import otelcol.receiver.awsxray
+	_ "github.com/grafana/agent/component/otelcol/receiver/datadog"                 // Import otelcol.receiver.datadog
 	_ "github.com/grafana/agent/component/otelcol/receiver/jaeger"                  // Import otelcol.receiver.jaeger
 	_ "github.com/grafana/agent/component/otelcol/receiver/kafka"                   // Import otelcol.receiver.kafka
 	_ "github.com/grafana/agent/component/otelcol/receiver/loki"                    // Import otelcol.receiver.loki
@@ -54,20 +73,36 @@ import (
 	_ "github.com/grafana/agent/component/phlare/write"                             // Import phlare.write
 	_ "github.com/grafana/agent/component/prometheus/exporter/apache"               // Import prometheus.exporter.apache
 	_ "github.com/grafana/agent/component/prometheus/exporter/blackbox"             // Import prometheus.exporter.blackbox
+	_ "github.com/grafana/agent/component/prometheus/exporter/bmc"                  // Import prometheus.exporter.bmc
+	_ "github.com/grafana/agent/component/prometheus/exporter/certificate"          // Import prometheus.exporter.certificate
 	_ "github.com/grafana/agent/component/prometheus/exporter/consul"               // Import prometheus.exporter.consul
+	_ "github.com/grafana/agent/component/prometheus/exporter/dcgm"                 // Import prometheus.exporter.dcgm
+	_ "github.com/grafana/agent/component/prometheus/exporter/gcp"                  // Import prometheus.exporter.gcp
 	_ "github.com/grafana/agent/component/prometheus/exporter/github"               // Import prometheus.exporter.github
+	_ "github.com/grafana/agent/component/prometheus/exporter/jmx"                  // Import prometheus.exporter.jmx
 	_ "github.com/grafana/agent/component/prometheus/exporter/memcached"            // Import prometheus.exporter.memcached
 	_ "github.com/grafana/agent/component/prometheus/exporter/mysql"                // Import prometheus.exporter.mysql
+	_ "github.com/grafana/agent/component/prometheus/exporter/ping"                 // Import prometheus.exporter.ping
 	_ "github.com/grafana/agent/component/prometheus/exporter/postgres"             // Import prometheus.exporter.postgres
 	_ "github.com/grafana/agent/component/prometheus/exporter/process"              // Import prometheus.exporter.process
 	_ "github.com/grafana/agent/component/prometheus/exporter/redis"                // Import prometheus.exporter.redis
 	_ "github.com/grafana/agent/component/prometheus/exporter/snmp"                 // Import prometheus.exporter.snmp
 	_ "github.com/grafana/agent/component/prometheus/exporter/statsd"               // Import prometheus.exporter.statsd
+	_ "github.com/grafana/agent/component/prometheus/exporter/systemd"              // Import prometheus.exporter.systemd
 	_ "github.com/grafana/agent/component/prometheus/exporter/unix"                 // Import prometheus.exporter.unix
+	_ "github.com/grafana/agent/component/prometheus/exporter/vsphere"              // Import prometheus.exporter.vsphere
 	_ "github.com/grafana/agent/component/prometheus/operator/podmonitors"          // Import prometheus.operator.podmonitors
+	_ "github.com/grafana/agent/component/prometheus/receive_collectd"              // Import prometheus.receive_collectd
+	_ "github.com/grafana/agent/component/prometheus/receive_http"                  // Import prometheus.receive_http
+	_ "github.com/grafana/agent/component/prometheus/receive_influx"                // Import prometheus.receive_influx
+	_ "github.com/grafana/agent/component/prometheus/receive_statsd"                // Import prometheus.receive_statsd
 	_ "github.com/grafana/agent/component/prometheus/relabel"                       // Import prometheus.relabel
 	_ "github.com/grafana/agent/component/prometheus/remotewrite"                   // Import prometheus.remote_write
+	_ "github.com/grafana/agent/component/prometheus/rewrite_histograms"            // Import prometheus.rewrite_histograms
 	_ "github.com/grafana/agent/component/prometheus/scrape"                        // Import prometheus.scrape
 	_ "github.com/grafana/agent/component/remote/http"                              // Import remote.http
 	_ "github.com/grafana/agent/component/remote/s3"                                // Import remote.s3
+	_ "github.com/grafana/agent/component/synthetics/http"                          // Import synthetics.http
+	_ "github.com/grafana/agent/component/testing/logs/generator"                   // Import testing.logs.generator
+	_ "github.com/grafana/agent/component/testing/metrics/generator"                // Import testing.metrics.generator
 )
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/prometheus/common/config"
+)
+
+// HTTPClientDefaults holds settings which are inherited by every
+// HTTPClientConfig that doesn't set them itself.
+type HTTPClientDefaults struct {
+	ProxyURL      URL        `river:"proxy_url,attr,optional"`
+	TLSMinVersion TLSVersion `river:"tls_min_version,attr,optional"`
+}
+
+var (
+	httpClientDefaultsMut sync.RWMutex
+	httpClientDefaults    HTTPClientDefaults
+)
+
+// SetHTTPClientDefaults changes the defaults inherited by HTTPClientConfigs.
+// Components only pick up new defaults the next time their HTTPClientConfig
+// is converted.
+func SetHTTPClientDefaults(d HTTPClientDefaults) {
+	httpClientDefaultsMut.Lock()
+	defer httpClientDefaultsMut.Unlock()
+	httpClientDefaults = d
+}
+
+// GetHTTPClientDefaults returns the defaults inherited by HTTPClientConfigs.
+func GetHTTPClientDefaults() HTTPClientDefaults {
+	httpClientDefaultsMut.RLock()
+	defer httpClientDefaultsMut.RUnlock()
+	return httpClientDefaults
+}
+
+// applyHTTPClientDefaults sets fields of c which are unset to the current
+// HTTPClientDefaults.
+func applyHTTPClientDefaults(c *config.HTTPClientConfig) {
+	d := GetHTTPClientDefaults()
+
+	if c.ProxyURL.URL == nil && d.ProxyURL.URL != nil {
+		c.ProxyURL = d.ProxyURL.Convert()
+	}
+	if c.TLSConfig.MinVersion == 0 {
+		c.TLSConfig.MinVersion = config.TLSVersion(d.TLSMinVersion)
+	}
+}
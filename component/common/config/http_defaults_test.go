@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientDefaults(t *testing.T) {
+	var defaults HTTPClientDefaults
+	require.NoError(t, river.Unmarshal([]byte(`
+		proxy_url       = "http://proxy:3128"
+		tls_min_version = "TLS12"
+	`), &defaults))
+
+	SetHTTPClientDefaults(defaults)
+	defer SetHTTPClientDefaults(HTTPClientDefaults{})
+
+	t.Run("inherited", func(t *testing.T) {
+		converted := CloneDefaultHTTPClientConfig().Convert()
+		require.Equal(t, "http://proxy:3128", converted.ProxyURL.String())
+		require.Equal(t, config.TLSVersion(config.TLSVersions["TLS12"]), converted.TLSConfig.MinVersion)
+
+		var nilConfig *HTTPClientConfig
+		require.Equal(t, "http://proxy:3128", nilConfig.Convert().ProxyURL.String())
+		require.Nil(t, config.DefaultHTTPClientConfig.ProxyURL.URL, "shared default must not be modified")
+	})
+
+	t.Run("overridden", func(t *testing.T) {
+		var httpClientConfig HTTPClientConfig
+		require.NoError(t, river.Unmarshal([]byte(`
+			proxy_url = "http://other-proxy:3128"
+
+			tls_config {
+				min_version = "TLS13"
+			}
+		`), &httpClientConfig))
+
+		converted := httpClientConfig.Convert()
+		require.Equal(t, "http://other-proxy:3128", converted.ProxyURL.String())
+		require.Equal(t, config.TLSVersion(config.TLSVersions["TLS13"]), converted.TLSConfig.MinVersion)
+	})
+}
@@ -104,13 +104,16 @@ func (h *HTTPClientConfig) Validate() error {
 }
 
 // Convert converts HTTPClientConfig to the native Prometheus type. If h is
-// nil, the default client config is returned.
+// nil, the default client config is returned. Settings which aren't set by h
+// are inherited from the current HTTPClientDefaults.
 func (h *HTTPClientConfig) Convert() *config.HTTPClientConfig {
 	if h == nil {
-		return &config.DefaultHTTPClientConfig
+		res := config.DefaultHTTPClientConfig
+		applyHTTPClientDefaults(&res)
+		return &res
 	}
 
-	return &config.HTTPClientConfig{
+	res := &config.HTTPClientConfig{
 		BasicAuth:       h.BasicAuth.Convert(),
 		Authorization:   h.Authorization.Convert(),
 		OAuth2:          h.OAuth2.Convert(),
@@ -121,6 +124,8 @@ func (h *HTTPClientConfig) Convert() *config.HTTPClientConfig {
 		FollowRedirects: h.FollowRedirects,
 		EnableHTTP2:     h.EnableHTTP2,
 	}
+	applyHTTPClientDefaults(res)
+	return res
 }
 
 // Clone creates a shallow clone of h.
@@ -0,0 +1,451 @@
+// Package diskbudget implements a disk usage budget shared by every component
+// storing data on disk, such as WALs and positions files.
+//
+// The Flow controller owns a Budget which it passes to components through
+// their options. Components register the paths they write to as consumers of
+// a subsystem. The budget periodically measures the usage of the data directory and of
+// every subsystem, and asks consumers to evict data when a quota is exceeded.
+package diskbudget
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Subsystems of the components which register consumers.
+const (
+	SubsystemWAL       = "wal"
+	SubsystemPositions = "positions"
+)
+
+// Arguments configures the disk budget.
+type Arguments struct {
+	MaxSize       units.Base2Bytes     `river:"max_size,attr,optional"`
+	CheckInterval time.Duration        `river:"check_interval,attr,optional"`
+	Subsystems    []SubsystemArguments `river:"subsystem,block,optional"`
+}
+
+// SubsystemArguments configures the quota of a subsystem.
+type SubsystemArguments struct {
+	Name             string           `river:",label"`
+	MaxSize          units.Base2Bytes `river:"max_size,attr,optional"`
+	EvictionPriority int              `river:"eviction_priority,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	CheckInterval: time.Minute,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.MaxSize < 0:
+		return fmt.Errorf("max_size must not be negative")
+	case args.CheckInterval <= 0:
+		return fmt.Errorf("check_interval must be greater than 0")
+	}
+
+	names := make(map[string]struct{}, len(args.Subsystems))
+	for _, s := range args.Subsystems {
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("subsystem %q is configured more than once", s.Name)
+		}
+		names[s.Name] = struct{}{}
+
+		if s.MaxSize < 0 {
+			return fmt.Errorf("subsystem %q: max_size must not be negative", s.Name)
+		}
+	}
+	return nil
+}
+
+// enabled reports whether args set any quota.
+func (args Arguments) enabled() bool {
+	if args.MaxSize > 0 {
+		return true
+	}
+	for _, s := range args.Subsystems {
+		if s.MaxSize > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Consumer is a path on disk written to by a component.
+type Consumer struct {
+	// ID identifies the consumer in logs, such as the ID of its component.
+	ID string
+	// Subsystem the consumer belongs to, such as SubsystemWAL.
+	Subsystem string
+	// Path of the file or directory written to by the consumer.
+	Path string
+
+	// Evict is called to reclaim disk space when a quota covering the
+	// consumer is exceeded. Consumers which can't evict data leave it nil;
+	// their usage still counts towards the quotas.
+	Evict func(ctx context.Context) error
+}
+
+// Budget enforces disk usage quotas on its consumers.
+type Budget struct {
+	metrics *metrics
+
+	mut       sync.Mutex
+	log       log.Logger
+	dataPath  string
+	args      Arguments
+	consumers map[*Consumer]struct{}
+	started   bool
+
+	// updated is written to whenever args changes.
+	updated chan struct{}
+	done    chan struct{}
+	stop    sync.Once
+}
+
+// NewBudget creates a Budget. The budget doesn't enforce any quota until
+// Update is called with arguments setting one.
+func NewBudget(reg prometheus.Registerer) *Budget {
+	return &Budget{
+		metrics:   newMetrics(reg),
+		log:       log.NewNopLogger(),
+		consumers: make(map[*Consumer]struct{}),
+		updated:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// Update changes the quotas of the budget. dataPath is the directory holding
+// the data of every component, whose usage counts towards args.MaxSize. Once a
+// quota is set, quotas are checked every args.CheckInterval until Stop is
+// called. Update is a no-op on a nil Budget.
+func (b *Budget) Update(l log.Logger, dataPath string, args Arguments) {
+	if b == nil {
+		return
+	}
+
+	b.mut.Lock()
+	b.log = l
+	b.dataPath = dataPath
+	b.args = args
+	start := !b.started && args.enabled()
+	if start {
+		b.started = true
+	}
+	b.mut.Unlock()
+
+	if start {
+		go b.run()
+	}
+
+	select {
+	case b.updated <- struct{}{}:
+	default:
+	}
+}
+
+// Register adds a consumer to the budget, returning a function which removes
+// it. Consumers registered to a nil Budget are never evicted from.
+func (b *Budget) Register(c Consumer) (unregister func()) {
+	if b == nil {
+		return func() {}
+	}
+
+	cp := &c
+
+	b.mut.Lock()
+	b.consumers[cp] = struct{}{}
+	b.mut.Unlock()
+
+	return func() {
+		b.mut.Lock()
+		delete(b.consumers, cp)
+		b.mut.Unlock()
+	}
+}
+
+// Stop stops checking the quotas of the budget.
+func (b *Budget) Stop() {
+	if b == nil {
+		return
+	}
+	b.stop.Do(func() { close(b.done) })
+}
+
+func (b *Budget) run() {
+	for {
+		b.mut.Lock()
+		interval := b.args.CheckInterval
+		b.mut.Unlock()
+
+		select {
+		case <-b.done:
+			return
+		case <-b.updated:
+			// no-op; force the interval to be reread.
+		case <-time.After(interval):
+			b.Check(context.Background())
+		}
+	}
+}
+
+// consumerUsage is the measured usage of a consumer.
+type consumerUsage struct {
+	*Consumer
+	bytes int64
+}
+
+// Check measures the usage of every consumer and evicts data from consumers
+// whose quotas are exceeded. Subsystem quotas are enforced first. If the data
+// directory still exceeds its quota afterwards, data is evicted from every
+// subsystem in decreasing order of eviction priority until enough space has
+// been reclaimed.
+func (b *Budget) Check(ctx context.Context) {
+	b.mut.Lock()
+	var (
+		logger   = b.log
+		dataPath = b.dataPath
+		args     = b.args
+		usages   = make([]consumerUsage, 0, len(b.consumers))
+	)
+	for c := range b.consumers {
+		usages = append(usages, consumerUsage{Consumer: c})
+	}
+	b.mut.Unlock()
+
+	if !args.enabled() {
+		return
+	}
+
+	subsystems := make(map[string]SubsystemArguments)
+	for _, s := range args.Subsystems {
+		subsystems[s.Name] = s
+	}
+
+	bySubsystem := make(map[string][]consumerUsage)
+	for i := range usages {
+		size, err := pathSize(usages[i].Path)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to measure disk usage", "consumer", usages[i].ID, "path", usages[i].Path, "err", err)
+		}
+		usages[i].bytes = size
+
+		name := usages[i].Subsystem
+		bySubsystem[name] = append(bySubsystem[name], usages[i])
+		if _, ok := subsystems[name]; !ok {
+			subsystems[name] = SubsystemArguments{Name: name}
+		}
+	}
+
+	b.metrics.usage.Reset()
+	b.metrics.limit.Reset()
+	for name, s := range subsystems {
+		b.metrics.usage.WithLabelValues(name).Set(float64(sumUsage(bySubsystem[name])))
+		if s.MaxSize > 0 {
+			b.metrics.limit.WithLabelValues(name).Set(float64(s.MaxSize))
+		}
+	}
+
+	// Enforce the quotas of subsystems.
+	for name, s := range subsystems {
+		usage := sumUsage(bySubsystem[name])
+		if s.MaxSize <= 0 || usage <= int64(s.MaxSize) {
+			continue
+		}
+		excess := usage - int64(s.MaxSize)
+		level.Warn(logger).Log("msg", "subsystem exceeds its disk budget, evicting data", "subsystem", name, "usage", usage, "max_size", int64(s.MaxSize))
+		if freed := b.evict(ctx, logger, name, bySubsystem[name], excess); freed < excess {
+			level.Warn(logger).Log("msg", "could not reclaim enough disk space for subsystem", "subsystem", name, "excess", excess, "freed", freed)
+		}
+	}
+
+	if args.MaxSize <= 0 {
+		return
+	}
+
+	// Enforce the quota of the data directory.
+	var total int64
+	if dataPath != "" {
+		size, err := pathSize(dataPath)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to measure disk usage", "path", dataPath, "err", err)
+		}
+		total = size
+	} else {
+		for _, u := range usages {
+			size, _ := pathSize(u.Path)
+			total += size
+		}
+	}
+	b.metrics.totalUsage.Set(float64(total))
+	b.metrics.totalLimit.Set(float64(args.MaxSize))
+
+	if total <= int64(args.MaxSize) {
+		return
+	}
+	excess := total - int64(args.MaxSize)
+	level.Warn(logger).Log("msg", "data directory exceeds its disk budget, evicting data", "usage", total, "max_size", int64(args.MaxSize))
+
+	order := make([]SubsystemArguments, 0, len(subsystems))
+	for _, s := range subsystems {
+		order = append(order, s)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].EvictionPriority != order[j].EvictionPriority {
+			return order[i].EvictionPriority > order[j].EvictionPriority
+		}
+		return order[i].Name < order[j].Name
+	})
+
+	for _, s := range order {
+		if excess <= 0 {
+			return
+		}
+
+		// Usage was possibly reduced by enforcing subsystem quotas; measure it
+		// again before evicting.
+		consumers := bySubsystem[s.Name]
+		for i := range consumers {
+			consumers[i].bytes, _ = pathSize(consumers[i].Path)
+		}
+		excess -= b.evict(ctx, logger, s.Name, consumers, excess)
+	}
+	if excess > 0 {
+		level.Warn(logger).Log("msg", "could not reclaim enough disk space for the data directory", "excess", excess)
+	}
+}
+
+// evict evicts data from consumers, largest first, until at least excess
+// bytes are freed or every consumer has been evicted from. It returns the
+// number of bytes freed.
+func (b *Budget) evict(ctx context.Context, logger log.Logger, subsystem string, consumers []consumerUsage, excess int64) int64 {
+	sort.Slice(consumers, func(i, j int) bool { return consumers[i].bytes > consumers[j].bytes })
+
+	var freed int64
+	for _, c := range consumers {
+		if freed >= excess {
+			break
+		} else if c.Evict == nil {
+			continue
+		}
+
+		b.metrics.evictions.WithLabelValues(subsystem).Inc()
+		if err := c.Evict(ctx); err != nil {
+			b.metrics.evictionFailures.WithLabelValues(subsystem).Inc()
+			level.Error(logger).Log("msg", "failed to evict data", "subsystem", subsystem, "consumer", c.ID, "err", err)
+			continue
+		}
+
+		after, err := pathSize(c.Path)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to measure disk usage", "consumer", c.ID, "path", c.Path, "err", err)
+			continue
+		}
+		if after < c.bytes {
+			freed += c.bytes - after
+			b.metrics.evictedBytes.WithLabelValues(subsystem).Add(float64(c.bytes - after))
+		}
+		level.Info(logger).Log("msg", "evicted data", "subsystem", subsystem, "consumer", c.ID, "before", c.bytes, "after", after)
+	}
+	return freed
+}
+
+func sumUsage(usages []consumerUsage) int64 {
+	var sum int64
+	for _, u := range usages {
+		sum += u.bytes
+	}
+	return sum
+}
+
+// pathSize returns the total size of the files at path, which may be a file
+// or a directory. Paths which don't exist have a size of 0.
+func pathSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			// Files can be removed while walking.
+			return nil
+		} else if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+type metrics struct {
+	usage            *prometheus.GaugeVec
+	limit            *prometheus.GaugeVec
+	totalUsage       prometheus.Gauge
+	totalLimit       prometheus.Gauge
+	evictions        *prometheus.CounterVec
+	evictionFailures *prometheus.CounterVec
+	evictedBytes     *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	f := promauto.With(reg)
+	return &metrics{
+		usage: f.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_disk_budget_usage_bytes",
+			Help: "Disk space used by the consumers of each subsystem.",
+		}, []string{"subsystem"}),
+		limit: f.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_disk_budget_limit_bytes",
+			Help: "Disk space quota of each subsystem.",
+		}, []string{"subsystem"}),
+		totalUsage: f.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_disk_budget_data_dir_usage_bytes",
+			Help: "Disk space used by the data directory.",
+		}),
+		totalLimit: f.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_disk_budget_data_dir_limit_bytes",
+			Help: "Disk space quota of the data directory.",
+		}),
+		evictions: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_disk_budget_evictions_total",
+			Help: "Total number of evictions of data, by subsystem.",
+		}, []string{"subsystem"}),
+		evictionFailures: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_disk_budget_eviction_failures_total",
+			Help: "Total number of failed evictions of data, by subsystem.",
+		}, []string{"subsystem"}),
+		evictedBytes: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_disk_budget_evicted_bytes_total",
+			Help: "Total disk space reclaimed by evictions, by subsystem.",
+		}, []string{"subsystem"}),
+	}
+}
@@ -0,0 +1,147 @@
+package diskbudget
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		max_size = "10GiB"
+
+		subsystem "wal" {
+			max_size          = "8GiB"
+			eviction_priority = 10
+		}
+	`), &args))
+	require.Equal(t, DefaultArguments.CheckInterval, args.CheckInterval)
+	require.Equal(t, []SubsystemArguments{{Name: "wal", MaxSize: 8 << 30, EvictionPriority: 10}}, args.Subsystems)
+	require.True(t, args.enabled())
+
+	err := river.Unmarshal([]byte(`
+		subsystem "wal" {}
+		subsystem "wal" {}
+	`), &args)
+	require.EqualError(t, err, `subsystem "wal" is configured more than once`)
+
+	require.Error(t, river.Unmarshal([]byte(`check_interval = "0s"`), &args))
+}
+
+// fakeConsumer is a directory whose files are removed on eviction.
+type fakeConsumer struct {
+	dir     string
+	evicted int
+}
+
+func newFakeConsumer(t *testing.T, root, name string, size int) *fakeConsumer {
+	dir := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data"), make([]byte, size), 0o644))
+	return &fakeConsumer{dir: dir}
+}
+
+func (c *fakeConsumer) evict(context.Context) error {
+	c.evicted++
+	return os.Remove(filepath.Join(c.dir, "data"))
+}
+
+func TestBudget_SubsystemQuota(t *testing.T) {
+	var (
+		root = t.TempDir()
+		reg  = prometheus.NewRegistry()
+		b    = NewBudget(reg)
+
+		small = newFakeConsumer(t, root, "small", 100)
+		large = newFakeConsumer(t, root, "large", 300)
+		other = newFakeConsumer(t, root, "other", 1000)
+	)
+	b.Register(Consumer{ID: "small", Subsystem: SubsystemWAL, Path: small.dir, Evict: small.evict})
+	b.Register(Consumer{ID: "large", Subsystem: SubsystemWAL, Path: large.dir, Evict: large.evict})
+	b.Register(Consumer{ID: "other", Subsystem: SubsystemPositions, Path: other.dir, Evict: other.evict})
+
+	args := DefaultArguments
+	args.Subsystems = []SubsystemArguments{{Name: SubsystemWAL, MaxSize: 250}}
+	b.Update(log.NewNopLogger(), root, args)
+	b.Check(context.Background())
+
+	// Evicting the largest consumer is enough to get back within the quota.
+	require.Equal(t, 0, small.evicted)
+	require.Equal(t, 1, large.evicted)
+	require.Equal(t, 0, other.evicted)
+
+	require.Equal(t, 400.0, testutil.ToFloat64(b.metrics.usage.WithLabelValues(SubsystemWAL)))
+	require.Equal(t, 250.0, testutil.ToFloat64(b.metrics.limit.WithLabelValues(SubsystemWAL)))
+	require.Equal(t, 300.0, testutil.ToFloat64(b.metrics.evictedBytes.WithLabelValues(SubsystemWAL)))
+
+	// Within the quota, nothing is evicted.
+	b.Check(context.Background())
+	require.Equal(t, 1, large.evicted)
+	require.Equal(t, 0, small.evicted)
+}
+
+func TestBudget_DataDirQuota(t *testing.T) {
+	var (
+		root = t.TempDir()
+		b    = NewBudget(prometheus.NewRegistry())
+
+		wal       = newFakeConsumer(t, root, "wal", 500)
+		positions = newFakeConsumer(t, root, "positions", 500)
+	)
+	// Files not registered by any consumer count towards the data directory.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "unknown"), make([]byte, 200), 0o644))
+
+	b.Register(Consumer{ID: "wal", Subsystem: SubsystemWAL, Path: wal.dir, Evict: wal.evict})
+	unregister := b.Register(Consumer{ID: "positions", Subsystem: SubsystemPositions, Path: positions.dir, Evict: positions.evict})
+
+	args := DefaultArguments
+	args.MaxSize = 1000
+	args.Subsystems = []SubsystemArguments{
+		{Name: SubsystemWAL, EvictionPriority: 1},
+		{Name: SubsystemPositions, EvictionPriority: 2},
+	}
+	b.Update(log.NewNopLogger(), root, args)
+	b.Check(context.Background())
+
+	// Subsystems with a higher eviction priority are evicted from first.
+	require.Equal(t, 1, positions.evicted)
+	require.Equal(t, 0, wal.evicted)
+	require.Equal(t, 1200.0, testutil.ToFloat64(b.metrics.totalUsage))
+
+	// Unregistered consumers are never evicted from.
+	unregister()
+	require.NoError(t, os.WriteFile(filepath.Join(positions.dir, "data"), make([]byte, 500), 0o644))
+	b.Check(context.Background())
+	require.Equal(t, 1, positions.evicted)
+	require.Equal(t, 1, wal.evicted)
+}
+
+func TestBudget_Disabled(t *testing.T) {
+	var (
+		root = t.TempDir()
+		b    = NewBudget(prometheus.NewRegistry())
+		wal  = newFakeConsumer(t, root, "wal", 500)
+	)
+	b.Register(Consumer{ID: "wal", Subsystem: SubsystemWAL, Path: wal.dir, Evict: wal.evict})
+
+	b.Update(log.NewNopLogger(), root, DefaultArguments)
+	b.Check(context.Background())
+	require.Equal(t, 0, wal.evicted)
+}
+
+func TestBudget_Nil(t *testing.T) {
+	var b *Budget
+
+	unregister := b.Register(Consumer{ID: "wal", Subsystem: SubsystemWAL, Path: t.TempDir()})
+	unregister()
+	b.Update(log.NewNopLogger(), t.TempDir(), DefaultArguments)
+	b.Stop()
+}
@@ -0,0 +1,145 @@
+package loki
+
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+// Ack tracks the delivery of a log entry. Sources which need to know when an
+// entry has been delivered, so that they only advance their position after
+// it can no longer be lost, set Entry.Ack to a new Ack.
+//
+// loki.write calls Done once the batch containing an entry has been accepted
+// by Loki or has been permanently dropped. Components which drop entries or
+// which don't forward entries any further also call Done. Components which
+// forward an entry to n receivers call Add(n-1) before forwarding it, so that
+// the Ack is done once every copy of the entry has been handled. Entries
+// which are merged into a single entry are tracked with JoinAcks.
+//
+// A nil *Ack is valid and ignores all calls, so components can call Add and
+// Done without checking whether the source asked for acknowledgements.
+type Ack struct {
+	pending atomic.Int64
+	done    func()
+}
+
+// NewAck creates a new Ack for a single copy of an entry. done is called once
+// every copy of the entry has been handled.
+func NewAck(done func()) *Ack {
+	a := &Ack{done: done}
+	a.pending.Store(1)
+	return a
+}
+
+// Add records that the number of copies of the entry in flight changed by n.
+// Calling Add(-1) is equivalent to calling Done, which means Add(n-1) may be
+// used to fan out an entry to n receivers, even if n is 0.
+func (a *Ack) Add(n int) {
+	if a == nil || n == 0 {
+		return
+	}
+	if a.pending.Add(int64(n)) == 0 {
+		a.done()
+	}
+}
+
+// Done records that a copy of the entry has been handled.
+func (a *Ack) Done() {
+	a.Add(-1)
+}
+
+// JoinAcks returns an Ack which marks every non-nil Ack in acks as done once
+// it's done. It's used when multiple entries are merged into a single entry.
+// JoinAcks returns nil if none of acks are non-nil.
+func JoinAcks(acks ...*Ack) *Ack {
+	var joined []*Ack
+	for _, a := range acks {
+		if a != nil {
+			joined = append(joined, a)
+		}
+	}
+	if len(joined) == 0 {
+		return nil
+	}
+
+	return NewAck(func() {
+		for _, a := range joined {
+			a.Done()
+		}
+	})
+}
+
+// AckTracker tracks the delivery of entries read in order from a source, such
+// as the entries of a journal or the messages of a Kafka partition. While
+// entries can be delivered out of order, AckTracker calls its commit function
+// with the position of the newest entry for which it and every entry before
+// it have been delivered. Sources use it to only advance their position past
+// entries which can no longer be lost.
+type AckTracker[T any] struct {
+	commit func(T)
+
+	mut     sync.Mutex
+	first   uint64 // Sequence number of the oldest entry still pending.
+	next    uint64 // Sequence number of the next tracked entry.
+	pending map[uint64]*trackedPosition[T]
+}
+
+type trackedPosition[T any] struct {
+	position T
+	done     bool
+}
+
+// NewAckTracker creates a new AckTracker which calls commit as entries are
+// delivered. commit is never called concurrently.
+func NewAckTracker[T any](commit func(T)) *AckTracker[T] {
+	return &AckTracker[T]{
+		commit:  commit,
+		pending: make(map[uint64]*trackedPosition[T]),
+	}
+}
+
+// Track starts tracking the entry at position and returns the Ack to set on
+// it. Entries must be tracked in the order they are read.
+func (t *AckTracker[T]) Track(position T) *Ack {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	seq := t.next
+	t.next++
+	t.pending[seq] = &trackedPosition[T]{position: position}
+
+	return NewAck(func() { t.ack(seq) })
+}
+
+// Pending returns the number of entries which have been tracked but not
+// committed yet.
+func (t *AckTracker[T]) Pending() int {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return len(t.pending)
+}
+
+func (t *AckTracker[T]) ack(seq uint64) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.pending[seq].done = true
+
+	var (
+		latest    T
+		committed bool
+	)
+	for {
+		p, ok := t.pending[t.first]
+		if !ok || !p.done {
+			break
+		}
+		latest, committed = p.position, true
+		delete(t.pending, t.first)
+		t.first++
+	}
+	if committed {
+		t.commit(latest)
+	}
+}
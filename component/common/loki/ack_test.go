@@ -0,0 +1,76 @@
+package loki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAck(t *testing.T) {
+	var done int
+	ack := NewAck(func() { done++ })
+
+	// Fan out to three receivers.
+	ack.Add(3 - 1)
+	ack.Done()
+	ack.Done()
+	require.Equal(t, 0, done)
+	ack.Done()
+	require.Equal(t, 1, done)
+}
+
+func TestAck_NoReceivers(t *testing.T) {
+	var done int
+	ack := NewAck(func() { done++ })
+
+	ack.Add(0 - 1)
+	require.Equal(t, 1, done)
+}
+
+func TestAck_Nil(t *testing.T) {
+	var ack *Ack
+	require.NotPanics(t, func() {
+		ack.Add(2)
+		ack.Done()
+	})
+}
+
+func TestJoinAcks(t *testing.T) {
+	var done int
+	a, b := NewAck(func() { done++ }), NewAck(func() { done++ })
+
+	joined := JoinAcks(a, nil, b)
+	require.Equal(t, 0, done)
+	joined.Done()
+	require.Equal(t, 2, done)
+
+	require.Nil(t, JoinAcks(nil, nil))
+}
+
+func TestAckTracker(t *testing.T) {
+	var committed []int
+	tracker := NewAckTracker(func(pos int) { committed = append(committed, pos) })
+
+	acks := make([]*Ack, 5)
+	for i := range acks {
+		acks[i] = tracker.Track(i)
+	}
+
+	// Acknowledging entries out of order only commits once every earlier entry
+	// has been acknowledged.
+	acks[1].Done()
+	acks[2].Done()
+	require.Empty(t, committed)
+	require.Equal(t, 5, tracker.Pending())
+
+	acks[0].Done()
+	require.Equal(t, []int{2}, committed)
+	require.Equal(t, 2, tracker.Pending())
+
+	acks[4].Done()
+	require.Equal(t, []int{2}, committed)
+
+	acks[3].Done()
+	require.Equal(t, []int{2, 4}, committed)
+	require.Equal(t, 0, tracker.Pending())
+}
@@ -0,0 +1,331 @@
+// Package deadletter implements dead letter queues for log entries which
+// components permanently fail to process or deliver.
+package deadletter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// forwardQueueSize is the number of entries which can be waiting to be
+// forwarded to receivers before new entries are dropped.
+const forwardQueueSize = 1024
+
+// Arguments configures where a dead letter queue writes log entries.
+type Arguments struct {
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr,optional"`
+	File      *FileArguments      `river:"file,block,optional"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *Arguments) UnmarshalRiver(f func(v interface{}) error) error {
+	*a = Arguments{}
+
+	type arguments Arguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+	if len(a.ForwardTo) == 0 && a.File == nil {
+		return fmt.Errorf("at least one of forward_to or file must be set")
+	}
+	return nil
+}
+
+// FileArguments configures writing log entries to rotated local files.
+type FileArguments struct {
+	Path        string           `river:"path,attr,optional"`
+	MaxFileSize units.Base2Bytes `river:"max_file_size,attr,optional"`
+	MaxFiles    int              `river:"max_files,attr,optional"`
+}
+
+// DefaultFileArguments holds default settings for FileArguments.
+var DefaultFileArguments = FileArguments{
+	MaxFileSize: 10 * units.MiB,
+	MaxFiles:    5,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *FileArguments) UnmarshalRiver(f func(v interface{}) error) error {
+	*a = DefaultFileArguments
+
+	type arguments FileArguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+
+	switch {
+	case a.MaxFileSize <= 0:
+		return fmt.Errorf("max_file_size must be greater than 0")
+	case a.MaxFiles <= 0:
+		return fmt.Errorf("max_files must be greater than 0")
+	}
+	return nil
+}
+
+// Queue writes log entries to the outputs configured in its Arguments.
+//
+// A nil *Queue is valid and ignores all calls, so components can hand
+// entries to their queue without checking whether one is configured.
+type Queue struct {
+	logger   log.Logger
+	metrics  *metrics
+	dataPath string
+
+	mut       sync.Mutex
+	args      *Arguments
+	file      *rotatingFile
+	forwardTo []loki.LogsReceiver
+
+	forward chan loki.Entry
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+// New creates a new Queue. Files are written to a directory in dataPath
+// unless a path is configured. The Queue doesn't write entries anywhere until
+// it's updated with non-nil Arguments.
+func New(logger log.Logger, reg prometheus.Registerer, dataPath string) (*Queue, error) {
+	m, err := newMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		logger:   log.With(logger, "component", "dead_letter_queue"),
+		metrics:  m,
+		dataPath: dataPath,
+		forward:  make(chan loki.Entry, forwardQueueSize),
+		done:     make(chan struct{}),
+	}
+
+	q.wg.Add(1)
+	go q.runForward()
+	return q, nil
+}
+
+// Update updates the outputs of q. Passing nil Arguments disables q.
+func (q *Queue) Update(args *Arguments) error {
+	if q == nil {
+		return nil
+	}
+
+	q.mut.Lock()
+	var prevFile *FileArguments
+	if q.args != nil {
+		prevFile = q.args.File
+	}
+
+	var closeFile *rotatingFile
+	if args == nil || args.File == nil || prevFile == nil || *args.File != *prevFile {
+		var newFile *rotatingFile
+		if args != nil && args.File != nil {
+			path := args.File.Path
+			if path == "" {
+				if q.dataPath == "" {
+					q.mut.Unlock()
+					return fmt.Errorf("dead letter queue file path must be set")
+				}
+				path = filepath.Join(q.dataPath, "dead_letter")
+			}
+			newFile = newRotatingFile(path, int64(args.File.MaxFileSize), args.File.MaxFiles)
+		}
+		closeFile, q.file = q.file, newFile
+	}
+
+	q.args = args
+	q.forwardTo = nil
+	if args != nil {
+		q.forwardTo = args.ForwardTo
+	}
+	q.mut.Unlock()
+
+	q.closeFile(closeFile)
+	return nil
+}
+
+// closeFile closes f, which may be nil. It must be called without holding
+// q.mut.
+func (q *Queue) closeFile(f *rotatingFile) {
+	if f == nil {
+		return
+	}
+	if err := f.Close(); err != nil {
+		level.Warn(q.logger).Log("msg", "failed to close dead letter file", "err", err)
+	}
+}
+
+// recordVersion is the version of the format of records written to dead
+// letter files. It must be incremented whenever fields of record are removed
+// or change meaning, so that tools reading the files can tell formats apart.
+const recordVersion = 1
+
+// record is a log entry written to dead letter files as a line of JSON.
+type record struct {
+	Version   int               `json:"version"`
+	Timestamp time.Time         `json:"timestamp"`
+	Labels    map[string]string `json:"labels"`
+	Line      string            `json:"line"`
+	Reason    string            `json:"reason"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Send writes entries to the outputs of q, along with the reason they were
+// rejected and an optional error. Send doesn't acknowledge entries; the
+// entries forwarded to receivers are copies without an Ack.
+func (q *Queue) Send(entries []loki.Entry, reason string, err error) {
+	if q == nil || len(entries) == 0 {
+		return
+	}
+
+	q.mut.Lock()
+	enabled, file, forward := q.args != nil, q.file, len(q.forwardTo) > 0
+	q.mut.Unlock()
+
+	if !enabled {
+		return
+	}
+	q.metrics.entries.WithLabelValues(reason).Add(float64(len(entries)))
+
+	// Files are written without holding q.mut so that slow disks don't block
+	// updates or forwarding. The file serializes concurrent writes itself.
+	if file != nil {
+		for _, e := range entries {
+			r := record{
+				Version:   recordVersion,
+				Timestamp: e.Timestamp,
+				Labels:    make(map[string]string, len(e.Labels)),
+				Line:      e.Line,
+				Reason:    reason,
+			}
+			for name, value := range e.Labels {
+				r.Labels[string(name)] = string(value)
+			}
+			if err != nil {
+				r.Error = err.Error()
+			}
+
+			buf, writeErr := json.Marshal(r)
+			if writeErr == nil {
+				writeErr = file.Write(append(buf, '\n'))
+			}
+			if writeErr != nil {
+				level.Warn(q.logger).Log("msg", "failed to write entry to dead letter file", "err", writeErr)
+				q.metrics.failed.WithLabelValues("file").Inc()
+			}
+		}
+	}
+
+	if forward {
+		for _, e := range entries {
+			e.Ack = nil
+			select {
+			case q.forward <- e:
+			default:
+				q.metrics.failed.WithLabelValues("queue_full").Inc()
+			}
+		}
+	}
+}
+
+// runForward forwards queued entries to the receivers of q. Receivers are
+// read when an entry is forwarded, so that entries queued before an update
+// go to the new receivers.
+func (q *Queue) runForward() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.done:
+			return
+		case e := <-q.forward:
+			q.mut.Lock()
+			forwardTo := q.forwardTo
+			q.mut.Unlock()
+
+			for _, r := range forwardTo {
+				select {
+				case <-q.done:
+					return
+				case r <- e:
+				}
+			}
+		}
+	}
+}
+
+// Stop stops forwarding entries and closes the files of q. Entries which
+// haven't been forwarded yet are dropped.
+func (q *Queue) Stop() {
+	if q == nil {
+		return
+	}
+	q.once.Do(func() { close(q.done) })
+	q.wg.Wait()
+
+	q.mut.Lock()
+	file := q.file
+	q.file, q.args = nil, nil
+	q.mut.Unlock()
+
+	q.closeFile(file)
+}
+
+type metrics struct {
+	entries *prometheus.CounterVec
+	failed  *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_dead_letter_entries_total",
+			Help: "Total number of log entries sent to the dead letter queue, by reason.",
+		}, []string{"reason"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_dead_letter_failed_entries_total",
+			Help: "Total number of log entries the dead letter queue failed to write or forward.",
+		}, []string{"reason"}),
+	}
+
+	if reg == nil {
+		return m, nil
+	}
+
+	// Existing metrics are shared so that several queues can report to the
+	// same registry.
+	var err error
+	if m.entries, err = registerOrGet(reg, m.entries); err != nil {
+		return nil, err
+	}
+	if m.failed, err = registerOrGet(reg, m.failed); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// registerOrGet registers c with reg, returning the already registered
+// collector if an identical one was registered before.
+func registerOrGet(reg prometheus.Registerer, c *prometheus.CounterVec) (*prometheus.CounterVec, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(*prometheus.CounterVec); ok {
+			return existing, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,178 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_File(t *testing.T) {
+	dataPath := t.TempDir()
+	q, err := New(log.NewNopLogger(), prometheus.NewRegistry(), dataPath)
+	require.NoError(t, err)
+	defer q.Stop()
+	require.NoError(t, q.Update(&Arguments{File: &FileArguments{MaxFileSize: 1000, MaxFiles: 5}}))
+
+	ts := time.Unix(1, 0).UTC()
+	q.Send([]loki.Entry{{
+		Labels: model.LabelSet{"job": "app"},
+		Entry:  logproto.Entry{Timestamp: ts, Line: "rejected line"},
+	}}, "rejected", errors.New("server returned HTTP status 400"))
+
+	buf, err := os.ReadFile(filepath.Join(dataPath, "dead_letter", fileName))
+	require.NoError(t, err)
+
+	var r record
+	require.NoError(t, json.Unmarshal(buf, &r))
+	require.Equal(t, record{
+		Version:   recordVersion,
+		Timestamp: ts,
+		Labels:    map[string]string{"job": "app"},
+		Line:      "rejected line",
+		Reason:    "rejected",
+		Error:     "server returned HTTP status 400",
+	}, r)
+}
+
+func TestNew_Metrics(t *testing.T) {
+	// Queues share the metrics of a registry.
+	reg := prometheus.NewRegistry()
+	for i := 0; i < 2; i++ {
+		q, err := New(log.NewNopLogger(), reg, "")
+		require.NoError(t, err)
+		q.Stop()
+	}
+
+	// Conflicting metrics are reported as an error rather than a panic.
+	reg = prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_dead_letter_entries_total",
+		Help: "Conflicting metric.",
+	}))
+	_, err := New(log.NewNopLogger(), reg, "")
+	require.Error(t, err)
+}
+
+func TestQueue_FileRotation(t *testing.T) {
+	dir := t.TempDir()
+	q, err := New(log.NewNopLogger(), prometheus.NewRegistry(), "")
+	require.NoError(t, err)
+	defer q.Stop()
+	require.NoError(t, q.Update(&Arguments{File: &FileArguments{Path: dir, MaxFileSize: 300, MaxFiles: 3}}))
+
+	// Each record is about 130 bytes, so every file holds two records.
+	for i := 0; i < 10; i++ {
+		q.Send([]loki.Entry{{
+			Labels: model.LabelSet{"job": "app"},
+			Entry:  logproto.Entry{Timestamp: time.Unix(int64(i), 0).UTC(), Line: strings.Repeat("x", 50)},
+		}}, "rejected", nil)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, fileName+"*"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		filepath.Join(dir, fileName),
+		filepath.Join(dir, fileName+".1"),
+		filepath.Join(dir, fileName+".2"),
+	}, files)
+
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		require.NoError(t, err)
+		require.LessOrEqual(t, fi.Size(), int64(300))
+	}
+
+	// The current file holds the most recent records.
+	buf, err := os.ReadFile(filepath.Join(dir, fileName))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	var r record
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &r))
+	require.Equal(t, time.Unix(9, 0).UTC(), r.Timestamp)
+}
+
+func TestQueue_Forward(t *testing.T) {
+	q, err := New(log.NewNopLogger(), prometheus.NewRegistry(), "")
+	require.NoError(t, err)
+	defer q.Stop()
+
+	ch := make(loki.LogsReceiver, 1)
+	require.NoError(t, q.Update(&Arguments{ForwardTo: []loki.LogsReceiver{ch}}))
+
+	q.Send([]loki.Entry{{
+		Labels: model.LabelSet{"job": "app"},
+		Entry:  logproto.Entry{Timestamp: time.Unix(1, 0), Line: "rejected line"},
+		Ack:    loki.NewAck(func() {}),
+	}}, "rejected", nil)
+
+	select {
+	case e := <-ch:
+		require.Equal(t, "rejected line", e.Line)
+		require.Nil(t, e.Ack)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for forwarded entry")
+	}
+}
+
+func TestQueue_Disabled(t *testing.T) {
+	var nilQueue *Queue
+	nilQueue.Send([]loki.Entry{{Entry: logproto.Entry{Line: "line"}}}, "rejected", nil)
+	nilQueue.Stop()
+
+	dir := t.TempDir()
+	q, err := New(log.NewNopLogger(), prometheus.NewRegistry(), dir)
+	require.NoError(t, err)
+	defer q.Stop()
+	require.NoError(t, q.Update(nil))
+	q.Send([]loki.Entry{{Entry: logproto.Entry{Line: "line"}}}, "rejected", nil)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := map[string]struct {
+		cfg         string
+		expected    Arguments
+		expectedErr string
+	}{
+		"file defaults": {
+			cfg:      `file {}`,
+			expected: Arguments{File: &DefaultFileArguments},
+		},
+		"no outputs": {
+			cfg:         ``,
+			expectedErr: "at least one of forward_to or file must be set",
+		},
+		"invalid max_files": {
+			cfg:         `file { max_files = 0 }`,
+			expectedErr: "max_files must be greater than 0",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, args)
+		})
+	}
+}
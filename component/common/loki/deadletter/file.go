@@ -0,0 +1,122 @@
+package deadletter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileName is the name of the file which dead letter records are appended
+// to. Rotated files are suffixed with their generation, starting at 1 for
+// the most recent one.
+const fileName = "dead_letter.log"
+
+// errFileClosed is returned when writing to a closed rotatingFile.
+var errFileClosed = errors.New("dead letter file is closed")
+
+// rotatingFile appends records to a file in a directory, rotating it once it
+// reaches a maximum size and keeping a maximum number of files. It's safe for
+// concurrent use.
+type rotatingFile struct {
+	dir      string
+	maxSize  int64
+	maxFiles int
+
+	mut    sync.Mutex
+	f      *os.File
+	size   int64
+	closed bool
+}
+
+func newRotatingFile(dir string, maxSize int64, maxFiles int) *rotatingFile {
+	return &rotatingFile{dir: dir, maxSize: maxSize, maxFiles: maxFiles}
+}
+
+// Write appends buf to the file, rotating it first if buf would make it
+// larger than the maximum size. Records larger than the maximum size are
+// written to a file of their own.
+func (r *rotatingFile) Write(buf []byte) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.closed {
+		return errFileClosed
+	}
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if r.size > 0 && r.size+int64(len(buf)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.f.Write(buf)
+	r.size += int64(n)
+	return err
+}
+
+func (r *rotatingFile) path(generation int) string {
+	if generation == 0 {
+		return filepath.Join(r.dir, fileName)
+	}
+	return filepath.Join(r.dir, fmt.Sprintf("%s.%d", fileName, generation))
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(r.dir, 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path(0), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f, r.size = f, fi.Size()
+	return nil
+}
+
+// rotate renames the current file and the rotated files to the next
+// generation, removing the oldest file, and opens a new file.
+func (r *rotatingFile) rotate() error {
+	if err := r.closeCurrent(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(r.path(r.maxFiles - 1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for gen := r.maxFiles - 2; gen >= 0; gen-- {
+		if err := os.Rename(r.path(gen), r.path(gen+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return r.open()
+}
+
+// Close closes the current file. Writes fail after r is closed.
+func (r *rotatingFile) Close() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.closed = true
+	return r.closeCurrent()
+}
+
+// closeCurrent closes the current file. r.mut must be held when calling.
+func (r *rotatingFile) closeCurrent() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f, r.size = nil, 0
+	return err
+}
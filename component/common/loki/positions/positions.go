@@ -16,6 +16,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/diskbudget"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -31,6 +32,9 @@ type Config struct {
 	PositionsFile     string        `mapstructure:"filename" yaml:"filename"`
 	IgnoreInvalidYaml bool          `mapstructure:"ignore_invalid_yaml" yaml:"ignore_invalid_yaml"`
 	ReadOnly          bool          `mapstructure:"-" yaml:"-"`
+
+	// DiskBudget the positions file counts towards. May be nil.
+	DiskBudget *diskbudget.Budget `mapstructure:"-" yaml:"-"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -48,12 +52,13 @@ func (cfg *Config) RegisterFlags(flags *flag.FlagSet) {
 
 // Positions tracks how far through each file we've read.
 type positions struct {
-	logger    log.Logger
-	cfg       Config
-	mtx       sync.Mutex
-	positions map[Entry]string
-	quit      chan struct{}
-	done      chan struct{}
+	logger     log.Logger
+	cfg        Config
+	mtx        sync.Mutex
+	positions  map[Entry]string
+	unregister func()
+	quit       chan struct{}
+	done       chan struct{}
 }
 
 // Entry describes a positions file entry consisting of an absolute file path and
@@ -110,6 +115,11 @@ func New(logger log.Logger, cfg Config) (Positions, error) {
 		quit:      make(chan struct{}),
 		done:      make(chan struct{}),
 	}
+	p.unregister = cfg.DiskBudget.Register(diskbudget.Consumer{
+		ID:        cfg.PositionsFile,
+		Subsystem: diskbudget.SubsystemPositions,
+		Path:      cfg.PositionsFile,
+	})
 
 	go p.run()
 	return p, nil
@@ -118,6 +128,7 @@ func New(logger log.Logger, cfg Config) (Positions, error) {
 func (p *positions) Stop() {
 	close(p.quit)
 	<-p.done
+	p.unregister()
 }
 
 func (p *positions) PutString(path, labels string, pos string) {
@@ -31,6 +31,10 @@ type LogsReceiver chan Entry
 type Entry struct {
 	Labels model.LabelSet
 	logproto.Entry
+
+	// Ack, if non-nil, tracks the delivery of the entry. See Ack for how
+	// components must handle it.
+	Ack *Ack
 }
 
 // InstrumentedEntryHandler ...
@@ -142,8 +146,23 @@ func NewEntryMutatorHandler(next EntryHandler, f EntryMutatorFunc) EntryHandler
 func AddLabelsMiddleware(additionalLabels model.LabelSet) EntryMiddleware {
 	return EntryMiddlewareFunc(func(eh EntryHandler) EntryHandler {
 		return NewEntryMutatorHandler(eh, func(e Entry) Entry {
-			e.Labels = additionalLabels.Merge(e.Labels)
+			e.Labels = mergeLabels(additionalLabels, e.Labels)
 			return e
 		})
 	})
 }
+
+// mergeLabels returns a new label set with the labels of both ls and other.
+// Labels from other take precedence. Unlike model.LabelSet.Merge, the
+// resulting map is allocated with enough capacity for both sets, avoiding
+// growing it while merging.
+func mergeLabels(ls, other model.LabelSet) model.LabelSet {
+	res := make(model.LabelSet, len(ls)+len(other))
+	for k, v := range ls {
+		res[k] = v
+	}
+	for k, v := range other {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,83 @@
+package loki
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddLabelsMiddleware(t *testing.T) {
+	ch := make(chan Entry, 1)
+	handler := AddLabelsMiddleware(model.LabelSet{"foo": "bar", "job": "static"}).Wrap(NewEntryHandler(ch, func() {}))
+	defer handler.Stop()
+
+	entryLabels := model.LabelSet{"job": "dynamic"}
+	handler.Chan() <- Entry{Labels: entryLabels, Entry: logproto.Entry{Line: "line"}}
+
+	e := <-ch
+	require.Equal(t, model.LabelSet{"foo": "bar", "job": "dynamic"}, e.Labels)
+	require.Equal(t, model.LabelSet{"job": "dynamic"}, entryLabels, "labels of the incoming entry must not be modified")
+}
+
+func BenchmarkAddLabelsMiddleware(b *testing.B) {
+	for _, numLabels := range []int{1, 5, 10} {
+		b.Run(fmt.Sprintf("%d labels", numLabels), func(b *testing.B) {
+			labels := make(model.LabelSet, numLabels)
+			for i := 0; i < numLabels; i++ {
+				labels[model.LabelName(fmt.Sprintf("label_%d", i))] = "value"
+			}
+
+			ch := make(chan Entry)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for range ch {
+				}
+			}()
+
+			handler := AddLabelsMiddleware(labels).Wrap(NewEntryHandler(ch, func() {}))
+			entries := handler.Chan()
+			now := time.Now()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				entries <- Entry{
+					Labels: model.LabelSet{"filename": "/var/log/app.log"},
+					Entry:  logproto.Entry{Timestamp: now, Line: "line"},
+				}
+			}
+			handler.Stop()
+			close(ch)
+			<-done
+		})
+	}
+}
+
+// mergeSink prevents the compiler from optimizing away merges in benchmarks.
+var mergeSink model.LabelSet
+
+func BenchmarkMergeLabels(b *testing.B) {
+	ls := model.LabelSet{
+		"job": "app", "namespace": "default", "pod": "app-0", "container": "app",
+		"instance": "node-0", "cluster": "prod", "region": "eu",
+	}
+	other := model.LabelSet{"filename": "/var/log/app.log", "stream": "stdout"}
+
+	b.Run("model.LabelSet.Merge", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			mergeSink = ls.Merge(other)
+		}
+	})
+	b.Run("mergeLabels", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			mergeSink = mergeLabels(ls, other)
+		}
+	})
+}
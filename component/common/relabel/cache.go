@@ -0,0 +1,172 @@
+package relabel
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// Cache is a bounded LRU cache which memoizes the result of applying a set of
+// relabeling rules to a label set. Entries are keyed on the fingerprint of the
+// input label set; hash collisions are resolved by comparing the full input
+// label set.
+//
+// Relabeled label sets returned from the cache are shared between callers and
+// must be treated as immutable.
+//
+// The cache does not track which relabeling rules produced a cached value;
+// callers must call Purge whenever the rules change.
+type Cache struct {
+	lru     *lru.Cache
+	metrics *cacheMetrics
+}
+
+type cacheItem struct {
+	original  model.LabelSet
+	relabeled model.LabelSet
+}
+
+// NewCache creates a new Cache which holds at most size entries. Metrics for
+// the cache are registered against reg (if non-nil), using namespace as the
+// prefix for metric names.
+func NewCache(size int, namespace string, reg prometheus.Registerer) (*Cache, error) {
+	l, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{
+		lru:     l,
+		metrics: newCacheMetrics(namespace, reg),
+	}, nil
+}
+
+// Relabel applies rcs to lset, returning a cached result if lset has been
+// relabeled before. An empty label set is returned if the relabeling rules
+// dropped lset.
+func (c *Cache) Relabel(lset model.LabelSet, rcs []*relabel.Config) model.LabelSet {
+	fp := lset.Fingerprint()
+
+	relabeled, found := c.Get(fp, lset.Equal)
+	if found {
+		return relabeled
+	}
+
+	relabeled = Process(lset, rcs)
+	c.Add(fp, lset, relabeled)
+	return relabeled
+}
+
+// Get returns the cached relabeled label set for the input with fingerprint
+// fp. equal is invoked on the original input of each entry stored under fp
+// until it returns true, so that hash collisions do not return the wrong
+// result.
+//
+// Get updates the hit and miss metrics of the cache.
+func (c *Cache) Get(fp model.Fingerprint, equal func(original model.LabelSet) bool) (model.LabelSet, bool) {
+	val, found := c.lru.Get(fp)
+	if found {
+		for _, ci := range val.([]cacheItem) {
+			if equal(ci.original) {
+				c.metrics.hits.Inc()
+				return ci.relabeled, true
+			}
+		}
+	}
+
+	c.metrics.misses.Inc()
+	return nil, false
+}
+
+// Add stores the relabeled result of original under the fingerprint fp. If
+// other entries exist for fp (i.e., a hash collision), the new entry is
+// appended alongside them.
+func (c *Cache) Add(fp model.Fingerprint, original, relabeled model.LabelSet) {
+	item := cacheItem{original: original, relabeled: relabeled}
+
+	var items []cacheItem
+	if val, found := c.lru.Peek(fp); found {
+		items = append(val.([]cacheItem), item)
+	} else {
+		items = []cacheItem{item}
+	}
+
+	if evicted := c.lru.Add(fp, items); evicted {
+		c.metrics.evictions.Inc()
+	}
+	c.metrics.size.Set(float64(c.lru.Len()))
+}
+
+// Purge removes all entries from the cache.
+func (c *Cache) Purge() {
+	c.lru.Purge()
+	c.metrics.size.Set(0)
+}
+
+// Resize changes the maximum number of entries held by the cache, returning
+// the number of entries evicted by the resize.
+func (c *Cache) Resize(size int) int {
+	evicted := c.lru.Resize(size)
+	c.metrics.evictions.Add(float64(evicted))
+	c.metrics.size.Set(float64(c.lru.Len()))
+	return evicted
+}
+
+// Len returns the number of fingerprints stored in the cache.
+func (c *Cache) Len() int {
+	return c.lru.Len()
+}
+
+// Process applies rcs to lset without consulting a cache. An empty label set
+// is returned if the relabeling rules dropped lset.
+func Process(lset model.LabelSet, rcs []*relabel.Config) model.LabelSet {
+	lbls := make(labels.Labels, 0, len(lset))
+	for k, v := range lset {
+		lbls = append(lbls, labels.Label{Name: string(k), Value: string(v)})
+	}
+
+	lbls, keep := relabel.Process(lbls, rcs...)
+	if !keep {
+		return model.LabelSet{}
+	}
+
+	relabeled := make(model.LabelSet, len(lbls))
+	for _, l := range lbls {
+		relabeled[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return relabeled
+}
+
+type cacheMetrics struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+	size      prometheus.Gauge
+}
+
+func newCacheMetrics(namespace string, reg prometheus.Registerer) *cacheMetrics {
+	m := &cacheMetrics{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: namespace + "_cache_hits",
+			Help: "Total number of cache hits",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: namespace + "_cache_misses",
+			Help: "Total number of cache misses",
+		}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: namespace + "_cache_evictions",
+			Help: "Total number of entries evicted from the relabel cache",
+		}),
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: namespace + "_cache_size",
+			Help: "Total size of relabel cache",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.hits, m.misses, m.evictions, m.size)
+	}
+	return m
+}
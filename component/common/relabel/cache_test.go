@@ -0,0 +1,133 @@
+package relabel
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+)
+
+var testRules = []*relabel.Config{
+	{
+		SourceLabels: model.LabelNames{"name"},
+		Regex:        relabel.MustNewRegexp("(.+)"),
+		Separator:    ";",
+		Action:       relabel.Replace,
+		TargetLabel:  "env",
+		Replacement:  "staging",
+	},
+	{
+		SourceLabels: model.LabelNames{"name"},
+		Regex:        relabel.MustNewRegexp("dropme"),
+		Separator:    ";",
+		Action:       relabel.Drop,
+	},
+}
+
+func TestCache(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := NewCache(4, "test_relabel", reg)
+	require.NoError(t, err)
+
+	foo := model.LabelSet{"name": "foo"}
+	require.Equal(t, model.LabelSet{"name": "foo", "env": "staging"}, c.Relabel(foo, testRules))
+	require.Equal(t, model.LabelSet{"name": "foo", "env": "staging"}, c.Relabel(foo, testRules))
+	require.Equal(t, 1, c.Len())
+
+	// Dropped label sets are cached as empty label sets.
+	dropped := model.LabelSet{"name": "dropme"}
+	require.Empty(t, c.Relabel(dropped, testRules))
+	cached, ok := c.Get(dropped.Fingerprint(), dropped.Equal)
+	require.True(t, ok)
+	require.Empty(t, cached)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.hits))
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.misses))
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.size))
+
+	c.Purge()
+	require.Equal(t, 0, c.Len())
+	require.Equal(t, 0.0, testutil.ToFloat64(c.metrics.size))
+}
+
+func TestCache_Collisions(t *testing.T) {
+	c, err := NewCache(4, "test_relabel", nil)
+	require.NoError(t, err)
+
+	// These LabelSets are known to collide (string: 8746e5b6c5f0fb60)
+	// https://github.com/pstibrany/fnv-1a-64bit-collisions
+	ls1 := model.LabelSet{"A": "K6sjsNNczPl"}
+	ls2 := model.LabelSet{"A": "cswpLMIZpwt"}
+	require.Equal(t, ls1.Fingerprint(), ls2.Fingerprint(), "expected labelset fingerprints to collide; have we changed the hashing algorithm?")
+
+	rules := []*relabel.Config{{
+		SourceLabels: model.LabelNames{"A"},
+		Regex:        relabel.MustNewRegexp("(.+)"),
+		Separator:    ";",
+		Action:       relabel.Replace,
+		TargetLabel:  "copy",
+		Replacement:  "$1",
+	}}
+
+	require.Equal(t, model.LabelSet{"A": "K6sjsNNczPl", "copy": "K6sjsNNczPl"}, c.Relabel(ls1, rules))
+	require.Equal(t, model.LabelSet{"A": "cswpLMIZpwt", "copy": "cswpLMIZpwt"}, c.Relabel(ls2, rules))
+
+	// Both label sets are stored under the same fingerprint.
+	require.Equal(t, 1, c.Len())
+	require.Equal(t, model.LabelSet{"A": "K6sjsNNczPl", "copy": "K6sjsNNczPl"}, c.Relabel(ls1, rules))
+	require.Equal(t, model.LabelSet{"A": "cswpLMIZpwt", "copy": "cswpLMIZpwt"}, c.Relabel(ls2, rules))
+}
+
+func TestCache_Eviction(t *testing.T) {
+	c, err := NewCache(2, "test_relabel", nil)
+	require.NoError(t, err)
+
+	lsets := []model.LabelSet{{"name": "a"}, {"name": "b"}, {"name": "c"}}
+	for _, ls := range lsets {
+		c.Relabel(ls, testRules)
+	}
+	require.Equal(t, 2, c.Len())
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.evictions))
+
+	// The least recently used label set should have been evicted.
+	_, ok := c.Get(lsets[0].Fingerprint(), lsets[0].Equal)
+	require.False(t, ok)
+
+	require.Equal(t, 1, c.Resize(1))
+	require.Equal(t, 1, c.Len())
+}
+
+func BenchmarkRelabel(b *testing.B) {
+	// Simulate a stream of entries coming from a fixed set of streams, as is
+	// typical for log pipelines.
+	lsets := make([]model.LabelSet, 100)
+	for i := range lsets {
+		lsets[i] = model.LabelSet{
+			"name":      model.LabelValue(fmt.Sprintf("app-%d", i)),
+			"namespace": "default",
+			"filename":  model.LabelValue(fmt.Sprintf("/var/log/pods/app-%d/0.log", i)),
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Process(lsets[i%len(lsets)], testRules)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		c, err := NewCache(len(lsets), "bench_relabel", nil)
+		require.NoError(b, err)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = c.Relabel(lsets[i%len(lsets)], testRules)
+		}
+	})
+}
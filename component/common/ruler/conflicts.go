@@ -0,0 +1,56 @@
+package ruler
+
+import (
+	"sync"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+)
+
+// appliedRuleGroups tracks the rule groups the component last wrote to the
+// ruler. It's used to detect conflicts, where a managed rule group was changed
+// by something other than the component, such as a person using mimirtool or
+// lokitool, or another deployment using the same namespace prefix.
+//
+// Rule groups are only tracked in memory, so conflicts made while the
+// component isn't running can't be detected.
+type appliedRuleGroups struct {
+	mut    sync.Mutex
+	groups map[appliedRuleGroupKey]rulefmt.RuleGroup
+}
+
+type appliedRuleGroupKey struct {
+	tenant, namespace, group string
+}
+
+func newAppliedRuleGroups() *appliedRuleGroups {
+	return &appliedRuleGroups{
+		groups: make(map[appliedRuleGroupKey]rulefmt.RuleGroup),
+	}
+}
+
+// Set records that group was written to namespace in tenant.
+func (a *appliedRuleGroups) Set(tenant, namespace string, group rulefmt.RuleGroup) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	a.groups[appliedRuleGroupKey{tenant, namespace, group.Name}] = group
+}
+
+// Delete records that the group with the given name was removed from
+// namespace in tenant.
+func (a *appliedRuleGroups) Delete(tenant, namespace, group string) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	delete(a.groups, appliedRuleGroupKey{tenant, namespace, group})
+}
+
+// Modified reports whether actual, the rule group currently stored in
+// namespace in tenant, differs from the rule group last written by the
+// component. Rule groups which weren't written by the component since it
+// started are never reported as modified.
+func (a *appliedRuleGroups) Modified(tenant, namespace string, actual rulefmt.RuleGroup) bool {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	applied, ok := a.groups[appliedRuleGroupKey{tenant, namespace, actual.Name}]
+	return ok && !equalRuleGroups(applied, actual)
+}
@@ -0,0 +1,75 @@
+package ruler
+
+import "fmt"
+
+// DebugInfo is the debug information of a Reconciler. Components convert it
+// to their own debug information type.
+type DebugInfo struct {
+	Error           string
+	PrometheusRules []DebugK8sPrometheusRule
+	RuleNamespaces  []DebugRuleNamespace
+}
+
+type DebugK8sPrometheusRule struct {
+	Namespace     string `river:"namespace,attr"`
+	Name          string `river:"name,attr"`
+	UID           string `river:"uid,attr"`
+	NumRuleGroups int    `river:"num_rule_groups,attr"`
+}
+
+// DebugRuleNamespace is a namespace of the ruler managed by the Reconciler.
+type DebugRuleNamespace struct {
+	Tenant        string
+	Name          string
+	NumRuleGroups int
+}
+
+// DebugInfo returns the state of the rules in Kubernetes and in the ruler.
+func (c *Reconciler) DebugInfo() DebugInfo {
+	var output DebugInfo
+
+	c.stateMut.RLock()
+	for tenant, namespaces := range c.currentState {
+		for ns, groups := range namespaces {
+			if !isManagedNamespace(c.args.NamespacePrefix, ns) {
+				continue
+			}
+
+			output.RuleNamespaces = append(output.RuleNamespaces, DebugRuleNamespace{
+				Tenant:        tenant,
+				Name:          ns,
+				NumRuleGroups: len(groups),
+			})
+		}
+	}
+	c.stateMut.RUnlock()
+
+	// This should load from the informer cache, so it shouldn't fail under normal circumstances.
+	managedK8sNamespaces, err := c.namespaceLister.List(c.namespaceSelector)
+	if err != nil {
+		return DebugInfo{
+			Error: fmt.Sprintf("failed to list namespaces: %v", err),
+		}
+	}
+
+	for _, n := range managedK8sNamespaces {
+		// This should load from the informer cache, so it shouldn't fail under normal circumstances.
+		rules, err := c.ruleLister.PrometheusRules(n.Name).List(c.ruleSelector)
+		if err != nil {
+			return DebugInfo{
+				Error: fmt.Sprintf("failed to list rules: %v", err),
+			}
+		}
+
+		for _, r := range rules {
+			output.PrometheusRules = append(output.PrometheusRules, DebugK8sPrometheusRule{
+				Namespace:     n.Name,
+				Name:          r.Name,
+				UID:           string(r.UID),
+				NumRuleGroups: len(r.Spec.Groups),
+			})
+		}
+	}
+
+	return output
+}
@@ -1,4 +1,4 @@
-package rules
+package ruler
 
 import (
 	"bytes"
@@ -22,6 +22,7 @@ type ruleGroupDiff struct {
 }
 
 type ruleGroupsByNamespace map[string][]rulefmt.RuleGroup
+type ruleGroupsByTenant map[string]ruleGroupsByNamespace
 type ruleGroupDiffsByNamespace map[string][]ruleGroupDiff
 
 func diffRuleState(desired, actual ruleGroupsByNamespace) ruleGroupDiffsByNamespace {
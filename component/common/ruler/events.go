@@ -1,4 +1,4 @@
-package rules
+package ruler
 
 import (
 	"context"
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/prometheus/prometheus/model/rulefmt"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
@@ -27,9 +28,14 @@ type eventType string
 
 const (
 	eventTypeResourceChanged eventType = "resource-changed"
-	eventTypeSyncMimir       eventType = "sync-mimir"
 )
 
+// syncEventType returns the type of the events which load the current state
+// of the rules from the ruler, such as "sync-mimir".
+func (c *Reconciler) syncEventType() eventType {
+	return eventType("sync-" + c.backend.Name)
+}
+
 type queuedEventHandler struct {
 	log   log.Logger
 	queue workqueue.RateLimitingInterface
@@ -70,7 +76,7 @@ func (c *queuedEventHandler) publishEvent(obj interface{}) {
 	})
 }
 
-func (c *Component) eventLoop(ctx context.Context) {
+func (c *Reconciler) eventLoop(ctx context.Context) {
 	for {
 		eventInterface, shutdown := c.queue.Get()
 		if shutdown {
@@ -110,15 +116,15 @@ func (c *Component) eventLoop(ctx context.Context) {
 	}
 }
 
-func (c *Component) processEvent(ctx context.Context, e event) error {
+func (c *Reconciler) processEvent(ctx context.Context, e event) error {
 	defer c.queue.Done(e)
 
 	switch e.typ {
 	case eventTypeResourceChanged:
 		level.Info(c.log).Log("msg", "processing event", "type", e.typ, "key", e.objectKey)
-	case eventTypeSyncMimir:
+	case c.syncEventType():
 		level.Debug(c.log).Log("msg", "syncing current state from ruler")
-		err := c.syncMimir(ctx)
+		err := c.syncRuler(ctx)
 		if err != nil {
 			return err
 		}
@@ -129,81 +135,143 @@ func (c *Component) processEvent(ctx context.Context, e event) error {
 	return c.reconcileState(ctx)
 }
 
-func (c *Component) syncMimir(ctx context.Context) error {
-	rulesByNamespace, err := c.mimirClient.ListRules(ctx, "")
+// syncRuler loads the current state of the rules managed by the component
+// from every tenant.
+func (c *Reconciler) syncRuler(ctx context.Context) error {
+	for _, tenant := range c.tenants() {
+		if err := c.syncRulerTenant(ctx, tenant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Reconciler) syncRulerTenant(ctx context.Context, tenant string) error {
+	rulesByNamespace, err := c.clientForTenant(tenant).ListRules(ctx, "")
 	if err != nil {
-		level.Error(c.log).Log("msg", "failed to list rules from mimir", "err", err)
+		level.Error(c.log).Log("msg", "failed to list rules from ruler", "tenant", tenant, "err", err)
 		return err
 	}
 
 	for ns := range rulesByNamespace {
-		if !isManagedMimirNamespace(c.args.MimirNameSpacePrefix, ns) {
+		if !isManagedNamespace(c.args.NamespacePrefix, ns) {
 			delete(rulesByNamespace, ns)
 		}
 	}
 
-	c.currentState = rulesByNamespace
+	c.stateMut.Lock()
+	defer c.stateMut.Unlock()
+	if c.currentState == nil {
+		c.currentState = make(ruleGroupsByTenant)
+	}
+	c.currentState[tenant] = rulesByNamespace
 
 	return nil
 }
 
-func (c *Component) reconcileState(ctx context.Context) error {
+func (c *Reconciler) reconcileState(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	desiredState, err := c.loadStateFromK8s()
+	diffs, err := c.diffState()
 	if err != nil {
 		return err
 	}
 
-	diffs := diffRuleState(desiredState, c.currentState)
 	var result error
-	for ns, diff := range diffs {
-		err = c.applyChanges(ctx, ns, diff)
-		if err != nil {
-			result = multierror.Append(result, err)
-			continue
+	for _, tenant := range c.tenants() {
+		for ns, diff := range diffs[tenant] {
+			err = c.applyChanges(ctx, tenant, ns, diff)
+			if err != nil {
+				result = multierror.Append(result, err)
+				continue
+			}
 		}
 	}
 
 	return result
 }
 
-func (c *Component) loadStateFromK8s() (ruleGroupsByNamespace, error) {
+// diffState returns the changes needed for the rules in the ruler to match the
+// rules discovered in Kubernetes, by tenant.
+func (c *Reconciler) diffState() (map[string]ruleGroupDiffsByNamespace, error) {
+	desiredState, err := c.loadStateFromK8s()
+	if err != nil {
+		return nil, err
+	}
+
+	c.stateMut.RLock()
+	defer c.stateMut.RUnlock()
+
+	diffs := make(map[string]ruleGroupDiffsByNamespace)
+	for _, tenant := range c.tenants() {
+		diffs[tenant] = diffRuleState(desiredState[tenant], c.currentState[tenant])
+	}
+	return diffs, nil
+}
+
+func (c *Reconciler) loadStateFromK8s() (ruleGroupsByTenant, error) {
 	matchedNamespaces, err := c.namespaceLister.List(c.namespaceSelector)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
-	desiredState := make(ruleGroupsByNamespace)
+	desiredState := make(ruleGroupsByTenant)
 	for _, ns := range matchedNamespaces {
 		crdState, err := c.ruleLister.PrometheusRules(ns.Name).List(c.ruleSelector)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list rules: %w", err)
 		}
 
+		tenant := c.tenantForNamespace(ns.Name)
+		if desiredState[tenant] == nil {
+			desiredState[tenant] = make(ruleGroupsByNamespace)
+		}
+
 		for _, pr := range crdState {
-			mimirNs := mimirNamespaceForRuleCRD(c.args.MimirNameSpacePrefix, pr)
+			rulerNs := namespaceForRuleCRD(c.args.NamespacePrefix, pr)
 
-			groups, err := convertCRDRuleGroupToRuleGroup(pr.Spec)
+			groups, err := c.convertCRDRuleGroupToRuleGroup(pr.Spec)
 			if err != nil {
 				return nil, fmt.Errorf("failed to convert rule group: %w", err)
 			}
 
-			desiredState[mimirNs] = groups
+			desiredState[tenant][rulerNs] = filterRuleGroups(groups, c.ruleGroupSelector)
 		}
 	}
 
 	return desiredState, nil
 }
 
-func convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec) ([]rulefmt.RuleGroup, error) {
+// filterRuleGroups returns the rule groups which contain at least one rule
+// whose labels match selector. Groups are kept or dropped as a whole, since
+// rules within a group may depend on each other.
+func filterRuleGroups(groups []rulefmt.RuleGroup, selector labels.Selector) []rulefmt.RuleGroup {
+	if selector == nil || selector.Empty() {
+		return groups
+	}
+
+	var res []rulefmt.RuleGroup
+	for _, g := range groups {
+		for _, r := range g.Rules {
+			if selector.Matches(labels.Set(r.Labels)) {
+				res = append(res, g)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// convertCRDRuleGroupToRuleGroup converts the rule groups of a PrometheusRule
+// resource, validating them with the parser of the backend.
+func (c *Reconciler) convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec) ([]rulefmt.RuleGroup, error) {
 	buf, err := yaml.Marshal(crd)
 	if err != nil {
 		return nil, err
 	}
 
-	groups, errs := rulefmt.Parse(buf)
+	groups, errs := c.backend.ParseRuleGroups(buf)
 	if len(errs) > 0 {
 		return nil, multierror.Append(nil, errs...)
 	}
@@ -211,50 +279,60 @@ func convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec) ([]rulefmt.Ru
 	return groups.Groups, nil
 }
 
-func (c *Component) applyChanges(ctx context.Context, namespace string, diffs []ruleGroupDiff) error {
+func (c *Reconciler) applyChanges(ctx context.Context, tenant, namespace string, diffs []ruleGroupDiff) error {
 	if len(diffs) == 0 {
 		return nil
 	}
 
+	client := c.clientForTenant(tenant)
+
 	for _, diff := range diffs {
 		switch diff.Kind {
 		case ruleGroupDiffKindAdd:
-			err := c.mimirClient.CreateRuleGroup(ctx, namespace, diff.Desired)
+			err := client.CreateRuleGroup(ctx, namespace, diff.Desired)
 			if err != nil {
 				return err
 			}
-			level.Info(c.log).Log("msg", "added rule group", "namespace", namespace, "group", diff.Desired.Name)
+			c.applied.Set(tenant, namespace, diff.Desired)
+			level.Info(c.log).Log("msg", "added rule group", "tenant", tenant, "namespace", namespace, "group", diff.Desired.Name)
 		case ruleGroupDiffKindRemove:
-			err := c.mimirClient.DeleteRuleGroup(ctx, namespace, diff.Actual.Name)
+			err := client.DeleteRuleGroup(ctx, namespace, diff.Actual.Name)
 			if err != nil {
 				return err
 			}
-			level.Info(c.log).Log("msg", "removed rule group", "namespace", namespace, "group", diff.Actual.Name)
+			c.applied.Delete(tenant, namespace, diff.Actual.Name)
+			level.Info(c.log).Log("msg", "removed rule group", "tenant", tenant, "namespace", namespace, "group", diff.Actual.Name)
 		case ruleGroupDiffKindUpdate:
-			err := c.mimirClient.CreateRuleGroup(ctx, namespace, diff.Desired)
+			if c.applied.Modified(tenant, namespace, diff.Actual) {
+				c.metrics.conflictsTotal.Inc()
+				level.Warn(c.log).Log("msg", "rule group was modified outside of the component and will be overwritten", "tenant", tenant, "namespace", namespace, "group", diff.Actual.Name)
+			}
+
+			err := client.CreateRuleGroup(ctx, namespace, diff.Desired)
 			if err != nil {
 				return err
 			}
-			level.Info(c.log).Log("msg", "updated rule group", "namespace", namespace, "group", diff.Desired.Name)
+			c.applied.Set(tenant, namespace, diff.Desired)
+			level.Info(c.log).Log("msg", "updated rule group", "tenant", tenant, "namespace", namespace, "group", diff.Desired.Name)
 		default:
 			level.Error(c.log).Log("msg", "unknown rule group diff kind", "kind", diff.Kind)
 		}
 	}
 
-	// resync mimir state after applying changes
-	return c.syncMimir(ctx)
+	// resync ruler state after applying changes
+	return c.syncRulerTenant(ctx, tenant)
 }
 
-// mimirNamespaceForRuleCRD returns the namespace that the rule CRD should be
-// stored in mimir. This function, along with isManagedNamespace, is used to
-// determine if a rule CRD is managed by the agent.
-func mimirNamespaceForRuleCRD(prefix string, pr *promv1.PrometheusRule) string {
+// namespaceForRuleCRD returns the namespace that the rule CRD should be
+// stored in the ruler. This function, along with isManagedNamespace, is used
+// to determine if a rule CRD is managed by the agent.
+func namespaceForRuleCRD(prefix string, pr *promv1.PrometheusRule) string {
 	return fmt.Sprintf("%s/%s/%s/%s", prefix, pr.Namespace, pr.Name, pr.UID)
 }
 
-// isManagedMimirNamespace returns true if the namespace is managed by the agent.
+// isManagedNamespace returns true if the namespace is managed by the agent.
 // Unmanaged namespaces are left as is by the operator.
-func isManagedMimirNamespace(prefix, namespace string) bool {
+func isManagedNamespace(prefix, namespace string) bool {
 	prefixPart := regexp.QuoteMeta(prefix)
 	namespacePart := `.+`
 	namePart := `.+`
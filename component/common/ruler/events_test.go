@@ -0,0 +1,345 @@
+package ruler
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	rulerClient "github.com/grafana/agent/pkg/ruler/client"
+	v1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	promListers "github.com/prometheus-operator/prometheus-operator/pkg/client/listers/monitoring/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+type fakeRulerClient struct {
+	rulesMut sync.RWMutex
+	rules    map[string][]rulefmt.RuleGroup
+}
+
+var _ rulerClient.Interface = &fakeRulerClient{}
+
+func newFakeRulerClient() *fakeRulerClient {
+	return &fakeRulerClient{
+		rules: make(map[string][]rulefmt.RuleGroup),
+	}
+}
+
+func (m *fakeRulerClient) CreateRuleGroup(ctx context.Context, namespace string, rule rulefmt.RuleGroup) error {
+	m.rulesMut.Lock()
+	defer m.rulesMut.Unlock()
+	m.deleteLocked(namespace, rule.Name)
+	m.rules[namespace] = append(m.rules[namespace], rule)
+	return nil
+}
+
+func (m *fakeRulerClient) DeleteRuleGroup(ctx context.Context, namespace, group string) error {
+	m.rulesMut.Lock()
+	defer m.rulesMut.Unlock()
+	m.deleteLocked(namespace, group)
+	return nil
+}
+
+func (m *fakeRulerClient) deleteLocked(namespace, group string) {
+	for ns, v := range m.rules {
+		if namespace != "" && namespace != ns {
+			continue
+		}
+		for i, g := range v {
+			if g.Name == group {
+				m.rules[ns] = append(m.rules[ns][:i], m.rules[ns][i+1:]...)
+
+				if len(m.rules[ns]) == 0 {
+					delete(m.rules, ns)
+				}
+
+				return
+			}
+		}
+	}
+}
+
+func (m *fakeRulerClient) ListRules(ctx context.Context, namespace string) (map[string][]rulefmt.RuleGroup, error) {
+	m.rulesMut.RLock()
+	defer m.rulesMut.RUnlock()
+	output := make(map[string][]rulefmt.RuleGroup)
+	for ns, v := range m.rules {
+		if namespace != "" && namespace != ns {
+			continue
+		}
+		output[ns] = v
+	}
+	return output, nil
+}
+
+var testBackend = Backend{
+	Name:            "test",
+	DisplayName:     "Test",
+	API:             rulerClient.MimirAPI,
+	ParseRuleGroups: rulefmt.Parse,
+}
+
+func TestEventTypeIsHashable(t *testing.T) {
+	// This test is here to ensure that the EventType type is hashable according to the workqueue implementation
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	queue.AddRateLimited(event{})
+}
+
+func TestEventLoop(t *testing.T) {
+	nsIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	nsLister := coreListers.NewNamespaceLister(nsIndexer)
+
+	ruleIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	ruleLister := promListers.NewPrometheusRuleLister(ruleIndexer)
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "namespace",
+			UID:  types.UID("33f8860c-bd06-4c0d-a0b1-a114d6b9937b"),
+		},
+	}
+
+	rule := &v1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "name",
+			Namespace: "namespace",
+			UID:       types.UID("64aab764-c95e-4ee9-a932-cd63ba57e6cf"),
+		},
+		Spec: v1.PrometheusRuleSpec{
+			Groups: []v1.RuleGroup{
+				{
+					Name: "group",
+					Rules: []v1.Rule{
+						{
+							Alert: "alert",
+							Expr:  intstr.FromString("expr"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	component := Reconciler{
+		log:               log.NewLogfmtLogger(os.Stdout),
+		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		namespaceLister:   nsLister,
+		namespaceSelector: labels.Everything(),
+		ruleLister:        ruleLister,
+		ruleSelector:      labels.Everything(),
+		rulerClient:       newFakeRulerClient(),
+		backend:           testBackend,
+		args:              Arguments{NamespacePrefix: "agent"},
+		applied:           newAppliedRuleGroups(),
+		metrics:           newMetrics(testBackend),
+	}
+	eventHandler := newQueuedEventHandler(component.log, component.queue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go component.eventLoop(ctx)
+
+	// Add a namespace and rule to kubernetes
+	nsIndexer.Add(ns)
+	ruleIndexer.Add(rule)
+	eventHandler.OnAdd(rule)
+
+	// Wait for the rule to be added to the ruler
+	require.Eventually(t, func() bool {
+		rules, err := component.rulerClient.ListRules(ctx, "")
+		require.NoError(t, err)
+		return len(rules) == 1
+	}, time.Second, 10*time.Millisecond)
+	component.queue.AddRateLimited(event{typ: component.syncEventType()})
+
+	// Update the rule in kubernetes
+	rule.Spec.Groups[0].Rules = append(rule.Spec.Groups[0].Rules, v1.Rule{
+		Alert: "alert2",
+		Expr:  intstr.FromString("expr2"),
+	})
+	ruleIndexer.Update(rule)
+	eventHandler.OnUpdate(rule, rule)
+
+	// Wait for the rule to be updated in the ruler
+	require.Eventually(t, func() bool {
+		allRules, err := component.rulerClient.ListRules(ctx, "")
+		require.NoError(t, err)
+		rules := allRules[namespaceForRuleCRD("agent", rule)][0].Rules
+		return len(rules) == 2
+	}, time.Second, 10*time.Millisecond)
+	component.queue.AddRateLimited(event{typ: component.syncEventType()})
+
+	// Remove the rule from kubernetes
+	ruleIndexer.Delete(rule)
+	eventHandler.OnDelete(rule)
+
+	// Wait for the rule to be removed from the ruler
+	require.Eventually(t, func() bool {
+		rules, err := component.rulerClient.ListRules(ctx, "")
+		require.NoError(t, err)
+		return len(rules) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReconcileState_Tenants(t *testing.T) {
+	nsIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	ruleIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+
+	newRule := func(namespace, uid string, groups ...v1.RuleGroup) *v1.PrometheusRule {
+		return &v1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rules",
+				Namespace: namespace,
+				UID:       types.UID(uid),
+			},
+			Spec: v1.PrometheusRuleSpec{Groups: groups},
+		}
+	}
+	newGroup := func(name, team string) v1.RuleGroup {
+		return v1.RuleGroup{
+			Name: name,
+			Rules: []v1.Rule{{
+				Alert:  "alert",
+				Expr:   intstr.FromString("expr"),
+				Labels: map[string]string{"team": team},
+			}},
+		}
+	}
+
+	for _, ns := range []string{"team-a", "team-b"} {
+		require.NoError(t, nsIndexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}))
+	}
+	ruleA := newRule("team-a", "64aab764-c95e-4ee9-a932-cd63ba57e6cf", newGroup("a", "a"), newGroup("other", "other"))
+	ruleB := newRule("team-b", "33f8860c-bd06-4c0d-a0b1-a114d6b9937b", newGroup("b", "b"))
+	require.NoError(t, ruleIndexer.Add(ruleA))
+	require.NoError(t, ruleIndexer.Add(ruleB))
+
+	ruleGroupSelector, err := convertSelectorToListOptions(LabelSelector{
+		MatchExpressions: []MatchExpression{{Key: "team", Operator: "In", Values: []string{"a", "b"}}},
+	})
+	require.NoError(t, err)
+
+	var (
+		defaultClient = newFakeRulerClient()
+		tenantClient  = newFakeRulerClient()
+	)
+	component := Reconciler{
+		log:               log.NewLogfmtLogger(os.Stdout),
+		namespaceLister:   coreListers.NewNamespaceLister(nsIndexer),
+		namespaceSelector: labels.Everything(),
+		ruleLister:        promListers.NewPrometheusRuleLister(ruleIndexer),
+		ruleSelector:      labels.Everything(),
+		ruleGroupSelector: ruleGroupSelector,
+		rulerClient:       defaultClient,
+		tenantClients:     map[string]rulerClient.Interface{"tenant-b": tenantClient},
+		backend:           testBackend,
+		args: Arguments{
+			NamespacePrefix:    "agent",
+			NamespaceTenantIDs: map[string]string{"team-b": "tenant-b"},
+		},
+		applied: newAppliedRuleGroups(),
+		metrics: newMetrics(testBackend),
+	}
+
+	ctx := context.Background()
+	require.NoError(t, component.syncRuler(ctx))
+
+	// Check the dry run before applying any changes.
+	diffs, err := component.diffState()
+	require.NoError(t, err)
+	require.Equal(t, DiffResult{Changes: []RuleGroupChange{
+		{Tenant: "", Namespace: namespaceForRuleCRD("agent", ruleA), Group: "a", Kind: "add"},
+		{Tenant: "tenant-b", Namespace: namespaceForRuleCRD("agent", ruleB), Group: "b", Kind: "add"},
+	}}, newDiffResult(diffs))
+
+	require.NoError(t, component.reconcileState(ctx))
+
+	// The group not matching the rule group selector must not be loaded.
+	defaultRules, err := defaultClient.ListRules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, defaultRules, 1)
+	require.Len(t, defaultRules[namespaceForRuleCRD("agent", ruleA)], 1)
+	require.Equal(t, "a", defaultRules[namespaceForRuleCRD("agent", ruleA)][0].Name)
+
+	tenantRules, err := tenantClient.ListRules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tenantRules, 1)
+	require.Equal(t, "b", tenantRules[namespaceForRuleCRD("agent", ruleB)][0].Name)
+
+	// Nothing is left to change after reconciling.
+	diffs, err = component.diffState()
+	require.NoError(t, err)
+	require.Empty(t, newDiffResult(diffs).Changes)
+}
+
+func TestApplyChanges_Conflict(t *testing.T) {
+	newGroup := func(expr string) rulefmt.RuleGroup {
+		var g rulefmt.RuleGroup
+		g.Name = "group"
+		g.Rules = []rulefmt.RuleNode{{}}
+		g.Rules[0].Alert.SetString("alert")
+		g.Rules[0].Expr.SetString(expr)
+		return g
+	}
+
+	var (
+		ctx       = context.Background()
+		namespace = "agent/namespace/name/64aab764-c95e-4ee9-a932-cd63ba57e6cf"
+		client    = newFakeRulerClient()
+	)
+	component := Reconciler{
+		log:         log.NewNopLogger(),
+		backend:     testBackend,
+		rulerClient: client,
+		args:        Arguments{NamespacePrefix: "agent"},
+		applied:     newAppliedRuleGroups(),
+		metrics:     newMetrics(testBackend),
+	}
+
+	// Changing a rule group written by the component isn't a conflict.
+	applied := newGroup("expr_a")
+	require.NoError(t, component.applyChanges(ctx, "", namespace, []ruleGroupDiff{
+		{Kind: ruleGroupDiffKindAdd, Desired: applied},
+	}))
+	require.NoError(t, component.applyChanges(ctx, "", namespace, []ruleGroupDiff{
+		{Kind: ruleGroupDiffKindUpdate, Actual: applied, Desired: newGroup("expr_b")},
+	}))
+	require.Equal(t, 0.0, testutil.ToFloat64(component.metrics.conflictsTotal))
+
+	// Changing a rule group which was modified by someone else is.
+	modified := newGroup("expr_modified")
+	require.NoError(t, client.CreateRuleGroup(ctx, namespace, modified))
+	require.NoError(t, component.applyChanges(ctx, "", namespace, []ruleGroupDiff{
+		{Kind: ruleGroupDiffKindUpdate, Actual: modified, Desired: newGroup("expr_b")},
+	}))
+	require.Equal(t, 1.0, testutil.ToFloat64(component.metrics.conflictsTotal))
+
+	rules, err := client.ListRules(ctx, namespace)
+	require.NoError(t, err)
+	require.Equal(t, "expr_b", rules[namespace][0].Rules[0].Expr.Value)
+}
@@ -1,4 +1,4 @@
-package rules
+package ruler
 
 import (
 	"time"
@@ -6,7 +6,7 @@ import (
 	"github.com/grafana/agent/component"
 )
 
-func (c *Component) reportUnhealthy(err error) {
+func (c *Reconciler) reportUnhealthy(err error) {
 	c.healthMut.Lock()
 	defer c.healthMut.Unlock()
 	c.health = component.Health{
@@ -16,7 +16,7 @@ func (c *Component) reportUnhealthy(err error) {
 	}
 }
 
-func (c *Component) reportHealthy() {
+func (c *Reconciler) reportHealthy() {
 	c.healthMut.Lock()
 	defer c.healthMut.Unlock()
 	c.health = component.Health{
@@ -25,7 +25,7 @@ func (c *Component) reportHealthy() {
 	}
 }
 
-func (c *Component) CurrentHealth() component.Health {
+func (c *Reconciler) CurrentHealth() component.Health {
 	c.healthMut.RLock()
 	defer c.healthMut.RUnlock()
 	return c.health
@@ -0,0 +1,84 @@
+package ruler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// DiffResult lists the changes which the next reconciliation would make to
+// the rules in the ruler.
+type DiffResult struct {
+	Changes []RuleGroupChange `json:"changes"`
+}
+
+// RuleGroupChange is a single rule group which would be added, updated, or
+// removed.
+type RuleGroupChange struct {
+	Tenant    string `json:"tenant"`
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+}
+
+// Handler implements component.HTTPComponent.
+func (c *Reconciler) Handler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/diff", c.handleDiff).Methods(http.MethodGet)
+	return r
+}
+
+// handleDiff performs a dry run of a reconciliation, returning the changes
+// which would be made to the ruler without applying them.
+func (c *Reconciler) handleDiff(w http.ResponseWriter, _ *http.Request) {
+	if c.namespaceLister == nil || c.ruleLister == nil {
+		http.Error(w, "component has not started yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	diffs, err := c.diffState()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to compute diff: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newDiffResult(diffs))
+}
+
+func newDiffResult(diffs map[string]ruleGroupDiffsByNamespace) DiffResult {
+	res := DiffResult{Changes: []RuleGroupChange{}}
+	for tenant, namespaces := range diffs {
+		for ns, nsDiffs := range namespaces {
+			for _, d := range nsDiffs {
+				group := d.Desired.Name
+				if d.Kind == ruleGroupDiffKindRemove {
+					group = d.Actual.Name
+				}
+
+				res.Changes = append(res.Changes, RuleGroupChange{
+					Tenant:    tenant,
+					Namespace: ns,
+					Group:     group,
+					Kind:      string(d.Kind),
+				})
+			}
+		}
+	}
+
+	sort.Slice(res.Changes, func(i, j int) bool {
+		a, b := res.Changes[i], res.Changes[j]
+		switch {
+		case a.Tenant != b.Tenant:
+			return a.Tenant < b.Tenant
+		case a.Namespace != b.Namespace:
+			return a.Namespace < b.Namespace
+		default:
+			return a.Group < b.Group
+		}
+	})
+	return res
+}
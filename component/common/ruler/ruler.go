@@ -0,0 +1,409 @@
+// Package ruler reconciles the rules of PrometheusRule resources discovered in
+// Kubernetes with the rules stored in a Mimir or Loki ruler. It's shared by
+// the mimir.rules.kubernetes and loki.rules.kubernetes components, which
+// configure the differences between the two rulers through a Backend.
+package ruler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	rulerClient "github.com/grafana/agent/pkg/ruler/client"
+	promListers "github.com/prometheus-operator/prometheus-operator/pkg/client/listers/monitoring/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/weaveworks/common/instrument"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
+	controller "sigs.k8s.io/controller-runtime"
+
+	promExternalVersions "github.com/prometheus-operator/prometheus-operator/pkg/client/informers/externalversions"
+	promVersioned "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+)
+
+// Backend describes the ruler which rules are loaded into.
+type Backend struct {
+	// Name of the ruler, such as "mimir". It prefixes the names of metrics and
+	// of the component.
+	Name string
+	// DisplayName of the ruler, such as "Mimir", used in help texts.
+	DisplayName string
+	// API of the ruler.
+	API rulerClient.API
+	// ParseRuleGroups parses the rule groups of a PrometheusRule resource,
+	// marshaled to YAML, validating them for the query language of the ruler.
+	ParseRuleGroups func(content []byte) (*rulefmt.RuleGroups, []error)
+}
+
+// Reconciler loads the rules of PrometheusRule resources into a ruler. It
+// implements the methods of component.Component, except for Update, which
+// takes Arguments rather than the arguments of a component.
+type Reconciler struct {
+	log     log.Logger
+	opts    component.Options
+	backend Backend
+	args    Arguments
+
+	rulerClient   rulerClient.Interface
+	tenantClients map[string]rulerClient.Interface
+	k8sClient     kubernetes.Interface
+	promClient    promVersioned.Interface
+	ruleLister    promListers.PrometheusRuleLister
+	ruleInformer  cache.SharedIndexInformer
+
+	namespaceLister   coreListers.NamespaceLister
+	namespaceInformer cache.SharedIndexInformer
+	informerStopChan  chan struct{}
+	ticker            *time.Ticker
+
+	queue         workqueue.RateLimitingInterface
+	configUpdates chan ConfigUpdate
+
+	namespaceSelector labels.Selector
+	ruleSelector      labels.Selector
+	ruleGroupSelector labels.Selector
+
+	stateMut     sync.RWMutex
+	currentState ruleGroupsByTenant
+	applied      *appliedRuleGroups
+
+	metrics   *metrics
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+type metrics struct {
+	configUpdatesTotal prometheus.Counter
+
+	eventsTotal    *prometheus.CounterVec
+	eventsFailed   *prometheus.CounterVec
+	eventsRetried  *prometheus.CounterVec
+	conflictsTotal prometheus.Counter
+
+	rulerClientTiming *prometheus.HistogramVec
+}
+
+func (m *metrics) Register(r prometheus.Registerer) error {
+	r.MustRegister(
+		m.configUpdatesTotal,
+		m.eventsTotal,
+		m.eventsFailed,
+		m.eventsRetried,
+		m.conflictsTotal,
+		m.rulerClientTiming,
+	)
+	return nil
+}
+
+func newMetrics(b Backend) *metrics {
+	subsystem := b.Name + "_rules"
+	return &metrics{
+		configUpdatesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "config_updates_total",
+			Help:      "Total number of times the configuration has been updated.",
+		}),
+		eventsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "events_total",
+			Help:      "Total number of events processed, partitioned by event type.",
+		}, []string{"type"}),
+		eventsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "events_failed_total",
+			Help:      "Total number of events that failed to be processed, even after retries, partitioned by event type.",
+		}, []string{"type"}),
+		eventsRetried: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "events_retried_total",
+			Help:      "Total number of retries across all events, partitioned by event type.",
+		}, []string{"type"}),
+		conflictsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "conflicts_total",
+			Help:      "Total number of managed rule groups which were overwritten after being modified outside of the component.",
+		}),
+		rulerClientTiming: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: subsystem,
+			Name:      b.Name + "_client_request_duration_seconds",
+			Help:      fmt.Sprintf("Duration of requests to the %s API.", b.DisplayName),
+			Buckets:   instrument.DefBuckets,
+		}, instrument.HistogramCollectorBuckets),
+	}
+}
+
+type ConfigUpdate struct {
+	args Arguments
+	err  chan error
+}
+
+// New creates a Reconciler loading rules into the ruler described by b.
+func New(o component.Options, b Backend, args Arguments) (*Reconciler, error) {
+	metrics := newMetrics(b)
+	err := metrics.Register(o.Registerer)
+	if err != nil {
+		return nil, fmt.Errorf("registering metrics failed: %w", err)
+	}
+
+	c := &Reconciler{
+		log:           o.Logger,
+		opts:          o,
+		backend:       b,
+		args:          args,
+		configUpdates: make(chan ConfigUpdate),
+		ticker:        time.NewTicker(args.SyncInterval),
+		applied:       newAppliedRuleGroups(),
+		metrics:       metrics,
+	}
+
+	err = c.init()
+	if err != nil {
+		return nil, fmt.Errorf("initializing component failed: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *Reconciler) Run(ctx context.Context) error {
+	err := c.startup(ctx)
+	if err != nil {
+		level.Error(c.log).Log("msg", "starting up component failed", "err", err)
+		c.reportUnhealthy(err)
+	}
+
+	for {
+		select {
+		case update := <-c.configUpdates:
+			c.metrics.configUpdatesTotal.Inc()
+			c.shutdown()
+
+			c.args = update.args
+			err := c.init()
+			if err != nil {
+				level.Error(c.log).Log("msg", "updating configuration failed", "err", err)
+				c.reportUnhealthy(err)
+				update.err <- err
+				continue
+			}
+
+			err = c.startup(ctx)
+			if err != nil {
+				level.Error(c.log).Log("msg", "updating configuration failed", "err", err)
+				c.reportUnhealthy(err)
+				update.err <- err
+				continue
+			}
+
+			update.err <- nil
+		case <-ctx.Done():
+			c.shutdown()
+			return nil
+		case <-c.ticker.C:
+			c.queue.Add(event{
+				typ: c.syncEventType(),
+			})
+		}
+	}
+}
+
+// startup launches the informers and starts the event loop.
+func (c *Reconciler) startup(ctx context.Context) error {
+	c.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), c.backend.Name+".rules.kubernetes")
+	c.informerStopChan = make(chan struct{})
+
+	if err := c.startNamespaceInformer(); err != nil {
+		return err
+	}
+	if err := c.startRuleInformer(); err != nil {
+		return err
+	}
+	err := c.syncRuler(ctx)
+	if err != nil {
+		return err
+	}
+	go c.eventLoop(ctx)
+	return nil
+}
+
+func (c *Reconciler) shutdown() {
+	close(c.informerStopChan)
+	c.queue.ShutDownWithDrain()
+}
+
+// Update applies new arguments, waiting until they're applied.
+func (c *Reconciler) Update(args Arguments) error {
+	errChan := make(chan error)
+	c.configUpdates <- ConfigUpdate{
+		args: args,
+		err:  errChan,
+	}
+	return <-errChan
+}
+
+func (c *Reconciler) init() error {
+	level.Info(c.log).Log("msg", "initializing with new configuration")
+
+	// TODO: allow overriding some stuff in RestConfig and k8s client options?
+	restConfig, err := controller.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get k8s config: %w", err)
+	}
+
+	c.k8sClient, err = kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	c.promClient, err = promVersioned.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create prometheus operator client: %w", err)
+	}
+
+	httpClient := c.args.HTTPClientConfig.Convert()
+
+	newClient := func(tenant string) (rulerClient.Interface, error) {
+		return rulerClient.New(c.log, rulerClient.Config{
+			ID:               tenant,
+			Address:          c.args.Address,
+			API:              c.backend.API,
+			UseLegacyRoutes:  c.args.UseLegacyRoutes,
+			HTTPClientConfig: *httpClient,
+		}, c.metrics.rulerClientTiming)
+	}
+
+	c.rulerClient, err = newClient(c.args.TenantID)
+	if err != nil {
+		return err
+	}
+
+	c.tenantClients = make(map[string]rulerClient.Interface)
+	for _, tenant := range c.args.NamespaceTenantIDs {
+		if _, ok := c.tenantClients[tenant]; ok || tenant == c.args.TenantID {
+			continue
+		}
+		c.tenantClients[tenant], err = newClient(tenant)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.ticker.Reset(c.args.SyncInterval)
+
+	c.namespaceSelector, err = convertSelectorToListOptions(c.args.RuleNamespaceSelector)
+	if err != nil {
+		return err
+	}
+
+	c.ruleSelector, err = convertSelectorToListOptions(c.args.RuleSelector)
+	if err != nil {
+		return err
+	}
+
+	c.ruleGroupSelector, err = convertSelectorToListOptions(c.args.RuleGroupSelector)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// tenantForNamespace returns the tenant which rules from the given
+// Kubernetes namespace are loaded into.
+func (c *Reconciler) tenantForNamespace(namespace string) string {
+	if tenant, ok := c.args.NamespaceTenantIDs[namespace]; ok {
+		return tenant
+	}
+	return c.args.TenantID
+}
+
+// clientForTenant returns the ruler client used to manage the rules of the
+// given tenant.
+func (c *Reconciler) clientForTenant(tenant string) rulerClient.Interface {
+	if client, ok := c.tenantClients[tenant]; ok {
+		return client
+	}
+	return c.rulerClient
+}
+
+// tenants returns the sorted list of tenants managed by the component.
+func (c *Reconciler) tenants() []string {
+	tenants := []string{c.args.TenantID}
+	for tenant := range c.tenantClients {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+func convertSelectorToListOptions(selector LabelSelector) (labels.Selector, error) {
+	matchExpressions := []metav1.LabelSelectorRequirement{}
+
+	for _, me := range selector.MatchExpressions {
+		matchExpressions = append(matchExpressions, metav1.LabelSelectorRequirement{
+			Key:      me.Key,
+			Operator: metav1.LabelSelectorOperator(me.Operator),
+			Values:   me.Values,
+		})
+	}
+
+	return metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels:      selector.MatchLabels,
+		MatchExpressions: matchExpressions,
+	})
+}
+
+func (c *Reconciler) startNamespaceInformer() error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		c.k8sClient,
+		24*time.Hour,
+		informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+			lo.LabelSelector = c.namespaceSelector.String()
+		}),
+	)
+
+	namespaces := factory.Core().V1().Namespaces()
+	c.namespaceLister = namespaces.Lister()
+	c.namespaceInformer = namespaces.Informer()
+	_, err := c.namespaceInformer.AddEventHandler(newQueuedEventHandler(c.log, c.queue))
+	if err != nil {
+		return err
+	}
+
+	factory.Start(c.informerStopChan)
+	factory.WaitForCacheSync(c.informerStopChan)
+	return nil
+}
+
+func (c *Reconciler) startRuleInformer() error {
+	factory := promExternalVersions.NewSharedInformerFactoryWithOptions(
+		c.promClient,
+		24*time.Hour,
+		promExternalVersions.WithTweakListOptions(func(lo *metav1.ListOptions) {
+			lo.LabelSelector = c.ruleSelector.String()
+		}),
+	)
+
+	promRules := factory.Monitoring().V1().PrometheusRules()
+	c.ruleLister = promRules.Lister()
+	c.ruleInformer = promRules.Informer()
+	_, err := c.ruleInformer.AddEventHandler(newQueuedEventHandler(c.log, c.queue))
+	if err != nil {
+		return err
+	}
+
+	factory.Start(c.informerStopChan)
+	factory.WaitForCacheSync(c.informerStopChan)
+	return nil
+}
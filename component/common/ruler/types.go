@@ -0,0 +1,34 @@
+package ruler
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component/common/config"
+)
+
+// Arguments configures a Reconciler. Components build Arguments from their
+// own River arguments.
+type Arguments struct {
+	Address            string
+	TenantID           string
+	UseLegacyRoutes    bool
+	HTTPClientConfig   config.HTTPClientConfig
+	SyncInterval       time.Duration
+	NamespacePrefix    string
+	NamespaceTenantIDs map[string]string
+
+	RuleSelector          LabelSelector
+	RuleNamespaceSelector LabelSelector
+	RuleGroupSelector     LabelSelector
+}
+
+type LabelSelector struct {
+	MatchLabels      map[string]string `river:"match_labels,attr,optional"`
+	MatchExpressions []MatchExpression `river:"match_expression,block,optional"`
+}
+
+type MatchExpression struct {
+	Key      string   `river:"key,attr"`
+	Operator string   `river:"operator,attr"`
+	Values   []string `river:"values,attr,optional"`
+}
@@ -0,0 +1,84 @@
+// Package tracesampling shares trace sampling decisions between traces and
+// logs pipelines.
+package tracesampling
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// Decisions reports the sampling decisions made by a traces pipeline.
+type Decisions interface {
+	// Sampled reports whether the trace with the given hex-encoded ID was
+	// sampled.
+	Sampled(traceID string) bool
+}
+
+// Cache is a bounded LRU implementation of Decisions which records the IDs of
+// sampled traces. Once the cache is full, the least recently sampled traces
+// are forgotten. Trace IDs are also forgotten once they are older than the
+// cache's TTL.
+type Cache struct {
+	lru *lru.Cache
+	ttl atomic.Int64 // time.Duration; 0 disables expiry.
+
+	now func() time.Time // Overridden by tests.
+}
+
+var _ Decisions = (*Cache)(nil)
+
+// NewCache creates a new Cache which remembers at most maxEntries trace IDs
+// for up to ttl. A ttl of 0 keeps trace IDs until they are evicted.
+func NewCache(maxEntries int, ttl time.Duration) (*Cache, error) {
+	l, err := lru.New(maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{lru: l, now: time.Now}
+	c.ttl.Store(int64(ttl))
+	return c, nil
+}
+
+// Record marks the trace with the given hex-encoded ID as sampled.
+func (c *Cache) Record(traceID string) {
+	c.lru.Add(normalize(traceID), c.now())
+}
+
+// Sampled implements Decisions.
+func (c *Cache) Sampled(traceID string) bool {
+	key := normalize(traceID)
+
+	v, ok := c.lru.Peek(key)
+	if !ok {
+		return false
+	}
+	if ttl := time.Duration(c.ttl.Load()); ttl > 0 && c.now().Sub(v.(time.Time)) > ttl {
+		c.lru.Remove(key)
+		return false
+	}
+	return true
+}
+
+// Len returns the number of trace IDs in the cache, including trace IDs which
+// have expired but haven't been looked up since.
+func (c *Cache) Len() int {
+	return c.lru.Len()
+}
+
+// SetLimits changes the number of trace IDs the cache can remember and how
+// long it remembers them for. The oldest trace IDs are evicted if the cache
+// shrinks.
+func (c *Cache) SetLimits(maxEntries int, ttl time.Duration) {
+	c.lru.Resize(maxEntries)
+	c.ttl.Store(int64(ttl))
+}
+
+// normalize converts a hex-encoded trace ID into the lowercase form used by
+// OpenTelemetry so that lookups aren't sensitive to how log lines format
+// trace IDs.
+func normalize(traceID string) string {
+	return strings.ToLower(traceID)
+}
@@ -0,0 +1,50 @@
+package tracesampling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	c, err := NewCache(2, 0)
+	require.NoError(t, err)
+
+	c.Record("0102030405060708090A0B0C0D0E0F10")
+	require.True(t, c.Sampled("0102030405060708090a0b0c0d0e0f10"))
+	require.False(t, c.Sampled("ffffffffffffffffffffffffffffffff"))
+
+	c.Record("a")
+	c.Record("b")
+	require.False(t, c.Sampled("0102030405060708090a0b0c0d0e0f10"), "oldest trace ID should have been evicted")
+	require.True(t, c.Sampled("a"))
+	require.True(t, c.Sampled("b"))
+
+	c.SetLimits(1, 0)
+	require.False(t, c.Sampled("a"))
+	require.True(t, c.Sampled("b"))
+}
+
+func TestCache_TTL(t *testing.T) {
+	c, err := NewCache(10, time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Record("a")
+	now = now.Add(30 * time.Second)
+	c.Record("b")
+	require.True(t, c.Sampled("a"))
+
+	now = now.Add(31 * time.Second)
+	require.False(t, c.Sampled("a"), "trace ID should have expired")
+	require.True(t, c.Sampled("b"))
+	require.Equal(t, 1, c.Len())
+
+	// Disabling the TTL keeps trace IDs until they're evicted.
+	c.SetLimits(10, 0)
+	now = now.Add(time.Hour)
+	require.True(t, c.Sampled("b"))
+}
@@ -1,17 +1,21 @@
 package aws
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	promcfg "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	promaws "github.com/prometheus/prometheus/discovery/aws"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
 )
 
 func init() {
@@ -22,6 +26,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return NewEC2(opts, args.(EC2Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
@@ -42,6 +48,8 @@ type EC2Arguments struct {
 	RefreshInterval time.Duration     `river:"refresh_interval,attr,optional"`
 	Port            int               `river:"port,attr,optional"`
 	Filters         []*EC2Filter      `river:"filter,block,optional"`
+
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
 }
 
 func (args EC2Arguments) Convert() *promaws.EC2SDConfig {
@@ -54,6 +62,8 @@ func (args EC2Arguments) Convert() *promaws.EC2SDConfig {
 		RoleARN:         args.RoleARN,
 		RefreshInterval: model.Duration(args.RefreshInterval),
 		Port:            args.Port,
+
+		HTTPClientConfig: *args.HTTPClientConfig.Convert(),
 	}
 	for _, f := range args.Filters {
 		cfg.Filters = append(cfg.Filters, &promaws.EC2Filter{
@@ -67,6 +77,8 @@ func (args EC2Arguments) Convert() *promaws.EC2SDConfig {
 var DefaultEC2SDConfig = EC2Arguments{
 	Port:            80,
 	RefreshInterval: 60 * time.Second,
+
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
 }
 
 // UnmarshalRiver implements river.Unmarshaler, applying defaults and
@@ -95,13 +107,78 @@ func (args *EC2Arguments) UnmarshalRiver(f func(interface{}) error) error {
 			return errors.New("EC2 SD configuration filter values cannot be empty")
 		}
 	}
-	return nil
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	return args.HTTPClientConfig.Validate()
 }
 
 // New creates a new discovery.ec2 component.
 func NewEC2(opts component.Options, args EC2Arguments) (component.Component, error) {
 	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
 		conf := args.(EC2Arguments).Convert()
-		return promaws.NewEC2Discovery(conf, opts.Logger), nil
+		return &ec2CostLabelsDiscoverer{Discoverer: promaws.NewEC2Discovery(conf, opts.Logger)}, nil
 	})
 }
+
+const (
+	ec2LabelInstanceLifecycle = model.MetaLabelPrefix + "ec2_instance_lifecycle"
+	ec2LabelInstanceType      = model.MetaLabelPrefix + "ec2_instance_type"
+	ec2LabelInstanceFamily    = model.MetaLabelPrefix + "ec2_instance_family"
+	ec2LabelInstanceSize      = model.MetaLabelPrefix + "ec2_instance_size"
+	ec2LabelInstancePricing   = model.MetaLabelPrefix + "ec2_instance_pricing"
+
+	// ec2PricingOnDemand is the pricing of instances for which EC2 does not
+	// report a lifecycle.
+	ec2PricingOnDemand = "on-demand"
+)
+
+// ec2CostLabelsDiscoverer wraps the upstream EC2 discoverer to add
+// cost-relevant meta labels to discovered targets.
+type ec2CostLabelsDiscoverer struct {
+	discovery.Discoverer
+}
+
+// Run implements discovery.Discoverer.
+func (d *ec2CostLabelsDiscoverer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	ch := make(chan []*targetgroup.Group)
+	go d.Discoverer.Run(ctx, ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tgs := <-ch:
+			for _, tg := range tgs {
+				for _, target := range tg.Targets {
+					addEC2CostLabels(target)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case up <- tgs:
+			}
+		}
+	}
+}
+
+// addEC2CostLabels adds labels derived from the instance lifecycle and
+// instance type to target. Labels set by the upstream discoverer are left
+// untouched.
+//
+// EC2 only reports a lifecycle for spot and scheduled instances, so the
+// pricing of targets without a lifecycle is on-demand. The instance type
+// (e.g., m5.large) is split into its family (m5) and size (large).
+func addEC2CostLabels(target model.LabelSet) {
+	if lifecycle, ok := target[ec2LabelInstanceLifecycle]; ok {
+		target[ec2LabelInstancePricing] = lifecycle
+	} else {
+		target[ec2LabelInstancePricing] = ec2PricingOnDemand
+	}
+
+	instanceType := string(target[ec2LabelInstanceType])
+	if family, size, ok := strings.Cut(instanceType, "."); ok {
+		target[ec2LabelInstanceFamily] = model.LabelValue(family)
+		target[ec2LabelInstanceSize] = model.LabelValue(size)
+	}
+}
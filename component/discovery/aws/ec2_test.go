@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"crypto/tls"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	promcfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddEC2CostLabels(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  model.LabelSet
+		expect model.LabelSet
+	}{
+		{
+			name: "on-demand instance",
+			input: model.LabelSet{
+				ec2LabelInstanceType: "m5.large",
+			},
+			expect: model.LabelSet{
+				ec2LabelInstanceType:    "m5.large",
+				ec2LabelInstancePricing: "on-demand",
+				ec2LabelInstanceFamily:  "m5",
+				ec2LabelInstanceSize:    "large",
+			},
+		},
+		{
+			name: "spot instance",
+			input: model.LabelSet{
+				ec2LabelInstanceType:      "c6gn.16xlarge",
+				ec2LabelInstanceLifecycle: "spot",
+			},
+			expect: model.LabelSet{
+				ec2LabelInstanceType:      "c6gn.16xlarge",
+				ec2LabelInstanceLifecycle: "spot",
+				ec2LabelInstancePricing:   "spot",
+				ec2LabelInstanceFamily:    "c6gn",
+				ec2LabelInstanceSize:      "16xlarge",
+			},
+		},
+		{
+			name:  "unknown instance type",
+			input: model.LabelSet{},
+			expect: model.LabelSet{
+				ec2LabelInstancePricing: "on-demand",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			addEC2CostLabels(tc.input)
+			require.Equal(t, tc.expect, tc.input)
+		})
+	}
+}
+
+func TestEC2HTTPClientConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	region    = "us-east-1"
+	proxy_url = "http://proxy.example.com:3128"
+
+	tls_config {
+		ca_file     = "/etc/ssl/corporate-ca.pem"
+		min_version = "TLS12"
+	}
+`
+
+	var args EC2Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+
+	expectProxy, err := url.Parse("http://proxy.example.com:3128")
+	require.NoError(t, err)
+
+	cfg := args.Convert()
+	require.Equal(t, expectProxy, cfg.HTTPClientConfig.ProxyURL.URL)
+	require.Equal(t, "/etc/ssl/corporate-ca.pem", cfg.HTTPClientConfig.TLSConfig.CAFile)
+	require.Equal(t, promcfg.TLSVersion(tls.VersionTLS12), cfg.HTTPClientConfig.TLSConfig.MinVersion)
+	require.True(t, cfg.HTTPClientConfig.FollowRedirects)
+}
@@ -23,6 +23,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
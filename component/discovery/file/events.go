@@ -0,0 +1,244 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// eventDebounce is how long eventWatcher waits after a filesystem event
+// before notifying, so that bursts of events (such as a log rotation or many
+// files being created at once) result in a single sync.
+const eventDebounce = 100 * time.Millisecond
+
+// eventWatcher uses filesystem notifications (inotify on Linux, kqueue on
+// macOS and BSD, and ReadDirectoryChangesW on Windows) to detect files being
+// created, removed, or renamed in the directories which may contain files
+// matching a set of glob patterns.
+type eventWatcher struct {
+	log     log.Logger
+	watcher *fsnotify.Watcher
+	changed chan struct{}
+	done    chan struct{}
+
+	mut     sync.Mutex
+	watched map[string]struct{}
+}
+
+// newEventWatcher creates a new eventWatcher. It returns an error if
+// filesystem notifications aren't available on the host.
+func newEventWatcher(l log.Logger) (*eventWatcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &eventWatcher{
+		log:     l,
+		watcher: fw,
+		changed: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		watched: make(map[string]struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Changed returns a channel which is written to after files in a watched
+// directory are created, removed, or renamed.
+func (w *eventWatcher) Changed() <-chan struct{} {
+	return w.changed
+}
+
+func (w *eventWatcher) run() {
+	defer close(w.done)
+
+	var (
+		debounce   *time.Timer
+		debounceCh <-chan time.Time
+	)
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+				continue
+			}
+			if debounceCh == nil {
+				debounce = time.NewTimer(eventDebounce)
+				debounceCh = debounce.C
+			}
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been dropped, such as when the inotify queue
+			// overflows. Sync anyway so nothing is missed.
+			level.Warn(w.log).Log("msg", "error watching filesystem events", "err", err)
+			if debounceCh == nil {
+				debounce = time.NewTimer(eventDebounce)
+				debounceCh = debounce.C
+			}
+
+		case <-debounceCh:
+			debounce, debounceCh = nil, nil
+			select {
+			case w.changed <- struct{}{}:
+			default:
+				// A notification is already pending.
+			}
+		}
+	}
+}
+
+// SetPatterns updates the set of watched directories to those which may
+// contain files matching patterns. Directories which can't be watched, such
+// as those which don't exist yet or exceed the limit of watches on the host,
+// are skipped; changes to them are only discovered by polling.
+func (w *eventWatcher) SetPatterns(patterns []string) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	dirs := make(map[string]struct{})
+	for _, p := range patterns {
+		for _, dir := range patternDirs(p) {
+			dirs[dir] = struct{}{}
+		}
+	}
+
+	for dir := range w.watched {
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+		// Removing a watch fails if the directory has been deleted, in which
+		// case the watch was already removed.
+		_ = w.watcher.Remove(dir)
+		delete(w.watched, dir)
+	}
+
+	var failed int
+	for dir := range dirs {
+		if _, ok := w.watched[dir]; ok {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			failed++
+			level.Debug(w.log).Log("msg", "failed to watch directory for filesystem events", "dir", dir, "err", err)
+			continue
+		}
+		w.watched[dir] = struct{}{}
+	}
+	if failed > 0 {
+		level.Warn(w.log).Log("msg", "some directories can't be watched for filesystem events and will only be discovered by polling", "count", failed)
+	}
+}
+
+// Close stops watching for events.
+func (w *eventWatcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+// patternDirs returns the existing directories which must be watched to
+// detect files matching pattern being created or removed. This includes the
+// directories leading up to the matching files, so that newly created
+// directories matching the pattern can be discovered.
+func patternDirs(pattern string) []string {
+	pattern = filepath.Clean(pattern)
+
+	dir := filepath.Dir(pattern)
+	if filepath.Base(pattern) == "**" {
+		// A trailing ** matches files in every subdirectory.
+		dir = pattern
+	}
+
+	sep := string(filepath.Separator)
+	segments := strings.Split(dir, sep)
+
+	// Find the base directory, which is the longest prefix without glob
+	// metacharacters.
+	var i int
+	for i < len(segments) && !hasMeta(segments[i]) {
+		i++
+	}
+	base := strings.Join(segments[:i], sep)
+	switch {
+	case base == "" && filepath.IsAbs(pattern):
+		base = sep
+	case base == "":
+		base = "."
+	}
+
+	var (
+		res     []string
+		current = []string{base}
+	)
+	for _, segment := range segments[i:] {
+		res = append(res, current...)
+
+		if segment == "**" {
+			// Every directory below the current ones may contain matches.
+			for _, d := range current {
+				res = append(res, subdirectories(d)...)
+			}
+			return res
+		}
+
+		var next []string
+		for _, d := range current {
+			matches, err := doublestar.Glob(filepath.Join(d, segment))
+			if err != nil {
+				continue
+			}
+			for _, m := range matches {
+				if isDir(m) {
+					next = append(next, m)
+				}
+			}
+		}
+		current = next
+	}
+	return append(res, current...)
+}
+
+// subdirectories returns all directories below dir.
+func subdirectories(dir string) []string {
+	var res []string
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// Skip directories which can't be read rather than aborting.
+			return nil
+		}
+		if d.IsDir() && path != dir {
+			res = append(res, path)
+		}
+		return nil
+	})
+	return res
+}
+
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[{\`)
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
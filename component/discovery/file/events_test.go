@@ -0,0 +1,105 @@
+//go:build !windows
+
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestPatternDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"a/x", "a/y/z", "b/x", "c"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, d), 0755))
+	}
+
+	tt := []struct {
+		pattern string
+		expect  []string
+	}{
+		{
+			pattern: "a/x/*.log",
+			expect:  []string{"a/x"},
+		},
+		{
+			pattern: "*/x/*.log",
+			expect:  []string{".", "a", "b", "c", "a/x", "b/x"},
+		},
+		{
+			pattern: "a/**/*.log",
+			expect:  []string{"a", "a/x", "a/y", "a/y/z"},
+		},
+		{
+			pattern: "a/**",
+			expect:  []string{"a", "a/x", "a/y", "a/y/z"},
+		},
+		{
+			pattern: "missing/*/*.log",
+			expect:  []string{"missing"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.pattern, func(t *testing.T) {
+			var expect []string
+			for _, e := range tc.expect {
+				expect = append(expect, filepath.Join(dir, e))
+			}
+			actual := patternDirs(filepath.Join(dir, tc.pattern))
+
+			sort.Strings(expect)
+			sort.Strings(actual)
+			require.Equal(t, expect, actual)
+		})
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "t1.txt")
+
+	var found atomic.Int64
+	c, err := New(component.Options{
+		ID:     "test",
+		Logger: util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {
+			found.Store(int64(len(e.(discovery.Exports).Targets)))
+		},
+		Registerer: prometheus.NewRegistry(),
+	}, Arguments{
+		PathTargets: []discovery.Target{{"__path__": filepath.Join(dir, "**", "*.txt")}},
+		SyncPeriod:  time.Hour,
+		WatchEvents: true,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool { return found.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// Files created after startup are discovered without waiting for the sync
+	// period, including those in newly created directories.
+	writeFile(t, dir, "t2.txt")
+	require.Eventually(t, func() bool { return found.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	subdir := filepath.Join(dir, "subdir")
+	require.NoError(t, os.Mkdir(subdir, 0755))
+	writeFile(t, subdir, "t3.txt")
+	require.Eventually(t, func() bool { return found.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "t1.txt")))
+	require.Eventually(t, func() bool { return found.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+}
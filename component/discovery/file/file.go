@@ -19,6 +19,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
@@ -27,6 +29,7 @@ func init() {
 type Arguments struct {
 	PathTargets []discovery.Target `river:"path_targets,attr"`
 	SyncPeriod  time.Duration      `river:"sync_period,attr,optional"`
+	WatchEvents bool               `river:"watch_events,attr,optional"`
 }
 
 var _ component.Component = (*Component)(nil)
@@ -39,6 +42,7 @@ type Component struct {
 	args     Arguments
 	watches  []watch
 	watchDog *time.Ticker
+	reload   chan struct{}
 }
 
 // New creates a new discovery.file component.
@@ -49,6 +53,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		args:     args,
 		watches:  make([]watch, 0),
 		watchDog: time.NewTicker(args.SyncPeriod),
+		reload:   make(chan struct{}, 1),
 	}
 
 	if err := c.Update(args); err != nil {
@@ -86,6 +91,11 @@ func (c *Component) Update(args component.Arguments) error {
 		})
 	}
 
+	select {
+	case c.reload <- struct{}{}:
+	default:
+		// Reload is already pending.
+	}
 	return nil
 }
 
@@ -99,13 +109,62 @@ func (c *Component) Run(ctx context.Context) error {
 		// The component node checks to see if exports have actually changed.
 		c.opts.OnStateChange(discovery.Exports{Targets: paths})
 	}
+
+	// events is set when watching for filesystem events is enabled. Polling
+	// continues while events are watched, so that changes in directories which
+	// can't be watched are still discovered.
+	var events *eventWatcher
+	defer func() {
+		if events != nil {
+			events.Close()
+		}
+	}()
+	updateEvents := func() {
+		c.mut.RLock()
+		enabled := c.args.WatchEvents
+		patterns := c.getPatterns()
+		c.mut.RUnlock()
+
+		switch {
+		case enabled && events == nil:
+			w, err := newEventWatcher(c.opts.Logger)
+			if err != nil {
+				level.Warn(c.opts.Logger).Log("msg", "filesystem events unavailable, falling back to polling", "err", err)
+				return
+			}
+			events = w
+		case !enabled && events != nil:
+			events.Close()
+			events = nil
+		}
+		if events != nil {
+			// Refresh the watched directories, as directories matching the
+			// patterns may have been created or removed.
+			events.SetPatterns(patterns)
+		}
+	}
+	eventsChanged := func() <-chan struct{} {
+		if events == nil {
+			return nil
+		}
+		return events.Changed()
+	}
+
 	// Trigger initial check
+	updateEvents()
 	update()
 	defer c.watchDog.Stop()
 	for {
 		select {
 		case <-c.watchDog.C:
 			// This triggers a check for any new paths, along with pushing new targets.
+			updateEvents()
+			update()
+		case <-eventsChanged():
+			updateEvents()
+			update()
+		case <-c.reload:
+			updateEvents()
 			update()
 		case <-ctx.Done():
 			return nil
@@ -113,6 +172,18 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// getPatterns returns the glob patterns of all watches. c.mut must be held
+// when calling.
+func (c *Component) getPatterns() []string {
+	patterns := make([]string, 0, len(c.watches))
+	for _, w := range c.watches {
+		if p := w.getPath(); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
 func (c *Component) getWatchedFiles() []discovery.Target {
 	paths := make([]discovery.Target, 0)
 	// See if there is anything new we need to check.
@@ -2,12 +2,15 @@ package relabel
 
 import (
 	"context"
+	"reflect"
 	"sync"
 
+	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/discovery"
-	"github.com/prometheus/prometheus/model/labels"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 )
 
@@ -20,6 +23,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
@@ -30,6 +35,25 @@ type Arguments struct {
 
 	// The relabelling rules to apply to each target's label set.
 	RelabelConfigs []*flow_relabel.Config `river:"rule,block,optional"`
+
+	// The maximum number of items to hold in the component's LRU cache.
+	MaxCacheSize int `river:"max_cache_size,attr,optional"`
+}
+
+// DefaultArguments provides the default arguments for the discovery.relabel
+// component.
+var DefaultArguments = Arguments{
+	MaxCacheSize: 10_000,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(a))
 }
 
 // Exports holds values which are exported by the discovery.relabel component.
@@ -42,15 +66,26 @@ type Exports struct {
 type Component struct {
 	opts component.Options
 
-	mut sync.RWMutex
-	rcs []*relabel.Config
+	mut          sync.RWMutex
+	rcs          []*relabel.Config
+	cache        *flow_relabel.Cache
+	maxCacheSize int
 }
 
 var _ component.Component = (*Component)(nil)
 
 // New creates a new discovery.relabel component.
 func New(o component.Options, args Arguments) (*Component, error) {
-	c := &Component{opts: o}
+	cache, err := flow_relabel.NewCache(args.MaxCacheSize, "discovery_relabel", o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:         o,
+		cache:        cache,
+		maxCacheSize: args.MaxCacheSize,
+	}
 
 	// Call to Update() to set the output once at the start
 	if err := c.Update(args); err != nil {
@@ -73,14 +108,24 @@ func (c *Component) Update(args component.Arguments) error {
 
 	newArgs := args.(Arguments)
 
-	targets := make([]discovery.Target, 0, len(newArgs.Targets))
 	relabelConfigs := flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelConfigs)
+	if relabelingChanged(c.rcs, relabelConfigs) {
+		level.Debug(c.opts.Logger).Log("msg", "received new relabel configs, purging cache")
+		c.cache.Purge()
+	}
+	if newArgs.MaxCacheSize != c.maxCacheSize {
+		evicted := c.cache.Resize(newArgs.MaxCacheSize)
+		if evicted > 0 {
+			level.Debug(c.opts.Logger).Log("msg", "resizing the cache lead to evicting of items", "len_items_evicted", evicted)
+		}
+		c.maxCacheSize = newArgs.MaxCacheSize
+	}
 	c.rcs = relabelConfigs
 
+	targets := make([]discovery.Target, 0, len(newArgs.Targets))
 	for _, t := range newArgs.Targets {
-		lset := componentMapToPromLabels(t)
-		lset, keep := relabel.Process(lset, relabelConfigs...)
-		if keep {
+		lset := c.relabel(t)
+		if len(lset) > 0 {
 			targets = append(targets, promLabelsToComponent(lset))
 		}
 	}
@@ -93,19 +138,61 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
-func componentMapToPromLabels(ls discovery.Target) labels.Labels {
-	res := make([]labels.Label, 0, len(ls))
+// relabel returns the relabeled label set for t, consulting the cache first.
+// An empty label set is returned if t was dropped.
+func (c *Component) relabel(t discovery.Target) model.LabelSet {
+	fp := model.Fingerprint(model.LabelsToSignature(t))
+
+	lset, found := c.cache.Get(fp, func(original model.LabelSet) bool {
+		return targetEqual(t, original)
+	})
+	if found {
+		return lset
+	}
+
+	original := componentMapToPromLabels(t)
+	lset = flow_relabel.Process(original, c.rcs)
+	c.cache.Add(fp, original, lset)
+	return lset
+}
+
+func relabelingChanged(prev, next []*relabel.Config) bool {
+	if len(prev) != len(next) {
+		return true
+	}
+	for i := range prev {
+		if !reflect.DeepEqual(prev[i], next[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func targetEqual(t discovery.Target, lset model.LabelSet) bool {
+	if len(t) != len(lset) {
+		return false
+	}
+	for k, v := range t {
+		if other, ok := lset[model.LabelName(k)]; !ok || string(other) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func componentMapToPromLabels(ls discovery.Target) model.LabelSet {
+	res := make(model.LabelSet, len(ls))
 	for k, v := range ls {
-		res = append(res, labels.Label{Name: k, Value: v})
+		res[model.LabelName(k)] = model.LabelValue(v)
 	}
 
 	return res
 }
 
-func promLabelsToComponent(ls labels.Labels) discovery.Target {
+func promLabelsToComponent(ls model.LabelSet) discovery.Target {
 	res := make(map[string]string, len(ls))
-	for _, l := range ls {
-		res[l.Name] = l.Value
+	for k, v := range ls {
+		res[string(k)] = string(v)
 	}
 
 	return res
@@ -0,0 +1,264 @@
+package receive
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/otelcol"
+	faro "github.com/grafana/agent/pkg/integrations/v2/app_agent_receiver"
+	"github.com/grafana/loki/pkg/logproto"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+	"golang.org/x/time/rate"
+)
+
+// apiKeyHeader is the header holding the API key of requests, as sent by the
+// Faro web SDK.
+const apiKeyHeader = "x-api-key"
+
+// measurementMetricName is the name of the metric measurements are converted
+// into.
+const measurementMetricName = "faro_measurement"
+
+func (c *Component) handleCollect(w http.ResponseWriter, r *http.Request) {
+	c.mut.RLock()
+	args, rateLimiter := c.args, c.rateLimiter
+	c.mut.RUnlock()
+
+	status, err := c.collect(r, args, rateLimiter)
+	c.metrics.requests.WithLabelValues(strconv.Itoa(status)).Inc()
+	if err != nil {
+		level.Debug(c.opts.Logger).Log("msg", "rejected faro payload", "status", status, "err", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// collect forwards the signals of a payload, returning the status code to
+// respond with.
+func (c *Component) collect(r *http.Request, args Arguments, rateLimiter *rate.Limiter) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	if args.Server.RateLimiting.Enabled && !rateLimiter.Allow() {
+		return http.StatusTooManyRequests, errors.New(http.StatusText(http.StatusTooManyRequests))
+	}
+	if key := string(args.Server.APIKey); key != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(apiKeyHeader)), []byte(key)) == 0 {
+		return http.StatusUnauthorized, errors.New("api key not provided or incorrect")
+	}
+
+	var p faro.Payload
+	body := http.MaxBytesReader(nil, r.Body, int64(args.Server.MaxAllowedPayloadSize))
+	if err := json.NewDecoder(body).Decode(&p); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return http.StatusRequestEntityTooLarge, fmt.Errorf("payload larger than %s", args.Server.MaxAllowedPayloadSize)
+		}
+		return http.StatusBadRequest, err
+	}
+	c.metrics.observePayload(p)
+
+	// Every signal is forwarded even if forwarding another one failed.
+	var errs error
+	if err := c.forwardLogs(r.Context(), args, p); err != nil {
+		c.metrics.forwardErrors.WithLabelValues(signalLogs).Inc()
+		errs = multierr.Append(errs, err)
+	}
+	if err := c.forwardMeasurements(r.Context(), p); err != nil {
+		c.metrics.forwardErrors.WithLabelValues(signalMetrics).Inc()
+		errs = multierr.Append(errs, err)
+	}
+	if err := forwardTraces(r.Context(), args.Output.Traces, p); err != nil {
+		c.metrics.forwardErrors.WithLabelValues(signalTraces).Inc()
+		errs = multierr.Append(errs, err)
+	}
+	if errs != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to forward faro payload", "err", errs)
+		return http.StatusInternalServerError, errs
+	}
+	return http.StatusAccepted, nil
+}
+
+// forwardLogs forwards the logs, exceptions, measurements, and events of a
+// payload as logfmt log lines.
+func (c *Component) forwardLogs(ctx context.Context, args Arguments, p faro.Payload) error {
+	receivers := args.Output.Logs
+	if len(receivers) == 0 {
+		return nil
+	}
+
+	meta := p.Meta.KeyVal()
+	var kvs []*faro.KeyVal
+	for _, l := range p.Logs {
+		kvs = append(kvs, l.KeyVal())
+	}
+	for _, e := range p.Exceptions {
+		kvs = append(kvs, e.KeyVal())
+	}
+	for _, m := range p.Measurements {
+		kvs = append(kvs, m.KeyVal())
+	}
+	for _, e := range p.Events {
+		kvs = append(kvs, e.KeyVal())
+	}
+
+	now := time.Now()
+	for _, kv := range kvs {
+		faro.MergeKeyVal(kv, meta)
+		line, err := logfmt.MarshalKeyvals(faro.KeyValToInterfaceSlice(kv)...)
+		if err != nil {
+			return fmt.Errorf("failed to encode log line: %w", err)
+		}
+
+		entry := loki.Entry{
+			Labels: logLabels(args.LogLabels, kv),
+			Entry:  logproto.Entry{Timestamp: now, Line: string(line)},
+		}
+		for _, receiver := range receivers {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to forward log entries: %w", ctx.Err())
+			case receiver <- entry:
+			}
+		}
+	}
+	return nil
+}
+
+// logLabels returns the labels of a log entry. Labels with an empty value
+// take their value from the key of the same name of the entry.
+func logLabels(cfg map[string]string, kv *faro.KeyVal) model.LabelSet {
+	set := make(model.LabelSet, len(cfg))
+	for k, v := range cfg {
+		if v == "" {
+			val, ok := kv.Get(k)
+			if !ok {
+				continue
+			}
+			v = fmt.Sprint(val)
+		}
+		set[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return set
+}
+
+// forwardMeasurements forwards the values of the measurements of a payload as
+// samples of the faro_measurement metric.
+func (c *Component) forwardMeasurements(ctx context.Context, p faro.Payload) error {
+	if len(p.Measurements) == 0 {
+		return nil
+	}
+
+	app := c.fanout.Appender(ctx)
+	for _, m := range p.Measurements {
+		ts := m.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+
+		keys := make([]string, 0, len(m.Values))
+		for k := range m.Values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			lbls := labels.FromStrings(
+				model.MetricNameLabel, measurementMetricName,
+				"app_name", p.Meta.App.Name,
+				"app_environment", p.Meta.App.Environment,
+				"type", k,
+			)
+			if _, err := app.Append(0, lbls, ts.UnixMilli(), m.Values[k]); err != nil {
+				_ = app.Rollback()
+				return fmt.Errorf("failed to forward sample for series %s: %w", lbls, err)
+			}
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return fmt.Errorf("failed to forward samples: %w", err)
+	}
+	return nil
+}
+
+// forwardTraces forwards the traces of a payload to consumers.
+func forwardTraces(ctx context.Context, consumers []otelcol.Consumer, p faro.Payload) error {
+	if p.Traces == nil || p.Traces.SpanCount() == 0 {
+		return nil
+	}
+
+	var errs error
+	for i, consumer := range consumers {
+		td := p.Traces.Traces
+		// Give every consumer but the last its own copy, in case it mutates
+		// the traces.
+		if i < len(consumers)-1 && consumer.Capabilities().MutatesData {
+			td = ptrace.NewTraces()
+			p.Traces.CopyTo(td)
+		}
+		errs = multierr.Append(errs, consumer.ConsumeTraces(ctx, td))
+	}
+	return errs
+}
+
+// Signals of payloads, used as label values of metrics.
+const (
+	signalLogs    = "logs"
+	signalMetrics = "metrics"
+	signalTraces  = "traces"
+)
+
+type metrics struct {
+	requests      *prometheus_client.CounterVec
+	received      *prometheus_client.CounterVec
+	forwardErrors *prometheus_client.CounterVec
+}
+
+func newMetrics(reg prometheus_client.Registerer) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_faro_receive_requests_total",
+			Help: "Total number of requests received, by status code.",
+		}, []string{"status_code"}),
+		received: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_faro_receive_items_total",
+			Help: "Total number of logs, exceptions, measurements, events, and spans received, by kind.",
+		}, []string{"kind"}),
+		forwardErrors: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_faro_receive_forward_errors_total",
+			Help: "Total number of payloads which failed to be forwarded, by signal.",
+		}, []string{"signal"}),
+	}
+
+	for _, c := range []prometheus_client.Collector{m.requests, m.received, m.forwardErrors} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *metrics) observePayload(p faro.Payload) {
+	m.received.WithLabelValues("log").Add(float64(len(p.Logs)))
+	m.received.WithLabelValues("exception").Add(float64(len(p.Exceptions)))
+	m.received.WithLabelValues("measurement").Add(float64(len(p.Measurements)))
+	m.received.WithLabelValues("event").Add(float64(len(p.Events)))
+	if p.Traces != nil {
+		m.received.WithLabelValues("span").Add(float64(p.Traces.SpanCount()))
+	}
+}
@@ -0,0 +1,265 @@
+// Package receive implements the faro.receive component.
+package receive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/rs/cors"
+	"golang.org/x/time/rate"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "faro.receive",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// collectPath is the path Faro web SDK payloads are received on.
+const collectPath = "/collect"
+
+// Arguments holds values which are used to configure the faro.receive
+// component.
+type Arguments struct {
+	Server    ServerArguments   `river:"server,block,optional"`
+	LogLabels map[string]string `river:"log_labels,attr,optional"`
+
+	Output *OutputArguments `river:"output,block"`
+}
+
+// ServerArguments configures the HTTP server payloads are received on.
+type ServerArguments struct {
+	ListenAddress         string            `river:"listen_address,attr,optional"`
+	ListenPort            int               `river:"listen_port,attr,optional"`
+	CORSAllowedOrigins    []string          `river:"cors_allowed_origins,attr,optional"`
+	APIKey                rivertypes.Secret `river:"api_key,attr,optional"`
+	MaxAllowedPayloadSize units.Base2Bytes  `river:"max_allowed_payload_size,attr,optional"`
+
+	RateLimiting RateLimitingArguments `river:"rate_limiting,block,optional"`
+}
+
+// RateLimitingArguments configures limiting the rate of received payloads.
+type RateLimitingArguments struct {
+	Enabled   bool    `river:"enabled,attr,optional"`
+	Rate      float64 `river:"rate,attr,optional"`
+	BurstSize int     `river:"burst_size,attr,optional"`
+}
+
+// OutputArguments configures where to send the signals of received
+// payloads.
+type OutputArguments struct {
+	Logs    []loki.LogsReceiver  `river:"logs,attr,optional"`
+	Metrics []storage.Appendable `river:"metrics,attr,optional"`
+	Traces  []otelcol.Consumer   `river:"traces,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Server: ServerArguments{
+		ListenAddress:         "127.0.0.1",
+		ListenPort:            12347,
+		MaxAllowedPayloadSize: 5 * units.MiB,
+		RateLimiting: RateLimitingArguments{
+			Enabled:   true,
+			Rate:      50,
+			BurstSize: 100,
+		},
+	},
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Server.ListenPort <= 0 || args.Server.ListenPort > 65535:
+		return fmt.Errorf("server listen_port must be between 1 and 65535")
+	case args.Server.MaxAllowedPayloadSize <= 0:
+		return fmt.Errorf("server max_allowed_payload_size must be greater than 0")
+	case args.Server.RateLimiting.Enabled && args.Server.RateLimiting.Rate <= 0:
+		return fmt.Errorf("rate_limiting rate must be greater than 0")
+	case args.Server.RateLimiting.Enabled && args.Server.RateLimiting.BurstSize <= 0:
+		return fmt.Errorf("rate_limiting burst_size must be greater than 0")
+	}
+	return nil
+}
+
+// Component implements the faro.receive component.
+type Component struct {
+	opts    component.Options
+	fanout  *prometheus.Fanout
+	metrics *metrics
+
+	mut         sync.RWMutex
+	args        Arguments
+	rateLimiter *rate.Limiter
+	handler     http.Handler
+	server      *http.Server
+	listener    net.Listener
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new faro.receive component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := newMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.Output.Metrics, o.ID, o.Registerer),
+		metrics: m,
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.stopServer()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.fanout.UpdateChildren(newArgs.Output.Metrics)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.args.Server.RateLimiting != newArgs.Server.RateLimiting || c.rateLimiter == nil {
+		rl := newArgs.Server.RateLimiting
+		c.rateLimiter = rate.NewLimiter(rate.Limit(rl.Rate), rl.BurstSize)
+	}
+
+	c.handler = c.newHandler(newArgs.Server.CORSAllowedOrigins)
+
+	if c.server == nil || !serverAddressEqual(c.args.Server, newArgs.Server) {
+		c.stopServer()
+		if err := c.startServer(newArgs.Server); err != nil {
+			return err
+		}
+	}
+
+	c.args = newArgs
+	return nil
+}
+
+func serverAddressEqual(a, b ServerArguments) bool {
+	return a.ListenAddress == b.ListenAddress && a.ListenPort == b.ListenPort
+}
+
+// startServer starts the HTTP server. c.mut must be held when calling.
+func (c *Component) startServer(cfg ServerArguments) error {
+	addr := net.JoinHostPort(cfg.ListenAddress, strconv.Itoa(cfg.ListenPort))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(collectPath, c.serveHTTP)
+
+	c.listener = lis
+	c.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(c.opts.Logger).Log("msg", "http server exited with error", "err", err)
+		}
+	}(c.server)
+
+	level.Info(c.opts.Logger).Log("msg", "receiving faro payloads", "addr", lis.Addr().String(), "path", collectPath)
+	return nil
+}
+
+// stopServer stops the HTTP server. c.mut must be held when calling.
+func (c *Component) stopServer() {
+	if c.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to gracefully stop http server", "err", err)
+	}
+	c.server, c.listener = nil, nil
+}
+
+// newHandler returns the handler of collect requests, answering CORS
+// preflight requests from the allowed origins.
+func (c *Component) newHandler(origins []string) http.Handler {
+	handler := http.Handler(http.HandlerFunc(c.handleCollect))
+	if len(origins) == 0 {
+		return handler
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodPost},
+		AllowedHeaders: []string{apiKeyHeader, "content-type"},
+	}).Handler(handler)
+}
+
+// serveHTTP serves requests with the handler of the latest arguments, so that
+// allowed origins can be updated without restarting the server.
+func (c *Component) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mut.RLock()
+	handler := c.handler
+	c.mut.RUnlock()
+	handler.ServeHTTP(w, r)
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var info debugInfo
+	if c.listener != nil {
+		info.Address = c.listener.Addr().String()
+	}
+	return info
+}
+
+type debugInfo struct {
+	Address string `river:"address,attr,optional"`
+}
@@ -0,0 +1,200 @@
+package receive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		server {
+			api_key = "secret"
+		}
+		output {}
+	`), &args))
+	require.Equal(t, "127.0.0.1", args.Server.ListenAddress)
+	require.Equal(t, 12347, args.Server.ListenPort)
+	require.EqualValues(t, 5<<20, args.Server.MaxAllowedPayloadSize)
+	require.True(t, args.Server.RateLimiting.Enabled)
+
+	err := river.Unmarshal([]byte(`
+		server {
+			rate_limiting {
+				rate = 0
+			}
+		}
+		output {}
+	`), &args)
+	require.EqualError(t, err, "rate_limiting rate must be greater than 0")
+}
+
+const testPayload = `{
+	"logs": [{
+		"message": "click",
+		"level": "info",
+		"timestamp": "2021-09-30T10:46:17.680Z"
+	}],
+	"exceptions": [{
+		"type": "Error",
+		"value": "Cannot read property 'find' of undefined",
+		"timestamp": "2021-09-30T10:46:17.680Z"
+	}],
+	"measurements": [{
+		"values": {"ttfb": 14.5, "fcp": 220},
+		"timestamp": "2021-09-30T10:46:17.680Z"
+	}],
+	"traces": {
+		"resourceSpans": [{
+			"scopeSpans": [{
+				"spans": [{
+					"traceId": "2d6f18da2663c7e477df23d8a8ad95b7",
+					"spanId": "50e64e3fac969cbb",
+					"name": "documentFetch"
+				}]
+			}]
+		}]
+	},
+	"meta": {
+		"app": {"name": "shop", "environment": "production"}
+	}
+}`
+
+func TestComponent(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received = make(map[string]float64)
+	)
+	metricsReceiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		received[l.String()] = v
+		return ref, nil
+	}))
+	logs := make(loki.LogsReceiver, 10)
+	traces := &tracesConsumer{}
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		server {
+			cors_allowed_origins = ["https://shop.example.com"]
+			api_key              = "secret"
+		}
+		log_labels = {
+			app  = "frontend",
+			kind = "",
+		}
+		output {}
+	`), &args))
+	args.Server.ListenPort = 0 // Pick a free port.
+	args.Output = &OutputArguments{
+		Logs:    []loki.LogsReceiver{logs},
+		Metrics: []storage.Appendable{metricsReceiver},
+		Traces:  []otelcol.Consumer{traces},
+	}
+
+	c, err := New(component.Options{
+		ID:         "faro.receive.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	url := fmt.Sprintf("http://%s%s", c.DebugInfo().(debugInfo).Address, collectPath)
+	send := func(method, apiKey, body string) *http.Response {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Origin", "https://shop.example.com")
+		if apiKey != "" {
+			req.Header.Set(apiKeyHeader, apiKey)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", apiKeyHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := send(http.MethodOptions, "", "")
+	require.Equal(t, "https://shop.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	require.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "wrong", testPayload).StatusCode)
+	require.Equal(t, http.StatusBadRequest, send(http.MethodPost, "secret", "{").StatusCode)
+
+	resp = send(http.MethodPost, "secret", testPayload)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, "https://shop.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	// Logs, exceptions, and measurements are all forwarded as log lines.
+	var kinds []string
+	for i := 0; i < 3; i++ {
+		entry := <-logs
+		require.Equal(t, model.LabelValue("frontend"), entry.Labels["app"])
+		kinds = append(kinds, string(entry.Labels["kind"]))
+		require.Contains(t, entry.Line, "app_name=shop")
+	}
+	require.Equal(t, []string{"log", "exception", "measurement"}, kinds)
+
+	mut.Lock()
+	require.Equal(t, map[string]float64{
+		`{__name__="faro_measurement", app_environment="production", app_name="shop", type="fcp"}`:  220,
+		`{__name__="faro_measurement", app_environment="production", app_name="shop", type="ttfb"}`: 14.5,
+	}, received)
+	mut.Unlock()
+
+	require.Equal(t, 1, traces.spanCount())
+}
+
+// tracesConsumer is an otelcol.Consumer which counts received spans.
+type tracesConsumer struct {
+	mut   sync.Mutex
+	spans int
+}
+
+func (c *tracesConsumer) spanCount() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.spans
+}
+
+func (c *tracesConsumer) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{}
+}
+
+func (c *tracesConsumer) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.spans += td.SpanCount()
+	return nil
+}
+
+func (c *tracesConsumer) ConsumeMetrics(context.Context, pmetric.Metrics) error { return nil }
+
+func (c *tracesConsumer) ConsumeLogs(context.Context, plog.Logs) error { return nil }
@@ -82,6 +82,7 @@ func (c *Component) Run(ctx context.Context) error {
 			return nil
 		case entry := <-c.receiver:
 			level.Info(c.opts.Logger).Log("receiver", c.opts.ID, "entry", entry.Line, "labels", entry.Labels.String())
+			entry.Ack.Done()
 		}
 	}
 }
@@ -22,6 +22,7 @@ type CounterConfig struct {
 	Prefix      string        `river:"prefix,attr,optional"`
 	MaxIdle     time.Duration `river:"max_idle_duration,attr,optional"`
 	Value       string        `river:"value,attr,optional"`
+	Expression  string        `river:"expression,attr,optional"`
 
 	// Counter-specific fields
 	Action          string `river:"action,attr"`
@@ -47,13 +48,19 @@ func (c *CounterConfig) UnmarshalRiver(f func(v interface{}) error) error {
 		return fmt.Errorf("max_idle_duration must be greater or equal than 1s")
 	}
 
-	if c.Source == "" {
+	if c.Expression != "" && c.Source != "" {
+		return fmt.Errorf("only one of 'source' or 'expression' can be set")
+	}
+	if c.Source == "" && c.Expression == "" {
 		c.Source = c.Name
 	}
 	if c.Action != CounterInc && c.Action != CounterAdd {
 		return fmt.Errorf("the 'action' counter field must be either 'inc' or 'add'")
 	}
 
+	if c.MatchAll && c.Expression != "" {
+		return fmt.Errorf("a 'counter' metric supports either 'match_all' or an 'expression', but not both")
+	}
 	if c.MatchAll && c.Value != "" {
 		return fmt.Errorf("a 'counter' metric supports either 'match_all' or a 'value', but not both")
 	}
@@ -73,12 +80,12 @@ type Counters struct {
 func NewCounters(name string, config *CounterConfig) (*Counters, error) {
 	return &Counters{
 		metricVec: newMetricVec(func(labels map[string]string) prometheus.Metric {
-			return &expiringCounter{prometheus.NewCounter(prometheus.CounterOpts{
-				Help:        config.Description,
-				Name:        name,
-				ConstLabels: labels,
-			}),
-				0,
+			return &expiringCounter{
+				Counter: prometheus.NewCounter(prometheus.CounterOpts{
+					Help:        config.Description,
+					Name:        name,
+					ConstLabels: labels,
+				}),
 			}
 		}, int64(config.MaxIdle.Seconds())),
 		Cfg: config,
@@ -92,24 +99,19 @@ func (c *Counters) With(labels model.LabelSet) prometheus.Counter {
 
 type expiringCounter struct {
 	prometheus.Counter
-	lastModSec int64
+	lastModified
 }
 
 // Inc increments the counter by 1. Use Add to increment it by arbitrary
 // non-negative values.
 func (e *expiringCounter) Inc() {
 	e.Counter.Inc()
-	e.lastModSec = time.Now().Unix()
+	e.touch()
 }
 
 // Add adds the given value to the counter. It panics if the value is <
 // 0.
 func (e *expiringCounter) Add(val float64) {
 	e.Counter.Add(val)
-	e.lastModSec = time.Now().Unix()
-}
-
-// HasExpired implements Expirable
-func (e *expiringCounter) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
-	return currentTimeSec-e.lastModSec >= maxAgeSec
+	e.touch()
 }
@@ -42,6 +42,29 @@ func TestCounterExpiration(t *testing.T) {
 	assert.Contains(t, cnt.metrics, lbl2.Fingerprint())
 }
 
+func TestCounterExpirationWithoutCollect(t *testing.T) {
+	t.Parallel()
+	cfg := &CounterConfig{
+		Action:  "inc",
+		MaxIdle: 1 * time.Second,
+	}
+
+	cnt, err := NewCounters("test1", cfg)
+	assert.Nil(t, err)
+
+	lbl1 := model.LabelSet{"pod": "ephemeral"}
+	cnt.With(lbl1).Inc()
+
+	time.Sleep(1100 * time.Millisecond) // Wait just past our max idle of 1 sec
+
+	// Updating another counter removes the expired one, even if the metrics
+	// are never collected.
+	lbl2 := model.LabelSet{"pod": "long-lived"}
+	cnt.With(lbl2).Inc()
+	assert.NotContains(t, cnt.metrics, lbl1.Fingerprint())
+	assert.Contains(t, cnt.metrics, lbl2.Fingerprint())
+}
+
 func collect(c prometheus.Collector) {
 	done := make(chan struct{})
 	collector := make(chan prometheus.Metric)
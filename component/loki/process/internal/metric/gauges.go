@@ -33,6 +33,7 @@ type GaugeConfig struct {
 	Prefix      string        `river:"prefix,attr,optional"`
 	MaxIdle     time.Duration `river:"max_idle_duration,attr,optional"`
 	Value       string        `river:"value,attr,optional"`
+	Expression  string        `river:"expression,attr,optional"`
 
 	// Gauge-specific fields
 	Action string `river:"action,attr"`
@@ -51,7 +52,10 @@ func (g *GaugeConfig) UnmarshalRiver(f func(v interface{}) error) error {
 		return fmt.Errorf("max_idle_duration must be greater or equal than 1s")
 	}
 
-	if g.Source == "" {
+	if g.Expression != "" && g.Source != "" {
+		return fmt.Errorf("only one of 'source' or 'expression' can be set")
+	}
+	if g.Source == "" && g.Expression == "" {
 		g.Source = g.Name
 	}
 
@@ -72,12 +76,12 @@ type Gauges struct {
 func NewGauges(name string, config *GaugeConfig) (*Gauges, error) {
 	return &Gauges{
 		metricVec: newMetricVec(func(labels map[string]string) prometheus.Metric {
-			return &expiringGauge{prometheus.NewGauge(prometheus.GaugeOpts{
-				Help:        config.Description,
-				Name:        name,
-				ConstLabels: labels,
-			}),
-				0,
+			return &expiringGauge{
+				Gauge: prometheus.NewGauge(prometheus.GaugeOpts{
+					Help:        config.Description,
+					Name:        name,
+					ConstLabels: labels,
+				}),
 			}
 		}, int64(config.MaxIdle.Seconds())),
 		Cfg: config,
@@ -91,50 +95,45 @@ func (g *Gauges) With(labels model.LabelSet) prometheus.Gauge {
 
 type expiringGauge struct {
 	prometheus.Gauge
-	lastModSec int64
+	lastModified
 }
 
 // Set sets the Gauge to an arbitrary value.
 func (g *expiringGauge) Set(val float64) {
 	g.Gauge.Set(val)
-	g.lastModSec = time.Now().Unix()
+	g.touch()
 }
 
 // Inc increments the Gauge by 1. Use Add to increment it by arbitrary
 // values.
 func (g *expiringGauge) Inc() {
 	g.Gauge.Inc()
-	g.lastModSec = time.Now().Unix()
+	g.touch()
 }
 
 // Dec decrements the Gauge by 1. Use Sub to decrement it by arbitrary
 // values.
 func (g *expiringGauge) Dec() {
 	g.Gauge.Dec()
-	g.lastModSec = time.Now().Unix()
+	g.touch()
 }
 
 // Add adds the given value to the Gauge. (The value can be negative,
 // resulting in a decrease of the Gauge.)
 func (g *expiringGauge) Add(val float64) {
 	g.Gauge.Add(val)
-	g.lastModSec = time.Now().Unix()
+	g.touch()
 }
 
 // Sub subtracts the given value from the Gauge. (The value can be
 // negative, resulting in an increase of the Gauge.)
 func (g *expiringGauge) Sub(val float64) {
 	g.Gauge.Sub(val)
-	g.lastModSec = time.Now().Unix()
+	g.touch()
 }
 
 // SetToCurrentTime sets the Gauge to the current Unix time in seconds.
 func (g *expiringGauge) SetToCurrentTime() {
 	g.Gauge.SetToCurrentTime()
-	g.lastModSec = time.Now().Unix()
-}
-
-// HasExpired implements Expirable
-func (g *expiringGauge) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
-	return currentTimeSec-g.lastModSec >= maxAgeSec
+	g.touch()
 }
@@ -22,9 +22,10 @@ type HistogramConfig struct {
 	Prefix      string        `river:"prefix,attr,optional"`
 	MaxIdle     time.Duration `river:"max_idle_duration,attr,optional"`
 	Value       string        `river:"value,attr,optional"`
+	Expression  string        `river:"expression,attr,optional"`
 
 	// Histogram-specific fields
-	Buckets []float64 `river:"buckets,attr"`
+	Buckets []float64 `river:"buckets,attr,optional"`
 }
 
 // UnmarshalRiver implements the unmarshaller
@@ -36,11 +37,18 @@ func (h *HistogramConfig) UnmarshalRiver(f func(v interface{}) error) error {
 		return err
 	}
 
+	if len(h.Buckets) == 0 {
+		h.Buckets = prometheus.DefBuckets
+	}
+
 	if h.MaxIdle < 1*time.Second {
 		return fmt.Errorf("max_idle_duration must be greater or equal than 1s")
 	}
 
-	if h.Source == "" {
+	if h.Expression != "" && h.Source != "" {
+		return fmt.Errorf("only one of 'source' or 'expression' can be set")
+	}
+	if h.Source == "" && h.Expression == "" {
 		h.Source = h.Name
 	}
 	return nil
@@ -56,13 +64,13 @@ type Histograms struct {
 func NewHistograms(name string, config *HistogramConfig) (*Histograms, error) {
 	return &Histograms{
 		metricVec: newMetricVec(func(labels map[string]string) prometheus.Metric {
-			return &expiringHistogram{prometheus.NewHistogram(prometheus.HistogramOpts{
-				Help:        config.Description,
-				Name:        name,
-				ConstLabels: labels,
-				Buckets:     config.Buckets,
-			}),
-				0,
+			return &expiringHistogram{
+				Histogram: prometheus.NewHistogram(prometheus.HistogramOpts{
+					Help:        config.Description,
+					Name:        name,
+					ConstLabels: labels,
+					Buckets:     config.Buckets,
+				}),
 			}
 		}, int64(config.MaxIdle.Seconds())),
 		Cfg: config,
@@ -76,16 +84,11 @@ func (h *Histograms) With(labels model.LabelSet) prometheus.Histogram {
 
 type expiringHistogram struct {
 	prometheus.Histogram
-	lastModSec int64
+	lastModified
 }
 
 // Observe adds a single observation to the histogram.
 func (h *expiringHistogram) Observe(val float64) {
 	h.Histogram.Observe(val)
-	h.lastModSec = time.Now().Unix()
-}
-
-// HasExpired implements Expirable
-func (h *expiringHistogram) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
-	return currentTimeSec-h.lastModSec >= maxAgeSec
+	h.touch()
 }
@@ -3,6 +3,7 @@ package metric
 import (
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/loki/pkg/util"
@@ -16,11 +17,27 @@ type Expirable interface {
 	HasExpired(currentTimeSec int64, maxAgeSec int64) bool
 }
 
+// lastModified records when a metric was last updated. Metrics are updated
+// without holding the lock of their metricVec, so it's accessed atomically.
+type lastModified struct {
+	sec atomic.Int64
+}
+
+func (l *lastModified) touch() {
+	l.sec.Store(time.Now().Unix())
+}
+
+// HasExpired implements Expirable
+func (l *lastModified) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
+	return currentTimeSec-l.sec.Load() >= maxAgeSec
+}
+
 type metricVec struct {
-	factory   func(labels map[string]string) prometheus.Metric
-	mtx       sync.Mutex
-	metrics   map[model.Fingerprint]prometheus.Metric
-	maxAgeSec int64
+	factory      func(labels map[string]string) prometheus.Metric
+	mtx          sync.Mutex
+	metrics      map[model.Fingerprint]prometheus.Metric
+	maxAgeSec    int64
+	lastPruneSec int64
 }
 
 func newMetricVec(factory func(labels map[string]string) prometheus.Metric, maxAgeSec int64) *metricVec {
@@ -49,6 +66,12 @@ func (c *metricVec) Collect(ch chan<- prometheus.Metric) {
 func (c *metricVec) With(labels model.LabelSet) prometheus.Metric {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
+	// Expired metrics are also pruned here, at most once per second, so that
+	// metrics of streams which went idle are removed even if they're never
+	// collected.
+	if now := time.Now().Unix(); now > c.lastPruneSec {
+		c.prune()
+	}
 	fp := labels.Fingerprint()
 	var ok bool
 	var metric prometheus.Metric
@@ -88,6 +111,7 @@ func (c *metricVec) Delete(labels model.LabelSet) bool {
 // it does not take out a lock on the metrics map so whoever calls this function should do so.
 func (c *metricVec) prune() {
 	currentTimeSec := time.Now().Unix()
+	c.lastPruneSec = currentTimeSec
 	for fp, m := range c.metrics {
 		if em, ok := m.(Expirable); ok {
 			if em.HasExpired(currentTimeSec, c.maxAgeSec) {
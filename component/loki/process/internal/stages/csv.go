@@ -0,0 +1,194 @@
+package stages
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/common/model"
+)
+
+// Configuration errors.
+var (
+	ErrCSVInvalidDelimiter  = errors.New("csv stage delimiter must be a single character other than a quote or a newline")
+	ErrCSVDropHeaderColumns = errors.New("csv stage drop_header can't be used when columns are set")
+	ErrCSVEmptyColumns      = errors.New("csv stage columns must not all be empty")
+)
+
+// maxCSVHeadersCacheSize is the maximum number of streams whose inferred
+// header is remembered.
+const maxCSVHeadersCacheSize = 10000
+
+// CSVConfig contains the configuration for a csvStage.
+type CSVConfig struct {
+	Columns    []string `river:"columns,attr,optional"`
+	Delimiter  string   `river:"delimiter,attr,optional"`
+	Source     string   `river:"source,attr,optional"`
+	LazyQuotes bool     `river:"lazy_quotes,attr,optional"`
+	DropHeader bool     `river:"drop_header,attr,optional"`
+}
+
+// DefaultCSVConfig holds the default settings for a csvStage.
+var DefaultCSVConfig = CSVConfig{
+	Delimiter: ",",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *CSVConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultCSVConfig
+
+	type csvConfig CSVConfig
+	return f((*csvConfig)(c))
+}
+
+// validateCSVConfig validates a csv stage config and returns its delimiter.
+func validateCSVConfig(c CSVConfig) (rune, error) {
+	delim, size := utf8.DecodeRuneInString(c.Delimiter)
+	if size == 0 || size != len(c.Delimiter) || delim == utf8.RuneError || delim == '"' || delim == '\r' || delim == '\n' {
+		return 0, ErrCSVInvalidDelimiter
+	}
+
+	if len(c.Columns) > 0 {
+		if c.DropHeader {
+			return 0, ErrCSVDropHeaderColumns
+		}
+		empty := true
+		for _, col := range c.Columns {
+			if col != "" {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			return 0, ErrCSVEmptyColumns
+		}
+	}
+	return delim, nil
+}
+
+func newCSVStage(logger log.Logger, config CSVConfig) (Stage, error) {
+	delim, err := validateCSVConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers *lru.Cache
+	if len(config.Columns) == 0 {
+		headers, err = lru.New(maxCSVHeadersCacheSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &csvStage{
+		cfg:     config,
+		delim:   delim,
+		headers: headers,
+		logger:  log.With(logger, "component", "stage", "type", StageTypeCSV),
+	}, nil
+}
+
+// csvStage extracts the fields of CSV or TSV records into the extracted map,
+// using either the configured columns or the header row of each stream.
+type csvStage struct {
+	cfg    CSVConfig
+	delim  rune
+	logger log.Logger
+
+	// Inferred headers by stream, nil if columns are configured.
+	headers *lru.Cache
+}
+
+func (c *csvStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			isHeader := c.processEntry(e.Labels, e.Extracted, &e.Line)
+			if isHeader && c.cfg.DropHeader {
+				e.Ack.Done()
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// processEntry extracts the fields of entry or of the configured source. It
+// returns true if the record was used as the header of its stream.
+func (c *csvStage) processEntry(labels model.LabelSet, extracted map[string]interface{}, entry *string) bool {
+	input := *entry
+	if c.cfg.Source != "" {
+		v, ok := extracted[c.cfg.Source]
+		if !ok {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "source does not exist in the set of extracted values", "source", c.cfg.Source)
+			}
+			return false
+		}
+		s, err := getString(v)
+		if err != nil {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "failed to convert source value to string", "source", c.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+			}
+			return false
+		}
+		input = s
+	}
+	if strings.TrimSpace(input) == "" {
+		return false
+	}
+
+	r := csv.NewReader(strings.NewReader(input))
+	r.Comma = c.delim
+	r.LazyQuotes = c.cfg.LazyQuotes
+	r.FieldsPerRecord = -1
+	record, err := r.Read()
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "failed to parse csv record", "err", err)
+		return false
+	}
+
+	columns := c.cfg.Columns
+	if c.headers != nil {
+		stream := labels.String()
+		header, ok := c.headers.Get(stream)
+		if !ok {
+			for i := range record {
+				record[i] = strings.TrimSpace(record[i])
+			}
+			c.headers.Add(stream, record)
+			return true
+		}
+		columns = header.([]string)
+	}
+
+	if len(record) != len(columns) {
+		level.Debug(c.logger).Log("msg", "number of fields doesn't match the number of columns", "fields", len(record), "columns", len(columns))
+	}
+	for i, field := range record {
+		if i >= len(columns) {
+			break
+		}
+		if columns[i] == "" {
+			continue
+		}
+		extracted[columns[i]] = field
+	}
+	if Debug {
+		level.Debug(c.logger).Log("msg", "extracted data debug in csv stage", "extracted data", fmt.Sprintf("%v", extracted))
+	}
+	return false
+}
+
+// Name implements Stage.
+func (c *csvStage) Name() string {
+	return StageTypeCSV
+}
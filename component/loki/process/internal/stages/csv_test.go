@@ -0,0 +1,106 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+)
+
+func TestCSVConfig(t *testing.T) {
+	var cfg CSVConfig
+	require.NoError(t, river.Unmarshal([]byte(`columns = ["a", "b"]`), &cfg))
+	require.Equal(t, CSVConfig{Columns: []string{"a", "b"}, Delimiter: ","}, cfg)
+
+	tests := map[string]struct {
+		config CSVConfig
+		err    error
+	}{
+		"valid":              {CSVConfig{Delimiter: ","}, nil},
+		"tab":                {CSVConfig{Delimiter: "\t"}, nil},
+		"empty delimiter":    {CSVConfig{}, ErrCSVInvalidDelimiter},
+		"long delimiter":     {CSVConfig{Delimiter: ",,"}, ErrCSVInvalidDelimiter},
+		"quote delimiter":    {CSVConfig{Delimiter: `"`}, ErrCSVInvalidDelimiter},
+		"newline delimiter":  {CSVConfig{Delimiter: "\n"}, ErrCSVInvalidDelimiter},
+		"drop header":        {CSVConfig{Delimiter: ",", DropHeader: true}, nil},
+		"drop header column": {CSVConfig{Delimiter: ",", Columns: []string{"a"}, DropHeader: true}, ErrCSVDropHeaderColumns},
+		"empty columns":      {CSVConfig{Delimiter: ",", Columns: []string{"", ""}}, ErrCSVEmptyColumns},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := validateCSVConfig(tc.config)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestCSVStage_Columns(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(`
+stage.csv {
+    columns = ["time", "", "user", "message"]
+}`), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, nil, `2023-01-01T00:00:00Z,skipped,alice,"hello, ""world"""`, time.Now()),
+		newEntry(nil, nil, `2023-01-01T00:00:01Z,skipped`, time.Now()),
+		newEntry(nil, nil, `2023-01-01T00:00:02Z,skipped,bob,hi,extra`, time.Now()),
+		newEntry(nil, nil, `"unterminated`, time.Now()),
+	)
+	require.Len(t, out, 4)
+	require.Equal(t, map[string]interface{}{"time": "2023-01-01T00:00:00Z", "user": "alice", "message": `hello, "world"`}, out[0].Extracted)
+	require.Equal(t, map[string]interface{}{"time": "2023-01-01T00:00:01Z"}, out[1].Extracted)
+	require.Equal(t, map[string]interface{}{"time": "2023-01-01T00:00:02Z", "user": "bob", "message": "hi"}, out[2].Extracted)
+	require.Equal(t, map[string]interface{}{}, out[3].Extracted)
+}
+
+func TestCSVStage_HeaderInference(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(`
+stage.csv {
+    delimiter   = "\t"
+    drop_header = true
+}`), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	var (
+		a = model.LabelSet{"filename": "a.tsv"}
+		b = model.LabelSet{"filename": "b.tsv"}
+	)
+	out := processEntries(pl,
+		newEntry(nil, a, "level\t msg ", time.Now()),
+		newEntry(nil, b, "", time.Now()),
+		newEntry(nil, b, "id\tstatus", time.Now()),
+		newEntry(nil, a, "info\tstarted", time.Now()),
+		newEntry(nil, b, "1\tok", time.Now()),
+	)
+	require.Len(t, out, 3)
+	require.Equal(t, "", out[0].Line)
+	require.Equal(t, map[string]interface{}{"filename": "b.tsv"}, out[0].Extracted)
+	require.Equal(t, map[string]interface{}{"filename": "a.tsv", "level": "info", "msg": "started"}, out[1].Extracted)
+	require.Equal(t, map[string]interface{}{"filename": "b.tsv", "id": "1", "status": "ok"}, out[2].Extracted)
+}
+
+func TestCSVStage_Source(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(`
+stage.json {
+    expressions = { row = "" }
+}
+
+stage.csv {
+    source    = "row"
+    delimiter = ";"
+    columns   = ["a", "b"]
+}`), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `{"row": "1;2"}`, time.Now()))
+	require.Equal(t, map[string]interface{}{"row": "1;2", "a": "1", "b": "2"}, out[0].Extracted)
+}
@@ -0,0 +1,51 @@
+package stages
+
+import (
+	"github.com/grafana/agent/component/common/loki/deadletter"
+)
+
+// Reasons for sending entries to the dead letter queue.
+const (
+	deadLetterMalformedJSON = "malformed_json"
+	deadLetterMalformedXML  = "malformed_xml"
+	deadLetterRateLimited   = "rate_limited"
+)
+
+// deadLetterStage is implemented by stages which send the entries they drop
+// because they failed to process them to a dead letter queue.
+type deadLetterStage interface {
+	setDeadLetterQueue(q *deadletter.Queue)
+}
+
+// SetDeadLetterQueue sets the dead letter queue of the stages of the
+// pipeline, including the stages of nested pipelines. It must be called
+// before the pipeline is run.
+func (p *Pipeline) SetDeadLetterQueue(q *deadletter.Queue) {
+	p.setDeadLetterQueue(q)
+}
+
+func (p *Pipeline) setDeadLetterQueue(q *deadletter.Queue) {
+	if p == nil {
+		// Match stages which drop entries have no nested pipeline.
+		return
+	}
+	for _, s := range p.stages {
+		if s, ok := s.(deadLetterStage); ok {
+			s.setDeadLetterQueue(q)
+		}
+	}
+}
+
+func (m *matcherStage) setDeadLetterQueue(q *deadletter.Queue) {
+	if s, ok := m.stage.(deadLetterStage); ok {
+		s.setDeadLetterQueue(q)
+	}
+}
+
+func (j *jsonStage) setDeadLetterQueue(q *deadletter.Queue) { j.deadLetter = q }
+
+func (x *xmlStage) setDeadLetterQueue(q *deadletter.Queue) { x.deadLetter = q }
+
+func (m *limitStage) setDeadLetterQueue(q *deadletter.Queue) { m.deadLetter = q }
+
+func (t *throttleStage) setDeadLetterQueue(q *deadletter.Queue) { t.deadLetter = q }
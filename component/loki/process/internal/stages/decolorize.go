@@ -0,0 +1,130 @@
+package stages
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/loki/process/internal/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// ansiEscapeRegex matches ANSI escape sequences: CSI sequences such as color
+// codes and cursor movement, OSC sequences such as terminal titles and
+// hyperlinks, and two-character escape sequences.
+var ansiEscapeRegex = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// DecolorizeConfig contains the configuration for a decolorizeStage.
+type DecolorizeConfig struct {
+	StripControlCharacters   bool     `river:"strip_control_characters,attr,optional"`
+	AllowedControlCharacters []string `river:"allowed_control_characters,attr,optional"`
+}
+
+// DefaultDecolorizeConfig holds the default settings for a decolorizeStage.
+var DefaultDecolorizeConfig = DecolorizeConfig{
+	StripControlCharacters:   true,
+	AllowedControlCharacters: []string{"\t", "\n"},
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *DecolorizeConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultDecolorizeConfig
+
+	type decolorizeConfig DecolorizeConfig
+	return f((*decolorizeConfig)(c))
+}
+
+// validateDecolorizeConfig validates the DecolorizeConfig and returns the set
+// of control characters which are kept.
+func validateDecolorizeConfig(c DecolorizeConfig) (map[rune]struct{}, error) {
+	allowed := make(map[rune]struct{}, len(c.AllowedControlCharacters))
+	for _, s := range c.AllowedControlCharacters {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) || !unicode.IsControl(r) {
+			return nil, fmt.Errorf("decolorize stage allowed_control_characters must only contain single control characters, got %q", s)
+		}
+		allowed[r] = struct{}{}
+	}
+	return allowed, nil
+}
+
+func newDecolorizeStage(logger log.Logger, config DecolorizeConfig, registerer prometheus.Registerer) (Stage, error) {
+	allowed, err := validateDecolorizeConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sanitized, err := metric.NewCounters("loki_process_decolorized_lines_total", &metric.CounterConfig{
+		Description: "Total number of log lines from which ANSI escape sequences or control characters were removed.",
+		MaxIdle:     metric.DefaultCounterConfig.MaxIdle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := registerer.Register(sanitized); err != nil {
+		return nil, err
+	}
+
+	return toStage(&decolorizeStage{
+		cfg:       config,
+		logger:    log.With(logger, "component", "stage", "type", StageTypeDecolorize),
+		allowed:   allowed,
+		sanitized: sanitized,
+	}), nil
+}
+
+// decolorizeStage removes ANSI escape sequences and control characters from
+// log lines.
+type decolorizeStage struct {
+	cfg       DecolorizeConfig
+	logger    log.Logger
+	allowed   map[rune]struct{}
+	sanitized *metric.Counters
+}
+
+// Process implements Stage.
+func (d *decolorizeStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	line := ansiEscapeRegex.ReplaceAllString(*entry, "")
+	if d.cfg.StripControlCharacters {
+		line = d.stripControlCharacters(line)
+	}
+
+	if line != *entry {
+		*entry = line
+		d.sanitized.With(labels).Inc()
+	}
+}
+
+// stripControlCharacters removes all control characters from s which aren't
+// explicitly allowed.
+func (d *decolorizeStage) stripControlCharacters(s string) string {
+	isRemoved := func(r rune) bool {
+		if !unicode.IsControl(r) {
+			return false
+		}
+		_, ok := d.allowed[r]
+		return !ok
+	}
+
+	// Avoid allocating for lines without any control characters, which are the
+	// common case.
+	if strings.IndexFunc(s, isRemoved) == -1 {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if isRemoved(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Name implements Stage.
+func (d *decolorizeStage) Name() string {
+	return StageTypeDecolorize
+}
@@ -0,0 +1,109 @@
+package stages
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDecolorizeRiver = `
+stage.decolorize {}
+`
+
+func TestDecolorizePipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	name := "test"
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDecolorizeRiver), &name, registry)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"app": "colored"}, "\x1b[31mERROR\x1b[0m request failed", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "plain"}, "INFO request completed", time.Now()),
+	)
+	require.Len(t, out, 2)
+	assert.Equal(t, "ERROR request failed", out[0].Line)
+	assert.Equal(t, "INFO request completed", out[1].Line)
+
+	expect := `
+# HELP loki_process_decolorized_lines_total Total number of log lines from which ANSI escape sequences or control characters were removed.
+# TYPE loki_process_decolorized_lines_total counter
+loki_process_decolorized_lines_total{app="colored"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expect), "loki_process_decolorized_lines_total"))
+}
+
+func TestDecolorize(t *testing.T) {
+	tests := []struct {
+		name   string
+		config DecolorizeConfig
+		line   string
+		expect string
+	}{
+		{
+			name:   "color codes",
+			config: DefaultDecolorizeConfig,
+			line:   "\x1b[1;32mINFO\x1b[0m \x1b[38;5;208mstarted\x1b[m",
+			expect: "INFO started",
+		},
+		{
+			name:   "cursor movement and terminal title",
+			config: DefaultDecolorizeConfig,
+			line:   "\x1b]0;my title\x07\x1b[2Kprogress 50%\x1b[1G",
+			expect: "progress 50%",
+		},
+		{
+			name:   "control characters removed except allowlist",
+			config: DefaultDecolorizeConfig,
+			line:   "a\x00b\x08c\td\ne\r",
+			expect: "abc\td\ne",
+		},
+		{
+			name:   "control characters kept",
+			config: DecolorizeConfig{StripControlCharacters: false},
+			line:   "\x1b[31ma\x00b\x1b[0m",
+			expect: "a\x00b",
+		},
+		{
+			name:   "custom allowlist",
+			config: DecolorizeConfig{StripControlCharacters: true, AllowedControlCharacters: []string{"\r"}},
+			line:   "a\tb\r\n",
+			expect: "ab\r",
+		},
+		{
+			name:   "unicode preserved",
+			config: DefaultDecolorizeConfig,
+			line:   "\x1b[33mwarnung: größe überschritten ✓\x1b[0m",
+			expect: "warnung: größe überschritten ✓",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := newDecolorizeStage(util_log.Logger, tc.config, prometheus.NewRegistry())
+			require.NoError(t, err)
+
+			out := processEntries(st, newEntry(nil, model.LabelSet{}, tc.line, time.Now()))
+			require.Len(t, out, 1)
+			assert.Equal(t, tc.expect, out[0].Line)
+		})
+	}
+}
+
+func TestDecolorizeConfig_Validate(t *testing.T) {
+	_, err := validateDecolorizeConfig(DecolorizeConfig{AllowedControlCharacters: []string{"ab"}})
+	require.EqualError(t, err, `decolorize stage allowed_control_characters must only contain single control characters, got "ab"`)
+
+	_, err = validateDecolorizeConfig(DecolorizeConfig{AllowedControlCharacters: []string{"a"}})
+	require.Error(t, err)
+
+	allowed, err := validateDecolorizeConfig(DefaultDecolorizeConfig)
+	require.NoError(t, err)
+	require.Len(t, allowed, 2)
+}
@@ -0,0 +1,162 @@
+package stages
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/common/model"
+)
+
+// Configuration errors.
+var (
+	ErrDedupInvalidWindow = errors.New("dedup stage window must be greater than 0")
+	ErrDedupEmptyCountKey = errors.New("dedup stage count_key must not be empty")
+)
+
+// DedupConfig contains the configuration for a dedupStage.
+type DedupConfig struct {
+	Window   time.Duration `river:"window,attr,optional"`
+	CountKey string        `river:"count_key,attr,optional"`
+}
+
+// DefaultDedupConfig holds the default settings for a dedupStage.
+var DefaultDedupConfig = DedupConfig{
+	Window:   10 * time.Second,
+	CountKey: "repeat_count",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *DedupConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultDedupConfig
+
+	type dedupConfig DedupConfig
+	return f((*dedupConfig)(c))
+}
+
+func validateDedupConfig(c DedupConfig) error {
+	switch {
+	case c.Window <= 0:
+		return ErrDedupInvalidWindow
+	case c.CountKey == "":
+		return ErrDedupEmptyCountKey
+	}
+	return nil
+}
+
+func newDedupStage(logger log.Logger, config DedupConfig) (Stage, error) {
+	if err := validateDedupConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Pending entries are checked ten times over the course of the window,
+	// but at most every 10ms and at least once per second.
+	checkInterval := config.Window / 10
+	if checkInterval < 10*time.Millisecond {
+		checkInterval = 10 * time.Millisecond
+	} else if checkInterval > time.Second {
+		checkInterval = time.Second
+	}
+
+	return &dedupStage{
+		cfg:           config,
+		logger:        log.With(logger, "component", "stage", "type", StageTypeDedup),
+		checkInterval: checkInterval,
+	}, nil
+}
+
+// dedupStage collapses consecutive identical lines of a stream into the first
+// of them, setting the number of collapsed lines in the extracted map. The
+// first line of a stream is held back until a different line is received or
+// the window expires.
+type dedupStage struct {
+	cfg           DedupConfig
+	logger        log.Logger
+	checkInterval time.Duration
+}
+
+// dedupGroup is a group of identical lines waiting to be collapsed.
+type dedupGroup struct {
+	entry    Entry
+	count    int
+	acks     []*loki.Ack
+	deadline time.Time
+}
+
+// Run implements Stage.
+func (d *dedupStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(d.checkInterval)
+		defer ticker.Stop()
+
+		groups := make(map[model.Fingerprint]*dedupGroup)
+
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					// Forward every pending group rather than losing it when the
+					// pipeline is reloaded or shut down.
+					for _, g := range groups {
+						out <- d.collapse(g)
+					}
+					return
+				}
+
+				stream := e.Labels.Fingerprint()
+				if g, ok := groups[stream]; ok {
+					if g.entry.Line == e.Line {
+						g.count++
+						if e.Ack != nil {
+							g.acks = append(g.acks, e.Ack)
+						}
+						continue
+					}
+					out <- d.collapse(g)
+				}
+
+				g := &dedupGroup{
+					entry:    e,
+					count:    1,
+					deadline: time.Now().Add(d.cfg.Window),
+				}
+				if e.Ack != nil {
+					g.acks = append(g.acks, e.Ack)
+				}
+				groups[stream] = g
+
+			case now := <-ticker.C:
+				for stream, g := range groups {
+					if now.Before(g.deadline) {
+						continue
+					}
+					out <- d.collapse(g)
+					delete(groups, stream)
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// collapse returns the first entry of g, annotated with the number of lines
+// in g and acknowledging all of them once it's handled.
+func (d *dedupStage) collapse(g *dedupGroup) Entry {
+	e := g.entry
+	e.Extracted[d.cfg.CountKey] = g.count
+	e.Ack = loki.JoinAcks(g.acks...)
+	if g.count > 1 {
+		level.Debug(d.logger).Log("msg", "collapsed repeated lines", "labels", e.Labels.String(), "count", g.count)
+	}
+	return e
+}
+
+// Name implements Stage.
+func (d *dedupStage) Name() string {
+	return StageTypeDedup
+}
@@ -0,0 +1,147 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+var testDedupRiver = `
+stage.dedup {
+	window = "1m"
+}
+
+stage.template {
+	source   = "line"
+	template = "{{ .Entry }}{{ if gt (int .repeat_count) 1 }} (repeated {{ .repeat_count }} times){{ end }}"
+}
+
+stage.output {
+	source = "line"
+}
+`
+
+func TestDedup(t *testing.T) {
+	st, err := newDedupStage(util_log.Logger, DedupConfig{Window: time.Minute, CountKey: "repeat_count"})
+	require.NoError(t, err)
+
+	in := make(chan Entry)
+	out := st.Run(in)
+
+	var (
+		streamA = model.LabelSet{"stream": "a"}
+		streamB = model.LabelSet{"stream": "b"}
+		ts      = time.Unix(1, 0)
+		acked   = atomic.NewInt32(0)
+	)
+	send := func(labels model.LabelSet, line string) {
+		e := newEntry(nil, labels, line, ts)
+		e.Ack = loki.NewAck(func() { acked.Inc() })
+		in <- e
+	}
+	receive := func() Entry {
+		select {
+		case e := <-out:
+			return e
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for entry")
+			return Entry{}
+		}
+	}
+
+	// Repeated lines are collapsed per stream, and forwarded once a different
+	// line is received.
+	send(streamA, "connection refused, retrying")
+	send(streamB, "connection refused, retrying")
+	send(streamA, "connection refused, retrying")
+	send(streamA, "connection refused, retrying")
+	send(streamA, "connected")
+
+	e := receive()
+	require.Equal(t, "connection refused, retrying", e.Line)
+	require.Equal(t, streamA, e.Labels)
+	require.Equal(t, 3, e.Extracted["repeat_count"])
+
+	// The collapsed entry acknowledges every line it replaces.
+	require.Equal(t, int32(0), acked.Load())
+	e.Ack.Done()
+	require.Equal(t, int32(3), acked.Load())
+
+	// Pending lines are forwarded when the stage is stopped.
+	close(in)
+	lines := map[string]int{}
+	for e := range out {
+		lines[e.Labels.String()+" "+e.Line] = e.Extracted["repeat_count"].(int)
+	}
+	require.Equal(t, map[string]int{
+		`{stream="a"} connected`:                    1,
+		`{stream="b"} connection refused, retrying`: 1,
+	}, lines)
+}
+
+func TestDedupWindow(t *testing.T) {
+	st, err := newDedupStage(util_log.Logger, DedupConfig{Window: 100 * time.Millisecond, CountKey: "count"})
+	require.NoError(t, err)
+
+	in := make(chan Entry)
+	out := st.Run(in)
+	defer close(in)
+
+	for i := 0; i < 3; i++ {
+		in <- newEntry(nil, model.LabelSet{}, "retrying", time.Now())
+	}
+
+	select {
+	case e := <-out:
+		require.Equal(t, "retrying", e.Line)
+		require.Equal(t, 3, e.Extracted["count"])
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for entry")
+	}
+
+	// A repeated line after the window expired starts a new group.
+	in <- newEntry(nil, model.LabelSet{}, "retrying", time.Now())
+	select {
+	case e := <-out:
+		require.Equal(t, 1, e.Extracted["count"])
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for entry")
+	}
+}
+
+func TestDedupPipeline(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDedupRiver), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{}, "connection refused, retrying", time.Now()),
+		newEntry(nil, model.LabelSet{}, "connection refused, retrying", time.Now()),
+		newEntry(nil, model.LabelSet{}, "connection refused, retrying", time.Now()),
+		newEntry(nil, model.LabelSet{}, "connected", time.Now()),
+	)
+	require.Len(t, out, 2)
+	require.Equal(t, "connection refused, retrying (repeated 3 times)", out[0].Line)
+	require.Equal(t, "connected", out[1].Line)
+}
+
+func TestDedupConfigValidation(t *testing.T) {
+	tests := map[string]struct {
+		config DedupConfig
+		err    error
+	}{
+		"valid":           {config: DefaultDedupConfig},
+		"invalid window":  {config: DedupConfig{CountKey: "count"}, err: ErrDedupInvalidWindow},
+		"empty count_key": {config: DedupConfig{Window: time.Second}, err: ErrDedupEmptyCountKey},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.err, validateDedupConfig(tc.config))
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -305,6 +306,26 @@ func TestDropPipeline(t *testing.T) {
 	assert.Equal(t, out[0].Line, testMatchLogLineApp2)
 }
 
+func TestDropPipeline_Ack(t *testing.T) {
+	plName := "test_drop_pipeline"
+	pl, err := NewPipeline(util.TestFlowLogger(t), loadConfig(testDropRiver), &plName, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	var acked []string
+	newAckedEntry := func(line string) Entry {
+		e := newEntry(nil, nil, line, time.Now())
+		e.Ack = loki.NewAck(func() { acked = append(acked, line) })
+		return e
+	}
+	out := processEntries(pl, newAckedEntry(testMatchLogLineApp1), newAckedEntry(testMatchLogLineApp2))
+
+	// Dropped entries are acknowledged, while the entry which went through is
+	// still pending.
+	require.Len(t, out, 1)
+	require.Equal(t, []string{testMatchLogLineApp1}, acked)
+	require.NotNil(t, out[0].Ack)
+}
+
 var (
 	dropVal          = "msg"
 	dropRegex        = ".*blah"
@@ -0,0 +1,191 @@
+package stages
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+)
+
+// Supported policies for resolving collisions between extracted keys.
+const (
+	EventLogCollisionSuffix    = "suffix"
+	EventLogCollisionOverwrite = "overwrite"
+	EventLogCollisionSkip      = "skip"
+)
+
+// Configuration errors.
+var (
+	ErrEventLogMessageInvalidDepth     = errors.New("eventlogmessage stage max_depth must be greater than 0")
+	ErrEventLogMessageInvalidCollision = fmt.Errorf("eventlogmessage stage on_collision must be one of %q, %q, or %q", EventLogCollisionSuffix, EventLogCollisionOverwrite, EventLogCollisionSkip)
+)
+
+// EventLogMessageConfig contains the configuration for an
+// eventLogMessageStage.
+type EventLogMessageConfig struct {
+	Source      string `river:"source,attr,optional"`
+	MaxDepth    int    `river:"max_depth,attr,optional"`
+	OnCollision string `river:"on_collision,attr,optional"`
+}
+
+// DefaultEventLogMessageConfig holds the default settings for an
+// eventLogMessageStage.
+var DefaultEventLogMessageConfig = EventLogMessageConfig{
+	Source:      "event_data",
+	MaxDepth:    3,
+	OnCollision: EventLogCollisionSuffix,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *EventLogMessageConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultEventLogMessageConfig
+
+	type eventLogMessageConfig EventLogMessageConfig
+	return f((*eventLogMessageConfig)(c))
+}
+
+func validateEventLogMessageConfig(c EventLogMessageConfig) error {
+	if c.MaxDepth <= 0 {
+		return ErrEventLogMessageInvalidDepth
+	}
+	switch c.OnCollision {
+	case EventLogCollisionSuffix, EventLogCollisionOverwrite, EventLogCollisionSkip:
+		return nil
+	default:
+		return ErrEventLogMessageInvalidCollision
+	}
+}
+
+func newEventLogMessageStage(logger log.Logger, config EventLogMessageConfig) (Stage, error) {
+	if err := validateEventLogMessageConfig(config); err != nil {
+		return nil, err
+	}
+	return toStage(&eventLogMessageStage{
+		cfg:    config,
+		logger: log.With(logger, "component", "stage", "type", StageTypeEventLogMessage),
+	}), nil
+}
+
+// eventLogMessageStage extracts the values of a Windows event's EventData or
+// UserData XML into the extracted map.
+type eventLogMessageStage struct {
+	cfg    EventLogMessageConfig
+	logger log.Logger
+}
+
+// xmlNode is a generic XML element.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	InnerXML string     `xml:",innerxml"`
+	Children []xmlNode  `xml:",any"`
+}
+
+// Process implements Stage.
+func (m *eventLogMessageStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	input := *entry
+	if m.cfg.Source != "" {
+		v, ok := extracted[m.cfg.Source]
+		if !ok {
+			level.Debug(m.logger).Log("msg", "source does not exist in the set of extracted values", "source", m.cfg.Source)
+			return
+		}
+		s, err := getString(v)
+		if err != nil {
+			level.Debug(m.logger).Log("msg", "failed to convert source value to string", "source", m.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+			return
+		}
+		input = s
+	}
+	if strings.TrimSpace(input) == "" {
+		return
+	}
+
+	var root xmlNode
+	if err := xml.Unmarshal([]byte(input), &root); err != nil {
+		level.Debug(m.logger).Log("msg", "failed to parse event XML", "err", err)
+		return
+	}
+
+	// Keys produced while parsing this event are tracked separately from
+	// keys which existed before, so that repeated elements within the event
+	// are always suffixed instead of being overwritten or skipped.
+	seen := make(map[string]struct{})
+	for _, child := range root.Children {
+		m.flatten(extracted, seen, child, "", 1)
+	}
+}
+
+// flatten adds the value of n to extracted. Elements nested deeper than
+// max_depth are added as their raw inner XML.
+func (m *eventLogMessageStage) flatten(extracted map[string]interface{}, seen map[string]struct{}, n xmlNode, prefix string, depth int) {
+	key := sanitizeEventLogKey(n.XMLName.Local)
+	// <Data Name="..."> elements are named by their Name attribute.
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == "Name" && attr.Value != "" {
+			key = sanitizeEventLogKey(attr.Value)
+			break
+		}
+	}
+	if prefix != "" {
+		key = prefix + "_" + key
+	}
+
+	switch {
+	case len(n.Children) == 0:
+		m.set(extracted, seen, key, strings.TrimSpace(n.Content))
+	case depth >= m.cfg.MaxDepth:
+		m.set(extracted, seen, key, strings.TrimSpace(n.InnerXML))
+	default:
+		for _, child := range n.Children {
+			m.flatten(extracted, seen, child, key, depth+1)
+		}
+	}
+}
+
+// set sets key in extracted, resolving collisions according to the
+// on_collision policy.
+func (m *eventLogMessageStage) set(extracted map[string]interface{}, seen map[string]struct{}, key, value string) {
+	_, repeated := seen[key]
+	_, exists := extracted[key]
+
+	if exists && (repeated || m.cfg.OnCollision == EventLogCollisionSuffix) {
+		for i := 1; ; i++ {
+			candidate := key + "_" + strconv.Itoa(i)
+			if _, ok := extracted[candidate]; !ok {
+				key = candidate
+				break
+			}
+		}
+	} else if exists && m.cfg.OnCollision == EventLogCollisionSkip {
+		level.Debug(m.logger).Log("msg", "skipping key which already exists in the extracted map", "key", key)
+		return
+	}
+
+	seen[key] = struct{}{}
+	extracted[key] = value
+}
+
+// sanitizeEventLogKey replaces characters which aren't valid in label names
+// so that extracted keys can be used with the labels stage.
+func sanitizeEventLogKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// Name implements Stage.
+func (m *eventLogMessageStage) Name() string {
+	return StageTypeEventLogMessage
+}
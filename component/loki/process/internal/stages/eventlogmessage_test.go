@@ -0,0 +1,139 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEventLogMessageRiver = `
+stage.json {
+	expressions = { event_data = "" }
+}
+stage.eventlogmessage {}
+`
+
+var testEventLogMessageLine = `{"event_data":"<EventData><Data Name='SubjectUserSid'>S-1-5-18</Data><Data Name='SubjectUserName'>HOST$</Data><Data>unnamed</Data><Data>second</Data></EventData>"}`
+
+func TestEventLogMessagePipeline(t *testing.T) {
+	name := "test"
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testEventLogMessageRiver), &name, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, model.LabelSet{}, testEventLogMessageLine, time.Now()))
+	require.Len(t, out, 1)
+
+	assert.Equal(t, "S-1-5-18", out[0].Extracted["SubjectUserSid"])
+	assert.Equal(t, "HOST$", out[0].Extracted["SubjectUserName"])
+	assert.Equal(t, "unnamed", out[0].Extracted["Data"])
+	assert.Equal(t, "second", out[0].Extracted["Data_1"])
+}
+
+const testUserDataXML = `<UserData>
+	<LogFileCleared xmlns="http://manifests.microsoft.com/win/2004/08/windows/eventlog">
+		<SubjectUserSid>S-1-5-21</SubjectUserSid>
+		<SubjectUserName>admin</SubjectUserName>
+		<Details>
+			<Channel>Security</Channel>
+			<Backup><Path>C:\backup.evtx</Path></Backup>
+		</Details>
+	</LogFileCleared>
+</UserData>`
+
+func TestEventLogMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    EventLogMessageConfig
+		extracted map[string]interface{}
+		expect    map[string]interface{}
+	}{
+		{
+			name:      "nested user data",
+			config:    DefaultEventLogMessageConfig,
+			extracted: map[string]interface{}{"event_data": testUserDataXML},
+			expect: map[string]interface{}{
+				"event_data":                     testUserDataXML,
+				"LogFileCleared_SubjectUserSid":  "S-1-5-21",
+				"LogFileCleared_SubjectUserName": "admin",
+				"LogFileCleared_Details_Channel": "Security",
+				"LogFileCleared_Details_Backup":  `<Path>C:\backup.evtx</Path>`,
+			},
+		},
+		{
+			name:      "max depth",
+			config:    EventLogMessageConfig{Source: "event_data", MaxDepth: 1, OnCollision: EventLogCollisionSuffix},
+			extracted: map[string]interface{}{"event_data": `<UserData><A><B>1</B></A><C>2</C></UserData>`},
+			expect: map[string]interface{}{
+				"event_data": `<UserData><A><B>1</B></A><C>2</C></UserData>`,
+				"A":          "<B>1</B>",
+				"C":          "2",
+			},
+		},
+		{
+			name:      "collision suffix",
+			config:    DefaultEventLogMessageConfig,
+			extracted: map[string]interface{}{"event_data": `<EventData><Data Name="event_data">x</Data></EventData>`},
+			expect: map[string]interface{}{
+				"event_data":   `<EventData><Data Name="event_data">x</Data></EventData>`,
+				"event_data_1": "x",
+			},
+		},
+		{
+			name:      "collision overwrite",
+			config:    EventLogMessageConfig{Source: "event_data", MaxDepth: 3, OnCollision: EventLogCollisionOverwrite},
+			extracted: map[string]interface{}{"event_data": `<EventData><Data Name="user">x</Data></EventData>`, "user": "y"},
+			expect: map[string]interface{}{
+				"event_data": `<EventData><Data Name="user">x</Data></EventData>`,
+				"user":       "x",
+			},
+		},
+		{
+			name:      "collision skip",
+			config:    EventLogMessageConfig{Source: "event_data", MaxDepth: 3, OnCollision: EventLogCollisionSkip},
+			extracted: map[string]interface{}{"event_data": `<EventData><Data Name="user">x</Data></EventData>`, "user": "y"},
+			expect: map[string]interface{}{
+				"event_data": `<EventData><Data Name="user">x</Data></EventData>`,
+				"user":       "y",
+			},
+		},
+		{
+			name:      "key sanitized",
+			config:    DefaultEventLogMessageConfig,
+			extracted: map[string]interface{}{"event_data": `<EventData><Data Name="Logon Type">2</Data></EventData>`},
+			expect: map[string]interface{}{
+				"event_data": `<EventData><Data Name="Logon Type">2</Data></EventData>`,
+				"Logon_Type": "2",
+			},
+		},
+		{
+			name:      "invalid xml",
+			config:    DefaultEventLogMessageConfig,
+			extracted: map[string]interface{}{"event_data": `<EventData><Data>`},
+			expect:    map[string]interface{}{"event_data": `<EventData><Data>`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := newEventLogMessageStage(util_log.Logger, tc.config)
+			require.NoError(t, err)
+
+			out := processEntries(st, newEntry(tc.extracted, model.LabelSet{}, "", time.Now()))
+			require.Len(t, out, 1)
+			assert.Equal(t, tc.expect, out[0].Extracted)
+		})
+	}
+}
+
+func TestEventLogMessageConfig_Validate(t *testing.T) {
+	err := validateEventLogMessageConfig(EventLogMessageConfig{MaxDepth: 0, OnCollision: EventLogCollisionSuffix})
+	require.ErrorIs(t, err, ErrEventLogMessageInvalidDepth)
+
+	err = validateEventLogMessageConfig(EventLogMessageConfig{MaxDepth: 1, OnCollision: "merge"})
+	require.ErrorIs(t, err, ErrEventLogMessageInvalidCollision)
+}
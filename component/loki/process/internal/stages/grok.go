@@ -0,0 +1,296 @@
+package stages
+
+import (
+	"bufio"
+	_ "embed" // enables the go:embed directive
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+)
+
+// Configuration errors.
+var (
+	ErrGrokPatternsRequired = errors.New("grok stage patterns are required")
+	ErrGrokEmptyPattern     = errors.New("grok stage patterns must not be empty")
+)
+
+// bundledGrokPatterns holds the standard grok pattern library.
+//
+//go:embed grok-patterns
+var bundledGrokPatterns string
+
+// GrokConfig contains the configuration for a grokStage.
+type GrokConfig struct {
+	Patterns           []string          `river:"patterns,attr"`
+	PatternDefinitions map[string]string `river:"pattern_definitions,attr,optional"`
+	PatternFiles       []string          `river:"pattern_files,attr,optional"`
+	Source             string            `river:"source,attr,optional"`
+}
+
+// grokCapture is a field captured by a grok expression.
+type grokCapture struct {
+	name string
+	typ  string // Type to convert the captured value to: "", "int" or "float".
+}
+
+// grokExpression is a compiled grok pattern.
+type grokExpression struct {
+	pattern  string
+	re       *regexp.Regexp
+	captures map[int]grokCapture // Captures by submatch index.
+}
+
+func validateGrokConfig(c GrokConfig) ([]grokExpression, error) {
+	if len(c.Patterns) == 0 {
+		return nil, ErrGrokPatternsRequired
+	}
+
+	definitions, err := parseGrokPatterns(strings.NewReader(bundledGrokPatterns))
+	if err != nil {
+		return nil, fmt.Errorf("parsing bundled grok patterns: %w", err)
+	}
+	for _, path := range c.PatternFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening grok pattern file: %w", err)
+		}
+		filePatterns, err := parseGrokPatterns(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing grok pattern file %s: %w", path, err)
+		}
+		for name, pattern := range filePatterns {
+			definitions[name] = pattern
+		}
+	}
+	for name, pattern := range c.PatternDefinitions {
+		definitions[name] = pattern
+	}
+
+	expressions := make([]grokExpression, 0, len(c.Patterns))
+	for _, pattern := range c.Patterns {
+		if pattern == "" {
+			return nil, ErrGrokEmptyPattern
+		}
+		expr, err := compileGrok(pattern, definitions)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expr)
+	}
+	return expressions, nil
+}
+
+// parseGrokPatterns parses pattern definitions in the format of Logstash
+// pattern files: one "NAME PATTERN" definition per line. Empty lines and
+// lines starting with # are ignored.
+func parseGrokPatterns(r io.Reader) (map[string]string, error) {
+	patterns := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, pattern, ok := strings.Cut(line, " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("line %d: expected a name and a pattern separated by a space", lineNum)
+		}
+		patterns[name] = pattern
+	}
+	return patterns, scanner.Err()
+}
+
+// grokTokenRegexp matches references to patterns, such as %{NAME},
+// %{NAME:field} or %{NAME:field:int}, and named groups, such as (?<field>
+// or (?P<field>.
+var grokTokenRegexp = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::(\w+))?\}|\(\?P?<([^>=!][^>]*)>`)
+
+// grokCompiler expands references to patterns into a regular expression.
+type grokCompiler struct {
+	definitions map[string]string
+	captures    map[int]grokCapture // Captures by group name index.
+}
+
+// compileGrok compiles pattern into a regular expression, expanding the
+// patterns it references from definitions.
+func compileGrok(pattern string, definitions map[string]string) (grokExpression, error) {
+	c := &grokCompiler{
+		definitions: definitions,
+		captures:    make(map[int]grokCapture),
+	}
+	expanded, err := c.expand(pattern, nil)
+	if err != nil {
+		return grokExpression{}, fmt.Errorf("invalid grok pattern %q: %w", pattern, err)
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return grokExpression{}, fmt.Errorf("invalid grok pattern %q: %w", pattern, err)
+	}
+
+	// Captured fields are given generated group names, since field names
+	// can contain characters which aren't valid in group names.
+	captures := make(map[int]grokCapture, len(c.captures))
+	for i, name := range re.SubexpNames() {
+		if !strings.HasPrefix(name, "grok") {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(name, "grok"))
+		if err != nil {
+			continue
+		}
+		captures[i] = c.captures[idx]
+	}
+	return grokExpression{pattern: pattern, re: re, captures: captures}, nil
+}
+
+func (c *grokCompiler) expand(pattern string, stack []string) (string, error) {
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, m := range grokTokenRegexp.FindAllStringSubmatchIndex(pattern, -1) {
+		sb.WriteString(pattern[last:m[0]])
+		last = m[1]
+
+		// Named group.
+		if m[8] != -1 {
+			sb.WriteString("(?P<" + c.addCapture(pattern[m[8]:m[9]], "") + ">")
+			continue
+		}
+
+		name := pattern[m[2]:m[3]]
+		for _, s := range stack {
+			if s == name {
+				return "", fmt.Errorf("pattern %s references itself", name)
+			}
+		}
+		definition, ok := c.definitions[name]
+		if !ok {
+			return "", fmt.Errorf("unknown pattern %s", name)
+		}
+		expanded, err := c.expand(definition, append(stack, name))
+		if err != nil {
+			return "", err
+		}
+
+		if m[4] == -1 {
+			sb.WriteString("(?:" + expanded + ")")
+			continue
+		}
+		var typ string
+		if m[6] != -1 {
+			typ = pattern[m[6]:m[7]]
+			if typ != "int" && typ != "float" && typ != "string" {
+				return "", fmt.Errorf("unsupported type %s of field %s", typ, pattern[m[4]:m[5]])
+			}
+		}
+		sb.WriteString("(?P<" + c.addCapture(pattern[m[4]:m[5]], typ) + ">" + expanded + ")")
+	}
+	sb.WriteString(pattern[last:])
+	return sb.String(), nil
+}
+
+// addCapture records a captured field and returns its group name.
+func (c *grokCompiler) addCapture(name, typ string) string {
+	idx := len(c.captures)
+	c.captures[idx] = grokCapture{name: name, typ: typ}
+	return "grok" + strconv.Itoa(idx)
+}
+
+func newGrokStage(logger log.Logger, config GrokConfig) (Stage, error) {
+	expressions, err := validateGrokConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return toStage(&grokStage{
+		cfg:         config,
+		expressions: expressions,
+		logger:      log.With(logger, "component", "stage", "type", StageTypeGrok),
+	}), nil
+}
+
+// grokStage extracts the fields captured by the first matching grok pattern
+// into the extracted map.
+type grokStage struct {
+	cfg         GrokConfig
+	expressions []grokExpression
+	logger      log.Logger
+}
+
+// Process implements Stage.
+func (g *grokStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	input := *entry
+	if g.cfg.Source != "" {
+		v, ok := extracted[g.cfg.Source]
+		if !ok {
+			level.Debug(g.logger).Log("msg", "source does not exist in the set of extracted values", "source", g.cfg.Source)
+			return
+		}
+		s, err := getString(v)
+		if err != nil {
+			level.Debug(g.logger).Log("msg", "failed to convert source value to string", "source", g.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+			return
+		}
+		input = s
+	}
+
+	for _, expr := range g.expressions {
+		match := expr.re.FindStringSubmatchIndex(input)
+		if match == nil {
+			continue
+		}
+
+		// A field can be captured by multiple groups, such as in
+		// alternations; the first group which participated in the match wins.
+		set := make(map[string]struct{}, len(expr.captures))
+		for i := 1; i < len(match)/2; i++ {
+			capture, ok := expr.captures[i]
+			if !ok || match[2*i] == -1 {
+				continue
+			}
+			if _, ok := set[capture.name]; ok {
+				continue
+			}
+			set[capture.name] = struct{}{}
+
+			value, err := convertGrokValue(input[match[2*i]:match[2*i+1]], capture.typ)
+			if err != nil {
+				level.Debug(g.logger).Log("msg", "failed to convert captured value", "field", capture.name, "err", err)
+				continue
+			}
+			extracted[capture.name] = value
+		}
+		level.Debug(g.logger).Log("msg", "extracted data debug in grok stage", "pattern", expr.pattern, "extracted data", fmt.Sprintf("%v", extracted))
+		return
+	}
+	level.Debug(g.logger).Log("msg", "no grok pattern matched")
+}
+
+func convertGrokValue(value, typ string) (interface{}, error) {
+	switch typ {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+// Name implements Stage.
+func (g *grokStage) Name() string {
+	return StageTypeGrok
+}
@@ -0,0 +1,179 @@
+package stages
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/pkg/util"
+)
+
+func TestGrokBundledPatterns(t *testing.T) {
+	definitions, err := parseGrokPatterns(strings.NewReader(bundledGrokPatterns))
+	require.NoError(t, err)
+	require.Greater(t, len(definitions), 80)
+
+	for name := range definitions {
+		_, err := compileGrok("%{"+name+"}", definitions)
+		require.NoError(t, err, "pattern %s", name)
+	}
+}
+
+func TestGrokConfig(t *testing.T) {
+	tests := map[string]struct {
+		config GrokConfig
+		err    string
+	}{
+		"no patterns":    {GrokConfig{}, ErrGrokPatternsRequired.Error()},
+		"empty pattern":  {GrokConfig{Patterns: []string{""}}, ErrGrokEmptyPattern.Error()},
+		"unknown":        {GrokConfig{Patterns: []string{"%{NOPE:x}"}}, "unknown pattern NOPE"},
+		"invalid regexp": {GrokConfig{Patterns: []string{"%{INT:x}("}}, "missing closing )"},
+		"invalid type":   {GrokConfig{Patterns: []string{"%{INT:x:bool}"}}, "unsupported type bool of field x"},
+		"cycle": {GrokConfig{
+			Patterns:           []string{"%{A}"},
+			PatternDefinitions: map[string]string{"A": "a%{B}", "B": "b%{A}"},
+		}, "pattern A references itself"},
+		"missing file": {GrokConfig{
+			Patterns:     []string{"%{INT}"},
+			PatternFiles: []string{filepath.Join(t.TempDir(), "missing")},
+		}, "opening grok pattern file"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := validateGrokConfig(tc.config)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestGrokStage(t *testing.T) {
+	t.Parallel()
+	logger := util.TestFlowLogger(t)
+
+	patternFile := filepath.Join(t.TempDir(), "patterns")
+	require.NoError(t, os.WriteFile(patternFile, []byte(`
+# Custom patterns
+FIREWALL_ACTION (?:allow|deny)
+FIREWALL %{FIREWALL_ACTION:action} %{IP:src}:%{POSINT:src_port:int}
+`), 0644))
+
+	tests := map[string]struct {
+		config string
+		entry  string
+		want   map[string]interface{}
+	}{
+		"combined apache log": {
+			`stage.grok {
+				patterns = ["%{COMBINEDAPACHELOG}"]
+			}`,
+			`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+			map[string]interface{}{
+				"clientip":    "127.0.0.1",
+				"ident":       "-",
+				"auth":        "frank",
+				"timestamp":   "10/Oct/2000:13:55:36 -0700",
+				"verb":        "GET",
+				"request":     "/apache_pb.gif",
+				"httpversion": "1.0",
+				"response":    "200",
+				"bytes":       "2326",
+				"referrer":    `"http://www.example.com/start.html"`,
+				"agent":       `"Mozilla/4.08"`,
+			},
+		},
+		"syslog line": {
+			`stage.grok {
+				patterns = ["%{SYSLOGLINE}"]
+			}`,
+			`May  4 10:00:00 host01 sshd[1234]: Accepted publickey for root`,
+			map[string]interface{}{
+				"timestamp": "May  4 10:00:00",
+				"logsource": "host01",
+				"program":   "sshd",
+				"pid":       "1234",
+				"message":   "Accepted publickey for root",
+			},
+		},
+		"types and dotted names": {
+			`stage.grok {
+				patterns = ["%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:log.level} took %{NUMBER:duration:float}s, %{INT:http.status:int}"]
+			}`,
+			`2023-05-04T10:00:00.123Z WARN took 1.5s, 503`,
+			map[string]interface{}{
+				"time":        "2023-05-04T10:00:00.123Z",
+				"log.level":   "WARN",
+				"duration":    1.5,
+				"http.status": int64(503),
+			},
+		},
+		"first matching pattern wins": {
+			`stage.grok {
+				patterns = ["^%{INT:code} %{WORD:word}$", "^%{WORD:word} %{GREEDYDATA:rest}$"]
+			}`,
+			`hello big world`,
+			map[string]interface{}{
+				"word": "hello",
+				"rest": "big world",
+			},
+		},
+		"named groups and custom definitions": {
+			`stage.grok {
+				patterns            = ["%{SESSION:session} (?<user>\\w+)"]
+				pattern_definitions = { SESSION = "[0-9a-f]{8}" }
+			}`,
+			`deadbeef alice`,
+			map[string]interface{}{
+				"session": "deadbeef",
+				"user":    "alice",
+			},
+		},
+		"pattern files": {
+			`stage.grok {
+				patterns      = ["%{FIREWALL}"]
+				pattern_files = ["` + patternFile + `"]
+			}`,
+			`deny 10.0.0.1:443`,
+			map[string]interface{}{
+				"action":   "deny",
+				"src":      "10.0.0.1",
+				"src_port": int64(443),
+			},
+		},
+		"no match": {
+			`stage.grok {
+				patterns = ["^%{INT:code}$"]
+			}`,
+			`not a number`,
+			map[string]interface{}{},
+		},
+		"source": {
+			`stage.json {
+				expressions = { msg = "" }
+			}
+			stage.grok {
+				source   = "msg"
+				patterns = ["user=%{USERNAME:user}"]
+			}`,
+			`{"msg": "login user=bob"}`,
+			map[string]interface{}{
+				"msg":  "login user=bob",
+				"user": "bob",
+			},
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pl, err := NewPipeline(logger, loadConfig(tc.config), nil, prometheus.DefaultRegisterer)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, tc.entry, time.Now()))[0]
+			require.Equal(t, tc.want, out.Extracted)
+		})
+	}
+}
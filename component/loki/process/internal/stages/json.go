@@ -11,6 +11,8 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/jmespath/go-jmespath"
 	json "github.com/json-iterator/go"
 )
@@ -67,6 +69,7 @@ type jsonStage struct {
 	cfg         *JSONConfig
 	expressions map[string]*jmespath.JMESPath
 	logger      log.Logger
+	deadLetter  *deadletter.Queue
 }
 
 // newJSONStage creates a new json pipeline stage from a config.
@@ -89,6 +92,8 @@ func (j *jsonStage) Run(in chan Entry) chan Entry {
 		for e := range in {
 			err := j.processEntry(e.Extracted, &e.Line)
 			if err != nil && j.cfg.DropMalformed {
+				j.deadLetter.Send([]loki.Entry{e.Entry}, deadLetterMalformedJSON, err)
+				e.Ack.Done()
 				continue
 			}
 			out <- e
@@ -0,0 +1,257 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+)
+
+// Supported policies for keys repeated within the same log line.
+const (
+	KeyValueDuplicateLast   = "last"
+	KeyValueDuplicateFirst  = "first"
+	KeyValueDuplicateSuffix = "suffix"
+	KeyValueDuplicateJoin   = "join"
+)
+
+// Configuration errors.
+var (
+	ErrKeyValueEmptyPairDelimiter  = errors.New("key_value stage pair_delimiter must not be empty")
+	ErrKeyValueEmptyValueDelimiter = errors.New("key_value stage value_delimiter must not be empty")
+	ErrKeyValueSameDelimiters      = errors.New("key_value stage pair_delimiter and value_delimiter must be different")
+	ErrKeyValueInvalidQuote        = errors.New("key_value stage quote_chars must be ASCII characters other than delimiters and backslashes")
+	ErrKeyValueInvalidDuplicate    = fmt.Errorf("key_value stage on_duplicate must be one of %q, %q, %q, or %q", KeyValueDuplicateLast, KeyValueDuplicateFirst, KeyValueDuplicateSuffix, KeyValueDuplicateJoin)
+)
+
+// KeyValueConfig contains the configuration for a keyValueStage.
+type KeyValueConfig struct {
+	Mapping        map[string]string `river:"mapping,attr,optional"`
+	Source         string            `river:"source,attr,optional"`
+	PairDelimiter  string            `river:"pair_delimiter,attr,optional"`
+	ValueDelimiter string            `river:"value_delimiter,attr,optional"`
+	QuoteChars     string            `river:"quote_chars,attr,optional"`
+	OnDuplicate    string            `river:"on_duplicate,attr,optional"`
+	JoinSeparator  string            `river:"join_separator,attr,optional"`
+}
+
+// DefaultKeyValueConfig holds the default settings for a keyValueStage.
+var DefaultKeyValueConfig = KeyValueConfig{
+	PairDelimiter:  " ",
+	ValueDelimiter: "=",
+	QuoteChars:     `"'`,
+	OnDuplicate:    KeyValueDuplicateLast,
+	JoinSeparator:  ",",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *KeyValueConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultKeyValueConfig
+
+	type keyValueConfig KeyValueConfig
+	return f((*keyValueConfig)(c))
+}
+
+// validateKeyValueConfig validates a key_value stage config and returns an
+// inverse of its mapping, from keys of the line to names in the extracted
+// map. It returns a nil map if every key should be extracted.
+func validateKeyValueConfig(c KeyValueConfig) (map[string]string, error) {
+	switch {
+	case c.PairDelimiter == "":
+		return nil, ErrKeyValueEmptyPairDelimiter
+	case c.ValueDelimiter == "":
+		return nil, ErrKeyValueEmptyValueDelimiter
+	case c.PairDelimiter == c.ValueDelimiter:
+		return nil, ErrKeyValueSameDelimiters
+	}
+	for _, q := range c.QuoteChars {
+		if q >= utf8.RuneSelf || q == '\\' || strings.ContainsRune(c.PairDelimiter, q) || strings.ContainsRune(c.ValueDelimiter, q) {
+			return nil, ErrKeyValueInvalidQuote
+		}
+	}
+	switch c.OnDuplicate {
+	case KeyValueDuplicateLast, KeyValueDuplicateFirst, KeyValueDuplicateSuffix, KeyValueDuplicateJoin:
+	default:
+		return nil, ErrKeyValueInvalidDuplicate
+	}
+
+	if len(c.Mapping) == 0 {
+		return nil, nil
+	}
+	inverseMapping := make(map[string]string, len(c.Mapping))
+	for k, v := range c.Mapping {
+		// if value is not set, use the key for setting data in extracted map.
+		if v == "" {
+			v = k
+		}
+		inverseMapping[v] = k
+	}
+	return inverseMapping, nil
+}
+
+func newKeyValueStage(logger log.Logger, config KeyValueConfig) (Stage, error) {
+	inverseMapping, err := validateKeyValueConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return toStage(&keyValueStage{
+		cfg:            config,
+		inverseMapping: inverseMapping,
+		logger:         log.With(logger, "component", "stage", "type", StageTypeKeyValue),
+	}), nil
+}
+
+// keyValueStage extracts key-value pairs separated by configurable
+// delimiters into the extracted map.
+type keyValueStage struct {
+	cfg            KeyValueConfig
+	inverseMapping map[string]string
+	logger         log.Logger
+}
+
+// Process implements Stage.
+func (kv *keyValueStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	input := *entry
+	if kv.cfg.Source != "" {
+		v, ok := extracted[kv.cfg.Source]
+		if !ok {
+			level.Debug(kv.logger).Log("msg", "source does not exist in the set of extracted values", "source", kv.cfg.Source)
+			return
+		}
+		s, err := getString(v)
+		if err != nil {
+			level.Debug(kv.logger).Log("msg", "failed to convert source value to string", "source", kv.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+			return
+		}
+		input = s
+	}
+
+	// Values of the line by extracted name, in order of appearance.
+	values := make(map[string][]string)
+	var names []string
+	for _, pair := range kv.parse(input) {
+		name := pair.key
+		if kv.inverseMapping != nil {
+			var ok bool
+			if name, ok = kv.inverseMapping[pair.key]; !ok {
+				continue
+			}
+		}
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = append(values[name], pair.value)
+	}
+
+	for _, name := range names {
+		vv := values[name]
+		switch kv.cfg.OnDuplicate {
+		case KeyValueDuplicateLast:
+			extracted[name] = vv[len(vv)-1]
+		case KeyValueDuplicateFirst:
+			extracted[name] = vv[0]
+		case KeyValueDuplicateJoin:
+			extracted[name] = strings.Join(vv, kv.cfg.JoinSeparator)
+		case KeyValueDuplicateSuffix:
+			extracted[name] = vv[0]
+			for i, v := range vv[1:] {
+				extracted[name+"_"+strconv.Itoa(i+1)] = v
+			}
+		}
+	}
+	level.Debug(kv.logger).Log("msg", "extracted data debug in key_value stage", "extracted data", fmt.Sprintf("%v", extracted))
+}
+
+type keyValuePair struct {
+	key, value string
+}
+
+// parse splits input into key-value pairs. Tokens without a value delimiter
+// and pairs with an empty key are ignored. Values starting with one of the
+// quote characters run until the matching unescaped quote, and may contain
+// delimiters; backslashes escape the next character inside quotes.
+func (kv *keyValueStage) parse(input string) []keyValuePair {
+	var (
+		pairs      []keyValuePair
+		pairDelim  = kv.cfg.PairDelimiter
+		valueDelim = kv.cfg.ValueDelimiter
+	)
+
+	for len(input) > 0 {
+		if strings.HasPrefix(input, pairDelim) {
+			input = input[len(pairDelim):]
+			continue
+		}
+
+		// Read the key, up to the value delimiter.
+		keyEnd := strings.Index(input, valueDelim)
+		pairEnd := strings.Index(input, pairDelim)
+		if keyEnd == -1 || (pairEnd != -1 && pairEnd < keyEnd) {
+			// Token without a value.
+			if pairEnd == -1 {
+				break
+			}
+			input = input[pairEnd+len(pairDelim):]
+			continue
+		}
+		key := strings.TrimSpace(input[:keyEnd])
+		input = input[keyEnd+len(valueDelim):]
+		if !strings.HasPrefix(pairDelim, " ") {
+			// Allow spaces before quoted values, such as in "key = 'value'".
+			input = strings.TrimLeft(input, " ")
+		}
+
+		// Read the value, up to the next pair delimiter.
+		var value string
+		if len(input) > 0 && strings.IndexByte(kv.cfg.QuoteChars, input[0]) != -1 {
+			value, input = readQuoted(input)
+			if i := strings.Index(input, pairDelim); i != -1 {
+				input = input[i:]
+			} else {
+				input = ""
+			}
+		} else if i := strings.Index(input, pairDelim); i != -1 {
+			value, input = strings.TrimSpace(input[:i]), input[i:]
+		} else {
+			value, input = strings.TrimSpace(input), ""
+		}
+
+		if key != "" {
+			pairs = append(pairs, keyValuePair{key: key, value: value})
+		}
+	}
+	return pairs
+}
+
+// readQuoted reads the value quoted by the first character of s, and returns
+// the unescaped value along with the rest of s. Unterminated values run
+// until the end of s.
+func readQuoted(s string) (value, rest string) {
+	quote := s[0]
+
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			sb.WriteByte(s[i])
+		case c == quote:
+			return sb.String(), s[i+1:]
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), ""
+}
+
+// Name implements Stage.
+func (kv *keyValueStage) Name() string {
+	return StageTypeKeyValue
+}
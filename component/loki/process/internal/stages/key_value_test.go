@@ -0,0 +1,148 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+)
+
+func TestKeyValueConfig(t *testing.T) {
+	var cfg KeyValueConfig
+	require.NoError(t, river.Unmarshal([]byte(`pair_delimiter = ";"`), &cfg))
+	want := DefaultKeyValueConfig
+	want.PairDelimiter = ";"
+	require.Equal(t, want, cfg)
+
+	tests := map[string]struct {
+		modify func(c *KeyValueConfig)
+		err    error
+	}{
+		"default":               {func(c *KeyValueConfig) {}, nil},
+		"empty pair delimiter":  {func(c *KeyValueConfig) { c.PairDelimiter = "" }, ErrKeyValueEmptyPairDelimiter},
+		"empty value delimiter": {func(c *KeyValueConfig) { c.ValueDelimiter = "" }, ErrKeyValueEmptyValueDelimiter},
+		"same delimiters":       {func(c *KeyValueConfig) { c.ValueDelimiter = " " }, ErrKeyValueSameDelimiters},
+		"quote delimiter":       {func(c *KeyValueConfig) { c.QuoteChars = "=" }, ErrKeyValueInvalidQuote},
+		"backslash quote":       {func(c *KeyValueConfig) { c.QuoteChars = `\` }, ErrKeyValueInvalidQuote},
+		"non-ascii quote":       {func(c *KeyValueConfig) { c.QuoteChars = "«" }, ErrKeyValueInvalidQuote},
+		"no quotes":             {func(c *KeyValueConfig) { c.QuoteChars = "" }, nil},
+		"invalid duplicate":     {func(c *KeyValueConfig) { c.OnDuplicate = "error" }, ErrKeyValueInvalidDuplicate},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := DefaultKeyValueConfig
+			tc.modify(&cfg)
+			_, err := validateKeyValueConfig(cfg)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestKeyValueStage(t *testing.T) {
+	t.Parallel()
+	logger := util.TestFlowLogger(t)
+
+	tests := map[string]struct {
+		config string
+		entry  string
+		want   map[string]interface{}
+	}{
+		"defaults": {
+			`stage.key_value {}`,
+			`date=2023-05-04 action=deny msg="blocked by policy \"default\"" bare src='10.0.0.1' empty= =novalue`,
+			map[string]interface{}{
+				"date":   "2023-05-04",
+				"action": "deny",
+				"msg":    `blocked by policy "default"`,
+				"src":    "10.0.0.1",
+				"empty":  "",
+			},
+		},
+		"custom delimiters": {
+			`stage.key_value {
+				pair_delimiter  = ", "
+				value_delimiter = ":"
+			}`,
+			`user: alice, role : "admin, ops", time:12:00:01`,
+			map[string]interface{}{
+				"user": "alice",
+				"role": "admin, ops",
+				"time": "12:00:01",
+			},
+		},
+		"multi-character delimiters": {
+			`stage.key_value {
+				pair_delimiter  = "|"
+				value_delimiter = "=>"
+			}`,
+			`a=>1|b=>x=y|c=>|d`,
+			map[string]interface{}{
+				"a": "1",
+				"b": "x=y",
+				"c": "",
+			},
+		},
+		"mapping": {
+			`stage.key_value {
+				mapping = { source = "src", dst = "" }
+			}`,
+			`src=10.0.0.1 dst=10.0.0.2 proto=tcp`,
+			map[string]interface{}{
+				"source": "10.0.0.1",
+				"dst":    "10.0.0.2",
+			},
+		},
+		"duplicate last": {
+			`stage.key_value {}`,
+			`tag=a tag=b tag=c`,
+			map[string]interface{}{"tag": "c"},
+		},
+		"duplicate first": {
+			`stage.key_value { on_duplicate = "first" }`,
+			`tag=a tag=b tag=c`,
+			map[string]interface{}{"tag": "a"},
+		},
+		"duplicate suffix": {
+			`stage.key_value { on_duplicate = "suffix" }`,
+			`tag=a tag=b tag=c`,
+			map[string]interface{}{"tag": "a", "tag_1": "b", "tag_2": "c"},
+		},
+		"duplicate join": {
+			`stage.key_value {
+				on_duplicate   = "join"
+				join_separator = ";"
+			}`,
+			`tag=a other=1 tag=b`,
+			map[string]interface{}{"tag": "a;b", "other": "1"},
+		},
+		"unterminated quote": {
+			`stage.key_value {}`,
+			`a=1 msg="unterminated value`,
+			map[string]interface{}{"a": "1", "msg": "unterminated value"},
+		},
+		"source": {
+			`stage.json {
+				expressions = { kv = "" }
+			}
+			stage.key_value {
+				source = "kv"
+			}`,
+			`{"kv": "a=1 b=2"}`,
+			map[string]interface{}{"kv": "a=1 b=2", "a": "1", "b": "2"},
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pl, err := NewPipeline(logger, loadConfig(tc.config), nil, prometheus.DefaultRegisterer)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, tc.entry, time.Now()))[0]
+			require.Equal(t, tc.want, out.Extracted)
+		})
+	}
+}
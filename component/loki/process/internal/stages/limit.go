@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"golang.org/x/time/rate"
@@ -83,6 +85,7 @@ type limitStage struct {
 	rateLimiterByLabel GenerationalMap[model.LabelValue, *rate.Limiter]
 	dropCount          *prometheus.CounterVec
 	dropCountByLabel   *prometheus.CounterVec
+	deadLetter         *deadletter.Queue
 }
 
 func (m *limitStage) Run(in chan Entry) chan Entry {
@@ -94,6 +97,8 @@ func (m *limitStage) Run(in chan Entry) chan Entry {
 				out <- e
 				continue
 			}
+			m.deadLetter.Send([]loki.Entry{e.Entry}, deadLetterRateLimited, nil)
+			e.Ack.Done()
 		}
 	}()
 	return out
@@ -178,6 +178,7 @@ func (m *matcherStage) runDrop(in chan Entry) chan Entry {
 				continue
 			}
 			m.dropCount.WithLabelValues(m.dropReason).Inc()
+			e.Ack.Done()
 		}
 	}()
 	return out
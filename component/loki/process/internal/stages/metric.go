@@ -1,11 +1,13 @@
 package stages
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
 	"reflect"
 	"strconv"
+	"text/template"
 	"time"
 
 	"github.com/go-kit/log"
@@ -46,8 +48,31 @@ type MetricsConfig struct {
 }
 
 type cfgCollector struct {
-	cfg       MetricConfig
-	collector prometheus.Collector
+	cfg        MetricConfig
+	collector  prometheus.Collector
+	expression *template.Template
+}
+
+// newMetricExpression parses the expression of a metric, if it has one.
+func newMetricExpression(cfg MetricConfig) (*template.Template, error) {
+	var expression string
+	switch {
+	case cfg.Counter != nil:
+		expression = cfg.Counter.Expression
+	case cfg.Gauge != nil:
+		expression = cfg.Gauge.Expression
+	case cfg.Histogram != nil:
+		expression = cfg.Histogram.Expression
+	}
+	if expression == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("metric_expression").Funcs(functionMap).Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric expression: %w", err)
+	}
+	return tmpl, nil
 }
 
 // newMetricStage creates a new set of metrics to process for each log entry
@@ -55,7 +80,11 @@ func newMetricStage(logger log.Logger, config MetricsConfig, registry prometheus
 	metrics := map[string]cfgCollector{}
 	for _, cfg := range config.Metrics {
 		var collector prometheus.Collector
-		var err error
+
+		expression, err := newMetricExpression(cfg)
+		if err != nil {
+			return nil, err
+		}
 
 		switch {
 		case cfg.Counter != nil:
@@ -70,7 +99,7 @@ func newMetricStage(logger log.Logger, config MetricsConfig, registry prometheus
 				return nil, err
 			}
 			registry.MustRegister(collector)
-			metrics[cfg.Counter.Name] = cfgCollector{cfg: cfg, collector: collector}
+			metrics[cfg.Counter.Name] = cfgCollector{cfg: cfg, collector: collector, expression: expression}
 		case cfg.Gauge != nil:
 			customPrefix := ""
 			if cfg.Gauge.Prefix != "" {
@@ -83,7 +112,7 @@ func newMetricStage(logger log.Logger, config MetricsConfig, registry prometheus
 				return nil, err
 			}
 			registry.MustRegister(collector)
-			metrics[cfg.Gauge.Name] = cfgCollector{cfg: cfg, collector: collector}
+			metrics[cfg.Gauge.Name] = cfgCollector{cfg: cfg, collector: collector, expression: expression}
 		case cfg.Histogram != nil:
 			customPrefix := ""
 			if cfg.Histogram.Prefix != "" {
@@ -96,7 +125,7 @@ func newMetricStage(logger log.Logger, config MetricsConfig, registry prometheus
 				return nil, err
 			}
 			registry.MustRegister(collector)
-			metrics[cfg.Histogram.Name] = cfgCollector{cfg: cfg, collector: collector}
+			metrics[cfg.Histogram.Name] = cfgCollector{cfg: cfg, collector: collector, expression: expression}
 		default:
 			return nil, fmt.Errorf("undefined stage type in '%v', exiting", cfg)
 		}
@@ -133,19 +162,19 @@ func (m *metricStage) Process(labels model.LabelSet, extracted map[string]interf
 		}
 		switch {
 		case cc.cfg.Counter != nil:
-			if v, ok := extracted[cc.cfg.Counter.Source]; ok {
+			if v, ok := m.getValue(cc, cc.cfg.Counter.Source, extracted, entry); ok {
 				m.recordCounter(name, cc.collector.(*metric.Counters), labels, v)
 			} else {
 				level.Debug(m.logger).Log("msg", "source does not exist", "err", fmt.Sprintf("source: %s, does not exist", cc.cfg.Counter.Source))
 			}
 		case cc.cfg.Gauge != nil:
-			if v, ok := extracted[cc.cfg.Gauge.Source]; ok {
+			if v, ok := m.getValue(cc, cc.cfg.Gauge.Source, extracted, entry); ok {
 				m.recordGauge(name, cc.collector.(*metric.Gauges), labels, v)
 			} else {
 				level.Debug(m.logger).Log("msg", "source does not exist", "err", fmt.Sprintf("source: %s, does not exist", cc.cfg.Gauge.Source))
 			}
 		case cc.cfg.Histogram != nil:
-			if v, ok := extracted[cc.cfg.Histogram.Source]; ok {
+			if v, ok := m.getValue(cc, cc.cfg.Histogram.Source, extracted, entry); ok {
 				m.recordHistogram(name, cc.collector.(*metric.Histograms), labels, v)
 			} else {
 				level.Debug(m.logger).Log("msg", "source does not exist", "err", fmt.Sprintf("source: %s, does not exist", cc.cfg.Histogram.Source))
@@ -154,6 +183,41 @@ func (m *metricStage) Process(labels model.LabelSet, extracted map[string]interf
 	}
 }
 
+// getValue returns the value to update a metric with, either from the
+// extracted map or by evaluating the metric expression.
+func (m *metricStage) getValue(cc cfgCollector, source string, extracted map[string]interface{}, entry *string) (interface{}, bool) {
+	if cc.expression == nil {
+		v, ok := extracted[source]
+		return v, ok
+	}
+
+	td := make(map[string]interface{}, len(extracted)+1)
+	for k, v := range extracted {
+		s, err := getString(v)
+		if err != nil {
+			continue
+		}
+		td[k] = s
+	}
+	if entry != nil {
+		td["Entry"] = *entry
+	}
+
+	buf := &bytes.Buffer{}
+	if err := cc.expression.Execute(buf, td); err != nil {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "failed to evaluate metric expression", "err", err)
+		}
+		return nil, false
+	}
+	// An expression which evaluates to an empty string behaves like a missing
+	// source.
+	if buf.Len() == 0 {
+		return nil, false
+	}
+	return buf.String(), true
+}
+
 // Name implements Stage
 func (m *metricStage) Name() string {
 	return StageTypeMetric
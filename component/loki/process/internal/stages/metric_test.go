@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component/loki/process/internal/metric"
+	"github.com/grafana/agent/pkg/river"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -155,6 +156,90 @@ loki_process_custom_longitude{test="app",vehicle="1"} -10.1234
 	}
 }
 
+func TestMetricExpression(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	testConfig := `
+stage.logfmt {
+		mapping = { "duration_ms" = "", "status" = "" }
+}
+stage.metrics {
+		metric.histogram {
+				name = "request_duration_seconds"
+				description = "request duration"
+				expression = "{{ divf .duration_ms 1000 }}"
+				buckets = [0.1, 1]
+		}
+		metric.counter {
+				name = "server_errors_total"
+				description = "server errors"
+				expression = "{{ if hasPrefix \"5\" .status }}1{{ end }}"
+				action = "add"
+		}
+} `
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testConfig), nil, registry)
+	require.NoError(t, err)
+
+	processEntries(pl,
+		newEntry(nil, model.LabelSet{"test": "app"}, `duration_ms=50 status=200`, time.Now()),
+		newEntry(nil, model.LabelSet{"test": "app"}, `duration_ms=500 status=503`, time.Now()),
+	)
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_process_custom_request_duration_seconds request duration
+# TYPE loki_process_custom_request_duration_seconds histogram
+loki_process_custom_request_duration_seconds_bucket{test="app",le="0.1"} 1
+loki_process_custom_request_duration_seconds_bucket{test="app",le="1"} 2
+loki_process_custom_request_duration_seconds_bucket{test="app",le="+Inf"} 2
+loki_process_custom_request_duration_seconds_sum{test="app"} 0.55
+loki_process_custom_request_duration_seconds_count{test="app"} 2
+# HELP loki_process_custom_server_errors_total server errors
+# TYPE loki_process_custom_server_errors_total counter
+loki_process_custom_server_errors_total{test="app"} 1
+`)))
+}
+
+func TestMetricExpressionValidation(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"source and expression": {
+			config: `metric.gauge {
+				name = "value"
+				source = "value"
+				expression = "{{ .value }}"
+				action = "set"
+			}`,
+			err: "only one of 'source' or 'expression' can be set",
+		},
+		"match_all and expression": {
+			config: `metric.counter {
+				name = "lines"
+				match_all = true
+				expression = "{{ .value }}"
+				action = "inc"
+			}`,
+			err: "a 'counter' metric supports either 'match_all' or an 'expression', but not both",
+		},
+		"invalid expression": {
+			config: `metric.histogram {
+				name = "value"
+				expression = "{{ .value "
+			}`,
+			err: "invalid metric expression",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cfg Configs
+			err := river.Unmarshal([]byte("stage.metrics {\n"+tc.config+"\n}"), &cfg)
+			if err == nil {
+				_, err = NewPipeline(util_log.Logger, cfg.Stages, nil, prometheus.NewRegistry())
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
 func TestPipelineWithMissingKey_Metrics(t *testing.T) {
 	var buf bytes.Buffer
 	w := log.NewSyncWriter(&buf)
@@ -18,27 +18,36 @@ import (
 
 // TODO(@tpaschalis) Let's use this as the list of stages we need to port over.
 const (
-	StageTypeJSON         = "json"
-	StageTypeLogfmt       = "logfmt"
-	StageTypeRegex        = "regex"
-	StageTypeReplace      = "replace"
-	StageTypeMetric       = "metrics"
-	StageTypeLabel        = "labels"
-	StageTypeLabelDrop    = "labeldrop"
-	StageTypeTimestamp    = "timestamp"
-	StageTypeOutput       = "output"
-	StageTypeDocker       = "docker"
-	StageTypeCRI          = "cri"
-	StageTypeMatch        = "match"
-	StageTypeTemplate     = "template"
-	StageTypePipeline     = "pipeline"
-	StageTypeTenant       = "tenant"
-	StageTypeDrop         = "drop"
-	StageTypeLimit        = "limit"
-	StageTypeMultiline    = "multiline"
-	StageTypePack         = "pack"
-	StageTypeLabelAllow   = "labelallow"
-	StageTypeStaticLabels = "static_labels"
+	StageTypeJSON            = "json"
+	StageTypeLogfmt          = "logfmt"
+	StageTypeRegex           = "regex"
+	StageTypeReplace         = "replace"
+	StageTypeMetric          = "metrics"
+	StageTypeLabel           = "labels"
+	StageTypeLabelDrop       = "labeldrop"
+	StageTypeTimestamp       = "timestamp"
+	StageTypeOutput          = "output"
+	StageTypeDocker          = "docker"
+	StageTypeCRI             = "cri"
+	StageTypeMatch           = "match"
+	StageTypeTemplate        = "template"
+	StageTypePipeline        = "pipeline"
+	StageTypeTenant          = "tenant"
+	StageTypeDrop            = "drop"
+	StageTypeLimit           = "limit"
+	StageTypeMultiline       = "multiline"
+	StageTypePack            = "pack"
+	StageTypeLabelAllow      = "labelallow"
+	StageTypeStaticLabels    = "static_labels"
+	StageTypeDecolorize      = "decolorize"
+	StageTypeEventLogMessage = "eventlogmessage"
+	StageTypeTraceSampling   = "trace_sampling"
+	StageTypeXML             = "xml"
+	StageTypeCSV             = "csv"
+	StageTypeKeyValue        = "key_value"
+	StageTypeGrok            = "grok"
+	StageTypeDedup           = "dedup"
+	StageTypeThrottle        = "throttle"
 )
 
 // Processor takes an existing set of labels, timestamp and log entry and returns either a possibly mutated
@@ -210,6 +219,51 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.DecolorizeConfig != nil:
+		s, err = newDecolorizeStage(logger, *cfg.DecolorizeConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.EventLogMessageConfig != nil:
+		s, err = newEventLogMessageStage(logger, *cfg.EventLogMessageConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.TraceSamplingConfig != nil:
+		s, err = newTraceSamplingStage(logger, *cfg.TraceSamplingConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.XMLConfig != nil:
+		s, err = newXMLStage(logger, *cfg.XMLConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.CSVConfig != nil:
+		s, err = newCSVStage(logger, *cfg.CSVConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.KeyValueConfig != nil:
+		s, err = newKeyValueStage(logger, *cfg.KeyValueConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.GrokConfig != nil:
+		s, err = newGrokStage(logger, *cfg.GrokConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.DedupConfig != nil:
+		s, err = newDedupStage(logger, *cfg.DedupConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.ThrottleConfig != nil:
+		s, err = newThrottleStage(logger, *cfg.ThrottleConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		panic("unreachable; should have decoded into one of the StageConfig fields")
 	}
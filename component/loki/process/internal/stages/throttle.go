@@ -0,0 +1,168 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
+	"github.com/grafana/agent/component/loki/process/internal/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/time/rate"
+)
+
+// Configuration errors.
+var (
+	ErrThrottleInvalidRateOrBurst = errors.New("throttle stage rate and burst must be greater than 0")
+	ErrThrottleInvalidMaxStreams  = errors.New("throttle stage max_streams must be greater than 0")
+)
+
+// ThrottleConfig contains the configuration for a throttleStage.
+type ThrottleConfig struct {
+	Rate            float64           `river:"rate,attr"`
+	Burst           int               `river:"burst,attr"`
+	SpilloverLabels map[string]string `river:"spillover_labels,attr,optional"`
+	MaxStreams      int               `river:"max_streams,attr,optional"`
+	DropReason      string            `river:"drop_counter_reason,attr,optional"`
+}
+
+// DefaultThrottleConfig holds the default settings for a throttleStage.
+var DefaultThrottleConfig = ThrottleConfig{
+	MaxStreams: 10000,
+	DropReason: "throttle_stage",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *ThrottleConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultThrottleConfig
+
+	type throttleConfig ThrottleConfig
+	return f((*throttleConfig)(c))
+}
+
+// validateThrottleConfig validates a throttle stage config and returns its
+// spillover labels.
+func validateThrottleConfig(c ThrottleConfig) (model.LabelSet, error) {
+	switch {
+	case c.Rate <= 0 || c.Burst <= 0:
+		return nil, ErrThrottleInvalidRateOrBurst
+	case c.MaxStreams <= 0:
+		return nil, ErrThrottleInvalidMaxStreams
+	}
+
+	var spillover model.LabelSet
+	if len(c.SpilloverLabels) > 0 {
+		spillover = make(model.LabelSet, len(c.SpilloverLabels))
+		for name, value := range c.SpilloverLabels {
+			ln, lv := model.LabelName(name), model.LabelValue(value)
+			if !ln.IsValid() || !lv.IsValid() || value == "" {
+				return nil, fmt.Errorf("throttle stage spillover_labels contains an invalid label %s=%q", name, value)
+			}
+			spillover[ln] = lv
+		}
+	}
+	return spillover, nil
+}
+
+func newThrottleStage(logger log.Logger, config ThrottleConfig, registerer prometheus.Registerer) (Stage, error) {
+	spillover, err := validateThrottleConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	throttledEntries, err := registerStreamCounters(registerer, "loki_process_throttled_entries_total",
+		"Total number of log entries which exceeded the rate limit of their stream.")
+	if err != nil {
+		return nil, err
+	}
+	throttledBytes, err := registerStreamCounters(registerer, "loki_process_throttled_bytes_total",
+		"Total number of bytes of log lines which exceeded the rate limit of their stream.")
+	if err != nil {
+		return nil, err
+	}
+
+	newLimiter := func() *rate.Limiter { return rate.NewLimiter(rate.Limit(config.Rate), config.Burst) }
+	return &throttleStage{
+		cfg:              config,
+		logger:           log.With(logger, "component", "stage", "type", StageTypeThrottle),
+		spillover:        spillover,
+		limiters:         NewGenMap[model.Fingerprint, *rate.Limiter](config.MaxStreams, newLimiter, nil),
+		dropCount:        getDropCountMetric(registerer),
+		throttledEntries: throttledEntries,
+		throttledBytes:   throttledBytes,
+	}, nil
+}
+
+// registerStreamCounters registers counters with the labels of log streams,
+// or returns the existing counters if another stage registered them first.
+func registerStreamCounters(registerer prometheus.Registerer, name, help string) (*metric.Counters, error) {
+	counters, err := metric.NewCounters(name, &metric.CounterConfig{
+		Description: help,
+		MaxIdle:     metric.DefaultCounterConfig.MaxIdle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := registerer.Register(counters); err != nil {
+		existing, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			return nil, err
+		}
+		counters = existing.ExistingCollector.(*metric.Counters)
+	}
+	return counters, nil
+}
+
+// throttleStage limits the rate of log entries of each stream. Entries which
+// exceed the rate limit of their stream are dropped, or forwarded with
+// spillover labels added if any are configured.
+type throttleStage struct {
+	cfg        ThrottleConfig
+	logger     log.Logger
+	spillover  model.LabelSet
+	limiters   GenerationalMap[model.Fingerprint, *rate.Limiter]
+	deadLetter *deadletter.Queue
+
+	dropCount        *prometheus.CounterVec
+	throttledEntries *metric.Counters
+	throttledBytes   *metric.Counters
+}
+
+// Run implements Stage.
+func (t *throttleStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if t.limiters.GetOrCreate(e.Labels.Fingerprint()).Allow() {
+				out <- e
+				continue
+			}
+
+			t.throttledEntries.With(e.Labels).Inc()
+			t.throttledBytes.With(e.Labels).Add(float64(len(e.Line)))
+
+			if t.spillover != nil {
+				e.Labels = e.Labels.Merge(t.spillover)
+				out <- e
+				continue
+			}
+
+			if Debug {
+				level.Debug(t.logger).Log("msg", "dropping throttled entry", "labels", e.Labels.String())
+			}
+			t.dropCount.WithLabelValues(t.cfg.DropReason).Inc()
+			t.deadLetter.Send([]loki.Entry{e.Entry}, deadLetterRateLimited, nil)
+			e.Ack.Done()
+		}
+	}()
+	return out
+}
+
+// Name implements Stage.
+func (t *throttleStage) Name() string {
+	return StageTypeThrottle
+}
@@ -0,0 +1,120 @@
+package stages
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+var testThrottleRiver = `
+stage.throttle {
+	rate             = 1
+	burst            = 2
+	spillover_labels = { throttled = "true" }
+}
+`
+
+func TestThrottleDrop(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	st, err := newThrottleStage(util_log.Logger, ThrottleConfig{
+		Rate:       0.001,
+		Burst:      2,
+		MaxStreams: 10,
+		DropReason: "throttle_stage",
+	}, registry)
+	require.NoError(t, err)
+
+	var (
+		streamA = model.LabelSet{"stream": "a"}
+		streamB = model.LabelSet{"stream": "b"}
+		acked   = atomic.NewInt32(0)
+	)
+	entries := make([]Entry, 0, 4)
+	for _, labels := range []model.LabelSet{streamA, streamA, streamA, streamB} {
+		e := newEntry(nil, labels, "line", time.Now())
+		e.Ack = loki.NewAck(func() { acked.Inc() })
+		entries = append(entries, e)
+	}
+
+	// Each stream has its own burst, so only the third entry of stream a is
+	// throttled.
+	out := processEntries(st, entries...)
+	require.Len(t, out, 3)
+	require.Equal(t, int32(1), acked.Load())
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_process_dropped_lines_total A count of all log lines dropped as a result of a pipeline stage
+# TYPE loki_process_dropped_lines_total counter
+loki_process_dropped_lines_total{reason="throttle_stage"} 1
+# HELP loki_process_throttled_bytes_total Total number of bytes of log lines which exceeded the rate limit of their stream.
+# TYPE loki_process_throttled_bytes_total counter
+loki_process_throttled_bytes_total{stream="a"} 4
+# HELP loki_process_throttled_entries_total Total number of log entries which exceeded the rate limit of their stream.
+# TYPE loki_process_throttled_entries_total counter
+loki_process_throttled_entries_total{stream="a"} 1
+`)))
+}
+
+func TestThrottleSpillover(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testThrottleRiver), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"app": "api"}, "1", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "api"}, "2", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "api"}, "3", time.Now()),
+	)
+	require.Len(t, out, 3)
+	require.Equal(t, model.LabelSet{"app": "api"}, out[0].Labels)
+	require.Equal(t, model.LabelSet{"app": "api"}, out[1].Labels)
+	require.Equal(t, model.LabelSet{"app": "api", "throttled": "true"}, out[2].Labels)
+}
+
+func TestThrottleConfigValidation(t *testing.T) {
+	tests := map[string]struct {
+		config ThrottleConfig
+		err    string
+	}{
+		"valid": {
+			config: ThrottleConfig{Rate: 10, Burst: 20, MaxStreams: 10, SpilloverLabels: map[string]string{"throttled": "true"}},
+		},
+		"missing rate": {
+			config: ThrottleConfig{Burst: 20, MaxStreams: 10},
+			err:    ErrThrottleInvalidRateOrBurst.Error(),
+		},
+		"missing burst": {
+			config: ThrottleConfig{Rate: 10, MaxStreams: 10},
+			err:    ErrThrottleInvalidRateOrBurst.Error(),
+		},
+		"invalid max_streams": {
+			config: ThrottleConfig{Rate: 10, Burst: 20},
+			err:    ErrThrottleInvalidMaxStreams.Error(),
+		},
+		"invalid spillover label name": {
+			config: ThrottleConfig{Rate: 10, Burst: 20, MaxStreams: 10, SpilloverLabels: map[string]string{"throttled-by": "agent"}},
+			err:    `throttle stage spillover_labels contains an invalid label throttled-by="agent"`,
+		},
+		"empty spillover label value": {
+			config: ThrottleConfig{Rate: 10, Burst: 20, MaxStreams: 10, SpilloverLabels: map[string]string{"throttled": ""}},
+			err:    `throttle stage spillover_labels contains an invalid label throttled=""`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := validateThrottleConfig(tc.config)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package stages
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/tracesampling"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Configuration errors.
+var (
+	ErrTraceSamplingNoDecisions     = errors.New("trace_sampling stage decisions must be set")
+	ErrTraceSamplingInvalidWait     = errors.New("trace_sampling stage wait must be greater than 0")
+	ErrTraceSamplingInvalidCapacity = errors.New("trace_sampling stage max_pending must be greater than 0")
+)
+
+// TraceSamplingConfig contains the configuration for a traceSamplingStage.
+type TraceSamplingConfig struct {
+	Decisions  tracesampling.Decisions `river:"decisions,attr"`
+	Source     string                  `river:"source,attr,optional"`
+	Wait       time.Duration           `river:"wait,attr,optional"`
+	MaxPending int                     `river:"max_pending,attr,optional"`
+	DropReason string                  `river:"drop_counter_reason,attr,optional"`
+}
+
+// DefaultTraceSamplingConfig holds the default settings for a
+// traceSamplingStage.
+var DefaultTraceSamplingConfig = TraceSamplingConfig{
+	Source:     "trace_id",
+	Wait:       45 * time.Second,
+	MaxPending: 10000,
+	DropReason: "trace_not_sampled",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *TraceSamplingConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*c = DefaultTraceSamplingConfig
+
+	type traceSamplingConfig TraceSamplingConfig
+	return f((*traceSamplingConfig)(c))
+}
+
+func validateTraceSamplingConfig(c TraceSamplingConfig) error {
+	switch {
+	case c.Decisions == nil:
+		return ErrTraceSamplingNoDecisions
+	case c.Wait <= 0:
+		return ErrTraceSamplingInvalidWait
+	case c.MaxPending <= 0:
+		return ErrTraceSamplingInvalidCapacity
+	}
+	return nil
+}
+
+func newTraceSamplingStage(logger log.Logger, config TraceSamplingConfig, registerer prometheus.Registerer) (Stage, error) {
+	if err := validateTraceSamplingConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Pending entries are checked ten times over the course of the wait
+	// period, but at least once per second.
+	checkInterval := config.Wait / 10
+	if checkInterval > time.Second {
+		checkInterval = time.Second
+	}
+
+	return &traceSamplingStage{
+		cfg:           config,
+		logger:        log.With(logger, "component", "stage", "type", StageTypeTraceSampling),
+		dropCount:     getDropCountMetric(registerer),
+		checkInterval: checkInterval,
+	}, nil
+}
+
+// traceSamplingStage only keeps log lines whose trace ID was sampled by a
+// traces pipeline. Because traces are sampled after their spans have been
+// collected, lines with a trace ID which isn't known to be sampled yet are held
+// back until the trace is sampled or the wait period expires.
+type traceSamplingStage struct {
+	cfg           TraceSamplingConfig
+	logger        log.Logger
+	dropCount     *prometheus.CounterVec
+	checkInterval time.Duration
+}
+
+// pendingEntry is an entry waiting for the sampling decision of its trace.
+type pendingEntry struct {
+	entry    Entry
+	traceID  string
+	deadline time.Time
+}
+
+// Run implements Stage.
+func (s *traceSamplingStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(s.checkInterval)
+		defer ticker.Stop()
+
+		// Entries all wait for the same duration, so pending is always sorted by
+		// deadline.
+		var pending []pendingEntry
+
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					// Forward everything still pending rather than losing it when
+					// the pipeline is reloaded or shut down.
+					for _, p := range pending {
+						out <- p.entry
+					}
+					return
+				}
+
+				traceID, ok := s.traceID(e)
+				if !ok || s.cfg.Decisions.Sampled(traceID) {
+					out <- e
+					continue
+				}
+
+				if len(pending) >= s.cfg.MaxPending {
+					level.Debug(s.logger).Log("msg", "too many pending entries, dropping oldest", "trace_id", pending[0].traceID)
+					s.dropCount.WithLabelValues(s.cfg.DropReason).Inc()
+					pending[0].entry.Ack.Done()
+					pending = pending[1:]
+				}
+				pending = append(pending, pendingEntry{
+					entry:    e,
+					traceID:  traceID,
+					deadline: time.Now().Add(s.cfg.Wait),
+				})
+
+			case now := <-ticker.C:
+				pending = s.flush(out, pending, now)
+			}
+		}
+	}()
+	return out
+}
+
+// flush forwards pending entries whose trace has been sampled and drops
+// entries whose deadline has passed. The remaining entries are returned.
+func (s *traceSamplingStage) flush(out chan Entry, pending []pendingEntry, now time.Time) []pendingEntry {
+	remaining := pending[:0]
+	for _, p := range pending {
+		switch {
+		case s.cfg.Decisions.Sampled(p.traceID):
+			out <- p.entry
+		case now.After(p.deadline):
+			level.Debug(s.logger).Log("msg", "trace was not sampled, dropping entry", "trace_id", p.traceID)
+			s.dropCount.WithLabelValues(s.cfg.DropReason).Inc()
+			p.entry.Ack.Done()
+		default:
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
+
+// traceID returns the trace ID of e. Entries without a trace ID aren't
+// subject to sampling.
+func (s *traceSamplingStage) traceID(e Entry) (string, bool) {
+	v, ok := e.Extracted[s.cfg.Source]
+	if !ok {
+		return "", false
+	}
+	traceID, err := getString(v)
+	if err != nil {
+		level.Debug(s.logger).Log("msg", "failed to convert source value to string", "source", s.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+		return "", false
+	}
+	return traceID, traceID != ""
+}
+
+// Name implements Stage.
+func (s *traceSamplingStage) Name() string {
+	return StageTypeTraceSampling
+}
@@ -0,0 +1,122 @@
+package stages
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDecisions is a tracesampling.Decisions backed by a set of trace IDs.
+type fakeDecisions struct {
+	mut     sync.Mutex
+	sampled map[string]struct{}
+}
+
+func (d *fakeDecisions) Sample(traceID string) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.sampled[traceID] = struct{}{}
+}
+
+func (d *fakeDecisions) Sampled(traceID string) bool {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	_, ok := d.sampled[traceID]
+	return ok
+}
+
+func TestTraceSampling(t *testing.T) {
+	decisions := &fakeDecisions{sampled: map[string]struct{}{"sampled": {}}}
+	registry := prometheus.NewRegistry()
+
+	st, err := newTraceSamplingStage(util_log.Logger, TraceSamplingConfig{
+		Decisions:  decisions,
+		Source:     "trace_id",
+		Wait:       500 * time.Millisecond,
+		MaxPending: 10,
+		DropReason: "trace_not_sampled",
+	}, registry)
+	require.NoError(t, err)
+
+	in := make(chan Entry)
+	out := st.Run(in)
+
+	send := func(line string, extracted map[string]interface{}) {
+		in <- newEntry(extracted, model.LabelSet{}, line, time.Now())
+	}
+	receive := func() string {
+		select {
+		case e := <-out:
+			return e.Line
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for entry")
+			return ""
+		}
+	}
+
+	// Entries without a trace ID and entries of sampled traces are forwarded
+	// immediately.
+	send("no trace", nil)
+	require.Equal(t, "no trace", receive())
+	send("sampled", map[string]interface{}{"trace_id": "sampled"})
+	require.Equal(t, "sampled", receive())
+
+	// Entries of traces which are sampled later are held back until the
+	// decision is made; entries of unsampled traces are eventually dropped.
+	send("not sampled", map[string]interface{}{"trace_id": "not-sampled"})
+	send("sampled later", map[string]interface{}{"trace_id": "later"})
+	decisions.Sample("later")
+	require.Equal(t, "sampled later", receive())
+
+	time.Sleep(time.Second)
+	close(in)
+	_, ok := <-out
+	require.False(t, ok, "expected unsampled entry to be dropped")
+
+	expect := `
+# HELP loki_process_dropped_lines_total A count of all log lines dropped as a result of a pipeline stage
+# TYPE loki_process_dropped_lines_total counter
+loki_process_dropped_lines_total{reason="trace_not_sampled"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expect), "loki_process_dropped_lines_total"))
+}
+
+func TestTraceSampling_FlushOnClose(t *testing.T) {
+	st, err := newTraceSamplingStage(util_log.Logger, TraceSamplingConfig{
+		Decisions:  &fakeDecisions{sampled: map[string]struct{}{}},
+		Source:     "trace_id",
+		Wait:       time.Hour,
+		MaxPending: 1,
+		DropReason: "trace_not_sampled",
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(st,
+		newEntry(map[string]interface{}{"trace_id": "a"}, model.LabelSet{}, "dropped when full", time.Now()),
+		newEntry(map[string]interface{}{"trace_id": "b"}, model.LabelSet{}, "pending", time.Now()),
+	)
+	require.Len(t, out, 1)
+	require.Equal(t, "pending", out[0].Line)
+}
+
+func TestTraceSamplingConfig_Validate(t *testing.T) {
+	cfg := DefaultTraceSamplingConfig
+	require.ErrorIs(t, validateTraceSamplingConfig(cfg), ErrTraceSamplingNoDecisions)
+
+	cfg.Decisions = &fakeDecisions{}
+	require.NoError(t, validateTraceSamplingConfig(cfg))
+
+	cfg.Wait = 0
+	require.ErrorIs(t, validateTraceSamplingConfig(cfg), ErrTraceSamplingInvalidWait)
+
+	cfg.Wait = time.Second
+	cfg.MaxPending = 0
+	require.ErrorIs(t, validateTraceSamplingConfig(cfg), ErrTraceSamplingInvalidCapacity)
+}
@@ -0,0 +1,399 @@
+package stages
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/antchfx/xpath"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
+)
+
+// Config Errors
+const (
+	ErrEmptyXMLStageConfig     = "empty xml stage configuration"
+	ErrEmptyXMLStageSource     = "empty source"
+	ErrCouldNotCompileXPath    = "could not compile XPath expression"
+	ErrEmptyXMLNamespacePrefix = "empty namespace prefix"
+	ErrMalformedXML            = "malformed xml"
+)
+
+// XMLConfig represents an XML Stage configuration
+type XMLConfig struct {
+	Expressions   map[string]string `river:"expressions,attr"`
+	Namespaces    map[string]string `river:"namespaces,attr,optional"`
+	Source        *string           `river:"source,attr,optional"`
+	DropMalformed bool              `river:"drop_malformed,attr,optional"`
+}
+
+// validateXMLConfig validates an xml config and returns a map of compiled
+// XPath expressions.
+func validateXMLConfig(c *XMLConfig) (map[string]*xpath.Expr, error) {
+	if c == nil {
+		return nil, errors.New(ErrEmptyXMLStageConfig)
+	}
+
+	if len(c.Expressions) == 0 {
+		return nil, errors.New(ErrExpressionsRequired)
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, errors.New(ErrEmptyXMLStageSource)
+	}
+
+	for prefix := range c.Namespaces {
+		if prefix == "" {
+			return nil, errors.New(ErrEmptyXMLNamespacePrefix)
+		}
+	}
+
+	// Prefixes are only checked against a non-nil map, so that prefixes which
+	// aren't declared are reported instead of never matching.
+	namespaces := c.Namespaces
+	if namespaces == nil {
+		namespaces = map[string]string{}
+	}
+
+	expressions := map[string]*xpath.Expr{}
+
+	for n, e := range c.Expressions {
+		var err error
+		expr := e
+		// If there is no expression, use the name as the expression.
+		if e == "" {
+			expr = n
+		}
+		expressions[n], err = xpath.CompileWithNS(expr, namespaces)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrCouldNotCompileXPath, err)
+		}
+	}
+	return expressions, nil
+}
+
+// xmlStage sets extracted data using XPath expressions
+type xmlStage struct {
+	cfg         *XMLConfig
+	expressions map[string]*xpath.Expr
+	logger      log.Logger
+	deadLetter  *deadletter.Queue
+}
+
+// newXMLStage creates a new xml pipeline stage from a config.
+func newXMLStage(logger log.Logger, cfg XMLConfig) (Stage, error) {
+	expressions, err := validateXMLConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlStage{
+		cfg:         &cfg,
+		expressions: expressions,
+		logger:      log.With(logger, "component", "stage", "type", StageTypeXML),
+	}, nil
+}
+
+func (x *xmlStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := x.processEntry(e.Extracted, &e.Line)
+			if err != nil && x.cfg.DropMalformed {
+				x.deadLetter.Send([]loki.Entry{e.Entry}, deadLetterMalformedXML, err)
+				e.Ack.Done()
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (x *xmlStage) processEntry(extracted map[string]interface{}, entry *string) error {
+	// If a source key is provided, the xml stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if x.cfg.Source != nil {
+		if _, ok := extracted[*x.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(x.logger).Log("msg", "source does not exist in the set of extracted values", "source", *x.cfg.Source)
+			}
+			return nil
+		}
+
+		value, err := getString(extracted[*x.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(x.logger).Log("msg", "failed to convert source value to string", "source", *x.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*x.cfg.Source]))
+			}
+			return nil
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(x.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return nil
+	}
+
+	doc, err := parseXMLDocument(*input)
+	if err != nil {
+		if Debug {
+			level.Debug(x.logger).Log("msg", "failed to parse log line", "err", err)
+		}
+		return errors.New(ErrMalformedXML)
+	}
+
+	for n, e := range x.expressions {
+		switch r := e.Evaluate(newXMLNavigator(doc)).(type) {
+		case *xpath.NodeIterator:
+			// Node sets extract the value of their first node, and nothing if
+			// they're empty.
+			if r.MoveNext() {
+				extracted[n] = r.Current().Value()
+			}
+		case float64, string, bool:
+			extracted[n] = r
+		}
+	}
+	if Debug {
+		level.Debug(x.logger).Log("msg", "extracted data debug in xml stage", "extracted data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+// Name implements Stage
+func (x *xmlStage) Name() string {
+	return StageTypeXML
+}
+
+// xmlDocNode is a node of a parsed XML document.
+type xmlDocNode struct {
+	typ   xpath.NodeType
+	name  xml.Name // Space holds the namespace URI of elements.
+	data  string   // Content of text and comment nodes.
+	attrs []xml.Attr
+
+	parent, firstChild, lastChild, prev, next *xmlDocNode
+}
+
+func (n *xmlDocNode) appendChild(c *xmlDocNode) {
+	c.parent = n
+	if n.lastChild == nil {
+		n.firstChild = c
+	} else {
+		n.lastChild.next = c
+		c.prev = n.lastChild
+	}
+	n.lastChild = c
+}
+
+// text returns the concatenated text of n and its descendants.
+func (n *xmlDocNode) text() string {
+	switch n.typ {
+	case xpath.TextNode, xpath.CommentNode:
+		return n.data
+	}
+
+	var sb strings.Builder
+	var walk func(*xmlDocNode)
+	walk = func(n *xmlDocNode) {
+		for c := n.firstChild; c != nil; c = c.next {
+			switch c.typ {
+			case xpath.TextNode:
+				sb.WriteString(c.data)
+			case xpath.ElementNode:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// parseXMLDocument parses s into a tree of nodes. Processing instructions and
+// directives are ignored.
+func parseXMLDocument(s string) (*xmlDocNode, error) {
+	var (
+		root    = &xmlDocNode{typ: xpath.RootNode}
+		curr    = root
+		dec     = xml.NewDecoder(strings.NewReader(s))
+		hasRoot bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if curr == root {
+				if hasRoot {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				hasRoot = true
+			}
+			n := &xmlDocNode{typ: xpath.ElementNode, name: tok.Name}
+			for _, attr := range tok.Attr {
+				// Namespace declarations aren't attributes in XPath.
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				n.attrs = append(n.attrs, attr)
+			}
+			curr.appendChild(n)
+			curr = n
+		case xml.EndElement:
+			curr = curr.parent
+		case xml.CharData:
+			if curr == root {
+				// Whitespace around the root element.
+				continue
+			}
+			if last := curr.lastChild; last != nil && last.typ == xpath.TextNode {
+				last.data += string(tok)
+				continue
+			}
+			curr.appendChild(&xmlDocNode{typ: xpath.TextNode, data: string(tok)})
+		case xml.Comment:
+			curr.appendChild(&xmlDocNode{typ: xpath.CommentNode, data: string(tok)})
+		}
+	}
+	if !hasRoot {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// xmlNavigator implements xpath.NodeNavigator over a parsed XML document.
+//
+// Nodes have no prefix, so unprefixed names in expressions match elements
+// and attributes of any namespace. Prefixed names match the namespace URI
+// the prefix is bound to in the namespaces of the stage.
+type xmlNavigator struct {
+	root, curr *xmlDocNode
+	attr       int // Index of the current attribute of curr, or -1.
+}
+
+var _ xpath.NodeNavigator = (*xmlNavigator)(nil)
+
+func newXMLNavigator(root *xmlDocNode) *xmlNavigator {
+	return &xmlNavigator{root: root, curr: root, attr: -1}
+}
+
+func (x *xmlNavigator) NodeType() xpath.NodeType {
+	if x.attr != -1 {
+		return xpath.AttributeNode
+	}
+	return x.curr.typ
+}
+
+func (x *xmlNavigator) LocalName() string {
+	if x.attr != -1 {
+		return x.curr.attrs[x.attr].Name.Local
+	}
+	return x.curr.name.Local
+}
+
+func (x *xmlNavigator) Prefix() string { return "" }
+
+// NamespaceURL returns the namespace URI of the current node. It's used by
+// xpath to match prefixed names.
+func (x *xmlNavigator) NamespaceURL() string {
+	if x.attr != -1 {
+		return x.curr.attrs[x.attr].Name.Space
+	}
+	return x.curr.name.Space
+}
+
+func (x *xmlNavigator) Value() string {
+	if x.attr != -1 {
+		return x.curr.attrs[x.attr].Value
+	}
+	return x.curr.text()
+}
+
+func (x *xmlNavigator) Copy() xpath.NodeNavigator {
+	n := *x
+	return &n
+}
+
+func (x *xmlNavigator) MoveToRoot() {
+	x.curr, x.attr = x.root, -1
+}
+
+func (x *xmlNavigator) MoveToParent() bool {
+	if x.attr != -1 {
+		x.attr = -1
+		return true
+	}
+	if x.curr.parent == nil {
+		return false
+	}
+	x.curr = x.curr.parent
+	return true
+}
+
+func (x *xmlNavigator) MoveToNextAttribute() bool {
+	if x.attr >= len(x.curr.attrs)-1 {
+		return false
+	}
+	x.attr++
+	return true
+}
+
+func (x *xmlNavigator) MoveToChild() bool {
+	if x.attr != -1 || x.curr.firstChild == nil {
+		return false
+	}
+	x.curr = x.curr.firstChild
+	return true
+}
+
+func (x *xmlNavigator) MoveToFirst() bool {
+	if x.attr != -1 || x.curr.prev == nil {
+		return false
+	}
+	for x.curr.prev != nil {
+		x.curr = x.curr.prev
+	}
+	return true
+}
+
+func (x *xmlNavigator) MoveToNext() bool {
+	if x.attr != -1 || x.curr.next == nil {
+		return false
+	}
+	x.curr = x.curr.next
+	return true
+}
+
+func (x *xmlNavigator) MoveToPrevious() bool {
+	if x.attr != -1 || x.curr.prev == nil {
+		return false
+	}
+	x.curr = x.curr.prev
+	return true
+}
+
+func (x *xmlNavigator) MoveTo(other xpath.NodeNavigator) bool {
+	n, ok := other.(*xmlNavigator)
+	if !ok || n.root != x.root {
+		return false
+	}
+	x.curr, x.attr = n.curr, n.attr
+	return true
+}
@@ -0,0 +1,261 @@
+package stages
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/pkg/util"
+)
+
+var testXMLRiverSingleStageWithoutSource = `
+stage.xml {
+    expressions = {
+        event_id = "/Event/System/EventID",
+        provider = "/Event/System/Provider/@Name",
+        user     = "//Data[@Name='SubjectUserName']",
+        data     = "count(//Data)",
+        unknown  = "/Event/Unknown",
+    }
+}
+`
+
+var testXMLRiverMultiStageWithNamespaces = `
+stage.json {
+    expressions = { "payload" = "" }
+}
+
+stage.xml {
+    source      = "payload"
+    namespaces  = { "s" = "http://schemas.xmlsoap.org/soap/envelope/", "m" = "urn:orders" }
+    expressions = {
+        order = "/s:Envelope/s:Body/m:Order/@id",
+        qty   = "number(/s:Envelope/s:Body/m:Order/m:Quantity) * 2",
+    }
+}`
+
+var testXMLLogLine = `
+<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event">
+  <System>
+    <Provider Name="Microsoft-Windows-Security-Auditing" Guid="{54849625-5478-4994-a5ba-3e3b0328c30d}"/>
+    <EventID>4624</EventID>
+  </System>
+  <EventData>
+    <Data Name="SubjectUserSid">S-1-5-18</Data>
+    <Data Name="SubjectUserName">HOST$</Data>
+  </EventData>
+</Event>
+`
+
+var testXMLNamespacedLogLine = `{"payload": "<s:Envelope xmlns:s=\"http://schemas.xmlsoap.org/soap/envelope/\"><s:Body><Order xmlns=\"urn:orders\" id=\"42\"><Quantity>3</Quantity></Order></s:Body></s:Envelope>"}`
+
+func TestPipeline_XML(t *testing.T) {
+	t.Parallel()
+	logger := util.TestFlowLogger(t)
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully run a pipeline with 1 xml stage without source": {
+			testXMLRiverSingleStageWithoutSource,
+			testXMLLogLine,
+			map[string]interface{}{
+				"event_id": "4624",
+				"provider": "Microsoft-Windows-Security-Auditing",
+				"user":     "HOST$",
+				"data":     float64(2),
+			},
+		},
+		"successfully run a pipeline with an xml stage with source and namespaces": {
+			testXMLRiverMultiStageWithNamespaces,
+			testXMLNamespacedLogLine,
+			map[string]interface{}{
+				"payload": `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><Order xmlns="urn:orders" id="42"><Quantity>3</Quantity></Order></s:Body></s:Envelope>`,
+				"order":   "42",
+				"qty":     float64(6),
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer)
+			assert.NoError(t, err, "Expected pipeline creation to not result in error")
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestXMLConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	var emptyString = ""
+
+	tests := map[string]struct {
+		config        *XMLConfig
+		wantExprCount int
+		err           error
+	}{
+		"empty config": {
+			nil,
+			0,
+			errors.New(ErrEmptyXMLStageConfig),
+		},
+		"no expressions": {
+			&XMLConfig{},
+			0,
+			errors.New(ErrExpressionsRequired),
+		},
+		"invalid expression": {
+			&XMLConfig{
+				Expressions: map[string]string{"extr1": "/Event["},
+			},
+			0,
+			errors.New(ErrCouldNotCompileXPath),
+		},
+		"undeclared prefix": {
+			&XMLConfig{
+				Expressions: map[string]string{"extr1": "/e:Event"},
+			},
+			0,
+			errors.New(ErrCouldNotCompileXPath),
+		},
+		"empty namespace prefix": {
+			&XMLConfig{
+				Expressions: map[string]string{"extr1": "/Event"},
+				Namespaces:  map[string]string{"": "urn:events"},
+			},
+			0,
+			errors.New(ErrEmptyXMLNamespacePrefix),
+		},
+		"empty source": {
+			&XMLConfig{
+				Expressions: map[string]string{"extr1": "/Event"},
+				Source:      &emptyString,
+			},
+			0,
+			errors.New(ErrEmptyXMLStageSource),
+		},
+		"valid": {
+			&XMLConfig{
+				Expressions: map[string]string{
+					"expr1": "/e:Event/e:System",
+					"expr2": "",
+					"expr3": "count(//Data)",
+				},
+				Namespaces: map[string]string{"e": "urn:events"},
+			},
+			3,
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			got, err := validateXMLConfig(tt.config)
+			if tt.err != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantExprCount, len(got))
+		})
+	}
+}
+
+func TestXMLParser_Parse(t *testing.T) {
+	t.Parallel()
+	logger := util.TestFlowLogger(t)
+
+	tests := map[string]struct {
+		config          XMLConfig
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"unprefixed names match any namespace": {
+			XMLConfig{Expressions: map[string]string{"id": "/Event/System/EventID"}},
+			testXMLLogLine,
+			map[string]interface{}{"id": "4624"},
+		},
+		"prefixed names match the namespace URI": {
+			XMLConfig{
+				Expressions: map[string]string{
+					"match":    "/e:Event/e:System/e:EventID",
+					"no_match": "/o:Event/o:System/o:EventID",
+				},
+				Namespaces: map[string]string{
+					"e": "http://schemas.microsoft.com/win/2004/08/events/event",
+					"o": "urn:other",
+				},
+			},
+			testXMLLogLine,
+			map[string]interface{}{"match": "4624"},
+		},
+		"element values concatenate nested text": {
+			XMLConfig{Expressions: map[string]string{"msg": "/log/msg"}},
+			`<log><msg>connection <b>refused</b> by peer</msg></log>`,
+			map[string]interface{}{"msg": "connection refused by peer"},
+		},
+		"boolean and string functions": {
+			XMLConfig{Expressions: map[string]string{
+				"is_error": "/log/@level = 'ERROR'",
+				"host":     "substring-before(/log/@src, ':')",
+			}},
+			`<?xml version="1.0"?><!-- java --><log level="ERROR" src="app01:8080"/>`,
+			map[string]interface{}{"is_error": true, "host": "app01"},
+		},
+		"namespace declarations aren't attributes": {
+			XMLConfig{Expressions: map[string]string{"attrs": "count(/Event/@*)"}},
+			`<Event xmlns="urn:events" xmlns:x="urn:x" x:id="1"/>`,
+			map[string]interface{}{"attrs": float64(1)},
+		},
+		"malformed xml": {
+			XMLConfig{Expressions: map[string]string{"id": "/Event"}},
+			`<Event><System></Event>`,
+			map[string]interface{}{},
+		},
+		"multiple root elements": {
+			XMLConfig{Expressions: map[string]string{"id": "/Event"}},
+			`<Event/><Event/>`,
+			map[string]interface{}{},
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			p, err := New(logger, nil, StageConfig{XMLConfig: &tt.config}, nil)
+			assert.NoError(t, err, "failed to create xml parser: %s", err)
+			out := processEntries(p, newEntry(nil, nil, tt.entry, time.Now()))[0]
+
+			assert.Equal(t, tt.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestValidateXMLDrop(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	s, err := newXMLStage(logger, XMLConfig{
+		DropMalformed: true,
+		Expressions:   map[string]string{"page": "/page"},
+	})
+	assert.NoError(t, err)
+
+	out := processEntries(s, newEntry(nil, nil, `<page>1</page>`, time.Now()))
+	assert.Equal(t, 1, len(out), "stage should have kept one valid xml line but got %v", out)
+
+	out = processEntries(s, newEntry(nil, nil, `<page>1</pag>`, time.Now()))
+	assert.Equal(t, 0, len(out), "stage should have kept zero valid xml lines but got %v", out)
+}
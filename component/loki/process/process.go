@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/grafana/agent/component/loki/process/internal/stages"
 )
 
@@ -18,14 +19,17 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
 // Arguments holds values which are used to configure the loki.process
 // component.
 type Arguments struct {
-	ForwardTo []loki.LogsReceiver  `river:"forward_to,attr"`
-	Stages    []stages.StageConfig `river:"stage,enum,optional"`
+	ForwardTo       []loki.LogsReceiver   `river:"forward_to,attr"`
+	Stages          []stages.StageConfig  `river:"stage,enum,optional"`
+	DeadLetterQueue *deadletter.Arguments `river:"dead_letter_queue,block,optional"`
 }
 
 // Exports exposes the receiver that can be used to send log entries to
@@ -40,7 +44,8 @@ var (
 
 // Component implements the loki.process component.
 type Component struct {
-	opts component.Options
+	opts       component.Options
+	deadLetter *deadletter.Queue
 
 	mut          sync.RWMutex
 	receiver     loki.LogsReceiver
@@ -53,8 +58,14 @@ type Component struct {
 
 // New creates a new loki.process component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	deadLetter, err := deadletter.New(o.Logger, o.Registerer, o.DataPath)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Component{
-		opts: o,
+		opts:       o,
+		deadLetter: deadLetter,
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -81,6 +92,7 @@ func (c *Component) Run(ctx context.Context) error {
 		close(c.processOut)
 		close(c.processIn)
 		c.mut.RUnlock()
+		c.deadLetter.Stop()
 	}()
 	wg := &sync.WaitGroup{}
 	wg.Add(2)
@@ -98,6 +110,10 @@ func (c *Component) Update(args component.Arguments) error {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
+	if err := c.deadLetter.Update(newArgs.DeadLetterQueue); err != nil {
+		return err
+	}
+
 	// We want to create a new pipeline if the config changed or if this is the
 	// first load. This will allow a component with no stages to function
 	// properly.
@@ -110,6 +126,7 @@ func (c *Component) Update(args component.Arguments) error {
 		if err != nil {
 			return err
 		}
+		pipeline.SetDeadLetterQueue(c.deadLetter)
 		c.entryHandler = loki.NewEntryHandler(c.processOut, func() {})
 		c.processIn = pipeline.Wrap(c.entryHandler).Chan()
 		c.stages = newArgs.Stages
@@ -147,6 +164,7 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 			return
 		case entry := <-c.processOut:
 			c.mut.RLock()
+			entry.Ack.Add(len(c.fanout) - 1)
 			for _, f := range c.fanout {
 				select {
 				case <-ctx.Done():
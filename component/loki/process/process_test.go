@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/grafana/agent/component/loki/process/internal/stages"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
@@ -303,3 +304,60 @@ stage.labels {
 		}
 	}
 }
+
+func TestDeadLetterQueue(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	// Malformed lines dropped by a nested pipeline are sent to the dead letter
+	// queue too.
+	stg := `stage.match {
+				selector = "{job=\"app\"}"
+
+				stage.json {
+					expressions    = { level = "" }
+					drop_malformed = true
+				}
+			}`
+
+	type cfg struct {
+		Stages []stages.StageConfig `river:"stage,enum"`
+	}
+	var stagesCfg cfg
+	err := river.Unmarshal([]byte(stg), &stagesCfg)
+	require.NoError(t, err)
+
+	out, dead := make(loki.LogsReceiver), make(loki.LogsReceiver)
+
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		DataPath:      t.TempDir(),
+		OnStateChange: func(e component.Exports) {},
+	}
+	args := Arguments{
+		ForwardTo:       []loki.LogsReceiver{out},
+		Stages:          stagesCfg.Stages,
+		DeadLetterQueue: &deadletter.Arguments{ForwardTo: []loki.LogsReceiver{dead}},
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	c.receiver <- loki.Entry{
+		Labels: model.LabelSet{"job": "app"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: `{"level":`},
+	}
+
+	select {
+	case e := <-dead:
+		require.Equal(t, `{"level":`, e.Line)
+		require.Equal(t, model.LabelSet{"job": "app"}, e.Labels)
+	case e := <-out:
+		require.FailNow(t, "malformed entry was forwarded", e.Line)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for dead letter entry")
+	}
+}
@@ -8,9 +8,6 @@ import (
 type metrics struct {
 	entriesProcessed prometheus_client.Counter
 	entriesOutgoing  prometheus_client.Counter
-	cacheHits        prometheus_client.Counter
-	cacheMisses      prometheus_client.Counter
-	cacheSize        prometheus_client.Gauge
 }
 
 // newMetrics creates a new set of metrics. If reg is non-nil, the metrics
@@ -26,26 +23,11 @@ func newMetrics(reg prometheus.Registerer) *metrics {
 		Name: "loki_relabel_entries_written",
 		Help: "Total number of log entries forwarded",
 	})
-	m.cacheMisses = prometheus_client.NewCounter(prometheus_client.CounterOpts{
-		Name: "loki_relabel_cache_misses",
-		Help: "Total number of cache misses",
-	})
-	m.cacheHits = prometheus_client.NewCounter(prometheus_client.CounterOpts{
-		Name: "loki_relabel_cache_hits",
-		Help: "Total number of cache hits",
-	})
-	m.cacheSize = prometheus_client.NewGauge(prometheus_client.GaugeOpts{
-		Name: "loki_relabel_cache_size",
-		Help: "Total size of relabel cache",
-	})
 
 	if reg != nil {
 		reg.MustRegister(
 			m.entriesProcessed,
 			m.entriesOutgoing,
-			m.cacheMisses,
-			m.cacheHits,
-			m.cacheSize,
 		)
 	}
 
@@ -10,9 +10,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/pkg/river"
-	lru "github.com/hashicorp/golang-lru"
 	"github.com/prometheus/common/model"
-	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 )
 
@@ -24,6 +22,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -72,7 +72,7 @@ type Component struct {
 	receiver loki.LogsReceiver
 	fanout   []loki.LogsReceiver
 
-	cache        *lru.Cache
+	cache        *flow_relabel.Cache
 	maxCacheSize int
 }
 
@@ -82,7 +82,7 @@ var (
 
 // New creates a new loki.relabel component.
 func New(o component.Options, args Arguments) (*Component, error) {
-	cache, err := lru.New(args.MaxCacheSize)
+	cache, err := flow_relabel.NewCache(args.MaxCacheSize, "loki_relabel", o.Registerer)
 	if err != nil {
 		return nil, err
 	}
@@ -115,14 +115,16 @@ func (c *Component) Run(ctx context.Context) error {
 			return nil
 		case entry := <-c.receiver:
 			c.metrics.entriesProcessed.Inc()
-			lbls := c.relabel(entry)
+			lbls := c.relabel(entry.Labels)
 			if len(lbls) == 0 {
 				level.Debug(c.opts.Logger).Log("msg", "dropping entry after relabeling", "labels", entry.Labels.String())
+				entry.Ack.Done()
 				continue
 			}
 
 			c.metrics.entriesOutgoing.Inc()
 			entry.Labels = lbls
+			entry.Ack.Add(len(c.fanout) - 1)
 			for _, f := range c.fanout {
 				select {
 				case <-ctx.Done():
@@ -144,7 +146,6 @@ func (c *Component) Update(args component.Arguments) error {
 	if relabelingChanged(c.rcs, newRCS) {
 		level.Debug(c.opts.Logger).Log("msg", "received new relabel configs, purging cache")
 		c.cache.Purge()
-		c.metrics.cacheSize.Set(0)
 	}
 	if newArgs.MaxCacheSize != c.maxCacheSize {
 		evicted := c.cache.Resize(newArgs.MaxCacheSize)
@@ -152,6 +153,7 @@ func (c *Component) Update(args component.Arguments) error {
 			level.Debug(c.opts.Logger).Log("msg", "resizing the cache lead to evicting of items", "len_items_evicted", evicted)
 		}
 	}
+	c.maxCacheSize = newArgs.MaxCacheSize
 	c.rcs = newRCS
 	c.fanout = newArgs.ForwardTo
 
@@ -172,63 +174,8 @@ func relabelingChanged(prev, next []*relabel.Config) bool {
 	return false
 }
 
-type cacheItem struct {
-	original  model.LabelSet
-	relabeled model.LabelSet
-}
-
-// TODO(@tpaschalis) It's unfortunate how we have to cast back and forth
-// between model.LabelSet (map) and labels.Labels (slice). Promtail does
-// not have this issue as relabel config rules are only applied to targets.
-// Do we want to use labels.Labels in loki.Entry instead?
-func (c *Component) relabel(e loki.Entry) model.LabelSet {
-	hash := e.Labels.Fingerprint()
-
-	// Let's look in the cache for the hash of the entry's labels.
-	val, found := c.cache.Get(hash)
-
-	// We've seen this hash before; let's see if we've already relabeled this
-	// specific entry before and can return early, or if it's a collision.
-	if found {
-		for _, ci := range val.([]cacheItem) {
-			if e.Labels.Equal(ci.original) {
-				c.metrics.cacheHits.Inc()
-				return ci.relabeled
-			}
-		}
-	}
-
-	// Seems like it's either a new entry or a hash collision.
-	c.metrics.cacheMisses.Inc()
-	relabeled := c.process(e)
-
-	// In case it's a new hash, initialize it as a new cacheItem.
-	// If it was a collision, append the result to the cached slice.
-	if !found {
-		val = []cacheItem{{e.Labels, relabeled}}
-	} else {
-		val = append(val.([]cacheItem), cacheItem{e.Labels, relabeled})
-	}
-
-	c.cache.Add(hash, val)
-	c.metrics.cacheSize.Set(float64(c.cache.Len()))
-
-	return relabeled
-}
-
-func (c *Component) process(e loki.Entry) model.LabelSet {
-	var lbls labels.Labels
-	for k, v := range e.Labels {
-		lbls = append(lbls, labels.Label{
-			Name:  string(k),
-			Value: string(v),
-		})
-	}
-	lbls, _ = relabel.Process(lbls, c.rcs...)
-
-	relabeled := make(model.LabelSet, len(lbls))
-	for i := range lbls {
-		relabeled[model.LabelName(lbls[i].Name)] = model.LabelValue(lbls[i].Value)
-	}
-	return relabeled
+func (c *Component) relabel(lset model.LabelSet) model.LabelSet {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.cache.Relabel(lset, c.rcs)
 }
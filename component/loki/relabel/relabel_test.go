@@ -215,18 +215,12 @@ func TestCache(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 	// Let's look into the cache's structure now!
-	// The cache should have stored each label set by its fingerprint.
+	// The cache should have stored each label set by its fingerprint, with
+	// the correct relabeled values applied to it.
 	for i := 0; i < 3; i++ {
-		val, ok := c.cache.Get(lsets[i].Fingerprint())
+		cached, ok := c.cache.Get(lsets[i].Fingerprint(), lsets[i].Equal)
 		require.True(t, ok)
-		cached, ok := val.([]cacheItem)
-		require.True(t, ok)
-
-		// Each cache value should be a 1-item slice, with the correct initial
-		// and relabeled values applied to it.
-		require.Len(t, cached, 1)
-		require.Equal(t, cached[0].original, lsets[i])
-		require.Equal(t, cached[0].relabeled, rlsets[i])
+		require.Equal(t, rlsets[i], cached)
 	}
 
 	// Let's send over an entry we've seen before.
@@ -235,11 +229,9 @@ func TestCache(t *testing.T) {
 	e.Labels = lsets[0]
 	c.receiver <- e
 	require.Equal(t, c.cache.Len(), 3)
-	val, _ := c.cache.Get(lsets[0].Fingerprint())
-	cachedVal := val.([]cacheItem)
-	require.Len(t, cachedVal, 1)
-	require.Equal(t, cachedVal[0].original, lsets[0])
-	require.Equal(t, cachedVal[0].relabeled, rlsets[0])
+	cached, ok := c.cache.Get(lsets[0].Fingerprint(), lsets[0].Equal)
+	require.True(t, ok)
+	require.Equal(t, rlsets[0], cached)
 
 	// Now, let's try to hit a collision.
 	// These LabelSets are known to collide (string: 8746e5b6c5f0fb60)
@@ -259,15 +251,12 @@ func TestCache(t *testing.T) {
 	// Both of these should be under a single, new cache key which will contain
 	// both entries.
 	require.Equal(t, c.cache.Len(), 4)
-	val, ok := c.cache.Get(ls1.Fingerprint())
+	cached, ok = c.cache.Get(ls1.Fingerprint(), ls1.Equal)
 	require.True(t, ok)
-	cachedVal = val.([]cacheItem)
-	require.Len(t, cachedVal, 2)
-
-	require.Equal(t, cachedVal[0].original, ls1)
-	require.Equal(t, cachedVal[1].original, ls2)
-	require.Equal(t, cachedVal[0].relabeled, ls1.Merge(envls))
-	require.Equal(t, cachedVal[1].relabeled, ls2.Merge(envls))
+	require.Equal(t, ls1.Merge(envls), cached)
+	cached, ok = c.cache.Get(ls2.Fingerprint(), ls2.Equal)
+	require.True(t, ok)
+	require.Equal(t, ls2.Merge(envls), cached)
 
 	// Finally, send two more entries, which should fill up the cache and evict
 	// the Least Recently Used items (lsets[1], and lsets[2]).
@@ -276,12 +265,15 @@ func TestCache(t *testing.T) {
 	e.Labels = lsets[4]
 	c.receiver <- e
 
+	time.Sleep(100 * time.Millisecond)
 	require.Equal(t, c.cache.Len(), 4)
-	wantKeys := []model.Fingerprint{lsets[0].Fingerprint(), ls1.Fingerprint(), lsets[3].Fingerprint(), lsets[4].Fingerprint()}
-	for i, k := range c.cache.Keys() { // Returns the cache keys in LRU order.
-		f, ok := k.(model.Fingerprint)
+	for _, ls := range []model.LabelSet{lsets[1], lsets[2]} {
+		_, ok := c.cache.Get(ls.Fingerprint(), ls.Equal)
+		require.False(t, ok)
+	}
+	for _, ls := range []model.LabelSet{lsets[0], ls1, lsets[3], lsets[4]} {
+		_, ok := c.cache.Get(ls.Fingerprint(), ls.Equal)
 		require.True(t, ok)
-		require.Equal(t, f, wantKeys[i])
 	}
 }
 
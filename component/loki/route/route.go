@@ -0,0 +1,268 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/clients/pkg/logentry/logql"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.route",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Supported routing modes.
+const (
+	ModeFirstMatch = "first_match"
+	ModeFanOut     = "fan_out"
+)
+
+// defaultRouteName is the name of the route made of the default receivers in
+// metrics.
+const defaultRouteName = "default"
+
+// Arguments holds values which are used to configure the loki.route
+// component.
+type Arguments struct {
+	// How entries matching multiple routes are handled.
+	Mode string `river:"mode,attr,optional"`
+
+	// Where entries which don't match any route are forwarded to.
+	DefaultForwardTo []loki.LogsReceiver `river:"default_forward_to,attr,optional"`
+
+	// The routes to match each log entry against, in order.
+	Routes []RouteConfig `river:"route,block,optional"`
+}
+
+// RouteConfig configures a single route of the loki.route component.
+type RouteConfig struct {
+	Name      string              `river:"name,attr,optional"`
+	Selector  string              `river:"selector,attr"`
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// DefaultArguments provides the default arguments for the loki.route
+// component.
+var DefaultArguments = Arguments{
+	Mode: ModeFirstMatch,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+	return a.Validate()
+}
+
+// Validate returns an error if the arguments are invalid.
+func (a *Arguments) Validate() error {
+	_, err := compileRoutes(*a)
+	return err
+}
+
+// Exports holds values which are exported by the loki.route component.
+type Exports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+}
+
+// route is a compiled RouteConfig.
+type route struct {
+	name      string
+	matchers  []*labels.Matcher
+	filter    logql.Filter
+	forwardTo []loki.LogsReceiver
+}
+
+// matches returns true if the labels and the line of entry match the
+// selector of the route.
+func (r *route) matches(entry loki.Entry) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(string(entry.Labels[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	return r.filter == nil || r.filter([]byte(entry.Line))
+}
+
+func compileRoutes(args Arguments) ([]*route, error) {
+	switch args.Mode {
+	case ModeFirstMatch, ModeFanOut:
+	default:
+		return nil, fmt.Errorf("mode must be one of %q or %q, got %q", ModeFirstMatch, ModeFanOut, args.Mode)
+	}
+
+	var (
+		routes = make([]*route, 0, len(args.Routes))
+		names  = make(map[string]struct{}, len(args.Routes))
+	)
+	for i, rc := range args.Routes {
+		name := rc.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		if name == defaultRouteName {
+			return nil, fmt.Errorf("route name %q is reserved for default_forward_to", defaultRouteName)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate route name %q", name)
+		}
+		names[name] = struct{}{}
+
+		if rc.Selector == "" {
+			return nil, fmt.Errorf("route %q: selector must not be empty", name)
+		}
+		expr, err := logql.ParseExpr(rc.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: invalid selector: %w", name, err)
+		}
+		filter, err := expr.Filter()
+		if err != nil {
+			return nil, fmt.Errorf("route %q: invalid selector: %w", name, err)
+		}
+
+		routes = append(routes, &route{
+			name:      name,
+			matchers:  expr.Matchers(),
+			filter:    filter,
+			forwardTo: rc.ForwardTo,
+		})
+	}
+	return routes, nil
+}
+
+// Component implements the loki.route component.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+
+	mut              sync.RWMutex
+	mode             string
+	routes           []*route
+	defaultForwardTo []loki.LogsReceiver
+
+	receiver loki.LogsReceiver
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// New creates a new loki.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		metrics: newMetrics(o.Registerer),
+	}
+
+	// Create and immediately export the receiver which remains the same for
+	// the component's lifetime.
+	c.receiver = make(loki.LogsReceiver)
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver:
+			c.metrics.entriesProcessed.Inc()
+
+			receivers := c.receiversFor(entry)
+			if len(receivers) == 0 {
+				level.Debug(c.opts.Logger).Log("msg", "dropping entry which didn't match any route", "labels", entry.Labels.String())
+				c.metrics.entriesDropped.Inc()
+				entry.Ack.Done()
+				continue
+			}
+
+			entry.Ack.Add(len(receivers) - 1)
+			for _, r := range receivers {
+				select {
+				case <-ctx.Done():
+					return nil
+				case r <- entry:
+				}
+			}
+		}
+	}
+}
+
+// receiversFor returns the receivers entry should be forwarded to. Receivers
+// shared by multiple matching routes are only returned once.
+func (c *Component) receiversFor(entry loki.Entry) []loki.LogsReceiver {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var (
+		receivers []loki.LogsReceiver
+		seen      = make(map[loki.LogsReceiver]struct{})
+		matched   bool
+	)
+	add := func(forwardTo []loki.LogsReceiver) {
+		for _, r := range forwardTo {
+			if _, ok := seen[r]; ok {
+				continue
+			}
+			seen[r] = struct{}{}
+			receivers = append(receivers, r)
+		}
+	}
+
+	for _, r := range c.routes {
+		if !r.matches(entry) {
+			continue
+		}
+		matched = true
+		c.metrics.entriesRouted.WithLabelValues(r.name).Inc()
+		add(r.forwardTo)
+		if c.mode == ModeFirstMatch {
+			break
+		}
+	}
+	if !matched && len(c.defaultForwardTo) > 0 {
+		c.metrics.entriesRouted.WithLabelValues(defaultRouteName).Inc()
+		add(c.defaultForwardTo)
+	}
+	return receivers
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	routes, err := compileRoutes(newArgs)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.mode = newArgs.Mode
+	c.routes = routes
+	c.defaultForwardTo = newArgs.DefaultForwardTo
+	return nil
+}
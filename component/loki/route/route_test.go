@@ -0,0 +1,190 @@
+package route
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouting(t *testing.T) {
+	var (
+		errors  = make(loki.LogsReceiver, 10)
+		timeout = make(loki.LogsReceiver, 10)
+		rest    = make(loki.LogsReceiver, 10)
+	)
+	routes := []RouteConfig{
+		{Name: "errors", Selector: `{level="error"}`, ForwardTo: []loki.LogsReceiver{errors}},
+		{Name: "timeout", Selector: `{job=~".*"} |= "timeout"`, ForwardTo: []loki.LogsReceiver{timeout}},
+	}
+
+	tt := []struct {
+		name     string
+		mode     string
+		line     string
+		level    string
+		expected map[loki.LogsReceiver]int
+	}{
+		{"first_match only sends to the first route", ModeFirstMatch, "timeout", "error", map[loki.LogsReceiver]int{errors: 1}},
+		{"first_match matches lines", ModeFirstMatch, "timeout", "info", map[loki.LogsReceiver]int{timeout: 1}},
+		{"first_match sends unmatched entries to the default", ModeFirstMatch, "ok", "info", map[loki.LogsReceiver]int{rest: 1}},
+		{"fan_out sends to every matching route", ModeFanOut, "timeout", "error", map[loki.LogsReceiver]int{errors: 1, timeout: 1}},
+		{"fan_out sends unmatched entries to the default", ModeFanOut, "ok", "debug", map[loki.LogsReceiver]int{rest: 1}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(component.Options{
+				Logger:        util.TestFlowLogger(t),
+				Registerer:    prometheus.NewRegistry(),
+				OnStateChange: func(e component.Exports) {},
+			}, Arguments{
+				Mode:             tc.mode,
+				DefaultForwardTo: []loki.LogsReceiver{rest},
+				Routes:           routes,
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go c.Run(ctx)
+
+			acked := make(chan struct{})
+			c.receiver <- loki.Entry{
+				Labels: model.LabelSet{"job": "app", "level": model.LabelValue(tc.level)},
+				Entry:  logproto.Entry{Timestamp: time.Now(), Line: tc.line},
+				Ack:    loki.NewAck(func() { close(acked) }),
+			}
+
+			for r, n := range tc.expected {
+				for i := 0; i < n; i++ {
+					select {
+					case e := <-r:
+						require.Equal(t, tc.line, e.Line)
+						e.Ack.Done()
+					case <-time.After(5 * time.Second):
+						require.FailNow(t, "failed waiting for log line")
+					}
+				}
+			}
+
+			select {
+			case <-acked:
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "entry wasn't acknowledged")
+			}
+			require.Empty(t, errors)
+			require.Empty(t, timeout)
+			require.Empty(t, rest)
+		})
+	}
+}
+
+func TestDropsUnmatchedEntries(t *testing.T) {
+	ch := make(loki.LogsReceiver, 1)
+	c, err := New(component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}, Arguments{
+		Mode:   ModeFirstMatch,
+		Routes: []RouteConfig{{Selector: `{level="error"}`, ForwardTo: []loki.LogsReceiver{ch}}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	acked := make(chan struct{})
+	c.receiver <- loki.Entry{
+		Labels: model.LabelSet{"level": "debug"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "debug line"},
+		Ack:    loki.NewAck(func() { close(acked) }),
+	}
+
+	select {
+	case <-acked:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "dropped entry wasn't acknowledged")
+	}
+	require.Empty(t, ch)
+}
+
+func TestUnmarshalRiver(t *testing.T) {
+	tt := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			cfg: `
+				mode = "fan_out"
+				route {
+					name       = "errors"
+					selector   = "{level=\"error\"} |~ \"(?i)panic\""
+					forward_to = []
+				}`,
+		},
+		{
+			name:        "invalid mode",
+			cfg:         `mode = "round_robin"`,
+			expectedErr: `mode must be one of "first_match" or "fan_out", got "round_robin"`,
+		},
+		{
+			name: "invalid selector",
+			cfg: `
+				route {
+					selector   = "level=error"
+					forward_to = []
+				}`,
+			expectedErr: `route "0": invalid selector`,
+		},
+		{
+			name: "duplicate names",
+			cfg: `
+				route {
+					name       = "a"
+					selector   = "{level=\"error\"}"
+					forward_to = []
+				}
+				route {
+					name       = "a"
+					selector   = "{level=\"warn\"}"
+					forward_to = []
+				}`,
+			expectedErr: `duplicate route name "a"`,
+		},
+		{
+			name: "reserved name",
+			cfg: `
+				route {
+					name       = "default"
+					selector   = "{level=\"error\"}"
+					forward_to = []
+				}`,
+			expectedErr: `route name "default" is reserved for default_forward_to`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package rules
+
+import "github.com/grafana/agent/component/common/ruler"
+
+type DebugInfo struct {
+	Error              string                   `river:"error,attr,optional"`
+	PrometheusRules    []DebugK8sPrometheusRule `river:"prometheus_rule,block,optional"`
+	LokiRuleNamespaces []DebugLokiNamespace     `river:"loki_rule_namespace,block,optional"`
+}
+
+type DebugK8sPrometheusRule = ruler.DebugK8sPrometheusRule
+
+type DebugLokiNamespace struct {
+	Tenant        string `river:"tenant,attr,optional"`
+	Name          string `river:"name,attr"`
+	NumRuleGroups int    `river:"num_rule_groups,attr"`
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	info := c.Reconciler.DebugInfo()
+
+	output := DebugInfo{
+		Error:           info.Error,
+		PrometheusRules: info.PrometheusRules,
+	}
+	for _, ns := range info.RuleNamespaces {
+		output.LokiRuleNamespaces = append(output.LokiRuleNamespaces, DebugLokiNamespace{
+			Tenant:        ns.Tenant,
+			Name:          ns.Name,
+			NumRuleGroups: ns.NumRuleGroups,
+		})
+	}
+	return output
+}
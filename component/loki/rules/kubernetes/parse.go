@@ -0,0 +1,54 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3" // Used for prometheus rulefmt compatibility instead of gopkg.in/yaml.v2
+)
+
+// parseRuleGroups parses rule groups like rulefmt.Parse. Unlike
+// rulefmt.Parse, expressions aren't parsed, since they're LogQL rather than
+// PromQL; they're validated by the Loki ruler instead.
+func parseRuleGroups(content []byte) (*rulefmt.RuleGroups, []error) {
+	var groups rulefmt.RuleGroups
+	if err := yaml.Unmarshal(content, &groups); err != nil {
+		return nil, []error{err}
+	}
+
+	if errs := validateRuleGroups(groups.Groups); len(errs) > 0 {
+		return nil, errs
+	}
+	return &groups, nil
+}
+
+// validateRuleGroups performs the checks of rulefmt.RuleGroups.Validate
+// which don't depend on the query language.
+func validateRuleGroups(groups []rulefmt.RuleGroup) []error {
+	var errs []error
+
+	seen := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		if g.Name == "" {
+			errs = append(errs, fmt.Errorf("rule group name must not be empty"))
+			continue
+		}
+		if _, ok := seen[g.Name]; ok {
+			errs = append(errs, fmt.Errorf("groupname: %q is repeated in the same resource", g.Name))
+		}
+		seen[g.Name] = struct{}{}
+
+		for i, r := range g.Rules {
+			switch {
+			case r.Record.Value != "" && r.Alert.Value != "":
+				errs = append(errs, fmt.Errorf("group %q, rule %d: only one of 'record' and 'alert' must be set", g.Name, i+1))
+			case r.Record.Value == "" && r.Alert.Value == "":
+				errs = append(errs, fmt.Errorf("group %q, rule %d: one of 'record' or 'alert' must be set", g.Name, i+1))
+			case r.Expr.Value == "":
+				errs = append(errs, fmt.Errorf("group %q, rule %d: field 'expr' must be set in rule", g.Name, i+1))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,39 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuleGroups(t *testing.T) {
+	groups, errs := parseRuleGroups([]byte(`
+groups:
+- name: group
+  rules:
+  - alert: HighErrorRate
+    expr: sum by (app) (rate({namespace="default"} |= "error" [5m])) > 10
+    for: 5m
+  - record: app:errors:rate5m
+    expr: sum by (app) (rate({namespace="default"} |= "error" [5m]))
+`))
+	require.Empty(t, errs)
+	require.Len(t, groups.Groups, 1)
+	require.Len(t, groups.Groups[0].Rules, 2)
+	require.Equal(t, "HighErrorRate", groups.Groups[0].Rules[0].Alert.Value)
+	require.Equal(t, `sum by (app) (rate({namespace="default"} |= "error" [5m])) > 10`, groups.Groups[0].Rules[0].Expr.Value)
+
+	_, errs = parseRuleGroups([]byte(`
+groups:
+- name: group
+  rules:
+  - alert: a
+    expr: '{app="foo"}'
+- name: group
+  rules:
+  - expr: '{app="foo"}'
+`))
+	require.Len(t, errs, 2)
+	require.EqualError(t, errs[0], `groupname: "group" is repeated in the same resource`)
+	require.EqualError(t, errs[1], `group "group", rule 1: one of 'record' or 'alert' must be set`)
+}
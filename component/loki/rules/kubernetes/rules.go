@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/ruler"
+	rulerClient "github.com/grafana/agent/pkg/ruler/client"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.rules.kubernetes",
+		Args:    Arguments{},
+		Exports: nil,
+		Build: func(o component.Options, c component.Arguments) (component.Component, error) {
+			return NewComponent(o, c.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
+	})
+}
+
+// backend loads rules into the Loki ruler. Expressions are LogQL, so they're
+// validated by the ruler rather than by the component.
+var backend = ruler.Backend{
+	Name:            "loki",
+	DisplayName:     "Loki ruler",
+	API:             rulerClient.LokiAPI,
+	ParseRuleGroups: parseRuleGroups,
+}
+
+// Component loads the rules of PrometheusRule resources into Loki.
+type Component struct {
+	*ruler.Reconciler
+}
+
+var _ component.Component = (*Component)(nil)
+var _ component.DebugComponent = (*Component)(nil)
+var _ component.HealthComponent = (*Component)(nil)
+var _ component.HTTPComponent = (*Component)(nil)
+
+func NewComponent(o component.Options, args Arguments) (*Component, error) {
+	r, err := ruler.New(o, backend, args.rulerArguments())
+	if err != nil {
+		return nil, err
+	}
+	return &Component{Reconciler: r}, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	return c.Reconciler.Update(newConfig.(Arguments).rulerArguments())
+}
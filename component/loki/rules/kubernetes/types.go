@@ -0,0 +1,75 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/ruler"
+)
+
+type Arguments struct {
+	Address             string                  `river:"address,attr"`
+	TenantID            string                  `river:"tenant_id,attr,optional"`
+	UseLegacyRoutes     bool                    `river:"use_legacy_routes,attr,optional"`
+	HTTPClientConfig    config.HTTPClientConfig `river:",squash"`
+	SyncInterval        time.Duration           `river:"sync_interval,attr,optional"`
+	LokiNameSpacePrefix string                  `river:"loki_namespace_prefix,attr,optional"`
+	NamespaceTenantIDs  map[string]string       `river:"namespace_tenant_ids,attr,optional"`
+
+	RuleSelector          LabelSelector `river:"rule_selector,block,optional"`
+	RuleNamespaceSelector LabelSelector `river:"rule_namespace_selector,block,optional"`
+	RuleGroupSelector     LabelSelector `river:"rule_group_selector,block,optional"`
+}
+
+var DefaultArguments = Arguments{
+	SyncInterval:        30 * time.Second,
+	LokiNameSpacePrefix: "agent",
+	HTTPClientConfig:    config.DefaultHTTPClientConfig,
+}
+
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.SyncInterval <= 0 {
+		return fmt.Errorf("sync_interval must be greater than 0")
+	}
+	if args.LokiNameSpacePrefix == "" {
+		return fmt.Errorf("loki_namespace_prefix must not be empty")
+	}
+	for ns, tenant := range args.NamespaceTenantIDs {
+		if tenant == "" {
+			return fmt.Errorf("namespace_tenant_ids: tenant ID for namespace %q must not be empty", ns)
+		}
+	}
+
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	return args.HTTPClientConfig.Validate()
+}
+
+type (
+	LabelSelector   = ruler.LabelSelector
+	MatchExpression = ruler.MatchExpression
+)
+
+// rulerArguments converts args to the arguments of the reconciler.
+func (args Arguments) rulerArguments() ruler.Arguments {
+	return ruler.Arguments{
+		Address:            args.Address,
+		TenantID:           args.TenantID,
+		UseLegacyRoutes:    args.UseLegacyRoutes,
+		HTTPClientConfig:   args.HTTPClientConfig,
+		SyncInterval:       args.SyncInterval,
+		NamespacePrefix:    args.LokiNameSpacePrefix,
+		NamespaceTenantIDs: args.NamespaceTenantIDs,
+
+		RuleSelector:          args.RuleSelector,
+		RuleNamespaceSelector: args.RuleNamespaceSelector,
+		RuleGroupSelector:     args.RuleGroupSelector,
+	}
+}
@@ -0,0 +1,415 @@
+// Package api implements the loki.source.api component.
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/util/memwatch"
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.api",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
+	})
+}
+
+// pushPath is the path push requests are received on.
+const pushPath = "/loki/api/v1/push"
+
+const (
+	// tenantHeader is the header holding the tenant of a push request.
+	tenantHeader = "X-Scope-OrgID"
+
+	// reservedLabelTenantID is the label loki.write uses to pick the tenant
+	// of an entry.
+	reservedLabelTenantID = "__tenant_id__"
+
+	// anonymousTenant is the tenant reported in metrics for requests without
+	// a tenant header.
+	anonymousTenant = "anonymous"
+)
+
+// Arguments holds values which are used to configure the loki.source.api
+// component.
+type Arguments struct {
+	HTTP                 ServerArguments     `river:"http,block"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	MaxRequestBodySize   units.Base2Bytes    `river:"max_request_body_size,attr,optional"`
+
+	// TenantPassthrough runs the component in gateway mode, keeping the tenant
+	// of each request so loki.write sends entries as the same tenant.
+	TenantPassthrough bool `river:"tenant_passthrough,attr,optional"`
+}
+
+// ServerArguments configures the HTTP server push requests are received on.
+type ServerArguments struct {
+	ListenAddress string `river:"listen_address,attr,optional"`
+	ListenPort    int    `river:"listen_port,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	HTTP: ServerArguments{
+		ListenAddress: "0.0.0.0",
+	},
+	MaxRequestBodySize: 10 * units.MiB,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.HTTP.ListenPort <= 0 || args.HTTP.ListenPort > 65535:
+		return fmt.Errorf("http listen_port must be between 1 and 65535")
+	case args.MaxRequestBodySize <= 0:
+		return fmt.Errorf("max_request_body_size must be greater than 0")
+	}
+	return nil
+}
+
+// errMemoryPressure is returned for requests refused due to high memory
+// pressure.
+var errMemoryPressure = errors.New("refusing request due to high memory pressure, retry later")
+
+// Component implements the loki.source.api component.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+
+	mut      sync.RWMutex
+	args     Arguments
+	rcs      []*relabel.Config
+	labels   model.LabelSet
+	server   *http.Server
+	listener net.Listener
+
+	// memoryPressure is set while memory pressure is high, refusing requests
+	// until it's relieved.
+	memoryPressure atomic.Bool
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new loki.source.api component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := newMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:    o,
+		metrics: m,
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer memwatch.Default().Register(func(l memwatch.Level) {
+		c.memoryPressure.Store(l >= memwatch.LevelHigh)
+	})()
+
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.stopServer()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.server == nil || c.args.HTTP != newArgs.HTTP {
+		c.stopServer()
+		if err := c.startServer(newArgs.HTTP); err != nil {
+			return err
+		}
+	}
+
+	c.args = newArgs
+	c.rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	c.labels = make(model.LabelSet, len(newArgs.Labels))
+	for k, v := range newArgs.Labels {
+		c.labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return nil
+}
+
+// startServer starts the HTTP server. c.mut must be held when calling.
+func (c *Component) startServer(cfg ServerArguments) error {
+	addr := net.JoinHostPort(cfg.ListenAddress, strconv.Itoa(cfg.ListenPort))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(pushPath, c.handlePush)
+
+	c.listener = lis
+	c.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(c.opts.Logger).Log("msg", "http server exited with error", "err", err)
+		}
+	}(c.server)
+
+	level.Info(c.opts.Logger).Log("msg", "receiving push requests", "addr", lis.Addr().String(), "path", pushPath)
+	return nil
+}
+
+// stopServer stops the HTTP server. c.mut must be held when calling.
+func (c *Component) stopServer() {
+	if c.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to gracefully stop http server", "err", err)
+	}
+	c.server, c.listener = nil, nil
+}
+
+func (c *Component) handlePush(w http.ResponseWriter, r *http.Request) {
+	c.mut.RLock()
+	var (
+		args   = c.args
+		rcs    = c.rcs
+		extra  = c.labels
+		tenant = r.Header.Get(tenantHeader)
+	)
+	c.mut.RUnlock()
+
+	metricsTenant := tenant
+	if metricsTenant == "" {
+		metricsTenant = anonymousTenant
+	}
+
+	status, err := c.push(r, tenant, args, rcs, extra)
+	c.metrics.requests.WithLabelValues(metricsTenant, strconv.Itoa(status)).Inc()
+	if err != nil {
+		level.Debug(c.opts.Logger).Log("msg", "rejected push request", "tenant", metricsTenant, "status", status, "err", err)
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", strconv.Itoa(int(memwatch.RetryAfter.Seconds())))
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// push decodes the push request in r and forwards its entries. The HTTP status
+// code to respond with is returned.
+func (c *Component) push(r *http.Request, tenant string, args Arguments, rcs []*relabel.Config, extra model.LabelSet) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	if c.memoryPressure.Load() {
+		return http.StatusServiceUnavailable, errMemoryPressure
+	}
+	if args.TenantPassthrough && tenant == "" {
+		return http.StatusUnauthorized, fmt.Errorf("no tenant ID in %s header", tenantHeader)
+	}
+
+	// Read the body up front so oversized requests can be told apart from
+	// malformed ones, which the decoders don't preserve.
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, int64(args.MaxRequestBodySize)))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %s", args.MaxRequestBodySize)
+		}
+		return http.StatusBadRequest, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	req, err := push.ParseRequest(c.opts.Logger, tenant, r, nil)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	metricsTenant := tenant
+	if metricsTenant == "" {
+		metricsTenant = anonymousTenant
+	}
+
+	var lastErr error
+	for _, stream := range req.Streams {
+		lbls, err := c.streamLabels(stream.Labels, rcs, extra)
+		if err != nil {
+			lastErr = err
+			continue
+		} else if lbls == nil {
+			// Dropped by relabeling.
+			continue
+		}
+		if args.TenantPassthrough {
+			lbls[reservedLabelTenantID] = model.LabelValue(tenant)
+		}
+
+		for _, entry := range stream.Entries {
+			e := loki.Entry{
+				Labels: lbls.Clone(),
+				Entry:  logproto.Entry{Line: entry.Line, Timestamp: entry.Timestamp},
+			}
+			if !args.UseIncomingTimestamp {
+				e.Timestamp = time.Now()
+			}
+
+			if err := c.forward(r.Context(), args.ForwardTo, e); err != nil {
+				return http.StatusServiceUnavailable, err
+			}
+			c.metrics.entries.WithLabelValues(metricsTenant).Inc()
+			c.metrics.bytes.WithLabelValues(metricsTenant).Add(float64(len(entry.Line)))
+		}
+	}
+
+	if lastErr != nil {
+		return http.StatusBadRequest, fmt.Errorf("at least one stream in the push request failed to process: %w", lastErr)
+	}
+	return http.StatusNoContent, nil
+}
+
+// streamLabels returns the labels of the entries of a stream with the given
+// labels. nil is returned if the stream is dropped by relabeling.
+func (c *Component) streamLabels(streamLabels string, rcs []*relabel.Config, extra model.LabelSet) (model.LabelSet, error) {
+	ls, err := promql_parser.ParseMetric(streamLabels)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(ls)
+
+	lb := labels.NewBuilder(ls)
+	for k, v := range extra {
+		lb.Set(string(k), string(v))
+	}
+
+	processed, keep := relabel.Process(lb.Labels(nil), rcs...)
+	if !keep || len(processed) == 0 {
+		return nil, nil
+	}
+
+	res := make(model.LabelSet, len(processed))
+	for _, l := range processed {
+		if strings.HasPrefix(l.Name, "__") {
+			continue
+		}
+		res[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return res, nil
+}
+
+// forward sends e to every receiver, giving up if ctx is canceled.
+func (c *Component) forward(ctx context.Context, receivers []loki.LogsReceiver, e loki.Entry) error {
+	for _, receiver := range receivers {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case receiver <- e:
+		}
+	}
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var info debugInfo
+	if c.listener != nil {
+		info.Address = c.listener.Addr().String()
+	}
+	return info
+}
+
+type debugInfo struct {
+	Address string `river:"address,attr,optional"`
+}
+
+type metrics struct {
+	requests *prometheus.CounterVec
+	entries  *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_requests_total",
+			Help: "Total number of push requests received, by tenant and status code.",
+		}, []string{"tenant", "status_code"}),
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_entries_total",
+			Help: "Total number of log entries forwarded, by tenant.",
+		}, []string{"tenant"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_bytes_total",
+			Help: "Total number of bytes of log lines forwarded, by tenant.",
+		}, []string{"tenant"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.entries, m.bytes} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = `{"streams": [{"stream": {"job": "app", "__meta_drop": "x"}, "values": [["1680000000000000000", "hello"], ["1680000001000000000", "world"]]}]}`
+
+func TestPush(t *testing.T) {
+	ch := make(chan loki.Entry, 10)
+	c, addr := newTestComponent(t, `
+		labels                 = {"env" = "test"}
+		use_incoming_timestamp = true
+	`, ch)
+
+	res := sendPush(t, addr, "tenant-a", testPayload)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	for _, line := range []string{"hello", "world"} {
+		select {
+		case e := <-ch:
+			require.Equal(t, line, e.Line)
+			require.Equal(t, model.LabelSet{"job": "app", "env": "test"}, e.Labels)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for log line")
+		}
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.entries.WithLabelValues("tenant-a")))
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.requests.WithLabelValues("tenant-a", "204")))
+
+	// Requests without a tenant are reported as the anonymous tenant.
+	res = sendPush(t, addr, "", testPayload)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.entries.WithLabelValues(anonymousTenant)))
+}
+
+func TestPush_TenantPassthrough(t *testing.T) {
+	ch := make(chan loki.Entry, 10)
+	c, addr := newTestComponent(t, `tenant_passthrough = true`, ch)
+
+	// A tenant is required in gateway mode.
+	res := sendPush(t, addr, "", testPayload)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.requests.WithLabelValues(anonymousTenant, "401")))
+	require.Empty(t, ch)
+
+	res = sendPush(t, addr, "tenant-a", testPayload)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	e := <-ch
+	require.Equal(t, model.LabelSet{"job": "app", reservedLabelTenantID: "tenant-a"}, e.Labels)
+	require.WithinDuration(t, time.Now(), e.Timestamp, 5*time.Second)
+}
+
+func TestPush_MaxRequestBodySize(t *testing.T) {
+	_, addr := newTestComponent(t, `max_request_body_size = "16B"`, make(chan loki.Entry, 10))
+
+	res := sendPush(t, addr, "", testPayload)
+	require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+}
+
+func TestPush_MemoryPressure(t *testing.T) {
+	// The component isn't run, so memory pressure isn't reset by the memory
+	// watchdog.
+	c, addr := buildTestComponent(t, ``, make(chan loki.Entry, 10))
+	c.memoryPressure.Store(true)
+
+	res := sendPush(t, addr, "", testPayload)
+	require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	require.Equal(t, "5", res.Header.Get("Retry-After"))
+
+	c.memoryPressure.Store(false)
+	res = sendPush(t, addr, "", testPayload)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func newTestComponent(t *testing.T, config string, ch chan loki.Entry) (*Component, string) {
+	t.Helper()
+
+	c, addr := buildTestComponent(t, config, ch)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go c.Run(ctx)
+	return c, addr
+}
+
+func buildTestComponent(t *testing.T, config string, ch chan loki.Entry) (*Component, string) {
+	t.Helper()
+
+	port := freePort(t)
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(config+`
+		http {
+			listen_address = "127.0.0.1"
+			listen_port    = `+port+`
+		}
+		forward_to = []
+	`), &args))
+	args.ForwardTo = []loki.LogsReceiver{ch}
+
+	c, err := New(component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.stopServer()
+	})
+
+	return c, c.DebugInfo().(debugInfo).Address
+}
+
+func sendPush(t *testing.T, addr, tenant, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+pushPath, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if tenant != "" {
+		req.Header.Set(tenantHeader, tenant)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	return res
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	_, port, err := net.SplitHostPort(lis.Addr().String())
+	require.NoError(t, err)
+	return port
+}
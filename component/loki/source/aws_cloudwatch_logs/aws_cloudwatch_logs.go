@@ -0,0 +1,523 @@
+// Package aws_cloudwatch_logs implements the loki.source.aws_cloudwatch_logs
+// component.
+package aws_cloudwatch_logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/positions"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.aws_cloudwatch_logs",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// loki.source.aws_cloudwatch_logs component.
+type Arguments struct {
+	Region    string            `river:"region,attr,optional"`
+	Endpoint  string            `river:"endpoint,attr,optional"`
+	AccessKey string            `river:"access_key,attr,optional"`
+	SecretKey rivertypes.Secret `river:"secret_key,attr,optional"`
+	Profile   string            `river:"profile,attr,optional"`
+	RoleARN   string            `river:"role_arn,attr,optional"`
+
+	LogGroupNames     []string          `river:"log_group_names,attr,optional"`
+	LogGroupPrefix    string            `river:"log_group_prefix,attr,optional"`
+	LogGroupTags      map[string]string `river:"log_group_tags,attr,optional"`
+	LogStreamPrefix   string            `river:"log_stream_prefix,attr,optional"`
+	FilterPattern     string            `river:"filter_pattern,attr,optional"`
+	DiscoveryInterval time.Duration     `river:"discovery_interval,attr,optional"`
+	PollFrequency     time.Duration     `river:"poll_frequency,attr,optional"`
+	InitialLookback   time.Duration     `river:"initial_lookback,attr,optional"`
+
+	Labels       map[string]string   `river:"labels,attr,optional"`
+	RelabelRules flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	ForwardTo    []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	DiscoveryInterval: 5 * time.Minute,
+	PollFrequency:     30 * time.Second,
+	InitialLookback:   1 * time.Hour,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case len(args.LogGroupNames) == 0 && args.LogGroupPrefix == "" && len(args.LogGroupTags) == 0:
+		return fmt.Errorf("at least one of log_group_names, log_group_prefix or log_group_tags must be set")
+	case args.AccessKey != "" && args.SecretKey == "":
+		return fmt.Errorf("secret_key must be set when access_key is set")
+	case args.DiscoveryInterval <= 0:
+		return fmt.Errorf("discovery_interval must be greater than 0")
+	case args.PollFrequency <= 0:
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	case args.InitialLookback <= 0:
+		return fmt.Errorf("initial_lookback must be greater than 0")
+	}
+	return nil
+}
+
+// discoversGroups returns true if log groups are discovered rather than only
+// read from log_group_names.
+func (args Arguments) discoversGroups() bool {
+	return args.LogGroupPrefix != "" || len(args.LogGroupTags) > 0
+}
+
+const (
+	labelLogGroup  = "__aws_cloudwatch_log_group"
+	labelLogStream = "__aws_cloudwatch_log_stream"
+)
+
+// Component implements the loki.source.aws_cloudwatch_logs component.
+type Component struct {
+	opts      component.Options
+	metrics   *metrics
+	positions positions.Positions
+	posKey    string
+
+	mut           sync.Mutex
+	args          Arguments
+	client        cloudwatchlogsiface.CloudWatchLogsAPI
+	relabelRules  []*relabel.Config
+	groups        []string // Log groups to read, sorted by name.
+	lastDiscovery time.Time
+	lastPoll      time.Time
+
+	// Updated is written to whenever args updates.
+	updated chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+)
+
+// New creates a new loki.source.aws_cloudwatch_logs component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	err := os.MkdirAll(o.DataPath, 0750)
+	if err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	positionsFile, err := positions.New(o.Logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: filepath.Join(o.DataPath, "positions.yml"),
+		DiskBudget:    o.DiskBudget,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:      o,
+		metrics:   newMetrics(o.Registerer),
+		positions: positionsFile,
+		posKey:    positions.CursorKey(o.ID),
+		updated:   make(chan struct{}, 1),
+
+		health: component.Health{
+			Health:     component.HealthTypeUnknown,
+			Message:    "component started",
+			UpdateTime: time.Now(),
+		},
+	}
+	if err := c.Update(args); err != nil {
+		positionsFile.Stop()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.positions.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.nextPoll()):
+			c.poll(ctx)
+		case <-c.updated:
+			// no-op; force the next wait to be reread.
+		}
+	}
+}
+
+// Update implements component.Component. Log groups are discovered again on
+// the next poll.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	client, err := newClient(newArgs)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	c.args = newArgs
+	c.client = client
+	c.relabelRules = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	c.lastDiscovery = time.Time{}
+	c.mut.Unlock()
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// newClient creates a CloudWatch Logs client from the credentials in args. If
+// a role is set, the credentials are used to assume it, and requests are made
+// with the temporary credentials of the role.
+func newClient(args Arguments) (cloudwatchlogsiface.CloudWatchLogsAPI, error) {
+	var creds *credentials.Credentials
+	if args.AccessKey != "" {
+		creds = credentials.NewStaticCredentials(args.AccessKey, string(args.SecretKey), "")
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Endpoint:    aws.String(args.Endpoint),
+			Region:      aws.String(args.Region),
+			Credentials: creds,
+		},
+		Profile: args.Profile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create AWS session: %w", err)
+	}
+
+	if args.RoleARN != "" {
+		return cloudwatchlogs.New(sess, &aws.Config{
+			Credentials: stscreds.NewCredentials(sess, args.RoleARN),
+		}), nil
+	}
+	return cloudwatchlogs.New(sess), nil
+}
+
+// nextPoll returns how long to wait to poll given the last time a
+// poll occurred. nextPoll returns 0 if a poll should occur immediately.
+func (c *Component) nextPoll() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	nextPoll := c.lastPoll.Add(c.args.PollFrequency)
+	now := time.Now()
+
+	if now.After(nextPoll) {
+		// Poll immediately; next poll period was in the past.
+		return 0
+	}
+	return nextPoll.Sub(now)
+}
+
+// poll discovers log groups if needed and reads new events from each of
+// them. After polling, the component's health is updated with the success or
+// failure status.
+func (c *Component) poll(ctx context.Context) {
+	c.mut.Lock()
+	c.lastPoll = time.Now()
+	var (
+		args         = c.args
+		client       = c.client
+		relabelRules = c.relabelRules
+		discover     = time.Since(c.lastDiscovery) >= args.DiscoveryInterval
+		groups       = c.groups
+	)
+	c.mut.Unlock()
+
+	startTime := time.Now()
+	var errs []string
+
+	if discover {
+		discovered, err := discoverGroups(ctx, client, args)
+		if err != nil {
+			level.Error(c.opts.Logger).Log("msg", "failed to discover log groups", "err", err)
+			errs = append(errs, fmt.Sprintf("discovering log groups: %s", err))
+		} else {
+			groups = discovered
+			c.metrics.logGroups.Set(float64(len(groups)))
+
+			c.mut.Lock()
+			c.groups = groups
+			c.lastDiscovery = startTime
+			c.mut.Unlock()
+		}
+	}
+
+	for _, group := range groups {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := c.readGroup(ctx, client, args, relabelRules, group); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "failed to read log group", "log_group", group, "err", err)
+			c.metrics.pollFailures.WithLabelValues(group).Inc()
+			errs = append(errs, fmt.Sprintf("reading log group %s: %s", group, err))
+		}
+	}
+
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	if len(errs) == 0 {
+		c.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    fmt.Sprintf("read %d log groups", len(groups)),
+			UpdateTime: startTime,
+		}
+	} else {
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    strings.Join(errs, "; "),
+			UpdateTime: startTime,
+		}
+	}
+}
+
+// discoverGroups returns the names of the log groups to read, sorted by name.
+func discoverGroups(ctx context.Context, client cloudwatchlogsiface.CloudWatchLogsAPI, args Arguments) ([]string, error) {
+	set := make(map[string]struct{}, len(args.LogGroupNames))
+	for _, name := range args.LogGroupNames {
+		set[name] = struct{}{}
+	}
+
+	if args.discoversGroups() {
+		input := &cloudwatchlogs.DescribeLogGroupsInput{}
+		if args.LogGroupPrefix != "" {
+			input.LogGroupNamePrefix = aws.String(args.LogGroupPrefix)
+		}
+
+		var candidates []*cloudwatchlogs.LogGroup
+		err := client.DescribeLogGroupsPagesWithContext(ctx, input, func(out *cloudwatchlogs.DescribeLogGroupsOutput, _ bool) bool {
+			candidates = append(candidates, out.LogGroups...)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range candidates {
+			if len(args.LogGroupTags) > 0 {
+				ok, err := hasTags(ctx, client, group, args.LogGroupTags)
+				if err != nil {
+					return nil, err
+				} else if !ok {
+					continue
+				}
+			}
+			set[aws.StringValue(group.LogGroupName)] = struct{}{}
+		}
+	}
+
+	groups := make([]string, 0, len(set))
+	for name := range set {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// hasTags returns true if group has all of the given tags.
+func hasTags(ctx context.Context, client cloudwatchlogsiface.CloudWatchLogsAPI, group *cloudwatchlogs.LogGroup, tags map[string]string) (bool, error) {
+	// The ARN of log groups ends with :* to match their log streams, which
+	// must be removed to refer to the log group itself.
+	arn := strings.TrimSuffix(aws.StringValue(group.Arn), ":*")
+
+	out, err := client.ListTagsForResourceWithContext(ctx, &cloudwatchlogs.ListTagsForResourceInput{
+		ResourceArn: aws.String(arn),
+	})
+	if err != nil {
+		return false, fmt.Errorf("listing tags of log group %s: %w", aws.StringValue(group.LogGroupName), err)
+	}
+
+	for k, v := range tags {
+		if aws.StringValue(out.Tags[k]) != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// readGroup reads the events of group following its cursor, saving the
+// cursor after each page of events has been sent.
+func (c *Component) readGroup(ctx context.Context, client cloudwatchlogsiface.CloudWatchLogsAPI, args Arguments, relabelRules []*relabel.Config, group string) error {
+	cursorLabels := args.Region + "/" + group
+
+	cur, err := parseCursor(c.positions.GetString(c.posKey, cursorLabels))
+	if err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "ignoring invalid cursor of log group", "log_group", group, "err", err)
+	}
+	if cur.Time == 0 {
+		cur.Time = time.Now().Add(-args.InitialLookback).UnixMilli()
+	}
+
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(group),
+		StartTime:    aws.Int64(cur.Time),
+	}
+	if args.LogStreamPrefix != "" {
+		input.LogStreamNamePrefix = aws.String(args.LogStreamPrefix)
+	}
+	if args.FilterPattern != "" {
+		input.FilterPattern = aws.String(args.FilterPattern)
+	}
+
+	static := make(model.LabelSet, len(args.Labels))
+	for k, v := range args.Labels {
+		static[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	for {
+		out, err := client.FilterLogEventsWithContext(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range out.Events {
+			// Events at the time of the cursor are read again since the start
+			// time is inclusive.
+			id := aws.StringValue(event.EventId)
+			if aws.Int64Value(event.Timestamp) == cur.Time && cur.hasID(id) {
+				continue
+			}
+
+			entry := loki.Entry{
+				Labels: entryLabels(static, relabelRules, group, aws.StringValue(event.LogStreamName)),
+				Entry: logproto.Entry{
+					Timestamp: time.UnixMilli(aws.Int64Value(event.Timestamp)),
+					Line:      aws.StringValue(event.Message),
+				},
+			}
+
+			for _, receiver := range args.ForwardTo {
+				select {
+				case <-ctx.Done():
+					return nil
+				case receiver <- entry:
+				}
+			}
+			c.metrics.entries.WithLabelValues(group).Inc()
+			cur.advance(aws.Int64Value(event.Timestamp), id)
+		}
+
+		// Only save the cursor once all events of the page have been sent.
+		c.positions.PutString(c.posKey, cursorLabels, cur.String())
+
+		if out.NextToken == nil || aws.StringValue(out.NextToken) == aws.StringValue(input.NextToken) {
+			return nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// entryLabels returns the labels of an event read from the given log group and
+// stream. Internal labels identifying the log group and stream are available
+// to relabelRules, and dropped afterwards.
+func entryLabels(static model.LabelSet, relabelRules []*relabel.Config, group, stream string) model.LabelSet {
+	if len(relabelRules) == 0 {
+		return static.Clone()
+	}
+
+	lbls := make(map[string]string, len(static)+2)
+	for k, v := range static {
+		lbls[string(k)] = string(v)
+	}
+	lbls[labelLogGroup] = group
+	lbls[labelLogStream] = stream
+
+	processed, _ := relabel.Process(labels.FromMap(lbls), relabelRules...)
+	res := make(model.LabelSet, len(processed))
+	for _, l := range processed {
+		if strings.HasPrefix(l.Name, "__") {
+			continue
+		}
+		res[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return res
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.Lock()
+	var (
+		groups = c.groups
+		region = c.args.Region
+	)
+	c.mut.Unlock()
+
+	var info debugInfo
+	for _, group := range groups {
+		gi := logGroupInfo{Name: group}
+		if cur, err := parseCursor(c.positions.GetString(c.posKey, region+"/"+group)); err == nil && cur.Time > 0 {
+			gi.LastEventTime = time.UnixMilli(cur.Time).UTC().Format(time.RFC3339)
+		}
+		info.LogGroups = append(info.LogGroups, gi)
+	}
+	return info
+}
+
+type debugInfo struct {
+	LogGroups []logGroupInfo `river:"log_group,block,optional"`
+}
+
+type logGroupInfo struct {
+	Name          string `river:"name,attr"`
+	LastEventTime string `river:"last_event_time,attr,optional"`
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
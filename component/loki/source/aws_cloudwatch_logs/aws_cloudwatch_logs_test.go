@@ -0,0 +1,193 @@
+package aws_cloudwatch_logs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCloudWatchLogs implements the subset of the CloudWatch Logs API used by
+// the component.
+type fakeCloudWatchLogs struct {
+	mut    sync.Mutex
+	groups map[string]fakeLogGroup
+}
+
+type fakeLogGroup struct {
+	tags   map[string]string
+	events []map[string]any
+}
+
+func (f *fakeCloudWatchLogs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var req map[string]any
+	body, _ := io.ReadAll(r.Body)
+	_ = json.Unmarshal(body, &req)
+
+	var resp any
+	switch r.Header.Get("X-Amz-Target") {
+	case "Logs_20140328.DescribeLogGroups":
+		var groups []map[string]any
+		for name := range f.groups {
+			groups = append(groups, map[string]any{
+				"logGroupName": name,
+				"arn":          "arn:aws:logs:us-east-1:123456789012:log-group:" + name + ":*",
+			})
+		}
+		resp = map[string]any{"logGroups": groups}
+
+	case "Logs_20140328.ListTagsForResource":
+		arn := req["resourceArn"].(string)
+		name := arn[len("arn:aws:logs:us-east-1:123456789012:log-group:"):]
+		resp = map[string]any{"tags": f.groups[name].tags}
+
+	case "Logs_20140328.FilterLogEvents":
+		// Return one event per page to exercise pagination.
+		var (
+			group = f.groups[req["logGroupName"].(string)]
+			start = int64(req["startTime"].(float64))
+			skip  = 0
+		)
+		if token, ok := req["nextToken"].(string); ok {
+			skip = int(token[0] - '0')
+		}
+
+		var events []map[string]any
+		for _, e := range group.events {
+			if int64(e["timestamp"].(int)) >= start {
+				events = append(events, e)
+			}
+		}
+		out := map[string]any{"events": events[skip:minInt(skip+1, len(events))]}
+		if skip+1 < len(events) {
+			out["nextToken"] = string(rune('0' + skip + 1))
+		}
+		resp = out
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestComponent(t *testing.T) {
+	now := time.Now().UnixMilli()
+	fake := &fakeCloudWatchLogs{groups: map[string]fakeLogGroup{
+		"/app/api": {
+			tags: map[string]string{"team": "a"},
+			events: []map[string]any{
+				{"eventId": "1", "timestamp": int(now - 2000), "logStreamName": "i-1", "message": "first"},
+				{"eventId": "2", "timestamp": int(now - 1000), "logStreamName": "i-2", "message": "second"},
+			},
+		},
+		"/app/worker": {
+			tags:   map[string]string{"team": "b"},
+			events: []map[string]any{{"eventId": "3", "timestamp": int(now - 1000), "logStreamName": "i-3", "message": "ignored"}},
+		},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		region           = "us-east-1"
+		endpoint         = "`+srv.URL+`"
+		access_key       = "AKID"
+		secret_key       = "SECRET"
+		log_group_prefix = "/app/"
+		log_group_tags   = { "team" = "a" }
+		poll_frequency   = "50ms"
+		labels           = { "job" = "cloudwatch" }
+		forward_to       = []
+	`), &args))
+	rule := flow_relabel.DefaultRelabelConfig
+	rule.SourceLabels = []string{"__aws_cloudwatch_log_group"}
+	rule.TargetLabel = "log_group"
+	args.RelabelRules = flow_relabel.Rules{&rule}
+
+	ch := make(loki.LogsReceiver)
+	args.ForwardTo = []loki.LogsReceiver{ch}
+
+	opts := component.Options{
+		ID:         "loki.source.aws_cloudwatch_logs.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		DataPath:   t.TempDir(),
+	}
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	for _, expect := range []string{"first", "second"} {
+		select {
+		case entry := <-ch:
+			require.Equal(t, expect, entry.Line)
+			require.Equal(t, model.LabelSet{"job": "cloudwatch", "log_group": "/app/api"}, entry.Labels)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", expect)
+		}
+	}
+
+	// Events which were already sent aren't sent again, but new events are.
+	fake.mut.Lock()
+	group := fake.groups["/app/api"]
+	group.events = append(group.events, map[string]any{"eventId": "4", "timestamp": int(now - 1000), "logStreamName": "i-2", "message": "third"})
+	fake.groups["/app/api"] = group
+	fake.mut.Unlock()
+
+	select {
+	case entry := <-ch:
+		require.Equal(t, "third", entry.Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for third")
+	}
+	select {
+	case entry := <-ch:
+		t.Fatalf("unexpected entry %q", entry.Line)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+	require.Equal(t, debugInfo{LogGroups: []logGroupInfo{{
+		Name:          "/app/api",
+		LastEventTime: time.UnixMilli(now - 1000).UTC().Format(time.RFC3339),
+	}}}, c.DebugInfo())
+}
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		region     = "us-east-1"
+		forward_to = []
+	`), &args)
+	require.EqualError(t, err, "at least one of log_group_names, log_group_prefix or log_group_tags must be set")
+}
@@ -0,0 +1,49 @@
+package aws_cloudwatch_logs
+
+import (
+	"encoding/json"
+)
+
+// cursor is the position of a log group: the timestamp in milliseconds of the
+// newest sent event, along with the IDs of the events sent with that
+// timestamp. Events are requested from the timestamp of the cursor onwards,
+// so events which were already sent are skipped by their ID.
+type cursor struct {
+	Time int64    `json:"time"`
+	IDs  []string `json:"ids,omitempty"`
+}
+
+// parseCursor parses a cursor saved in the positions file. An empty string
+// returns a zero cursor.
+func parseCursor(s string) (cursor, error) {
+	var cur cursor
+	if s == "" {
+		return cur, nil
+	}
+	err := json.Unmarshal([]byte(s), &cur)
+	return cur, err
+}
+
+func (c cursor) hasID(id string) bool {
+	for _, other := range c.IDs {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// advance moves the cursor to an event which has been sent.
+func (c *cursor) advance(ts int64, id string) {
+	switch {
+	case ts > c.Time:
+		c.Time, c.IDs = ts, []string{id}
+	case ts == c.Time:
+		c.IDs = append(c.IDs, id)
+	}
+}
+
+func (c cursor) String() string {
+	bb, _ := json.Marshal(c)
+	return string(bb)
+}
@@ -0,0 +1,31 @@
+package aws_cloudwatch_logs
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	entries      *prometheus.CounterVec
+	pollFailures *prometheus.CounterVec
+	logGroups    prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_aws_cloudwatch_logs_entries_total",
+			Help: "Total number of log events read from CloudWatch log groups.",
+		}, []string{"log_group"}),
+		pollFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_aws_cloudwatch_logs_poll_failures_total",
+			Help: "Total number of failed reads of CloudWatch log groups.",
+		}, []string{"log_group"}),
+		logGroups: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "loki_source_aws_cloudwatch_logs_log_groups",
+			Help: "Number of CloudWatch log groups being read.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.entries, m.pollFailures, m.logGroups)
+	}
+	return m
+}
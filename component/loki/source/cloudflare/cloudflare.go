@@ -26,6 +26,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -108,6 +110,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		PositionsFile:     filepath.Join(o.DataPath, "positions.yml"),
 		IgnoreInvalidYaml: false,
 		ReadOnly:          false,
+		DiskBudget:        o.DiskBudget,
 	})
 	if err != nil {
 		return nil, err
@@ -18,6 +18,8 @@ import (
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/discovery"
 	dt "github.com/grafana/agent/component/loki/source/docker/internal/dockertarget"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 )
@@ -30,6 +32,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -47,6 +51,22 @@ type Arguments struct {
 	ForwardTo    []loki.LogsReceiver `river:"forward_to,attr"`
 	Labels       map[string]string   `river:"labels,attr,optional"`
 	RelabelRules flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	LogFormat    logformat.Format    `river:"log_format,attr,optional"`
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// DefaultArguments holds default settings for loki.source.docker.
+var DefaultArguments = Arguments{
+	LogFormat: logformat.FormatRaw,
+}
+
+// UnmarshalRiver implements river.Unmarshaler and applies defaults.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(args))
 }
 
 var (
@@ -83,6 +103,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		PositionsFile:     filepath.Join(o.DataPath, "positions.yml"),
 		IgnoreInvalidYaml: false,
 		ReadOnly:          false,
+		DiskBudget:        o.DiskBudget,
 	})
 	if err != nil {
 		return nil, err
@@ -196,6 +217,7 @@ func (c *Component) Update(args component.Arguments) error {
 			labels.Merge(c.defaultLabels),
 			c.rcs,
 			c.manager.opts.client,
+			c.manager.opts.logFormat,
 		)
 		if err != nil {
 			return err
@@ -216,7 +238,7 @@ func (c *Component) Update(args component.Arguments) error {
 //
 // getTailerOptions must only be called when c.mut is held.
 func (c *Component) getManagerOptions(args Arguments) (*options, error) {
-	if reflect.DeepEqual(c.args.Host, args.Host) && c.lastOptions != nil {
+	if reflect.DeepEqual(c.args.Host, args.Host) && c.args.LogFormat == args.LogFormat && c.lastOptions != nil {
 		return c.lastOptions, nil
 	}
 
@@ -234,6 +256,7 @@ func (c *Component) getManagerOptions(args Arguments) (*options, error) {
 		client:    client,
 		handler:   loki.NewEntryHandler(c.handler, func() {}),
 		positions: c.posFile,
+		logFormat: args.LogFormat,
 	}, nil
 }
 
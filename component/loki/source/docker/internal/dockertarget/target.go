@@ -21,6 +21,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -45,6 +46,7 @@ type Target struct {
 	labels        model.LabelSet
 	relabelConfig []*relabel.Config
 	metrics       *Metrics
+	logFormat     logformat.Format
 
 	cancel  context.CancelFunc
 	client  client.APIClient
@@ -54,7 +56,7 @@ type Target struct {
 }
 
 // NewTarget starts a new target to read logs from a given container ID.
-func NewTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandler, position positions.Positions, containerID string, labels model.LabelSet, relabelConfig []*relabel.Config, client client.APIClient) (*Target, error) {
+func NewTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandler, position positions.Positions, containerID string, labels model.LabelSet, relabelConfig []*relabel.Config, client client.APIClient, logFormat logformat.Format) (*Target, error) {
 	pos, err := position.Get(positions.CursorKey(containerID), labels.String())
 	if err != nil {
 		return nil, err
@@ -73,6 +75,7 @@ func NewTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandler, p
 		labels:        labels,
 		relabelConfig: relabelConfig,
 		metrics:       metrics,
+		logFormat:     logFormat,
 
 		client:  client,
 		running: atomic.NewBool(false),
@@ -183,6 +186,7 @@ func (t *Target) process(r io.Reader, logStream string) {
 	}()
 
 	reader := bufio.NewReader(r)
+	decoder := logformat.NewDecoder(t.logFormat)
 	for {
 		line, err := readLine(reader)
 		if err != nil {
@@ -200,6 +204,13 @@ func (t *Target) process(r io.Reader, logStream string) {
 			continue
 		}
 
+		// Partial lines are buffered by the decoder until the rest of the line
+		// is read.
+		line, ok := decoder.Decode(line)
+		if !ok {
+			continue
+		}
+
 		// Add all labels from the config, relabel and filter them.
 		lb := labels.NewBuilder(nil)
 		for k, v := range t.labels {
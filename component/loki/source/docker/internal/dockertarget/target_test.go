@@ -20,6 +20,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/grafana/agent/component/loki/source/docker/internal/fake"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
@@ -71,6 +72,7 @@ func TestDockerTarget(t *testing.T) {
 		model.LabelSet{"job": "docker"},
 		[]*relabel.Config{},
 		client,
+		logformat.FormatAuto,
 	)
 	require.NoError(t, err)
 	tgt.StartIfNotRunning()
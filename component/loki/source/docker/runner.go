@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
 	dt "github.com/grafana/agent/component/loki/source/docker/internal/dockertarget"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/grafana/agent/pkg/runner"
 	"github.com/prometheus/common/model"
 )
@@ -51,6 +52,9 @@ type options struct {
 
 	// positions interface so tailers can save/restore offsets in log files.
 	positions positions.Positions
+
+	// logFormat is the format of log lines to decode.
+	logFormat logformat.Format
 }
 
 // tailerTask is the payload used to create tailers. It implements runner.Task.
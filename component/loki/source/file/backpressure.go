@@ -0,0 +1,54 @@
+package file
+
+import (
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/util/memwatch"
+)
+
+// pauseGate pauses tailers from reading lines while memory pressure is high.
+// Lines stay on disk while paused, so reading picks up where it left off once
+// the pressure is relieved.
+type pauseGate struct {
+	log log.Logger
+
+	mut     sync.Mutex
+	resumed chan struct{} // Closed while not paused.
+}
+
+func newPauseGate(l log.Logger) *pauseGate {
+	resumed := make(chan struct{})
+	close(resumed)
+	return &pauseGate{log: l, resumed: resumed}
+}
+
+// OnMemoryPressure implements a memwatch callback.
+func (g *pauseGate) OnMemoryPressure(l memwatch.Level) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	var paused bool
+	select {
+	case <-g.resumed:
+	default:
+		paused = true
+	}
+
+	switch {
+	case l >= memwatch.LevelHigh && !paused:
+		level.Warn(g.log).Log("msg", "pausing tailers due to memory pressure", "level", l)
+		g.resumed = make(chan struct{})
+	case l < memwatch.LevelHigh && paused:
+		level.Info(g.log).Log("msg", "resuming tailers after memory pressure was relieved")
+		close(g.resumed)
+	}
+}
+
+// Resumed returns a channel which is closed while tailers aren't paused.
+func (g *pauseGate) Resumed() <-chan struct{} {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	return g.resumed
+}
@@ -49,6 +49,11 @@ type decompressor struct {
 	path   string
 	labels string
 
+	// entryLabels are the labels of every entry read from the file, including
+	// the filename label. Entries are given a copy of them, as downstream
+	// components may modify the labels of an entry.
+	entryLabels model.LabelSet
+
 	posAndSizeMtx sync.Mutex
 	stopOnce      sync.Once
 
@@ -63,10 +68,11 @@ type decompressor struct {
 	size     int64
 }
 
-func newDecompressor(metrics *metrics, logger log.Logger, handler loki.EntryHandler, positions positions.Positions, path string, labels string, encodingFormat string) (*decompressor, error) {
+func newDecompressor(metrics *metrics, logger log.Logger, handler loki.EntryHandler, positions positions.Positions, path string, labels model.LabelSet, encodingFormat string) (*decompressor, error) {
 	logger = log.With(logger, "component", "decompressor")
 
-	pos, err := positions.Get(path, labels)
+	labelsStr := labels.String()
+	pos, err := positions.Get(path, labelsStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get positions: %w", err)
 	}
@@ -82,18 +88,19 @@ func newDecompressor(metrics *metrics, logger log.Logger, handler loki.EntryHand
 	}
 
 	decompressor := &decompressor{
-		metrics:   metrics,
-		logger:    logger,
-		handler:   loki.AddLabelsMiddleware(model.LabelSet{filenameLabel: model.LabelValue(path)}).Wrap(handler),
-		positions: positions,
-		path:      path,
-		labels:    labels,
-		running:   atomic.NewBool(false),
-		posquit:   make(chan struct{}),
-		posdone:   make(chan struct{}),
-		done:      make(chan struct{}),
-		position:  pos,
-		decoder:   decoder,
+		metrics:     metrics,
+		logger:      logger,
+		handler:     handler,
+		positions:   positions,
+		path:        path,
+		labels:      labelsStr,
+		entryLabels: labels.Merge(model.LabelSet{filenameLabel: model.LabelValue(path)}),
+		running:     atomic.NewBool(false),
+		posquit:     make(chan struct{}),
+		posdone:     make(chan struct{}),
+		done:        make(chan struct{}),
+		position:    pos,
+		decoder:     decoder,
 	}
 
 	go decompressor.readLines()
@@ -232,7 +239,7 @@ func (d *decompressor) readLines() {
 		d.metrics.readLines.WithLabelValues(d.path).Inc()
 
 		entries <- loki.Entry{
-			Labels: model.LabelSet{},
+			Labels: d.entryLabels.Clone(),
 			Entry: logproto.Entry{
 				Timestamp: time.Now(),
 				Line:      finalText,
@@ -272,7 +279,6 @@ func (d *decompressor) Stop() {
 		// Wait for readLines() to consume all the remaining messages and exit when the channel is closed
 		<-d.done
 		level.Info(d.logger).Log("msg", "stopped decompressor", "path", d.path)
-		d.handler.Stop()
 	})
 }
 
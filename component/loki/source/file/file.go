@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/util/memwatch"
 	"github.com/prometheus/common/model"
 )
 
@@ -25,6 +26,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -49,6 +52,7 @@ var (
 type Component struct {
 	opts    component.Options
 	metrics *metrics
+	gate    *pauseGate
 
 	updateMut sync.Mutex
 
@@ -72,6 +76,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		PositionsFile:     filepath.Join(o.DataPath, "positions.yml"),
 		IgnoreInvalidYaml: false,
 		ReadOnly:          false,
+		DiskBudget:        o.DiskBudget,
 	})
 	if err != nil {
 		return nil, err
@@ -80,6 +85,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	c := &Component{
 		opts:    o,
 		metrics: newMetrics(o.Registerer),
+		gate:    newPauseGate(o.Logger),
 
 		handler:   make(loki.LogsReceiver),
 		receivers: args.ForwardTo,
@@ -100,6 +106,9 @@ func New(o component.Options, args Arguments) (*Component, error) {
 // comes alive _after_ it's been passed to us and we never receive another
 // Update()? Or should it be a responsibility of the discovery component?
 func (c *Component) Run(ctx context.Context) error {
+	// Pause tailers while memory pressure is high.
+	defer memwatch.Default().Register(c.gate.OnMemoryPressure)()
+
 	defer func() {
 		level.Info(c.opts.Logger).Log("msg", "loki.source.file component shutting down, stopping readers and positions file")
 		c.mut.RLock()
@@ -172,6 +181,12 @@ func (c *Component) Update(args component.Arguments) error {
 		return nil
 	}
 
+	// Readers add the target labels to the entries they read themselves rather
+	// than through middleware, so they can all share a single handler. This
+	// avoids allocating a merged label set and passing each entry through an
+	// extra goroutine for every label middleware.
+	c.entryHandler = loki.NewEntryHandler(c.handler, func() {})
+
 	for _, target := range newArgs.Targets {
 		path := target[pathLabel]
 
@@ -190,7 +205,6 @@ func (c *Component) Update(args component.Arguments) error {
 		}
 
 		c.reportSize(path, labels.String())
-		c.entryHandler = loki.AddLabelsMiddleware(labels).Wrap(loki.NewEntryHandler(c.handler, func() {}))
 
 		reader, err := c.startTailing(path, labels, c.entryHandler)
 		if err != nil {
@@ -290,7 +304,7 @@ func (c *Component) startTailing(path string, labels model.LabelSet, handler lok
 			handler,
 			c.posFile,
 			path,
-			labels.String(),
+			labels,
 			"",
 		)
 		if err != nil {
@@ -305,8 +319,9 @@ func (c *Component) startTailing(path string, labels model.LabelSet, handler lok
 			c.opts.Logger,
 			handler,
 			c.posFile,
+			c.gate,
 			path,
-			labels.String(),
+			labels,
 			"",
 		)
 		if err != nil {
@@ -28,10 +28,17 @@ type tailer struct {
 	logger    log.Logger
 	handler   loki.EntryHandler
 	positions positions.Positions
+	gate      *pauseGate
 
 	path   string
 	labels string
-	tail   *tail.Tail
+
+	// entryLabels are the labels of every entry read from the file, including
+	// the filename label. Entries are given a copy of them, as downstream
+	// components may modify the labels of an entry.
+	entryLabels model.LabelSet
+
+	tail *tail.Tail
 
 	posAndSizeMtx sync.Mutex
 	stopOnce      sync.Once
@@ -44,20 +51,21 @@ type tailer struct {
 	decoder *encoding.Decoder
 }
 
-func newTailer(metrics *metrics, logger log.Logger, handler loki.EntryHandler, positions positions.Positions, path string, labels string, encoding string) (*tailer, error) {
+func newTailer(metrics *metrics, logger log.Logger, handler loki.EntryHandler, positions positions.Positions, gate *pauseGate, path string, labels model.LabelSet, encoding string) (*tailer, error) {
 	// Simple check to make sure the file we are tailing doesn't
 	// have a position already saved which is past the end of the file.
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
-	pos, err := positions.Get(path, labels)
+	labelsStr := labels.String()
+	pos, err := positions.Get(path, labelsStr)
 	if err != nil {
 		return nil, err
 	}
 
 	if fi.Size() < pos {
-		positions.Remove(path, labels)
+		positions.Remove(path, labelsStr)
 	}
 
 	tail, err := tail.TailFile(path, tail.Config{
@@ -77,17 +85,19 @@ func newTailer(metrics *metrics, logger log.Logger, handler loki.EntryHandler, p
 
 	logger = log.With(logger, "component", "tailer")
 	tailer := &tailer{
-		metrics:   metrics,
-		logger:    logger,
-		handler:   loki.AddLabelsMiddleware(model.LabelSet{filenameLabel: model.LabelValue(path)}).Wrap(handler),
-		positions: positions,
-		path:      path,
-		labels:    labels,
-		tail:      tail,
-		running:   atomic.NewBool(false),
-		posquit:   make(chan struct{}),
-		posdone:   make(chan struct{}),
-		done:      make(chan struct{}),
+		metrics:     metrics,
+		logger:      logger,
+		handler:     handler,
+		positions:   positions,
+		gate:        gate,
+		path:        path,
+		labels:      labelsStr,
+		entryLabels: labels.Merge(model.LabelSet{filenameLabel: model.LabelValue(path)}),
+		tail:        tail,
+		running:     atomic.NewBool(false),
+		posquit:     make(chan struct{}),
+		posdone:     make(chan struct{}),
+		done:        make(chan struct{}),
 	}
 
 	if encoding != "" {
@@ -159,6 +169,14 @@ func (t *tailer) readLines() {
 	}()
 	entries := t.handler.Chan()
 	for {
+		// Wait while tailers are paused. Stopping the tailer must not wait for
+		// the pause to end, as lines have to be drained for the underlying
+		// tailer to exit.
+		select {
+		case <-t.gate.Resumed():
+		case <-t.posquit:
+		}
+
 		line, ok := <-t.tail.Lines
 		if !ok {
 			level.Info(t.logger).Log("msg", "tail routine: tail channel closed, stopping tailer", "path", t.path, "reason", t.tail.Tomb.Err())
@@ -186,7 +204,7 @@ func (t *tailer) readLines() {
 
 		t.metrics.readLines.WithLabelValues(t.path).Inc()
 		entries <- loki.Entry{
-			Labels: model.LabelSet{},
+			Labels: t.entryLabels.Clone(),
 			Entry: logproto.Entry{
 				Timestamp: line.Time,
 				Line:      text,
@@ -243,7 +261,6 @@ func (t *tailer) Stop() {
 		// Wait for readLines() to consume all the remaining messages and exit when the channel is closed
 		<-t.done
 		level.Info(t.logger).Log("msg", "stopped tailing file", "path", t.path)
-		t.handler.Stop()
 	})
 }
 
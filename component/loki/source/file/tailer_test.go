@@ -0,0 +1,146 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/positions"
+	"github.com/grafana/agent/pkg/util/memwatch"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailer_EntryLabels(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\nsecond\n"), 0644))
+
+	ps := newTestPositions(t, dir)
+	ch := make(chan loki.Entry)
+	labels := model.LabelSet{"job": "app", filenameLabel: "overridden"}
+
+	tailer, err := newTailer(newMetrics(prometheus.NewRegistry()), log.NewNopLogger(), loki.NewEntryHandler(ch, func() {}), ps, newPauseGate(log.NewNopLogger()), path, labels, "")
+	require.NoError(t, err)
+	defer func() {
+		// Stop waits for entries to be read, so drain the channel until the
+		// tailer has stopped.
+		go func() {
+			for range ch {
+			}
+		}()
+		tailer.Stop()
+		close(ch)
+	}()
+
+	expect := model.LabelSet{"job": "app", filenameLabel: model.LabelValue(path)}
+	var entries []loki.Entry
+	for len(entries) < 2 {
+		select {
+		case e := <-ch:
+			require.Equal(t, expect, e.Labels)
+			entries = append(entries, e)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for entries")
+		}
+	}
+
+	// Entries must not share label sets, as downstream components may modify
+	// them.
+	entries[0].Labels["job"] = "modified"
+	require.Equal(t, model.LabelValue("app"), entries[1].Labels["job"])
+}
+
+func TestTailer_Pause(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0644))
+
+	gate := newPauseGate(log.NewNopLogger())
+	gate.OnMemoryPressure(memwatch.LevelHigh)
+
+	ps := newTestPositions(t, dir)
+	ch := make(chan loki.Entry)
+	tailer, err := newTailer(newMetrics(prometheus.NewRegistry()), log.NewNopLogger(), loki.NewEntryHandler(ch, func() {}), ps, gate, path, model.LabelSet{"job": "app"}, "")
+	require.NoError(t, err)
+
+	select {
+	case <-ch:
+		require.FailNow(t, "unexpected entry while paused")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	gate.OnMemoryPressure(memwatch.LevelNormal)
+	select {
+	case e := <-ch:
+		require.Equal(t, "first", e.Line)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for entry after resuming")
+	}
+
+	// Stopping a paused tailer must not wait for it to resume.
+	gate.OnMemoryPressure(memwatch.LevelCritical)
+	go func() {
+		for range ch {
+		}
+	}()
+	tailer.Stop()
+	close(ch)
+}
+
+func BenchmarkTailer(b *testing.B) {
+	const numLines = 100000
+
+	dir := b.TempDir()
+	path := filepath.Join(dir, "app.log")
+	line := `level=info ts=2023-04-01T12:00:00.000Z caller=main.go:42 msg="handled request" method=GET path=/api/v1/query status=200 duration=1.2ms` + "\n"
+	require.NoError(b, os.WriteFile(path, []byte(strings.Repeat(line, numLines)), 0644))
+
+	ps := newTestPositions(b, dir)
+	labels := model.LabelSet{"job": "app", "namespace": "default", "pod": "app-0"}
+
+	ch := make(chan loki.Entry)
+	handler := loki.NewEntryHandler(ch, func() {})
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(line) * numLines))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ps.Remove(path, labels.String())
+
+		tailer, err := newTailer(newMetrics(prometheus.NewRegistry()), log.NewNopLogger(), handler, ps, newPauseGate(log.NewNopLogger()), path, labels, "")
+		require.NoError(b, err)
+		for n := 0; n < numLines; n++ {
+			<-ch
+		}
+
+		b.StopTimer()
+		go func(ch chan loki.Entry) {
+			for range ch {
+			}
+		}(ch)
+		tailer.Stop()
+		close(ch)
+		ch = make(chan loki.Entry)
+		handler = loki.NewEntryHandler(ch, func() {})
+		b.StartTimer()
+	}
+}
+
+func newTestPositions(t testing.TB, dir string) positions.Positions {
+	t.Helper()
+
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: filepath.Join(dir, "positions.yml"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(ps.Stop)
+	return ps
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"sync"
 
+	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/loki/source/gelf/internal/target"
+	"github.com/grafana/agent/pkg/util/netwatch"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
@@ -21,6 +23,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -29,22 +33,58 @@ var _ component.Component = (*Component)(nil)
 // Component is a receiver for graylog formatted log files.
 type Component struct {
 	mut       sync.RWMutex
-	target    *target.Target
 	o         component.Options
 	metrics   *target.Metrics
 	handler   *handler
 	receivers []loki.LogsReceiver
+
+	// targetMut is separate from mut so that entries can still be forwarded
+	// while the target is being stopped, which waits for its pending entries
+	// to be forwarded.
+	targetMut sync.Mutex
+	args      Arguments
+	target    *target.Target
+	netState  netwatch.State // State of the listen address when the target was started.
 }
 
 // Run starts the component.
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
-		c.target.Stop()
+		c.targetMut.Lock()
+		defer c.targetMut.Unlock()
+		if c.target != nil {
+			c.target.Stop()
+		}
 	}()
+
+	netChanges, unsubscribe := netwatch.Default().Subscribe()
+	defer unsubscribe()
+
+	// restartDone is non-nil while the target is being restarted after a
+	// network change. Restarts happen in the background since stopping the
+	// target waits for its pending entries to be read from c.handler.
+	var restartDone chan struct{}
+
 	for {
+		pendingNetChanges := netChanges
+		if restartDone != nil {
+			pendingNetChanges = nil // Don't start another restart until this one is done.
+		}
+
 		select {
 		case <-ctx.Done():
+			if restartDone != nil {
+				drainUntil(c.handler.c, restartDone)
+			}
 			return nil
+		case <-pendingNetChanges:
+			restartDone = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				c.restartOnNetworkChange()
+			}(restartDone)
+		case <-restartDone:
+			restartDone = nil
 		case entry := <-c.handler.c:
 			c.mut.RLock()
 			lokiEntry := loki.Entry{
@@ -67,19 +107,34 @@ func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
 
 	c.mut.Lock()
-	defer c.mut.Unlock()
+	c.receivers = newArgs.Receivers
+	c.mut.Unlock()
+
+	c.targetMut.Lock()
+	defer c.targetMut.Unlock()
+
+	if err := c.startTarget(newArgs); err != nil {
+		return err
+	}
+	c.args = newArgs
+	return nil
+}
 
+// startTarget stops the running target, if any, and starts a new target for
+// args. targetMut must be held when calling.
+func (c *Component) startTarget(args Arguments) error {
 	if c.target != nil {
 		c.target.Stop()
+		c.target = nil
 	}
-	c.receivers = newArgs.Receivers
 
 	var rcs []*relabel.Config
-	if newArgs.RelabelRules != nil && len(newArgs.RelabelRules) > 0 {
-		rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	if args.RelabelRules != nil && len(args.RelabelRules) > 0 {
+		rcs = flow_relabel.ComponentToPromRelabelConfigs(args.RelabelRules)
 	}
 
-	t, err := target.NewTarget(c.metrics, c.o.Logger, c.handler, rcs, convertConfig(newArgs))
+	c.netState = netwatch.Default().State([]string{args.ListenAddress})
+	t, err := target.NewTarget(c.metrics, c.o.Logger, c.handler, rcs, convertConfig(args))
 	if err != nil {
 		return err
 	}
@@ -87,6 +142,33 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// restartOnNetworkChange restarts the target if it listens on a specific
+// address which was added to or removed from the host since it was started.
+func (c *Component) restartOnNetworkChange() {
+	c.targetMut.Lock()
+	defer c.targetMut.Unlock()
+
+	if netwatch.Default().State([]string{c.args.ListenAddress}) == c.netState {
+		return
+	}
+
+	level.Info(c.o.Logger).Log("msg", "listen address changed, restarting GELF listener")
+	if err := c.startTarget(c.args); err != nil {
+		level.Error(c.o.Logger).Log("msg", "failed to restart GELF listener", "err", err)
+	}
+}
+
+// drainUntil discards entries from ch until done is closed.
+func drainUntil(ch chan loki.Entry, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-ch:
+		}
+	}
+}
+
 // Arguments are the arguments for the component.
 type Arguments struct {
 	// ListenAddress only supports UDP.
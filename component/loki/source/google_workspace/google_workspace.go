@@ -0,0 +1,276 @@
+// Package google_workspace implements the loki.source.google_workspace
+// component.
+package google_workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/source/internal/auditlog"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"golang.org/x/oauth2/google"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.google_workspace",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// reportsScope is the OAuth scope required to read audit activities.
+const reportsScope = "https://www.googleapis.com/auth/admin.reports.audit.readonly"
+
+// Arguments holds values which are used to configure the
+// loki.source.google_workspace component.
+type Arguments struct {
+	Credentials     rivertypes.Secret   `river:"credentials,attr"`
+	Subject         string              `river:"subject,attr"`
+	Application     string              `river:"application,attr,optional"`
+	CustomerID      string              `river:"customer_id,attr,optional"`
+	URL             string              `river:"url,attr,optional"`
+	InitialLookback time.Duration       `river:"initial_lookback,attr,optional"`
+	PollFrequency   time.Duration       `river:"poll_frequency,attr,optional"`
+	Labels          map[string]string   `river:"labels,attr,optional"`
+	ForwardTo       []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Application:     "admin",
+	URL:             "https://admin.googleapis.com",
+	InitialLookback: 1 * time.Hour,
+	PollFrequency:   1 * time.Minute,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Subject == "":
+		return fmt.Errorf("subject must not be empty")
+	case args.Application == "":
+		return fmt.Errorf("application must not be empty")
+	case args.InitialLookback <= 0:
+		return fmt.Errorf("initial_lookback must be greater than 0")
+	case args.PollFrequency <= 0:
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	if _, err := google.JWTConfigFromJSON([]byte(args.Credentials), reportsScope); err != nil {
+		return fmt.Errorf("invalid credentials: %w", err)
+	}
+	return nil
+}
+
+// Component implements the loki.source.google_workspace component.
+type Component struct {
+	*auditlog.Component
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new loki.source.google_workspace component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	inner, err := auditlog.New(o, "loki_source_google_workspace")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{Component: inner}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	// The service account impersonates subject, which must be an admin of the
+	// Google Workspace account. Tokens are refreshed by the client as they
+	// expire.
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(newArgs.Credentials), reportsScope)
+	if err != nil {
+		return fmt.Errorf("invalid credentials: %w", err)
+	}
+	jwtConfig.Subject = newArgs.Subject
+
+	labels := make(model.LabelSet, len(newArgs.Labels))
+	for k, v := range newArgs.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	c.Component.Update(auditlog.Arguments{
+		Fetcher: &fetcher{
+			args: newArgs,
+			cli:  jwtConfig.Client(context.Background()),
+		},
+		PollFrequency: newArgs.PollFrequency,
+		Labels:        labels,
+		ForwardTo:     newArgs.ForwardTo,
+	})
+	return nil
+}
+
+// fetcher fetches activities from the Google Workspace Admin SDK Reports API.
+//
+// The API returns activities from newest to oldest, so every poll fetches all
+// pages of activities since the cursor before sending them in order.
+type fetcher struct {
+	args Arguments
+	cli  *http.Client
+}
+
+var _ auditlog.Fetcher = (*fetcher)(nil)
+
+// cursor is the time of the newest sent activity, along with the unique
+// qualifiers of the activities sent at that time. The API includes
+// activities at the start time, so they're skipped if already sent.
+type cursor struct {
+	Time time.Time `json:"time"`
+	IDs  []string  `json:"ids,omitempty"`
+}
+
+// activity holds the fields of an activity used by the fetcher.
+type activity struct {
+	ID struct {
+		Time            time.Time `json:"time"`
+		UniqueQualifier string    `json:"uniqueQualifier"`
+	} `json:"id"`
+}
+
+func (f *fetcher) Source() string {
+	return f.args.URL + "/" + f.args.Application + "?customer=" + f.args.CustomerID
+}
+
+func (f *fetcher) Fetch(ctx context.Context, rawCursor string) (auditlog.Page, error) {
+	var cur cursor
+	if rawCursor != "" {
+		if err := json.Unmarshal([]byte(rawCursor), &cur); err != nil {
+			return auditlog.Page{}, fmt.Errorf("decoding cursor: %w", err)
+		}
+	} else {
+		cur.Time = time.Now().Add(-f.args.InitialLookback).UTC()
+	}
+
+	sent := make(map[string]struct{}, len(cur.IDs))
+	for _, id := range cur.IDs {
+		sent[id] = struct{}{}
+	}
+
+	var (
+		records   []auditlog.Record
+		pageToken string
+		next      = cur
+	)
+	for {
+		items, nextPageToken, err := f.fetchPage(ctx, cur.Time, pageToken)
+		if err != nil {
+			return auditlog.Page{}, err
+		}
+
+		for _, item := range items {
+			var a activity
+			if err := json.Unmarshal(item, &a); err != nil {
+				return auditlog.Page{}, fmt.Errorf("decoding activity: %w", err)
+			}
+			if a.ID.Time.Before(cur.Time) {
+				continue
+			}
+			if _, ok := sent[a.ID.UniqueQualifier]; ok && a.ID.Time.Equal(cur.Time) {
+				continue
+			}
+
+			records = append(records, auditlog.Record{
+				Line:      string(item),
+				Timestamp: a.ID.Time,
+			})
+
+			switch {
+			case a.ID.Time.After(next.Time):
+				next = cursor{Time: a.ID.Time, IDs: []string{a.ID.UniqueQualifier}}
+			case a.ID.Time.Equal(next.Time):
+				next.IDs = append(next.IDs, a.ID.UniqueQualifier)
+			}
+		}
+
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	bb, err := json.Marshal(next)
+	if err != nil {
+		return auditlog.Page{}, fmt.Errorf("encoding cursor: %w", err)
+	}
+	return auditlog.Page{Records: records, Cursor: string(bb)}, nil
+}
+
+// fetchPage fetches a single page of activities since startTime.
+func (f *fetcher) fetchPage(ctx context.Context, startTime time.Time, pageToken string) ([]json.RawMessage, string, error) {
+	u, err := url.Parse(f.args.URL)
+	if err != nil {
+		return nil, "", err
+	}
+	u = u.JoinPath("/admin/reports/v1/activity/users/all/applications", f.args.Application)
+
+	q := u.Query()
+	q.Set("startTime", startTime.Format(time.RFC3339Nano))
+	q.Set("maxResults", "1000")
+	if pageToken != "" {
+		q.Set("pageToken", pageToken)
+	}
+	if f.args.CustomerID != "" {
+		q.Set("customerId", f.args.CustomerID)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("building request: %w", err)
+	}
+
+	body, _, err := auditlog.Do(f.cli, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var resp struct {
+		Items         []json.RawMessage `json:"items"`
+		NextPageToken string            `json:"nextPageToken"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", fmt.Errorf("decoding activities: %w", err)
+	}
+	return resp.Items, resp.NextPageToken, nil
+}
@@ -0,0 +1,98 @@
+package google_workspace
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/google"
+)
+
+func TestFetcher(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/admin/reports/v1/activity/users/all/applications/login", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		// Activities are returned from newest to oldest.
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			_, _ = w.Write([]byte(`{
+				"items": [
+					{"id": {"time": "2023-04-01T10:02:00.000Z", "uniqueQualifier": "3"}},
+					{"id": {"time": "2023-04-01T10:02:00.000Z", "uniqueQualifier": "2"}}
+				],
+				"nextPageToken": "page-2"
+			}`))
+		case "page-2":
+			_, _ = w.Write([]byte(`{
+				"items": [
+					{"id": {"time": "2023-04-01T10:00:00.000Z", "uniqueQualifier": "1"}}
+				]
+			}`))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		credentials = `+riverString(t, testCredentials(t, srv.URL+"/token"))+`
+		subject     = "admin@example.com"
+		application = "login"
+		url         = "`+srv.URL+`"
+		forward_to  = []
+	`), &args))
+
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(args.Credentials), reportsScope)
+	require.NoError(t, err)
+	f := &fetcher{args: args, cli: jwtConfig.Client(context.Background())}
+
+	page, err := f.Fetch(context.Background(), `{"time": "2023-04-01T10:00:00Z", "ids": ["1"]}`)
+	require.NoError(t, err)
+
+	// Activities are sent from oldest to newest, skipping already sent
+	// activities.
+	require.Len(t, page.Records, 2)
+	require.Equal(t, time.Date(2023, 4, 1, 10, 2, 0, 0, time.UTC), page.Records[0].Timestamp)
+	require.JSONEq(t, `{"id": {"time": "2023-04-01T10:02:00.000Z", "uniqueQualifier": "3"}}`, page.Records[0].Line)
+	require.JSONEq(t, `{"time": "2023-04-01T10:02:00Z", "ids": ["3", "2"]}`, page.Cursor)
+	require.False(t, page.More)
+}
+
+// testCredentials returns the credentials of a service account which
+// requests tokens from tokenURL.
+func testCredentials(t *testing.T, tokenURL string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	bb, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "agent@example.iam.gserviceaccount.com",
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      tokenURL,
+	})
+	require.NoError(t, err)
+	return string(bb)
+}
+
+func riverString(t *testing.T, s string) string {
+	bb, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(bb)
+}
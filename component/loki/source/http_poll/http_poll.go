@@ -0,0 +1,456 @@
+// Package http_poll implements the loki.source.http_poll component.
+package http_poll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/jmespath/go-jmespath"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.http_poll",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// loki.source.http_poll component.
+type Arguments struct {
+	URL           string              `river:"url,attr"`
+	ForwardTo     []loki.LogsReceiver `river:"forward_to,attr"`
+	PollFrequency time.Duration       `river:"poll_frequency,attr,optional"`
+	PollTimeout   time.Duration       `river:"poll_timeout,attr,optional"`
+	Records       string              `river:"records,attr,optional"`
+	Key           string              `river:"key,attr,optional"`
+	Timestamp     string              `river:"timestamp,attr,optional"`
+	MaxKeys       int                 `river:"max_keys,attr,optional"`
+	Labels        map[string]string   `river:"labels,attr,optional"`
+
+	Client config.HTTPClientConfig `river:"client,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	PollFrequency: 1 * time.Minute,
+	PollTimeout:   10 * time.Second,
+	Records:       "@",
+	MaxKeys:       10000,
+	Client:        config.DefaultHTTPClientConfig,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.PollFrequency <= 0:
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	case args.PollTimeout <= 0:
+		return fmt.Errorf("poll_timeout must be greater than 0")
+	case args.PollTimeout >= args.PollFrequency:
+		return fmt.Errorf("poll_timeout must be less than poll_frequency")
+	case args.MaxKeys <= 0:
+		return fmt.Errorf("max_keys must be greater than 0")
+	}
+
+	if _, err := args.compile(); err != nil {
+		return err
+	}
+	return args.Client.Validate()
+}
+
+// expressions are the compiled JMESPath expressions of Arguments.
+type expressions struct {
+	records   *jmespath.JMESPath
+	key       *jmespath.JMESPath // nil if records are deduplicated by content.
+	timestamp *jmespath.JMESPath // nil if entries are timestamped when read.
+}
+
+func (args *Arguments) compile() (expressions, error) {
+	var (
+		exprs expressions
+		err   error
+	)
+
+	if exprs.records, err = jmespath.Compile(args.Records); err != nil {
+		return exprs, fmt.Errorf("invalid records expression %q: %w", args.Records, err)
+	}
+	if args.Key != "" {
+		if exprs.key, err = jmespath.Compile(args.Key); err != nil {
+			return exprs, fmt.Errorf("invalid key expression %q: %w", args.Key, err)
+		}
+	}
+	if args.Timestamp != "" {
+		if exprs.timestamp, err = jmespath.Compile(args.Timestamp); err != nil {
+			return exprs, fmt.Errorf("invalid timestamp expression %q: %w", args.Timestamp, err)
+		}
+	}
+	return exprs, nil
+}
+
+// Component implements the loki.source.http_poll component.
+type Component struct {
+	log     log.Logger
+	opts    component.Options
+	metrics *metrics
+
+	mut      sync.Mutex
+	args     Arguments
+	exprs    expressions
+	labels   model.LabelSet
+	cli      *http.Client
+	lastPoll time.Time
+	seen     *seenKeys
+
+	// Updated is written to whenever args updates.
+	updated chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new loki.source.http_poll component. Keys of records seen by
+// a previous run of the component are loaded so that records aren't sent
+// again after a restart.
+func New(o component.Options, args Arguments) (*Component, error) {
+	seen, err := loadSeenKeys(o.DataPath, args.MaxKeys)
+	if err != nil {
+		level.Warn(o.Logger).Log("msg", "could not load keys of previously seen records", "err", err)
+		seen = newSeenKeys(args.MaxKeys)
+	}
+
+	c := &Component{
+		log:     o.Logger,
+		opts:    o,
+		metrics: newMetrics(o.Registerer),
+		seen:    seen,
+		updated: make(chan struct{}, 1),
+
+		health: component.Health{
+			Health:     component.HealthTypeUnknown,
+			Message:    "component started",
+			UpdateTime: time.Now(),
+		},
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.nextPoll()):
+			c.poll(ctx)
+		case <-c.updated:
+			// no-op; force the next wait to be reread.
+		}
+	}
+}
+
+// nextPoll returns how long to wait to poll given the last time a
+// poll occurred. nextPoll returns 0 if a poll should occur immediately.
+func (c *Component) nextPoll() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	nextPoll := c.lastPoll.Add(c.args.PollFrequency)
+	now := time.Now()
+
+	if now.After(nextPoll) {
+		// Poll immediately; next poll period was in the past.
+		return 0
+	}
+	return nextPoll.Sub(now)
+}
+
+// poll fetches the configured URL and sends new records to the receivers.
+// After polling, the component's health is updated with the success or
+// failure status.
+func (c *Component) poll(ctx context.Context) {
+	startTime := time.Now()
+	err := c.pollError(ctx)
+
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	if err == nil {
+		c.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "polled endpoint",
+			UpdateTime: startTime,
+		}
+	} else {
+		level.Error(c.log).Log("msg", "failed to poll endpoint", "err", err)
+		c.metrics.pollFailures.Inc()
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("polling failed: %s", err),
+			UpdateTime: startTime,
+		}
+	}
+}
+
+// pollError is like poll but returns an error if one occurred.
+func (c *Component) pollError(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.lastPoll = time.Now()
+
+	body, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	records, err := c.exprs.records.Search(doc)
+	if err != nil {
+		return fmt.Errorf("selecting records: %w", err)
+	}
+
+	var newKeys bool
+	for _, record := range recordList(records) {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encoding record: %w", err)
+		}
+
+		key, err := c.recordKey(record, line)
+		if err != nil {
+			return err
+		}
+		if !c.seen.Add(key) {
+			continue
+		}
+		newKeys = true
+
+		entry := loki.Entry{
+			Labels: c.labels.Clone(),
+			Entry: logproto.Entry{
+				Timestamp: c.recordTimestamp(record),
+				Line:      string(line),
+			},
+		}
+		for _, receiver := range c.args.ForwardTo {
+			select {
+			case <-ctx.Done():
+				return nil
+			case receiver <- entry:
+			}
+		}
+		c.metrics.entries.Inc()
+	}
+
+	if newKeys {
+		if err := c.seen.Save(c.opts.DataPath); err != nil {
+			level.Warn(c.log).Log("msg", "could not save keys of seen records", "err", err)
+		}
+	}
+	return nil
+}
+
+func (c *Component) fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.args.PollTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.args.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return bb, nil
+}
+
+// recordList returns the records selected by the records expression. A
+// selection which isn't a list is treated as a single record.
+func recordList(records interface{}) []interface{} {
+	switch records := records.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return records
+	default:
+		return []interface{}{records}
+	}
+}
+
+// recordKey returns the key used to deduplicate record. Records are
+// deduplicated by their content if no key expression is configured.
+func (c *Component) recordKey(record interface{}, line []byte) (string, error) {
+	if c.exprs.key == nil {
+		return string(line), nil
+	}
+
+	key, err := c.exprs.key.Search(record)
+	if err != nil {
+		return "", fmt.Errorf("evaluating key expression: %w", err)
+	}
+	switch key := key.(type) {
+	case nil:
+		return "", fmt.Errorf("key expression %q did not match record %s", c.args.Key, line)
+	case string:
+		return key, nil
+	default:
+		bb, err := json.Marshal(key)
+		return string(bb), err
+	}
+}
+
+// recordTimestamp returns the timestamp of record. RFC3339 strings and
+// numbers of seconds since the Unix epoch are supported. The current time is
+// returned if no timestamp expression is configured or the timestamp can't be
+// parsed.
+func (c *Component) recordTimestamp(record interface{}) time.Time {
+	now := time.Now()
+	if c.exprs.timestamp == nil {
+		return now
+	}
+
+	ts, err := c.exprs.timestamp.Search(record)
+	if err != nil {
+		level.Debug(c.log).Log("msg", "could not evaluate timestamp expression", "err", err)
+		return now
+	}
+
+	switch ts := ts.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return t
+		}
+	case float64:
+		sec := int64(ts)
+		return time.Unix(sec, int64((ts-float64(sec))*float64(time.Second)))
+	}
+
+	level.Debug(c.log).Log("msg", "could not parse record timestamp", "timestamp", fmt.Sprint(ts))
+	return now
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+
+	exprs, err := newArgs.compile()
+	if err != nil {
+		return err
+	}
+
+	cli, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		c.opts.ID,
+		prom_config.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return err
+	}
+
+	labels := make(model.LabelSet, len(newArgs.Labels))
+	for k, v := range newArgs.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	c.args = newArgs
+	c.exprs = exprs
+	c.cli = cli
+	c.labels = labels
+	c.seen.SetMax(newArgs.MaxKeys)
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+type metrics struct {
+	entries      prometheus.Counter
+	pollFailures prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		entries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_http_poll_entries_total",
+			Help: "Total number of new records sent as log entries.",
+		}),
+		pollFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_http_poll_failures_total",
+			Help: "Total number of failed polls of the endpoint.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.entries, m.pollFailures)
+	}
+	return m
+}
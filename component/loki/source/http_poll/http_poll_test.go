@@ -0,0 +1,134 @@
+package http_poll
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponent(t *testing.T) {
+	var (
+		mut  sync.Mutex
+		body string
+	)
+	setBody := func(b string) {
+		mut.Lock()
+		defer mut.Unlock()
+		body = b
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	setBody(`{"events": [
+		{"id": 1, "time": "2023-04-01T10:00:00Z", "action": "login"},
+		{"id": 2, "time": "2023-04-01T10:01:00Z", "action": "logout"}
+	]}`)
+
+	receiver := make(loki.LogsReceiver)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		url            = "`+srv.URL+`"
+		forward_to     = []
+		poll_frequency = "50ms"
+		poll_timeout   = "25ms"
+		records        = "events"
+		key            = "id"
+		timestamp      = "time"
+		labels         = { "job" = "audit" }
+	`), &args))
+	args.ForwardTo = []loki.LogsReceiver{receiver}
+
+	dataPath := t.TempDir()
+	opts := component.Options{
+		ID:         "loki.source.http_poll.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		DataPath:   dataPath,
+	}
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	expectEntry := func(line string, ts time.Time) {
+		t.Helper()
+		select {
+		case entry := <-receiver:
+			require.Equal(t, line, entry.Line)
+			require.Equal(t, ts, entry.Timestamp.UTC())
+			require.Equal(t, model.LabelSet{"job": "audit"}, entry.Labels)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for entry %s", line)
+		}
+	}
+	expectEntry(`{"action":"login","id":1,"time":"2023-04-01T10:00:00Z"}`, time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC))
+	expectEntry(`{"action":"logout","id":2,"time":"2023-04-01T10:01:00Z"}`, time.Date(2023, 4, 1, 10, 1, 0, 0, time.UTC))
+
+	// Only new records are sent on the next polls.
+	setBody(`{"events": [
+		{"id": 2, "time": "2023-04-01T10:01:00Z", "action": "logout"},
+		{"id": 3, "time": "2023-04-01T10:02:00Z", "action": "login"}
+	]}`)
+	expectEntry(`{"action":"login","id":3,"time":"2023-04-01T10:02:00Z"}`, time.Date(2023, 4, 1, 10, 2, 0, 0, time.UTC))
+
+	select {
+	case entry := <-receiver:
+		t.Fatalf("unexpected entry %s", entry.Line)
+	case <-time.After(200 * time.Millisecond):
+	}
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+
+	// Seen keys are remembered across restarts.
+	cancel()
+	opts.Registerer = prometheus.NewRegistry()
+	c, err = New(opts, args)
+	require.NoError(t, err)
+	require.False(t, c.seen.Add("3"))
+}
+
+func TestSeenKeys(t *testing.T) {
+	s := newSeenKeys(2)
+	require.True(t, s.Add("a"))
+	require.True(t, s.Add("b"))
+	require.False(t, s.Add("a"))
+
+	// The oldest key is forgotten once the maximum is exceeded.
+	require.True(t, s.Add("c"))
+	require.True(t, s.Add("a"))
+	require.Equal(t, []string{"c", "a"}, s.keys)
+
+	dataPath := t.TempDir()
+	require.NoError(t, s.Save(dataPath))
+	loaded, err := loadSeenKeys(dataPath, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, loaded.keys)
+}
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		url        = "http://localhost"
+		forward_to = []
+		records    = "events[?"
+	`), &args)
+	require.ErrorContains(t, err, `invalid records expression "events[?"`)
+}
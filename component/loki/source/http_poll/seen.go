@@ -0,0 +1,88 @@
+package http_poll
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// seenKeysFilename is the name of the file in the component's data directory
+// which holds the keys of seen records.
+const seenKeysFilename = "seen_keys.json"
+
+// seenKeys remembers the keys of the most recently seen records. Once more
+// than max keys are remembered, the oldest keys are forgotten.
+type seenKeys struct {
+	max  int
+	keys []string // Ordered from oldest to newest.
+	set  map[string]struct{}
+}
+
+func newSeenKeys(max int) *seenKeys {
+	return &seenKeys{
+		max: max,
+		set: make(map[string]struct{}),
+	}
+}
+
+// loadSeenKeys loads the keys saved in dataPath. An empty set of keys is
+// returned if no keys were saved.
+func loadSeenKeys(dataPath string, max int) (*seenKeys, error) {
+	s := newSeenKeys(max)
+
+	buf, err := os.ReadFile(filepath.Join(dataPath, seenKeysFilename))
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading seen keys: %w", err)
+	}
+
+	var keys []string
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return nil, fmt.Errorf("decoding seen keys: %w", err)
+	}
+	for _, key := range keys {
+		s.Add(key)
+	}
+	return s, nil
+}
+
+// Add remembers key, returning false if key was already seen.
+func (s *seenKeys) Add(key string) bool {
+	if _, ok := s.set[key]; ok {
+		return false
+	}
+	s.keys = append(s.keys, key)
+	s.set[key] = struct{}{}
+	s.trim()
+	return true
+}
+
+// SetMax changes the maximum number of keys to remember.
+func (s *seenKeys) SetMax(max int) {
+	s.max = max
+	s.trim()
+}
+
+func (s *seenKeys) trim() {
+	if len(s.keys) <= s.max {
+		return
+	}
+	for _, key := range s.keys[:len(s.keys)-s.max] {
+		delete(s.set, key)
+	}
+	s.keys = s.keys[len(s.keys)-s.max:]
+}
+
+// Save saves the keys to dataPath.
+func (s *seenKeys) Save(dataPath string) error {
+	buf, err := json.Marshal(s.keys)
+	if err != nil {
+		return fmt.Errorf("encoding seen keys: %w", err)
+	}
+	if err := os.MkdirAll(dataPath, 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataPath, seenKeysFilename), buf, 0640)
+}
@@ -0,0 +1,277 @@
+// Package auditlog implements the logic shared by components which pull audit
+// logs from the APIs of SaaS products.
+package auditlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/positions"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+)
+
+// Record is a single audit log record.
+type Record struct {
+	Line      string
+	Timestamp time.Time
+}
+
+// Page is a page of records returned by a Fetcher.
+type Page struct {
+	// Records of the page, ordered from oldest to newest.
+	Records []Record
+
+	// Cursor to resume fetching from once the records of the page have been
+	// sent.
+	Cursor string
+
+	// More is true if more records can be fetched immediately.
+	More bool
+
+	// Wait is how long to wait before fetching the next page, such as when
+	// the API reported that the rate limit is about to be exceeded.
+	Wait time.Duration
+}
+
+// Fetcher fetches pages of audit log records from an API.
+type Fetcher interface {
+	// Source identifies where records are fetched from. Cursors are only
+	// resumed for the same source.
+	Source() string
+
+	// Fetch fetches the page of records following cursor. cursor is empty
+	// if no records were fetched from the source before.
+	Fetch(ctx context.Context, cursor string) (Page, error)
+}
+
+// Arguments configure a Component.
+type Arguments struct {
+	Fetcher       Fetcher
+	PollFrequency time.Duration
+	Labels        model.LabelSet
+	ForwardTo     []loki.LogsReceiver
+}
+
+// Component polls a Fetcher for new records, forwarding them as log entries.
+// The cursor of the last sent page is saved in a positions file in the data
+// directory of the component, so that polling resumes where it left off when
+// the agent restarts.
+type Component struct {
+	log       log.Logger
+	metrics   *metrics
+	positions positions.Positions
+	posKey    string
+
+	mut      sync.Mutex
+	args     Arguments
+	lastPoll time.Time
+
+	// Updated is written to whenever args updates.
+	updated chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+// New creates a new Component. Metrics of the component are prefixed with
+// metricsPrefix, such as loki_source_okta.
+func New(o component.Options, metricsPrefix string) (*Component, error) {
+	err := os.MkdirAll(o.DataPath, 0750)
+	if err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	positionsFile, err := positions.New(o.Logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: filepath.Join(o.DataPath, "positions.yml"),
+		DiskBudget:    o.DiskBudget,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Component{
+		log:       o.Logger,
+		metrics:   newMetrics(o.Registerer, metricsPrefix),
+		positions: positionsFile,
+		posKey:    positions.CursorKey(o.ID),
+		updated:   make(chan struct{}, 1),
+
+		health: component.Health{
+			Health:     component.HealthTypeUnknown,
+			Message:    "component started",
+			UpdateTime: time.Now(),
+		},
+	}, nil
+}
+
+// Run polls for new records until ctx is canceled.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.positions.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.nextPoll()):
+			c.poll(ctx)
+		case <-c.updated:
+			// no-op; force the next wait to be reread.
+		}
+	}
+}
+
+// Update updates the arguments of the Component.
+func (c *Component) Update(args Arguments) {
+	c.mut.Lock()
+	c.args = args
+	c.mut.Unlock()
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+}
+
+// nextPoll returns how long to wait to poll given the last time a
+// poll occurred. nextPoll returns 0 if a poll should occur immediately.
+func (c *Component) nextPoll() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	nextPoll := c.lastPoll.Add(c.args.PollFrequency)
+	now := time.Now()
+
+	if now.After(nextPoll) {
+		// Poll immediately; next poll period was in the past.
+		return 0
+	}
+	return nextPoll.Sub(now)
+}
+
+// poll fetches and sends pages of records until no more records are
+// available. After polling, the component's health is updated with the
+// success or failure status.
+func (c *Component) poll(ctx context.Context) {
+	c.mut.Lock()
+	c.lastPoll = time.Now()
+	args := c.args
+	c.mut.Unlock()
+
+	startTime := time.Now()
+	err := c.pollError(ctx, args)
+
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	if err == nil {
+		c.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "polled audit log",
+			UpdateTime: startTime,
+		}
+	} else {
+		level.Error(c.log).Log("msg", "failed to poll audit log", "err", err)
+		c.metrics.pollFailures.Inc()
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("polling failed: %s", err),
+			UpdateTime: startTime,
+		}
+	}
+}
+
+// pollError is like poll but returns an error if one occurred.
+func (c *Component) pollError(ctx context.Context, args Arguments) error {
+	if args.Fetcher == nil {
+		return nil
+	}
+	source := args.Fetcher.Source()
+	cursor := c.positions.GetString(c.posKey, source)
+
+	for {
+		page, err := args.Fetcher.Fetch(ctx, cursor)
+
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
+			c.metrics.rateLimited.Inc()
+			level.Warn(c.log).Log("msg", "audit log API rate limit exceeded, waiting before retrying", "wait", rateLimited.RetryAfter)
+			if !sleep(ctx, rateLimited.RetryAfter) {
+				return nil
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for _, record := range page.Records {
+			entry := loki.Entry{
+				Labels: args.Labels.Clone(),
+				Entry: logproto.Entry{
+					Timestamp: record.Timestamp,
+					Line:      record.Line,
+				},
+			}
+			for _, receiver := range args.ForwardTo {
+				select {
+				case <-ctx.Done():
+					return nil
+				case receiver <- entry:
+				}
+			}
+		}
+		c.metrics.records.Add(float64(len(page.Records)))
+
+		// Only save the cursor once all records of the page have been sent.
+		if page.Cursor != "" {
+			cursor = page.Cursor
+			c.positions.PutString(c.posKey, source, cursor)
+		}
+
+		if !page.More {
+			return nil
+		}
+		if !sleep(ctx, page.Wait) {
+			return nil
+		}
+	}
+}
+
+// sleep waits for d, returning false if ctx was canceled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// CurrentHealth returns the health of the Component.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+// Cursor returns the cursor saved for source.
+func (c *Component) Cursor(source string) string {
+	return c.positions.GetString(c.posKey, source)
+}
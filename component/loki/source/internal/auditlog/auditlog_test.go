@@ -0,0 +1,119 @@
+package auditlog
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFetcher struct {
+	mut     sync.Mutex
+	cursors []string // Cursors Fetch was called with.
+	pages   map[string]Page
+	limited bool // Whether the next call is rate limited.
+}
+
+func (f *fakeFetcher) Source() string { return "fake" }
+
+func (f *fakeFetcher) Fetch(ctx context.Context, cursor string) (Page, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.limited {
+		f.limited = false
+		return Page{}, &RateLimitError{RetryAfter: 10 * time.Millisecond}
+	}
+	f.cursors = append(f.cursors, cursor)
+	return f.pages[cursor], nil
+}
+
+func TestComponent(t *testing.T) {
+	fetcher := &fakeFetcher{
+		limited: true,
+		pages: map[string]Page{
+			"": {
+				Records: []Record{{Line: "a", Timestamp: time.Unix(1, 0)}},
+				Cursor:  "1",
+				More:    true,
+			},
+			"1": {
+				Records: []Record{{Line: "b", Timestamp: time.Unix(2, 0)}},
+				Cursor:  "2",
+			},
+		},
+	}
+
+	receiver := make(loki.LogsReceiver)
+	opts := component.Options{
+		ID:         "loki.source.test.default",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		DataPath:   t.TempDir(),
+	}
+	c, err := New(opts, "loki_source_test")
+	require.NoError(t, err)
+	c.Update(Arguments{
+		Fetcher:       fetcher,
+		PollFrequency: time.Hour,
+		Labels:        model.LabelSet{"job": "test"},
+		ForwardTo:     []loki.LogsReceiver{receiver},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	// Rate limited requests are retried, and all available pages are fetched.
+	for _, line := range []string{"a", "b"} {
+		select {
+		case entry := <-receiver:
+			require.Equal(t, line, entry.Line)
+			require.Equal(t, model.LabelSet{"job": "test"}, entry.Labels)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for entry %s", line)
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return c.CurrentHealth().Health == component.HealthTypeHealthy
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "2", c.Cursor("fake"))
+
+	fetcher.mut.Lock()
+	defer fetcher.mut.Unlock()
+	require.Equal(t, []string{"", "1"}, fetcher.cursors)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tt := []struct {
+		name   string
+		header http.Header
+		expect time.Duration
+	}{
+		{"retry after seconds", http.Header{"Retry-After": []string{"30"}}, 30 * time.Second},
+		{"retry after date", http.Header{"Retry-After": []string{now.Add(time.Minute).UTC().Format(http.TimeFormat)}}, time.Minute},
+		{"rate limit reset", http.Header{"X-Rate-Limit-Reset": []string{"1010"}}, 10 * time.Second},
+		{"no header", http.Header{}, defaultRetryAfter},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, retryAfter(tc.header, now))
+		})
+	}
+
+	h := http.Header{"X-Rate-Limit-Remaining": []string{"0"}, "X-Rate-Limit-Reset": []string{"1005"}}
+	require.Equal(t, 5*time.Second, RateLimitWait(h, now))
+	h.Set("X-Rate-Limit-Remaining", "10")
+	require.Zero(t, RateLimitWait(h, now))
+}
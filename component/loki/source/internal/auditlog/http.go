@@ -0,0 +1,93 @@
+package auditlog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRetryAfter is how long to wait after being rate limited when the API
+// doesn't report when to retry.
+const defaultRetryAfter = time.Minute
+
+// RateLimitError is returned when an API rejected a request because its rate
+// limit was exceeded.
+type RateLimitError struct {
+	// RetryAfter is how long to wait before retrying the request.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// Do performs req with cli and returns the body of a successful response.
+// Responses with a 429 status code return a *RateLimitError.
+func Do(cli *http.Client, req *http.Request) ([]byte, http.Header, error) {
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, resp.Header, &RateLimitError{RetryAfter: retryAfter(resp.Header, time.Now())}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		if len(body) > 512 {
+			body = body[:512]
+		}
+		return nil, resp.Header, fmt.Errorf("unexpected status code %s: %s", resp.Status, body)
+	}
+	return body, resp.Header, nil
+}
+
+// retryAfter returns how long to wait before retrying a rate limited request.
+// The standard Retry-After header is used if present, followed by the
+// X-Rate-Limit-Reset header used by APIs such as Okta's.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t.Sub(now)
+		}
+	}
+	if reset, ok := rateLimitReset(h, now); ok {
+		return reset
+	}
+	return defaultRetryAfter
+}
+
+// RateLimitWait returns how long to wait before sending the next request when
+// the X-Rate-Limit-Remaining header of a response reports that no requests
+// are remaining until the rate limit resets.
+func RateLimitWait(h http.Header, now time.Time) time.Duration {
+	if h.Get("X-Rate-Limit-Remaining") != "0" {
+		return 0
+	}
+	wait, _ := rateLimitReset(h, now)
+	return wait
+}
+
+// rateLimitReset returns the time until the rate limit resets, reported as
+// seconds since the Unix epoch in the X-Rate-Limit-Reset header.
+func rateLimitReset(h http.Header, now time.Time) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(h.Get("X-Rate-Limit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Unix(reset, 0).Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
@@ -0,0 +1,31 @@
+package auditlog
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	records      prometheus.Counter
+	rateLimited  prometheus.Counter
+	pollFailures prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer, prefix string) *metrics {
+	m := &metrics{
+		records: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_records_total",
+			Help: "Total number of audit log records sent as log entries.",
+		}),
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_rate_limited_requests_total",
+			Help: "Total number of requests rejected because the API rate limit was exceeded.",
+		}),
+		pollFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: prefix + "_poll_failures_total",
+			Help: "Total number of failed polls of the audit log.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.records, m.rateLimited, m.pollFailures)
+	}
+	return m
+}
@@ -42,6 +42,10 @@ type TargetConfig struct {
 	// Authentication strategy with Kafka brokers
 	Authentication Authentication `yaml:"authentication"`
 
+	// WaitForDelivery only marks messages as consumed once the entries parsed
+	// from them have been delivered, rather than as soon as they're read.
+	WaitForDelivery bool `yaml:"-"`
+
 	MessageParser MessageParser
 }
 
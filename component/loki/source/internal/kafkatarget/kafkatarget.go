@@ -38,6 +38,10 @@ type KafkaTarget struct {
 	relabelConfig        []*relabel.Config
 	useIncomingTimestamp bool
 	messageParser        MessageParser
+
+	// tracker, if set, marks messages as consumed once their entries have been
+	// delivered.
+	tracker *loki.AckTracker[*sarama.ConsumerMessage]
 }
 
 func NewKafkaTarget(
@@ -49,9 +53,10 @@ func NewKafkaTarget(
 	client loki.EntryHandler,
 	useIncomingTimestamp bool,
 	messageParser MessageParser,
+	waitForDelivery bool,
 ) *KafkaTarget {
 
-	return &KafkaTarget{
+	t := &KafkaTarget{
 		logger:               logger,
 		discoveredLabels:     discoveredLabels,
 		lbs:                  lbs,
@@ -63,6 +68,12 @@ func NewKafkaTarget(
 		useIncomingTimestamp: useIncomingTimestamp,
 		messageParser:        messageParser,
 	}
+	if waitForDelivery {
+		t.tracker = loki.NewAckTracker(func(message *sarama.ConsumerMessage) {
+			session.MarkMessage(message, "")
+		})
+	}
+	return t
 }
 
 const (
@@ -89,16 +100,31 @@ func (t *KafkaTarget) run() {
 		if len(lbs) > 0 {
 			out = out.Merge(lbs)
 		}
+		// When waiting for delivery, the message is marked as consumed by the
+		// tracker once every entry parsed from it has been delivered. Messages
+		// are marked in order, so a message is never marked while an earlier
+		// one is still pending.
+		var ack *loki.Ack
+		if t.tracker != nil {
+			ack = t.tracker.Track(message)
+		}
+
 		entries, err := t.messageParser.Parse(message, out, t.relabelConfig, t.useIncomingTimestamp)
 		if err != nil {
 			level.Error(t.logger).Log("msg", "message parsing error", "err", err)
 		} else {
+			ack.Add(len(entries))
 			for _, entry := range entries {
+				entry.Ack = ack
 				t.client.Chan() <- entry
 			}
 		}
 
-		t.session.MarkMessage(message, "")
+		if ack != nil {
+			ack.Done()
+		} else {
+			t.session.MarkMessage(message, "")
+		}
 	}
 }
 
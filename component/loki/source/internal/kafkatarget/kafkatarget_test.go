@@ -174,7 +174,7 @@ func Test_TargetRun(t *testing.T) {
 				},
 			)
 
-			tg := NewKafkaTarget(nil, session, claim, tt.inDiscoveredLS, tt.inLS, tt.relabels, fc, true, &KafkaTargetMessageParser{})
+			tg := NewKafkaTarget(nil, session, claim, tt.inDiscoveredLS, tt.inLS, tt.relabels, fc, true, &KafkaTargetMessageParser{}, false)
 
 			var wg sync.WaitGroup
 			wg.Add(1)
@@ -203,3 +203,42 @@ func Test_TargetRun(t *testing.T) {
 		})
 	}
 }
+
+func Test_TargetRun_WaitForDelivery(t *testing.T) {
+	session, claim := &testSession{}, newTestClaim("footopic", 10, 12)
+	fc := kafkafake.New(func() {})
+
+	tg := NewKafkaTarget(nil, session, claim, nil, model.LabelSet{"buzz": "bazz"}, nil, fc, true, &KafkaTargetMessageParser{}, true)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tg.run()
+	}()
+
+	for i := 0; i < 3; i++ {
+		claim.Send(&sarama.ConsumerMessage{
+			Timestamp: time.Unix(0, int64(i)),
+			Value:     []byte(fmt.Sprintf("%d", i)),
+			Offset:    int64(i),
+		})
+	}
+	claim.Stop()
+	wg.Wait()
+
+	re := fc.Received()
+	require.Len(t, re, 3)
+
+	// Messages aren't marked as consumed until their entries are delivered,
+	// and are marked in order.
+	require.Empty(t, session.markedMessage)
+	re[1].Ack.Done()
+	require.Empty(t, session.markedMessage)
+	re[0].Ack.Done()
+	require.Len(t, session.markedMessage, 1)
+	require.Equal(t, int64(1), session.markedMessage[0].Offset)
+	re[2].Ack.Done()
+	require.Len(t, session.markedMessage, 2)
+	require.Equal(t, int64(2), session.markedMessage[1].Offset)
+}
@@ -304,6 +304,7 @@ func (ts *TargetSyncer) NewTarget(session sarama.ConsumerGroupSession, claim sar
 		ts.client,
 		ts.cfg.KafkaConfig.UseIncomingTimestamp,
 		ts.messageParser,
+		ts.cfg.KafkaConfig.WaitForDelivery,
 	)
 
 	return t, nil
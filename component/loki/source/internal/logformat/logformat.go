@@ -0,0 +1,211 @@
+// Package logformat decodes the formats container runtimes write log lines
+// in, so that log sources can forward the original content of log lines.
+package logformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is a format of container log lines.
+type Format string
+
+// Supported formats of container log lines.
+const (
+	// FormatAuto detects the format of each log line, decoding lines in the
+	// CRI or docker-json formats and passing through other lines unmodified.
+	FormatAuto Format = "auto"
+
+	// FormatRaw passes through all log lines unmodified.
+	FormatRaw Format = "raw"
+
+	// FormatCRI decodes log lines in the CRI format, such as
+	// "2019-04-30T02:12:41.8443515Z stdout F message".
+	FormatCRI Format = "cri"
+
+	// FormatDocker decodes log lines in the format of Docker's json-file
+	// logging driver, such as {"log":"message\n","stream":"stdout","time":"..."}.
+	FormatDocker Format = "docker"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Format) UnmarshalText(text []byte) error {
+	switch Format(text) {
+	case FormatAuto, FormatRaw, FormatCRI, FormatDocker:
+		*f = Format(text)
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q, must be one of %q, %q, %q or %q", text, FormatAuto, FormatRaw, FormatCRI, FormatDocker)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (f Format) MarshalText() ([]byte, error) {
+	return []byte(f), nil
+}
+
+// maxPartialLines is the maximum number of partial lines to reassemble into a
+// single line. Lines are flushed once the limit is reached so that a stream
+// which never completes a line doesn't grow without bounds.
+const maxPartialLines = 100
+
+// A Decoder decodes log lines of a single container. Decoders reassemble log
+// lines which container runtimes split into multiple partial lines. Decoders
+// are not safe for concurrent use.
+type Decoder struct {
+	format Format
+
+	// Partial lines waiting to be completed, per stream.
+	partial map[string]*partialLine
+}
+
+type partialLine struct {
+	content strings.Builder
+	count   int
+}
+
+// NewDecoder returns a new Decoder for log lines in the given format. An
+// empty format is treated as FormatRaw.
+func NewDecoder(format Format) *Decoder {
+	if format == "" {
+		format = FormatRaw
+	}
+	return &Decoder{
+		format:  format,
+		partial: make(map[string]*partialLine),
+	}
+}
+
+// Decode decodes a single log line, returning its content. ok is false when
+// line is a partial line which was buffered until the rest of the line is
+// decoded; the caller must skip the line in that case.
+//
+// Lines which don't match the format of the Decoder are returned unmodified.
+func (d *Decoder) Decode(line string) (content string, ok bool) {
+	switch d.format {
+	case FormatCRI:
+		if l, parsed := parseCRI(line); parsed {
+			return d.reassemble(l)
+		}
+	case FormatDocker:
+		if l, parsed := parseDocker(line); parsed {
+			return d.reassemble(l)
+		}
+	case FormatAuto:
+		if l, parsed := parseCRI(line); parsed {
+			return d.reassemble(l)
+		}
+		if l, parsed := parseDocker(line); parsed {
+			return d.reassemble(l)
+		}
+	}
+	return line, true
+}
+
+// decodedLine is a log line decoded from a container runtime format.
+type decodedLine struct {
+	stream  string
+	content string
+	partial bool
+}
+
+// reassemble buffers partial lines until a complete line is decoded for the
+// same stream.
+func (d *Decoder) reassemble(l decodedLine) (string, bool) {
+	p := d.partial[l.stream]
+
+	if l.partial {
+		if p == nil {
+			p = &partialLine{}
+			d.partial[l.stream] = p
+		}
+		p.content.WriteString(l.content)
+		p.count++
+
+		if p.count < maxPartialLines {
+			return "", false
+		}
+		delete(d.partial, l.stream)
+		return p.content.String(), true
+	}
+
+	if p == nil {
+		return l.content, true
+	}
+	delete(d.partial, l.stream)
+	p.content.WriteString(l.content)
+	return p.content.String(), true
+}
+
+// parseCRI parses a log line in the CRI format:
+//
+//	<RFC3339Nano timestamp> <stdout|stderr> <F|P> <content>
+//
+// The P flag marks partial lines, while F marks the last part of a line.
+func parseCRI(line string) (decodedLine, bool) {
+	line = trimNewline(line)
+
+	timestamp, rest, found := strings.Cut(line, " ")
+	if !found {
+		return decodedLine{}, false
+	}
+	if _, err := time.Parse(time.RFC3339Nano, timestamp); err != nil {
+		return decodedLine{}, false
+	}
+
+	stream, rest, found := strings.Cut(rest, " ")
+	if !found || (stream != "stdout" && stream != "stderr") {
+		return decodedLine{}, false
+	}
+
+	// The content may be empty, in which case there's no separator after the
+	// flag.
+	flag, content, _ := strings.Cut(rest, " ")
+	if flag != "F" && flag != "P" {
+		return decodedLine{}, false
+	}
+
+	return decodedLine{
+		stream:  stream,
+		content: content,
+		partial: flag == "P",
+	}, true
+}
+
+// dockerLine is a log line written by Docker's json-file logging driver.
+type dockerLine struct {
+	Log    *string `json:"log"`
+	Stream string  `json:"stream"`
+	Time   string  `json:"time"`
+}
+
+// parseDocker parses a log line written by Docker's json-file logging driver.
+// Docker splits long lines into multiple entries; every entry but the last is
+// missing the trailing newline.
+func parseDocker(line string) (decodedLine, bool) {
+	line = trimNewline(line)
+	if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+		return decodedLine{}, false
+	}
+
+	var l dockerLine
+	if err := json.Unmarshal([]byte(line), &l); err != nil || l.Log == nil || l.Stream == "" {
+		return decodedLine{}, false
+	}
+	if _, err := time.Parse(time.RFC3339Nano, l.Time); err != nil {
+		return decodedLine{}, false
+	}
+
+	return decodedLine{
+		stream:  l.Stream,
+		content: trimNewline(*l.Log),
+		partial: !strings.HasSuffix(*l.Log, "\n"),
+	}, true
+}
+
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
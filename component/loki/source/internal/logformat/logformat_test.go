@@ -0,0 +1,93 @@
+package logformat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	tt := []struct {
+		name   string
+		format Format
+		input  []string
+		expect []string
+	}{
+		{
+			name:   "raw",
+			format: FormatRaw,
+			input:  []string{"2019-04-30T02:12:41.8443515Z stdout F hello\n"},
+			expect: []string{"2019-04-30T02:12:41.8443515Z stdout F hello\n"},
+		},
+		{
+			name:   "cri",
+			format: FormatCRI,
+			input: []string{
+				"2019-04-30T02:12:41.8443515Z stdout F hello\n",
+				"2019-04-30T02:12:41.8443515Z stderr F \n",
+				"not a cri line\n",
+			},
+			expect: []string{"hello", "", "not a cri line\n"},
+		},
+		{
+			name:   "cri partial lines",
+			format: FormatCRI,
+			input: []string{
+				"2019-04-30T02:12:41.8443515Z stdout P hel",
+				"2019-04-30T02:12:41.8443515Z stderr F error",
+				"2019-04-30T02:12:41.8443515Z stdout P lo ",
+				"2019-04-30T02:12:41.8443515Z stdout F world",
+			},
+			expect: []string{"error", "hello world"},
+		},
+		{
+			name:   "docker",
+			format: FormatDocker,
+			input: []string{
+				`{"log":"hello\n","stream":"stdout","time":"2019-04-30T02:12:41.8443515Z"}`,
+				`{"log":"hel","stream":"stdout","time":"2019-04-30T02:12:41.8443515Z"}`,
+				`{"log":"lo\n","stream":"stdout","time":"2019-04-30T02:12:41.8443515Z"}`,
+				`{"msg":"application json"}`,
+			},
+			expect: []string{"hello", "hello", `{"msg":"application json"}`},
+		},
+		{
+			name:   "auto",
+			format: FormatAuto,
+			input: []string{
+				"2019-04-30T02:12:41.8443515Z stdout F cri\n",
+				`{"log":"docker\n","stream":"stderr","time":"2019-04-30T02:12:41.8443515Z"}`,
+				"level=info msg=raw\n",
+				"2019-04-30 stdout F not a cri timestamp\n",
+			},
+			expect: []string{"cri", "docker", "level=info msg=raw\n", "2019-04-30 stdout F not a cri timestamp\n"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDecoder(tc.format)
+
+			var actual []string
+			for _, line := range tc.input {
+				if content, ok := d.Decode(line); ok {
+					actual = append(actual, content)
+				}
+			}
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestDecoder_MaxPartialLines(t *testing.T) {
+	d := NewDecoder(FormatCRI)
+
+	for i := 0; i < maxPartialLines-1; i++ {
+		_, ok := d.Decode("2019-04-30T02:12:41.8443515Z stdout P a")
+		require.False(t, ok)
+	}
+
+	content, ok := d.Decode("2019-04-30T02:12:41.8443515Z stdout P a")
+	require.True(t, ok)
+	require.Len(t, content, maxPartialLines)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -96,6 +97,25 @@ var defaultJournalEntryFunc = func(c sdjournal.JournalReaderConfig, cursor strin
 	return journal.GetEntry()
 }
 
+// BatchConfig configures how journal entries are batched before they're
+// sent. Batching entries lowers the overhead of reading journals which produce
+// many entries per second, as the read position is saved once per batch.
+type BatchConfig struct {
+	// MaxSize is the maximum number of entries in a batch. A batch is sent as
+	// soon as it's full.
+	MaxSize int
+
+	// FlushInterval is the maximum amount of time an entry waits in an
+	// incomplete batch before the batch is sent.
+	FlushInterval time.Duration
+}
+
+// DefaultBatchConfig holds the default settings for batching journal entries.
+var DefaultBatchConfig = BatchConfig{
+	MaxSize:       100,
+	FlushInterval: 100 * time.Millisecond,
+}
+
 // JournalTarget tails systemd journal entries.
 // nolint
 type JournalTarget struct {
@@ -107,9 +127,17 @@ type JournalTarget struct {
 	relabelConfig []*relabel.Config
 	config        *scrapeconfig.JournalTargetConfig
 	labels        model.LabelSet
+	batchConfig   BatchConfig
+	labelFields   map[string]struct{} // Fields to create labels for, nil for all fields.
 
 	r     journalReader
 	until chan time.Time
+
+	batchMut    sync.Mutex
+	batch       []loki.Entry
+	batchCursor string      // Cursor of the last entry in batch.
+	flushTimer  *time.Timer // Flushes an incomplete batch, nil if batch is empty.
+	stopped     bool
 }
 
 // NewJournalTarget configures a new JournalTarget.
@@ -121,6 +149,8 @@ func NewJournalTarget(
 	jobName string,
 	relabelConfig []*relabel.Config,
 	targetConfig *scrapeconfig.JournalTargetConfig,
+	batchConfig BatchConfig,
+	labelFields []string,
 ) (*JournalTarget, error) {
 
 	return journalTargetWithReader(
@@ -131,6 +161,8 @@ func NewJournalTarget(
 		jobName,
 		relabelConfig,
 		targetConfig,
+		batchConfig,
+		labelFields,
 		defaultJournalReaderFunc,
 		defaultJournalEntryFunc,
 	)
@@ -144,6 +176,8 @@ func journalTargetWithReader(
 	jobName string,
 	relabelConfig []*relabel.Config,
 	targetConfig *scrapeconfig.JournalTargetConfig,
+	batchConfig BatchConfig,
+	labelFields []string,
 	readerFunc journalReaderFunc,
 	entryFunc journalEntryFunc,
 ) (*JournalTarget, error) {
@@ -157,6 +191,17 @@ func journalTargetWithReader(
 	if entryFunc == nil {
 		entryFunc = defaultJournalEntryFunc
 	}
+	if batchConfig.MaxSize <= 0 {
+		batchConfig.MaxSize = 1
+	}
+
+	var labelFieldsSet map[string]struct{}
+	if len(labelFields) > 0 {
+		labelFieldsSet = make(map[string]struct{}, len(labelFields))
+		for _, f := range labelFields {
+			labelFieldsSet[strings.ToUpper(f)] = struct{}{}
+		}
+	}
 
 	until := make(chan time.Time)
 	t := &JournalTarget{
@@ -168,8 +213,11 @@ func journalTargetWithReader(
 		relabelConfig: relabelConfig,
 		labels:        targetConfig.Labels,
 		config:        targetConfig,
+		batchConfig:   batchConfig,
+		labelFields:   labelFieldsSet,
 
 		until: until,
+		batch: make([]loki.Entry, 0, batchConfig.MaxSize),
 	}
 
 	var maxAge time.Duration
@@ -307,7 +355,7 @@ func (t *JournalTarget) formatter(entry *sdjournal.JournalEntry) (string, error)
 		}
 	}
 
-	entryLabels := makeJournalFields(entry.Fields)
+	entryLabels := makeJournalFields(entry.Fields, t.labelFields)
 
 	// Add constant labels
 	for k, v := range t.labels {
@@ -333,17 +381,59 @@ func (t *JournalTarget) formatter(entry *sdjournal.JournalEntry) (string, error)
 	}
 
 	t.metrics.journalLines.Inc()
-	t.positions.PutString(t.positionPath, "", entry.Cursor)
-	t.handler.Chan() <- loki.Entry{
+	t.appendBatch(loki.Entry{
 		Labels: lbls,
 		Entry: logproto.Entry{
 			Line:      msg,
 			Timestamp: ts,
 		},
-	}
+	}, entry.Cursor)
 	return journalEmptyStr, nil
 }
 
+// appendBatch appends an entry read at the given cursor to the current batch.
+// The batch is sent once it's full, or once the flush interval passed since
+// the first entry was appended to it.
+func (t *JournalTarget) appendBatch(entry loki.Entry, cursor string) {
+	t.batchMut.Lock()
+	defer t.batchMut.Unlock()
+
+	t.batch = append(t.batch, entry)
+	t.batchCursor = cursor
+
+	switch {
+	case len(t.batch) >= t.batchConfig.MaxSize:
+		t.flushBatch()
+	case t.flushTimer == nil:
+		t.flushTimer = time.AfterFunc(t.batchConfig.FlushInterval, func() {
+			t.batchMut.Lock()
+			defer t.batchMut.Unlock()
+
+			if !t.stopped {
+				t.flushBatch()
+			}
+		})
+	}
+}
+
+// flushBatch sends all entries in the current batch and saves the position of
+// the last entry. flushBatch must be called with t.batchMut held.
+func (t *JournalTarget) flushBatch() {
+	if t.flushTimer != nil {
+		t.flushTimer.Stop()
+		t.flushTimer = nil
+	}
+	if len(t.batch) == 0 {
+		return
+	}
+
+	for _, entry := range t.batch {
+		t.handler.Chan() <- entry
+	}
+	t.positions.PutString(t.positionPath, "", t.batchCursor)
+	t.batch = t.batch[:0]
+}
+
 // Type returns JournalTargetType.
 func (t *JournalTarget) Type() target.TargetType {
 	return target.JournalTargetType
@@ -378,14 +468,35 @@ func (t *JournalTarget) Details() interface{} {
 // Stop shuts down the JournalTarget.
 func (t *JournalTarget) Stop() error {
 	t.until <- time.Now()
+
+	// The reader stopped following the journal, so no more entries will be
+	// appended to the batch.
+	t.batchMut.Lock()
+	t.flushBatch()
+	t.stopped = true
+	t.batchMut.Unlock()
+
 	err := t.r.Close()
 	t.handler.Stop()
 	return err
 }
 
-func makeJournalFields(fields map[string]string) map[string]string {
-	result := make(map[string]string, len(fields))
+// makeJournalFields creates the internal __journal_* labels for the fields of
+// a journal entry. If allowed is non-nil, labels are only created for the
+// fields in allowed.
+func makeJournalFields(fields map[string]string, allowed map[string]struct{}) map[string]string {
+	size := len(fields)
+	if allowed != nil && len(allowed) < size {
+		size = len(allowed) + 1 // The priority field creates two labels.
+	}
+
+	result := make(map[string]string, size)
 	for k, v := range fields {
+		if allowed != nil {
+			if _, ok := allowed[k]; !ok {
+				continue
+			}
+		}
 		if k == "PRIORITY" {
 			result[fmt.Sprintf("__journal_%s_%s", strings.ToLower(k), "keyword")] = makeJournalPriority(v)
 		}
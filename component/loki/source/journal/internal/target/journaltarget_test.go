@@ -8,6 +8,7 @@ package target
 // to other loki components.
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -21,6 +22,7 @@ import (
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -104,7 +106,7 @@ func TestJournalTarget(t *testing.T) {
 
 	registry := prometheus.NewRegistry()
 	jt, err := journalTargetWithReader(NewMetrics(registry), logger, client, ps, "test", relabels,
-		&scrapeconfig.JournalTargetConfig{}, newMockJournalReader, newMockJournalEntry(nil))
+		&scrapeconfig.JournalTargetConfig{}, DefaultBatchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -158,7 +160,7 @@ func TestJournalTargetParsingErrors(t *testing.T) {
 
 	registry := prometheus.NewRegistry()
 	jt, err := journalTargetWithReader(NewMetrics(registry), logger, client, ps, "test", relabels,
-		&scrapeconfig.JournalTargetConfig{}, newMockJournalReader, newMockJournalEntry(nil))
+		&scrapeconfig.JournalTargetConfig{}, DefaultBatchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -235,7 +237,7 @@ func TestJournalTarget_JSON(t *testing.T) {
 	cfg := &scrapeconfig.JournalTargetConfig{JSON: true}
 
 	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", relabels,
-		cfg, newMockJournalReader, newMockJournalEntry(nil))
+		cfg, DefaultBatchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -286,7 +288,7 @@ func TestJournalTarget_Since(t *testing.T) {
 	}
 
 	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", nil,
-		&cfg, newMockJournalReader, newMockJournalEntry(nil))
+		&cfg, DefaultBatchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -326,7 +328,7 @@ func TestJournalTarget_Cursor_TooOld(t *testing.T) {
 	})
 
 	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", nil,
-		&cfg, newMockJournalReader, journalEntry)
+		&cfg, DefaultBatchConfig, nil, newMockJournalReader, journalEntry)
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -366,7 +368,7 @@ func TestJournalTarget_Cursor_NotTooOld(t *testing.T) {
 	})
 
 	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", nil,
-		&cfg, newMockJournalReader, journalEntry)
+		&cfg, DefaultBatchConfig, nil, newMockJournalReader, journalEntry)
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -381,7 +383,7 @@ func Test_MakeJournalFields(t *testing.T) {
 		"OTHER_FIELD": "foobar",
 		"PRIORITY":    "6",
 	}
-	receivedFields := makeJournalFields(entryFields)
+	receivedFields := makeJournalFields(entryFields, nil)
 	expectedFields := map[string]string{
 		"__journal_code_file":        "journaltarget_test.go",
 		"__journal_other_field":      "foobar",
@@ -389,6 +391,57 @@ func Test_MakeJournalFields(t *testing.T) {
 		"__journal_priority_keyword": "info",
 	}
 	assert.Equal(t, expectedFields, receivedFields)
+
+	allowed := map[string]struct{}{"PRIORITY": {}, "MISSING_FIELD": {}}
+	receivedFields = makeJournalFields(entryFields, allowed)
+	expectedFields = map[string]string{
+		"__journal_priority":         "6",
+		"__journal_priority_keyword": "info",
+	}
+	assert.Equal(t, expectedFields, receivedFields)
+}
+
+func TestJournalTarget_LabelFields(t *testing.T) {
+	logger := log.NewNopLogger()
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	client := New(func() {})
+
+	relabelCfg := `
+- source_labels: ['__journal__systemd_unit']
+  target_label: 'unit'
+- source_labels: ['__journal_code_file']
+  target_label: 'code_file'`
+
+	var relabels []*relabel.Config
+	require.NoError(t, yaml.Unmarshal([]byte(relabelCfg), &relabels))
+
+	cfg := &scrapeconfig.JournalTargetConfig{JSON: true}
+	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", relabels,
+		cfg, DefaultBatchConfig, []string{"_systemd_unit"}, newMockJournalReader, newMockJournalEntry(nil))
+	require.NoError(t, err)
+
+	r := jt.r.(*mockJournalReader)
+	r.t = t
+	r.Write(map[string]string{
+		"MESSAGE":       "ping",
+		"CODE_FILE":     "journaltarget_test.go",
+		"_SYSTEMD_UNIT": "foo.service",
+	})
+	require.NoError(t, jt.Stop())
+	client.Stop()
+
+	// Fields outside of the allowlist don't create labels, but are still
+	// included in the JSON body.
+	received := client.Received()
+	require.Len(t, received, 1)
+	require.Equal(t, model.LabelSet{"unit": "foo.service"}, received[0].Labels)
+	require.Equal(t, `{"CODE_FILE":"journaltarget_test.go","MESSAGE":"ping","_SYSTEMD_UNIT":"foo.service"}`, received[0].Line)
 }
 
 func TestJournalTarget_Matches(t *testing.T) {
@@ -417,7 +470,7 @@ func TestJournalTarget_Matches(t *testing.T) {
 	}
 
 	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", nil,
-		&cfg, newMockJournalReader, newMockJournalEntry(nil))
+		&cfg, DefaultBatchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
 	require.NoError(t, err)
 
 	r := jt.r.(*mockJournalReader)
@@ -426,6 +479,119 @@ func TestJournalTarget_Matches(t *testing.T) {
 	client.Stop()
 }
 
+func TestJournalTarget_Batching(t *testing.T) {
+	logger := log.NewNopLogger()
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	client := New(func() {})
+
+	batchConfig := BatchConfig{MaxSize: 5, FlushInterval: time.Hour}
+	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", nil,
+		&scrapeconfig.JournalTargetConfig{Labels: model.LabelSet{"job": "test"}}, batchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
+	require.NoError(t, err)
+
+	r := jt.r.(*mockJournalReader)
+	write := func(cursor string) {
+		_, err := r.config.Formatter(&sdjournal.JournalEntry{
+			Fields:            map[string]string{"MESSAGE": "ping"},
+			Cursor:            cursor,
+			RealtimeTimestamp: uint64(time.Now().UnixMicro()),
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 7; i++ {
+		write(fmt.Sprintf("cursor-%d", i))
+	}
+
+	// Only the full batch is sent, and the position is saved once it's sent.
+	require.Eventually(t, func() bool { return len(client.Received()) == 5 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, "cursor-4", ps.GetString(jt.positionPath, ""))
+
+	// Stopping the target sends the incomplete batch.
+	require.NoError(t, jt.Stop())
+	require.Len(t, client.Received(), 7)
+	require.Equal(t, "cursor-6", ps.GetString(jt.positionPath, ""))
+}
+
+func TestJournalTarget_BatchFlushInterval(t *testing.T) {
+	logger := log.NewNopLogger()
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: t.TempDir() + "/positions.yml",
+	})
+	require.NoError(t, err)
+
+	client := New(func() {})
+
+	batchConfig := BatchConfig{MaxSize: 100, FlushInterval: 10 * time.Millisecond}
+	jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, client, ps, "test", nil,
+		&scrapeconfig.JournalTargetConfig{Labels: model.LabelSet{"job": "test"}}, batchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
+	require.NoError(t, err)
+
+	r := jt.r.(*mockJournalReader)
+	r.t = t
+	r.Write(map[string]string{"MESSAGE": "ping"})
+
+	// The incomplete batch is sent once the flush interval passed.
+	require.Eventually(t, func() bool { return len(client.Received()) == 1 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, jt.Stop())
+}
+
+func BenchmarkJournalTarget(b *testing.B) {
+	for _, maxSize := range []int{1, 100, 1000} {
+		b.Run(fmt.Sprintf("max_batch_size=%d", maxSize), func(b *testing.B) {
+			logger := log.NewNopLogger()
+
+			ps, err := positions.New(logger, positions.Config{
+				SyncPeriod:    10 * time.Second,
+				PositionsFile: b.TempDir() + "/positions.yml",
+			})
+			require.NoError(b, err)
+			defer ps.Stop()
+
+			ch := make(chan loki.Entry)
+			go func() {
+				for range ch {
+				}
+			}()
+			handler := loki.NewEntryHandler(ch, func() { close(ch) })
+
+			batchConfig := BatchConfig{MaxSize: maxSize, FlushInterval: 100 * time.Millisecond}
+			jt, err := journalTargetWithReader(NewMetrics(prometheus.NewRegistry()), logger, handler, ps, "test", nil,
+				&scrapeconfig.JournalTargetConfig{Labels: model.LabelSet{"job": "test"}}, batchConfig, nil, newMockJournalReader, newMockJournalEntry(nil))
+			require.NoError(b, err)
+
+			r := jt.r.(*mockJournalReader)
+			entry := &sdjournal.JournalEntry{
+				Fields: map[string]string{
+					"MESSAGE":           "ping",
+					"_SYSTEMD_UNIT":     "foo.service",
+					"PRIORITY":          "6",
+					"SYSLOG_IDENTIFIER": "foo",
+				},
+				Cursor:            "s=1;i=1",
+				RealtimeTimestamp: uint64(time.Now().UnixMicro()),
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = r.config.Formatter(entry)
+			}
+			b.StopTimer()
+
+			require.NoError(b, jt.Stop())
+		})
+	}
+}
+
 // Client is a fake client used for testing.
 type Client struct {
 	entries  chan loki.Entry
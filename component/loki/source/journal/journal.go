@@ -28,6 +28,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -51,6 +53,7 @@ func New(o component.Options, args Arguments) (component.Component, error) {
 		PositionsFile:     filepath.Join(o.DataPath, "positions.yml"),
 		IgnoreInvalidYaml: false,
 		ReadOnly:          false,
+		DiskBudget:        o.DiskBudget,
 	})
 	if err != nil {
 		return nil, err
@@ -112,7 +115,11 @@ func (c *Component) Update(args component.Arguments) error {
 	rcs := flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
 	entryHandler := loki.NewEntryHandler(c.handler, func() {})
 
-	newTarget, err := target.NewJournalTarget(c.metrics, c.o.Logger, entryHandler, c.positions, c.o.ID, rcs, convertArgs(c.o.ID, newArgs))
+	batchConfig := target.BatchConfig{
+		MaxSize:       newArgs.MaxBatchSize,
+		FlushInterval: newArgs.BatchFlushInterval,
+	}
+	newTarget, err := target.NewJournalTarget(c.metrics, c.o.Logger, entryHandler, c.positions, c.o.ID, rcs, convertArgs(c.o.ID, newArgs), batchConfig, newArgs.LabelFields)
 	if err != nil {
 		return err
 	}
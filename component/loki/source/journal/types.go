@@ -1,6 +1,7 @@
 package journal
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/grafana/agent/component/common/loki"
@@ -14,7 +15,11 @@ type Arguments struct {
 	Path         string              `river:"path,attr,optional"`
 	RelabelRules flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
 	Matches      string              `river:"matches,attr,optional"`
+	LabelFields  []string            `river:"label_fields,attr,optional"`
 	Receivers    []loki.LogsReceiver `river:"forward_to,attr"`
+
+	MaxBatchSize       int           `river:"max_batch_size,attr,optional"`
+	BatchFlushInterval time.Duration `river:"batch_flush_interval,attr,optional"`
 }
 
 func defaultArgs() Arguments {
@@ -22,6 +27,9 @@ func defaultArgs() Arguments {
 		FormatAsJson: false,
 		MaxAge:       7 * time.Hour,
 		Path:         "",
+
+		MaxBatchSize:       100,
+		BatchFlushInterval: 100 * time.Millisecond,
 	}
 }
 
@@ -34,5 +42,11 @@ func (r *Arguments) UnmarshalRiver(f func(v interface{}) error) error {
 		return err
 	}
 
+	if r.MaxBatchSize <= 0 {
+		return fmt.Errorf("max_batch_size must be greater than 0")
+	}
+	if r.BatchFlushInterval <= 0 {
+		return fmt.Errorf("batch_flush_interval must be greater than 0")
+	}
 	return nil
 }
@@ -23,6 +23,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki", "github.com/Shopify/sarama"},
 	})
 }
 
@@ -37,6 +39,7 @@ type Arguments struct {
 	Authentication       KafkaAuthentication `river:"authentication,block,optional"`
 	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
 	Labels               map[string]string   `river:"labels,attr,optional"`
+	WaitForDelivery      bool                `river:"wait_for_delivery,attr,optional"`
 
 	ForwardTo    []loki.LogsReceiver `river:"forward_to,attr"`
 	RelabelRules flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
@@ -142,6 +145,7 @@ func (c *Component) Run(ctx context.Context) error {
 			return nil
 		case entry := <-c.handler:
 			c.mut.RLock()
+			entry.Ack.Add(len(c.fanout) - 1)
 			for _, receiver := range c.fanout {
 				receiver <- entry
 			}
@@ -194,6 +198,7 @@ func (args *Arguments) Convert() kt.Config {
 			Version:              args.Version,
 			Assignor:             args.Assignor,
 			Authentication:       args.Authentication.Convert(),
+			WaitForDelivery:      args.WaitForDelivery,
 		},
 		RelabelConfigs: flow_relabel.ComponentToPromRelabelConfigs(args.RelabelRules),
 	}
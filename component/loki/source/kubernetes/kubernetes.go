@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/grafana/agent/component/loki/source/kubernetes/kubetail"
 	"github.com/grafana/agent/pkg/river"
 	"k8s.io/client-go/kubernetes"
@@ -31,6 +32,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -40,6 +43,9 @@ type Arguments struct {
 	Targets   []discovery.Target  `river:"targets,attr"`
 	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
 
+	// Format of log lines to decode.
+	LogFormat logformat.Format `river:"log_format,attr,optional"`
+
 	// Client settings to connect to Kubernetes.
 	Client commonk8s.ClientArguments `river:"client,block,optional"`
 }
@@ -48,6 +54,7 @@ var _ river.Unmarshaler = (*Arguments)(nil)
 
 // DefaultArguments holds default settings for loki.source.kubernetes.
 var DefaultArguments = Arguments{
+	LogFormat: logformat.FormatRaw,
 	Client: commonk8s.ClientArguments{
 		HTTPClientConfig: config.DefaultHTTPClientConfig,
 	},
@@ -92,6 +99,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	positionsFile, err := positions.New(o.Logger, positions.Config{
 		SyncPeriod:    10 * time.Second,
 		PositionsFile: filepath.Join(o.DataPath, "positions.yml"),
+		DiskBudget:    o.DiskBudget,
 	})
 
 	if err != nil {
@@ -207,7 +215,7 @@ func (c *Component) Update(args component.Arguments) error {
 //
 // getTailerOptions must only be called when c.mut is held.
 func (c *Component) getTailerOptions(args Arguments) (*kubetail.Options, error) {
-	if reflect.DeepEqual(c.args.Client, args.Client) && c.lastOptions != nil {
+	if reflect.DeepEqual(c.args.Client, args.Client) && c.args.LogFormat == args.LogFormat && c.lastOptions != nil {
 		return c.lastOptions, nil
 	}
 
@@ -224,6 +232,7 @@ func (c *Component) getTailerOptions(args Arguments) (*kubetail.Options, error)
 		Client:    clientSet,
 		Handler:   loki.NewEntryHandler(c.handler, func() {}),
 		Positions: c.positions,
+		LogFormat: args.LogFormat,
 	}, nil
 }
 
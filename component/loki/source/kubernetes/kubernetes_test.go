@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"testing"
 
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/stretchr/testify/require"
 )
@@ -22,6 +23,9 @@ func TestRiverConfig(t *testing.T) {
 	var args Arguments
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.NoError(t, err)
+
+	// Log lines must not be rewritten unless decoding is explicitly enabled.
+	require.Equal(t, logformat.FormatRaw, args.LogFormat)
 }
 
 func TestBadRiverConfig(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/grafana/agent/pkg/runner"
 	"k8s.io/client-go/kubernetes"
 )
@@ -23,6 +24,10 @@ type Options struct {
 
 	// Positions interface so tailers can save/restore offsets in log files.
 	Positions positions.Positions
+
+	// LogFormat is the format of log lines to decode. Log lines are passed
+	// through unmodified if LogFormat is empty.
+	LogFormat logformat.Format
 }
 
 // A Manager manages a set of running Tailers.
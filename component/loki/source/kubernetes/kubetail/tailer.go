@@ -12,6 +12,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/source/internal/logformat"
 	"github.com/grafana/agent/pkg/runner"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/loki/pkg/logproto"
@@ -176,6 +177,7 @@ func (t *tailer) tail(ctx context.Context, handler loki.EntryHandler) error {
 
 	ch := handler.Chan()
 	reader := bufio.NewReader(stream)
+	decoder := logformat.NewDecoder(t.opts.LogFormat)
 
 	for {
 		line, err := reader.ReadString('\n')
@@ -189,6 +191,13 @@ func (t *tailer) tail(ctx context.Context, handler loki.EntryHandler) error {
 			}
 			lastReadTime = entryTimestamp
 
+			// Partial lines are buffered by the decoder until the rest of the
+			// line is read.
+			entryLine, ok := decoder.Decode(entryLine)
+			if !ok {
+				continue
+			}
+
 			entry := loki.Entry{
 				Labels: t.lset,
 				Entry: logproto.Entry{
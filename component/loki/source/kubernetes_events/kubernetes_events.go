@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kit/log"
@@ -35,6 +36,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -48,6 +51,9 @@ type Arguments struct {
 
 	// Client settings to connect to Kubernetes.
 	Client kubernetes.ClientArguments `river:"client,block,optional"`
+
+	// LeaderElection is nil when leader election is disabled.
+	LeaderElection *LeaderElectionArguments `river:"leader_election,block,optional"`
 }
 
 var _ river.Unmarshaler = (*Arguments)(nil)
@@ -87,6 +93,10 @@ type Component struct {
 	runner     *runner.Runner[eventControllerTask]
 	newTasksCh chan struct{}
 
+	leading       atomic.Bool   // Whether events should be watched.
+	electionCh    chan struct{} // Written to when leader election settings change.
+	leaderMetrics *leaderMetrics
+
 	mut        sync.Mutex
 	args       Arguments
 	restConfig *rest.Config
@@ -105,13 +115,19 @@ var (
 
 // New creates a new loki.source.kubernetes_events component.
 func New(o component.Options, args Arguments) (*Component, error) {
-	err := os.MkdirAll(o.DataPath, 0750)
+	leaderMetrics, err := newLeaderMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(o.DataPath, 0750)
 	if err != nil && !os.IsExist(err) {
 		return nil, err
 	}
 	positionsFile, err := positions.New(o.Logger, positions.Config{
 		SyncPeriod:    10 * time.Second,
 		PositionsFile: filepath.Join(o.DataPath, "positions.yml"),
+		DiskBudget:    o.DiskBudget,
 	})
 	if err != nil {
 		return nil, err
@@ -125,7 +141,9 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		runner: runner.New(func(t eventControllerTask) runner.Worker {
 			return newEventController(t)
 		}),
-		newTasksCh: make(chan struct{}, 1),
+		newTasksCh:    make(chan struct{}, 1),
+		electionCh:    make(chan struct{}, 1),
+		leaderMetrics: leaderMetrics,
 	}
 	if err := c.Update(args); err != nil {
 		return nil, err
@@ -150,9 +168,14 @@ func (c *Component) Run(ctx context.Context) error {
 			case <-ctx.Done():
 				return nil
 			case <-c.newTasksCh:
-				c.tasksMut.RLock()
-				tasks := c.tasks
-				c.tasksMut.RUnlock()
+				// Only watch events while leading; applying no tasks stops all
+				// running watchers.
+				var tasks []eventControllerTask
+				if c.leading.Load() {
+					c.tasksMut.RLock()
+					tasks = c.tasks
+					c.tasksMut.RUnlock()
+				}
 
 				if err := c.runner.ApplyTasks(ctx, tasks); err != nil {
 					level.Error(c.log).Log("msg", "failed to apply event watchers", "err", err)
@@ -163,6 +186,14 @@ func (c *Component) Run(ctx context.Context) error {
 		cancel()
 	})
 
+	// Runner to campaign for leadership.
+	rg.Add(func() error {
+		c.runLeaderElection(ctx)
+		return nil
+	}, func(_ error) {
+		cancel()
+	})
+
 	// Runner to forward received logs.
 	rg.Add(func() error {
 		for {
@@ -200,7 +231,8 @@ func (c *Component) Update(args component.Arguments) error {
 	restConfig := c.restConfig
 
 	// Create a new restConfig if we don't have one or if our arguments changed.
-	if restConfig == nil || !reflect.DeepEqual(c.args.Client, newArgs.Client) {
+	restConfigChanged := restConfig == nil || !reflect.DeepEqual(c.args.Client, newArgs.Client)
+	if restConfigChanged {
 		var err error
 		restConfig, err = newArgs.Client.BuildRESTConfig(c.log)
 		if err != nil {
@@ -226,14 +258,94 @@ func (c *Component) Update(args component.Arguments) error {
 	c.tasks = newTasks
 	c.tasksMut.Unlock()
 
+	c.requestApplyTasks()
+
+	electionChanged := restConfigChanged || !reflect.DeepEqual(c.args.LeaderElection, newArgs.LeaderElection)
+
+	c.args = newArgs
+	c.restConfig = restConfig
+
+	if electionChanged {
+		select {
+		case c.electionCh <- struct{}{}:
+		default:
+			// no-op: election restart already queued.
+		}
+	}
+	return nil
+}
+
+func (c *Component) requestApplyTasks() {
 	select {
 	case c.newTasksCh <- struct{}{}:
 	default:
 		// no-op: task reload already queued.
 	}
+}
 
-	c.args = newArgs
-	return nil
+// runLeaderElection determines whether events should be watched until ctx is
+// canceled. Events are always watched when leader election is disabled;
+// otherwise, they're only watched while holding the lease. The election is
+// restarted whenever its settings change.
+func (c *Component) runLeaderElection(ctx context.Context) {
+	var (
+		cancelElection context.CancelFunc
+		electionDone   chan struct{}
+	)
+	stopElection := func() {
+		if cancelElection != nil {
+			cancelElection()
+			<-electionDone
+			cancelElection = nil
+		}
+	}
+	defer stopElection()
+
+	for {
+		c.mut.Lock()
+		var (
+			args       = c.args.LeaderElection
+			restConfig = c.restConfig
+		)
+		c.mut.Unlock()
+
+		stopElection()
+
+		if args == nil {
+			c.setLeading(true)
+		} else {
+			c.setLeading(false)
+
+			electionCtx, cancel := context.WithCancel(ctx)
+			cancelElection = cancel
+			electionDone = make(chan struct{})
+
+			go func() {
+				defer close(electionDone)
+				err := runLeaderElection(electionCtx, c.log, restConfig, *args, c.leaderMetrics, c.setLeading)
+				if err != nil {
+					level.Error(c.log).Log("msg", "failed to run leader election", "err", err)
+				}
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.electionCh:
+		}
+	}
+}
+
+// setLeading changes whether events should be watched.
+func (c *Component) setLeading(leading bool) {
+	c.leading.Store(leading)
+	if leading {
+		c.leaderMetrics.isLeader.Set(1)
+	} else {
+		c.leaderMetrics.isLeader.Set(0)
+	}
+	c.requestApplyTasks()
 }
 
 // getNamespaces gets a list of namespaces to watch from the arguments. If the
@@ -0,0 +1,159 @@
+package kubernetes_events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElectionArguments configures lease-based leader election between
+// agents running loki.source.kubernetes_events, so that only one of them
+// watches events at a time.
+type LeaderElectionArguments struct {
+	LeaseName      string        `river:"lease_name,attr"`
+	LeaseNamespace string        `river:"lease_namespace,attr"`
+	Identity       string        `river:"identity,attr,optional"`
+	LeaseDuration  time.Duration `river:"lease_duration,attr,optional"`
+	RenewDeadline  time.Duration `river:"renew_deadline,attr,optional"`
+	RetryPeriod    time.Duration `river:"retry_period,attr,optional"`
+}
+
+// DefaultLeaderElectionArguments holds default settings for
+// LeaderElectionArguments.
+var DefaultLeaderElectionArguments = LeaderElectionArguments{
+	LeaseDuration: 15 * time.Second,
+	RenewDeadline: 10 * time.Second,
+	RetryPeriod:   2 * time.Second,
+}
+
+// UnmarshalRiver implements river.Unmarshaler and applies defaults.
+func (args *LeaderElectionArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultLeaderElectionArguments
+
+	type arguments LeaderElectionArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("identity not set and unable to get hostname: %w", err)
+		}
+		args.Identity = hostname
+	}
+
+	switch {
+	case args.LeaseName == "":
+		return fmt.Errorf("lease_name must not be an empty string")
+	case args.LeaseNamespace == "":
+		return fmt.Errorf("lease_namespace must not be an empty string")
+	case args.RetryPeriod <= 0:
+		return fmt.Errorf("retry_period must be greater than 0")
+	case args.RenewDeadline <= args.RetryPeriod:
+		return fmt.Errorf("renew_deadline must be greater than retry_period")
+	case args.LeaseDuration <= args.RenewDeadline:
+		return fmt.Errorf("lease_duration must be greater than renew_deadline")
+	}
+	return nil
+}
+
+// leaderMetrics reports the state of leader election.
+type leaderMetrics struct {
+	isLeader prometheus.Gauge
+	leader   *prometheus.GaugeVec
+}
+
+func newLeaderMetrics(reg prometheus.Registerer) (*leaderMetrics, error) {
+	m := &leaderMetrics{
+		isLeader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "loki_source_kubernetes_events_is_leader",
+			Help: "Set to 1 when this agent is watching events, either as the elected leader or because leader election is disabled.",
+		}),
+		leader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "loki_source_kubernetes_events_leader_info",
+			Help: "Identity of the agent currently holding the leader election lease. Set to 1 for the current leader.",
+		}, []string{"identity"}),
+	}
+	if err := reg.Register(m.isLeader); err != nil {
+		return nil, err
+	}
+	if err := reg.Register(m.leader); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// setLeader records identity as the current leader.
+func (m *leaderMetrics) setLeader(identity string) {
+	m.leader.Reset()
+	if identity != "" {
+		m.leader.WithLabelValues(identity).Set(1)
+	}
+}
+
+// runLeaderElection campaigns for the lease described by args until ctx is
+// canceled. onChange is called with true when leadership is acquired and
+// with false when it is lost. Leadership is campaigned for again after it's
+// lost, so another agent takes over if the leader stops renewing the lease.
+func runLeaderElection(ctx context.Context, l log.Logger, cfg *rest.Config, args LeaderElectionArguments, m *leaderMetrics, onChange func(leading bool)) error {
+	clientSet, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("building Kubernetes client: %w", err)
+	}
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		args.LeaseNamespace,
+		args.LeaseName,
+		clientSet.CoreV1(),
+		clientSet.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: args.Identity},
+	)
+	if err != nil {
+		return fmt.Errorf("creating lease lock: %w", err)
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   args.LeaseDuration,
+		RenewDeadline:   args.RenewDeadline,
+		RetryPeriod:     args.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            args.LeaseNamespace + "/" + args.LeaseName,
+
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(_ context.Context) {
+				level.Info(l).Log("msg", "acquired leader election lease", "identity", args.Identity)
+				onChange(true)
+			},
+			OnStoppedLeading: func() {
+				level.Info(l).Log("msg", "lost leader election lease", "identity", args.Identity)
+				onChange(false)
+			},
+			OnNewLeader: func(identity string) {
+				level.Info(l).Log("msg", "new leader elected", "leader", identity)
+				m.setLeader(identity)
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("creating leader elector: %w", err)
+	}
+
+	for ctx.Err() == nil {
+		// Run blocks until leadership is lost or ctx is canceled.
+		elector.Run(ctx)
+	}
+	m.setLeader("")
+	return nil
+}
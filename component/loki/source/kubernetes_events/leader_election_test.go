@@ -0,0 +1,71 @@
+package kubernetes_events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderElectionArguments(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		forward_to = []
+
+		leader_election {
+			lease_name      = "agent-events"
+			lease_namespace = "monitoring"
+			identity        = "agent-0"
+		}
+	`), &args))
+
+	require.Equal(t, &LeaderElectionArguments{
+		LeaseName:      "agent-events",
+		LeaseNamespace: "monitoring",
+		Identity:       "agent-0",
+		LeaseDuration:  15 * time.Second,
+		RenewDeadline:  10 * time.Second,
+		RetryPeriod:    2 * time.Second,
+	}, args.LeaderElection)
+}
+
+func TestLeaderElectionArguments_Invalid(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name:   "missing namespace",
+			cfg:    `lease_name = "agent-events"`,
+			expect: `missing required attribute "lease_namespace"`,
+		},
+		{
+			name: "renew deadline too long",
+			cfg: `
+				lease_name      = "agent-events"
+				lease_namespace = "monitoring"
+				renew_deadline  = "20s"
+			`,
+			expect: "lease_duration must be greater than renew_deadline",
+		},
+		{
+			name: "retry period too long",
+			cfg: `
+				lease_name      = "agent-events"
+				lease_namespace = "monitoring"
+				retry_period    = "10s"
+			`,
+			expect: "renew_deadline must be greater than retry_period",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte("forward_to = []\nleader_election {\n"+tc.cfg+"\n}"), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
@@ -0,0 +1,365 @@
+// Package microsoft365 implements the loki.source.microsoft365 component.
+package microsoft365
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/source/internal/auditlog"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.microsoft365",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// loki.source.microsoft365 component.
+type Arguments struct {
+	TenantID        string              `river:"tenant_id,attr"`
+	ClientID        string              `river:"client_id,attr"`
+	ClientSecret    rivertypes.Secret   `river:"client_secret,attr"`
+	ContentTypes    []string            `river:"content_types,attr,optional"`
+	URL             string              `river:"url,attr,optional"`
+	LoginURL        string              `river:"login_url,attr,optional"`
+	InitialLookback time.Duration       `river:"initial_lookback,attr,optional"`
+	PollFrequency   time.Duration       `river:"poll_frequency,attr,optional"`
+	Labels          map[string]string   `river:"labels,attr,optional"`
+	ForwardTo       []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	ContentTypes: []string{
+		"Audit.AzureActiveDirectory",
+		"Audit.Exchange",
+		"Audit.SharePoint",
+		"Audit.General",
+	},
+	URL:             "https://manage.office.com",
+	LoginURL:        "https://login.microsoftonline.com",
+	InitialLookback: 1 * time.Hour,
+	PollFrequency:   1 * time.Minute,
+}
+
+// maxLookback is how far back the Management Activity API keeps content.
+const maxLookback = 7 * 24 * time.Hour
+
+// maxWindow is the longest time range content can be listed for at once.
+const maxWindow = 24 * time.Hour
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.TenantID == "":
+		return fmt.Errorf("tenant_id must not be empty")
+	case args.ClientID == "":
+		return fmt.Errorf("client_id must not be empty")
+	case len(args.ContentTypes) == 0:
+		return fmt.Errorf("content_types must not be empty")
+	case args.InitialLookback <= 0 || args.InitialLookback > maxLookback:
+		return fmt.Errorf("initial_lookback must be greater than 0 and at most %s", maxLookback)
+	case args.PollFrequency <= 0:
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	return nil
+}
+
+// Component implements the loki.source.microsoft365 component.
+type Component struct {
+	*auditlog.Component
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new loki.source.microsoft365 component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	inner, err := auditlog.New(o, "loki_source_microsoft365")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{Component: inner}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	labels := make(model.LabelSet, len(newArgs.Labels))
+	for k, v := range newArgs.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	c.Component.Update(auditlog.Arguments{
+		Fetcher:       newFetcher(newArgs),
+		PollFrequency: newArgs.PollFrequency,
+		Labels:        labels,
+		ForwardTo:     newArgs.ForwardTo,
+	})
+	return nil
+}
+
+// fetcher fetches audit records from the Office 365 Management Activity API.
+// Records are published in content blobs; the fetcher lists the blobs made
+// available since the cursor for each content type and fetches their records.
+type fetcher struct {
+	args Arguments
+	cli  *http.Client
+
+	started map[string]bool // Content types with a started subscription.
+}
+
+var _ auditlog.Fetcher = (*fetcher)(nil)
+
+func newFetcher(args Arguments) *fetcher {
+	// Tokens are requested with the client credentials of an Azure AD
+	// application, and are refreshed by the client as they expire.
+	creds := clientcredentials.Config{
+		ClientID:     args.ClientID,
+		ClientSecret: string(args.ClientSecret),
+		TokenURL:     strings.TrimSuffix(args.LoginURL, "/") + "/" + url.PathEscape(args.TenantID) + "/oauth2/v2.0/token",
+		Scopes:       []string{strings.TrimSuffix(args.URL, "/") + "/.default"},
+	}
+
+	return &fetcher{
+		args:    args,
+		cli:     creds.Client(context.Background()),
+		started: make(map[string]bool),
+	}
+}
+
+// contentCursor is the creation time of the newest fetched content blob of a
+// content type, along with the IDs of the blobs created at that time.
+type contentCursor struct {
+	Time time.Time `json:"time"`
+	IDs  []string  `json:"ids,omitempty"`
+}
+
+// content is a content blob listed by the API.
+type content struct {
+	ContentID      string    `json:"contentId"`
+	ContentURI     string    `json:"contentUri"`
+	ContentCreated time.Time `json:"contentCreated"`
+}
+
+// timeFormat is the format of times in requests to the API.
+const timeFormat = "2006-01-02T15:04:05"
+
+func (f *fetcher) Source() string {
+	return f.args.URL + "/" + f.args.TenantID
+}
+
+func (f *fetcher) Fetch(ctx context.Context, rawCursor string) (auditlog.Page, error) {
+	cursors := make(map[string]contentCursor)
+	if rawCursor != "" {
+		if err := json.Unmarshal([]byte(rawCursor), &cursors); err != nil {
+			return auditlog.Page{}, fmt.Errorf("decoding cursor: %w", err)
+		}
+	}
+
+	var (
+		page auditlog.Page
+		now  = time.Now().UTC()
+	)
+	for _, contentType := range f.args.ContentTypes {
+		if err := f.startSubscription(ctx, contentType); err != nil {
+			return auditlog.Page{}, err
+		}
+
+		cur, ok := cursors[contentType]
+		if !ok {
+			cur.Time = now.Add(-f.args.InitialLookback)
+		}
+		// Content older than the retention of the API can't be listed.
+		if oldest := now.Add(-maxLookback).Add(time.Minute); cur.Time.Before(oldest) {
+			cur = contentCursor{Time: oldest}
+		}
+
+		end := cur.Time.Add(maxWindow)
+		if end.After(now) {
+			end = now
+		} else {
+			page.More = true
+		}
+
+		records, next, err := f.fetchContent(ctx, contentType, cur, end)
+		if err != nil {
+			return auditlog.Page{}, err
+		}
+		if len(records) == 0 && end.Before(now) {
+			// Skip over windows without content.
+			next = contentCursor{Time: end}
+		}
+		page.Records = append(page.Records, records...)
+		cursors[contentType] = next
+	}
+
+	bb, err := json.Marshal(cursors)
+	if err != nil {
+		return auditlog.Page{}, fmt.Errorf("encoding cursor: %w", err)
+	}
+	page.Cursor = string(bb)
+	return page, nil
+}
+
+// startSubscription starts the subscription to contentType, which is required
+// before content can be listed.
+func (f *fetcher) startSubscription(ctx context.Context, contentType string) error {
+	if f.started[contentType] {
+		return nil
+	}
+
+	u := f.apiURL("subscriptions/start", url.Values{"contentType": {contentType}})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+
+	// AF20024 is returned when the subscription is already enabled.
+	_, _, err = auditlog.Do(f.cli, req)
+	var rateLimited *auditlog.RateLimitError
+	switch {
+	case errors.As(err, &rateLimited):
+		return err
+	case err != nil && !strings.Contains(err.Error(), "AF20024"):
+		return fmt.Errorf("starting subscription to %s: %w", contentType, err)
+	}
+
+	f.started[contentType] = true
+	return nil
+}
+
+// fetchContent fetches the records of the content blobs of contentType
+// created between the cursor and end.
+func (f *fetcher) fetchContent(ctx context.Context, contentType string, cur contentCursor, end time.Time) ([]auditlog.Record, contentCursor, error) {
+	fetched := make(map[string]struct{}, len(cur.IDs))
+	for _, id := range cur.IDs {
+		fetched[id] = struct{}{}
+	}
+
+	var (
+		records []auditlog.Record
+		next    = cur
+		listURL = f.apiURL("subscriptions/content", url.Values{
+			"contentType": {contentType},
+			"startTime":   {cur.Time.UTC().Format(timeFormat)},
+			"endTime":     {end.UTC().Format(timeFormat)},
+		})
+	)
+	for listURL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+		if err != nil {
+			return nil, next, fmt.Errorf("building request: %w", err)
+		}
+		body, header, err := auditlog.Do(f.cli, req)
+		if err != nil {
+			return nil, next, err
+		}
+
+		var contents []content
+		if err := json.Unmarshal(body, &contents); err != nil {
+			return nil, next, fmt.Errorf("decoding content list: %w", err)
+		}
+
+		for _, c := range contents {
+			// Blobs may be listed again on later pages or at the start of the
+			// next window.
+			if _, ok := fetched[c.ContentID]; ok {
+				continue
+			}
+			fetched[c.ContentID] = struct{}{}
+
+			blobRecords, err := f.fetchBlob(ctx, c.ContentURI)
+			if err != nil {
+				return nil, next, err
+			}
+			records = append(records, blobRecords...)
+
+			switch {
+			case c.ContentCreated.After(next.Time):
+				next = contentCursor{Time: c.ContentCreated, IDs: []string{c.ContentID}}
+			case c.ContentCreated.Equal(next.Time):
+				next.IDs = append(next.IDs, c.ContentID)
+			}
+		}
+
+		listURL = header.Get("NextPageUri")
+	}
+	return records, next, nil
+}
+
+// fetchBlob fetches the records of a content blob.
+func (f *fetcher) fetchBlob(ctx context.Context, contentURI string) ([]auditlog.Record, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, contentURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	body, _, err := auditlog.Do(f.cli, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []json.RawMessage
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("decoding content: %w", err)
+	}
+
+	records := make([]auditlog.Record, 0, len(events))
+	for _, event := range events {
+		var meta struct {
+			CreationTime string `json:"CreationTime"`
+		}
+		if err := json.Unmarshal(event, &meta); err != nil {
+			return nil, fmt.Errorf("decoding record: %w", err)
+		}
+		ts, err := time.Parse(timeFormat, meta.CreationTime)
+		if err != nil {
+			ts = time.Now()
+		}
+		records = append(records, auditlog.Record{
+			Line:      string(event),
+			Timestamp: ts,
+		})
+	}
+	return records, nil
+}
+
+func (f *fetcher) apiURL(path string, query url.Values) string {
+	u := strings.TrimSuffix(f.args.URL, "/") + "/api/v1.0/" + url.PathEscape(f.args.TenantID) + "/activity/feed/" + path
+	return u + "?" + query.Encode()
+}
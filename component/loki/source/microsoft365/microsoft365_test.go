@@ -0,0 +1,117 @@
+package microsoft365
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcher(t *testing.T) {
+	var (
+		created = time.Now().UTC().Add(-30 * time.Minute).Truncate(time.Second)
+		first   = created.Format(time.RFC3339)
+		second  = created.Add(5 * time.Minute).Format(time.RFC3339)
+
+		mut        sync.Mutex
+		tokens     int
+		subscribed []string
+	)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+
+		if r.URL.Path == "/login/tenant-1/oauth2/v2.0/token" {
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+			require.Equal(t, srv.URL+"/.default", r.Form.Get("scope"))
+			tokens++
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 3600}`, tokens)
+			return
+		}
+		require.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/api/v1.0/tenant-1/activity/feed/subscriptions/start":
+			contentType := r.URL.Query().Get("contentType")
+			subscribed = append(subscribed, contentType)
+			if contentType == "Audit.Exchange" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": {"code": "AF20024", "message": "The subscription is already enabled."}}`))
+			}
+		case "/api/v1.0/tenant-1/activity/feed/subscriptions/content":
+			if r.URL.Query().Get("contentType") != "Audit.General" {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("NextPageUri", srv.URL+r.URL.String()+"&page=2")
+				_, _ = fmt.Fprintf(w, `[{"contentId": "a", "contentUri": "%s/blob/a", "contentCreated": %q}]`, srv.URL, first)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `[
+				{"contentId": "a", "contentUri": "%[1]s/blob/a", "contentCreated": %[2]q},
+				{"contentId": "b", "contentUri": "%[1]s/blob/b", "contentCreated": %[3]q}
+			]`, srv.URL, first, second)
+		case "/blob/a":
+			_, _ = w.Write([]byte(`[{"Id": "1", "CreationTime": "2023-04-01T09:59:00"}]`))
+		case "/blob/b":
+			_, _ = w.Write([]byte(`[{"Id": "2", "CreationTime": "2023-04-01T10:04:00"}, {"Id": "3", "CreationTime": "2023-04-01T10:04:30"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		tenant_id     = "tenant-1"
+		client_id     = "client-1"
+		client_secret = "secret"
+		content_types = ["Audit.Exchange", "Audit.General"]
+		url           = "`+srv.URL+`"
+		login_url     = "`+srv.URL+`/login"
+		forward_to    = []
+	`), &args))
+
+	f := newFetcher(args)
+	page, err := f.Fetch(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Audit.Exchange", "Audit.General"}, subscribed)
+	require.Equal(t, 1, tokens)
+
+	// Blobs are only fetched once, even if they're listed on multiple pages.
+	require.Len(t, page.Records, 3)
+	require.JSONEq(t, `{"Id": "1", "CreationTime": "2023-04-01T09:59:00"}`, page.Records[0].Line)
+	require.Equal(t, time.Date(2023, 4, 1, 9, 59, 0, 0, time.UTC), page.Records[0].Timestamp)
+	require.False(t, page.More)
+
+	var cursors map[string]contentCursor
+	require.NoError(t, json.Unmarshal([]byte(page.Cursor), &cursors))
+	require.Equal(t, []string{"b"}, cursors["Audit.General"].IDs)
+	require.Equal(t, created.Add(5*time.Minute), cursors["Audit.General"].Time.UTC())
+	require.Contains(t, cursors, "Audit.Exchange")
+}
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		tenant_id        = "tenant-1"
+		client_id        = "client-1"
+		client_secret    = "secret"
+		initial_lookback = "240h"
+		forward_to       = []
+	`), &args)
+	require.EqualError(t, err, "initial_lookback must be greater than 0 and at most 168h0m0s")
+}
@@ -0,0 +1,227 @@
+// Package okta implements the loki.source.okta component.
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/source/internal/auditlog"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.okta",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the loki.source.okta
+// component.
+type Arguments struct {
+	URL             string              `river:"url,attr"`
+	APIToken        rivertypes.Secret   `river:"api_token,attr,optional"`
+	Filter          string              `river:"filter,attr,optional"`
+	InitialLookback time.Duration       `river:"initial_lookback,attr,optional"`
+	PageSize        int                 `river:"page_size,attr,optional"`
+	PollFrequency   time.Duration       `river:"poll_frequency,attr,optional"`
+	Labels          map[string]string   `river:"labels,attr,optional"`
+	ForwardTo       []loki.LogsReceiver `river:"forward_to,attr"`
+
+	Client config.HTTPClientConfig `river:"client,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	InitialLookback: 1 * time.Hour,
+	PageSize:        1000,
+	PollFrequency:   1 * time.Minute,
+	Client:          config.DefaultHTTPClientConfig,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.InitialLookback <= 0:
+		return fmt.Errorf("initial_lookback must be greater than 0")
+	case args.PageSize <= 0 || args.PageSize > 1000:
+		return fmt.Errorf("page_size must be between 1 and 1000")
+	case args.PollFrequency <= 0:
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	if _, err := url.Parse(args.URL); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	return args.Client.Validate()
+}
+
+// Component implements the loki.source.okta component.
+type Component struct {
+	*auditlog.Component
+	opts component.Options
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new loki.source.okta component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	inner, err := auditlog.New(o, "loki_source_okta")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{Component: inner, opts: o}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	cli, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		c.opts.ID,
+		prom_config.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return err
+	}
+
+	labels := make(model.LabelSet, len(newArgs.Labels))
+	for k, v := range newArgs.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	c.Component.Update(auditlog.Arguments{
+		Fetcher:       &fetcher{args: newArgs, cli: cli},
+		PollFrequency: newArgs.PollFrequency,
+		Labels:        labels,
+		ForwardTo:     newArgs.ForwardTo,
+	})
+	return nil
+}
+
+// fetcher fetches events from the Okta System Log API. The cursor is the
+// "next" link returned by the API, which keeps pointing to the events
+// following the last returned event once no more events are available.
+type fetcher struct {
+	args Arguments
+	cli  *http.Client
+}
+
+var _ auditlog.Fetcher = (*fetcher)(nil)
+
+func (f *fetcher) Source() string {
+	return f.args.URL + "?filter=" + f.args.Filter
+}
+
+func (f *fetcher) Fetch(ctx context.Context, cursor string) (auditlog.Page, error) {
+	reqURL := cursor
+	if reqURL == "" {
+		u, err := url.Parse(f.args.URL)
+		if err != nil {
+			return auditlog.Page{}, err
+		}
+		u = u.JoinPath("/api/v1/logs")
+
+		q := u.Query()
+		q.Set("since", time.Now().Add(-f.args.InitialLookback).UTC().Format(time.RFC3339))
+		q.Set("sortOrder", "ASCENDING")
+		q.Set("limit", strconv.Itoa(f.args.PageSize))
+		if f.args.Filter != "" {
+			q.Set("filter", f.args.Filter)
+		}
+		u.RawQuery = q.Encode()
+		reqURL = u.String()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return auditlog.Page{}, fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.args.APIToken != "" {
+		req.Header.Set("Authorization", "SSWS "+string(f.args.APIToken))
+	}
+
+	body, header, err := auditlog.Do(f.cli, req)
+	if err != nil {
+		return auditlog.Page{}, err
+	}
+
+	var events []json.RawMessage
+	if err := json.Unmarshal(body, &events); err != nil {
+		return auditlog.Page{}, fmt.Errorf("decoding events: %w", err)
+	}
+
+	page := auditlog.Page{
+		Records: make([]auditlog.Record, 0, len(events)),
+		Cursor:  nextLink(header),
+		More:    len(events) >= f.args.PageSize,
+		Wait:    auditlog.RateLimitWait(header, time.Now()),
+	}
+	for _, event := range events {
+		var meta struct {
+			Published time.Time `json:"published"`
+		}
+		if err := json.Unmarshal(event, &meta); err != nil {
+			return auditlog.Page{}, fmt.Errorf("decoding event: %w", err)
+		}
+		page.Records = append(page.Records, auditlog.Record{
+			Line:      string(event),
+			Timestamp: meta.Published,
+		})
+	}
+	if page.Cursor == "" {
+		// Without a next link, the same request has to be repeated.
+		page.Cursor = reqURL
+		page.More = false
+	}
+	return page, nil
+}
+
+var linkRegexp = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextLink returns the URL of the "next" link in the Link headers of h.
+func nextLink(h http.Header) string {
+	for _, link := range h.Values("Link") {
+		if m := linkRegexp.FindStringSubmatch(link); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/river"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcher(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/logs", r.URL.Path)
+		require.Equal(t, "SSWS secret-token", r.Header.Get("Authorization"))
+
+		switch r.URL.Query().Get("after") {
+		case "":
+			require.Equal(t, "ASCENDING", r.URL.Query().Get("sortOrder"))
+			require.Equal(t, `eventType eq "user.session.start"`, r.URL.Query().Get("filter"))
+			require.NotEmpty(t, r.URL.Query().Get("since"))
+
+			w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/logs?limit=2>; rel="self"`, srv.URL))
+			w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/logs?after=2&limit=2>; rel="next"`, srv.URL))
+			_, _ = w.Write([]byte(`[
+				{"uuid": "1", "published": "2023-04-01T10:00:00.000Z"},
+				{"uuid": "2", "published": "2023-04-01T10:01:00.000Z"}
+			]`))
+		case "2":
+			w.Header().Set("X-Rate-Limit-Remaining", "0")
+			w.Header().Set("X-Rate-Limit-Reset", fmt.Sprint(time.Now().Add(time.Minute).Unix()))
+			w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/logs?after=3&limit=2>; rel="next"`, srv.URL))
+			_, _ = w.Write([]byte(`[{"uuid": "3", "published": "2023-04-01T10:02:00.000Z"}]`))
+		}
+	}))
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		url        = "`+srv.URL+`"
+		api_token  = "secret-token"
+		filter     = "eventType eq \"user.session.start\""
+		page_size  = 2
+		forward_to = []
+	`), &args))
+
+	cli, err := prom_config.NewClientFromConfig(*config.DefaultHTTPClientConfig.Convert(), "test")
+	require.NoError(t, err)
+	f := &fetcher{args: args, cli: cli}
+
+	page, err := f.Fetch(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.Equal(t, time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC), page.Records[0].Timestamp)
+	require.Equal(t, srv.URL+"/api/v1/logs?after=2&limit=2", page.Cursor)
+	require.True(t, page.More)
+	require.Zero(t, page.Wait)
+
+	// A partial page means no more events are available yet, and the rate
+	// limit headers are respected.
+	page, err = f.Fetch(context.Background(), page.Cursor)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.JSONEq(t, `{"uuid": "3", "published": "2023-04-01T10:02:00.000Z"}`, page.Records[0].Line)
+	require.Equal(t, srv.URL+"/api/v1/logs?after=3&limit=2", page.Cursor)
+	require.False(t, page.More)
+	require.Greater(t, page.Wait, 50*time.Second)
+}
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		url        = "https://example.okta.com"
+		page_size  = 5000
+		forward_to = []
+	`), &args)
+	require.EqualError(t, err, "page_size must be between 1 and 1000")
+}
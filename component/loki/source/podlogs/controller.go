@@ -23,7 +23,7 @@ type controller struct {
 	reconciler *reconciler
 
 	mut       sync.RWMutex
-	informers cache.Informers
+	informers cache.Informers // nil when only watching specific namespaces.
 	client    client.Client
 	reloadCh  chan struct{} // Written to when informers or client changes
 
@@ -34,6 +34,10 @@ type controller struct {
 // Generous timeout period for configuring all informers
 const informerSyncTimeout = 10 * time.Second
 
+// How often to reconcile when only watching specific namespaces. Informers
+// aren't used in that mode, so changes are only picked up by polling.
+const namespacedResyncInterval = 30 * time.Second
+
 // newController creates a new, unstarted controller. The controller will
 // request a reconcile when the state of Kubernetes changes.
 func newController(l log.Logger, reconciler *reconciler) *controller {
@@ -47,7 +51,10 @@ func newController(l log.Logger, reconciler *reconciler) *controller {
 	}
 }
 
-func (ctrl *controller) UpdateConfig(cfg *rest.Config) error {
+// UpdateConfig updates the Kubernetes config used by the controller. If
+// namespaces is non-empty, the controller only reads objects from those
+// namespaces and doesn't require cluster-wide permissions.
+func (ctrl *controller) UpdateConfig(cfg *rest.Config, namespaces []string) error {
 	scheme := runtime.NewScheme()
 	for _, add := range []func(*runtime.Scheme) error{
 		corev1.AddToScheme,
@@ -63,6 +70,19 @@ func (ctrl *controller) UpdateConfig(cfg *rest.Config) error {
 		return err
 	}
 
+	// Informers need cluster-wide list and watch permissions, so they can't be
+	// used when only specific namespaces may be read. Read from the API
+	// directly instead.
+	if len(namespaces) > 0 {
+		ctrl.mut.Lock()
+		ctrl.informers = nil
+		ctrl.client = cli
+		ctrl.mut.Unlock()
+
+		ctrl.scheduleReload()
+		return nil
+	}
+
 	cache, err := cache.New(cfg, cache.Options{Scheme: scheme})
 	if err != nil {
 		return err
@@ -82,20 +102,21 @@ func (ctrl *controller) UpdateConfig(cfg *rest.Config) error {
 	ctrl.client = delegateCli
 	ctrl.mut.Unlock()
 
+	ctrl.scheduleReload()
+	return nil
+}
+
+func (ctrl *controller) scheduleReload() {
 	select {
 	case ctrl.reloadCh <- struct{}{}:
 	default:
 		// Reload is already scheduled
 	}
-	return nil
 }
 
 // Run the controller.
 func (ctrl *controller) Run(ctx context.Context) error {
-	var (
-		cancel    context.CancelFunc
-		informers cache.Informers
-	)
+	var cancel context.CancelFunc
 
 	for {
 		select {
@@ -110,7 +131,7 @@ func (ctrl *controller) Run(ctx context.Context) error {
 			ctrl.mut.RUnlock()
 
 			// Stop old informers.
-			if informers != nil {
+			if cancel != nil {
 				cancel()
 			}
 
@@ -123,7 +144,6 @@ func (ctrl *controller) Run(ctx context.Context) error {
 			}()
 
 			cancel = informerCancel
-			informers = newInformers
 		}
 	}
 }
@@ -132,6 +152,10 @@ func (ctrl *controller) run(ctx context.Context, informers cache.Informers, clie
 	level.Info(ctrl.log).Log("msg", "starting controller")
 	defer level.Info(ctrl.log).Log("msg", "controller exiting")
 
+	if informers == nil {
+		return ctrl.runNamespaced(ctx, client)
+	}
+
 	go func() {
 		err := informers.Start(ctx)
 		if err != nil && ctx.Err() != nil {
@@ -159,6 +183,28 @@ func (ctrl *controller) run(ctx context.Context, informers cache.Informers, clie
 	}
 }
 
+// runNamespaced reconciles periodically rather than in response to changes
+// reported by informers.
+func (ctrl *controller) runNamespaced(ctx context.Context, client client.Client) error {
+	ticker := time.NewTicker(namespacedResyncInterval)
+	defer ticker.Stop()
+
+	ctrl.RequestReconcile()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			ctrl.RequestReconcile()
+		case <-ctrl.reconcileCh:
+			if err := ctrl.reconciler.Reconcile(ctx, client); err != nil {
+				level.Error(ctrl.log).Log("msg", "reconcile failed", "err", err)
+			}
+		}
+	}
+}
+
 // configureInformers starts the informers used by this controller to perform reconciles.
 func (ctrl *controller) configureInformers(ctx context.Context, informers cache.Informers) error {
 	// We want to re-reconcile the set of PodLogs whenever namespaces, pods, or
@@ -32,6 +32,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki", "github.com/prometheus-operator/prometheus-operator"},
 	})
 }
 
@@ -45,6 +47,10 @@ type Arguments struct {
 
 	Selector          config.LabelSelector `river:"selector,block,optional"`
 	NamespaceSelector config.LabelSelector `river:"namespace_selector,block,optional"`
+
+	// Namespaces limits discovery to the given namespaces so that only
+	// namespace-scoped permissions are required.
+	Namespaces []string `river:"namespaces,attr,optional"`
 }
 
 var _ river.Unmarshaler = (*Arguments)(nil)
@@ -61,7 +67,16 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*args = DefaultArguments
 
 	type arguments Arguments
-	return f((*arguments)(args))
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	for _, ns := range args.Namespaces {
+		if ns == "" {
+			return fmt.Errorf("namespaces must not contain empty strings")
+		}
+	}
+	return nil
 }
 
 // Component implements the loki.source.podlogs component.
@@ -99,6 +114,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	positionsFile, err := positions.New(o.Logger, positions.Config{
 		SyncPeriod:    10 * time.Second,
 		PositionsFile: filepath.Join(o.DataPath, "positions.yml"),
+		DiskBudget:    o.DiskBudget,
 	})
 	if err != nil {
 		return nil, err
@@ -247,8 +263,9 @@ func (c *Component) updateReconciler(args Arguments) error {
 	var (
 		selectorChanged          = !reflect.DeepEqual(c.args.Selector, args.Selector)
 		namespaceSelectorChanged = !reflect.DeepEqual(c.args.NamespaceSelector, args.NamespaceSelector)
+		namespacesChanged        = !reflect.DeepEqual(c.args.Namespaces, args.Namespaces)
 	)
-	if !selectorChanged && !namespaceSelectorChanged {
+	if !selectorChanged && !namespaceSelectorChanged && !namespacesChanged {
 		return nil
 	}
 
@@ -262,6 +279,7 @@ func (c *Component) updateReconciler(args Arguments) error {
 	}
 
 	c.reconciler.UpdateSelectors(sel, nsSel)
+	c.reconciler.UpdateNamespaces(args.Namespaces)
 
 	// Request a reconcile so the new selectors get applied.
 	c.controller.RequestReconcile()
@@ -272,8 +290,9 @@ func (c *Component) updateReconciler(args Arguments) error {
 // called after updateReconciler. mut must be held when calling.
 func (c *Component) updateController(args Arguments) error {
 	// We only need to update the controller if we already have a rest config
-	// generated and our client args haven't changed since the last call.
-	if reflect.DeepEqual(c.args.Client, args.Client) && c.restConfig != nil {
+	// generated and our client args and namespaces haven't changed since the
+	// last call.
+	if reflect.DeepEqual(c.args.Client, args.Client) && reflect.DeepEqual(c.args.Namespaces, args.Namespaces) && c.restConfig != nil {
 		return nil
 	}
 
@@ -283,7 +302,7 @@ func (c *Component) updateController(args Arguments) error {
 	}
 	c.restConfig = cfg
 
-	return c.controller.UpdateConfig(cfg)
+	return c.controller.UpdateConfig(cfg, args.Namespaces)
 }
 
 // DebugInfo returns debug information for loki.source.podlogs.
@@ -291,6 +310,7 @@ func (c *Component) DebugInfo() interface{} {
 	var info DebugInfo
 
 	info.DiscoveredPodLogs = c.reconciler.DebugInfo()
+	info.InaccessibleNamespaces = c.reconciler.InaccessibleNamespaces()
 
 	for _, target := range c.tailer.Targets() {
 		var lastError string
@@ -311,6 +331,7 @@ func (c *Component) DebugInfo() interface{} {
 
 // DebugInfo stores debug information for loki.source.podlogs.
 type DebugInfo struct {
-	DiscoveredPodLogs []DiscoveredPodLogs          `river:"pod_logs,block"`
-	Targets           []kubernetes.DebugInfoTarget `river:"target,block,optional"`
+	InaccessibleNamespaces []string                     `river:"inaccessible_namespaces,attr,optional"`
+	DiscoveredPodLogs      []DiscoveredPodLogs          `river:"pod_logs,block"`
+	Targets                []kubernetes.DebugInfoTarget `river:"target,block,optional"`
 }
@@ -17,8 +17,11 @@ import (
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/util/strutil"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -31,9 +34,11 @@ type reconciler struct {
 	reconcileMut             sync.RWMutex
 	podLogsSelector          labels.Selector
 	podLogsNamespaceSelector labels.Selector
+	namespaces               []string // Namespaces to read from; empty for all namespaces.
 
-	debugMut  sync.RWMutex
-	debugInfo []DiscoveredPodLogs
+	debugMut               sync.RWMutex
+	debugInfo              []DiscoveredPodLogs
+	inaccessibleNamespaces []string
 }
 
 // newReconciler creates a new reconciler which synchronizes targets with the
@@ -57,17 +62,32 @@ func (r *reconciler) UpdateSelectors(podLogs, namespace labels.Selector) {
 	r.podLogsNamespaceSelector = namespace
 }
 
+// UpdateNamespaces updates the set of namespaces which the reconciler reads
+// PodLogs and Pods from. An empty set reads from all namespaces.
+func (r *reconciler) UpdateNamespaces(namespaces []string) {
+	r.reconcileMut.Lock()
+	defer r.reconcileMut.Unlock()
+
+	r.namespaces = namespaces
+}
+
 // Reconcile synchronizes the set of running kubetail targets with the set of
 // discovered PodLogs.
 func (r *reconciler) Reconcile(ctx context.Context, cli client.Client) error {
+	r.reconcileMut.RLock()
+	defer r.reconcileMut.RUnlock()
+
 	var newDebugInfo []DiscoveredPodLogs
 	var newTasks []*kubetail.Target
 
-	listOpts := []client.ListOption{
-		client.MatchingLabelsSelector{Selector: r.podLogsSelector},
-	}
+	// Namespaces which can't be read from are skipped rather than failing the
+	// whole reconcile so that logs are still collected from the namespaces
+	// the agent has access to.
+	inaccessible := make(map[string]struct{})
+
 	var podLogsList monitoringv1alpha2.PodLogsList
-	if err := cli.List(ctx, &podLogsList, listOpts...); err != nil {
+	err := r.listInNamespaces(ctx, cli, &podLogsList, inaccessible, client.MatchingLabelsSelector{Selector: r.podLogsSelector})
+	if err != nil {
 		return fmt.Errorf("could not list PodLogs: %w", err)
 	}
 
@@ -75,8 +95,8 @@ func (r *reconciler) Reconcile(ctx context.Context, cli client.Client) error {
 		key := client.ObjectKeyFromObject(podLogs)
 
 		// Skip over this podLogs if it doesn't match the namespace selector.
-		podLogsNamespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: podLogs.Namespace}}
-		if err := cli.Get(ctx, client.ObjectKeyFromObject(&podLogsNamespace), &podLogsNamespace); err != nil {
+		podLogsNamespace, err := getNamespace(ctx, cli, podLogs.Namespace)
+		if err != nil {
 			level.Error(r.log).Log("msg", "failed to reconcile PodLogs", "operation", "get namespace", "key", key, "err", err)
 			continue
 		}
@@ -84,7 +104,7 @@ func (r *reconciler) Reconcile(ctx context.Context, cli client.Client) error {
 			continue
 		}
 
-		targets, discoveredPodLogs := r.reconcilePodLogs(ctx, cli, podLogs)
+		targets, discoveredPodLogs := r.reconcilePodLogs(ctx, cli, podLogs, inaccessible)
 
 		newTasks = append(newTasks, targets...)
 		newDebugInfo = append(newDebugInfo, discoveredPodLogs)
@@ -94,14 +114,60 @@ func (r *reconciler) Reconcile(ctx context.Context, cli client.Client) error {
 		level.Error(r.log).Log("msg", "failed to apply new tailers to run", "err", err)
 	}
 
+	inaccessibleNamespaces := make([]string, 0, len(inaccessible))
+	for ns := range inaccessible {
+		inaccessibleNamespaces = append(inaccessibleNamespaces, ns)
+	}
+	sort.Strings(inaccessibleNamespaces)
+
 	r.debugMut.Lock()
 	r.debugInfo = newDebugInfo
+	r.inaccessibleNamespaces = inaccessibleNamespaces
 	r.debugMut.Unlock()
 
 	return nil
 }
 
-func (r *reconciler) reconcilePodLogs(ctx context.Context, cli client.Client, podLogs *monitoringv1alpha2.PodLogs) ([]*kubetail.Target, DiscoveredPodLogs) {
+// listInNamespaces lists objects into list. If the reconciler is limited to a
+// set of namespaces, objects are listed from each namespace in turn and the
+// results are concatenated; namespaces which can't be listed are logged and
+// added to inaccessible. reconcileMut must be held when calling.
+func (r *reconciler) listInNamespaces(ctx context.Context, cli client.Client, list client.ObjectList, inaccessible map[string]struct{}, opts ...client.ListOption) error {
+	if len(r.namespaces) == 0 {
+		return cli.List(ctx, list, opts...)
+	}
+
+	var items []runtime.Object
+	for _, ns := range r.namespaces {
+		nsList := list.DeepCopyObject().(client.ObjectList)
+		if err := cli.List(ctx, nsList, append(opts, client.InNamespace(ns))...); err != nil {
+			level.Warn(r.log).Log("msg", "skipping namespace which could not be read", "namespace", ns, "err", err)
+			inaccessible[ns] = struct{}{}
+			continue
+		}
+
+		nsItems, err := meta.ExtractList(nsList)
+		if err != nil {
+			return err
+		}
+		items = append(items, nsItems...)
+	}
+	return meta.SetList(list, items)
+}
+
+// getNamespace gets the namespace called name. Reading namespaces requires
+// cluster-wide permissions; if the agent isn't allowed to read the namespace,
+// a namespace without any labels or annotations is returned instead.
+func getNamespace(ctx context.Context, cli client.Client, name string) (*corev1.Namespace, error) {
+	namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	err := cli.Get(ctx, client.ObjectKeyFromObject(&namespace), &namespace)
+	if apierrors.IsForbidden(err) {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+	}
+	return &namespace, err
+}
+
+func (r *reconciler) reconcilePodLogs(ctx context.Context, cli client.Client, podLogs *monitoringv1alpha2.PodLogs, inaccessible map[string]struct{}) ([]*kubetail.Target, DiscoveredPodLogs) {
 	var targets []*kubetail.Target
 
 	discoveredPodLogs := DiscoveredPodLogs{
@@ -127,12 +193,8 @@ func (r *reconciler) reconcilePodLogs(ctx context.Context, cli client.Client, po
 		return targets, discoveredPodLogs
 	}
 
-	opts := []client.ListOption{
-		client.MatchingLabelsSelector{Selector: sel},
-	}
-
 	var podList corev1.PodList
-	if err := cli.List(ctx, &podList, opts...); err != nil {
+	if err := r.listInNamespaces(ctx, cli, &podList, inaccessible, client.MatchingLabelsSelector{Selector: sel}); err != nil {
 		discoveredPodLogs.ReconcileError = fmt.Sprintf("failed to list Pods: %s", err)
 		level.Error(r.log).Log("msg", "failed to reconcile PodLogs", "operation", "list Pods", "key", key, "err", err)
 		return targets, discoveredPodLogs
@@ -152,8 +214,8 @@ func (r *reconciler) reconcilePodLogs(ctx context.Context, cli client.Client, po
 		}
 
 		// Skip over this pod if it doesn't match the namespace selector.
-		namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
-		if err := cli.Get(ctx, client.ObjectKeyFromObject(&namespace), &namespace); err != nil {
+		namespace, err := getNamespace(ctx, cli, pod.Namespace)
+		if err != nil {
 			level.Error(r.log).Log("msg", "failed to reconcile PodLogs", "operation", "get namespace for Pod", "key", key, "err", err)
 			continue
 		}
@@ -166,7 +228,7 @@ func (r *reconciler) reconcilePodLogs(ctx context.Context, cli client.Client, po
 		handleContainer := func(container *corev1.Container, initContainer bool) {
 			targetLabels := buildTargetLabels(discoveredContainer{
 				PodLogs:       podLogs,
-				PodNamespace:  &namespace,
+				PodNamespace:  namespace,
 				Pod:           &pod,
 				Container:     container,
 				InitContainer: initContainer,
@@ -217,6 +279,15 @@ func (r *reconciler) DebugInfo() []DiscoveredPodLogs {
 	return r.debugInfo
 }
 
+// InaccessibleNamespaces returns the namespaces which couldn't be read during
+// the most recent reconcile.
+func (r *reconciler) InaccessibleNamespaces() []string {
+	r.debugMut.RLock()
+	defer r.debugMut.RUnlock()
+
+	return r.inaccessibleNamespaces
+}
+
 type discoveredContainer struct {
 	PodLogs       *monitoringv1alpha2.PodLogs
 	PodNamespace  *corev1.Namespace
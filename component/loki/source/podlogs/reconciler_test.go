@@ -0,0 +1,80 @@
+package podlogs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/loki/source/kubernetes/kubetail"
+	monitoringv1alpha2 "github.com/grafana/agent/component/loki/source/podlogs/internal/apis/monitoring/v1alpha2"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// namespacedClient is a client.Client which is only allowed to read objects
+// from a set of namespaces, mimicking namespace-scoped RBAC.
+type namespacedClient struct {
+	client.Client
+	allowed map[string]struct{}
+}
+
+func (c *namespacedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		return apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, key.Name, nil)
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *namespacedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	var listOpts client.ListOptions
+	listOpts.ApplyOptions(opts)
+	if _, ok := c.allowed[listOpts.Namespace]; !ok {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", nil)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestReconciler_Namespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, monitoringv1alpha2.AddToScheme(scheme))
+
+	objects := []client.Object{
+		&monitoringv1alpha2.PodLogs{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "logs"}},
+		&monitoringv1alpha2.PodLogs{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "logs"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "app"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+	}
+	cli := &namespacedClient{
+		Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		allowed: map[string]struct{}{"team-a": {}},
+	}
+
+	tailer := kubetail.NewManager(log.NewNopLogger(), nil)
+	defer tailer.Stop()
+
+	r := newReconciler(log.NewNopLogger(), tailer)
+	r.UpdateNamespaces([]string{"team-a", "team-b"})
+	require.NoError(t, r.Reconcile(context.Background(), cli))
+
+	// PodLogs and Pods are discovered in team-a even though team-b and the
+	// namespaces themselves can't be read.
+	info := r.DebugInfo()
+	require.Len(t, info, 1)
+	require.Equal(t, "team-a", info[0].Namespace)
+	require.Len(t, info[0].Pods, 1)
+	require.Equal(t, "app", info[0].Pods[0].Name)
+	require.Equal(t, []string{"team-b"}, r.InaccessibleNamespaces())
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	st "github.com/grafana/agent/component/loki/source/syslog/internal/syslogtarget"
+	"github.com/grafana/agent/pkg/util/netwatch"
 	"github.com/prometheus/prometheus/model/relabel"
 )
 
@@ -21,6 +22,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -37,10 +40,16 @@ type Component struct {
 	opts    component.Options
 	metrics *st.Metrics
 
-	mut     sync.RWMutex
-	args    Arguments
-	fanout  []loki.LogsReceiver
-	targets []*st.SyslogTarget
+	mut    sync.RWMutex
+	fanout []loki.LogsReceiver
+
+	// listenersMut is separate from mut so that entries can still be
+	// forwarded while listeners are being stopped, which waits for their
+	// pending entries to be forwarded.
+	listenersMut sync.Mutex
+	args         Arguments
+	targets      []*st.SyslogTarget
+	netState     netwatch.State // State of the listen addresses when the listeners were started.
 
 	handler loki.LogsReceiver
 }
@@ -68,6 +77,8 @@ func New(o component.Options, args Arguments) (*Component, error) {
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		level.Info(c.opts.Logger).Log("msg", "loki.source.syslog component shutting down, stopping listeners")
+		c.listenersMut.Lock()
+		defer c.listenersMut.Unlock()
 		for _, l := range c.targets {
 			err := l.Stop()
 			if err != nil {
@@ -76,10 +87,34 @@ func (c *Component) Run(ctx context.Context) error {
 		}
 	}()
 
+	netChanges, unsubscribe := netwatch.Default().Subscribe()
+	defer unsubscribe()
+
+	// restartDone is non-nil while listeners are being restarted after a
+	// network change. Restarts happen in the background since stopping a
+	// listener waits for its pending entries to be read from c.handler.
+	var restartDone chan struct{}
+
 	for {
+		pendingNetChanges := netChanges
+		if restartDone != nil {
+			pendingNetChanges = nil // Don't start another restart until this one is done.
+		}
+
 		select {
 		case <-ctx.Done():
+			if restartDone != nil {
+				drainUntil(c.handler, restartDone)
+			}
 			return nil
+		case <-pendingNetChanges:
+			restartDone = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				c.restartOnNetworkChange()
+			}(restartDone)
+		case <-restartDone:
+			restartDone = nil
 		case entry := <-c.handler:
 			c.mut.RLock()
 			for _, receiver := range c.fanout {
@@ -92,11 +127,14 @@ func (c *Component) Run(ctx context.Context) error {
 
 // Update implements component.Component.
 func (c *Component) Update(args component.Arguments) error {
-	c.mut.Lock()
-	defer c.mut.Unlock()
-
 	newArgs := args.(Arguments)
+
+	c.mut.Lock()
 	c.fanout = newArgs.ForwardTo
+	c.mut.Unlock()
+
+	c.listenersMut.Lock()
+	defer c.listenersMut.Unlock()
 
 	var rcs []*relabel.Config
 	if newArgs.RelabelRules != nil && len(newArgs.RelabelRules) > 0 {
@@ -104,32 +142,69 @@ func (c *Component) Update(args component.Arguments) error {
 	}
 
 	if listenersChanged(c.args.SyslogListeners, newArgs.SyslogListeners) || relabelRulesChanged(c.args.RelabelRules, newArgs.RelabelRules) {
-		for _, l := range c.targets {
-			err := l.Stop()
-			if err != nil {
-				level.Error(c.opts.Logger).Log("msg", "error while stopping syslog listener", "err", err)
-			}
-		}
-		c.targets = make([]*st.SyslogTarget, 0)
-		entryHandler := loki.NewEntryHandler(c.handler, func() {})
+		c.startListeners(newArgs.SyslogListeners, rcs)
+		c.args = newArgs
+	}
 
-		for _, cfg := range newArgs.SyslogListeners {
-			t, err := st.NewSyslogTarget(c.metrics, c.opts.Logger, entryHandler, rcs, cfg.Convert())
-			if err != nil {
-				level.Error(c.opts.Logger).Log("msg", "failed to create syslog listener with provided config", "err", err)
-				continue
-			}
-			c.targets = append(c.targets, t)
+	return nil
+}
+
+// startListeners stops all running listeners and starts new listeners for
+// listeners. listenersMut must be held when calling.
+func (c *Component) startListeners(listeners []ListenerConfig, rcs []*relabel.Config) {
+	for _, l := range c.targets {
+		err := l.Stop()
+		if err != nil {
+			level.Error(c.opts.Logger).Log("msg", "error while stopping syslog listener", "err", err)
+		}
+	}
+	c.targets = make([]*st.SyslogTarget, 0)
+	c.netState = netwatch.Default().State(listenAddresses(listeners))
+	entryHandler := loki.NewEntryHandler(c.handler, func() {})
+
+	for _, cfg := range listeners {
+		t, err := st.NewSyslogTarget(c.metrics, c.opts.Logger, entryHandler, rcs, cfg.Convert())
+		if err != nil {
+			level.Error(c.opts.Logger).Log("msg", "failed to create syslog listener with provided config", "err", err)
+			continue
 		}
+		c.targets = append(c.targets, t)
+	}
+}
 
-		c.args = newArgs
+// restartOnNetworkChange restarts all listeners if one of their specific
+// listen addresses was added to or removed from the host since they were
+// started.
+func (c *Component) restartOnNetworkChange() {
+	c.listenersMut.Lock()
+	defer c.listenersMut.Unlock()
+
+	if netwatch.Default().State(listenAddresses(c.args.SyslogListeners)) == c.netState {
+		return
 	}
 
-	return nil
+	level.Info(c.opts.Logger).Log("msg", "listen addresses changed, restarting syslog listeners")
+
+	var rcs []*relabel.Config
+	if len(c.args.RelabelRules) > 0 {
+		rcs = flow_relabel.ComponentToPromRelabelConfigs(c.args.RelabelRules)
+	}
+	c.startListeners(c.args.SyslogListeners, rcs)
+}
+
+func listenAddresses(listeners []ListenerConfig) []string {
+	addrs := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		addrs = append(addrs, l.ListenAddress)
+	}
+	return addrs
 }
 
 // DebugInfo returns information about the status of listeners.
 func (c *Component) DebugInfo() interface{} {
+	c.listenersMut.Lock()
+	defer c.listenersMut.Unlock()
+
 	var res readerDebugInfo
 
 	for _, t := range c.targets {
@@ -154,6 +229,17 @@ type listenerInfo struct {
 	Labels        string `river:"labels,attr"`
 }
 
+// drainUntil discards entries from ch until done is closed.
+func drainUntil(ch loki.LogsReceiver, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-ch:
+		}
+	}
+}
+
 func listenersChanged(prev, next []ListenerConfig) bool {
 	return !reflect.DeepEqual(prev, next)
 }
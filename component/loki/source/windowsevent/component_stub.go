@@ -19,6 +19,8 @@ func init() {
 			level.Info(opts.Logger).Log("msg", "loki.source.windowsevent only works on windows platforms")
 			return &FakeComponent{}, nil
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 }
 
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// Supported Azure clouds.
+const (
+	AzurePublic     = "AzurePublic"
+	AzureChina      = "AzureChina"
+	AzureGovernment = "AzureGovernment"
+)
+
+// azureADRefreshBefore is how long before a token expires that it is
+// refreshed.
+const azureADRefreshBefore = 5 * time.Minute
+
+// AzureADConfig configures authenticating requests with an Azure AD token.
+type AzureADConfig struct {
+	// Cloud is the Azure cloud to request tokens from.
+	Cloud string `yaml:"cloud,omitempty"`
+	// Scope is the scope tokens are requested for, typically the application
+	// ID URI of the Loki deployment followed by "/.default".
+	Scope string `yaml:"scope"`
+
+	// ManagedIdentity and OAuth are mutually exclusive.
+	ManagedIdentity *AzureADManagedIdentityConfig `yaml:"managed_identity,omitempty"`
+	OAuth           *AzureADOAuthConfig           `yaml:"oauth,omitempty"`
+}
+
+// AzureADManagedIdentityConfig authenticates with an Azure managed identity.
+type AzureADManagedIdentityConfig struct {
+	// ClientID of the user-assigned managed identity. If empty, the system
+	// assigned managed identity is used.
+	ClientID string `yaml:"client_id,omitempty"`
+}
+
+// AzureADOAuthConfig authenticates with the client credentials of an Azure AD
+// application.
+type AzureADOAuthConfig struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	TenantID     string `yaml:"tenant_id"`
+}
+
+// Validate returns an error if c is invalid.
+func (c *AzureADConfig) Validate() error {
+	if _, err := azureCloud(c.Cloud); err != nil {
+		return err
+	}
+	if c.Scope == "" {
+		return fmt.Errorf("azuread scope must be provided")
+	}
+
+	switch {
+	case c.ManagedIdentity == nil && c.OAuth == nil:
+		return fmt.Errorf("one of azuread managed_identity or oauth must be provided")
+	case c.ManagedIdentity != nil && c.OAuth != nil:
+		return fmt.Errorf("azuread managed_identity and oauth are mutually exclusive")
+	case c.OAuth != nil && (c.OAuth.ClientID == "" || c.OAuth.ClientSecret == "" || c.OAuth.TenantID == ""):
+		return fmt.Errorf("azuread oauth client_id, client_secret, and tenant_id must be provided")
+	}
+	return nil
+}
+
+func azureCloud(name string) (cloud.Configuration, error) {
+	switch name {
+	case "", AzurePublic:
+		return cloud.AzurePublic, nil
+	case AzureChina:
+		return cloud.AzureChina, nil
+	case AzureGovernment:
+		return cloud.AzureGovernment, nil
+	default:
+		return cloud.Configuration{}, fmt.Errorf("unknown azuread cloud %q", name)
+	}
+}
+
+// newAzureADTripperware returns a Tripperware which adds an Azure AD token to
+// every request.
+func newAzureADTripperware(cfg *AzureADConfig) (Tripperware, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cloudCfg, _ := azureCloud(cfg.Cloud)
+	clientOpts := azcore.ClientOptions{Cloud: cloudCfg}
+
+	var (
+		cred azcore.TokenCredential
+		err  error
+	)
+	if mi := cfg.ManagedIdentity; mi != nil {
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOpts}
+		if mi.ClientID != "" {
+			opts.ID = azidentity.ClientID(mi.ClientID)
+		}
+		cred, err = azidentity.NewManagedIdentityCredential(opts)
+	} else {
+		cred, err = azidentity.NewClientSecretCredential(
+			cfg.OAuth.TenantID, cfg.OAuth.ClientID, cfg.OAuth.ClientSecret,
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOpts},
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating azuread credential: %w", err)
+	}
+
+	tokens := &azureADTokenCache{cred: cred, scope: cfg.Scope}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &azureADRoundTripper{tokens: tokens, next: next}
+	}, nil
+}
+
+// azureADTokenCache caches a token until shortly before it expires.
+type azureADTokenCache struct {
+	cred  azcore.TokenCredential
+	scope string
+
+	mut   sync.Mutex
+	token azcore.AccessToken
+}
+
+func (c *azureADTokenCache) Token(ctx context.Context) (string, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.token.Token != "" && time.Until(c.token.ExpiresOn) > azureADRefreshBefore {
+		return c.token.Token, nil
+	}
+
+	tok, err := c.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{c.scope}})
+	if err != nil {
+		return "", fmt.Errorf("getting azuread token: %w", err)
+	}
+	c.token = tok
+	return tok.Token, nil
+}
+
+type azureADRoundTripper struct {
+	tokens *azureADTokenCache
+	next   http.RoundTripper
+}
+
+func (rt *azureADRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredential struct {
+	calls  int
+	expiry time.Duration
+}
+
+func (c *fakeCredential) GetToken(_ context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calls++
+	return azcore.AccessToken{
+		Token:     opts.Scopes[0] + "-token",
+		ExpiresOn: time.Now().Add(c.expiry),
+	}, nil
+}
+
+func TestAzureADRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer api://loki/.default-token", r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		expiry        time.Duration
+		expectedCalls int
+	}{
+		{name: "token is cached", expiry: time.Hour, expectedCalls: 1},
+		{name: "token close to expiry is refreshed", expiry: time.Minute, expectedCalls: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cred := &fakeCredential{expiry: tc.expiry}
+			rt := &azureADRoundTripper{
+				tokens: &azureADTokenCache{cred: cred, scope: "api://loki/.default"},
+				next:   http.DefaultTransport,
+			}
+			cli := &http.Client{Transport: rt}
+
+			for i := 0; i < 3; i++ {
+				resp, err := cli.Get(srv.URL)
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				resp.Body.Close()
+			}
+			require.Equal(t, tc.expectedCalls, cred.calls)
+		})
+	}
+}
+
+func TestAzureADConfig_Validate(t *testing.T) {
+	cfg := AzureADConfig{
+		Scope: "api://loki/.default",
+		OAuth: &AzureADOAuthConfig{ClientID: "client-id", TenantID: "tenant-id"},
+	}
+	require.EqualError(t, cfg.Validate(), "azuread oauth client_id, client_secret, and tenant_id must be provided")
+
+	cfg.OAuth.ClientSecret = "client-secret"
+	require.NoError(t, cfg.Validate())
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
 )
 
 const (
@@ -29,6 +30,10 @@ type batch struct {
 	bytes     int
 	createdAt time.Time
 
+	// acks of the entries in the batch, which are done once the batch has
+	// been sent or permanently dropped.
+	acks []*loki.Ack
+
 	maxStreams int
 }
 
@@ -57,6 +62,7 @@ func (b *batch) add(entry loki.Entry) error {
 	labels := labelsMapToString(entry.Labels, ReservedLabelTenantID)
 	if stream, ok := b.streams[labels]; ok {
 		stream.Entries = append(stream.Entries, entry.Entry)
+		b.addAck(entry.Ack)
 		return nil
 	}
 
@@ -69,9 +75,24 @@ func (b *batch) add(entry loki.Entry) error {
 		Labels:  labels,
 		Entries: []logproto.Entry{entry.Entry},
 	}
+	b.addAck(entry.Ack)
 	return nil
 }
 
+func (b *batch) addAck(ack *loki.Ack) {
+	if ack != nil {
+		b.acks = append(b.acks, ack)
+	}
+}
+
+// ack marks the entries in the batch as handled.
+func (b *batch) ack() {
+	for _, a := range b.acks {
+		a.Done()
+	}
+	b.acks = nil
+}
+
 func labelsMapToString(ls model.LabelSet, without ...model.LabelName) string {
 	lstrs := make([]string, 0, len(ls))
 Outer:
@@ -88,6 +109,26 @@ Outer:
 	return fmt.Sprintf("{%s}", strings.Join(lstrs, ", "))
 }
 
+// entries returns the entries in the batch. Their labels don't include the
+// reserved tenant ID label.
+func (b *batch) entries() []loki.Entry {
+	var res []loki.Entry
+	for _, stream := range b.streams {
+		lbls, err := parser.ParseMetric(stream.Labels)
+		if err != nil {
+			continue
+		}
+		ls := make(model.LabelSet, len(lbls))
+		for _, l := range lbls {
+			ls[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+		for _, e := range stream.Entries {
+			res = append(res, loki.Entry{Labels: ls.Clone(), Entry: e})
+		}
+	}
+	return res
+}
+
 // sizeBytes returns the current batch size in bytes
 func (b *batch) sizeBytes() int {
 	return b.bytes
@@ -14,11 +14,13 @@ import (
 	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/util/memwatch"
 	"github.com/grafana/dskit/backoff"
 	lokiutil "github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/build"
@@ -36,9 +38,18 @@ const (
 	// pipeline stages
 	ReservedLabelTenantID = "__tenant_id__"
 
+	// Reasons for sending entries to the dead letter queue.
+	deadLetterRejected         = "rejected"
+	deadLetterRetriesExhausted = "retries_exhausted"
+	deadLetterMaxStreams       = "max_streams"
+
 	LatencyLabel = "filename"
 	HostLabel    = "host"
 	ClientLabel  = "client"
+	TenantLabel  = "tenant"
+
+	FailoverGroupLabel    = "group"
+	FailoverEndpointLabel = "endpoint"
 )
 
 var UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
@@ -53,6 +64,15 @@ type Metrics struct {
 	batchRetries     *prometheus.CounterVec
 	countersWithHost []*prometheus.CounterVec
 	streamLag        *prometheus.GaugeVec
+
+	// Metrics of TenantQueues.
+	tenantQueuedEntries  *prometheus.CounterVec
+	tenantDroppedEntries *prometheus.CounterVec
+	tenantQueueLength    *prometheus.GaugeVec
+
+	// Metrics of FailoverGroups.
+	failoverActiveEndpoint *prometheus.GaugeVec
+	failoverSwitches       *prometheus.CounterVec
 }
 
 func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
@@ -98,6 +118,28 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		Help: "Difference between current time and last batch timestamp for successful sends",
 	}, streamLagLabelsMerged)
 
+	m.tenantQueuedEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_tenant_queued_entries_total",
+		Help: "Number of log entries queued to be sent, by tenant.",
+	}, []string{HostLabel, TenantLabel})
+	m.tenantDroppedEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_tenant_dropped_entries_total",
+		Help: "Number of log entries dropped before being queued, by tenant and reason.",
+	}, []string{HostLabel, TenantLabel, "reason"})
+	m.tenantQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_tenant_queue_length",
+		Help: "Number of log entries waiting in the queue of a tenant.",
+	}, []string{HostLabel, TenantLabel})
+
+	m.failoverActiveEndpoint = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_failover_active_endpoint",
+		Help: "Whether an endpoint of a failover group is the one entries are sent to (1) or not (0).",
+	}, []string{FailoverGroupLabel, FailoverEndpointLabel})
+	m.failoverSwitches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_failover_switches_total",
+		Help: "Number of times a failover group switched to an endpoint.",
+	}, []string{FailoverGroupLabel, FailoverEndpointLabel})
+
 	if reg != nil {
 		m.encodedBytes = mustRegisterOrGet(reg, m.encodedBytes).(*prometheus.CounterVec)
 		m.sentBytes = mustRegisterOrGet(reg, m.sentBytes).(*prometheus.CounterVec)
@@ -107,6 +149,11 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		m.requestDuration = mustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
 		m.batchRetries = mustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
 		m.streamLag = mustRegisterOrGet(reg, m.streamLag).(*prometheus.GaugeVec)
+		m.tenantQueuedEntries = mustRegisterOrGet(reg, m.tenantQueuedEntries).(*prometheus.CounterVec)
+		m.tenantDroppedEntries = mustRegisterOrGet(reg, m.tenantDroppedEntries).(*prometheus.CounterVec)
+		m.tenantQueueLength = mustRegisterOrGet(reg, m.tenantQueueLength).(*prometheus.GaugeVec)
+		m.failoverActiveEndpoint = mustRegisterOrGet(reg, m.failoverActiveEndpoint).(*prometheus.GaugeVec)
+		m.failoverSwitches = mustRegisterOrGet(reg, m.failoverSwitches).(*prometheus.CounterVec)
 	}
 
 	return &m
@@ -149,8 +196,16 @@ type client struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	maxStreams int
+
+	// memoryPressure is set while memory pressure is high, shrinking batches
+	// so entries are held in memory for less time.
+	memoryPressure atomic.Bool
 }
 
+// pressureBatchSizeDivisor is how much smaller batches are while memory
+// pressure is high.
+const pressureBatchSizeDivisor = 4
+
 // Tripperware can wrap a roundtripper.
 type Tripperware func(http.RoundTripper) http.RoundTripper
 
@@ -175,7 +230,7 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		entries:         make(chan loki.Entry),
 		metrics:         metrics,
 		streamLagLabels: streamLagLabels,
-		name:            asSha256(cfg),
+		name:            configName(cfg),
 
 		externalLabels: cfg.ExternalLabels.LabelSet,
 		ctx:            ctx,
@@ -186,18 +241,12 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		c.name = cfg.Name
 	}
 
-	err := cfg.Client.Validate()
-	if err != nil {
-		return nil, err
-	}
-
-	c.client, err = config.NewClientFromConfig(cfg.Client, "GrafanaAgent", config.WithHTTP2Disabled())
+	var err error
+	c.client, err = newHTTPClient(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	c.client.Timeout = cfg.Timeout
-
 	// Initialize counters to 0 so the metrics are exported before the first
 	// occurrence of incrementing to avoid missing metrics.
 	for _, counter := range c.metrics.countersWithHost {
@@ -209,6 +258,31 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 	return c, nil
 }
 
+// newHTTPClient creates the HTTP client used to send requests to the
+// endpoint of cfg.
+func newHTTPClient(cfg Config) (*http.Client, error) {
+	err := cfg.Client.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := config.NewClientFromConfig(cfg.Client, "GrafanaAgent", config.WithHTTP2Disabled())
+	if err != nil {
+		return nil, err
+	}
+
+	client.Timeout = cfg.Timeout
+
+	if cfg.AzureAD != nil {
+		tp, err := newAzureADTripperware(cfg.AzureAD)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = tp(client.Transport)
+	}
+	return client, nil
+}
+
 // NewWithTripperware creates a new Loki client with a custom tripperware.
 func NewWithTripperware(metrics *Metrics, cfg Config, streamLagLabels []string, maxStreams int, logger log.Logger, tp Tripperware) (Client, error) {
 	c, err := newClient(metrics, cfg, streamLagLabels, maxStreams, logger)
@@ -240,7 +314,12 @@ func (c *client) run() {
 
 	maxWaitCheck := time.NewTicker(maxWaitCheckFrequency)
 
+	unregister := memwatch.Default().Register(func(l memwatch.Level) {
+		c.memoryPressure.Store(l >= memwatch.LevelHigh)
+	})
+
 	defer func() {
+		unregister()
 		maxWaitCheck.Stop()
 		// Send all pending batches
 		for tenantID, batch := range batches {
@@ -267,7 +346,7 @@ func (c *client) run() {
 
 			// If adding the entry to the batch will increase the size over the max
 			// size allowed, we do send the current batch and then create a new one
-			if batch.sizeBytesAfter(e) > c.cfg.BatchSize {
+			if batch.sizeBytesAfter(e) > c.maxBatchSize() {
 				c.sendBatch(tenantID, batch)
 
 				batches[tenantID] = newBatch(c.maxStreams, e)
@@ -279,12 +358,15 @@ func (c *client) run() {
 			if err != nil {
 				level.Error(c.logger).Log("msg", "batch add err", "error", err)
 				c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Inc()
+				c.cfg.DeadLetter.Send([]loki.Entry{e}, deadLetterMaxStreams, err)
+				e.Ack.Done()
 				return
 			}
 		case <-maxWaitCheck.C:
-			// Send all batches whose max wait time has been reached
+			// Send all batches whose max wait time has been reached, or which
+			// are too large after memory pressure became high.
 			for tenantID, batch := range batches {
-				if batch.age() < c.cfg.BatchWait {
+				if batch.age() < c.cfg.BatchWait && batch.sizeBytes() < c.maxBatchSize() {
 					continue
 				}
 
@@ -295,10 +377,27 @@ func (c *client) run() {
 	}
 }
 
+// maxBatchSize returns the size in bytes at which batches are sent.
+func (c *client) maxBatchSize() int {
+	if c.memoryPressure.Load() {
+		return c.cfg.BatchSize / pressureBatchSizeDivisor
+	}
+	return c.cfg.BatchSize
+}
+
 func (c *client) Chan() chan<- loki.Entry {
 	return c.entries
 }
 
+// configName returns the name of a client derived from cfg. The dead letter
+// queue and health tracker aren't part of the name, so that it doesn't change
+// when they're recreated.
+func configName(cfg Config) string {
+	cfg.DeadLetter = nil
+	cfg.Health = nil
+	return asSha256(cfg)
+}
+
 func asSha256(o interface{}) string {
 	h := sha256.New()
 	h.Write([]byte(fmt.Sprintf("%v", o)))
@@ -311,6 +410,7 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 	buf, entriesCount, err := batch.encode()
 	if err != nil {
 		level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
+		batch.ack()
 		return
 	}
 	bufBytes := float64(len(buf))
@@ -324,10 +424,12 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 		status, err = c.send(context.Background(), tenantID, buf)
 
 		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
+		c.cfg.Health.record(status, err)
 
 		if err == nil {
 			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+			batch.ack()
 			for _, s := range batch.streams {
 				lbls, err := parser.ParseMetric(s.Labels)
 				if err != nil {
@@ -384,6 +486,19 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 		level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
 		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+
+		// Entries which were rejected by Loki or ran out of retries will never
+		// be delivered, so they're acknowledged to let sources move on. Entries
+		// which were dropped because the client is stopping without retrying
+		// aren't acknowledged, so that sources read them again after a restart.
+		if c.ctx.Err() == nil {
+			reason := deadLetterRetriesExhausted
+			if status > 0 && status != 429 && status/100 != 5 {
+				reason = deadLetterRejected
+			}
+			c.cfg.DeadLetter.Send(batch.entries(), reason, err)
+			batch.ack()
+		}
 	}
 }
 
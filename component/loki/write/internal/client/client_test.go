@@ -4,6 +4,7 @@ package client
 // and run the clients that can send log entries to a Loki instance.
 
 import (
+	"fmt"
 	"io"
 	"math"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/loki/pkg/logproto"
@@ -26,6 +28,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 var logEntries = []loki.Entry{
@@ -495,3 +498,151 @@ func Test_Tripperware(t *testing.T) {
 	c.Stop()
 	require.True(t, called)
 }
+
+func TestClient_Ack(t *testing.T) {
+	tests := map[string]struct {
+		serverResponseStatus int
+		stopNow              bool
+		expectAcked          bool
+	}{
+		"batch sent": {
+			serverResponseStatus: 200,
+			expectAcked:          true,
+		},
+		"batch rejected": {
+			serverResponseStatus: 400,
+			expectAcked:          true,
+		},
+		"client stopped while retrying": {
+			serverResponseStatus: 500,
+			stopNow:              true,
+			expectAcked:          false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			receivedReqsChan := make(chan receivedReq, 100)
+			server := httptest.NewServer(createServerHandler(receivedReqsChan, testData.serverResponseStatus))
+			defer server.Close()
+
+			serverURL := flagext.URLValue{}
+			require.NoError(t, serverURL.Set(server.URL))
+
+			cfg := Config{
+				URL:           serverURL,
+				BatchWait:     10 * time.Millisecond,
+				BatchSize:     1024 * 1024,
+				Client:        config.HTTPClientConfig{},
+				BackoffConfig: backoff.Config{MinBackoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, MaxRetries: 1000},
+				Timeout:       1 * time.Second,
+			}
+			c, err := New(NewMetrics(prometheus.NewRegistry(), nil), cfg, nil, 0, log.NewNopLogger())
+			require.NoError(t, err)
+
+			acked := atomic.NewInt32(0)
+			for i := 0; i < 2; i++ {
+				c.Chan() <- loki.Entry{
+					Labels: model.LabelSet{"foo": "bar"},
+					Entry:  logproto.Entry{Timestamp: time.Now(), Line: "line"},
+					Ack:    loki.NewAck(func() { acked.Inc() }),
+				}
+			}
+
+			// Wait for the batch to be sent at least once.
+			select {
+			case <-receivedReqsChan:
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "timed out waiting for request")
+			}
+
+			if testData.stopNow {
+				c.StopNow()
+			} else {
+				c.Stop()
+			}
+
+			if testData.expectAcked {
+				require.Equal(t, int32(2), acked.Load())
+			} else {
+				require.Equal(t, int32(0), acked.Load())
+			}
+		})
+	}
+}
+
+func TestClient_DeadLetter(t *testing.T) {
+	tests := map[string]struct {
+		serverResponseStatus int
+		maxRetries           int
+		expectedReason       string
+	}{
+		"batch sent": {
+			serverResponseStatus: 200,
+		},
+		"batch rejected": {
+			serverResponseStatus: 400,
+			expectedReason:       deadLetterRejected,
+		},
+		"retries exhausted": {
+			serverResponseStatus: 500,
+			maxRetries:           2,
+			expectedReason:       deadLetterRetriesExhausted,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			receivedReqsChan := make(chan receivedReq, 100)
+			server := httptest.NewServer(createServerHandler(receivedReqsChan, testData.serverResponseStatus))
+			defer server.Close()
+
+			serverURL := flagext.URLValue{}
+			require.NoError(t, serverURL.Set(server.URL))
+
+			reg := prometheus.NewRegistry()
+			dlq, err := deadletter.New(log.NewNopLogger(), reg, t.TempDir())
+			require.NoError(t, err)
+			defer dlq.Stop()
+			dead := make(loki.LogsReceiver, 10)
+			require.NoError(t, dlq.Update(&deadletter.Arguments{ForwardTo: []loki.LogsReceiver{dead}}))
+
+			cfg := Config{
+				URL:           serverURL,
+				BatchWait:     10 * time.Millisecond,
+				BatchSize:     1024 * 1024,
+				Client:        config.HTTPClientConfig{},
+				BackoffConfig: backoff.Config{MinBackoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, MaxRetries: testData.maxRetries},
+				Timeout:       1 * time.Second,
+				DeadLetter:    dlq,
+			}
+			c, err := New(NewMetrics(reg, nil), cfg, nil, 0, log.NewNopLogger())
+			require.NoError(t, err)
+
+			c.Chan() <- loki.Entry{
+				Labels: model.LabelSet{"foo": "bar"},
+				Entry:  logproto.Entry{Timestamp: time.Unix(1, 0), Line: "line"},
+			}
+			c.Stop()
+
+			if testData.expectedReason == "" {
+				require.Empty(t, dead)
+				return
+			}
+			select {
+			case e := <-dead:
+				require.Equal(t, model.LabelSet{"foo": "bar"}, e.Labels)
+				require.Equal(t, "line", e.Line)
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "timed out waiting for dead letter entry")
+			}
+
+			expectedMetrics := fmt.Sprintf(`
+# HELP loki_dead_letter_entries_total Total number of log entries sent to the dead letter queue, by reason.
+# TYPE loki_dead_letter_entries_total counter
+loki_dead_letter_entries_total{reason=%q} 1
+`, testData.expectedReason)
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "loki_dead_letter_entries_total"))
+		})
+	}
+}
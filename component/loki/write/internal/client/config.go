@@ -7,6 +7,7 @@ import (
 	"flag"
 	"time"
 
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/config"
@@ -33,6 +34,10 @@ type Config struct {
 
 	Client config.HTTPClientConfig `yaml:",inline"`
 
+	// AzureAD authenticates requests with an Azure AD token. It can't be used
+	// together with the authentication options of Client.
+	AzureAD *AzureADConfig `yaml:"azuread,omitempty"`
+
 	BackoffConfig backoff.Config `yaml:"backoff_config"`
 	// The labels to add to any time series or alerts when communicating with loki
 	ExternalLabels lokiflag.LabelSet `yaml:"external_labels,omitempty"`
@@ -44,6 +49,14 @@ type Config struct {
 
 	// deprecated use StreamLagLabels from config.Config instead
 	StreamLagLabels flagext.StringSliceCSV `yaml:"stream_lag_labels"`
+
+	// DeadLetter receives the entries which are rejected by Loki or can't be
+	// sent after all retries. It may be nil.
+	DeadLetter *deadletter.Queue `yaml:"-"`
+
+	// Health records the outcome of requests to the endpoint, and is used by
+	// a FailoverGroup to detect when the endpoint is unhealthy. It may be nil.
+	Health *Health `yaml:"-"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
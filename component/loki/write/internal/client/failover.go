@@ -0,0 +1,326 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/agent/component/common/loki"
+)
+
+// Endpoints of a FailoverGroup, as reported in metrics.
+const (
+	failoverPrimary   = "primary"
+	failoverSecondary = "secondary"
+)
+
+// FailoverConfig configures a client created with NewFailoverGroup.
+type FailoverConfig struct {
+	Name          string        // Name of the group, used in metrics.
+	FailoverAfter time.Duration // How long the primary must fail before switching to the secondary.
+	FailbackAfter time.Duration // How long the primary must succeed before switching back to it.
+}
+
+// Health records whether the requests of the clients of an endpoint succeed.
+// A nil *Health records nothing.
+type Health struct {
+	mut          sync.Mutex
+	failingSince time.Time // Zero if the last request succeeded.
+	healthySince time.Time
+}
+
+// NewHealth creates a new Health which starts as healthy.
+func NewHealth() *Health {
+	return &Health{healthySince: time.Now()}
+}
+
+// record records the outcome of a request. Only the failures which sendBatch
+// retries, that is connection errors, 429s and 500s, make the endpoint
+// unhealthy; requests which are rejected because of their content show that
+// the endpoint is up.
+func (h *Health) record(status int, err error) {
+	if h == nil {
+		return
+	}
+	failed := err != nil && (status <= 0 || status == 429 || status/100 == 5)
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	switch {
+	case failed && h.failingSince.IsZero():
+		h.failingSince = time.Now()
+	case !failed && !h.failingSince.IsZero():
+		h.failingSince = time.Time{}
+		h.healthySince = time.Now()
+	}
+}
+
+// failingFor returns how long requests have been failing, or 0 if the last
+// request succeeded.
+func (h *Health) failingFor(now time.Time) time.Duration {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.failingSince.IsZero() {
+		return 0
+	}
+	return now.Sub(h.failingSince)
+}
+
+// healthyFor returns how long requests have been succeeding, or 0 if the last
+// request failed.
+func (h *Health) healthyFor(now time.Time) time.Duration {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if !h.failingSince.IsZero() {
+		return 0
+	}
+	return now.Sub(h.healthySince)
+}
+
+// FailoverGroup is a client which sends entries to a primary endpoint, and to
+// a secondary endpoint only while the primary is unhealthy.
+//
+// The primary is unhealthy once its requests have been failing for
+// FailoverAfter. While entries are sent to the secondary, the primary is
+// probed with empty push requests, and entries are sent to it again once its
+// requests have been succeeding for FailbackAfter. Entries which were already
+// handed to the primary when it became unhealthy are retried by the primary,
+// and aren't sent to the secondary.
+type FailoverGroup struct {
+	cfg       FailoverConfig
+	metrics   *Metrics
+	logger    log.Logger
+	primary   Client
+	secondary Client
+	health    *Health
+	prober    *client
+
+	checkInterval time.Duration
+	entries       chan loki.Entry
+	runDone       chan struct{}
+	failedOver    atomic.Bool
+
+	// ctx is canceled by StopNow to abandon entries which haven't been sent
+	// yet.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+var _ Client = (*FailoverGroup)(nil)
+
+// NewFailoverGroup creates a new FailoverGroup sending entries to primary and
+// secondary. primary must have been created from primaryCfg, whose Health is
+// used to detect when the primary is unhealthy.
+func NewFailoverGroup(metrics *Metrics, cfg FailoverConfig, primary, secondary Client, primaryCfg Config, logger log.Logger) (*FailoverGroup, error) {
+	if primaryCfg.Health == nil {
+		return nil, errors.New("failover group primary endpoint config must have a Health")
+	}
+	httpClient, err := newHTTPClient(primaryCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Health is checked ten times over the course of the shortest window, but
+	// at most every 10ms and at least once per second.
+	checkInterval := cfg.FailoverAfter
+	if cfg.FailbackAfter < checkInterval {
+		checkInterval = cfg.FailbackAfter
+	}
+	checkInterval /= 10
+	if checkInterval < 10*time.Millisecond {
+		checkInterval = 10 * time.Millisecond
+	} else if checkInterval > time.Second {
+		checkInterval = time.Second
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = primary.Name()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &FailoverGroup{
+		cfg:       cfg,
+		metrics:   metrics,
+		logger:    log.With(logger, "component", "failover", "group", cfg.Name),
+		primary:   primary,
+		secondary: secondary,
+		health:    primaryCfg.Health,
+		prober: &client{
+			cfg:    primaryCfg,
+			client: httpClient,
+		},
+
+		checkInterval: checkInterval,
+		entries:       make(chan loki.Entry),
+		runDone:       make(chan struct{}),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	// Initialize the counters to 0 so that they're exported before the first
+	// switch.
+	f.metrics.failoverSwitches.WithLabelValues(cfg.Name, failoverPrimary).Add(0)
+	f.metrics.failoverSwitches.WithLabelValues(cfg.Name, failoverSecondary).Add(0)
+	f.setActive(failoverPrimary, failoverSecondary)
+
+	f.wg.Add(2)
+	go f.run()
+	go f.probe()
+	return f, nil
+}
+
+func (f *FailoverGroup) run() {
+	defer f.wg.Done()
+	defer close(f.runDone)
+
+	check := time.NewTicker(f.checkInterval)
+	defer check.Stop()
+
+	for {
+		select {
+		case e, ok := <-f.entries:
+			if !ok {
+				return
+			}
+			f.send(e, check.C)
+		case <-check.C:
+			f.checkHealth()
+		}
+	}
+}
+
+// send sends e to the active client. The health of the primary is checked
+// while waiting, so that e is sent to the secondary if the primary becomes
+// unhealthy while it's blocked retrying earlier requests.
+func (f *FailoverGroup) send(e loki.Entry, check <-chan time.Time) {
+	for {
+		active := f.primary
+		if f.failedOver.Load() {
+			active = f.secondary
+		}
+
+		select {
+		case active.Chan() <- e:
+			return
+		case <-check:
+			f.checkHealth()
+		case <-f.ctx.Done():
+			e.Ack.Done()
+			return
+		}
+	}
+}
+
+// checkHealth switches to the secondary if the primary is unhealthy, and back
+// to the primary once it recovered. Must only be called by run.
+func (f *FailoverGroup) checkHealth() {
+	now := time.Now()
+	switch {
+	case !f.failedOver.Load() && f.health.failingFor(now) >= f.cfg.FailoverAfter:
+		level.Warn(f.logger).Log("msg", "primary endpoint is unhealthy, sending entries to secondary endpoint", "failover_after", f.cfg.FailoverAfter)
+		f.failedOver.Store(true)
+		f.setActive(failoverSecondary, failoverPrimary)
+		f.metrics.failoverSwitches.WithLabelValues(f.cfg.Name, failoverSecondary).Inc()
+	case f.failedOver.Load() && f.health.healthyFor(now) >= f.cfg.FailbackAfter:
+		level.Info(f.logger).Log("msg", "primary endpoint recovered, sending entries to primary endpoint", "failback_after", f.cfg.FailbackAfter)
+		f.failedOver.Store(false)
+		f.setActive(failoverPrimary, failoverSecondary)
+		f.metrics.failoverSwitches.WithLabelValues(f.cfg.Name, failoverPrimary).Inc()
+	}
+}
+
+func (f *FailoverGroup) setActive(active, inactive string) {
+	f.metrics.failoverActiveEndpoint.WithLabelValues(f.cfg.Name, active).Set(1)
+	f.metrics.failoverActiveEndpoint.WithLabelValues(f.cfg.Name, inactive).Set(0)
+}
+
+// probe sends empty push requests to the primary while entries are sent to
+// the secondary, as the primary doesn't make any requests which could show
+// that it recovered.
+func (f *FailoverGroup) probe() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.checkInterval)
+	defer ticker.Stop()
+
+	buf, _, err := newBatch(0).encode()
+	if err != nil {
+		level.Error(f.logger).Log("msg", "failed to encode probe request", "err", err)
+		return
+	}
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			if !f.failedOver.Load() {
+				continue
+			}
+			status, err := f.prober.send(f.ctx, f.prober.cfg.TenantID, buf)
+			if f.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				level.Debug(f.logger).Log("msg", "primary endpoint probe failed", "status", status, "err", err)
+			}
+			f.health.record(status, err)
+		}
+	}
+}
+
+// Chan implements Client.
+func (f *FailoverGroup) Chan() chan<- loki.Entry {
+	return f.entries
+}
+
+// Stop implements Client. Stop waits for the entries which were sent to
+// either endpoint to be sent.
+func (f *FailoverGroup) Stop() {
+	f.stop(false)
+}
+
+// StopNow implements Client. Entries which haven't been sent yet are dropped.
+func (f *FailoverGroup) StopNow() {
+	f.stop(true)
+}
+
+func (f *FailoverGroup) stop(now bool) {
+	f.once.Do(func() {
+		if now {
+			f.cancel()
+		}
+		close(f.entries)
+
+		// Wait for run to return before stopping the clients it sends to, and
+		// then stop probing.
+		<-f.runDone
+		f.cancel()
+		f.wg.Wait()
+
+		if now {
+			f.primary.StopNow()
+			f.secondary.StopNow()
+		} else {
+			f.primary.Stop()
+			f.secondary.Stop()
+		}
+
+		f.metrics.failoverActiveEndpoint.DeleteLabelValues(f.cfg.Name, failoverPrimary)
+		f.metrics.failoverActiveEndpoint.DeleteLabelValues(f.cfg.Name, failoverSecondary)
+	})
+}
+
+// Name implements Client.
+func (f *FailoverGroup) Name() string {
+	return f.cfg.Name
+}
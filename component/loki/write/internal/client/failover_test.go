@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/agent/component/common/loki"
+)
+
+func TestFailoverGroup(t *testing.T) {
+	var (
+		primaryHealthy    = atomic.NewBool(true)
+		primaryReceived   = atomic.NewInt64(0)
+		secondaryReceived = atomic.NewInt64(0)
+	)
+	newServer := func(healthy *atomic.Bool, received *atomic.Int64) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if healthy != nil && !healthy.Load() {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			var pushReq logproto.PushRequest
+			if err := util.ParseProtoReader(req.Context(), req.Body, int(req.ContentLength), math.MaxInt32, &pushReq, util.RawSnappy); err != nil {
+				rw.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			for _, s := range pushReq.Streams {
+				received.Add(int64(len(s.Entries)))
+			}
+		}))
+	}
+	primaryServer := newServer(primaryHealthy, primaryReceived)
+	defer primaryServer.Close()
+	secondaryServer := newServer(nil, secondaryReceived)
+	defer secondaryServer.Close()
+
+	newConfig := func(serverURL string) Config {
+		var u flagext.URLValue
+		require.NoError(t, u.Set(serverURL))
+		return Config{
+			URL:           u,
+			BatchWait:     10 * time.Millisecond,
+			BatchSize:     1,
+			Client:        config.HTTPClientConfig{},
+			BackoffConfig: backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+			Timeout:       time.Second,
+		}
+	}
+
+	metrics := NewMetrics(nil, nil)
+	primaryCfg := newConfig(primaryServer.URL)
+	primaryCfg.Health = NewHealth()
+	primary, err := New(metrics, primaryCfg, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+	secondary, err := New(metrics, newConfig(secondaryServer.URL), nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+
+	group, err := NewFailoverGroup(metrics, FailoverConfig{
+		Name:          "test",
+		FailoverAfter: 100 * time.Millisecond,
+		FailbackAfter: 100 * time.Millisecond,
+	}, primary, secondary, primaryCfg, log.NewNopLogger())
+	require.NoError(t, err)
+	defer group.StopNow()
+
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			group.Chan() <- loki.Entry{
+				Labels: model.LabelSet{"job": "test"},
+				Entry:  logproto.Entry{Timestamp: time.Now(), Line: "line"},
+			}
+		}
+	}
+	active := func(endpoint string) float64 {
+		return testutil.ToFloat64(metrics.failoverActiveEndpoint.WithLabelValues("test", endpoint))
+	}
+	switches := func(endpoint string) float64 {
+		return testutil.ToFloat64(metrics.failoverSwitches.WithLabelValues("test", endpoint))
+	}
+
+	// Entries are only sent to the primary while it's healthy.
+	send(3)
+	require.Eventually(t, func() bool { return primaryReceived.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1.0, active(failoverPrimary))
+	require.Zero(t, secondaryReceived.Load())
+
+	// Once the primary has been failing for long enough, entries are sent to
+	// the secondary. The entry the primary failed to send is retried by the
+	// primary.
+	primaryHealthy.Store(false)
+	send(1)
+	require.Eventually(t, func() bool { return active(failoverSecondary) == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 0.0, active(failoverPrimary))
+	require.Equal(t, 1.0, switches(failoverSecondary))
+
+	send(3)
+	require.Eventually(t, func() bool { return secondaryReceived.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	// Once the primary recovers, entries are sent to it again.
+	primaryHealthy.Store(true)
+	require.Eventually(t, func() bool { return active(failoverPrimary) == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1.0, switches(failoverPrimary))
+
+	send(2)
+	require.Eventually(t, func() bool { return primaryReceived.Load() == 6 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(3), secondaryReceived.Load())
+}
+
+func TestHealth(t *testing.T) {
+	errTest := errors.New("request failed")
+	h := NewHealth()
+	now := time.Now()
+	require.Zero(t, h.failingFor(now))
+
+	// Rejected requests show that the endpoint is up.
+	h.record(http.StatusBadRequest, errTest)
+	require.Zero(t, h.failingFor(now))
+
+	for _, status := range []int{-1, http.StatusTooManyRequests, http.StatusInternalServerError} {
+		h.record(status, errTest)
+		require.NotZero(t, h.failingFor(time.Now().Add(time.Second)))
+		require.Zero(t, h.healthyFor(time.Now().Add(time.Second)))
+
+		h.record(http.StatusNoContent, nil)
+		require.Zero(t, h.failingFor(time.Now().Add(time.Second)))
+		require.NotZero(t, h.healthyFor(time.Now().Add(time.Second)))
+	}
+}
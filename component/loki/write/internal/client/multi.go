@@ -59,6 +59,8 @@ func (m *MultiClient) start() {
 	go func() {
 		defer m.wg.Done()
 		for e := range m.entries {
+			// Each client acknowledges its own copy of the entry.
+			e.Ack.Add(len(m.clients) - 1)
 			for _, c := range m.clients {
 				c.Chan() <- e
 			}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
+)
+
+// Reasons for which the entries of a tenant are dropped by TenantQueues.
+const (
+	reasonMaxTenants  = "max_tenants"
+	reasonQueueFull   = "queue_full"
+	reasonClientError = "client_error"
+)
+
+// TenantQueuesConfig configures a client created with NewTenantQueues.
+type TenantQueuesConfig struct {
+	MaxTenants  int           // Maximum number of tenants with a queue at once.
+	QueueSize   int           // Maximum number of queued entries per tenant.
+	IdleTimeout time.Duration // How long a queue without entries is kept.
+}
+
+// TenantQueues is a client which sends the entries of each tenant through a
+// separate queue and client, so that a tenant whose requests are failing or
+// being retried doesn't delay the entries of other tenants.
+//
+// The tenant of an entry is the value of its ReservedLabelTenantID label;
+// entries without the label share a queue. Entries are dropped if their tenant
+// can't be given a queue because MaxTenants was reached, or if the queue of
+// their tenant is full. Dropped entries are sent to the dead letter queue of
+// the endpoint.
+type TenantQueues struct {
+	cfg        TenantQueuesConfig
+	name       string
+	host       string
+	metrics    *Metrics
+	logger     log.Logger
+	deadLetter *deadletter.Queue
+	newClient  func() (Client, error)
+
+	entries chan loki.Entry
+	queues  map[string]*tenantQueue // Only accessed by run.
+
+	// ctx is canceled by StopNow to abandon entries which are still queued.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+var _ Client = (*TenantQueues)(nil)
+
+type tenantQueue struct {
+	tenant    string
+	client    Client
+	entries   chan loki.Entry
+	lastEntry time.Time
+}
+
+// NewTenantQueues creates a new TenantQueues client for the endpoint
+// configured in endpoint. Each tenant is given its own client created with
+// New from endpoint.
+func NewTenantQueues(metrics *Metrics, cfg TenantQueuesConfig, endpoint Config, streamLagLabels []string, maxStreams int, logger log.Logger) (*TenantQueues, error) {
+	// Create a client once to validate the endpoint config before any entries
+	// are received.
+	c, err := New(metrics, endpoint, streamLagLabels, maxStreams, logger)
+	if err != nil {
+		return nil, err
+	}
+	c.StopNow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tq := &TenantQueues{
+		cfg:        cfg,
+		name:       c.Name(),
+		host:       endpoint.URL.Host,
+		metrics:    metrics,
+		logger:     log.With(logger, "component", "tenant_queues", "host", endpoint.URL.Host),
+		deadLetter: endpoint.DeadLetter,
+		newClient: func() (Client, error) {
+			return New(metrics, endpoint, streamLagLabels, maxStreams, logger)
+		},
+
+		entries: make(chan loki.Entry),
+		queues:  make(map[string]*tenantQueue),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	tq.wg.Add(1)
+	go tq.run()
+	return tq, nil
+}
+
+func (tq *TenantQueues) run() {
+	defer tq.wg.Done()
+
+	idleCheck := time.NewTicker(tq.cfg.IdleTimeout / 2)
+	defer idleCheck.Stop()
+
+	defer func() {
+		for _, q := range tq.queues {
+			tq.stopQueue(q)
+		}
+	}()
+
+	for {
+		select {
+		case e, ok := <-tq.entries:
+			if !ok {
+				return
+			}
+			tq.enqueue(e)
+
+		case now := <-idleCheck.C:
+			for _, q := range tq.queues {
+				if len(q.entries) == 0 && now.Sub(q.lastEntry) >= tq.cfg.IdleTimeout {
+					tq.stopQueue(q)
+				}
+			}
+		}
+	}
+}
+
+func (tq *TenantQueues) enqueue(e loki.Entry) {
+	tenant := string(e.Labels[ReservedLabelTenantID])
+
+	q, ok := tq.queues[tenant]
+	if !ok {
+		if len(tq.queues) >= tq.cfg.MaxTenants {
+			tq.drop(e, tenant, reasonMaxTenants)
+			return
+		}
+
+		var err error
+		if q, err = tq.startQueue(tenant); err != nil {
+			level.Error(tq.logger).Log("msg", "failed to create client for tenant", "tenant", tenant, "err", err)
+			tq.drop(e, tenant, reasonClientError)
+			return
+		}
+	}
+
+	q.lastEntry = time.Now()
+	select {
+	case q.entries <- e:
+		tq.metrics.tenantQueuedEntries.WithLabelValues(tq.host, tenant).Inc()
+		tq.metrics.tenantQueueLength.WithLabelValues(tq.host, tenant).Set(float64(len(q.entries)))
+	default:
+		tq.drop(e, tenant, reasonQueueFull)
+	}
+}
+
+func (tq *TenantQueues) drop(e loki.Entry, tenant, reason string) {
+	tq.metrics.tenantDroppedEntries.WithLabelValues(tq.host, tenant, reason).Inc()
+	tq.deadLetter.Send([]loki.Entry{e}, reason, nil)
+	e.Ack.Done()
+}
+
+func (tq *TenantQueues) startQueue(tenant string) (*tenantQueue, error) {
+	c, err := tq.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	q := &tenantQueue{
+		tenant:  tenant,
+		client:  c,
+		entries: make(chan loki.Entry, tq.cfg.QueueSize),
+	}
+	tq.queues[tenant] = q
+
+	tq.wg.Add(1)
+	go func() {
+		defer tq.wg.Done()
+		tq.runQueue(q)
+	}()
+	return q, nil
+}
+
+// runQueue sends the entries of q to its client until q.entries is closed.
+func (tq *TenantQueues) runQueue(q *tenantQueue) {
+	defer func() {
+		if tq.ctx.Err() != nil {
+			q.client.StopNow()
+		} else {
+			q.client.Stop()
+		}
+	}()
+
+	for e := range q.entries {
+		select {
+		case q.client.Chan() <- e:
+		case <-tq.ctx.Done():
+			e.Ack.Done()
+			continue
+		}
+		tq.metrics.tenantQueueLength.WithLabelValues(tq.host, q.tenant).Set(float64(len(q.entries)))
+	}
+}
+
+// stopQueue stops q after its queued entries are sent. Must only be called by
+// run.
+func (tq *TenantQueues) stopQueue(q *tenantQueue) {
+	close(q.entries)
+	delete(tq.queues, q.tenant)
+
+	labels := prometheus.Labels{HostLabel: tq.host, TenantLabel: q.tenant}
+	tq.metrics.tenantQueueLength.Delete(labels)
+}
+
+// Chan implements Client.
+func (tq *TenantQueues) Chan() chan<- loki.Entry {
+	return tq.entries
+}
+
+// Stop implements Client. Stop waits for the queued entries of every tenant
+// to be sent.
+func (tq *TenantQueues) Stop() {
+	tq.once.Do(func() { close(tq.entries) })
+	tq.wg.Wait()
+}
+
+// StopNow implements Client. Queued entries which haven't been sent yet are
+// dropped.
+func (tq *TenantQueues) StopNow() {
+	tq.cancel()
+	tq.Stop()
+}
+
+// Name implements Client.
+func (tq *TenantQueues) Name() string {
+	return tq.name
+}
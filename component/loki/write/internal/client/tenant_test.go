@@ -0,0 +1,103 @@
+package client
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/component/common/loki"
+)
+
+func TestTenantQueues(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received = make(map[string]int) // Tenant -> number of entries
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		tenant := req.Header.Get("X-Scope-OrgID")
+		if tenant == "slow" {
+			// Requests of the slow tenant are always retried.
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var pushReq logproto.PushRequest
+		if err := util.ParseProtoReader(req.Context(), req.Body, int(req.ContentLength), math.MaxInt32, &pushReq, util.RawSnappy); err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		for _, s := range pushReq.Streams {
+			received[tenant] += len(s.Entries)
+		}
+	}))
+	defer server.Close()
+
+	var serverURL flagext.URLValue
+	require.NoError(t, serverURL.Set(server.URL))
+
+	tq, err := NewTenantQueues(NewMetrics(nil, nil), TenantQueuesConfig{
+		MaxTenants:  2,
+		QueueSize:   10,
+		IdleTimeout: time.Minute,
+	}, Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     1,
+		Client:        config.HTTPClientConfig{},
+		BackoffConfig: backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond},
+		Timeout:       time.Second,
+	}, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+
+	send := func(tenant string) {
+		tq.Chan() <- loki.Entry{
+			Labels: model.LabelSet{ReservedLabelTenantID: model.LabelValue(tenant), "job": "test"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: "line"},
+		}
+	}
+
+	// Fill up the queue of the slow tenant.
+	for i := 0; i < 50; i++ {
+		send("slow")
+	}
+
+	// Entries of other tenants are still sent.
+	for i := 0; i < 3; i++ {
+		send("fast")
+	}
+	require.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return received["fast"] == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Only two tenants can be queued at once.
+	send("other")
+
+	tq.StopNow()
+
+	dropped := func(tenant, reason string) float64 {
+		return testutil.ToFloat64(tq.metrics.tenantDroppedEntries.WithLabelValues(serverURL.Host, tenant, reason))
+	}
+	require.Equal(t, 1.0, dropped("other", reasonMaxTenants))
+
+	// Some entries of the slow tenant were dropped because its queue was full.
+	require.Greater(t, dropped("slow", reasonQueueFull), 0.0)
+	require.Zero(t, dropped("fast", reasonQueueFull))
+}
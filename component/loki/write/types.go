@@ -8,6 +8,7 @@ import (
 	"github.com/alecthomas/units"
 	types "github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/loki/write/internal/client"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	lokiflagext "github.com/grafana/loki/pkg/util/flagext"
@@ -26,6 +27,64 @@ type EndpointOptions struct {
 	MaxBackoffRetries int                     `river:"max_backoff_retries,attr,optional"` // give up after this many; zero means infinite retries
 	TenantID          string                  `river:"tenant_id,attr,optional"`
 	HTTPClientConfig  *types.HTTPClientConfig `river:",squash"`
+	AzureAD           *AzureADArguments       `river:"azuread,block,optional"`
+}
+
+// AzureADArguments configures authenticating to an endpoint with an Azure AD
+// token.
+type AzureADArguments struct {
+	Cloud           string                           `river:"cloud,attr,optional"`
+	Scope           string                           `river:"scope,attr"`
+	ManagedIdentity *AzureADManagedIdentityArguments `river:"managed_identity,block,optional"`
+	OAuth           *AzureADOAuthArguments           `river:"oauth,block,optional"`
+}
+
+// AzureADManagedIdentityArguments configures authenticating with an Azure
+// managed identity.
+type AzureADManagedIdentityArguments struct {
+	ClientID string `river:"client_id,attr,optional"`
+}
+
+// AzureADOAuthArguments configures authenticating with the client
+// credentials of an Azure AD application.
+type AzureADOAuthArguments struct {
+	ClientID     string            `river:"client_id,attr"`
+	ClientSecret rivertypes.Secret `river:"client_secret,attr"`
+	TenantID     string            `river:"tenant_id,attr"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *AzureADArguments) UnmarshalRiver(f func(v interface{}) error) error {
+	*a = AzureADArguments{Cloud: client.AzurePublic}
+
+	type arguments AzureADArguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+	return a.Convert().Validate()
+}
+
+// Convert converts a into the client type.
+func (a *AzureADArguments) Convert() *client.AzureADConfig {
+	if a == nil {
+		return nil
+	}
+
+	res := &client.AzureADConfig{
+		Cloud: a.Cloud,
+		Scope: a.Scope,
+	}
+	if a.ManagedIdentity != nil {
+		res.ManagedIdentity = &client.AzureADManagedIdentityConfig{ClientID: a.ManagedIdentity.ClientID}
+	}
+	if a.OAuth != nil {
+		res.OAuth = &client.AzureADOAuthConfig{
+			ClientID:     a.OAuth.ClientID,
+			ClientSecret: string(a.OAuth.ClientSecret),
+			TenantID:     a.OAuth.TenantID,
+		}
+	}
+	return res
 }
 
 // GetDefaultEndpointOptions defines the default settings for sending logs to a
@@ -62,37 +121,138 @@ func (r *EndpointOptions) UnmarshalRiver(f func(v interface{}) error) error {
 
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
-		return r.HTTPClientConfig.Validate()
+		if err := r.HTTPClientConfig.Validate(); err != nil {
+			return err
+		}
+
+		httpClientAuthEnabled := r.HTTPClientConfig.BasicAuth != nil ||
+			r.HTTPClientConfig.Authorization != nil || r.HTTPClientConfig.OAuth2 != nil
+		if httpClientAuthEnabled && r.AzureAD != nil {
+			return fmt.Errorf("at most one of basic_auth, authorization, oauth2, & azuread must be configured")
+		}
+	}
+
+	return nil
+}
+
+// TenantQueuesArguments configures sending the entries of each tenant through a
+// separate queue.
+type TenantQueuesArguments struct {
+	MaxTenants  int           `river:"max_tenants,attr,optional"`
+	QueueSize   int           `river:"queue_size,attr,optional"`
+	IdleTimeout time.Duration `river:"idle_timeout,attr,optional"`
+}
+
+// DefaultTenantQueuesArguments holds default settings for
+// TenantQueuesArguments.
+var DefaultTenantQueuesArguments = TenantQueuesArguments{
+	MaxTenants:  100,
+	QueueSize:   10000,
+	IdleTimeout: 10 * time.Minute,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *TenantQueuesArguments) UnmarshalRiver(f func(v interface{}) error) error {
+	*a = DefaultTenantQueuesArguments
+
+	type arguments TenantQueuesArguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+
+	switch {
+	case a.MaxTenants <= 0:
+		return fmt.Errorf("max_tenants must be greater than 0")
+	case a.QueueSize <= 0:
+		return fmt.Errorf("queue_size must be greater than 0")
+	case a.IdleTimeout <= 0:
+		return fmt.Errorf("idle_timeout must be greater than 0")
+	}
+	return nil
+}
+
+// Convert converts a into the client type.
+func (a *TenantQueuesArguments) Convert() client.TenantQueuesConfig {
+	return client.TenantQueuesConfig{
+		MaxTenants:  a.MaxTenants,
+		QueueSize:   a.QueueSize,
+		IdleTimeout: a.IdleTimeout,
+	}
+}
+
+// FailoverArguments configures a group of two endpoints, where entries are
+// only sent to the secondary endpoint while the primary endpoint is
+// unhealthy.
+type FailoverArguments struct {
+	Name          string          `river:"name,attr,optional"`
+	FailoverAfter time.Duration   `river:"failover_after,attr,optional"`
+	FailbackAfter time.Duration   `river:"failback_after,attr,optional"`
+	Primary       EndpointOptions `river:"primary,block"`
+	Secondary     EndpointOptions `river:"secondary,block"`
+}
+
+// DefaultFailoverArguments holds default settings for FailoverArguments.
+var DefaultFailoverArguments = FailoverArguments{
+	FailoverAfter: 30 * time.Second,
+	FailbackAfter: time.Minute,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *FailoverArguments) UnmarshalRiver(f func(v interface{}) error) error {
+	*a = DefaultFailoverArguments
+
+	type arguments FailoverArguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
 	}
 
+	switch {
+	case a.FailoverAfter <= 0:
+		return fmt.Errorf("failover_after must be greater than 0")
+	case a.FailbackAfter <= 0:
+		return fmt.Errorf("failback_after must be greater than 0")
+	}
 	return nil
 }
 
+// Convert converts a into the client type.
+func (a *FailoverArguments) Convert() client.FailoverConfig {
+	return client.FailoverConfig{
+		Name:          a.Name,
+		FailoverAfter: a.FailoverAfter,
+		FailbackAfter: a.FailbackAfter,
+	}
+}
+
 func (args Arguments) convertClientConfigs() []client.Config {
 	var res []client.Config
 	for _, cfg := range args.Endpoints {
-		url, _ := url.Parse(cfg.URL)
-		cc := client.Config{
-			Name:      cfg.Name,
-			URL:       flagext.URLValue{URL: url},
-			BatchWait: cfg.BatchWait,
-			BatchSize: int(cfg.BatchSize),
-			Client:    *cfg.HTTPClientConfig.Convert(),
-			BackoffConfig: backoff.Config{
-				MinBackoff: cfg.MinBackoff,
-				MaxBackoff: cfg.MaxBackoff,
-				MaxRetries: cfg.MaxBackoffRetries,
-			},
-			ExternalLabels: lokiflagext.LabelSet{LabelSet: toLabelSet(args.ExternalLabels)},
-			Timeout:        cfg.RemoteTimeout,
-			TenantID:       cfg.TenantID,
-		}
-		res = append(res, cc)
+		res = append(res, args.convertClientConfig(cfg))
 	}
 
 	return res
 }
 
+func (args Arguments) convertClientConfig(cfg EndpointOptions) client.Config {
+	url, _ := url.Parse(cfg.URL)
+	return client.Config{
+		Name:      cfg.Name,
+		URL:       flagext.URLValue{URL: url},
+		BatchWait: cfg.BatchWait,
+		BatchSize: int(cfg.BatchSize),
+		Client:    *cfg.HTTPClientConfig.Convert(),
+		AzureAD:   cfg.AzureAD.Convert(),
+		BackoffConfig: backoff.Config{
+			MinBackoff: cfg.MinBackoff,
+			MaxBackoff: cfg.MaxBackoff,
+			MaxRetries: cfg.MaxBackoffRetries,
+		},
+		ExternalLabels: lokiflagext.LabelSet{LabelSet: toLabelSet(args.ExternalLabels)},
+		Timeout:        cfg.RemoteTimeout,
+		TenantID:       cfg.TenantID,
+	}
+}
+
 func toLabelSet(in map[string]string) model.LabelSet {
 	res := make(model.LabelSet, len(in))
 	for k, v := range in {
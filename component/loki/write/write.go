@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/deadletter"
 	"github.com/grafana/agent/component/loki/write/internal/client"
 	"github.com/grafana/agent/pkg/build"
 )
@@ -22,6 +23,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/loki"},
 	})
 
 	client.UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
@@ -32,6 +35,10 @@ type Arguments struct {
 	Endpoints      []EndpointOptions `river:"endpoint,block,optional"`
 	ExternalLabels map[string]string `river:"external_labels,attr,optional"`
 	MaxStreams     int               `river:"max_streams,attr,optional"`
+
+	Failover        []FailoverArguments    `river:"failover,block,optional"`
+	TenantQueues    *TenantQueuesArguments `river:"tenant_queues,block,optional"`
+	DeadLetterQueue *deadletter.Arguments  `river:"dead_letter_queue,block,optional"`
 }
 
 // Exports holds the receiver that is used to send log entries to the
@@ -46,8 +53,9 @@ var (
 
 // Component implements the loki.write component.
 type Component struct {
-	opts    component.Options
-	metrics *client.Metrics
+	opts       component.Options
+	metrics    *client.Metrics
+	deadLetter *deadletter.Queue
 
 	mut      sync.RWMutex
 	args     Arguments
@@ -57,9 +65,15 @@ type Component struct {
 
 // New creates a new loki.write component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	deadLetter, err := deadletter.New(o.Logger, o.Registerer, o.DataPath)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Component{
-		opts:    o,
-		metrics: client.NewMetrics(o.Registerer, streamLagLabels),
+		opts:       o,
+		metrics:    client.NewMetrics(o.Registerer, streamLagLabels),
+		deadLetter: deadLetter,
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -77,11 +91,22 @@ func New(o component.Options, args Arguments) (*Component, error) {
 
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
+	defer c.deadLetter.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case entry := <-c.receiver:
+			// Each client acknowledges its own copy of the entry.
+			var numClients int
+			for _, client := range c.clients {
+				if client != nil {
+					numClients++
+				}
+			}
+			entry.Ack.Add(numClients - 1)
+
 			for _, client := range c.clients {
 				if client != nil {
 					select {
@@ -109,19 +134,66 @@ func (c *Component) Update(args component.Arguments) error {
 			client.Stop()
 		}
 	}
-	c.clients = make([]client.Client, len(newArgs.Endpoints))
+	c.clients = make([]client.Client, 0, len(newArgs.Endpoints)+len(newArgs.Failover))
+
+	if err := c.deadLetter.Update(newArgs.DeadLetterQueue); err != nil {
+		return err
+	}
 
 	cfgs := newArgs.convertClientConfigs()
 	// TODO (@tpaschalis) We could use a client.NewMulti here to push the
 	// fanout logic back to the client layer, but I opted to keep it explicit
 	// here a) for easier debugging and b) possible improvements in the future.
 	for _, cfg := range cfgs {
-		client, err := client.New(c.metrics, cfg, streamLagLabels, newArgs.MaxStreams, c.opts.Logger)
+		cl, err := c.newClient(newArgs, cfg)
+		if err != nil {
+			return err
+		}
+		c.clients = append(c.clients, cl)
+	}
+
+	for _, failover := range newArgs.Failover {
+		cl, err := c.newFailoverGroup(newArgs, failover)
 		if err != nil {
 			return err
 		}
-		c.clients = append(c.clients, client)
+		c.clients = append(c.clients, cl)
 	}
 
 	return nil
 }
+
+// newClient creates a client sending entries to the endpoint of cfg.
+func (c *Component) newClient(args Arguments, cfg client.Config) (client.Client, error) {
+	cfg.DeadLetter = c.deadLetter
+
+	if args.TenantQueues != nil {
+		return client.NewTenantQueues(c.metrics, args.TenantQueues.Convert(), cfg, streamLagLabels, args.MaxStreams, c.opts.Logger)
+	}
+	return client.New(c.metrics, cfg, streamLagLabels, args.MaxStreams, c.opts.Logger)
+}
+
+// newFailoverGroup creates a client sending entries to the primary endpoint
+// of failover, or to its secondary endpoint while the primary is unhealthy.
+func (c *Component) newFailoverGroup(args Arguments, failover FailoverArguments) (client.Client, error) {
+	primaryCfg := args.convertClientConfig(failover.Primary)
+	primaryCfg.Health = client.NewHealth()
+
+	primary, err := c.newClient(args, primaryCfg)
+	if err != nil {
+		return nil, err
+	}
+	secondary, err := c.newClient(args, args.convertClientConfig(failover.Secondary))
+	if err != nil {
+		primary.StopNow()
+		return nil, err
+	}
+
+	group, err := client.NewFailoverGroup(c.metrics, failover.Convert(), primary, secondary, primaryCfg, c.opts.Logger)
+	if err != nil {
+		primary.StopNow()
+		secondary.StopNow()
+		return nil, err
+	}
+	return group, nil
+}
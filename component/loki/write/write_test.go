@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/write/internal/client"
 	"github.com/grafana/agent/pkg/flow/componenttest"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
@@ -50,6 +51,157 @@ func TestBadRiverConfig(t *testing.T) {
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
 
+func TestAzureADRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	endpoint {
+		url = "http://0.0.0.0:11111/loki/api/v1/push"
+
+		azuread {
+			scope = "api://loki/.default"
+
+			oauth {
+				client_id     = "client-id"
+				client_secret = "client-secret"
+				tenant_id     = "tenant-id"
+			}
+		}
+	}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+
+	cfgs := args.convertClientConfigs()
+	require.Len(t, cfgs, 1)
+	require.Equal(t, "AzurePublic", cfgs[0].AzureAD.Cloud)
+	require.Equal(t, "api://loki/.default", cfgs[0].AzureAD.Scope)
+	require.Equal(t, "client-secret", cfgs[0].AzureAD.OAuth.ClientSecret)
+}
+
+func TestTenantQueuesRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	endpoint {
+		url = "http://0.0.0.0:11111/loki/api/v1/push"
+	}
+
+	tenant_queues {
+		max_tenants = 10
+	}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Equal(t, client.TenantQueuesConfig{
+		MaxTenants:  10,
+		QueueSize:   10000,
+		IdleTimeout: 10 * time.Minute,
+	}, args.TenantQueues.Convert())
+
+	err := river.Unmarshal([]byte(`tenant_queues { queue_size = 0 }`), &args)
+	require.EqualError(t, err, "queue_size must be greater than 0")
+}
+
+func TestFailoverRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	failover {
+		name           = "main"
+		failover_after = "1m"
+
+		primary {
+			url = "http://loki-a:3100/loki/api/v1/push"
+		}
+		secondary {
+			url            = "http://loki-b:3100/loki/api/v1/push"
+			remote_timeout = "5s"
+		}
+	}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Len(t, args.Failover, 1)
+	require.Equal(t, client.FailoverConfig{
+		Name:          "main",
+		FailoverAfter: time.Minute,
+		FailbackAfter: time.Minute,
+	}, args.Failover[0].Convert())
+
+	// Endpoints of failover groups get the default endpoint settings.
+	require.Equal(t, 10*time.Second, args.Failover[0].Primary.RemoteTimeout)
+	require.Equal(t, 5*time.Second, args.Failover[0].Secondary.RemoteTimeout)
+
+	err := river.Unmarshal([]byte(`
+	failover {
+		failback_after = "0s"
+		primary { url = "http://loki-a:3100/loki/api/v1/push" }
+		secondary { url = "http://loki-b:3100/loki/api/v1/push" }
+	}`), &args)
+	require.EqualError(t, err, "failback_after must be greater than 0")
+}
+
+func TestBadAzureADRiverConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "missing credentials",
+			cfg: `
+			endpoint {
+				url = "http://0.0.0.0:11111/loki/api/v1/push"
+
+				azuread {
+					scope = "api://loki/.default"
+				}
+			}`,
+			expectedErr: "one of azuread managed_identity or oauth must be provided",
+		},
+		{
+			name: "unknown cloud",
+			cfg: `
+			endpoint {
+				url = "http://0.0.0.0:11111/loki/api/v1/push"
+
+				azuread {
+					cloud = "AzureMoon"
+					scope = "api://loki/.default"
+
+					managed_identity { }
+				}
+			}`,
+			expectedErr: `unknown azuread cloud "AzureMoon"`,
+		},
+		{
+			name: "combined with basic_auth",
+			cfg: `
+			endpoint {
+				url = "http://0.0.0.0:11111/loki/api/v1/push"
+
+				basic_auth {
+					username = "user"
+					password = "pass"
+				}
+
+				azuread {
+					scope = "api://loki/.default"
+
+					managed_identity { }
+				}
+			}`,
+			expectedErr: "at most one of basic_auth, authorization, oauth2, & azuread must be configured",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
 func Test(t *testing.T) {
 	// Set up the server that will receive the log entry, and expose it on ch.
 	ch := make(chan logproto.PushRequest)
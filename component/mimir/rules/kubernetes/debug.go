@@ -1,6 +1,6 @@
 package rules
 
-import "fmt"
+import "github.com/grafana/agent/component/common/ruler"
 
 type DebugInfo struct {
 	Error               string                   `river:"error,attr,optional"`
@@ -8,57 +8,28 @@ type DebugInfo struct {
 	MimirRuleNamespaces []DebugMimirNamespace    `river:"mimir_rule_namespace,block,optional"`
 }
 
-type DebugK8sPrometheusRule struct {
-	Namespace     string `river:"namespace,attr"`
-	Name          string `river:"name,attr"`
-	UID           string `river:"uid,attr"`
-	NumRuleGroups int    `river:"num_rule_groups,attr"`
-}
+type DebugK8sPrometheusRule = ruler.DebugK8sPrometheusRule
 
 type DebugMimirNamespace struct {
+	Tenant        string `river:"tenant,attr,optional"`
 	Name          string `river:"name,attr"`
 	NumRuleGroups int    `river:"num_rule_groups,attr"`
 }
 
+// DebugInfo implements component.DebugComponent.
 func (c *Component) DebugInfo() interface{} {
-	var output DebugInfo
-	for ns := range c.currentState {
-		if !isManagedMimirNamespace(c.args.MimirNameSpacePrefix, ns) {
-			continue
-		}
+	info := c.Reconciler.DebugInfo()
 
+	output := DebugInfo{
+		Error:           info.Error,
+		PrometheusRules: info.PrometheusRules,
+	}
+	for _, ns := range info.RuleNamespaces {
 		output.MimirRuleNamespaces = append(output.MimirRuleNamespaces, DebugMimirNamespace{
-			Name:          ns,
-			NumRuleGroups: len(c.currentState[ns]),
+			Tenant:        ns.Tenant,
+			Name:          ns.Name,
+			NumRuleGroups: ns.NumRuleGroups,
 		})
 	}
-
-	// This should load from the informer cache, so it shouldn't fail under normal circumstances.
-	managedK8sNamespaces, err := c.namespaceLister.List(c.namespaceSelector)
-	if err != nil {
-		return DebugInfo{
-			Error: fmt.Sprintf("failed to list namespaces: %v", err),
-		}
-	}
-
-	for _, n := range managedK8sNamespaces {
-		// This should load from the informer cache, so it shouldn't fail under normal circumstances.
-		rules, err := c.ruleLister.PrometheusRules(n.Name).List(c.ruleSelector)
-		if err != nil {
-			return DebugInfo{
-				Error: fmt.Sprintf("failed to list rules: %v", err),
-			}
-		}
-
-		for _, r := range rules {
-			output.PrometheusRules = append(output.PrometheusRules, DebugK8sPrometheusRule{
-				Namespace:     n.Name,
-				Name:          r.Name,
-				UID:           string(r.UID),
-				NumRuleGroups: len(r.Spec.Groups),
-			})
-		}
-	}
-
 	return output
 }
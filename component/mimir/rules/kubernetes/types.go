@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/ruler"
 )
 
 type Arguments struct {
@@ -14,9 +15,11 @@ type Arguments struct {
 	HTTPClientConfig     config.HTTPClientConfig `river:",squash"`
 	SyncInterval         time.Duration           `river:"sync_interval,attr,optional"`
 	MimirNameSpacePrefix string                  `river:"mimir_namespace_prefix,attr,optional"`
+	NamespaceTenantIDs   map[string]string       `river:"namespace_tenant_ids,attr,optional"`
 
 	RuleSelector          LabelSelector `river:"rule_selector,block,optional"`
 	RuleNamespaceSelector LabelSelector `river:"rule_namespace_selector,block,optional"`
+	RuleGroupSelector     LabelSelector `river:"rule_group_selector,block,optional"`
 }
 
 var DefaultArguments = Arguments{
@@ -39,18 +42,34 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	if args.MimirNameSpacePrefix == "" {
 		return fmt.Errorf("mimir_namespace_prefix must not be empty")
 	}
+	for ns, tenant := range args.NamespaceTenantIDs {
+		if tenant == "" {
+			return fmt.Errorf("namespace_tenant_ids: tenant ID for namespace %q must not be empty", ns)
+		}
+	}
 
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	return args.HTTPClientConfig.Validate()
 }
 
-type LabelSelector struct {
-	MatchLabels      map[string]string `river:"match_labels,attr,optional"`
-	MatchExpressions []MatchExpression `river:"match_expression,block,optional"`
-}
+type (
+	LabelSelector   = ruler.LabelSelector
+	MatchExpression = ruler.MatchExpression
+)
 
-type MatchExpression struct {
-	Key      string   `river:"key,attr"`
-	Operator string   `river:"operator,attr"`
-	Values   []string `river:"values,attr,optional"`
+// rulerArguments converts args to the arguments of the reconciler.
+func (args Arguments) rulerArguments() ruler.Arguments {
+	return ruler.Arguments{
+		Address:            args.Address,
+		TenantID:           args.TenantID,
+		UseLegacyRoutes:    args.UseLegacyRoutes,
+		HTTPClientConfig:   args.HTTPClientConfig,
+		SyncInterval:       args.SyncInterval,
+		NamespacePrefix:    args.MimirNameSpacePrefix,
+		NamespaceTenantIDs: args.NamespaceTenantIDs,
+
+		RuleSelector:          args.RuleSelector,
+		RuleNamespaceSelector: args.RuleNamespaceSelector,
+		RuleGroupSelector:     args.RuleGroupSelector,
+	}
 }
@@ -0,0 +1,354 @@
+// Package agent_management implements the module.agent_management component.
+package agent_management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	common_config "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/auditlog"
+	"github.com/grafana/agent/pkg/river"
+	prom_config "github.com/prometheus/common/config"
+)
+
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "module.agent_management",
+		Args:    Arguments{},
+		Exports: module.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// module.agent_management component.
+type Arguments struct {
+	URL           string        `river:"url,attr"`
+	Namespace     string        `river:"namespace,attr"`
+	ModuleName    string        `river:"module_name,attr"`
+	Version       string        `river:"version,attr,optional"`
+	RolloutKey    string        `river:"rollout_key,attr,optional"`
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+	PollTimeout   time.Duration `river:"poll_timeout,attr,optional"`
+
+	Client common_config.HTTPClientConfig `river:"client,block,optional"`
+
+	// Arguments to pass into the module.
+	Arguments map[string]any `river:"arguments,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	PollFrequency: 1 * time.Minute,
+	PollTimeout:   10 * time.Second,
+	Client:        common_config.DefaultHTTPClientConfig,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Namespace == "":
+		return fmt.Errorf("namespace must not be empty")
+	case args.ModuleName == "":
+		return fmt.Errorf("module_name must not be empty")
+	case args.PollFrequency <= 0:
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	case args.PollTimeout <= 0:
+		return fmt.Errorf("poll_timeout must be greater than 0")
+	case args.PollTimeout >= args.PollFrequency:
+		return fmt.Errorf("poll_timeout must be less than poll_frequency")
+	}
+
+	return args.Client.Validate()
+}
+
+// DebugInfo holds debug information for module.agent_management.
+type DebugInfo struct {
+	Version string `river:"version,attr,optional"`
+	Source  string `river:"source,attr,optional"`
+}
+
+// Component implements the module.agent_management component.
+type Component struct {
+	log  log.Logger
+	opts component.Options
+	mod  module.ModuleComponent
+
+	mut      sync.Mutex
+	args     Arguments
+	cli      *http.Client
+	lastPoll time.Time
+	loaded   *moduleRelease // Release currently running, nil if none.
+	source   string         // Where the running release was loaded from.
+
+	// Updated is written to whenever args updates.
+	updated chan struct{}
+}
+
+var (
+	_ component.Component             = (*Component)(nil)
+	_ component.HealthComponent       = (*Component)(nil)
+	_ component.HTTPComponent         = (*Component)(nil)
+	_ flow.ModuleControllersComponent = (*Component)(nil)
+	_ component.DebugComponent        = (*Component)(nil)
+)
+
+// New creates a new module.agent_management component. The last release
+// loaded by a previous run of the component is loaded before polling, so the
+// module keeps running if the agent management API can't be reached.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		log:     o.Logger,
+		opts:    o,
+		mod:     module.NewModuleComponent(o),
+		args:    args,
+		updated: make(chan struct{}, 1),
+	}
+
+	if url, err := moduleURL(args); err == nil {
+		if release, err := readCache(o.DataPath, url); err != nil {
+			level.Debug(c.log).Log("msg", "no cached module release loaded", "err", err)
+		} else if err := c.mod.LoadFlowContent(releaseTrigger(auditlog.TriggerStartup, release), args.Arguments, release.Content); err != nil {
+			level.Warn(c.log).Log("msg", "failed to load cached module release", "version", release.Version, "err", err)
+		} else {
+			c.loaded, c.source = &release, "cache"
+		}
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	go c.mod.RunFlowController(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.nextPoll()):
+			c.poll()
+		case <-c.updated:
+			// no-op; force the next wait to be reread.
+		}
+	}
+}
+
+// nextPoll returns how long to wait to poll given the last time a
+// poll occurred. nextPoll returns 0 if a poll should occur immediately.
+func (c *Component) nextPoll() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	nextPoll := c.lastPoll.Add(c.args.PollFrequency)
+	now := time.Now()
+
+	if now.After(nextPoll) {
+		// Poll immediately; next poll period was in the past.
+		return 0
+	}
+	return nextPoll.Sub(now)
+}
+
+// poll requests the releases of the module and loads the selected release if
+// it changed. c.mut must not be held when calling. Successfully loading a
+// release updates the health of the component; failures mark it unhealthy
+// while the previously loaded release keeps running.
+func (c *Component) poll() {
+	startTime := time.Now()
+	if err := c.pollError(); err != nil {
+		level.Error(c.log).Log("msg", "failed to poll module release", "err", err)
+		c.mod.SetHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("polling failed: %s", err),
+			UpdateTime: startTime,
+		})
+	}
+}
+
+// pollError is like poll but returns an error if one occurred.
+func (c *Component) pollError() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.lastPoll = time.Now()
+
+	url, err := moduleURL(c.args)
+	if err != nil {
+		return err
+	}
+	releases, err := c.fetchReleases(url)
+	if err != nil {
+		return err
+	}
+
+	rolloutKey := c.args.RolloutKey
+	if rolloutKey == "" {
+		rolloutKey, _ = os.Hostname()
+	}
+	release, err := selectRelease(releases, c.args.ModuleName, c.args.Version, rolloutKey)
+	if err != nil {
+		return err
+	}
+
+	if c.loaded != nil && c.loaded.Version == release.Version && c.loaded.Content == release.Content {
+		c.source = "api"
+		c.mod.SetHealth(component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    fmt.Sprintf("module version %s is up to date", release.Version),
+			UpdateTime: time.Now(),
+		})
+		return nil
+	}
+
+	if err := c.mod.LoadFlowContent(releaseTrigger(auditlog.TriggerRemoteConfig, release), c.args.Arguments, release.Content); err != nil {
+		return fmt.Errorf("loading version %s: %w", release.Version, err)
+	}
+	level.Info(c.log).Log("msg", "loaded module release", "version", release.Version)
+	c.loaded, c.source = &release, "api"
+
+	if err := writeCache(c.opts.DataPath, url, release); err != nil {
+		level.Warn(c.log).Log("msg", "could not cache module release", "err", err)
+	}
+	return nil
+}
+
+// releaseTrigger returns the audit log trigger of loading release.
+func releaseTrigger(kind string, release moduleRelease) auditlog.Trigger {
+	return auditlog.Trigger{Kind: kind, Revision: release.Version}
+}
+
+func (c *Component) fetchReleases(url string) ([]moduleRelease, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.args.PollTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+
+	var res moduleResponse
+	if err := json.Unmarshal(bb, &res); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if res.FormatVersion != moduleFormatVersion {
+		return nil, fmt.Errorf("unsupported format_version %d of response, expected %d", res.FormatVersion, moduleFormatVersion)
+	}
+	return res.Releases, nil
+}
+
+// Update implements component.Component. After the update completes, a poll
+// is forced.
+func (c *Component) Update(args component.Arguments) (err error) {
+	// poll after updating. If an error occurred during Update, we don't bother
+	// to do anything.
+	defer func() {
+		if err != nil {
+			return
+		}
+		c.poll()
+	}()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+	argumentsChanged := !reflect.DeepEqual(c.args.Arguments, newArgs.Arguments)
+	c.args = newArgs
+
+	cli, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		c.opts.ID,
+		prom_config.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return err
+	}
+	c.cli = cli
+
+	// Reload the running release so that it uses the new module arguments,
+	// even if polling fails.
+	if c.loaded != nil && argumentsChanged {
+		if err := c.mod.LoadFlowContent(releaseTrigger(auditlog.TriggerComponentUpdate, *c.loaded), newArgs.Arguments, c.loaded.Content); err != nil {
+			level.Warn(c.log).Log("msg", "failed to reload module with new arguments", "err", err)
+		}
+	}
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Handler implements component.HTTPComponent.
+func (c *Component) Handler() http.Handler {
+	return c.mod.Handler()
+}
+
+// ModuleControllers implements flow.ModuleControllersComponent.
+func (c *Component) ModuleControllers() []*flow.Flow {
+	return c.mod.ModuleControllers()
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.mod.CurrentHealth()
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.loaded == nil {
+		return DebugInfo{}
+	}
+	return DebugInfo{Version: c.loaded.Version, Source: c.source}
+}
@@ -0,0 +1,183 @@
+package agent_management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testContent(version string) string {
+	return `
+	argument "suffix" {
+		optional = true
+		default  = ""
+	}
+
+	export "version" {
+		value = "` + version + `" + argument.suffix.value
+	}`
+}
+
+func TestModule(t *testing.T) {
+	var (
+		mut           sync.Mutex
+		formatVersion = moduleFormatVersion
+		releases      []moduleRelease
+		exports       module.Exports
+	)
+	setReleases := func(r ...moduleRelease) {
+		mut.Lock()
+		defer mut.Unlock()
+		releases = r
+	}
+	getExport := func() any {
+		mut.Lock()
+		defer mut.Unlock()
+		return exports.Exports["version"]
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agent-management/api/agent/v2/namespace/team-a/module/logs" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("format_version") != "1" {
+			http.Error(w, "unsupported format_version", http.StatusBadRequest)
+			return
+		}
+		mut.Lock()
+		defer mut.Unlock()
+		_ = json.NewEncoder(w).Encode(moduleResponse{FormatVersion: formatVersion, Releases: releases})
+	}))
+	defer srv.Close()
+
+	setReleases(moduleRelease{Version: "v1", Content: testContent("v1")})
+
+	dataPath := t.TempDir()
+	opts := component.Options{
+		ID:       "module.agent_management.test",
+		Logger:   util.TestFlowLogger(t),
+		DataPath: dataPath,
+		HTTPPath: "/component/module.agent_management.test/",
+		OnStateChange: func(e component.Exports) {
+			mut.Lock()
+			defer mut.Unlock()
+			exports = e.(module.Exports)
+		},
+	}
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		url            = "`+srv.URL+`"
+		namespace      = "team-a"
+		module_name    = "logs"
+		rollout_key    = "agent-1"
+		poll_frequency = "50ms"
+		poll_timeout   = "25ms"
+	`), &args))
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool { return getExport() == "v1" }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, DebugInfo{Version: "v1", Source: "api"}, c.DebugInfo())
+
+	// A release rolled out to 0% of agents must be skipped.
+	zero := 0.0
+	setReleases(
+		moduleRelease{Version: "v2", Content: testContent("v2"), RolloutPercentage: &zero},
+		moduleRelease{Version: "v1", Content: testContent("v1")},
+	)
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, "v1", getExport())
+
+	// Fully rolling out the release loads it.
+	setReleases(
+		moduleRelease{Version: "v2", Content: testContent("v2")},
+		moduleRelease{Version: "v1", Content: testContent("v1")},
+	)
+	require.Eventually(t, func() bool { return getExport() == "v2" }, 5*time.Second, 10*time.Millisecond)
+
+	// Pinning a version loads it even if a newer release exists, and new
+	// module arguments are passed to it.
+	args.Version = "v1"
+	args.Arguments = map[string]any{"suffix": "!"}
+	require.NoError(t, c.Update(args))
+	require.Eventually(t, func() bool { return getExport() == "v1!" }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+
+	// Responses in an unsupported format are rejected, and the loaded release
+	// keeps running.
+	mut.Lock()
+	formatVersion = 2
+	mut.Unlock()
+	require.Eventually(t, func() bool {
+		return c.CurrentHealth().Health == component.HealthTypeUnhealthy
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, c.CurrentHealth().Message, "format_version")
+	require.Equal(t, "v1!", getExport())
+
+	// The last loaded release is cached so it can be loaded if the API is
+	// unreachable.
+	cancel()
+	srv.Close()
+
+	c, err = New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, DebugInfo{Version: "v1", Source: "cache"}, c.DebugInfo())
+	require.Equal(t, component.HealthTypeUnhealthy, c.CurrentHealth().Health)
+}
+
+func TestSelectRelease(t *testing.T) {
+	var (
+		zero    = 0.0
+		hundred = 100.0
+	)
+	releases := []moduleRelease{
+		{Version: "v3", RolloutPercentage: &zero},
+		{Version: "v2", RolloutPercentage: &hundred},
+		{Version: "v1"},
+	}
+
+	r, err := selectRelease(releases, "logs", "", "agent-1")
+	require.NoError(t, err)
+	require.Equal(t, "v2", r.Version)
+
+	r, err = selectRelease(releases, "logs", "v3", "agent-1")
+	require.NoError(t, err)
+	require.Equal(t, "v3", r.Version)
+
+	_, err = selectRelease(releases, "logs", "v4", "agent-1")
+	require.EqualError(t, err, `pinned version "v4" of module "logs" not found`)
+
+	_, err = selectRelease(releases[:1], "logs", "", "agent-1")
+	require.EqualError(t, err, `no release of module "logs" is rolled out to this agent`)
+}
+
+func TestRolloutBucket(t *testing.T) {
+	// Roughly half of the agents should be included in a 50% rollout.
+	var included int
+	for i := 0; i < 1000; i++ {
+		key := "agent-" + string(rune('a'+i%26)) + string(rune('a'+i/26))
+		if rolloutBucket(key, "logs") < 50 {
+			included++
+		}
+	}
+	require.InDelta(t, 500, included, 100)
+
+	require.Equal(t, rolloutBucket("agent-1", "logs"), rolloutBucket("agent-1", "logs"))
+}
@@ -0,0 +1,134 @@
+package agent_management
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// apiPath is the path of the agent management API, shared with the remote
+// configuration of static mode.
+const apiPath = "/agent-management/api/agent/v2"
+
+// moduleFormatVersion is the version of the format of moduleResponse. It's
+// sent in requests so the API can respond in a format the component supports,
+// and responses in any other format are rejected.
+const moduleFormatVersion = 1
+
+// moduleResponse is the response of the agent management API for a module.
+type moduleResponse struct {
+	// FormatVersion is the version of the format of the response.
+	FormatVersion int `json:"format_version"`
+	// Releases of the module, ordered from newest to oldest.
+	Releases []moduleRelease `json:"releases"`
+}
+
+// moduleRelease is a single version of a module.
+type moduleRelease struct {
+	Version string `json:"version"`
+	Content string `json:"content"`
+
+	// RolloutPercentage is the percentage of agents which should run the
+	// release while it's being rolled out. Releases which are fully rolled out
+	// omit it.
+	RolloutPercentage *float64 `json:"rollout_percentage,omitempty"`
+}
+
+// moduleURL returns the URL to request the releases of the module configured
+// in args from.
+func moduleURL(args Arguments) (string, error) {
+	fullPath, err := url.JoinPath(args.URL, apiPath, "namespace", args.Namespace, "module", args.ModuleName)
+	if err != nil {
+		return "", fmt.Errorf("error trying to join url: %w", err)
+	}
+	u, err := url.Parse(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("error trying to parse url: %w", err)
+	}
+	q := u.Query()
+	q.Set("format_version", strconv.Itoa(moduleFormatVersion))
+	if args.Version != "" {
+		q.Set("version", args.Version)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// selectRelease returns the release of the module to run. If version is set,
+// the release with that version is returned. Otherwise, the newest release
+// whose rollout includes the agent identified by rolloutKey is returned.
+func selectRelease(releases []moduleRelease, moduleName, version, rolloutKey string) (moduleRelease, error) {
+	if version != "" {
+		for _, r := range releases {
+			if r.Version == version {
+				return r, nil
+			}
+		}
+		return moduleRelease{}, fmt.Errorf("pinned version %q of module %q not found", version, moduleName)
+	}
+
+	bucket := rolloutBucket(rolloutKey, moduleName)
+	for _, r := range releases {
+		if r.RolloutPercentage == nil || bucket < *r.RolloutPercentage {
+			return r, nil
+		}
+	}
+	return moduleRelease{}, fmt.Errorf("no release of module %q is rolled out to this agent", moduleName)
+}
+
+// rolloutBucket places an agent into one of 100 buckets for the rollouts of a
+// module. An agent is included in a rollout when its bucket is lower than
+// the rollout percentage, so agents are never removed from a rollout as its
+// percentage grows. The same agents are picked first for every release of a
+// module.
+func rolloutBucket(rolloutKey, moduleName string) float64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(moduleName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(rolloutKey))
+	return float64(h.Sum32() % 100)
+}
+
+// cacheFilename is the name of the file in the component's data directory
+// which holds the last loaded release.
+const cacheFilename = "module-cache.json"
+
+// moduleCache is the last loaded release of a module, which is loaded on
+// startup if the agent management API can't be reached.
+type moduleCache struct {
+	URL     string        `json:"url"`
+	Release moduleRelease `json:"release"`
+}
+
+// readCache returns the cached release of the module at url in dataPath.
+func readCache(dataPath, url string) (moduleRelease, error) {
+	buf, err := os.ReadFile(filepath.Join(dataPath, cacheFilename))
+	if err != nil {
+		return moduleRelease{}, fmt.Errorf("error reading module cache: %w", err)
+	}
+
+	var cache moduleCache
+	if err := json.Unmarshal(buf, &cache); err != nil {
+		return moduleRelease{}, fmt.Errorf("error decoding module cache: %w", err)
+	}
+	if cache.URL != url {
+		return moduleRelease{}, fmt.Errorf("invalid module cache: cached module was requested from a different url")
+	}
+	return cache.Release, nil
+}
+
+// writeCache caches release of the module at url in dataPath.
+func writeCache(dataPath, url string, release moduleRelease) error {
+	buf, err := json.Marshal(moduleCache{URL: url, Release: release})
+	if err != nil {
+		return fmt.Errorf("could not encode module cache: %w", err)
+	}
+	if err := os.MkdirAll(dataPath, 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataPath, cacheFilename), buf, 0640)
+}
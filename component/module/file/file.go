@@ -10,6 +10,8 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/local/file"
 	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/river"
 )
@@ -61,9 +63,10 @@ type Component struct {
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
-	_ component.HTTPComponent   = (*Component)(nil)
+	_ component.Component             = (*Component)(nil)
+	_ component.HealthComponent       = (*Component)(nil)
+	_ component.HTTPComponent         = (*Component)(nil)
+	_ flow.ModuleControllersComponent = (*Component)(nil)
 )
 
 // New creates a new module.file component.
@@ -125,7 +128,7 @@ func (c *Component) Update(args component.Arguments) error {
 
 	// Force a content load here and bubble up any error. This will catch problems
 	// on initial load.
-	return c.mod.LoadFlowContent(newArgs.Arguments, c.getContent().Value)
+	return c.mod.LoadFlowContent(auditlog.Trigger{Kind: auditlog.TriggerComponentUpdate}, newArgs.Arguments, c.getContent().Value)
 }
 
 // NewManagedLocalComponent creates the new local.file managed component.
@@ -135,7 +138,9 @@ func (c *Component) NewManagedLocalComponent(o component.Options) (*file.Compone
 		c.setContent(e.(file.Exports).Content)
 
 		// Any errors found here are reported via component health
-		_ = c.mod.LoadFlowContent(c.getArgs().Arguments, c.getContent().Value)
+		args := c.getArgs()
+		trigger := auditlog.Trigger{Kind: auditlog.TriggerFileChange, Caller: args.LocalFileArguments.Filename}
+		_ = c.mod.LoadFlowContent(trigger, args.Arguments, c.getContent().Value)
 	}
 
 	return file.New(localFileOpts, c.getArgs().LocalFileArguments)
@@ -146,6 +151,11 @@ func (c *Component) Handler() http.Handler {
 	return c.mod.Handler()
 }
 
+// ModuleControllers implements flow.ModuleControllersComponent.
+func (c *Component) ModuleControllers() []*flow.Flow {
+	return c.mod.ModuleControllers()
+}
+
 // CurrentHealth implements component.HealthComponent.
 func (c *Component) CurrentHealth() component.Health {
 	return c.mod.CurrentHealth()
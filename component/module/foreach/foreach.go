@@ -0,0 +1,463 @@
+package foreach
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/auditlog"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "module.foreach",
+		Args:    Arguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// itemArgument is the name of the module argument which receives the element
+// of the collection an instance was created for.
+const itemArgument = "item"
+
+// Arguments holds values which are used to configure the module.foreach
+// component.
+type Arguments struct {
+	// Collection to create one module instance for per element.
+	Collection []any `river:"collection,attr"`
+
+	// Key is the name of the field used to identify elements of the
+	// collection. When empty, the element itself is used as its identity.
+	Key string `river:"key,attr,optional"`
+
+	// Content to load for each module instance.
+	Content rivertypes.OptionalSecret `river:"content,attr"`
+
+	// Arguments to pass into every module instance.
+	Arguments map[string]any `river:"arguments,attr,optional"`
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = Arguments{}
+
+	type arguments Arguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+
+	if _, ok := a.Arguments[itemArgument]; ok {
+		return fmt.Errorf("%q is reserved and may not be set in arguments", itemArgument)
+	}
+	return nil
+}
+
+// Exports holds values which are exported from the module instances.
+type Exports struct {
+	// Exports of each running module instance, keyed by element key.
+	Exports map[string]map[string]any `river:"exports,attr"`
+}
+
+// Component implements the module.foreach component.
+type Component struct {
+	opts component.Options
+
+	updateMut sync.Mutex // Serializes calls to Update.
+
+	mut       sync.Mutex
+	runCtx    context.Context
+	instances map[string]*instance // Running instances by element key.
+
+	exportsMut sync.Mutex
+	exports    map[string]map[string]any // Exports by element key.
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+// instance is a single module created for an element of the collection.
+type instance struct {
+	id  string
+	mod module.ModuleComponent
+
+	cancel context.CancelFunc
+	exited chan struct{}
+}
+
+var (
+	_ component.Component             = (*Component)(nil)
+	_ component.HealthComponent       = (*Component)(nil)
+	_ component.HTTPComponent         = (*Component)(nil)
+	_ flow.ModuleControllersComponent = (*Component)(nil)
+)
+
+// New creates a new module.foreach component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:      o,
+		instances: make(map[string]*instance),
+		exports:   make(map[string]map[string]any),
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	c.mut.Lock()
+	c.runCtx = ctx
+	for _, inst := range c.instances {
+		c.startInstance(inst)
+	}
+	c.mut.Unlock()
+
+	<-ctx.Done()
+
+	// Clear runCtx so that concurrent updates no longer start instances, then
+	// wait for the running instances without holding c.mut.
+	c.mut.Lock()
+	c.runCtx = nil
+	running := make([]*instance, 0, len(c.instances))
+	for _, inst := range c.instances {
+		running = append(running, inst)
+	}
+	c.mut.Unlock()
+
+	for _, inst := range running {
+		stopInstance(inst)
+	}
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.updateMut.Lock()
+	defer c.updateMut.Unlock()
+
+	// Validate the whole collection before making any changes so that an
+	// invalid update leaves the running instances untouched.
+	items, err := keyCollection(newArgs.Collection, newArgs.Key)
+	if err != nil {
+		c.setHealth(component.HealthTypeUnhealthy, err.Error())
+		return err
+	}
+	keys := sortedKeys(items)
+	ids := make(map[string]string, len(keys))
+	for _, key := range keys {
+		id := instanceID(key)
+		if other, ok := ids[id]; ok {
+			err := fmt.Errorf("elements %q and %q map to the same instance name %q", other, key, id)
+			c.setHealth(component.HealthTypeUnhealthy, err.Error())
+			return err
+		}
+		ids[id] = key
+	}
+
+	// Swap in the new set of instances. Loading and stopping instances can
+	// take a while, so it is done below without holding c.mut to keep
+	// CurrentHealth and Handler responsive.
+	var (
+		removed []*instance
+		loading = make([]*instance, 0, len(keys))
+	)
+	c.mut.Lock()
+	for key, inst := range c.instances {
+		if _, ok := items[key]; ok {
+			continue
+		}
+		removed = append(removed, inst)
+		delete(c.instances, key)
+		c.removeExports(key)
+	}
+	for _, key := range keys {
+		inst, ok := c.instances[key]
+		if !ok {
+			inst = c.newInstance(key, instanceID(key))
+			c.instances[key] = inst
+		}
+		loading = append(loading, inst)
+	}
+	c.mut.Unlock()
+
+	for _, inst := range removed {
+		stopInstance(inst)
+	}
+
+	var failed []string
+	for i, inst := range loading {
+		key := keys[i]
+
+		moduleArgs := make(map[string]any, len(newArgs.Arguments)+1)
+		for k, v := range newArgs.Arguments {
+			moduleArgs[k] = v
+		}
+		moduleArgs[itemArgument] = items[key]
+
+		trigger := auditlog.Trigger{Kind: auditlog.TriggerComponentUpdate}
+		if err := inst.mod.LoadFlowContent(trigger, moduleArgs, newArgs.Content.Value); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", key, err))
+		}
+	}
+
+	// Like the other module loaders, the controllers are run even if loading
+	// failed so that a later update can recover them.
+	c.mut.Lock()
+	if c.runCtx != nil {
+		for _, inst := range loading {
+			if inst.cancel == nil {
+				c.startInstance(inst)
+			}
+		}
+	}
+	c.mut.Unlock()
+
+	// Publish the set of instances even if none of them have exports yet so
+	// that removed elements disappear from the exports.
+	c.publishExports()
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("failed to load %d of %d module instances: %s", len(failed), len(keys), strings.Join(failed, "; "))
+		c.setHealth(component.HealthTypeUnhealthy, err.Error())
+		return err
+	}
+	c.setHealth(component.HealthTypeHealthy, fmt.Sprintf("%d module instances loaded", len(keys)))
+	return nil
+}
+
+// newInstance creates a new module instance named id for the element with
+// the given key. c.mut must be held when calling newInstance.
+func (c *Component) newInstance(key, id string) *instance {
+	c.exportsMut.Lock()
+	c.exports[key] = nil
+	c.exportsMut.Unlock()
+
+	opts := c.opts
+	opts.ID = path.Join(c.opts.ID, id)
+	opts.DataPath = filepath.Join(c.opts.DataPath, id)
+	opts.HTTPPath = path.Join(c.opts.HTTPPath, id) + "/"
+	opts.OnStateChange = func(e component.Exports) {
+		c.setExports(key, e.(module.Exports).Exports)
+	}
+
+	return &instance{
+		id:  id,
+		mod: module.NewModuleComponent(opts),
+	}
+}
+
+// startInstance runs the flow controller of inst in the background. c.mut
+// must be held when calling startInstance.
+func (c *Component) startInstance(inst *instance) {
+	ctx, cancel := context.WithCancel(c.runCtx)
+	inst.cancel = cancel
+	inst.exited = make(chan struct{})
+
+	go func() {
+		defer close(inst.exited)
+		inst.mod.RunFlowController(ctx)
+	}()
+}
+
+// stopInstance stops inst and waits for it to exit. It is a no-op if inst was
+// never started. Callers must ensure inst can't be started concurrently and
+// should not hold c.mut while waiting.
+func stopInstance(inst *instance) {
+	if inst.cancel == nil {
+		return
+	}
+	inst.cancel()
+	<-inst.exited
+}
+
+func (c *Component) setExports(key string, exports map[string]any) {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+
+	// Ignore late updates from instances which have since been removed.
+	if _, ok := c.exports[key]; !ok {
+		return
+	}
+	c.exports[key] = exports
+	c.publishExportsLocked()
+}
+
+func (c *Component) removeExports(key string) {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+	delete(c.exports, key)
+}
+
+func (c *Component) publishExports() {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+	c.publishExportsLocked()
+}
+
+func (c *Component) publishExportsLocked() {
+	exports := make(map[string]map[string]any, len(c.exports))
+	for key, e := range c.exports {
+		if e == nil {
+			e = map[string]any{}
+		}
+		exports[key] = e
+	}
+	c.opts.OnStateChange(Exports{Exports: exports})
+}
+
+// Handler implements component.HTTPComponent. Requests are forwarded to the
+// module instance named by the first path segment.
+func (c *Component) Handler() http.Handler {
+	r := mux.NewRouter()
+
+	r.PathPrefix("/{instance}/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["instance"]
+
+		c.mut.Lock()
+		var found *instance
+		for _, inst := range c.instances {
+			if inst.id == id {
+				found = inst
+				break
+			}
+		}
+		c.mut.Unlock()
+
+		if found == nil {
+			http.NotFound(w, r)
+			return
+		}
+		http.StripPrefix("/"+id, found.mod.Handler()).ServeHTTP(w, r)
+	})
+
+	return r
+}
+
+// ModuleControllers implements flow.ModuleControllersComponent.
+func (c *Component) ModuleControllers() []*flow.Flow {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	res := make([]*flow.Flow, 0, len(c.instances))
+	for _, key := range sortedKeys(c.instances) {
+		res = append(res, c.instances[key].mod.ModuleControllers()...)
+	}
+	return res
+}
+
+// CurrentHealth implements component.HealthComponent. The component is
+// unhealthy if any of its module instances are unhealthy.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	health := c.health
+	c.healthMut.RUnlock()
+
+	if health.Health != component.HealthTypeHealthy {
+		return health
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for _, key := range sortedKeys(c.instances) {
+		h := c.instances[key].mod.CurrentHealth()
+		if h.Health != component.HealthTypeHealthy {
+			h.Message = fmt.Sprintf("%s: %s", key, h.Message)
+			return h
+		}
+	}
+	return health
+}
+
+func (c *Component) setHealth(t component.HealthType, msg string) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+	c.health = component.Health{
+		Health:     t,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
+
+// keyCollection returns the elements of collection by their key. If keyField
+// is empty, the element itself is used as the key.
+func keyCollection(collection []any, keyField string) (map[string]any, error) {
+	items := make(map[string]any, len(collection))
+
+	for i, elem := range collection {
+		var key string
+		if keyField == "" {
+			key = stringify(elem)
+		} else {
+			var (
+				val any
+				ok  bool
+			)
+			switch elem := elem.(type) {
+			case map[string]any:
+				val, ok = elem[keyField]
+			case map[string]string:
+				val, ok = elem[keyField]
+			default:
+				return nil, fmt.Errorf("collection element %d is not an object and cannot be keyed by %q", i, keyField)
+			}
+			if !ok {
+				return nil, fmt.Errorf("collection element %d is missing key field %q", i, keyField)
+			}
+			key = stringify(val)
+		}
+
+		if _, ok := items[key]; ok {
+			return nil, fmt.Errorf("duplicate key %q in collection", key)
+		}
+		items[key] = elem
+	}
+
+	return items, nil
+}
+
+func stringify(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+var invalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// instanceID returns a name for the instance of key which is safe to use in
+// component IDs, HTTP paths, and data directories.
+func instanceID(key string) string {
+	return "item_" + invalidIDChars.ReplaceAllString(key, "_")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
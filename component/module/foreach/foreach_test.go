@@ -0,0 +1,139 @@
+package foreach
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+const testContent = `
+	argument "item" { }
+
+	argument "suffix" {
+		optional = true
+		default  = ""
+	}
+
+	export "value" {
+		value = argument.item.value.name + argument.suffix.value
+	}`
+
+func TestForeach(t *testing.T) {
+	var (
+		mut     sync.Mutex
+		exports Exports
+	)
+	getExports := func() map[string]map[string]any {
+		mut.Lock()
+		defer mut.Unlock()
+		return exports.Exports
+	}
+
+	opts := component.Options{
+		ID:       "module.foreach.test",
+		Logger:   util.TestFlowLogger(t),
+		DataPath: t.TempDir(),
+		HTTPPath: "/component/module.foreach.test/",
+		OnStateChange: func(e component.Exports) {
+			mut.Lock()
+			defer mut.Unlock()
+			exports = e.(Exports)
+		},
+	}
+
+	args := Arguments{
+		Collection: []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b"},
+		},
+		Key:     "name",
+		Content: rivertypes.OptionalSecret{Value: testContent},
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		e := getExports()
+		return len(e) == 2 && e["a"]["value"] == "a" && e["b"]["value"] == "b"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Removing an element should stop its instance and remove its exports,
+	// while adding an element creates a new instance.
+	args.Collection = []any{
+		map[string]any{"name": "b"},
+		map[string]any{"name": "c"},
+	}
+	args.Arguments = map[string]any{"suffix": "!"}
+	require.NoError(t, c.Update(args))
+
+	require.Eventually(t, func() bool {
+		e := getExports()
+		_, hasA := e["a"]
+		return len(e) == 2 && !hasA && e["b"]["value"] == "b!" && e["c"]["value"] == "c!"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	c.mut.Lock()
+	require.Len(t, c.instances, 2)
+	c.mut.Unlock()
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+}
+
+func TestForeach_InvalidUpdate(t *testing.T) {
+	opts := component.Options{
+		ID:            "module.foreach.test",
+		Logger:        util.TestFlowLogger(t),
+		DataPath:      t.TempDir(),
+		HTTPPath:      "/component/module.foreach.test/",
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := Arguments{
+		Collection: []any{map[string]any{"name": "a"}},
+		Key:        "name",
+		Content:    rivertypes.OptionalSecret{Value: testContent},
+	}
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	// An update whose elements collide must not stop the existing instances.
+	args.Collection = []any{
+		map[string]any{"name": "b.c"},
+		map[string]any{"name": "b_c"},
+	}
+	require.EqualError(t, c.Update(args), `elements "b.c" and "b_c" map to the same instance name "item_b_c"`)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	require.Len(t, c.instances, 1)
+	require.Contains(t, c.instances, "a")
+}
+
+func TestKeyCollection(t *testing.T) {
+	items, err := keyCollection([]any{"a", 1, "b"}, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"a": "a", "1": 1, "b": "b"}, items)
+
+	_, err = keyCollection([]any{"a", "a"}, "")
+	require.EqualError(t, err, `duplicate key "a" in collection`)
+
+	_, err = keyCollection([]any{"a"}, "name")
+	require.EqualError(t, err, `collection element 0 is not an object and cannot be keyed by "name"`)
+
+	_, err = keyCollection([]any{map[string]any{"address": "a"}}, "name")
+	require.EqualError(t, err, `collection element 0 is missing key field "name"`)
+}
+
+func TestInstanceID(t *testing.T) {
+	require.Equal(t, "item_redis-0_cache_svc_6379", instanceID("redis-0.cache.svc:6379"))
+}
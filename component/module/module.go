@@ -11,6 +11,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/web/api"
@@ -54,14 +55,17 @@ func NewModuleComponent(o component.Options) ModuleComponent {
 			OnExportsChange: func(exports map[string]any) {
 				o.OnStateChange(Exports{Exports: exports})
 			},
+			AuditLog:   o.AuditLog,
+			DiskBudget: o.DiskBudget,
 		}),
 	}
 }
 
 // LoadFlowContent loads the flow controller with the current component content. It
 // will set the component health in addition to return the error so that the consumer
-// can rely on either or both.
-func (c *ModuleComponent) LoadFlowContent(arguments map[string]any, contentValue string) error {
+// can rely on either or both. trigger is recorded in the audit log as the cause
+// of the load.
+func (c *ModuleComponent) LoadFlowContent(trigger auditlog.Trigger, arguments map[string]any, contentValue string) error {
 	f, err := flow.ReadFile(c.opts.ID, []byte(contentValue))
 	if err != nil {
 		c.SetHealth(component.Health{
@@ -73,7 +77,7 @@ func (c *ModuleComponent) LoadFlowContent(arguments map[string]any, contentValue
 		return err
 	}
 
-	err = c.ctrl.LoadFile(f, arguments)
+	err = c.ctrl.LoadFileWithTrigger(f, arguments, trigger)
 	if err != nil {
 		c.SetHealth(component.Health{
 			Health:     component.HealthTypeUnhealthy,
@@ -97,6 +101,12 @@ func (c *ModuleComponent) RunFlowController(ctx context.Context) {
 	c.ctrl.Run(ctx)
 }
 
+// ModuleControllers contains the implementation details for
+// ModuleControllers in a module component.
+func (c *ModuleComponent) ModuleControllers() []*flow.Flow {
+	return []*flow.Flow{c.ctrl}
+}
+
 // CurrentHealth contains the implementation details for CurrentHealth in a module component.
 func (c *ModuleComponent) CurrentHealth() component.Health {
 	c.mut.RLock()
@@ -6,6 +6,8 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 )
 
@@ -37,9 +39,10 @@ type Component struct {
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
-	_ component.HTTPComponent   = (*Component)(nil)
+	_ component.Component             = (*Component)(nil)
+	_ component.HealthComponent       = (*Component)(nil)
+	_ component.HTTPComponent         = (*Component)(nil)
+	_ flow.ModuleControllersComponent = (*Component)(nil)
 )
 
 // New creates a new module.string component.
@@ -64,7 +67,7 @@ func (c *Component) Run(ctx context.Context) error {
 func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
 
-	return c.mod.LoadFlowContent(newArgs.Arguments, newArgs.Content.Value)
+	return c.mod.LoadFlowContent(auditlog.Trigger{Kind: auditlog.TriggerComponentUpdate}, newArgs.Arguments, newArgs.Content.Value)
 }
 
 // Handler implements component.HTTPComponent.
@@ -72,6 +75,11 @@ func (c *Component) Handler() http.Handler {
 	return c.mod.Handler()
 }
 
+// ModuleControllers implements flow.ModuleControllersComponent.
+func (c *Component) ModuleControllers() []*flow.Flow {
+	return c.mod.ModuleControllers()
+}
+
 // CurrentHealth implements component.HealthComponent.
 func (c *Component) CurrentHealth() component.Health {
 	return c.mod.CurrentHealth()
@@ -20,6 +20,8 @@ func init() {
 			fact := bearertokenauthextension.NewFactory()
 			return auth.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/extension/bearertokenauthextension"},
 	})
 }
 
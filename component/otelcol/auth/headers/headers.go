@@ -23,6 +23,8 @@ func init() {
 			fact := headerssetterextension.NewFactory()
 			return auth.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/extension/headerssetterextension"},
 	})
 }
 
@@ -0,0 +1,337 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/config/configauth"
+	"go.opentelemetry.io/collector/config/configtls"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/grpc/credentials"
+	grpcOAuth "google.golang.org/grpc/credentials/oauth"
+)
+
+// clientAssertionType is the client_assertion_type of JWT client assertions,
+// as defined by RFC 7523.
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// clientAssertionConfig configures authenticating the client with a signed
+// JWT (private_key_jwt), which the upstream oauth2client extension doesn't
+// support. Clients authenticating with a client secret use the upstream
+// extension instead.
+type clientAssertionConfig struct {
+	otelconfig.ExtensionSettings
+
+	ClientID       string
+	TokenURL       string
+	EndpointParams url.Values
+	Scopes         []string
+	TLSSetting     configtls.TLSClientSetting
+	Timeout        time.Duration
+
+	PrivateKey     string
+	PrivateKeyFile string
+	Certificate    string
+	KeyID          string
+	Algorithm      string
+	Audience       string
+	Lifetime       time.Duration
+}
+
+var _ otelconfig.Extension = (*clientAssertionConfig)(nil)
+
+// Validate checks that the clientAssertionConfig is valid.
+func (cfg *clientAssertionConfig) Validate() error {
+	switch {
+	case cfg.ClientID == "":
+		return errors.New("no client_id provided")
+	case cfg.TokenURL == "":
+		return errors.New("no token_url provided")
+	}
+
+	if _, err := signingMethod(cfg.Algorithm); err != nil {
+		return err
+	}
+	if cfg.PrivateKey != "" {
+		if _, err := parsePrivateKey(cfg.Algorithm, []byte(cfg.PrivateKey)); err != nil {
+			return fmt.Errorf("invalid private_key: %w", err)
+		}
+	}
+	if cfg.Certificate != "" {
+		if _, err := certificateThumbprint(cfg.Certificate); err != nil {
+			return fmt.Errorf("invalid certificate: %w", err)
+		}
+	}
+	return nil
+}
+
+// newFactory returns a factory which creates extensions from the upstream
+// factory, unless they're configured with a clientAssertionConfig.
+func newFactory(upstream otelcomponent.ExtensionFactory, m *metrics) otelcomponent.ExtensionFactory {
+	return otelcomponent.NewExtensionFactory(
+		upstream.Type(),
+		upstream.CreateDefaultConfig,
+		func(ctx context.Context, set otelcomponent.ExtensionCreateSettings, cfg otelconfig.Extension) (otelcomponent.Extension, error) {
+			assertionCfg, ok := cfg.(*clientAssertionConfig)
+			if !ok {
+				return upstream.CreateExtension(ctx, set, cfg)
+			}
+
+			ca, err := newClientAuthenticator(assertionCfg, m)
+			if err != nil {
+				return nil, err
+			}
+			return configauth.NewClientAuthenticator(
+				configauth.WithClientRoundTripper(ca.roundTripper),
+				configauth.WithPerRPCCredentials(ca.perRPCCredentials),
+			), nil
+		},
+		upstream.ExtensionStability(),
+	)
+}
+
+// metrics tracks requests made to the token endpoint by clients
+// authenticating with a client assertion. Tokens are cached until they
+// expire, so requests are only made to refresh tokens.
+type metrics struct {
+	tokenRequests       prometheus.Counter
+	tokenFailures       prometheus.Counter
+	tokenRequestLatency prometheus.Histogram
+	tokenExpiry         prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		tokenRequests: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otelcol_auth_oauth2_token_requests_total",
+			Help: "Total number of requests made to the token endpoint to fetch a new token.",
+		}),
+		tokenFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otelcol_auth_oauth2_token_request_failures_total",
+			Help: "Total number of requests to the token endpoint which failed.",
+		}),
+		tokenRequestLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "otelcol_auth_oauth2_token_request_duration_seconds",
+			Help: "Latency of requests made to the token endpoint.",
+		}),
+		tokenExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "otelcol_auth_oauth2_token_expiry_timestamp_seconds",
+			Help: "Expiry time of the cached token in Unix seconds. 0 if the token doesn't expire.",
+		}),
+	}
+	for _, c := range []prometheus.Collector{m.tokenRequests, m.tokenFailures, m.tokenRequestLatency, m.tokenExpiry} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// clientAuthenticator provides client authentication using the OAuth2 client
+// credentials flow with client assertions for both gRPC and HTTP clients.
+type clientAuthenticator struct {
+	cfg     *clientAssertionConfig
+	metrics *metrics
+	client  *http.Client
+}
+
+func newClientAuthenticator(cfg *clientAssertionConfig, m *metrics) (*clientAuthenticator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsCfg, err := cfg.TLSSetting.LoadTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsCfg
+
+	return &clientAuthenticator{
+		cfg:     cfg,
+		metrics: m,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+		},
+	}, nil
+}
+
+// roundTripper returns an http.RoundTripper which performs the client
+// credentials flow and refreshes tokens as needed.
+func (ca *clientAuthenticator) roundTripper(base http.RoundTripper) (http.RoundTripper, error) {
+	return &oauth2.Transport{
+		Source: ca.tokenSource(),
+		Base:   base,
+	}, nil
+}
+
+// perRPCCredentials returns gRPC PerRPCCredentials which perform the client
+// credentials flow and refresh tokens as needed.
+func (ca *clientAuthenticator) perRPCCredentials() (credentials.PerRPCCredentials, error) {
+	return grpcOAuth.TokenSource{TokenSource: ca.tokenSource()}, nil
+}
+
+// tokenSource returns a TokenSource which caches tokens until they expire.
+func (ca *clientAuthenticator) tokenSource() oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &fetchingTokenSource{ca: ca})
+}
+
+// fetchingTokenSource requests a new token from the token endpoint every time
+// Token is called.
+type fetchingTokenSource struct {
+	ca *clientAuthenticator
+}
+
+func (ts *fetchingTokenSource) Token() (*oauth2.Token, error) {
+	var (
+		cfg = ts.ca.cfg
+		m   = ts.ca.metrics
+	)
+
+	start := time.Now()
+	m.tokenRequests.Inc()
+	defer func() { m.tokenRequestLatency.Observe(time.Since(start).Seconds()) }()
+
+	cc, err := ts.credentialsConfig()
+	if err == nil {
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, ts.ca.client)
+
+		var tok *oauth2.Token
+		if tok, err = cc.Token(ctx); err == nil {
+			if tok.Expiry.IsZero() {
+				m.tokenExpiry.Set(0)
+			} else {
+				m.tokenExpiry.Set(float64(tok.Expiry.Unix()))
+			}
+			return tok, nil
+		}
+	}
+
+	m.tokenFailures.Inc()
+	return nil, fmt.Errorf("failed to get security token from token endpoint (endpoint %q): %w", cfg.TokenURL, err)
+}
+
+// credentialsConfig returns the client credentials configuration to use for a
+// single token request. A new assertion is signed for every request.
+func (ts *fetchingTokenSource) credentialsConfig() (*clientcredentials.Config, error) {
+	cfg := ts.ca.cfg
+
+	assertion, err := signClientAssertion(cfg, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign client assertion: %w", err)
+	}
+
+	params := url.Values{}
+	for k, v := range cfg.EndpointParams {
+		params[k] = v
+	}
+	params.Set("client_assertion_type", clientAssertionType)
+	params.Set("client_assertion", assertion)
+
+	return &clientcredentials.Config{
+		ClientID:       cfg.ClientID,
+		TokenURL:       cfg.TokenURL,
+		Scopes:         cfg.Scopes,
+		EndpointParams: params,
+		AuthStyle:      oauth2.AuthStyleInParams,
+	}, nil
+}
+
+// signClientAssertion returns a JWT which authenticates cfg.ClientID to the
+// token endpoint as described by RFC 7523.
+func signClientAssertion(cfg *clientAssertionConfig, now time.Time) (string, error) {
+	method, err := signingMethod(cfg.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	pemKey := []byte(cfg.PrivateKey)
+	if cfg.PrivateKeyFile != "" {
+		// The file is read for every assertion so that rotated keys are picked
+		// up without a reload.
+		if pemKey, err = os.ReadFile(cfg.PrivateKeyFile); err != nil {
+			return "", err
+		}
+	}
+	key, err := parsePrivateKey(cfg.Algorithm, pemKey)
+	if err != nil {
+		return "", err
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	audience := cfg.Audience
+	if audience == "" {
+		audience = cfg.TokenURL
+	}
+
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		Issuer:    cfg.ClientID,
+		Subject:   cfg.ClientID,
+		Audience:  jwt.ClaimStrings{audience},
+		ID:        hex.EncodeToString(jti),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(cfg.Lifetime)),
+	})
+	if cfg.KeyID != "" {
+		token.Header["kid"] = cfg.KeyID
+	}
+	if cfg.Certificate != "" {
+		thumbprint, err := certificateThumbprint(cfg.Certificate)
+		if err != nil {
+			return "", err
+		}
+		token.Header["x5t"] = thumbprint
+	}
+	return token.SignedString(key)
+}
+
+// signingMethod returns the supported JWT signing method for alg.
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512":
+		return jwt.GetSigningMethod(alg), nil
+	default:
+		return nil, fmt.Errorf("unsupported client assertion algorithm %q", alg)
+	}
+}
+
+// parsePrivateKey parses a PEM-encoded private key suitable for alg.
+func parsePrivateKey(alg string, pemKey []byte) (interface{}, error) {
+	if alg[0] == 'E' {
+		return jwt.ParseECPrivateKeyFromPEM(pemKey)
+	}
+	return jwt.ParseRSAPrivateKeyFromPEM(pemKey)
+}
+
+// certificateThumbprint returns the base64url-encoded SHA-1 thumbprint of a
+// PEM-encoded certificate, as used by the x5t JWT header.
+func certificateThumbprint(cert string) (string, error) {
+	block, _ := pem.Decode([]byte(cert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New("no PEM-encoded certificate found")
+	}
+	sum := sha1.Sum(block.Bytes) //nolint:gosec // x5t is defined as a SHA-1 thumbprint.
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -1,12 +1,15 @@
 package oauth2
 
 import (
+	"fmt"
 	"net/url"
 	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/auth"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/oauth2clientauthextension"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
@@ -19,36 +22,130 @@ func init() {
 		Exports: auth.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := oauth2clientauthextension.NewFactory()
+			m, err := newMetrics(opts.Registerer)
+			if err != nil {
+				return nil, err
+			}
+			fact := newFactory(oauth2clientauthextension.NewFactory(), m)
 			return auth.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/extension/oauth2clientauthextension"},
 	})
 }
 
 // Arguments configures the otelcol.auth.oauth2 component.
 type Arguments struct {
-	ClientID       string                     `river:"client_id,attr"`
-	ClientSecret   string                     `river:"client_secret,attr"`
-	TokenURL       string                     `river:"token_url,attr"`
-	EndpointParams url.Values                 `river:"endpoint_params,attr,optional"`
-	Scopes         []string                   `river:"scopes,attr,optional"`
-	TLSSetting     otelcol.TLSClientArguments `river:"tls,block,optional"`
-	Timeout        time.Duration              `river:"timeout,attr,optional"`
+	ClientID        string                     `river:"client_id,attr"`
+	ClientSecret    string                     `river:"client_secret,attr,optional"`
+	TokenURL        string                     `river:"token_url,attr"`
+	EndpointParams  url.Values                 `river:"endpoint_params,attr,optional"`
+	Scopes          []string                   `river:"scopes,attr,optional"`
+	TLSSetting      otelcol.TLSClientArguments `river:"tls,block,optional"`
+	Timeout         time.Duration              `river:"timeout,attr,optional"`
+	ClientAssertion *ClientAssertionArguments  `river:"client_assertion,block,optional"`
 }
 
-var _ auth.Arguments = Arguments{}
+// ClientAssertionArguments configures authenticating the client with a signed
+// JWT (private_key_jwt) instead of a client secret.
+type ClientAssertionArguments struct {
+	PrivateKey     rivertypes.Secret `river:"private_key,attr,optional"`
+	PrivateKeyFile string            `river:"private_key_file,attr,optional"`
+	Certificate    string            `river:"certificate,attr,optional"`
+	KeyID          string            `river:"key_id,attr,optional"`
+	Algorithm      string            `river:"algorithm,attr,optional"`
+	Audience       string            `river:"audience,attr,optional"`
+	Lifetime       time.Duration     `river:"lifetime,attr,optional"`
+}
 
-// Convert implements auth.Arguments.
+// DefaultClientAssertionArguments holds default settings for
+// ClientAssertionArguments.
+var DefaultClientAssertionArguments = ClientAssertionArguments{
+	Algorithm: "RS256",
+	Lifetime:  5 * time.Minute,
+}
+
+var (
+	_ river.Unmarshaler = (*Arguments)(nil)
+	_ river.Unmarshaler = (*ClientAssertionArguments)(nil)
+	_ auth.Arguments    = Arguments{}
+)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.ClientSecret == "" && args.ClientAssertion == nil:
+		return fmt.Errorf("one of client_secret or the client_assertion block must be provided")
+	case args.ClientSecret != "" && args.ClientAssertion != nil:
+		return fmt.Errorf("client_secret and the client_assertion block are mutually exclusive")
+	}
+
+	cfg, err := args.Convert()
+	if err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *ClientAssertionArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultClientAssertionArguments
+
+	type arguments ClientAssertionArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.PrivateKey == "" && args.PrivateKeyFile == "":
+		return fmt.Errorf("one of private_key or private_key_file must be provided")
+	case args.PrivateKey != "" && args.PrivateKeyFile != "":
+		return fmt.Errorf("private_key and private_key_file are mutually exclusive")
+	case args.Lifetime <= 0:
+		return fmt.Errorf("lifetime must be greater than 0")
+	}
+	return nil
+}
+
+// Convert implements auth.Arguments. Clients authenticating with a client
+// secret use the upstream extension, while clients authenticating with a
+// client assertion use the authenticator of this package.
 func (args Arguments) Convert() (otelconfig.Extension, error) {
-	return &oauth2clientauthextension.Config{
+	a := args.ClientAssertion
+	if a == nil {
+		return &oauth2clientauthextension.Config{
+			ExtensionSettings: otelconfig.NewExtensionSettings(otelconfig.NewComponentID("oauth2")),
+			ClientID:          args.ClientID,
+			ClientSecret:      args.ClientSecret,
+			TokenURL:          args.TokenURL,
+			EndpointParams:    args.EndpointParams,
+			Scopes:            args.Scopes,
+			TLSSetting:        *args.TLSSetting.Convert(),
+			Timeout:           args.Timeout,
+		}, nil
+	}
+
+	return &clientAssertionConfig{
 		ExtensionSettings: otelconfig.NewExtensionSettings(otelconfig.NewComponentID("oauth2")),
 		ClientID:          args.ClientID,
-		ClientSecret:      args.ClientSecret,
 		TokenURL:          args.TokenURL,
 		EndpointParams:    args.EndpointParams,
 		Scopes:            args.Scopes,
 		TLSSetting:        *args.TLSSetting.Convert(),
 		Timeout:           args.Timeout,
+
+		PrivateKey:     string(a.PrivateKey),
+		PrivateKeyFile: a.PrivateKeyFile,
+		Certificate:    a.Certificate,
+		KeyID:          a.KeyID,
+		Algorithm:      a.Algorithm,
+		Audience:       a.Audience,
+		Lifetime:       a.Lifetime,
 	}, nil
 }
 
@@ -2,12 +2,17 @@ package oauth2_test
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/grafana/agent/component/otelcol/auth"
 	"github.com/grafana/agent/component/otelcol/auth/oauth2"
 	"github.com/grafana/agent/pkg/flow/componenttest"
@@ -15,6 +20,7 @@ import (
 	"github.com/grafana/agent/pkg/util"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/config/configauth"
+	"go.uber.org/atomic"
 	"gotest.tools/assert"
 )
 
@@ -132,3 +138,160 @@ func Test(t *testing.T) {
 		})
 	}
 }
+
+// TestClientAssertion ensures that a signed client assertion is sent to the
+// token endpoint instead of a client secret and that tokens are cached.
+func TestClientAssertion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer TestAccessToken", r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	var tokenRequests atomic.Int32
+	srvProvidingTokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Inc()
+		require.NoError(t, r.ParseForm())
+
+		assert.Equal(t, "someclientid", r.PostForm.Get("client_id"))
+		assert.Equal(t, "", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", r.PostForm.Get("client_assertion_type"))
+
+		var claims jwt.RegisteredClaims
+		tok, err := jwt.ParseWithClaims(r.PostForm.Get("client_assertion"), &claims, func(*jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", tok.Method.Alg())
+		assert.Equal(t, "somekeyid", tok.Header["kid"])
+		assert.Equal(t, "someclientid", claims.Issuer)
+		assert.Equal(t, "someclientid", claims.Subject)
+		assert.Equal(t, true, claims.VerifyAudience("https://login.example.com", true))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "TestAccessToken", "token_type": "Bearer", "expires_in": 3600}`)
+	}))
+	defer srvProvidingTokens.Close()
+
+	ctx := componenttest.TestContext(t)
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "otelcol.auth.oauth2")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		client_id = "someclientid"
+		token_url = "%s/oauth2/token"
+
+		client_assertion {
+			private_key = %q
+			key_id      = "somekeyid"
+			audience    = "https://login.example.com"
+		}
+	`, srvProvidingTokens.URL, keyPEM)
+	var args oauth2.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(auth.Exports)
+	clientAuth, ok := exports.Handler.Extension.(configauth.ClientAuthenticator)
+	require.True(t, ok, "handler does not implement configauth.ClientAuthenticator")
+
+	rt, err := clientAuth.RoundTripper(http.DefaultTransport)
+	require.NoError(t, err)
+	cli := &http.Client{Transport: rt}
+
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := cli.Do(req)
+		require.NoError(t, err, "HTTP request failed")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+	require.Equal(t, int32(1), tokenRequests.Load(), "token should have been cached")
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		testName    string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			testName: "missing credentials",
+			cfg: `
+				client_id = "someclientid"
+				token_url = "https://example.com/token"
+			`,
+			expectedErr: "one of client_secret or the client_assertion block must be provided",
+		},
+		{
+			testName: "secret and assertion",
+			cfg: `
+				client_id     = "someclientid"
+				client_secret = "someclientsecret"
+				token_url     = "https://example.com/token"
+
+				client_assertion {
+					private_key_file = "/path/to/key.pem"
+				}
+			`,
+			expectedErr: "client_secret and the client_assertion block are mutually exclusive",
+		},
+		{
+			testName: "missing private key",
+			cfg: `
+				client_id = "someclientid"
+				token_url = "https://example.com/token"
+
+				client_assertion { }
+			`,
+			expectedErr: "one of private_key or private_key_file must be provided",
+		},
+		{
+			testName: "unsupported algorithm",
+			cfg: `
+				client_id = "someclientid"
+				token_url = "https://example.com/token"
+
+				client_assertion {
+					private_key_file = "/path/to/key.pem"
+					algorithm        = "HS256"
+				}
+			`,
+			expectedErr: `unsupported client assertion algorithm "HS256"`,
+		},
+		{
+			testName: "invalid private key",
+			cfg: `
+				client_id = "someclientid"
+				token_url = "https://example.com/token"
+
+				client_assertion {
+					private_key = "not a key"
+				}
+			`,
+			expectedErr: "invalid private_key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var args oauth2.Arguments
+			err := river.Unmarshal([]byte(tt.cfg), &args)
+			require.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
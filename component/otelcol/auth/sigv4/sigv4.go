@@ -19,6 +19,8 @@ func init() {
 			fact := sigv4authextension.NewFactory()
 			return auth.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/extension/sigv4authextension"},
 	})
 }
 
@@ -0,0 +1,291 @@
+package spanmetrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// Dimensions set on every metric.
+const (
+	serviceNameKey = conventions.AttributeServiceName
+	spanNameKey    = "span.name"
+	operationKey   = "operation" // Name of spanNameKey used by the spanmetrics processor.
+	spanKindKey    = "span.kind"
+	statusCodeKey  = "status.code"
+)
+
+// Names of the metrics generated by the spanmetrics processor.
+const (
+	processorCallsName   = "calls_total"
+	processorLatencyName = "latency"
+)
+
+// metricNames holds the names of generated metrics and of the dimension
+// holding the name of spans.
+type metricNames struct {
+	calls, duration, events string
+	spanName                string
+}
+
+func newMetricNames(args Arguments) metricNames {
+	if args.LegacyMetricNames {
+		return metricNames{
+			calls:    processorCallsName,
+			duration: processorLatencyName,
+			events:   "events_total",
+			spanName: operationKey,
+		}
+	}
+
+	name := func(n string) string {
+		if args.Namespace == "" {
+			return n
+		}
+		return args.Namespace + "." + n
+	}
+	return metricNames{
+		calls:    name("calls"),
+		duration: name("duration"),
+		events:   name("events"),
+		spanName: spanNameKey,
+	}
+}
+
+// metricsExporter receives the metrics generated by the spanmetrics processor,
+// converts them, and passes them to the consumers of the component.
+type metricsExporter struct {
+	names     metricNames
+	exemplars bool
+	events    *eventsCounter // Adds the metric counting span events if set.
+	next      otelconsumer.Metrics
+}
+
+var _ otelcomponent.MetricsExporter = (*metricsExporter)(nil)
+
+// Start implements otelcomponent.Component.
+func (e *metricsExporter) Start(context.Context, otelcomponent.Host) error { return nil }
+
+// Shutdown implements otelcomponent.Component.
+func (e *metricsExporter) Shutdown(context.Context) error { return nil }
+
+// Capabilities implements otelconsumer.Metrics.
+func (e *metricsExporter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+// ConsumeMetrics implements otelconsumer.Metrics. The metrics are built for
+// every call by the processor, so they're converted in place.
+func (e *metricsExporter) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				e.convertMetric(ms.At(k))
+			}
+		}
+	}
+
+	if e.events != nil && rms.Len() > 0 && rms.At(0).ScopeMetrics().Len() > 0 {
+		e.events.appendMetric(rms.At(0).ScopeMetrics().At(0).Metrics(), e.names)
+	}
+	return e.next.ConsumeMetrics(ctx, md)
+}
+
+func (e *metricsExporter) convertMetric(m pmetric.Metric) {
+	switch m.Name() {
+	case processorCallsName:
+		m.SetName(e.names.calls)
+		dps := m.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			e.renameSpanName(dps.At(i).Attributes())
+		}
+	case processorLatencyName:
+		m.SetName(e.names.duration)
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			e.renameSpanName(dps.At(i).Attributes())
+			if !e.exemplars {
+				dps.At(i).Exemplars().RemoveIf(func(pmetric.Exemplar) bool { return true })
+			}
+		}
+	}
+}
+
+func (e *metricsExporter) renameSpanName(attrs pcommon.Map) {
+	if e.names.spanName == operationKey {
+		return
+	}
+	if v, ok := attrs.Get(operationKey); ok {
+		v.CopyTo(attrs.PutEmpty(e.names.spanName))
+		attrs.Remove(operationKey)
+	}
+}
+
+// eventsCounter counts span events by the dimensions of their spans and by
+// dimensions taken from the attributes of the events.
+type eventsCounter struct {
+	dimensions      []Dimension
+	eventDimensions []Dimension
+	delta           bool
+	cacheSize       int
+
+	mut       sync.Mutex
+	startTime time.Time
+	series    map[string]*eventsSeries
+}
+
+type eventsSeries struct {
+	attrs pcommon.Map
+	count int64
+}
+
+func newEventsCounter(args Arguments) *eventsCounter {
+	return &eventsCounter{
+		dimensions:      args.Dimensions,
+		eventDimensions: args.Events.Dimensions,
+		delta:           args.AggregationTemporality == AggregationTemporalityDelta,
+		cacheSize:       args.DimensionsCacheSize,
+
+		startTime: time.Now(),
+		series:    make(map[string]*eventsSeries),
+	}
+}
+
+// consumeTraces counts the events of the spans of td. Like the spanmetrics
+// processor, spans of resources without a service name are ignored.
+func (c *eventsCounter) consumeTraces(td ptrace.Traces) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		resourceAttrs := rs.Resource().Attributes()
+		serviceName, ok := resourceAttrs.Get(serviceNameKey)
+		if !ok {
+			continue
+		}
+
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				events := span.Events()
+				for l := 0; l < events.Len(); l++ {
+					c.count(serviceName.Str(), span, events.At(l), resourceAttrs)
+				}
+			}
+		}
+	}
+}
+
+func (c *eventsCounter) count(serviceName string, span ptrace.Span, event ptrace.SpanEvent, resourceAttrs pcommon.Map) {
+	var key strings.Builder
+	values := []string{serviceName, span.Name(), span.Kind().String(), span.Status().Code().String()}
+	for _, d := range c.dimensions {
+		v, _ := dimensionValue(d, span.Attributes(), resourceAttrs)
+		values = append(values, v.AsString())
+	}
+	for _, d := range c.eventDimensions {
+		v, _ := dimensionValue(d, event.Attributes())
+		values = append(values, v.AsString())
+	}
+	for _, v := range values {
+		key.WriteString(v)
+		key.WriteByte(0)
+	}
+
+	s, ok := c.series[key.String()]
+	if !ok {
+		// Events of new series are dropped once the cache is full, rather than
+		// letting it grow indefinitely.
+		if len(c.series) >= c.cacheSize {
+			return
+		}
+
+		attrs := pcommon.NewMap()
+		attrs.PutStr(serviceNameKey, serviceName)
+		attrs.PutStr(operationKey, span.Name())
+		attrs.PutStr(spanKindKey, span.Kind().String())
+		attrs.PutStr(statusCodeKey, span.Status().Code().String())
+		for _, d := range c.dimensions {
+			if v, ok := dimensionValue(d, span.Attributes(), resourceAttrs); ok {
+				v.CopyTo(attrs.PutEmpty(d.Name))
+			}
+		}
+		for _, d := range c.eventDimensions {
+			if v, ok := dimensionValue(d, event.Attributes()); ok {
+				v.CopyTo(attrs.PutEmpty(d.Name))
+			}
+		}
+
+		s = &eventsSeries{attrs: attrs}
+		c.series[key.String()] = s
+	}
+	s.count++
+}
+
+// appendMetric appends the metric counting span events to ms.
+func (c *eventsCounter) appendMetric(ms pmetric.MetricSlice, names metricNames) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if len(c.series) == 0 {
+		return
+	}
+
+	m := ms.AppendEmpty()
+	m.SetName(names.events)
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	if c.delta {
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	} else {
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	}
+
+	now := time.Now()
+	for _, s := range c.series {
+		dp := sum.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(pcommon.NewTimestampFromTime(c.startTime))
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
+		dp.SetIntValue(s.count)
+		s.attrs.CopyTo(dp.Attributes())
+		if names.spanName != operationKey {
+			v, _ := dp.Attributes().Get(operationKey)
+			v.CopyTo(dp.Attributes().PutEmpty(names.spanName))
+			dp.Attributes().Remove(operationKey)
+		}
+	}
+
+	if c.delta {
+		c.startTime = now
+		c.series = make(map[string]*eventsSeries)
+	}
+}
+
+// dimensionValue returns the value of a dimension from the first of attrs
+// which has it, or its default value.
+func dimensionValue(d Dimension, attrs ...pcommon.Map) (pcommon.Value, bool) {
+	for _, a := range attrs {
+		if v, ok := a.Get(d.Name); ok {
+			return v, true
+		}
+	}
+	if d.Default != nil {
+		return pcommon.NewValueStr(*d.Default), true
+	}
+	return pcommon.NewValueEmpty(), false
+}
@@ -0,0 +1,344 @@
+// Package spanmetrics provides an otelcol.connector.spanmetrics component.
+package spanmetrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	"github.com/grafana/agent/component/otelcol/internal/startgate"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/spanmetricsprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.connector.spanmetrics",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/processor/spanmetricsprocessor"},
+	})
+}
+
+// Supported values of the aggregation_temporality argument.
+const (
+	AggregationTemporalityCumulative = "CUMULATIVE"
+	AggregationTemporalityDelta      = "DELTA"
+)
+
+// Arguments configures the otelcol.connector.spanmetrics component.
+type Arguments struct {
+	Dimensions             []Dimension     `river:"dimension,block,optional"`
+	DimensionsCacheSize    int             `river:"dimensions_cache_size,attr,optional"`
+	AggregationTemporality string          `river:"aggregation_temporality,attr,optional"`
+	HistogramBuckets       []time.Duration `river:"histogram_buckets,attr,optional"`
+
+	// Namespace is prepended to the names of metrics, unless LegacyMetricNames
+	// is set.
+	Namespace string `river:"namespace,attr,optional"`
+	// LegacyMetricNames uses the names of the metrics and dimensions of the
+	// spanmetrics processor of OpenTelemetry Collector.
+	LegacyMetricNames bool `river:"legacy_metric_names,attr,optional"`
+
+	Exemplars ExemplarsArguments `river:"exemplars,block,optional"`
+	Events    EventsArguments    `river:"events,block,optional"`
+
+	// Output configures where to send generated metrics. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// Dimension is an additional dimension of generated metrics, taken from an
+// attribute.
+type Dimension struct {
+	Name    string  `river:"name,attr"`
+	Default *string `river:"default,attr,optional"`
+}
+
+// ExemplarsArguments configures exemplars of the duration histogram.
+type ExemplarsArguments struct {
+	Enabled bool `river:"enabled,attr,optional"`
+}
+
+// EventsArguments configures the metric counting span events.
+type EventsArguments struct {
+	Enabled    bool        `river:"enabled,attr,optional"`
+	Dimensions []Dimension `river:"dimension,block,optional"`
+}
+
+var (
+	_ river.Unmarshaler = (*Arguments)(nil)
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	DimensionsCacheSize:    1000,
+	AggregationTemporality: AggregationTemporalityCumulative,
+	Namespace:              "traces.spanmetrics",
+}
+
+// UnmarshalRiver implements river.Unmarshaler. It applies defaults to args and
+// validates settings provided by the user.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch args.AggregationTemporality {
+	case AggregationTemporalityCumulative, AggregationTemporalityDelta:
+	default:
+		return fmt.Errorf("aggregation_temporality must be %q or %q", AggregationTemporalityCumulative, AggregationTemporalityDelta)
+	}
+	if args.DimensionsCacheSize <= 0 {
+		return fmt.Errorf("dimensions_cache_size must be greater than 0")
+	}
+	for i := 1; i < len(args.HistogramBuckets); i++ {
+		if args.HistogramBuckets[i] <= args.HistogramBuckets[i-1] {
+			return fmt.Errorf("histogram_buckets must be in increasing order")
+		}
+	}
+	if err := validateDimensions(args.Dimensions, args.LegacyMetricNames); err != nil {
+		return err
+	}
+	if args.Events.Enabled && len(args.Events.Dimensions) == 0 {
+		return fmt.Errorf("events must have at least one dimension when enabled")
+	}
+	return validateDimensions(args.Events.Dimensions, args.LegacyMetricNames)
+}
+
+// validateDimensions returns an error if dimensions have duplicate names or
+// names of the dimensions set on every metric.
+func validateDimensions(dimensions []Dimension, legacyNames bool) error {
+	names := map[string]struct{}{
+		serviceNameKey: {},
+		spanKindKey:    {},
+		statusCodeKey:  {},
+	}
+	if legacyNames {
+		names[operationKey] = struct{}{}
+	} else {
+		names[spanNameKey] = struct{}{}
+	}
+
+	for _, d := range dimensions {
+		if d.Name == "" {
+			return fmt.Errorf("dimension name must not be empty")
+		}
+		if _, ok := names[d.Name]; ok {
+			return fmt.Errorf("duplicate dimension name %q", d.Name)
+		}
+		names[d.Name] = struct{}{}
+	}
+	return nil
+}
+
+// convert converts args into the configuration of the upstream processor,
+// which sends metrics to the exporter with the given ID.
+func (args Arguments) convert(exporterID otelconfig.ComponentID) *spanmetricsprocessor.Config {
+	dimensions := make([]spanmetricsprocessor.Dimension, 0, len(args.Dimensions))
+	for _, d := range args.Dimensions {
+		dimensions = append(dimensions, spanmetricsprocessor.Dimension{Name: d.Name, Default: d.Default})
+	}
+
+	temporality := "AGGREGATION_TEMPORALITY_CUMULATIVE"
+	if args.AggregationTemporality == AggregationTemporalityDelta {
+		temporality = "AGGREGATION_TEMPORALITY_DELTA"
+	}
+
+	return &spanmetricsprocessor.Config{
+		ProcessorSettings:       otelconfig.NewProcessorSettings(otelconfig.NewComponentID("spanmetrics")),
+		MetricsExporter:         exporterID.String(),
+		LatencyHistogramBuckets: args.HistogramBuckets,
+		Dimensions:              dimensions,
+		DimensionsCacheSize:     args.DimensionsCacheSize,
+		AggregationTemporality:  temporality,
+	}
+}
+
+// Connector is the otelcol.connector.spanmetrics component. It wraps the
+// spanmetrics processor of OpenTelemetry Collector, which sends the metrics
+// it generates to an exporter; the exporter given to the processor converts
+// them and passes them to the consumers of the component.
+type Connector struct {
+	proc *processor.Processor
+
+	mut       sync.RWMutex
+	procInput otelcol.Consumer // Input of proc.
+	events    *eventsCounter   // nil unless events are enabled.
+}
+
+var (
+	_ component.Component       = (*Connector)(nil)
+	_ component.HealthComponent = (*Connector)(nil)
+)
+
+// New creates a new otelcol.connector.spanmetrics component.
+func New(opts component.Options, args Arguments) (*Connector, error) {
+	c := &Connector{}
+
+	// Intercept the exports of the processor, which are wrapped to count span
+	// events before the processor generates metrics.
+	procOpts := opts
+	procOpts.OnStateChange = func(e component.Exports) {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.procInput = e.(otelcol.ConsumerExports).Input
+	}
+
+	events, pargs := c.build(args)
+	c.setEvents(events)
+
+	proc, err := processor.New(procOpts, factory{spanmetricsprocessor.NewFactory()}, pargs)
+	if err != nil {
+		return nil, err
+	}
+	c.proc = proc
+
+	opts.OnStateChange(otelcol.ConsumerExports{Input: (*connectorInput)(c)})
+	return c, nil
+}
+
+// factory wraps the upstream factory. The upstream processor looks up the
+// exporter it sends metrics to when it starts, so spans sent to it are held
+// back until then.
+type factory struct {
+	otelcomponent.ProcessorFactory
+}
+
+// CreateTracesProcessor implements otelcomponent.ProcessorFactory.
+func (f factory) CreateTracesProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Traces) (otelcomponent.TracesProcessor, error) {
+	p, err := f.ProcessorFactory.CreateTracesProcessor(ctx, set, cfg, next)
+	if err != nil {
+		return nil, err
+	}
+	return startgate.Traces(p), nil
+}
+
+// build creates the counter of span events and the arguments of the wrapped
+// processor for args.
+func (c *Connector) build(args Arguments) (*eventsCounter, processorArguments) {
+	var events *eventsCounter
+	if args.Events.Enabled {
+		events = newEventsCounter(args)
+	}
+	exporter := &metricsExporter{
+		names:     newMetricNames(args),
+		exemplars: args.Exemplars.Enabled,
+		events:    events,
+		next:      fanoutconsumer.Metrics(args.Output.Metrics),
+	}
+	return events, processorArguments{args: args, exporter: exporter}
+}
+
+func (c *Connector) setEvents(events *eventsCounter) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.events = events
+}
+
+// Run implements component.Component.
+func (c *Connector) Run(ctx context.Context) error {
+	return c.proc.Run(ctx)
+}
+
+// Update implements component.Component.
+func (c *Connector) Update(args component.Arguments) error {
+	events, pargs := c.build(args.(Arguments))
+	c.setEvents(events)
+	return c.proc.Update(pargs)
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Connector) CurrentHealth() component.Health {
+	return c.proc.CurrentHealth()
+}
+
+// connectorInput is the input of a Connector. It counts the events of spans
+// before passing them to the wrapped processor.
+type connectorInput Connector
+
+var _ otelcol.Consumer = (*connectorInput)(nil)
+
+func (c *connectorInput) input() (otelcol.Consumer, *eventsCounter) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.procInput, c.events
+}
+
+// Capabilities implements otelcol.Consumer.
+func (c *connectorInput) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelcol.Consumer.
+func (c *connectorInput) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	next, events := c.input()
+	if events != nil {
+		events.consumeTraces(td)
+	}
+	return next.ConsumeTraces(ctx, td)
+}
+
+// ConsumeMetrics implements otelcol.Consumer.
+func (c *connectorInput) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	next, _ := c.input()
+	return next.ConsumeMetrics(ctx, md)
+}
+
+// ConsumeLogs implements otelcol.Consumer.
+func (c *connectorInput) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	next, _ := c.input()
+	return next.ConsumeLogs(ctx, ld)
+}
+
+// processorArguments are the arguments of the wrapped processor.
+type processorArguments struct {
+	args     Arguments
+	exporter *metricsExporter
+}
+
+var _ processor.Arguments = processorArguments{}
+
+// exporterID is the ID of the exporter which the processor sends metrics to.
+var exporterID = otelconfig.NewComponentIDWithName("spanmetrics", "connector")
+
+// Convert implements processor.Arguments.
+func (pargs processorArguments) Convert() (otelconfig.Processor, error) {
+	return pargs.args.convert(exporterID), nil
+}
+
+// Extensions implements processor.Arguments.
+func (pargs processorArguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (pargs processorArguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter{
+		otelconfig.MetricsDataType: {exporterID: pargs.exporter},
+	}
+}
+
+// NextConsumers implements processor.Arguments. Spans aren't passed on by the
+// component, so the processor has no consumers.
+func (pargs processorArguments) NextConsumers() *otelcol.ConsumerArguments {
+	return &otelcol.ConsumerArguments{}
+}
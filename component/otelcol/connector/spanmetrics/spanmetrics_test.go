@@ -0,0 +1,270 @@
+package spanmetrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/connector/spanmetrics"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/dskit/backoff"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name:   "invalid temporality",
+			cfg:    `aggregation_temporality = "SOMETIMES"`,
+			expect: `aggregation_temporality must be "CUMULATIVE" or "DELTA"`,
+		},
+		{
+			name:   "unsorted buckets",
+			cfg:    `histogram_buckets = ["10ms", "2ms"]`,
+			expect: "histogram_buckets must be in increasing order",
+		},
+		{
+			name:   "reserved dimension",
+			cfg:    `dimension { name = "span.kind" }`,
+			expect: `duplicate dimension name "span.kind"`,
+		},
+		{
+			name: "reserved legacy dimension",
+			cfg: `
+				legacy_metric_names = true
+				dimension { name = "operation" }
+			`,
+			expect: `duplicate dimension name "operation"`,
+		},
+		{
+			name:   "events without dimensions",
+			cfg:    `events { enabled = true }`,
+			expect: "events must have at least one dimension when enabled",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args spanmetrics.Arguments
+			err := river.Unmarshal([]byte(tc.cfg+"\noutput {}\n"), &args)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
+
+func Test(t *testing.T) {
+	cfg := `
+		dimension {
+			name    = "http.method"
+			default = "GET"
+		}
+		exemplars {
+			enabled = true
+		}
+		events {
+			enabled = true
+			dimension {
+				name = "exception.type"
+			}
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	md := runConnector(t, cfg)
+
+	metrics := metricsByName(md)
+	require.Len(t, metrics, 3)
+
+	calls := metrics["traces.spanmetrics.calls"]
+	require.Equal(t, 1, calls.Sum().DataPoints().Len())
+	callsAttrs := calls.Sum().DataPoints().At(0).Attributes().AsRaw()
+	require.Equal(t, "TestSpan", callsAttrs["span.name"])
+	require.Equal(t, "GET", callsAttrs["http.method"])
+	require.NotContains(t, callsAttrs, "operation")
+
+	duration := metrics["traces.spanmetrics.duration"]
+	require.Equal(t, 1, duration.Histogram().DataPoints().Len())
+	require.Equal(t, 1, duration.Histogram().DataPoints().At(0).Exemplars().Len())
+
+	events := metrics["traces.spanmetrics.events"]
+	require.Equal(t, 1, events.Sum().DataPoints().Len())
+	eventsDP := events.Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), eventsDP.IntValue())
+	require.Equal(t, map[string]interface{}{
+		"service.name":   "test-service",
+		"span.name":      "TestSpan",
+		"span.kind":      "SPAN_KIND_SERVER",
+		"status.code":    "STATUS_CODE_UNSET",
+		"http.method":    "GET",
+		"exception.type": "IOException",
+	}, eventsDP.Attributes().AsRaw())
+}
+
+func TestLegacyMetricNames(t *testing.T) {
+	cfg := `
+		legacy_metric_names = true
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	md := runConnector(t, cfg)
+
+	metrics := metricsByName(md)
+	require.Len(t, metrics, 2)
+
+	calls, ok := metrics["calls_total"]
+	require.True(t, ok)
+	require.Equal(t, "TestSpan", calls.Sum().DataPoints().At(0).Attributes().AsRaw()["operation"])
+
+	latency, ok := metrics["latency"]
+	require.True(t, ok)
+	// Exemplars are disabled by default.
+	require.Equal(t, 0, latency.Histogram().DataPoints().At(0).Exemplars().Len())
+}
+
+// runConnector runs the otelcol.connector.spanmetrics component with the
+// given config, sends it test traces, and returns the first metrics it
+// generates.
+func runConnector(t *testing.T, cfg string) pmetric.Metrics {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.connector.spanmetrics")
+	require.NoError(t, err)
+
+	var args spanmetrics.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our arguments so metrics get forwarded to metricCh.
+	metricCh := make(chan pmetric.Metrics)
+	args.Output = makeMetricsOutput(metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	// Send traces in the background to our connector.
+	go func() {
+		exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+		bo := backoff.New(ctx, backoff.Config{
+			MinBackoff: 10 * time.Millisecond,
+			MaxBackoff: 100 * time.Millisecond,
+		})
+		for bo.Ongoing() {
+			err := exports.Input.ConsumeTraces(ctx, createTestTraces())
+			if err != nil {
+				level.Error(l).Log("msg", "failed to send traces", "err", err)
+				bo.Wait()
+				continue
+			}
+
+			return
+		}
+	}()
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+		return pmetric.Metrics{}
+	case md := <-metricCh:
+		return md
+	}
+}
+
+func metricsByName(md pmetric.Metrics) map[string]pmetric.Metric {
+	res := make(map[string]pmetric.Metric)
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				res[ms.At(k).Name()] = ms.At(k)
+			}
+		}
+	}
+	return res
+}
+
+// makeMetricsOutput returns ConsumerArguments which will forward metrics to
+// the provided channel.
+func makeMetricsOutput(ch chan pmetric.Metrics) *otelcol.ConsumerArguments {
+	metricConsumer := fakeconsumer.Consumer{
+		ConsumeMetricsFunc: func(ctx context.Context, m pmetric.Metrics) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- m:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&metricConsumer},
+	}
+}
+
+func createTestTraces() ptrace.Traces {
+	// Matches format from the protobuf definition:
+	// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+	var bb = `{
+		"resource_spans": [{
+			"resource": {
+				"attributes": [{
+					"key": "service.name",
+					"value": { "stringValue": "test-service" }
+				}]
+			},
+			"scope_spans": [{
+				"spans": [{
+					"trace_id": "7bba9f33312b3dbb8b2c2c62bb7abe2d",
+					"span_id": "086e83747d0e381e",
+					"name": "TestSpan",
+					"kind": 2,
+					"start_time_unix_nano": "1000000000",
+					"end_time_unix_nano": "1050000000",
+					"events": [{
+						"name": "exception",
+						"attributes": [{
+							"key": "exception.type",
+							"value": { "stringValue": "IOException" }
+						}]
+					}, {
+						"name": "exception",
+						"attributes": [{
+							"key": "exception.type",
+							"value": { "stringValue": "IOException" }
+						}]
+					}]
+				}]
+			}]
+		}]
+	}`
+
+	decoder := &ptrace.JSONUnmarshaler{}
+	data, err := decoder.UnmarshalTraces([]byte(bb))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/censuscollector"
 	"github.com/grafana/agent/component/otelcol/internal/lazycollector"
 	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
 	"github.com/grafana/agent/component/otelcol/internal/scheduler"
@@ -17,6 +18,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/external/obsreportconfig/obsmetrics"
 	sdkprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/sdk/metric"
 
@@ -41,6 +44,28 @@ type Arguments interface {
 	Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter
 }
 
+// SignalArguments is an optional extension of Arguments for exporters whose
+// configuration differs between telemetry signals, such as exporters which
+// send each signal to a different endpoint.
+type SignalArguments interface {
+	Arguments
+
+	// ConvertSignal converts the Arguments into an OpenTelemetry Collector
+	// exporter configuration used for the given telemetry signal.
+	ConvertSignal(signal otelconfig.DataType) (otelconfig.Exporter, error)
+}
+
+// ConsumerArguments is an optional extension of Arguments for exporters which
+// process telemetry before passing it to the upstream exporter, such as
+// exporters which split batches.
+type ConsumerArguments interface {
+	Arguments
+
+	// WrapConsumers wraps the consumers of the upstream exporter. The consumers
+	// of unsupported telemetry signals are nil and must be returned as nil.
+	WrapConsumers(traces otelconsumer.Traces, metrics otelconsumer.Metrics, logs otelconsumer.Logs) (otelconsumer.Traces, otelconsumer.Metrics, otelconsumer.Logs)
+}
+
 // Exporter is a Flow component shim which manages an OpenTelemetry Collector
 // exporter component.
 type Exporter struct {
@@ -141,30 +166,67 @@ func (e *Exporter) Update(args component.Arguments) error {
 		},
 	}
 
-	exporterConfig, err := eargs.Convert()
+	// convert returns the exporter configuration for a telemetry signal.
+	convert := func(signal otelconfig.DataType) (otelconfig.Exporter, error) {
+		var (
+			exporterConfig otelconfig.Exporter
+			err            error
+		)
+		if sargs, ok := eargs.(SignalArguments); ok {
+			exporterConfig, err = sargs.ConvertSignal(signal)
+		} else {
+			exporterConfig, err = eargs.Convert()
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Name the upstream component after our component ID so that its
+		// internal telemetry, which upstream records globally, can be told apart
+		// from other instances of the same component.
+		exporterConfig.SetIDName(e.opts.ID)
+		return exporterConfig, nil
+	}
+
+	tracesConfig, err := convert(otelconfig.TracesDataType)
+	if err != nil {
+		return err
+	}
+	metricsConfig, err := convert(otelconfig.MetricsDataType)
 	if err != nil {
 		return err
 	}
+	logsConfig, err := convert(otelconfig.LogsDataType)
+	if err != nil {
+		return err
+	}
+
+	// Every signal shares the same component ID, so a single collector exposes
+	// the telemetry of all of them.
+	census, err := censuscollector.New(obsmetrics.ExporterKey, tracesConfig.ID())
+	if err != nil {
+		return err
+	}
+	reg.MustRegister(census)
 
 	// Create instances of the exporter from our factory for each of our
 	// supported telemetry signals.
 	var components []otelcomponent.Component
 
-	tracesExporter, err := e.factory.CreateTracesExporter(e.ctx, settings, exporterConfig)
+	tracesExporter, err := e.factory.CreateTracesExporter(e.ctx, settings, tracesConfig)
 	if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
 		return err
 	} else if tracesExporter != nil {
 		components = append(components, tracesExporter)
 	}
 
-	metricsExporter, err := e.factory.CreateMetricsExporter(e.ctx, settings, exporterConfig)
+	metricsExporter, err := e.factory.CreateMetricsExporter(e.ctx, settings, metricsConfig)
 	if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
 		return err
 	} else if metricsExporter != nil {
 		components = append(components, metricsExporter)
 	}
 
-	logsExporter, err := e.factory.CreateLogsExporter(e.ctx, settings, exporterConfig)
+	logsExporter, err := e.factory.CreateLogsExporter(e.ctx, settings, logsConfig)
 	if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
 		return err
 	} else if logsExporter != nil {
@@ -173,7 +235,16 @@ func (e *Exporter) Update(args component.Arguments) error {
 
 	// Schedule the components to run once our component is running.
 	e.sched.Schedule(host, components...)
-	e.consumer.SetConsumers(tracesExporter, metricsExporter, logsExporter)
+
+	var (
+		tracesConsumer  otelconsumer.Traces  = tracesExporter
+		metricsConsumer otelconsumer.Metrics = metricsExporter
+		logsConsumer    otelconsumer.Logs    = logsExporter
+	)
+	if cargs, ok := eargs.(ConsumerArguments); ok {
+		tracesConsumer, metricsConsumer, logsConsumer = cargs.WrapConsumers(tracesConsumer, metricsConsumer, logsConsumer)
+	}
+	e.consumer.SetConsumers(tracesConsumer, metricsConsumer, logsConsumer)
 	return nil
 }
 
@@ -24,6 +24,8 @@ func init() {
 			fact := jaegerexporter.NewFactory()
 			return exporter.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/jaegerexporter"},
 	})
 }
 
@@ -18,6 +18,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -29,8 +30,9 @@ type Converter struct {
 	log     log.Logger
 	metrics *metrics
 
-	mut  sync.RWMutex
-	next []loki.LogsReceiver // Location to write converted logs.
+	mut              sync.RWMutex
+	next             []loki.LogsReceiver // Location to write converted logs.
+	kubernetesLabels bool                // Whether to promote Kubernetes resource attributes to labels.
 }
 
 var _ consumer.Logs = (*Converter)(nil)
@@ -60,6 +62,10 @@ func (conv *Converter) Capabilities() consumer.Capabilities {
 func (conv *Converter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
 	var entries []loki.Entry
 
+	conv.mut.RLock()
+	promoteKubernetes := conv.kubernetesLabels
+	conv.mut.RUnlock()
+
 	rls := ld.ResourceLogs()
 	for i := 0; i < rls.Len(); i++ {
 		ills := rls.At(i).ScopeLogs()
@@ -92,6 +98,11 @@ func (conv *Converter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
 				removeAttributes(log.Attributes(), mergedLabels)
 				removeAttributes(resource.Attributes(), mergedLabels)
 
+				if promoteKubernetes {
+					// Labels set through hints take precedence.
+					mergedLabels = promoteKubernetesAttributes(resource.Attributes()).Merge(mergedLabels)
+				}
+
 				entry, err := convertLogToLokiEntry(log, resource, format)
 				if err != nil {
 					level.Error(conv.log).Log("msg", "failed to convert log to loki entry", "err", err)
@@ -131,6 +142,39 @@ func (conv *Converter) UpdateFanout(fanout []loki.LogsReceiver) {
 	conv.next = fanout
 }
 
+// SetKubernetesLabels sets whether the Kubernetes resource attributes in
+// kubernetesAttributeLabels are promoted to labels without requiring hints.
+func (conv *Converter) SetKubernetesLabels(enabled bool) {
+	conv.mut.Lock()
+	defer conv.mut.Unlock()
+
+	conv.kubernetesLabels = enabled
+}
+
+// kubernetesAttributeLabels maps the Kubernetes resource attributes of the
+// OpenTelemetry semantic conventions to the label names commonly used for
+// them in Loki.
+var kubernetesAttributeLabels = map[string]model.LabelName{
+	"k8s.namespace.name": "namespace",
+	"k8s.pod.name":       "pod",
+	"k8s.container.name": "container",
+}
+
+// promoteKubernetesAttributes returns the labels for the Kubernetes resource
+// attributes in resAttrs, and removes the attributes which were promoted.
+func promoteKubernetesAttributes(resAttrs pcommon.Map) model.LabelSet {
+	out := model.LabelSet{}
+	resAttrs.RemoveIf(func(k string, v pcommon.Value) bool {
+		name, ok := kubernetesAttributeLabels[k]
+		if !ok || v.AsString() == "" {
+			return false
+		}
+		out[name] = model.LabelValue(v.AsString())
+		return true
+	})
+	return out
+}
+
 func addLogLevelAttributeAndHint(log plog.LogRecord) {
 	if log.SeverityNumber() == plog.SeverityNumberUnspecified {
 		return
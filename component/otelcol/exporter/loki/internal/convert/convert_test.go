@@ -20,6 +20,8 @@ func TestConverter(t *testing.T) {
 		expectLine      string
 		expectLabels    string
 		expectTimestamp time.Time
+
+		kubernetesLabels bool
 	}{
 		{
 			name: "log line without format hint",
@@ -447,6 +449,127 @@ func TestConverter(t *testing.T) {
 			expectLabels:    `{exporter="OTLP", level="ERROR", tenant.id="tenant_2"}`,
 			expectTimestamp: time.Date(2023, time.January, 4, 10, 10, 31, 972869000, time.UTC),
 		},
+		{
+			name:             "kubernetes resource attributes promoted to labels",
+			kubernetesLabels: true,
+			input: `{
+  "resourceLogs": [
+    {
+      "resource": {
+        "attributes": [
+          {
+            "key": "host.name",
+            "value": {
+              "stringValue": "testHost"
+            }
+          },
+          {
+            "key": "k8s.namespace.name",
+            "value": {
+              "stringValue": "default"
+            }
+          },
+          {
+            "key": "k8s.pod.name",
+            "value": {
+              "stringValue": "app-7d9f8"
+            }
+          },
+          {
+            "key": "k8s.container.name",
+            "value": {
+              "stringValue": "app"
+            }
+          },
+          {
+            "key": "loki.resource.labels",
+            "value": {
+              "stringValue": "host.name"
+            }
+          }
+        ]
+      },
+      "scopeLogs": [
+        {
+          "logRecords": [
+            {
+              "timeUnixNano": "1672827031972869000",
+              "severityNumber": 17,
+              "severityText": "Error",
+              "body": {
+                "stringValue": "hello world"
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`,
+			expectLine:      `{"body":"hello world","severity":"Error"}`,
+			expectLabels:    `{container="app", exporter="OTLP", host.name="testHost", level="ERROR", namespace="default", pod="app-7d9f8"}`,
+			expectTimestamp: time.Date(2023, time.January, 4, 10, 10, 31, 972869000, time.UTC),
+		},
+		{
+			name: "kubernetes resource attributes not promoted by default",
+			input: `{
+  "resourceLogs": [
+    {
+      "resource": {
+        "attributes": [
+          {
+            "key": "host.name",
+            "value": {
+              "stringValue": "testHost"
+            }
+          },
+          {
+            "key": "k8s.namespace.name",
+            "value": {
+              "stringValue": "default"
+            }
+          },
+          {
+            "key": "k8s.pod.name",
+            "value": {
+              "stringValue": "app-7d9f8"
+            }
+          },
+          {
+            "key": "k8s.container.name",
+            "value": {
+              "stringValue": "app"
+            }
+          },
+          {
+            "key": "loki.resource.labels",
+            "value": {
+              "stringValue": "host.name"
+            }
+          }
+        ]
+      },
+      "scopeLogs": [
+        {
+          "logRecords": [
+            {
+              "timeUnixNano": "1672827031972869000",
+              "severityNumber": 17,
+              "severityText": "Error",
+              "body": {
+                "stringValue": "hello world"
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`,
+			expectLine:      `{"body":"hello world","severity":"Error","resources":{"k8s.container.name":"app","k8s.namespace.name":"default","k8s.pod.name":"app-7d9f8"}}`,
+			expectLabels:    `{exporter="OTLP", host.name="testHost", level="ERROR"}`,
+			expectTimestamp: time.Date(2023, time.January, 4, 10, 10, 31, 972869000, time.UTC),
+		},
 	}
 
 	decoder := &plog.JSONUnmarshaler{}
@@ -458,6 +581,7 @@ func TestConverter(t *testing.T) {
 			l := util.TestLogger(t)
 			ch1, ch2 := make(loki.LogsReceiver), make(loki.LogsReceiver)
 			conv := convert.New(l, prometheus.NewRegistry(), []loki.LogsReceiver{ch1, ch2})
+			conv.SetKubernetesLabels(tc.kubernetesLabels)
 			go func() {
 				require.NoError(t, conv.ConsumeLogs(context.Background(), payload))
 			}()
@@ -21,12 +21,18 @@ func init() {
 		Build: func(o component.Options, a component.Arguments) (component.Component, error) {
 			return New(o, a.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
 	})
 }
 
 // Arguments configures the otelcol.exporter.loki component.
 type Arguments struct {
 	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+
+	// KubernetesLabels promotes the standard Kubernetes resource attributes to
+	// the namespace, pod, and container labels without requiring hints.
+	KubernetesLabels bool `river:"kubernetes_labels,attr,optional"`
 }
 
 // Component is the otelcol.exporter.loki component.
@@ -73,5 +79,6 @@ func (c *Component) Run(ctx context.Context) error {
 func (c *Component) Update(newConfig component.Arguments) error {
 	cfg := newConfig.(Arguments)
 	c.converter.UpdateFanout(cfg.ForwardTo)
+	c.converter.SetKubernetesLabels(cfg.KubernetesLabels)
 	return nil
 }
@@ -24,6 +24,8 @@ func init() {
 			fact := otlpexporter.NewFactory()
 			return exporter.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "go.opentelemetry.io/collector/exporter/otlpexporter"},
 	})
 }
 
@@ -35,11 +37,19 @@ type Arguments struct {
 	Retry otelcol.RetryArguments `river:"retry_on_failure,block,optional"`
 
 	Client GRPCClientArguments `river:"client,block"`
+
+	// The endpoints to send each signal to. If unset, Client.Endpoint is used
+	// for the corresponding signal. All signals share the remaining client
+	// settings, such as TLS and authentication.
+	TracesEndpoint  string `river:"traces_endpoint,attr,optional"`
+	MetricsEndpoint string `river:"metrics_endpoint,attr,optional"`
+	LogsEndpoint    string `river:"logs_endpoint,attr,optional"`
 }
 
 var (
-	_ river.Unmarshaler  = (*Arguments)(nil)
-	_ exporter.Arguments = Arguments{}
+	_ river.Unmarshaler        = (*Arguments)(nil)
+	_ exporter.Arguments       = Arguments{}
+	_ exporter.SignalArguments = Arguments{}
 )
 
 // DefaultArguments holds default values for Arguments.
@@ -70,6 +80,28 @@ func (args Arguments) Convert() (otelconfig.Exporter, error) {
 	}, nil
 }
 
+// ConvertSignal implements exporter.SignalArguments.
+func (args Arguments) ConvertSignal(signal otelconfig.DataType) (otelconfig.Exporter, error) {
+	cfg, err := args.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoint string
+	switch signal {
+	case otelconfig.TracesDataType:
+		endpoint = args.TracesEndpoint
+	case otelconfig.MetricsDataType:
+		endpoint = args.MetricsEndpoint
+	case otelconfig.LogsDataType:
+		endpoint = args.LogsEndpoint
+	}
+	if endpoint != "" {
+		cfg.(*otlpexporter.Config).GRPCClientSettings.Endpoint = endpoint
+	}
+	return cfg, nil
+}
+
 // Extensions implements exporter.Arguments.
 func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
 	return (*otelcol.GRPCClientArguments)(&args.Client).Extensions()
@@ -15,6 +15,8 @@ import (
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/dskit/backoff"
 	"github.com/stretchr/testify/require"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/exporter/otlpexporter"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/grpc"
@@ -137,3 +139,30 @@ func createTestTraces() ptrace.Traces {
 	}
 	return data
 }
+
+func TestArguments_SignalEndpoints(t *testing.T) {
+	cfg := `
+		traces_endpoint = "traces.example.com:4317"
+
+		client {
+			endpoint = "otlp.example.com:4317"
+			headers  = { "X-Scope-OrgID" = "tenant" }
+		}
+	`
+	var args otlp.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	expect := map[otelconfig.DataType]string{
+		otelconfig.TracesDataType:  "traces.example.com:4317",
+		otelconfig.MetricsDataType: "otlp.example.com:4317",
+		otelconfig.LogsDataType:    "otlp.example.com:4317",
+	}
+	for signal, endpoint := range expect {
+		exporterConfig, err := args.ConvertSignal(signal)
+		require.NoError(t, err)
+
+		otlpConfig := exporterConfig.(*otlpexporter.Config)
+		require.Equal(t, endpoint, otlpConfig.Endpoint, "signal %s", signal)
+		require.Equal(t, "tenant", otlpConfig.Headers["X-Scope-OrgID"], "signal %s", signal)
+	}
+}
@@ -3,6 +3,8 @@ package otlphttp
 
 import (
 	"errors"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/grafana/agent/component"
@@ -11,6 +13,7 @@ import (
 	"github.com/grafana/agent/pkg/river"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/exporter/otlphttpexporter"
 )
 
@@ -24,6 +27,8 @@ func init() {
 			fact := otlphttpexporter.NewFactory()
 			return exporter.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "go.opentelemetry.io/collector/exporter/otlphttpexporter"},
 	})
 }
 
@@ -40,12 +45,17 @@ type Arguments struct {
 	TracesEndpoint  string `river:"traces_endpoint,attr,optional"`
 	MetricsEndpoint string `river:"metrics_endpoint,attr,optional"`
 	LogsEndpoint    string `river:"logs_endpoint,attr,optional"`
+
+	// Routing sets an HTTP header from a resource attribute, splitting batches
+	// with different values of the attribute.
+	Routing *AttributeRoutingArguments `river:"attribute_routing,block,optional"`
 }
 
 var (
-	_ river.Unmarshaler  = (*Arguments)(nil)
-	_ river.Unmarshaler  = (*HTTPClientArguments)(nil)
-	_ exporter.Arguments = Arguments{}
+	_ river.Unmarshaler          = (*Arguments)(nil)
+	_ river.Unmarshaler          = (*HTTPClientArguments)(nil)
+	_ exporter.Arguments         = Arguments{}
+	_ exporter.ConsumerArguments = Arguments{}
 )
 
 // DefaultArguments holds default values for Arguments.
@@ -68,14 +78,59 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 
 // Convert implements exporter.Arguments.
 func (args Arguments) Convert() (otelconfig.Exporter, error) {
+	httpClientSettings := *(*otelcol.HTTPClientArguments)(&args.Client).Convert()
+	if args.Routing != nil {
+		// A static value of the routed header would override the routed value,
+		// so it's only used as the default value; see WrapConsumers.
+		headers := make(map[string]string, len(httpClientSettings.Headers))
+		for k, v := range httpClientSettings.Headers {
+			if !strings.EqualFold(k, args.Routing.Header) {
+				headers[k] = v
+			}
+		}
+		httpClientSettings.Headers = headers
+
+		header := args.Routing.Header
+		httpClientSettings.CustomRoundTripper = func(next http.RoundTripper) (http.RoundTripper, error) {
+			return &headerRoundTripper{next: next, header: header}, nil
+		}
+	}
+
 	return &otlphttpexporter.Config{
 		ExporterSettings:   otelconfig.NewExporterSettings(otelconfig.NewComponentID("otlp")),
-		HTTPClientSettings: *(*otelcol.HTTPClientArguments)(&args.Client).Convert(),
+		HTTPClientSettings: httpClientSettings,
 		QueueSettings:      *args.Queue.Convert(),
 		RetrySettings:      *args.Retry.Convert(),
 	}, nil
 }
 
+// WrapConsumers implements exporter.ConsumerArguments.
+func (args Arguments) WrapConsumers(traces otelconsumer.Traces, metrics otelconsumer.Metrics, logs otelconsumer.Logs) (otelconsumer.Traces, otelconsumer.Metrics, otelconsumer.Logs) {
+	if args.Routing == nil {
+		return traces, metrics, logs
+	}
+
+	r := router{attribute: args.Routing.Attribute, defaultValue: args.Routing.DefaultValue}
+	if r.defaultValue == "" {
+		for k, v := range args.Client.Headers {
+			if strings.EqualFold(k, args.Routing.Header) {
+				r.defaultValue = v
+			}
+		}
+	}
+
+	if traces != nil {
+		traces = &tracesRouter{router: r, next: traces}
+	}
+	if metrics != nil {
+		metrics = &metricsRouter{router: r, next: metrics}
+	}
+	if logs != nil {
+		logs = &logsRouter{router: r, next: logs}
+	}
+	return traces, metrics, logs
+}
+
 // Extensions implements exporter.Arguments.
 func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
 	return (*otelcol.HTTPClientArguments)(&args.Client).Extensions()
@@ -94,6 +94,93 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestAttributeRouting ensures that batches are split by the routed resource
+// attribute, and that each part is sent with its value in the routed header.
+func TestAttributeRouting(t *testing.T) {
+	type request struct {
+		tenant string
+		spans  int
+	}
+	ch := make(chan request, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		decoder := &ptrace.ProtoUnmarshaler{}
+		trace, _ := decoder.UnmarshalTraces(b)
+		ch <- request{tenant: r.Header.Get("X-Scope-OrgID"), spans: trace.SpanCount()}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.exporter.otlphttp")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		client {
+			endpoint    = "%s"
+			compression = "none"
+			headers     = {"X-Scope-OrgID" = "fallback"}
+		}
+
+		attribute_routing {
+			attribute = "tenant"
+		}
+	`, srv.URL)
+	var args otlphttp.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	var bb = `{
+		"resource_spans": [
+			{
+				"resource": {"attributes": [{"key": "tenant", "value": {"stringValue": "team-a"}}]},
+				"scope_spans": [{"spans": [{"name": "a1"}, {"name": "a2"}]}]
+			},
+			{
+				"resource": {"attributes": [{"key": "tenant", "value": {"stringValue": "team-b"}}]},
+				"scope_spans": [{"spans": [{"name": "b1"}]}]
+			},
+			{
+				"scope_spans": [{"spans": [{"name": "none"}]}]
+			},
+			{
+				"resource": {"attributes": [{"key": "tenant", "value": {"stringValue": "team-a"}}]},
+				"scope_spans": [{"spans": [{"name": "a3"}]}]
+			}
+		]
+	}`
+	traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(bb))
+	require.NoError(t, err)
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.NoError(t, exports.Input.ConsumeTraces(ctx, traces))
+
+	var got []request
+	for i := 0; i < 3; i++ {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for traces")
+		case r := <-ch:
+			got = append(got, r)
+		}
+	}
+	// Parts are sent concurrently by the sending queue.
+	require.ElementsMatch(t, []request{
+		{tenant: "team-a", spans: 3},
+		{tenant: "team-b", spans: 1},
+		{tenant: "fallback", spans: 1},
+	}, got)
+}
+
 func createTestTraces() ptrace.Traces {
 	// Matches format from the protobuf definition:
 	// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
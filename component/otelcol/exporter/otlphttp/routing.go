@@ -0,0 +1,196 @@
+package otlphttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+)
+
+// AttributeRoutingArguments configures setting an HTTP header of requests
+// from a resource attribute of the telemetry they contain.
+type AttributeRoutingArguments struct {
+	Attribute    string `river:"attribute,attr"`
+	Header       string `river:"header,attr,optional"`
+	DefaultValue string `river:"default_value,attr,optional"`
+}
+
+// DefaultAttributeRoutingArguments holds default values for
+// AttributeRoutingArguments.
+var DefaultAttributeRoutingArguments = AttributeRoutingArguments{
+	Header: "X-Scope-OrgID",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *AttributeRoutingArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultAttributeRoutingArguments
+	type arguments AttributeRoutingArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Attribute == "":
+		return errors.New("attribute must not be empty")
+	case args.Header == "":
+		return errors.New("header must not be empty")
+	}
+	return nil
+}
+
+// headerValueKey is the context key holding the value of the routed header for
+// a batch.
+type headerValueKey struct{}
+
+// headerRoundTripper sets a header of requests to the value held by their
+// context.
+type headerRoundTripper struct {
+	next   http.RoundTripper
+	header string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	value, _ := req.Context().Value(headerValueKey{}).(string)
+	if value == "" {
+		return rt.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(rt.header, value)
+	return rt.next.RoundTrip(req)
+}
+
+// router splits batches by the value of a resource attribute, and passes each
+// part to the upstream exporter with the value of the routed header in its
+// context.
+type router struct {
+	attribute    string
+	defaultValue string // Used for resources without the attribute.
+}
+
+// headerValue returns the value of the routed header for a resource.
+func (r router) headerValue(res pcommon.Resource) string {
+	if v, ok := res.Attributes().Get(r.attribute); ok && v.AsString() != "" {
+		return v.AsString()
+	}
+	return r.defaultValue
+}
+
+// split groups the indices of n resources by the value of the routed header,
+// returning the values in the order they were first seen.
+func (r router) split(n int, resource func(i int) pcommon.Resource) ([]string, map[string][]int) {
+	if n == 0 {
+		return []string{r.defaultValue}, nil
+	}
+
+	var (
+		values  []string
+		indices = make(map[string][]int)
+	)
+	for i := 0; i < n; i++ {
+		value := r.headerValue(resource(i))
+		if _, ok := indices[value]; !ok {
+			values = append(values, value)
+		}
+		indices[value] = append(indices[value], i)
+	}
+	return values, indices
+}
+
+func withHeaderValue(ctx context.Context, value string) context.Context {
+	return context.WithValue(ctx, headerValueKey{}, value)
+}
+
+type tracesRouter struct {
+	router
+	next otelconsumer.Traces
+}
+
+// Capabilities implements otelconsumer.Traces.
+func (c *tracesRouter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelconsumer.Traces.
+func (c *tracesRouter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	rss := td.ResourceSpans()
+	values, indices := c.split(rss.Len(), func(i int) pcommon.Resource { return rss.At(i).Resource() })
+	if len(values) == 1 {
+		return c.next.ConsumeTraces(withHeaderValue(ctx, values[0]), td)
+	}
+
+	var errs error
+	for _, value := range values {
+		part := ptrace.NewTraces()
+		for _, i := range indices[value] {
+			rss.At(i).CopyTo(part.ResourceSpans().AppendEmpty())
+		}
+		errs = multierr.Append(errs, c.next.ConsumeTraces(withHeaderValue(ctx, value), part))
+	}
+	return errs
+}
+
+type metricsRouter struct {
+	router
+	next otelconsumer.Metrics
+}
+
+// Capabilities implements otelconsumer.Metrics.
+func (c *metricsRouter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeMetrics implements otelconsumer.Metrics.
+func (c *metricsRouter) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	rms := md.ResourceMetrics()
+	values, indices := c.split(rms.Len(), func(i int) pcommon.Resource { return rms.At(i).Resource() })
+	if len(values) == 1 {
+		return c.next.ConsumeMetrics(withHeaderValue(ctx, values[0]), md)
+	}
+
+	var errs error
+	for _, value := range values {
+		part := pmetric.NewMetrics()
+		for _, i := range indices[value] {
+			rms.At(i).CopyTo(part.ResourceMetrics().AppendEmpty())
+		}
+		errs = multierr.Append(errs, c.next.ConsumeMetrics(withHeaderValue(ctx, value), part))
+	}
+	return errs
+}
+
+type logsRouter struct {
+	router
+	next otelconsumer.Logs
+}
+
+// Capabilities implements otelconsumer.Logs.
+func (c *logsRouter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeLogs implements otelconsumer.Logs.
+func (c *logsRouter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	rls := ld.ResourceLogs()
+	values, indices := c.split(rls.Len(), func(i int) pcommon.Resource { return rls.At(i).Resource() })
+	if len(values) == 1 {
+		return c.next.ConsumeLogs(withHeaderValue(ctx, values[0]), ld)
+	}
+
+	var errs error
+	for _, value := range values {
+		part := plog.NewLogs()
+		for _, i := range indices[value] {
+			rls.At(i).CopyTo(part.ResourceLogs().AppendEmpty())
+		}
+		errs = multierr.Append(errs, c.next.ConsumeLogs(withHeaderValue(ctx, value), part))
+	}
+	return errs
+}
@@ -26,6 +26,8 @@ func init() {
 		Build: func(o component.Options, a component.Arguments) (component.Component, error) {
 			return New(o, a.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
 	})
 }
 
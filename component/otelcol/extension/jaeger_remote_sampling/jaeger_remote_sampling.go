@@ -23,6 +23,8 @@ func init() {
 
 			return extension.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/jaegertracing/jaeger"},
 	})
 }
 
@@ -0,0 +1,166 @@
+// Package censuscollector exposes the OpenCensus metrics recorded by upstream
+// OpenTelemetry Collector components as Prometheus metrics.
+//
+// Upstream exporters and processors record their internal telemetry (such as
+// queue sizes and send failures) to global OpenCensus instruments which are
+// labeled with the ID of the recording component. A Collector only exposes
+// the series for a single component so that the metrics can be attributed to
+// the Flow component which owns it.
+package censuscollector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/metric/metricproducer"
+	"go.opencensus.io/stats/view"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/config/configtelemetry"
+	"go.opentelemetry.io/collector/external/obsreportconfig"
+)
+
+// Namespace is prepended to the name of every exposed metric, matching the
+// names used by the OpenTelemetry Collector.
+const Namespace = "otelcol"
+
+var (
+	registerViewsOnce sync.Once
+	registerViewsErr  error
+)
+
+// registerViews registers the upstream views so that measurements recorded by
+// upstream components are aggregated. Views are only registered once; the
+// error of that registration is returned to every caller.
+func registerViews() error {
+	registerViewsOnce.Do(func() {
+		registerViewsErr = view.Register(obsreportconfig.Configure(configtelemetry.LevelBasic).Views...)
+	})
+	return registerViewsErr
+}
+
+// Collector is a prometheus.Collector which exposes the OpenCensus metrics of
+// a single upstream component.
+type Collector struct {
+	key string // Label key identifying the kind of component, such as "exporter".
+	id  string // Value of the label for the component to expose.
+
+	producers func() []metricproducer.Producer
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New creates a new Collector exposing the metrics whose key label is set to
+// id. key is the kind of component, such as obsmetrics.ExporterKey. An error
+// is returned if the upstream views couldn't be registered.
+func New(key string, id otelconfig.ComponentID) (*Collector, error) {
+	if err := registerViews(); err != nil {
+		return nil, fmt.Errorf("failed to register OpenCensus views: %w", err)
+	}
+
+	return &Collector{
+		key:       key,
+		id:        id.String(),
+		producers: metricproducer.GlobalManager().GetAll,
+	}, nil
+}
+
+// Describe implements prometheus.Collector. The set of metrics isn't known
+// ahead of time, so no descriptions are sent, making the Collector unchecked.
+func (c *Collector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, producer := range c.producers() {
+		for _, m := range producer.Read() {
+			c.collectMetric(ch, m)
+		}
+	}
+}
+
+func (c *Collector) collectMetric(ch chan<- prometheus.Metric, m *metricdata.Metric) {
+	keyIndex := -1
+	var labelNames []string
+	for i, key := range m.Descriptor.LabelKeys {
+		if key.Key == c.key {
+			keyIndex = i
+			continue
+		}
+		labelNames = append(labelNames, sanitize(key.Key))
+	}
+	if keyIndex == -1 {
+		return
+	}
+
+	desc := prometheus.NewDesc(
+		Namespace+"_"+sanitize(m.Descriptor.Name),
+		m.Descriptor.Description,
+		labelNames,
+		nil,
+	)
+
+	for _, ts := range m.TimeSeries {
+		if len(ts.Points) == 0 || !ts.LabelValues[keyIndex].Present || ts.LabelValues[keyIndex].Value != c.id {
+			continue
+		}
+
+		labelValues := make([]string, 0, len(labelNames))
+		for i, lv := range ts.LabelValues {
+			if i != keyIndex {
+				labelValues = append(labelValues, lv.Value)
+			}
+		}
+
+		metric, err := convertPoint(desc, m.Descriptor.Type, ts.Points[len(ts.Points)-1], labelValues)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+			continue
+		} else if metric != nil {
+			ch <- metric
+		}
+	}
+}
+
+// convertPoint converts an OpenCensus point into a Prometheus metric. Summaries
+// aren't recorded by upstream components and are ignored.
+func convertPoint(desc *prometheus.Desc, ty metricdata.Type, p metricdata.Point, labelValues []string) (prometheus.Metric, error) {
+	switch ty {
+	case metricdata.TypeGaugeInt64:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(p.Value.(int64)), labelValues...)
+	case metricdata.TypeGaugeFloat64:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, p.Value.(float64), labelValues...)
+	case metricdata.TypeCumulativeInt64:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, float64(p.Value.(int64)), labelValues...)
+	case metricdata.TypeCumulativeFloat64:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, p.Value.(float64), labelValues...)
+	case metricdata.TypeGaugeDistribution, metricdata.TypeCumulativeDistribution:
+		dist := p.Value.(*metricdata.Distribution)
+		if dist.BucketOptions == nil || len(dist.Buckets) != len(dist.BucketOptions.Bounds)+1 {
+			return nil, fmt.Errorf("distribution has %d buckets for %d bounds", len(dist.Buckets), len(dist.BucketOptions.Bounds))
+		}
+
+		buckets := make(map[float64]uint64, len(dist.BucketOptions.Bounds))
+		var cumulative uint64
+		for i, bound := range dist.BucketOptions.Bounds {
+			cumulative += uint64(dist.Buckets[i].Count)
+			buckets[bound] = cumulative
+		}
+		return prometheus.NewConstHistogram(desc, uint64(dist.Count), dist.Sum, buckets, labelValues...)
+	default:
+		return nil, nil
+	}
+}
+
+// sanitize converts an OpenCensus name into a valid Prometheus name.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
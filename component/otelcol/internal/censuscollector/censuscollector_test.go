@@ -0,0 +1,53 @@
+package censuscollector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func TestCollector(t *testing.T) {
+	var (
+		exporterKey  = tag.MustNewKey("exporter")
+		transportKey = tag.MustNewKey("transport")
+		sent         = stats.Int64("exporter/test_sent_spans", "Number of spans sent.", stats.UnitDimensionless)
+	)
+
+	v := &view.View{
+		Name:        sent.Name(),
+		Description: sent.Description(),
+		Measure:     sent,
+		TagKeys:     []tag.Key{exporterKey, transportKey},
+		Aggregation: view.Sum(),
+	}
+	require.NoError(t, view.Register(v))
+	defer view.Unregister(v)
+
+	record := func(exporter string, n int64) {
+		ctx, err := tag.New(context.Background(), tag.Upsert(exporterKey, exporter), tag.Upsert(transportKey, "grpc"))
+		require.NoError(t, err)
+		stats.Record(ctx, sent.M(n))
+	}
+	record("otlp/otelcol.exporter.otlp.a", 5)
+	record("otlp/otelcol.exporter.otlp.b", 7)
+
+	reg := prometheus.NewRegistry()
+	c, err := New("exporter", otelconfig.NewComponentIDWithName("otlp", "otelcol.exporter.otlp.a"))
+	require.NoError(t, err)
+	reg.MustRegister(c)
+
+	expect := `
+# HELP otelcol_exporter_test_sent_spans Number of spans sent.
+# TYPE otelcol_exporter_test_sent_spans counter
+otelcol_exporter_test_sent_spans{transport="grpc"} 5
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect), "otelcol_exporter_test_sent_spans"))
+}
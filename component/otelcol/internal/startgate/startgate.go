@@ -0,0 +1,107 @@
+// Package startgate holds back data sent to OpenTelemetry Collector processors
+// until they started.
+//
+// Flow forwards data to processors as soon as they're created, while some
+// upstream processors initialize state in Start which they expect to be set
+// before data arrives.
+package startgate
+
+import (
+	"context"
+
+	otelcomponent "go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Traces wraps p so that data sent to it waits until p started.
+func Traces(p otelcomponent.TracesProcessor) otelcomponent.TracesProcessor {
+	return &tracesProcessor{TracesProcessor: p, gate: newGate()}
+}
+
+// Metrics wraps p so that data sent to it waits until p started.
+func Metrics(p otelcomponent.MetricsProcessor) otelcomponent.MetricsProcessor {
+	return &metricsProcessor{MetricsProcessor: p, gate: newGate()}
+}
+
+// Logs wraps p so that data sent to it waits until p started.
+func Logs(p otelcomponent.LogsProcessor) otelcomponent.LogsProcessor {
+	return &logsProcessor{LogsProcessor: p, gate: newGate()}
+}
+
+// gate is opened once a processor started.
+type gate struct {
+	started chan struct{}
+	err     error
+}
+
+func newGate() *gate {
+	return &gate{started: make(chan struct{})}
+}
+
+// start runs the start function of the processor and opens the gate.
+func (g *gate) start(startFunc func() error) error {
+	g.err = startFunc()
+	close(g.started)
+	return g.err
+}
+
+// wait blocks until the processor started, returning an error if it failed to
+// start or if ctx is canceled first.
+func (g *gate) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-g.started:
+		return g.err
+	}
+}
+
+type tracesProcessor struct {
+	otelcomponent.TracesProcessor
+	gate *gate
+}
+
+func (p *tracesProcessor) Start(ctx context.Context, host otelcomponent.Host) error {
+	return p.gate.start(func() error { return p.TracesProcessor.Start(ctx, host) })
+}
+
+func (p *tracesProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	if err := p.gate.wait(ctx); err != nil {
+		return err
+	}
+	return p.TracesProcessor.ConsumeTraces(ctx, td)
+}
+
+type metricsProcessor struct {
+	otelcomponent.MetricsProcessor
+	gate *gate
+}
+
+func (p *metricsProcessor) Start(ctx context.Context, host otelcomponent.Host) error {
+	return p.gate.start(func() error { return p.MetricsProcessor.Start(ctx, host) })
+}
+
+func (p *metricsProcessor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	if err := p.gate.wait(ctx); err != nil {
+		return err
+	}
+	return p.MetricsProcessor.ConsumeMetrics(ctx, md)
+}
+
+type logsProcessor struct {
+	otelcomponent.LogsProcessor
+	gate *gate
+}
+
+func (p *logsProcessor) Start(ctx context.Context, host otelcomponent.Host) error {
+	return p.gate.start(func() error { return p.LogsProcessor.Start(ctx, host) })
+}
+
+func (p *logsProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	if err := p.gate.wait(ctx); err != nil {
+		return err
+	}
+	return p.LogsProcessor.ConsumeLogs(ctx, ld)
+}
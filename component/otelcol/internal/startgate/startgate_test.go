@@ -0,0 +1,69 @@
+package startgate_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol/internal/startgate"
+	"github.com/stretchr/testify/require"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/atomic"
+)
+
+func TestTraces(t *testing.T) {
+	inner := &fakeTracesProcessor{}
+	p := startgate.Traces(inner)
+
+	consumed := make(chan error, 1)
+	go func() { consumed <- p.ConsumeTraces(context.Background(), ptrace.NewTraces()) }()
+
+	// Data is held back until the processor started.
+	select {
+	case <-consumed:
+		require.FailNow(t, "traces consumed before the processor started")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, p.Start(context.Background(), nil))
+	require.NoError(t, <-consumed)
+	require.True(t, inner.consumed.Load())
+}
+
+func TestTraces_StartError(t *testing.T) {
+	inner := &fakeTracesProcessor{startErr: fmt.Errorf("start failed")}
+	p := startgate.Traces(inner)
+
+	require.EqualError(t, p.Start(context.Background(), nil), "start failed")
+	require.EqualError(t, p.ConsumeTraces(context.Background(), ptrace.NewTraces()), "start failed")
+	require.False(t, inner.consumed.Load())
+}
+
+func TestTraces_Canceled(t *testing.T) {
+	p := startgate.Traces(&fakeTracesProcessor{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, p.ConsumeTraces(ctx, ptrace.NewTraces()), context.Canceled)
+}
+
+type fakeTracesProcessor struct {
+	startErr error
+	consumed atomic.Bool
+}
+
+func (p *fakeTracesProcessor) Start(context.Context, otelcomponent.Host) error { return p.startErr }
+
+func (p *fakeTracesProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *fakeTracesProcessor) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{}
+}
+
+func (p *fakeTracesProcessor) ConsumeTraces(context.Context, ptrace.Traces) error {
+	p.consumed.Store(true)
+	return nil
+}
@@ -24,6 +24,8 @@ func init() {
 			fact := batchprocessor.NewFactory()
 			return processor.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
 	})
 }
 
@@ -0,0 +1,150 @@
+// Package cumulativetodelta provides an otelcol.processor.cumulativetodelta
+// component.
+package cumulativetodelta
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/mitchellh/mapstructure"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/cumulativetodeltaprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.cumulativetodelta",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := cumulativetodeltaprocessor.NewFactory()
+			return processor.New(opts, fact, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/processor/cumulativetodeltaprocessor"},
+	})
+}
+
+// Arguments configures the otelcol.processor.cumulativetodelta component.
+type Arguments struct {
+	Include      *MatchArguments `river:"include,block,optional"`
+	Exclude      *MatchArguments `river:"exclude,block,optional"`
+	MaxStaleness time.Duration   `river:"max_staleness,attr,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+	_ river.Unmarshaler   = (*Arguments)(nil)
+)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = Arguments{}
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.MaxStaleness < 0 {
+		return fmt.Errorf("max_staleness must not be negative")
+	}
+	return nil
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	var cfg cumulativetodeltaprocessor.Config
+
+	// The match type of the filters is of a type internal to the collector,
+	// so the filters can only be set by decoding them.
+	err := mapstructure.Decode(map[string]interface{}{
+		"include": args.Include.convert(),
+		"exclude": args.Exclude.convert(),
+	}, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ProcessorSettings = otelconfig.NewProcessorSettings(otelconfig.NewComponentID("cumulativetodelta"))
+	cfg.MaxStaleness = args.MaxStaleness
+	return &cfg, nil
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// Supported values of the match_type argument.
+const (
+	MatchTypeStrict = "strict"
+	MatchTypeRegexp = "regexp"
+)
+
+// MatchArguments selects metrics by name.
+type MatchArguments struct {
+	Metrics   []string `river:"metrics,attr"`
+	MatchType string   `river:"match_type,attr,optional"`
+}
+
+// DefaultMatchArguments holds default settings for MatchArguments.
+var DefaultMatchArguments = MatchArguments{
+	MatchType: MatchTypeStrict,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *MatchArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultMatchArguments
+
+	type arguments MatchArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if len(args.Metrics) == 0 {
+		return fmt.Errorf("metrics must not be empty")
+	}
+	switch args.MatchType {
+	case MatchTypeStrict:
+	case MatchTypeRegexp:
+		for _, expr := range args.Metrics {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("invalid regular expression %q: %w", expr, err)
+			}
+		}
+	default:
+		return fmt.Errorf("match_type must be %q or %q", MatchTypeStrict, MatchTypeRegexp)
+	}
+	return nil
+}
+
+func (args *MatchArguments) convert() map[string]interface{} {
+	if args == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"metrics":    args.Metrics,
+		"match_type": args.MatchType,
+	}
+}
@@ -0,0 +1,166 @@
+package cumulativetodelta_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/cumulativetodelta"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/cumulativetodeltaprocessor"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.processor.cumulativetodelta component and ensures that it converts
+// cumulative sums to deltas.
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.processor.cumulativetodelta")
+	require.NoError(t, err)
+
+	cfg := `
+		include {
+			metrics = ["requests_total"]
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args cumulativetodelta.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our arguments so metrics get forwarded to metricCh.
+	metricCh := make(chan pmetric.Metrics, 2)
+	args.Output = makeMetricsOutput(metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.NoError(t, exports.Input.ConsumeMetrics(ctx, createTestMetrics(10, 5)))
+	require.NoError(t, exports.Input.ConsumeMetrics(ctx, createTestMetrics(20, 8)))
+
+	// The first point of a stream is forwarded as is, as the counter is
+	// assumed to start at zero.
+	for _, expect := range []float64{5, 3} {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for metrics")
+		case md := <-metricCh:
+			sum := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+			require.Equal(t, pmetric.AggregationTemporalityDelta, sum.AggregationTemporality())
+			require.Equal(t, expect, sum.DataPoints().At(0).DoubleValue())
+		}
+	}
+}
+
+func TestArguments(t *testing.T) {
+	var args cumulativetodelta.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		exclude {
+			metrics    = ["^process_.*"]
+			match_type = "regexp"
+		}
+		max_staleness = "1h"
+		output {}
+	`), &args))
+
+	otelCfg, err := args.Convert()
+	require.NoError(t, err)
+
+	actual := otelCfg.(*cumulativetodeltaprocessor.Config)
+	require.NoError(t, actual.Validate())
+	require.Empty(t, actual.Include.Metrics)
+	require.Equal(t, []string{"^process_.*"}, actual.Exclude.Metrics)
+	require.Equal(t, "regexp", string(actual.Exclude.MatchType))
+	require.Equal(t, time.Hour, actual.MaxStaleness)
+
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "unknown match type",
+			cfg: `
+				include {
+					metrics    = ["a"]
+					match_type = "glob"
+				}`,
+			expectedErr: `match_type must be "strict" or "regexp"`,
+		},
+		{
+			name: "invalid regular expression",
+			cfg: `
+				include {
+					metrics    = ["a("]
+					match_type = "regexp"
+				}`,
+			expectedErr: `invalid regular expression "a("`,
+		},
+		{
+			name:        "no metrics",
+			cfg:         `exclude { metrics = [] }`,
+			expectedErr: "metrics must not be empty",
+		},
+		{
+			name:        "negative max_staleness",
+			cfg:         `max_staleness = "-1s"`,
+			expectedErr: "max_staleness must not be negative",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args cumulativetodelta.Arguments
+			err := river.Unmarshal([]byte(tc.cfg+"\noutput {}\n"), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// makeMetricsOutput returns ConsumerArguments which will forward metrics to
+// the provided channel.
+func makeMetricsOutput(ch chan pmetric.Metrics) *otelcol.ConsumerArguments {
+	metricConsumer := fakeconsumer.Consumer{
+		ConsumeMetricsFunc: func(ctx context.Context, m pmetric.Metrics) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- m:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&metricConsumer},
+	}
+}
+
+func createTestMetrics(ts pcommon.Timestamp, value float64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("requests_total")
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetTimestamp(ts)
+	dp.SetDoubleValue(value)
+	return md
+}
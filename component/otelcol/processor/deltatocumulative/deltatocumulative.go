@@ -0,0 +1,93 @@
+// Package deltatocumulative provides an otelcol.processor.deltatocumulative
+// component.
+package deltatocumulative
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/component/otelcol/processor/deltatocumulative/internal/deltatocumulativeprocessor"
+	"github.com/grafana/agent/pkg/river"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.deltatocumulative",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := deltatocumulativeprocessor.NewFactory()
+			return processor.New(opts, fact, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
+	})
+}
+
+// Arguments configures the otelcol.processor.deltatocumulative component.
+type Arguments struct {
+	MaxStale   time.Duration `river:"max_stale,attr,optional"`
+	MaxStreams int           `river:"max_streams,attr,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+	_ river.Unmarshaler   = (*Arguments)(nil)
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	MaxStale: 5 * time.Minute,
+}
+
+// UnmarshalRiver implements river.Unmarshaler. It applies defaults to args and
+// validates settings provided by the user.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.MaxStale <= 0 {
+		return fmt.Errorf("max_stale must be greater than 0")
+	}
+	if args.MaxStreams < 0 {
+		return fmt.Errorf("max_streams must not be negative")
+	}
+	return nil
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	return &deltatocumulativeprocessor.Config{
+		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID(deltatocumulativeprocessor.TypeStr)),
+		MaxStale:          args.MaxStale,
+		MaxStreams:        args.MaxStreams,
+	}, nil
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
@@ -0,0 +1,115 @@
+package deltatocumulative_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/deltatocumulative"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.processor.deltatocumulative component and ensures that it converts
+// delta sums to cumulative sums.
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.processor.deltatocumulative")
+	require.NoError(t, err)
+
+	cfg := `
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args deltatocumulative.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our arguments so metrics get forwarded to metricCh.
+	metricCh := make(chan pmetric.Metrics, 2)
+	args.Output = makeMetricsOutput(metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.NoError(t, exports.Input.ConsumeMetrics(ctx, createTestMetrics(10, 5)))
+	require.NoError(t, exports.Input.ConsumeMetrics(ctx, createTestMetrics(20, 3)))
+
+	for _, expect := range []float64{5, 8} {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for metrics")
+		case md := <-metricCh:
+			sum := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+			require.Equal(t, pmetric.AggregationTemporalityCumulative, sum.AggregationTemporality())
+			require.Equal(t, expect, sum.DataPoints().At(0).DoubleValue())
+			require.Equal(t, pcommon.Timestamp(10), sum.DataPoints().At(0).StartTimestamp())
+		}
+	}
+}
+
+func TestArguments(t *testing.T) {
+	var args deltatocumulative.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`output {}`), &args))
+	require.Equal(t, 5*time.Minute, args.MaxStale)
+	require.Zero(t, args.MaxStreams)
+
+	err := river.Unmarshal([]byte(`
+		max_stale = "0s"
+		output {}
+	`), &args)
+	require.EqualError(t, err, "max_stale must be greater than 0")
+
+	err = river.Unmarshal([]byte(`
+		max_streams = -1
+		output {}
+	`), &args)
+	require.EqualError(t, err, "max_streams must not be negative")
+}
+
+// makeMetricsOutput returns ConsumerArguments which will forward metrics to
+// the provided channel.
+func makeMetricsOutput(ch chan pmetric.Metrics) *otelcol.ConsumerArguments {
+	metricConsumer := fakeconsumer.Consumer{
+		ConsumeMetricsFunc: func(ctx context.Context, m pmetric.Metrics) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- m:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&metricConsumer},
+	}
+}
+
+func createTestMetrics(ts pcommon.Timestamp, value float64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("requests_total")
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetTimestamp(ts)
+	dp.SetDoubleValue(value)
+	return md
+}
@@ -0,0 +1,55 @@
+// Package deltatocumulativeprocessor implements an OpenTelemetry Collector
+// processor which converts delta sums and histograms to cumulative
+// temporality.
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+// TypeStr is the unique identifier for the delta to cumulative processor.
+const TypeStr = "deltatocumulative"
+
+// Config holds the configuration for the delta to cumulative processor.
+type Config struct {
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	// MaxStale is how long a stream is tracked after its last data point.
+	MaxStale time.Duration `mapstructure:"max_stale"`
+	// MaxStreams limits the number of tracked streams. If 0, the number of
+	// streams is unlimited.
+	MaxStreams int `mapstructure:"max_streams"`
+}
+
+// NewFactory returns a new factory for the delta to cumulative processor.
+func NewFactory() component.ProcessorFactory {
+	return component.NewProcessorFactory(
+		TypeStr,
+		createDefaultConfig,
+		component.WithMetricsProcessor(createMetricsProcessor, component.StabilityLevelUndefined),
+	)
+}
+
+func createDefaultConfig() config.Processor {
+	return &Config{
+		ProcessorSettings: config.NewProcessorSettings(config.NewComponentID(TypeStr)),
+		MaxStale:          5 * time.Minute,
+	}
+}
+
+func createMetricsProcessor(
+	ctx context.Context,
+	params component.ProcessorCreateSettings,
+	cfg config.Processor,
+	nextConsumer consumer.Metrics,
+) (component.MetricsProcessor, error) {
+
+	p := newProcessor(params.Logger, cfg.(*Config))
+	return processorhelper.NewMetricsProcessor(ctx, params, cfg, nextConsumer, p.processMetrics)
+}
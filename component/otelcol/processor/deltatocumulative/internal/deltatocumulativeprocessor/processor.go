@@ -0,0 +1,234 @@
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol/processor/deltatocumulative/internal/streams"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// processor converts delta sums and histograms to cumulative temporality by
+// adding up the data points of each stream.
+//
+// Data points which aren't newer than the previous data point of their stream
+// are dropped, as are the data points of new streams while MaxStreams streams
+// are tracked.
+type processor struct {
+	logger     *zap.Logger
+	maxStale   time.Duration
+	maxStreams int
+	now        func() time.Time
+
+	mut       sync.Mutex
+	states    map[string]*streamState
+	lastSweep time.Time
+	limited   bool // Whether data points were dropped since the limit was reached.
+}
+
+// streamState is the running total of a stream.
+type streamState struct {
+	start, last pcommon.Timestamp
+	updated     time.Time
+
+	// Total of sums.
+	valueType pmetric.NumberDataPointValueType
+	intValue  int64
+	value     float64
+
+	// Total of histograms.
+	count    uint64
+	sum      float64
+	min, max float64
+	bounds   []float64
+	buckets  []uint64
+}
+
+func newProcessor(logger *zap.Logger, cfg *Config) *processor {
+	return &processor{
+		logger:     logger,
+		maxStale:   cfg.MaxStale,
+		maxStreams: cfg.MaxStreams,
+		now:        time.Now,
+
+		states: make(map[string]*streamState),
+	}
+}
+
+func (p *processor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	now := p.now()
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.sweep(now)
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		res := rms.At(i).Resource()
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			scope := sms.At(j).Scope()
+			sms.At(j).Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					sum := m.Sum()
+					if sum.AggregationTemporality() != pmetric.AggregationTemporalityDelta {
+						return false
+					}
+					sum.DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+						key := streams.Key(res, scope, m, dp.Attributes())
+						return !p.accumulateNumber(key, dp, now)
+					})
+					sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+					return sum.DataPoints().Len() == 0
+
+				case pmetric.MetricTypeHistogram:
+					hist := m.Histogram()
+					if hist.AggregationTemporality() != pmetric.AggregationTemporalityDelta {
+						return false
+					}
+					hist.DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+						key := streams.Key(res, scope, m, dp.Attributes())
+						return !p.accumulateHistogram(key, dp, now)
+					})
+					hist.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+					return hist.DataPoints().Len() == 0
+				}
+				return false
+			})
+		}
+	}
+	return md, nil
+}
+
+// state returns the state of the stream identified by key, and whether the
+// stream is new. It returns nil if the stream is new and the maximum number
+// of streams is reached.
+func (p *processor) state(key string, now time.Time) (st *streamState, created bool) {
+	if st, ok := p.states[key]; ok {
+		st.updated = now
+		return st, false
+	}
+
+	if p.maxStreams > 0 && len(p.states) >= p.maxStreams {
+		if !p.limited {
+			p.logger.Warn("dropping data points of new streams, maximum number of streams reached", zap.Int("max_streams", p.maxStreams))
+			p.limited = true
+		}
+		return nil, false
+	}
+
+	st = &streamState{updated: now}
+	p.states[key] = st
+	return st, true
+}
+
+// accumulateNumber adds dp to the total of its stream and sets dp to the
+// total, returning false if dp must be dropped.
+func (p *processor) accumulateNumber(key string, dp pmetric.NumberDataPoint, now time.Time) bool {
+	st, created := p.state(key, now)
+	switch {
+	case st == nil:
+		return false
+	case created || st.valueType != dp.ValueType():
+		st.start = startOf(dp.StartTimestamp(), dp.Timestamp())
+		st.valueType, st.intValue, st.value = dp.ValueType(), 0, 0
+	case dp.Timestamp() <= st.last:
+		return false
+	}
+	st.last = dp.Timestamp()
+
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		st.intValue += dp.IntValue()
+		dp.SetIntValue(st.intValue)
+	case pmetric.NumberDataPointValueTypeDouble:
+		st.value += dp.DoubleValue()
+		dp.SetDoubleValue(st.value)
+	}
+	dp.SetStartTimestamp(st.start)
+	return true
+}
+
+// accumulateHistogram adds dp to the total of its stream and sets dp to the
+// total, returning false if dp must be dropped.
+func (p *processor) accumulateHistogram(key string, dp pmetric.HistogramDataPoint, now time.Time) bool {
+	var (
+		bounds  = dp.ExplicitBounds().AsRaw()
+		buckets = dp.BucketCounts().AsRaw()
+	)
+
+	st, created := p.state(key, now)
+	switch {
+	case st == nil:
+		return false
+	case created || !slices.Equal(bounds, st.bounds) || len(buckets) != len(st.buckets):
+		// Totals can't be carried over to different buckets, so the stream
+		// is restarted.
+		st.start = startOf(dp.StartTimestamp(), dp.Timestamp())
+		st.count, st.sum = 0, 0
+		st.min, st.max = math.Inf(1), math.Inf(-1)
+		st.bounds, st.buckets = bounds, make([]uint64, len(buckets))
+	case dp.Timestamp() <= st.last:
+		return false
+	}
+	st.last = dp.Timestamp()
+
+	st.count += dp.Count()
+	st.sum += dp.Sum()
+	for i, n := range buckets {
+		st.buckets[i] += n
+	}
+	if dp.HasMin() {
+		st.min = math.Min(st.min, dp.Min())
+	}
+	if dp.HasMax() {
+		st.max = math.Max(st.max, dp.Max())
+	}
+
+	dp.SetStartTimestamp(st.start)
+	dp.SetCount(st.count)
+	if dp.HasSum() {
+		dp.SetSum(st.sum)
+	}
+	dp.BucketCounts().FromRaw(slices.Clone(st.buckets))
+	if dp.HasMin() {
+		dp.SetMin(st.min)
+	}
+	if dp.HasMax() {
+		dp.SetMax(st.max)
+	}
+	return true
+}
+
+// startOf returns the start timestamp of a new stream whose first data point
+// has the given start timestamp and timestamp.
+func startOf(start, ts pcommon.Timestamp) pcommon.Timestamp {
+	if start != 0 {
+		return start
+	}
+	return ts
+}
+
+// sweep stops tracking streams which haven't received data points for longer
+// than maxStale.
+func (p *processor) sweep(now time.Time) {
+	if p.maxStale <= 0 || now.Sub(p.lastSweep) < p.maxStale {
+		return
+	}
+	p.lastSweep = now
+
+	for key, st := range p.states {
+		if now.Sub(st.updated) > p.maxStale {
+			delete(p.states, key)
+		}
+	}
+	if p.maxStreams <= 0 || len(p.states) < p.maxStreams {
+		p.limited = false
+	}
+}
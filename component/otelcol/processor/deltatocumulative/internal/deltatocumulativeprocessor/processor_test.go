@@ -0,0 +1,164 @@
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+type numberPoint struct {
+	start, ts pcommon.Timestamp
+	value     int64
+}
+
+// sumMetrics returns metrics holding a delta sum with a single data point for
+// each of the given values of the "stream" attribute.
+func sumMetrics(p numberPoint, streams ...string) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("requests")
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	for _, stream := range streams {
+		dp := sum.DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("stream", stream)
+		dp.SetStartTimestamp(p.start)
+		dp.SetTimestamp(p.ts)
+		dp.SetIntValue(p.value)
+	}
+	return md
+}
+
+// sumPoints returns the data points of the first metric of md, keyed by the
+// value of their "stream" attribute.
+func sumPoints(t *testing.T, md pmetric.Metrics) map[string]numberPoint {
+	t.Helper()
+
+	ms := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	if ms.Len() == 0 {
+		return nil
+	}
+	sum := ms.At(0).Sum()
+	require.Equal(t, pmetric.AggregationTemporalityCumulative, sum.AggregationTemporality())
+
+	res := make(map[string]numberPoint)
+	for i := 0; i < sum.DataPoints().Len(); i++ {
+		dp := sum.DataPoints().At(i)
+		stream, _ := dp.Attributes().Get("stream")
+		res[stream.Str()] = numberPoint{start: dp.StartTimestamp(), ts: dp.Timestamp(), value: dp.IntValue()}
+	}
+	return res
+}
+
+func TestProcessor_Sum(t *testing.T) {
+	p := newProcessor(zap.NewNop(), &Config{MaxStale: time.Minute})
+
+	tests := []struct {
+		name   string
+		in     numberPoint
+		expect map[string]numberPoint
+	}{
+		{
+			name:   "first point starts the stream",
+			in:     numberPoint{start: 5, ts: 10, value: 1},
+			expect: map[string]numberPoint{"a": {start: 5, ts: 10, value: 1}},
+		},
+		{
+			name:   "points are added up",
+			in:     numberPoint{start: 10, ts: 20, value: 2},
+			expect: map[string]numberPoint{"a": {start: 5, ts: 20, value: 3}},
+		},
+		{
+			name: "point which isn't newer is dropped",
+			in:   numberPoint{start: 10, ts: 20, value: 2},
+		},
+		{
+			name:   "gaps between points are accepted",
+			in:     numberPoint{start: 25, ts: 30, value: 3},
+			expect: map[string]numberPoint{"a": {start: 5, ts: 30, value: 6}},
+		},
+	}
+	for _, tc := range tests {
+		md, err := p.processMetrics(context.Background(), sumMetrics(tc.in, "a"))
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, sumPoints(t, md), tc.name)
+	}
+}
+
+func TestProcessor_Histogram(t *testing.T) {
+	p := newProcessor(zap.NewNop(), &Config{MaxStale: time.Minute})
+
+	histogram := func(ts pcommon.Timestamp, bounds []float64, buckets []uint64, min, max float64) pmetric.Metrics {
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("latency")
+		hist := m.SetEmptyHistogram()
+		hist.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dp := hist.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(ts - 10)
+		dp.SetTimestamp(ts)
+		var count uint64
+		for _, n := range buckets {
+			count += n
+		}
+		dp.SetCount(count)
+		dp.SetSum(float64(count))
+		dp.SetMin(min)
+		dp.SetMax(max)
+		dp.ExplicitBounds().FromRaw(bounds)
+		dp.BucketCounts().FromRaw(buckets)
+		return md
+	}
+	point := func(md pmetric.Metrics) pmetric.HistogramDataPoint {
+		hist := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram()
+		require.Equal(t, pmetric.AggregationTemporalityCumulative, hist.AggregationTemporality())
+		require.Equal(t, 1, hist.DataPoints().Len())
+		return hist.DataPoints().At(0)
+	}
+
+	_, err := p.processMetrics(context.Background(), histogram(10, []float64{1}, []uint64{1, 2}, 0.5, 3))
+	require.NoError(t, err)
+	md, err := p.processMetrics(context.Background(), histogram(20, []float64{1}, []uint64{3, 0}, 0.1, 0.9))
+	require.NoError(t, err)
+
+	dp := point(md)
+	require.Equal(t, pcommon.Timestamp(10), dp.StartTimestamp())
+	require.Equal(t, uint64(6), dp.Count())
+	require.Equal(t, 6.0, dp.Sum())
+	require.Equal(t, []uint64{4, 2}, dp.BucketCounts().AsRaw())
+	require.Equal(t, 0.1, dp.Min())
+	require.Equal(t, 3.0, dp.Max())
+
+	// Changing the buckets restarts the stream.
+	md, err = p.processMetrics(context.Background(), histogram(30, []float64{1, 2}, []uint64{1, 1, 1}, 0.5, 3))
+	require.NoError(t, err)
+
+	dp = point(md)
+	require.Equal(t, pcommon.Timestamp(20), dp.StartTimestamp())
+	require.Equal(t, uint64(3), dp.Count())
+	require.Equal(t, []uint64{1, 1, 1}, dp.BucketCounts().AsRaw())
+}
+
+func TestProcessor_MaxStreams(t *testing.T) {
+	p := newProcessor(zap.NewNop(), &Config{MaxStale: time.Minute, MaxStreams: 1})
+
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	md, err := p.processMetrics(context.Background(), sumMetrics(numberPoint{ts: 10, value: 1}, "a", "b"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]numberPoint{"a": {start: 10, ts: 10, value: 1}}, sumPoints(t, md))
+
+	// Once the tracked stream is stale, new streams are accepted, and stale
+	// streams start over.
+	now = now.Add(2 * time.Minute)
+	md, err = p.processMetrics(context.Background(), sumMetrics(numberPoint{ts: 20, value: 1}, "b", "a"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]numberPoint{"b": {start: 20, ts: 20, value: 1}}, sumPoints(t, md))
+}
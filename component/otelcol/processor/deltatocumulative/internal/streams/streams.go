@@ -0,0 +1,55 @@
+// Package streams identifies the streams of metric data points, which the
+// deltatocumulative processor uses as keys for the state of each stream.
+package streams
+
+import (
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Key returns a key identifying the stream of a data point of metric m with
+// the attributes attrs. Data points have the same key if they belong to the
+// same resource, instrumentation scope, and metric, and have the same
+// attributes.
+func Key(res pcommon.Resource, scope pcommon.InstrumentationScope, m pmetric.Metric, attrs pcommon.Map) string {
+	var key strings.Builder
+	writeMap(&key, res.Attributes())
+	key.WriteString(scope.Name())
+	key.WriteByte(0)
+	key.WriteString(scope.Version())
+	key.WriteByte(0)
+	key.WriteString(m.Name())
+	key.WriteByte(0)
+	key.WriteString(m.Unit())
+	key.WriteByte(0)
+	key.WriteString(m.Type().String())
+	key.WriteByte(0)
+	writeMap(&key, attrs)
+	return key.String()
+}
+
+// writeMap writes the attributes of m to key, sorted by name.
+func writeMap(key *strings.Builder, m pcommon.Map) {
+	names := make([]string, 0, m.Len())
+	m.Range(func(k string, _ pcommon.Value) bool {
+		names = append(names, k)
+		return true
+	})
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, _ := m.Get(name)
+		key.WriteString(name)
+		key.WriteByte(0)
+		key.WriteString(v.Type().String())
+		key.WriteByte(0)
+		key.WriteString(v.AsString())
+		key.WriteByte(0)
+	}
+	// Separate the attributes from what follows them, so that attributes
+	// can't be mistaken for other fields of the key.
+	key.WriteByte(1)
+}
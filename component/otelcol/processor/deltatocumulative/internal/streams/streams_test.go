@@ -0,0 +1,47 @@
+package streams_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component/otelcol/processor/deltatocumulative/internal/streams"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestKey(t *testing.T) {
+	key := func(resAttrs, attrs map[string]interface{}) string {
+		res := pcommon.NewResource()
+		res.Attributes().FromRaw(resAttrs)
+		m := pmetric.NewMetric()
+		m.SetName("requests")
+		m.SetEmptySum()
+		dpAttrs := pcommon.NewMap()
+		dpAttrs.FromRaw(attrs)
+		return streams.Key(res, pcommon.NewInstrumentationScope(), m, dpAttrs)
+	}
+
+	// The order of attributes doesn't matter.
+	a := pcommon.NewMap()
+	a.PutStr("x", "1")
+	a.PutStr("y", "2")
+	b := pcommon.NewMap()
+	b.PutStr("y", "2")
+	b.PutStr("x", "1")
+	m := pmetric.NewMetric()
+	require.Equal(t,
+		streams.Key(pcommon.NewResource(), pcommon.NewInstrumentationScope(), m, a),
+		streams.Key(pcommon.NewResource(), pcommon.NewInstrumentationScope(), m, b),
+	)
+
+	// Attributes of the resource and the data point are told apart, as are
+	// values of different types.
+	require.NotEqual(t,
+		key(map[string]interface{}{"service": "api"}, nil),
+		key(nil, map[string]interface{}{"service": "api"}),
+	)
+	require.NotEqual(t,
+		key(nil, map[string]interface{}{"code": "200"}),
+		key(nil, map[string]interface{}{"code": 200}),
+	)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/processor"
 	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util/memwatch"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
 	"go.opentelemetry.io/collector/processor/memorylimiterprocessor"
@@ -25,12 +26,14 @@ func init() {
 			fact := memorylimiterprocessor.NewFactory()
 			return processor.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
 	})
 }
 
 // Arguments configures the otelcol.processor.memory_limiter component.
 type Arguments struct {
-	CheckInterval         time.Duration    `river:"check_interval,attr"`
+	CheckInterval         time.Duration    `river:"check_interval,attr,optional"`
 	MemoryLimit           units.Base2Bytes `river:"limit,attr,optional"`
 	MemorySpikeLimit      units.Base2Bytes `river:"spike_limit,attr,optional"`
 	MemoryLimitPercentage uint32           `river:"limit_percentage,attr,optional"`
@@ -47,7 +50,7 @@ var (
 
 // DefaultArguments holds default settings for Arguments.
 var DefaultArguments = Arguments{
-	CheckInterval:         0,
+	CheckInterval:         time.Second,
 	MemoryLimit:           0,
 	MemorySpikeLimit:      0,
 	MemoryLimitPercentage: 0,
@@ -82,12 +85,17 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 		return nil
 	}
 	if args.MemoryLimitPercentage > 0 {
-		if args.MemoryLimitPercentage <= 0 ||
-			args.MemoryLimitPercentage > 100 ||
-			args.MemorySpikePercentage <= 0 ||
-			args.MemorySpikePercentage > 100 {
-
-			return fmt.Errorf("limit_percentage and spike_limit_percentage must be greater than 0 and and less or equal than 100")
+		if args.MemoryLimitPercentage > 100 {
+			return fmt.Errorf("limit_percentage must be less than or equal to 100")
+		}
+		if args.MemorySpikePercentage >= args.MemoryLimitPercentage {
+			return fmt.Errorf("spike_limit_percentage must be less than limit_percentage")
+		}
+		if args.MemorySpikePercentage == 0 {
+			args.MemorySpikePercentage = args.MemoryLimitPercentage / 5
+			if args.MemorySpikePercentage == 0 {
+				args.MemorySpikePercentage = 1
+			}
 		}
 		return nil
 	}
@@ -95,8 +103,16 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	return fmt.Errorf("either limit or limit_percentage must be set to greater than zero")
 }
 
-// Convert implements processor.Arguments.
+// Convert implements processor.Arguments. If the agent has a memory ceiling,
+// limit_percentage and spike_limit_percentage are percentages of the ceiling
+// rather than of the total memory available.
 func (args Arguments) Convert() (otelconfig.Processor, error) {
+	if ceiling := memwatch.Default().Ceiling(); ceiling > 0 && args.MemoryLimitPercentage > 0 {
+		args.MemoryLimit = units.Base2Bytes(ceiling * uint64(args.MemoryLimitPercentage) / 100)
+		args.MemorySpikeLimit = units.Base2Bytes(ceiling * uint64(args.MemorySpikePercentage) / 100)
+		args.MemoryLimitPercentage, args.MemorySpikePercentage = 0, 0
+	}
+
 	return &memorylimiterprocessor.Config{
 		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("memory_limiter")),
 
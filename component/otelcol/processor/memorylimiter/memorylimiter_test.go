@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
@@ -12,9 +13,11 @@ import (
 	"github.com/grafana/agent/pkg/flow/componenttest"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/agent/pkg/util/memwatch"
 	"github.com/grafana/dskit/backoff"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/memorylimiterprocessor"
 )
 
 // Test performs a basic integration test which runs the
@@ -79,6 +82,43 @@ func Test(t *testing.T) {
 	}
 }
 
+func TestArguments_Percentages(t *testing.T) {
+	var args memorylimiter.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		limit_percentage = 50
+
+		output {}
+	`), &args))
+	require.Equal(t, time.Second, args.CheckInterval)
+	require.Equal(t, uint32(10), args.MemorySpikePercentage)
+
+	var invalid memorylimiter.Arguments
+	err := river.Unmarshal([]byte(`
+		limit_percentage       = 50
+		spike_limit_percentage = 50
+
+		output {}
+	`), &invalid)
+	require.ErrorContains(t, err, "spike_limit_percentage must be less than limit_percentage")
+
+	// Without a memory ceiling, percentages are passed to the processor as-is.
+	cfg, err := args.Convert()
+	require.NoError(t, err)
+	require.Equal(t, uint32(50), cfg.(*memorylimiterprocessor.Config).MemoryLimitPercentage)
+
+	// With a memory ceiling, percentages are of the ceiling.
+	memwatch.Default().SetCeiling(uint64(2*units.GiB), memwatch.DefaultThreshold)
+	t.Cleanup(func() { memwatch.Default().SetCeiling(0, memwatch.DefaultThreshold) })
+
+	cfg, err = args.Convert()
+	require.NoError(t, err)
+	converted := cfg.(*memorylimiterprocessor.Config)
+	require.Equal(t, uint32(1024), converted.MemoryLimitMiB)
+	require.Equal(t, uint32(204), converted.MemorySpikeLimitMiB)
+	require.Zero(t, converted.MemoryLimitPercentage)
+	require.Zero(t, converted.MemorySpikePercentage)
+}
+
 // makeTracesOutput returns ConsumerArguments which will forward traces to the
 // provided channel.
 func makeTracesOutput(ch chan ptrace.Traces) *otelcol.ConsumerArguments {
@@ -0,0 +1,89 @@
+// Package probabilistic_sampler provides an otelcol.processor.probabilistic_sampler component.
+package probabilistic_sampler
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/probabilisticsamplerprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.probabilistic_sampler",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := probabilisticsamplerprocessor.NewFactory()
+			return processor.New(opts, fact, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/processor/probabilisticsamplerprocessor"},
+	})
+}
+
+// Arguments configures the otelcol.processor.probabilistic_sampler component.
+type Arguments struct {
+	SamplingPercentage float32 `river:"sampling_percentage,attr,optional"`
+	HashSeed           uint32  `river:"hash_seed,attr,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+	_ river.Unmarshaler   = (*Arguments)(nil)
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	SamplingPercentage: 0,
+	HashSeed:           0,
+}
+
+// UnmarshalRiver implements river.Unmarshaler. It applies defaults to args and
+// validates settings provided by the user.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.SamplingPercentage < 0 || args.SamplingPercentage > 100 {
+		return fmt.Errorf("sampling_percentage must be between 0 and 100")
+	}
+	return nil
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	return &probabilisticsamplerprocessor.Config{
+		ProcessorSettings:  otelconfig.NewProcessorSettings(otelconfig.NewComponentID("probabilistic_sampler")),
+		SamplingPercentage: args.SamplingPercentage,
+		HashSeed:           args.HashSeed,
+	}, nil
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
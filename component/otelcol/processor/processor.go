@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/censuscollector"
 	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
 	"github.com/grafana/agent/component/otelcol/internal/lazycollector"
 	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
@@ -18,6 +19,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/external/obsreportconfig/obsmetrics"
 	sdkprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/sdk/metric"
 
@@ -150,6 +152,16 @@ func (p *Processor) Update(args component.Arguments) error {
 		return err
 	}
 
+	// Name the upstream component after our component ID so that its internal
+	// telemetry, which upstream records globally, can be told apart from
+	// other instances of the same component.
+	processorConfig.SetIDName(p.opts.ID)
+	census, err := censuscollector.New(obsmetrics.ProcessorKey, processorConfig.ID())
+	if err != nil {
+		return err
+	}
+	reg.MustRegister(census)
+
 	var (
 		next        = pargs.NextConsumers()
 		nextTraces  = fanoutconsumer.Traces(next.Traces)
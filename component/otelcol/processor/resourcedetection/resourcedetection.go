@@ -0,0 +1,268 @@
+// Package resourcedetection provides an otelcol.processor.resourcedetection
+// component.
+package resourcedetection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/startgate"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/mitchellh/mapstructure"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/resourcedetectionprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/config/confighttp"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.resourcedetection",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return processor.New(opts, newFactory(), args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/processor/resourcedetectionprocessor"},
+	})
+}
+
+// detectors holds the names of the detectors supported by the upstream
+// processor.
+var detectors = map[string]struct{}{
+	"aks":               {},
+	"azure":             {},
+	"consul":            {},
+	"docker":            {},
+	"ec2":               {},
+	"ecs":               {},
+	"eks":               {},
+	"elastic_beanstalk": {},
+	"env":               {},
+	"gcp":               {},
+	"system":            {},
+}
+
+// Arguments configures the otelcol.processor.resourcedetection component.
+type Arguments struct {
+	Detectors  []string      `river:"detectors,attr,optional"`
+	Override   bool          `river:"override,attr,optional"`
+	Timeout    time.Duration `river:"timeout,attr,optional"`
+	Attributes []string      `river:"attributes,attr,optional"`
+
+	EC2    EC2Arguments    `river:"ec2,block,optional"`
+	System SystemArguments `river:"system,block,optional"`
+	Consul ConsulArguments `river:"consul,block,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+	_ river.Unmarshaler   = (*Arguments)(nil)
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Detectors: []string{"env"},
+	Override:  true,
+	Timeout:   5 * time.Second,
+}
+
+// UnmarshalRiver implements river.Unmarshaler. It applies defaults to args and
+// validates settings provided by the user.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	for _, name := range args.Detectors {
+		if _, ok := detectors[name]; !ok {
+			return fmt.Errorf("unknown detector %q", name)
+		}
+	}
+	return nil
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	httpClientSettings := confighttp.NewDefaultHTTPClientSettings()
+	httpClientSettings.Timeout = args.Timeout
+
+	cfg := &resourcedetectionprocessor.Config{
+		ProcessorSettings:  otelconfig.NewProcessorSettings(otelconfig.NewComponentID("resourcedetection")),
+		Detectors:          args.Detectors,
+		Override:           args.Override,
+		HTTPClientSettings: httpClientSettings,
+		Attributes:         args.Attributes,
+	}
+
+	// The settings of the detectors are of types internal to the upstream
+	// processor, so they can only be set by decoding them.
+	err := mapstructure.Decode(map[string]interface{}{
+		"ec2":    args.EC2.Convert(),
+		"system": args.System.Convert(),
+		"consul": args.Consul.Convert(),
+	}, &cfg.DetectorConfig)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// EC2Arguments configures the ec2 detector.
+type EC2Arguments struct {
+	Tags []string `river:"tags,attr,optional"`
+}
+
+// Convert converts args into the upstream configuration of the detector.
+func (args EC2Arguments) Convert() map[string]interface{} {
+	return map[string]interface{}{
+		"tags": args.Tags,
+	}
+}
+
+// SystemArguments configures the system detector.
+type SystemArguments struct {
+	HostnameSources []string `river:"hostname_sources,attr,optional"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *SystemArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = SystemArguments{}
+
+	type arguments SystemArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	for _, source := range args.HostnameSources {
+		switch source {
+		case "dns", "os", "cname", "lookup":
+		default:
+			return fmt.Errorf("invalid hostname source %q", source)
+		}
+	}
+	return nil
+}
+
+// Convert converts args into the upstream configuration of the detector.
+func (args SystemArguments) Convert() map[string]interface{} {
+	return map[string]interface{}{
+		"hostname_sources": args.HostnameSources,
+	}
+}
+
+// ConsulArguments configures the consul detector.
+type ConsulArguments struct {
+	Address    string            `river:"address,attr,optional"`
+	Datacenter string            `river:"datacenter,attr,optional"`
+	Token      rivertypes.Secret `river:"token,attr,optional"`
+	Namespace  string            `river:"namespace,attr,optional"`
+	Meta       []string          `river:"meta,attr,optional"`
+}
+
+// Convert converts args into the upstream configuration of the detector.
+func (args ConsulArguments) Convert() map[string]interface{} {
+	// The upstream detector only uses the keys of the metadata allowlist.
+	meta := make(map[string]interface{}, len(args.Meta))
+	for _, key := range args.Meta {
+		meta[key] = true
+	}
+
+	return map[string]interface{}{
+		"address":    args.Address,
+		"datacenter": args.Datacenter,
+		"token":      string(args.Token),
+		"namespace":  args.Namespace,
+		"meta":       meta,
+	}
+}
+
+// factory wraps the upstream factory, which detects the resource of a
+// processor once and caches it by the ID of the processor. Flow keeps the ID
+// of a processor when its arguments change, so a new upstream factory is used
+// for every new configuration to detect the resource again.
+//
+// The upstream processors detect the resource when they start, so data sent
+// to them is held back until then.
+type factory struct {
+	otelcomponent.ProcessorFactory
+
+	mut sync.Mutex
+	cfg otelconfig.Processor
+}
+
+func newFactory() *factory {
+	return &factory{ProcessorFactory: resourcedetectionprocessor.NewFactory()}
+}
+
+// forConfig returns the upstream factory to create processors for cfg.
+func (f *factory) forConfig(cfg otelconfig.Processor) otelcomponent.ProcessorFactory {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.cfg != cfg {
+		f.cfg = cfg
+		f.ProcessorFactory = resourcedetectionprocessor.NewFactory()
+	}
+	return f.ProcessorFactory
+}
+
+// CreateTracesProcessor implements otelcomponent.ProcessorFactory.
+func (f *factory) CreateTracesProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Traces) (otelcomponent.TracesProcessor, error) {
+	p, err := f.forConfig(cfg).CreateTracesProcessor(ctx, set, cfg, next)
+	if err != nil {
+		return nil, err
+	}
+	return startgate.Traces(p), nil
+}
+
+// CreateMetricsProcessor implements otelcomponent.ProcessorFactory.
+func (f *factory) CreateMetricsProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Metrics) (otelcomponent.MetricsProcessor, error) {
+	p, err := f.forConfig(cfg).CreateMetricsProcessor(ctx, set, cfg, next)
+	if err != nil {
+		return nil, err
+	}
+	return startgate.Metrics(p), nil
+}
+
+// CreateLogsProcessor implements otelcomponent.ProcessorFactory.
+func (f *factory) CreateLogsProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Logs) (otelcomponent.LogsProcessor, error) {
+	p, err := f.forConfig(cfg).CreateLogsProcessor(ctx, set, cfg, next)
+	if err != nil {
+		return nil, err
+	}
+	return startgate.Logs(p), nil
+}
@@ -0,0 +1,267 @@
+package resourcedetection_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/resourcedetection"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/dskit/backoff"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/resourcedetectionprocessor"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.processor.resourcedetection component and ensures that it adds the
+// detected attributes to the resources of the data it forwards.
+func Test(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=detected,deployment.environment=prod")
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.processor.resourcedetection")
+	require.NoError(t, err)
+
+	cfg := `
+		detectors = ["env"]
+		override  = false
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args resourcedetection.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our arguments so traces get forwarded to traceCh.
+	traceCh := make(chan ptrace.Traces)
+	args.Output = makeTracesOutput(traceCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	// Send traces in the background to our processor.
+	go func() {
+		exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+		bo := backoff.New(ctx, backoff.Config{
+			MinBackoff: 10 * time.Millisecond,
+			MaxBackoff: 100 * time.Millisecond,
+		})
+		for bo.Ongoing() {
+			err := exports.Input.ConsumeTraces(ctx, createTestTraces())
+			if err != nil {
+				level.Error(l).Log("msg", "failed to send traces", "err", err)
+				bo.Wait()
+				continue
+			}
+
+			return
+		}
+	}()
+
+	// Wait for our processor to finish and forward data to traceCh.
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case tr := <-traceCh:
+		require.Equal(t, map[string]interface{}{
+			"service.name":           "test",
+			"deployment.environment": "prod",
+		}, tr.ResourceSpans().At(0).Resource().Attributes().AsRaw())
+	}
+}
+
+// TestUpdate ensures that the resource is detected again when the arguments
+// of the component change.
+func TestUpdate(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=staging")
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.processor.resourcedetection")
+	require.NoError(t, err)
+
+	var args resourcedetection.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`output {}`), &args))
+
+	traceCh := make(chan ptrace.Traces)
+	args.Output = makeTracesOutput(traceCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	for _, expect := range []string{"staging", "prod"} {
+		if expect == "prod" {
+			t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod")
+			require.NoError(t, ctrl.Update(args))
+		}
+
+		go func() {
+			require.NoError(t, exports.Input.ConsumeTraces(ctx, createTestTraces()))
+		}()
+
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for traces")
+		case tr := <-traceCh:
+			env, _ := tr.ResourceSpans().At(0).Resource().Attributes().Get("deployment.environment")
+			require.Equal(t, expect, env.Str())
+		}
+	}
+}
+
+func TestArguments(t *testing.T) {
+	cfg := `
+		detectors  = ["ec2", "consul", "system"]
+		timeout    = "2s"
+		override   = false
+		attributes = ["host.name", "cloud.region"]
+
+		ec2 {
+			tags = ["^team$"]
+		}
+
+		system {
+			hostname_sources = ["os"]
+		}
+
+		consul {
+			address = "localhost:8500"
+			token   = "secret"
+			meta    = ["rack"]
+		}
+
+		output {}
+	`
+	var args resourcedetection.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	otelCfg, err := args.Convert()
+	require.NoError(t, err)
+
+	actual := otelCfg.(*resourcedetectionprocessor.Config)
+	require.NoError(t, actual.Validate())
+	require.Equal(t, []string{"ec2", "consul", "system"}, actual.Detectors)
+	require.Equal(t, 2*time.Second, actual.Timeout)
+	require.False(t, actual.Override)
+	require.Equal(t, []string{"host.name", "cloud.region"}, actual.Attributes)
+	require.Equal(t, []string{"^team$"}, actual.DetectorConfig.EC2Config.Tags)
+	require.Equal(t, []string{"os"}, actual.DetectorConfig.SystemConfig.HostnameSources)
+	require.Equal(t, "localhost:8500", actual.DetectorConfig.ConsulConfig.Address)
+	require.Equal(t, "secret", actual.DetectorConfig.ConsulConfig.Token)
+	require.Equal(t, map[string]interface{}{"rack": true}, actual.DetectorConfig.ConsulConfig.MetaLabels)
+}
+
+func TestArguments_Defaults(t *testing.T) {
+	var args resourcedetection.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`output {}`), &args))
+
+	otelCfg, err := args.Convert()
+	require.NoError(t, err)
+
+	actual := otelCfg.(*resourcedetectionprocessor.Config)
+	require.Equal(t, []string{"env"}, actual.Detectors)
+	require.Equal(t, 5*time.Second, actual.Timeout)
+	require.True(t, actual.Override)
+}
+
+func TestArguments_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name:        "unknown detector",
+			cfg:         `detectors = ["system", "heroku"]`,
+			expectedErr: `unknown detector "heroku"`,
+		},
+		{
+			name:        "zero timeout",
+			cfg:         `timeout = "0s"`,
+			expectedErr: "timeout must be greater than 0",
+		},
+		{
+			name: "unknown hostname source",
+			cfg: `
+				system {
+					hostname_sources = ["dns", "etc_hosts"]
+				}`,
+			expectedErr: `invalid hostname source "etc_hosts"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args resourcedetection.Arguments
+			err := river.Unmarshal([]byte(tc.cfg+"\noutput {}\n"), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// makeTracesOutput returns ConsumerArguments which will forward traces to the
+// provided channel.
+func makeTracesOutput(ch chan ptrace.Traces) *otelcol.ConsumerArguments {
+	traceConsumer := fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(ctx context.Context, t ptrace.Traces) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- t:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&traceConsumer},
+	}
+}
+
+func createTestTraces() ptrace.Traces {
+	// Matches format from the protobuf definition:
+	// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+	var bb = `{
+		"resource_spans": [{
+			"resource": {
+				"attributes": [{
+					"key": "service.name",
+					"value": { "stringValue": "test" }
+				}]
+			},
+			"scope_spans": [{
+				"spans": [{
+					"name": "TestSpan"
+				}]
+			}]
+		}]
+	}`
+
+	decoder := &ptrace.JSONUnmarshaler{}
+	data, err := decoder.UnmarshalTraces([]byte(bb))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
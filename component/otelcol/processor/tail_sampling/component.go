@@ -0,0 +1,54 @@
+package tail_sampling
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/tracesampling"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	tsp "github.com/open-telemetry/opentelemetry-collector-contrib/processor/tailsamplingprocessor"
+)
+
+// Component implements the otelcol.processor.tail_sampling component. It
+// wraps the upstream processor and records the IDs of sampled traces as they
+// are forwarded to the configured outputs.
+type Component struct {
+	*processor.Processor
+
+	decisions *tracesampling.Cache
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new otelcol.processor.tail_sampling component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	decisions, err := tracesampling.NewCache(args.DecisionCache.MaxEntries, args.DecisionCache.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The wrapped processor exports otelcol.ConsumerExports; extend it with the
+	// sampling decisions before passing it on to the controller.
+	onStateChange := opts.OnStateChange
+	opts.OnStateChange = func(e component.Exports) {
+		onStateChange(Exports{
+			Input:     e.(otelcol.ConsumerExports).Input,
+			Decisions: decisions,
+		})
+	}
+
+	p, err := processor.New(opts, tsp.NewFactory(), args.withRecorder(decisions))
+	if err != nil {
+		return nil, err
+	}
+	return &Component{Processor: p, decisions: decisions}, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.decisions.SetLimits(newArgs.DecisionCache.MaxEntries, newArgs.DecisionCache.TTL)
+	return c.Processor.Update(newArgs.withRecorder(c.decisions))
+}
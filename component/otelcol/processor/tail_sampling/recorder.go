@@ -0,0 +1,64 @@
+package tail_sampling
+
+import (
+	"context"
+
+	"github.com/grafana/agent/component/common/tracesampling"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// recorder is an otelcol.Consumer which sits between the tail sampling
+// processor and its outputs. Every trace which reaches the recorder was
+// sampled, so its ID is recorded before the trace is forwarded.
+type recorder struct {
+	decisions *tracesampling.Cache
+	next      otelconsumer.Traces
+}
+
+var _ otelcol.Consumer = (*recorder)(nil)
+
+func newRecorder(decisions *tracesampling.Cache, next []otelcol.Consumer) *recorder {
+	return &recorder{
+		decisions: decisions,
+		next:      fanoutconsumer.Traces(next),
+	}
+}
+
+// Capabilities implements otelcol.Consumer.
+func (r *recorder) Capabilities() otelconsumer.Capabilities {
+	return r.next.Capabilities()
+}
+
+// ConsumeTraces implements otelcol.Consumer.
+func (r *recorder) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				if traceID := spans.At(k).TraceID(); !traceID.IsEmpty() {
+					r.decisions.Record(traceID.HexString())
+				}
+			}
+		}
+	}
+	return r.next.ConsumeTraces(ctx, td)
+}
+
+// ConsumeMetrics implements otelcol.Consumer. The tail sampling processor
+// never emits metrics.
+func (r *recorder) ConsumeMetrics(context.Context, pmetric.Metrics) error {
+	return nil
+}
+
+// ConsumeLogs implements otelcol.Consumer. The tail sampling processor never
+// emits logs.
+func (r *recorder) ConsumeLogs(context.Context, plog.Logs) error {
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/tracesampling"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/processor"
 	"github.com/grafana/agent/pkg/river"
@@ -18,21 +19,33 @@ func init() {
 	component.Register(component.Registration{
 		Name:    "otelcol.processor.tail_sampling",
 		Args:    Arguments{},
-		Exports: otelcol.ConsumerExports{},
+		Exports: Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := tsp.NewFactory()
-			return processor.New(opts, fact, args.(Arguments))
+			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/processor/tailsamplingprocessor"},
 	})
 }
 
+// Exports holds values exported by the otelcol.processor.tail_sampling
+// component.
+type Exports struct {
+	// Input accepts traces to be sampled.
+	Input otelcol.Consumer `river:"input,attr"`
+	// Decisions reports which traces were sampled, so that other pipelines can
+	// make consistent sampling decisions.
+	Decisions tracesampling.Decisions `river:"decisions,attr"`
+}
+
 // Arguments configures the otelcol.processor.tail_sampling component.
 type Arguments struct {
 	PolicyCfgs              []PolicyCfg   `river:"policy,block"`
 	DecisionWait            time.Duration `river:"decision_wait,attr,optional"`
 	NumTraces               uint64        `river:"num_traces,attr,optional"`
 	ExpectedNewTracesPerSec uint64        `river:"expected_new_traces_per_sec,attr,optional"`
+	DecisionCache           DecisionCache `river:"decision_cache,block,optional"`
 	// Output configures where to send processed data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
@@ -47,6 +60,17 @@ var DefaultArguments = Arguments{
 	DecisionWait:            30 * time.Second,
 	NumTraces:               50000,
 	ExpectedNewTracesPerSec: 0,
+	DecisionCache: DecisionCache{
+		MaxEntries: 100000,
+		TTL:        5 * time.Minute,
+	},
+}
+
+// DecisionCache configures how the IDs of sampled traces are remembered for
+// the decisions export.
+type DecisionCache struct {
+	MaxEntries int           `river:"max_entries,attr,optional"`
+	TTL        time.Duration `river:"ttl,attr,optional"`
 }
 
 // UnmarshalRiver implements river.Unmarshaler. It applies defaults to args and
@@ -67,9 +91,30 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 		return fmt.Errorf("num_traces must be greater than zero")
 	}
 
+	if args.DecisionCache.MaxEntries <= 0 {
+		return fmt.Errorf("decision_cache.max_entries must be greater than zero")
+	}
+
+	if args.DecisionCache.TTL < 0 {
+		return fmt.Errorf("decision_cache.ttl must not be negative")
+	}
+
 	return nil
 }
 
+// withRecorder returns a copy of args where sampled traces are recorded in
+// cache before being sent to the configured outputs.
+func (args Arguments) withRecorder(cache *tracesampling.Cache) Arguments {
+	if args.Output == nil {
+		return args
+	}
+
+	output := *args.Output
+	output.Traces = []otelcol.Consumer{newRecorder(cache, args.Output.Traces)}
+	args.Output = &output
+	return args
+}
+
 // Convert implements processor.Arguments.
 func (args Arguments) Convert() (otelconfig.Processor, error) {
 	// TODO: Get rid of mapstructure once tailsamplingprocessor.Config has all public types
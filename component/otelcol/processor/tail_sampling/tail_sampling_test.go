@@ -37,6 +37,24 @@ func TestBadRiverConfig(t *testing.T) {
 	require.Error(t, river.Unmarshal([]byte(exampleBadRiverConfig), &args), "num_traces must be greater than zero")
 }
 
+func TestBadDecisionCacheConfig(t *testing.T) {
+	exampleBadRiverConfig := `
+    policy {
+      name = "test-policy-1"
+      type = "always_sample"
+    }
+    decision_cache {
+      max_entries = 0
+    }
+    output {
+      // no-op: will be overridden by test code.
+    }
+`
+
+	var args Arguments
+	require.EqualError(t, river.Unmarshal([]byte(exampleBadRiverConfig), &args), "decision_cache.max_entries must be greater than zero")
+}
+
 func TestBadOtelConfig(t *testing.T) {
 	var exampleBadOtelConfig = `
     decision_wait               = "10s"
@@ -285,7 +303,7 @@ func TestTraceProcessing(t *testing.T) {
 
 	// Send traces in the background to our processor.
 	go func() {
-		exports := ctrl.Exports().(otelcol.ConsumerExports)
+		exports := ctrl.Exports().(Exports)
 
 		exports.Input.Capabilities()
 
@@ -312,6 +330,11 @@ func TestTraceProcessing(t *testing.T) {
 	case tr := <-traceCh:
 		require.Equal(t, 1, tr.SpanCount())
 	}
+
+	// The forwarded trace must have been recorded as sampled.
+	exports := ctrl.Exports().(Exports)
+	require.True(t, exports.Decisions.Sampled("0102030405060708090A0B0C0D0E0F10"))
+	require.False(t, exports.Decisions.Sampled("ffffffffffffffffffffffffffffffff"))
 }
 
 // makeTracesOutput returns ConsumerArguments which will forward traces to the
@@ -340,6 +363,7 @@ func createTestTraces() ptrace.Traces {
 		"resource_spans": [{
 			"scope_spans": [{
 				"spans": [{
+					"trace_id": "0102030405060708090a0b0c0d0e0f10",
 					"name": "TestSpan"
 				}]
 			}]
@@ -0,0 +1,126 @@
+// Package awsxray provides an otelcol.receiver.awsxray component.
+package awsxray
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/receiver"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/awsxrayreceiver"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/config/confignet"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "otelcol.receiver.awsxray",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := awsxrayreceiver.NewFactory()
+			return receiver.New(opts, fact, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/awsxrayreceiver"},
+	})
+}
+
+// Arguments configures the otelcol.receiver.awsxray component.
+type Arguments struct {
+	// Endpoint is the UDP address to receive segments from X-Ray SDKs on.
+	Endpoint string `river:"endpoint,attr,optional"`
+
+	ProxyServer ProxyServerArguments `river:"proxy_server,block,optional"`
+
+	// Output configures where to send received data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ receiver.ListenerArguments = Arguments{}
+	_ river.Unmarshaler          = (*Arguments)(nil)
+)
+
+// DefaultArguments holds default settings for otelcol.receiver.awsxray.
+var DefaultArguments = Arguments{
+	Endpoint:    "0.0.0.0:2000",
+	ProxyServer: DefaultProxyServerArguments,
+}
+
+// UnmarshalRiver applies defaults to args before unmarshaling.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(args))
+}
+
+// Convert implements receiver.Arguments.
+func (args Arguments) Convert() (otelconfig.Receiver, error) {
+	// The type of the proxy server settings is internal to the collector, so
+	// start from the defaults of the factory and override them.
+	cfg := awsxrayreceiver.NewFactory().CreateDefaultConfig().(*awsxrayreceiver.Config)
+	cfg.NetAddr = confignet.NetAddr{
+		Endpoint:  args.Endpoint,
+		Transport: "udp",
+	}
+
+	ps := args.ProxyServer
+	cfg.ProxyServer.TCPAddr = confignet.TCPAddr{Endpoint: ps.Endpoint}
+	cfg.ProxyServer.ProxyAddress = ps.ProxyAddress
+	cfg.ProxyServer.TLSSetting = *ps.TLS.Convert()
+	cfg.ProxyServer.Region = ps.Region
+	cfg.ProxyServer.RoleARN = ps.RoleARN
+	cfg.ProxyServer.AWSEndpoint = ps.AWSEndpoint
+	cfg.ProxyServer.LocalMode = ps.LocalMode
+	return cfg, nil
+}
+
+// Extensions implements receiver.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements receiver.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements receiver.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// ListenAddresses implements receiver.ListenerArguments.
+func (args Arguments) ListenAddresses() []string {
+	return []string{args.Endpoint, args.ProxyServer.Endpoint}
+}
+
+// ProxyServerArguments configures the local TCP proxy which relays sampling
+// requests from X-Ray SDKs to the AWS X-Ray service.
+type ProxyServerArguments struct {
+	Endpoint     string                     `river:"endpoint,attr,optional"`
+	ProxyAddress string                     `river:"proxy_address,attr,optional"`
+	Region       string                     `river:"region,attr,optional"`
+	RoleARN      string                     `river:"role_arn,attr,optional"`
+	AWSEndpoint  string                     `river:"aws_endpoint,attr,optional"`
+	LocalMode    bool                       `river:"local_mode,attr,optional"`
+	TLS          otelcol.TLSClientArguments `river:"tls,block,optional"`
+}
+
+var _ river.Unmarshaler = (*ProxyServerArguments)(nil)
+
+// DefaultProxyServerArguments holds default settings for
+// ProxyServerArguments.
+var DefaultProxyServerArguments = ProxyServerArguments{
+	Endpoint: "0.0.0.0:2000",
+}
+
+// UnmarshalRiver applies defaults to args before unmarshaling.
+func (args *ProxyServerArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultProxyServerArguments
+
+	type arguments ProxyServerArguments
+	return f((*arguments)(args))
+}
@@ -0,0 +1,147 @@
+package awsxray_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/receiver/awsxray"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/awsxrayreceiver"
+	"github.com/phayes/freeport"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.receiver.awsxray component and ensures that it can receive and
+// forward segments.
+func Test(t *testing.T) {
+	udpAddr := getFreeAddr(t)
+	proxyAddr := getFreeAddr(t)
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.receiver.awsxray")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		endpoint = "%s"
+
+		proxy_server {
+			endpoint   = "%s"
+			region     = "us-east-1"
+			local_mode = true
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`, udpAddr, proxyAddr)
+	var args awsxray.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our settings so traces get forwarded to traceCh.
+	traceCh := make(chan ptrace.Traces)
+	args.Output = makeTracesOutput(traceCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+
+	// Segments are sent over UDP, so keep sending until one gets through.
+	conn, err := net.Dial("udp", udpAddr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	segment := `{"format": "json", "version": 1}` + "\n" +
+		`{"trace_id": "1-5f84c7a1-e7d1852db8c4fd35d88bf49a", "id": "defdfd9912dc5a56", "name": "checkout",` +
+		` "start_time": 1602537377.495, "end_time": 1602537377.505}`
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		_, err := conn.Write([]byte(segment))
+		require.NoError(t, err)
+
+		select {
+		case <-timeout:
+			require.FailNow(t, "failed waiting for traces")
+		case tr := <-traceCh:
+			require.Equal(t, 1, tr.SpanCount())
+			span := tr.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+			require.Equal(t, "checkout", span.Name())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	in := `
+		proxy_server {
+			region   = "eu-west-1"
+			role_arn = "arn:aws:iam::123456789012:role/xray"
+
+			tls {
+				insecure_skip_verify = true
+			}
+		}
+
+		output { /* no-op */ }
+	`
+
+	var args awsxray.Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	ext, err := args.Convert()
+	require.NoError(t, err)
+	otelArgs, ok := (ext).(*awsxrayreceiver.Config)
+	require.True(t, ok)
+
+	require.Equal(t, "0.0.0.0:2000", otelArgs.Endpoint)
+	require.Equal(t, "udp", otelArgs.Transport)
+	require.Equal(t, "0.0.0.0:2000", otelArgs.ProxyServer.Endpoint)
+	require.Equal(t, "eu-west-1", otelArgs.ProxyServer.Region)
+	require.Equal(t, "arn:aws:iam::123456789012:role/xray", otelArgs.ProxyServer.RoleARN)
+	require.True(t, otelArgs.ProxyServer.TLSSetting.InsecureSkipVerify)
+	require.False(t, otelArgs.ProxyServer.LocalMode)
+}
+
+// makeTracesOutput returns ConsumerArguments which will forward traces to the
+// provided channel.
+func makeTracesOutput(ch chan ptrace.Traces) *otelcol.ConsumerArguments {
+	traceConsumer := fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(ctx context.Context, t ptrace.Traces) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- t:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&traceConsumer},
+	}
+}
+
+func getFreeAddr(t *testing.T) string {
+	t.Helper()
+
+	portNumber, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	return fmt.Sprintf("localhost:%d", portNumber)
+}
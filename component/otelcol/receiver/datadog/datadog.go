@@ -0,0 +1,83 @@
+// Package datadog provides an otelcol.receiver.datadog component.
+package datadog
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/receiver"
+	"github.com/grafana/agent/component/otelcol/receiver/datadog/internal/datadogreceiver"
+	"github.com/grafana/agent/pkg/river"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "otelcol.receiver.datadog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := datadogreceiver.NewFactory()
+			return receiver.New(opts, fact, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
+	})
+}
+
+// Arguments configures the otelcol.receiver.datadog component.
+type Arguments struct {
+	HTTPServer otelcol.HTTPServerArguments `river:",squash"`
+
+	// Output configures where to send received data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ receiver.ListenerArguments = Arguments{}
+	_ river.Unmarshaler          = (*Arguments)(nil)
+)
+
+// DefaultArguments holds default settings for otelcol.receiver.datadog.
+var DefaultArguments = Arguments{
+	HTTPServer: otelcol.HTTPServerArguments{
+		Endpoint: "0.0.0.0:8126",
+	},
+}
+
+// UnmarshalRiver applies defaults to args before unmarshaling.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(args))
+}
+
+// Convert implements receiver.Arguments.
+func (args Arguments) Convert() (otelconfig.Receiver, error) {
+	return &datadogreceiver.Config{
+		ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID(datadogreceiver.TypeStr)),
+
+		HTTPServerSettings: *args.HTTPServer.Convert(),
+	}, nil
+}
+
+// Extensions implements receiver.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements receiver.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements receiver.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// ListenAddresses implements receiver.ListenerArguments.
+func (args Arguments) ListenAddresses() []string {
+	return []string{args.HTTPServer.Endpoint}
+}
@@ -0,0 +1,144 @@
+package datadog_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/receiver/datadog"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/phayes/freeport"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.receiver.datadog component and ensures that it can receive and
+// forward traces and metrics.
+func Test(t *testing.T) {
+	httpAddr := getFreeAddr(t)
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.receiver.datadog")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		endpoint = "%s"
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`, httpAddr)
+	var args datadog.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our settings so traces and metrics get forwarded to channels.
+	traceCh := make(chan ptrace.Traces, 1)
+	metricCh := make(chan pmetric.Metrics, 1)
+	args.Output = makeOutput(traceCh, metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+
+	traces := `[[{"service": "checkout", "name": "http.request", "resource": "GET /cart",
+		"trace_id": 1, "span_id": 2, "start": 1600000000000000000, "duration": 5000000}]]`
+	resp := send(t, http.MethodPut, fmt.Sprintf("http://%s/v0.4/traces", httpAddr), traces)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	series := `{"series": [{"metric": "system.load.1", "host": "web-1", "points": [[1636629071, 0.7]]}]}`
+	resp = send(t, http.MethodPost, fmt.Sprintf("http://%s/api/v1/series", httpAddr), series)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case tr := <-traceCh:
+		require.Equal(t, 1, tr.SpanCount())
+	}
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+	case m := <-metricCh:
+		require.Equal(t, 1, m.DataPointCount())
+	}
+}
+
+// send sends body to url, retrying until the server is listening.
+func send(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	require.Eventually(t, func() bool {
+		var req *http.Request
+		req, err = http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err = http.DefaultClient.Do(req)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "failed to send request: %v", err)
+
+	resp.Body.Close()
+	return resp
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	var args datadog.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`output { /* no-op */ }`), &args))
+	require.Equal(t, []string{"0.0.0.0:8126"}, args.ListenAddresses())
+}
+
+// makeOutput returns ConsumerArguments which will forward traces and metrics
+// to the provided channels.
+func makeOutput(traceCh chan ptrace.Traces, metricCh chan pmetric.Metrics) *otelcol.ConsumerArguments {
+	consumer := fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(ctx context.Context, t ptrace.Traces) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case traceCh <- t:
+				return nil
+			}
+		},
+		ConsumeMetricsFunc: func(ctx context.Context, m pmetric.Metrics) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case metricCh <- m:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Traces:  []otelcol.Consumer{&consumer},
+		Metrics: []otelcol.Consumer{&consumer},
+	}
+}
+
+func getFreeAddr(t *testing.T) string {
+	t.Helper()
+
+	portNumber, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	return fmt.Sprintf("localhost:%d", portNumber)
+}
@@ -0,0 +1,104 @@
+// Package datadogreceiver implements an OpenTelemetry Collector receiver which
+// accepts traces and metrics sent with the Datadog intake protocols.
+//
+// The receiver lives in this repository because the upstream
+// github.com/open-telemetry/opentelemetry-collector-contrib/receiver/datadogreceiver
+// module can't be pinned: it was never released at v0.63.0, the
+// opentelemetry-collector-contrib version every other otelcol component is
+// built against, and its later releases require go.opentelemetry.io/collector
+// newer than the v0.63.1 in go.mod. Those releases are also not available
+// from the module proxy used to build the agent. Once the collector
+// dependencies are upgraded, this package should be deleted in favor of the
+// upstream receiver.
+package datadogreceiver
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+// TypeStr is the type of the receiver.
+const TypeStr = "datadog"
+
+// Config configures the receiver.
+type Config struct {
+	config.ReceiverSettings       `mapstructure:",squash"`
+	confighttp.HTTPServerSettings `mapstructure:",squash"`
+}
+
+// NewFactory returns a factory for the receiver.
+func NewFactory() component.ReceiverFactory {
+	f := &factory{receivers: make(map[*Config]*datadogReceiver)}
+
+	return component.NewReceiverFactory(
+		TypeStr,
+		createDefaultConfig,
+		component.WithTracesReceiver(f.createTracesReceiver, component.StabilityLevelAlpha),
+		component.WithMetricsReceiver(f.createMetricsReceiver, component.StabilityLevelAlpha),
+	)
+}
+
+func createDefaultConfig() config.Receiver {
+	return &Config{
+		ReceiverSettings: config.NewReceiverSettings(config.NewComponentID(TypeStr)),
+		HTTPServerSettings: confighttp.HTTPServerSettings{
+			Endpoint: "0.0.0.0:8126",
+		},
+	}
+}
+
+// factory creates a single receiver for both traces and metrics of the same
+// config, since they're served by the same HTTP server.
+type factory struct {
+	mut       sync.Mutex
+	receivers map[*Config]*datadogReceiver
+}
+
+func (f *factory) createTracesReceiver(
+	_ context.Context,
+	set component.ReceiverCreateSettings,
+	cfg config.Receiver,
+	next consumer.Traces,
+) (component.TracesReceiver, error) {
+
+	r := f.receiver(cfg.(*Config), set)
+	r.nextTraces = next
+	return r, nil
+}
+
+func (f *factory) createMetricsReceiver(
+	_ context.Context,
+	set component.ReceiverCreateSettings,
+	cfg config.Receiver,
+	next consumer.Metrics,
+) (component.MetricsReceiver, error) {
+
+	r := f.receiver(cfg.(*Config), set)
+	r.nextMetrics = next
+	return r, nil
+}
+
+// receiver returns the receiver for cfg, creating it if it doesn't exist yet.
+// The receiver is forgotten once it's shut down.
+func (f *factory) receiver(cfg *Config, set component.ReceiverCreateSettings) *datadogReceiver {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if r, ok := f.receivers[cfg]; ok {
+		return r
+	}
+
+	r := newReceiver(cfg, set)
+	r.onShutdown = func() {
+		f.mut.Lock()
+		defer f.mut.Unlock()
+		delete(f.receivers, cfg)
+	}
+	f.receivers[cfg] = r
+	return r
+}
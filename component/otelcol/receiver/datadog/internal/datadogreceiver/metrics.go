@@ -0,0 +1,93 @@
+package datadogreceiver
+
+import (
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// seriesPayload is the payload of the v1 series API.
+type seriesPayload struct {
+	Series []series `json:"series"`
+}
+
+type series struct {
+	Metric   string       `json:"metric"`
+	Points   [][]*float64 `json:"points"`
+	Type     string       `json:"type"`
+	Interval int64        `json:"interval"`
+	Host     string       `json:"host"`
+	Device   string       `json:"device"`
+	Tags     []string     `json:"tags"`
+}
+
+// typeCount is the type of series of counts. Other series, of type gauge or
+// rate, hold per-second values.
+const typeCount = "count"
+
+// toMetrics converts p to OpenTelemetry metrics, with a resource for every
+// host. Counts are converted to delta sums, and other series to gauges.
+func toMetrics(p seriesPayload) pmetric.Metrics {
+	res := pmetric.NewMetrics()
+	hosts := make(map[string]pmetric.MetricSlice)
+
+	for _, s := range p.Series {
+		metrics, ok := hosts[s.Host]
+		if !ok {
+			rm := res.ResourceMetrics().AppendEmpty()
+			if s.Host != "" {
+				rm.Resource().Attributes().PutStr(conventions.AttributeHostName, s.Host)
+			}
+			sm := rm.ScopeMetrics().AppendEmpty()
+			sm.Scope().SetName("Datadog")
+			metrics = sm.Metrics()
+			hosts[s.Host] = metrics
+		}
+
+		m := metrics.AppendEmpty()
+		m.SetName(s.Metric)
+
+		var dps pmetric.NumberDataPointSlice
+		switch s.Type {
+		case typeCount:
+			sum := m.SetEmptySum()
+			sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+			dps = sum.DataPoints()
+		default:
+			dps = m.SetEmptyGauge().DataPoints()
+		}
+
+		for _, point := range s.Points {
+			if len(point) != 2 || point[0] == nil || point[1] == nil {
+				continue
+			}
+
+			ts := time.Unix(int64(*point[0]), 0)
+			dp := dps.AppendEmpty()
+			dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+			if s.Type == typeCount && s.Interval > 0 {
+				dp.SetStartTimestamp(pcommon.NewTimestampFromTime(ts.Add(-time.Duration(s.Interval) * time.Second)))
+			}
+			dp.SetDoubleValue(*point[1])
+			putTags(dp.Attributes(), s)
+		}
+	}
+	return res
+}
+
+// putTags adds the tags of s to attrs. Tags of the form key:value become an
+// attribute named key, and tags without a value become an attribute with an
+// empty value.
+func putTags(attrs pcommon.Map, s series) {
+	attrs.EnsureCapacity(len(s.Tags) + 1)
+	if s.Device != "" {
+		attrs.PutStr("device", s.Device)
+	}
+	for _, tag := range s.Tags {
+		key, value, _ := strings.Cut(tag, ":")
+		attrs.PutStr(key, value)
+	}
+}
@@ -0,0 +1,50 @@
+package datadogreceiver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestToMetrics(t *testing.T) {
+	body := `{"series": [
+		{"metric": "system.load.1", "type": "gauge", "host": "web-1",
+		 "points": [[1636629071, 0.7], [1636629081, null]], "tags": ["env:prod", "canary"]},
+		{"metric": "http.requests", "type": "count", "interval": 10, "host": "web-1",
+		 "points": [[1636629080, 42]], "device": "eth0"},
+		{"metric": "queue.depth", "host": "web-2", "points": [[1636629080, 3]]}
+	]}`
+
+	var payload seriesPayload
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+
+	metrics := toMetrics(payload)
+	require.Equal(t, 2, metrics.ResourceMetrics().Len())
+	require.Equal(t, 3, metrics.DataPointCount())
+
+	rm := metrics.ResourceMetrics().At(0)
+	require.Equal(t, map[string]interface{}{"host.name": "web-1"}, rm.Resource().Attributes().AsRaw())
+
+	ms := rm.ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, ms.Len())
+
+	load := ms.At(0)
+	require.Equal(t, "system.load.1", load.Name())
+	require.Equal(t, pmetric.MetricTypeGauge, load.Type())
+	require.Equal(t, 1, load.Gauge().DataPoints().Len())
+	dp := load.Gauge().DataPoints().At(0)
+	require.Equal(t, 0.7, dp.DoubleValue())
+	require.Equal(t, time.Unix(1636629071, 0).UTC(), dp.Timestamp().AsTime())
+	require.Equal(t, map[string]interface{}{"env": "prod", "canary": ""}, dp.Attributes().AsRaw())
+
+	requests := ms.At(1)
+	require.Equal(t, pmetric.MetricTypeSum, requests.Type())
+	require.Equal(t, pmetric.AggregationTemporalityDelta, requests.Sum().AggregationTemporality())
+	dp = requests.Sum().DataPoints().At(0)
+	require.Equal(t, 42.0, dp.DoubleValue())
+	require.Equal(t, time.Unix(1636629070, 0).UTC(), dp.StartTimestamp().AsTime())
+	require.Equal(t, map[string]interface{}{"device": "eth0"}, dp.Attributes().AsRaw())
+}
@@ -0,0 +1,200 @@
+package datadogreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/obsreport"
+)
+
+// datadogReceiver serves the trace intake API of the Datadog agent, used by
+// Datadog tracing libraries, and the series API of Datadog, used by the
+// Datadog agent to send metrics.
+type datadogReceiver struct {
+	cfg      *Config
+	settings component.ReceiverCreateSettings
+	obsrecv  *obsreport.Receiver
+
+	nextTraces  consumer.Traces
+	nextMetrics consumer.Metrics
+	onShutdown  func()
+
+	// The receiver is started and shut down once per signal it's created
+	// for; only the first call of each does anything.
+	startOnce    sync.Once
+	startErr     error
+	shutdownOnce sync.Once
+	shutdownErr  error
+
+	server     *http.Server
+	shutdownWG sync.WaitGroup
+}
+
+var (
+	_ component.TracesReceiver  = (*datadogReceiver)(nil)
+	_ component.MetricsReceiver = (*datadogReceiver)(nil)
+)
+
+func newReceiver(cfg *Config, set component.ReceiverCreateSettings) *datadogReceiver {
+	return &datadogReceiver{
+		cfg:      cfg,
+		settings: set,
+		obsrecv: obsreport.NewReceiver(obsreport.ReceiverSettings{
+			ReceiverID:             cfg.ID(),
+			Transport:              "http",
+			ReceiverCreateSettings: set,
+		}),
+	}
+}
+
+// Start implements component.Component.
+func (r *datadogReceiver) Start(_ context.Context, host component.Host) error {
+	r.startOnce.Do(func() {
+		r.startErr = r.start(host)
+	})
+	return r.startErr
+}
+
+func (r *datadogReceiver) start(host component.Host) error {
+	if host == nil {
+		return errors.New("nil host")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", r.handleInfo)
+	mux.HandleFunc("/v0.3/traces", r.handleTraces(apiV03))
+	mux.HandleFunc("/v0.4/traces", r.handleTraces(apiV04))
+	mux.HandleFunc("/v0.5/traces", r.handleTraces(apiV05))
+	mux.HandleFunc("/api/v1/validate", r.handleValidate)
+	mux.HandleFunc("/api/v1/series", r.handleSeries)
+
+	var err error
+	r.server, err = r.cfg.HTTPServerSettings.ToServer(host, r.settings.TelemetrySettings, mux)
+	if err != nil {
+		return err
+	}
+
+	var listener net.Listener
+	listener, err = r.cfg.HTTPServerSettings.ToListener()
+	if err != nil {
+		return err
+	}
+	r.shutdownWG.Add(1)
+	go func() {
+		defer r.shutdownWG.Done()
+
+		if errHTTP := r.server.Serve(listener); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			host.ReportFatalError(errHTTP)
+		}
+	}()
+
+	return nil
+}
+
+// Shutdown implements component.Component.
+func (r *datadogReceiver) Shutdown(context.Context) error {
+	r.shutdownOnce.Do(func() {
+		if r.onShutdown != nil {
+			r.onShutdown()
+		}
+		if r.server != nil {
+			r.shutdownErr = r.server.Close()
+		}
+		r.shutdownWG.Wait()
+	})
+	return r.shutdownErr
+}
+
+// handleInfo advertises the supported endpoints to tracing libraries.
+func (r *datadogReceiver) handleInfo(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"endpoints":       []string{"/v0.3/traces", "/v0.4/traces", "/v0.5/traces"},
+		"client_drop_p0s": false,
+	})
+}
+
+func (r *datadogReceiver) handleTraces(api traceAPI) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost && req.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.nextTraces == nil {
+			http.Error(w, "traces are not accepted", http.StatusNotFound)
+			return
+		}
+
+		ctx := r.obsrecv.StartTracesOp(req.Context())
+
+		payload, err := decodeTraces(api, req)
+		if err != nil {
+			r.obsrecv.EndTracesOp(ctx, string(api), 0, err)
+			http.Error(w, fmt.Sprintf("failed to decode traces: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		traces := toTraces(payload, req.Header)
+		err = r.nextTraces.ConsumeTraces(ctx, traces)
+		r.obsrecv.EndTracesOp(ctx, string(api), traces.SpanCount(), err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if api == apiV03 {
+			_, _ = w.Write([]byte("OK"))
+			return
+		}
+		// Tracing libraries expect sampling rates by service in the response;
+		// leave them unset so that libraries keep their configured rates.
+		writeJSON(w, http.StatusOK, map[string]interface{}{"rate_by_service": map[string]float64{}})
+	}
+}
+
+// handleValidate accepts any API key, since the Datadog agent validates its
+// API key on startup.
+func (r *datadogReceiver) handleValidate(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{"valid": true})
+}
+
+func (r *datadogReceiver) handleSeries(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.nextMetrics == nil {
+		http.Error(w, "metrics are not accepted", http.StatusNotFound)
+		return
+	}
+
+	ctx := r.obsrecv.StartMetricsOp(req.Context())
+
+	var payload seriesPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		r.obsrecv.EndMetricsOp(ctx, "v1/series", 0, err)
+		http.Error(w, fmt.Sprintf("failed to decode series: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	metrics := toMetrics(payload)
+	err := r.nextMetrics.ConsumeMetrics(ctx, metrics)
+	r.obsrecv.EndMetricsOp(ctx, "v1/series", metrics.DataPointCount(), err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,294 @@
+package datadogreceiver
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ugorji/go/codec"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// traceAPI is a version of the trace intake API of the Datadog agent.
+type traceAPI string
+
+const (
+	apiV03 traceAPI = "v0.3"
+	apiV04 traceAPI = "v0.4"
+	apiV05 traceAPI = "v0.5"
+)
+
+// span is a span sent by Datadog tracing libraries. The v0.3 and v0.4 APIs
+// send lists of traces, each of which is a list of spans.
+type span struct {
+	Service  string             `json:"service"`
+	Name     string             `json:"name"`
+	Resource string             `json:"resource"`
+	TraceID  uint64             `json:"trace_id"`
+	SpanID   uint64             `json:"span_id"`
+	ParentID uint64             `json:"parent_id"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+	Error    int32              `json:"error"`
+	Meta     map[string]string  `json:"meta"`
+	Metrics  map[string]float64 `json:"metrics"`
+	Type     string             `json:"type"`
+}
+
+// v05Payload is the payload of the v0.5 API, which deduplicates strings
+// into a dictionary referenced by index.
+type v05Payload struct {
+	_struct bool `codec:",toarray"` //nolint:unused,structcheck
+
+	Strings []string
+	Traces  [][]v05Span
+}
+
+type v05Span struct {
+	_struct bool `codec:",toarray"` //nolint:unused,structcheck
+
+	Service  uint32
+	Name     uint32
+	Resource uint32
+	TraceID  uint64
+	SpanID   uint64
+	ParentID uint64
+	Start    int64
+	Duration int64
+	Error    int32
+	Meta     map[uint32]uint32
+	Metrics  map[uint32]float64
+	Type     uint32
+}
+
+// decodeTraces decodes the traces in the body of req.
+func decodeTraces(api traceAPI, req *http.Request) ([][]span, error) {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	switch {
+	case api == apiV05:
+		var payload v05Payload
+		if err := codec.NewDecoder(req.Body, msgpackHandle()).Decode(&payload); err != nil {
+			return nil, err
+		}
+		return payload.spans()
+
+	case mediaType == "application/msgpack":
+		var traces [][]span
+		err := codec.NewDecoder(req.Body, msgpackHandle()).Decode(&traces)
+		return traces, err
+
+	default:
+		var traces [][]span
+		err := json.NewDecoder(req.Body).Decode(&traces)
+		return traces, err
+	}
+}
+
+func msgpackHandle() *codec.MsgpackHandle {
+	var h codec.MsgpackHandle
+	h.RawToString = true
+	h.TypeInfos = codec.NewTypeInfos([]string{"codec", "json"})
+	return &h
+}
+
+// spans resolves the strings of the spans of p.
+func (p v05Payload) spans() ([][]span, error) {
+	str := func(i uint32) (string, error) {
+		if int(i) >= len(p.Strings) {
+			return "", fmt.Errorf("string index %d out of range of %d strings", i, len(p.Strings))
+		}
+		return p.Strings[i], nil
+	}
+
+	traces := make([][]span, 0, len(p.Traces))
+	for _, t := range p.Traces {
+		trace := make([]span, 0, len(t))
+		for _, s := range t {
+			res := span{
+				TraceID:  s.TraceID,
+				SpanID:   s.SpanID,
+				ParentID: s.ParentID,
+				Start:    s.Start,
+				Duration: s.Duration,
+				Error:    s.Error,
+				Meta:     make(map[string]string, len(s.Meta)),
+				Metrics:  make(map[string]float64, len(s.Metrics)),
+			}
+
+			for _, field := range []struct {
+				index uint32
+				dst   *string
+			}{
+				{s.Service, &res.Service},
+				{s.Name, &res.Name},
+				{s.Resource, &res.Resource},
+				{s.Type, &res.Type},
+			} {
+				v, err := str(field.index)
+				if err != nil {
+					return nil, err
+				}
+				*field.dst = v
+			}
+
+			for k, v := range s.Meta {
+				key, err := str(k)
+				if err != nil {
+					return nil, err
+				}
+				val, err := str(v)
+				if err != nil {
+					return nil, err
+				}
+				res.Meta[key] = val
+			}
+			for k, v := range s.Metrics {
+				key, err := str(k)
+				if err != nil {
+					return nil, err
+				}
+				res.Metrics[key] = v
+			}
+
+			trace = append(trace, res)
+		}
+		traces = append(traces, trace)
+	}
+	return traces, nil
+}
+
+// Tags of Datadog spans with a special meaning.
+const (
+	tagTraceIDHigh  = "_dd.p.tid"
+	tagSpanKind     = "span.kind"
+	tagErrorMessage = "error.msg"
+)
+
+// Attributes holding the fields of Datadog spans which have no equivalent
+// in OpenTelemetry.
+const (
+	attrSpanName = "dd.span.name"
+	attrSpanType = "dd.span.type"
+)
+
+// toTraces converts traces to OpenTelemetry traces, with a resource for
+// every service. Information about the tracing library is read from the
+// headers sent with the traces.
+func toTraces(traces [][]span, header http.Header) ptrace.Traces {
+	res := ptrace.NewTraces()
+	services := make(map[string]ptrace.SpanSlice)
+
+	for _, trace := range traces {
+		for _, s := range trace {
+			spans, ok := services[s.Service]
+			if !ok {
+				rs := res.ResourceSpans().AppendEmpty()
+				attrs := rs.Resource().Attributes()
+				attrs.PutStr(conventions.AttributeServiceName, s.Service)
+				attrs.PutStr(conventions.AttributeTelemetrySDKName, "Datadog")
+				if v := header.Get("Datadog-Meta-Lang"); v != "" {
+					attrs.PutStr(conventions.AttributeTelemetrySDKLanguage, v)
+				}
+				if v := header.Get("Datadog-Meta-Tracer-Version"); v != "" {
+					attrs.PutStr(conventions.AttributeTelemetrySDKVersion, v)
+				}
+				if v := header.Get("Datadog-Meta-Lang-Version"); v != "" {
+					attrs.PutStr(conventions.AttributeProcessRuntimeVersion, v)
+				}
+
+				ss := rs.ScopeSpans().AppendEmpty()
+				ss.Scope().SetName("Datadog")
+				ss.Scope().SetVersion(header.Get("Datadog-Meta-Tracer-Version"))
+				spans = ss.Spans()
+				services[s.Service] = spans
+			}
+
+			convertSpan(s, spans.AppendEmpty())
+		}
+	}
+	return res
+}
+
+func convertSpan(s span, dst ptrace.Span) {
+	var traceID [16]byte
+	if high, err := hex.DecodeString(s.Meta[tagTraceIDHigh]); err == nil && len(high) == 8 {
+		copy(traceID[:8], high)
+	}
+	binary.BigEndian.PutUint64(traceID[8:], s.TraceID)
+	dst.SetTraceID(pcommon.TraceID(traceID))
+	dst.SetSpanID(uint64ToSpanID(s.SpanID))
+	if s.ParentID != 0 {
+		dst.SetParentSpanID(uint64ToSpanID(s.ParentID))
+	}
+
+	// The resource of a Datadog span, such as "GET /users/:id", is what
+	// OpenTelemetry calls the span name. The Datadog span name, such as
+	// "http.request", is kept as an attribute.
+	name := s.Resource
+	if name == "" {
+		name = s.Name
+	}
+	dst.SetName(name)
+	dst.SetKind(spanKind(s))
+	dst.SetStartTimestamp(pcommon.Timestamp(s.Start))
+	dst.SetEndTimestamp(pcommon.Timestamp(s.Start + s.Duration))
+
+	if s.Error != 0 {
+		dst.Status().SetCode(ptrace.StatusCodeError)
+		dst.Status().SetMessage(s.Meta[tagErrorMessage])
+	}
+
+	attrs := dst.Attributes()
+	attrs.EnsureCapacity(len(s.Meta) + len(s.Metrics) + 2)
+	attrs.PutStr(attrSpanName, s.Name)
+	if s.Type != "" {
+		attrs.PutStr(attrSpanType, s.Type)
+	}
+	for k, v := range s.Meta {
+		if k == tagTraceIDHigh {
+			continue
+		}
+		attrs.PutStr(k, v)
+	}
+	for k, v := range s.Metrics {
+		attrs.PutDouble(k, v)
+	}
+}
+
+func uint64ToSpanID(id uint64) pcommon.SpanID {
+	var res [8]byte
+	binary.BigEndian.PutUint64(res[:], id)
+	return pcommon.SpanID(res)
+}
+
+// spanKind returns the kind of s from its span.kind tag, or guesses it from
+// its type.
+func spanKind(s span) ptrace.SpanKind {
+	switch s.Meta[tagSpanKind] {
+	case "server":
+		return ptrace.SpanKindServer
+	case "client":
+		return ptrace.SpanKindClient
+	case "producer":
+		return ptrace.SpanKindProducer
+	case "consumer":
+		return ptrace.SpanKindConsumer
+	case "internal":
+		return ptrace.SpanKindInternal
+	}
+
+	switch s.Type {
+	case "web", "server":
+		return ptrace.SpanKindServer
+	case "http", "grpc", "db", "sql", "cache", "redis", "memcached", "mongodb", "elasticsearch", "cassandra":
+		return ptrace.SpanKindClient
+	default:
+		return ptrace.SpanKindUnspecified
+	}
+}
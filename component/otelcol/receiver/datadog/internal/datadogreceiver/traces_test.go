@@ -0,0 +1,142 @@
+package datadogreceiver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var testSpan = span{
+	Service:  "checkout",
+	Name:     "http.request",
+	Resource: "GET /cart",
+	TraceID:  0x5b8efff798038103,
+	SpanID:   0xd0c0ffee,
+	ParentID: 0xbeef,
+	Start:    1_600_000_000_000_000_000,
+	Duration: 5_000_000,
+	Error:    1,
+	Meta: map[string]string{
+		"_dd.p.tid":        "640cfd8d00000000",
+		"http.status_code": "500",
+		"error.msg":        "out of stock",
+	},
+	Metrics: map[string]float64{"_sampling_priority_v1": 1},
+	Type:    "web",
+}
+
+func TestDecodeTraces(t *testing.T) {
+	t.Run("v0.3 json", func(t *testing.T) {
+		body := `[[{"service": "checkout", "name": "http.request", "resource": "GET /cart",
+			"trace_id": 6597491943016726787, "span_id": 3502309358, "parent_id": 48879,
+			"start": 1600000000000000000, "duration": 5000000, "error": 1, "type": "web",
+			"meta": {"_dd.p.tid": "640cfd8d00000000", "http.status_code": "500", "error.msg": "out of stock"},
+			"metrics": {"_sampling_priority_v1": 1}}]]`
+		req := httptest.NewRequest(http.MethodPut, "/v0.3/traces", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		traces, err := decodeTraces(apiV03, req)
+		require.NoError(t, err)
+		require.Equal(t, [][]span{{testSpan}}, traces)
+	})
+
+	t.Run("v0.4 msgpack", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, codec.NewEncoder(&buf, msgpackHandle()).Encode([][]span{{testSpan}}))
+
+		req := httptest.NewRequest(http.MethodPut, "/v0.4/traces", &buf)
+		req.Header.Set("Content-Type", "application/msgpack")
+
+		traces, err := decodeTraces(apiV04, req)
+		require.NoError(t, err)
+		require.Equal(t, [][]span{{testSpan}}, traces)
+	})
+
+	t.Run("v0.5 msgpack", func(t *testing.T) {
+		payload := []interface{}{
+			[]string{"checkout", "http.request", "GET /cart", "web", "_dd.p.tid", "640cfd8d00000000",
+				"http.status_code", "500", "error.msg", "out of stock", "_sampling_priority_v1"},
+			[][][]interface{}{{{
+				0, 1, 2, uint64(testSpan.TraceID), uint64(testSpan.SpanID), uint64(testSpan.ParentID),
+				testSpan.Start, testSpan.Duration, 1,
+				map[uint32]uint32{4: 5, 6: 7, 8: 9},
+				map[uint32]float64{10: 1},
+				3,
+			}}},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, codec.NewEncoder(&buf, msgpackHandle()).Encode(payload))
+
+		req := httptest.NewRequest(http.MethodPut, "/v0.5/traces", &buf)
+		req.Header.Set("Content-Type", "application/msgpack")
+
+		traces, err := decodeTraces(apiV05, req)
+		require.NoError(t, err)
+		require.Equal(t, [][]span{{testSpan}}, traces)
+	})
+
+	t.Run("v0.5 invalid string index", func(t *testing.T) {
+		payload := []interface{}{
+			[]string{"checkout"},
+			[][][]interface{}{{{0, 0, 0, 1, 1, 0, 0, 0, 0, map[uint32]uint32{}, map[uint32]float64{}, 7}}},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, codec.NewEncoder(&buf, msgpackHandle()).Encode(payload))
+
+		req := httptest.NewRequest(http.MethodPut, "/v0.5/traces", &buf)
+		_, err := decodeTraces(apiV05, req)
+		require.EqualError(t, err, "string index 7 out of range of 1 strings")
+	})
+}
+
+func TestToTraces(t *testing.T) {
+	header := http.Header{}
+	header.Set("Datadog-Meta-Lang", "go")
+	header.Set("Datadog-Meta-Tracer-Version", "1.48.0")
+
+	other := testSpan
+	other.Service = "payments"
+	other.Meta = nil
+	other.Error = 0
+	other.Type = "http"
+
+	traces := toTraces([][]span{{testSpan, other}}, header)
+	require.Equal(t, 2, traces.ResourceSpans().Len())
+	require.Equal(t, 2, traces.SpanCount())
+
+	rs := traces.ResourceSpans().At(0)
+	require.Equal(t, map[string]interface{}{
+		"service.name":           "checkout",
+		"telemetry.sdk.name":     "Datadog",
+		"telemetry.sdk.language": "go",
+		"telemetry.sdk.version":  "1.48.0",
+	}, rs.Resource().Attributes().AsRaw())
+
+	s := rs.ScopeSpans().At(0).Spans().At(0)
+	require.Equal(t, "640cfd8d000000005b8efff798038103", s.TraceID().HexString())
+	require.Equal(t, "00000000d0c0ffee", s.SpanID().HexString())
+	require.Equal(t, "000000000000beef", s.ParentSpanID().HexString())
+	require.Equal(t, "GET /cart", s.Name())
+	require.Equal(t, ptrace.SpanKindServer, s.Kind())
+	require.Equal(t, ptrace.StatusCodeError, s.Status().Code())
+	require.Equal(t, "out of stock", s.Status().Message())
+	require.Equal(t, int64(5_000_000), int64(s.EndTimestamp()-s.StartTimestamp()))
+	require.Equal(t, map[string]interface{}{
+		"dd.span.name":          "http.request",
+		"dd.span.type":          "web",
+		"http.status_code":      "500",
+		"error.msg":             "out of stock",
+		"_sampling_priority_v1": float64(1),
+	}, s.Attributes().AsRaw())
+
+	s = traces.ResourceSpans().At(1).ScopeSpans().At(0).Spans().At(0)
+	require.Equal(t, "00000000000000005b8efff798038103", s.TraceID().HexString())
+	require.Equal(t, ptrace.SpanKindClient, s.Kind())
+	require.Equal(t, ptrace.StatusCodeUnset, s.Status().Code())
+}
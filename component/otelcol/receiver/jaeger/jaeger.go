@@ -24,6 +24,8 @@ func init() {
 			fact := jaegerreceiver.NewFactory()
 			return receiver.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/jaegerreceiver"},
 	})
 }
 
@@ -37,8 +39,8 @@ type Arguments struct {
 }
 
 var (
-	_ river.Unmarshaler  = (*Arguments)(nil)
-	_ receiver.Arguments = Arguments{}
+	_ river.Unmarshaler          = (*Arguments)(nil)
+	_ receiver.ListenerArguments = Arguments{}
 )
 
 // DefaultArguments provides default settings for Arguments. All protocols are
@@ -147,6 +149,24 @@ func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
 	return args.Output
 }
 
+// ListenAddresses implements receiver.ListenerArguments.
+func (args Arguments) ListenAddresses() []string {
+	var addrs []string
+	if args.Protocols.GRPC != nil {
+		addrs = append(addrs, args.Protocols.GRPC.Endpoint)
+	}
+	if args.Protocols.ThriftHTTP != nil {
+		addrs = append(addrs, args.Protocols.ThriftHTTP.Endpoint)
+	}
+	if args.Protocols.ThriftBinary != nil {
+		addrs = append(addrs, args.Protocols.ThriftBinary.Endpoint)
+	}
+	if args.Protocols.ThriftCompact != nil {
+		addrs = append(addrs, args.Protocols.ThriftCompact.Endpoint)
+	}
+	return addrs
+}
+
 // ProtocolsArguments configures protocols for otelcol.receiver.jaeger to
 // listen on.
 type ProtocolsArguments struct {
@@ -27,6 +27,8 @@ func init() {
 		Build: func(o component.Options, a component.Arguments) (component.Component, error) {
 			return NewComponent(o, a.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza"},
 	})
 }
 
@@ -91,6 +93,7 @@ func (c *Component) Run(ctx context.Context) error {
 			if err != nil {
 				level.Error(c.opts.Logger).Log("msg", "failed to consume log entries", "err", err)
 			}
+			entry.Ack.Done()
 		}
 	}
 }
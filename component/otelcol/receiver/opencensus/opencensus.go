@@ -21,6 +21,8 @@ func init() {
 			fact := opencensusreceiver.NewFactory()
 			return receiver.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/opencensusreceiver"},
 	})
 }
 
@@ -35,8 +37,8 @@ type Arguments struct {
 }
 
 var (
-	_ receiver.Arguments = Arguments{}
-	_ river.Unmarshaler  = (*Arguments)(nil)
+	_ receiver.ListenerArguments = Arguments{}
+	_ river.Unmarshaler          = (*Arguments)(nil)
 )
 
 // Default server settings.
@@ -82,3 +84,8 @@ func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.Compone
 func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
 	return args.Output
 }
+
+// ListenAddresses implements receiver.ListenerArguments.
+func (args Arguments) ListenAddresses() []string {
+	return []string{args.GRPC.Endpoint}
+}
@@ -0,0 +1,308 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Priority classes of telemetry signals. Signals with a lower priority are
+// refused earlier as memory usage grows.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// AdmissionControlArguments configures refusing data received by
+// otelcol.receiver.otlp while the memory usage of the process is too high.
+type AdmissionControlArguments struct {
+	CheckInterval    time.Duration    `river:"check_interval,attr,optional"`
+	MemoryLimit      units.Base2Bytes `river:"limit,attr"`
+	MemorySpikeLimit units.Base2Bytes `river:"spike_limit,attr,optional"`
+	RetryAfter       time.Duration    `river:"retry_after,attr,optional"`
+
+	Traces  SignalAdmissionArguments `river:"traces,block,optional"`
+	Metrics SignalAdmissionArguments `river:"metrics,block,optional"`
+	Logs    SignalAdmissionArguments `river:"logs,block,optional"`
+}
+
+var (
+	_ river.Unmarshaler = (*AdmissionControlArguments)(nil)
+	_ river.Unmarshaler = (*SignalAdmissionArguments)(nil)
+)
+
+// DefaultAdmissionControlArguments holds default settings for
+// AdmissionControlArguments.
+var DefaultAdmissionControlArguments = AdmissionControlArguments{
+	CheckInterval: time.Second,
+	RetryAfter:    5 * time.Second,
+
+	Traces:  DefaultSignalAdmissionArguments,
+	Metrics: DefaultSignalAdmissionArguments,
+	Logs:    DefaultSignalAdmissionArguments,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *AdmissionControlArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultAdmissionControlArguments
+
+	type arguments AdmissionControlArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.CheckInterval <= 0:
+		return fmt.Errorf("check_interval must be greater than zero")
+	case args.MemoryLimit <= 0:
+		return fmt.Errorf("limit must be greater than zero")
+	case args.MemorySpikeLimit >= args.MemoryLimit:
+		return fmt.Errorf("spike_limit must be less than limit")
+	case args.RetryAfter <= 0:
+		return fmt.Errorf("retry_after must be greater than zero")
+	}
+
+	if args.MemorySpikeLimit <= 0 {
+		args.MemorySpikeLimit = args.MemoryLimit / 5
+	}
+	return nil
+}
+
+// threshold returns the memory usage at which data of the given priority is
+// refused. Low priority data is refused once usage is within spike_limit of
+// limit, high priority data once usage reaches limit, and normal priority data
+// halfway in between.
+func (args *AdmissionControlArguments) threshold(priority string) uint64 {
+	var (
+		limit = uint64(args.MemoryLimit)
+		spike = uint64(args.MemorySpikeLimit)
+	)
+
+	switch priority {
+	case PriorityLow:
+		return limit - spike
+	case PriorityHigh:
+		return limit
+	default:
+		return limit - spike/2
+	}
+}
+
+// SignalAdmissionArguments configures admission control for a single
+// telemetry signal.
+type SignalAdmissionArguments struct {
+	Priority   string        `river:"priority,attr,optional"`
+	RetryAfter time.Duration `river:"retry_after,attr,optional"`
+}
+
+// DefaultSignalAdmissionArguments holds default settings for
+// SignalAdmissionArguments.
+var DefaultSignalAdmissionArguments = SignalAdmissionArguments{
+	Priority: PriorityNormal,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *SignalAdmissionArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultSignalAdmissionArguments
+
+	type arguments SignalAdmissionArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch args.Priority {
+	case PriorityLow, PriorityNormal, PriorityHigh:
+	default:
+		return fmt.Errorf("priority must be one of %q, %q, or %q", PriorityLow, PriorityNormal, PriorityHigh)
+	}
+	if args.RetryAfter < 0 {
+		return fmt.Errorf("retry_after must not be negative")
+	}
+	return nil
+}
+
+// admissionController decides whether received data is passed to the next
+// consumers based on the memory usage of the process.
+type admissionController struct {
+	readMemory func() uint64
+	rejected   *prometheus.CounterVec
+
+	mut       sync.Mutex
+	args      *AdmissionControlArguments // nil if admission control is disabled.
+	lastCheck time.Time
+	usage     uint64
+}
+
+func newAdmissionController(reg prometheus.Registerer) (*admissionController, error) {
+	rejected := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "otelcol_receiver_admission_rejected_requests_total",
+		Help: "Total number of requests refused because memory usage was too high.",
+	}, []string{"signal", "priority"})
+	if err := reg.Register(rejected); err != nil {
+		return nil, err
+	}
+
+	return &admissionController{
+		readMemory: heapAlloc,
+		rejected:   rejected,
+	}, nil
+}
+
+// heapAlloc returns the number of bytes of allocated heap objects, which is
+// the same measure of memory usage as otelcol.processor.memory_limiter.
+func heapAlloc() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Alloc
+}
+
+// SetArguments updates the settings of the controller. Passing nil disables
+// admission control.
+func (c *admissionController) SetArguments(args *AdmissionControlArguments) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = args
+}
+
+// Admit returns a RESOURCE_EXHAUSTED error with a retry hint if data of the
+// given signal must be refused. Memory usage is read at most once every
+// check_interval.
+func (c *admissionController) Admit(signal string, signalArgs func(*AdmissionControlArguments) SignalAdmissionArguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.args == nil {
+		return nil
+	}
+
+	if now := time.Now(); now.Sub(c.lastCheck) >= c.args.CheckInterval {
+		c.usage = c.readMemory()
+		c.lastCheck = now
+	}
+
+	sa := signalArgs(c.args)
+	threshold := c.args.threshold(sa.Priority)
+	if c.usage < threshold {
+		return nil
+	}
+	c.rejected.WithLabelValues(signal, sa.Priority).Inc()
+
+	retryAfter := sa.RetryAfter
+	if retryAfter == 0 {
+		retryAfter = c.args.RetryAfter
+	}
+
+	st := status.Newf(codes.ResourceExhausted,
+		"memory usage %s is above the limit of %s for %s priority %s, retry after %s",
+		units.Base2Bytes(c.usage), units.Base2Bytes(threshold), sa.Priority, signal, retryAfter,
+	)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func tracesAdmission(args *AdmissionControlArguments) SignalAdmissionArguments {
+	return args.Traces
+}
+
+func metricsAdmission(args *AdmissionControlArguments) SignalAdmissionArguments {
+	return args.Metrics
+}
+
+func logsAdmission(args *AdmissionControlArguments) SignalAdmissionArguments {
+	return args.Logs
+}
+
+// admittedArguments wraps Arguments so the data of every signal is passed
+// through an admissionController before the configured output.
+type admittedArguments struct {
+	Arguments
+	ctrl *admissionController
+}
+
+// NextConsumers implements receiver.Arguments.
+func (args admittedArguments) NextConsumers() *otelcol.ConsumerArguments {
+	next := args.Arguments.NextConsumers()
+	if next == nil {
+		return nil
+	}
+
+	consumer := &admissionConsumer{
+		ctrl:    args.ctrl,
+		traces:  fanoutconsumer.Traces(next.Traces),
+		metrics: fanoutconsumer.Metrics(next.Metrics),
+		logs:    fanoutconsumer.Logs(next.Logs),
+	}
+
+	var res otelcol.ConsumerArguments
+	if len(next.Traces) > 0 {
+		res.Traces = []otelcol.Consumer{consumer}
+	}
+	if len(next.Metrics) > 0 {
+		res.Metrics = []otelcol.Consumer{consumer}
+	}
+	if len(next.Logs) > 0 {
+		res.Logs = []otelcol.Consumer{consumer}
+	}
+	return &res
+}
+
+// admissionConsumer is an otelcol.Consumer which refuses data while memory
+// usage is too high.
+type admissionConsumer struct {
+	ctrl *admissionController
+
+	traces  otelconsumer.Traces
+	metrics otelconsumer.Metrics
+	logs    otelconsumer.Logs
+}
+
+var _ otelcol.Consumer = (*admissionConsumer)(nil)
+
+// Capabilities implements otelcol.Consumer.
+func (c *admissionConsumer) Capabilities() otelconsumer.Capabilities {
+	// The wrapped fanout consumers clone data for consumers which mutate it.
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelcol.Consumer.
+func (c *admissionConsumer) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	if err := c.ctrl.Admit("traces", tracesAdmission); err != nil {
+		return err
+	}
+	return c.traces.ConsumeTraces(ctx, td)
+}
+
+// ConsumeMetrics implements otelcol.Consumer.
+func (c *admissionConsumer) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	if err := c.ctrl.Admit("metrics", metricsAdmission); err != nil {
+		return err
+	}
+	return c.metrics.ConsumeMetrics(ctx, md)
+}
+
+// ConsumeLogs implements otelcol.Consumer.
+func (c *admissionConsumer) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	if err := c.ctrl.Admit("logs", logsAdmission); err != nil {
+		return err
+	}
+	return c.logs.ConsumeLogs(ctx, ld)
+}
@@ -0,0 +1,96 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdmissionControlArguments(t *testing.T) {
+	var args AdmissionControlArguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		limit = "1000MiB"
+
+		logs {
+			priority    = "low"
+			retry_after = "30s"
+		}
+	`), &args))
+
+	require.Equal(t, 200*units.MiB, args.MemorySpikeLimit)
+	require.Equal(t, PriorityNormal, args.Traces.Priority)
+	require.Equal(t, SignalAdmissionArguments{Priority: PriorityLow, RetryAfter: 30 * time.Second}, args.Logs)
+
+	err := river.Unmarshal([]byte(`
+		limit = "1GiB"
+		traces { priority = "urgent" }
+	`), &args)
+	require.ErrorContains(t, err, `priority must be one of "low", "normal", or "high"`)
+
+	err = river.Unmarshal([]byte(`
+		limit       = "1GiB"
+		spike_limit = "1GiB"
+	`), &args)
+	require.ErrorContains(t, err, "spike_limit must be less than limit")
+}
+
+func TestAdmissionController(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ctrl, err := newAdmissionController(reg)
+	require.NoError(t, err)
+
+	var usage uint64
+	ctrl.readMemory = func() uint64 { return usage }
+
+	// Admission control is disabled by default.
+	usage = 10 * 1024
+	require.NoError(t, ctrl.Admit("traces", tracesAdmission))
+
+	ctrl.SetArguments(&AdmissionControlArguments{
+		CheckInterval:    time.Nanosecond,
+		MemoryLimit:      1000,
+		MemorySpikeLimit: 200,
+		RetryAfter:       5 * time.Second,
+
+		Traces:  SignalAdmissionArguments{Priority: PriorityHigh},
+		Metrics: SignalAdmissionArguments{Priority: PriorityNormal},
+		Logs:    SignalAdmissionArguments{Priority: PriorityLow, RetryAfter: 30 * time.Second},
+	})
+
+	tt := []struct {
+		usage                 uint64
+		traces, metrics, logs bool // Whether each signal is admitted.
+	}{
+		{usage: 799, traces: true, metrics: true, logs: true},
+		{usage: 800, traces: true, metrics: true, logs: false},
+		{usage: 900, traces: true, metrics: false, logs: false},
+		{usage: 1000, traces: false, metrics: false, logs: false},
+	}
+	for _, tc := range tt {
+		usage = tc.usage
+		time.Sleep(time.Millisecond)
+
+		require.Equal(t, tc.traces, ctrl.Admit("traces", tracesAdmission) == nil, "traces at %d", tc.usage)
+		require.Equal(t, tc.metrics, ctrl.Admit("metrics", metricsAdmission) == nil, "metrics at %d", tc.usage)
+		require.Equal(t, tc.logs, ctrl.Admit("logs", logsAdmission) == nil, "logs at %d", tc.usage)
+	}
+	require.Equal(t, 3.0, testutil.ToFloat64(ctrl.rejected.WithLabelValues("logs", PriorityLow)))
+	require.Equal(t, 1.0, testutil.ToFloat64(ctrl.rejected.WithLabelValues("traces", PriorityHigh)))
+
+	// Refusals carry a retry hint, which may be overridden per signal.
+	st := status.Convert(ctrl.Admit("logs", logsAdmission))
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	require.Equal(t, 30*time.Second, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	st = status.Convert(ctrl.Admit("metrics", metricsAdmission))
+	require.Equal(t, 5*time.Second, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+}
@@ -18,22 +18,59 @@ func init() {
 		Args: Arguments{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := otlpreceiver.NewFactory()
-			return receiver.New(opts, fact, args.(Arguments))
+			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector"},
 	})
 }
 
+// Component implements the otelcol.receiver.otlp component. It wraps the
+// upstream receiver to apply admission control to received data.
+type Component struct {
+	*receiver.Receiver
+
+	admission *admissionController
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new otelcol.receiver.otlp component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	admission, err := newAdmissionController(opts.Registerer)
+	if err != nil {
+		return nil, err
+	}
+	admission.SetArguments(args.AdmissionControl)
+
+	fact := otlpreceiver.NewFactory()
+	r, err := receiver.New(opts, fact, admittedArguments{Arguments: args, ctrl: admission})
+	if err != nil {
+		return nil, err
+	}
+	return &Component{Receiver: r, admission: admission}, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.admission.SetArguments(newArgs.AdmissionControl)
+	return c.Receiver.Update(admittedArguments{Arguments: newArgs, ctrl: c.admission})
+}
+
 // Arguments configures the otelcol.receiver.otlp component.
 type Arguments struct {
 	GRPC *GRPCServerArguments `river:"grpc,block,optional"`
 	HTTP *HTTPServerArguments `river:"http,block,optional"`
 
+	// AdmissionControl refuses received data while memory usage is too high.
+	AdmissionControl *AdmissionControlArguments `river:"admission_control,block,optional"`
+
 	// Output configures where to send received data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
 
-var _ receiver.Arguments = Arguments{}
+var _ receiver.ListenerArguments = Arguments{}
 
 // Convert implements receiver.Arguments.
 func (args Arguments) Convert() (otelconfig.Receiver, error) {
@@ -61,6 +98,18 @@ func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
 	return args.Output
 }
 
+// ListenAddresses implements receiver.ListenerArguments.
+func (args Arguments) ListenAddresses() []string {
+	var addrs []string
+	if args.GRPC != nil {
+		addrs = append(addrs, args.GRPC.Endpoint)
+	}
+	if args.HTTP != nil {
+		addrs = append(addrs, args.HTTP.Endpoint)
+	}
+	return addrs
+}
+
 type (
 	// GRPCServerArguments is used to configure otelcol.receiver.otlp with
 	// component-specific defaults.
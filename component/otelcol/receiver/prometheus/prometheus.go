@@ -32,6 +32,8 @@ func init() {
 		Build: func(o component.Options, a component.Arguments) (component.Component, error) {
 			return NewComponent(o, a.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/prometheus/prometheus"},
 	})
 }
 
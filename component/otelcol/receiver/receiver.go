@@ -6,13 +6,16 @@ import (
 	"context"
 	"errors"
 	"os"
+	"sync"
 
+	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
 	"github.com/grafana/agent/component/otelcol/internal/lazycollector"
 	"github.com/grafana/agent/component/otelcol/internal/scheduler"
 	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/util/netwatch"
 	"github.com/grafana/agent/pkg/util/zapadapter"
 	"github.com/prometheus/client_golang/prometheus"
 	otelcomponent "go.opentelemetry.io/collector/component"
@@ -44,6 +47,18 @@ type Arguments interface {
 	NextConsumers() *otelcol.ConsumerArguments
 }
 
+// ListenerArguments is an optional extension of Arguments for receivers which
+// listen on network addresses. Receivers are restarted when the addresses of
+// the host change if any of the addresses they listen on is a specific
+// address, so that they don't keep listening on addresses which have gone
+// away.
+type ListenerArguments interface {
+	Arguments
+
+	// ListenAddresses returns the addresses the receiver listens on.
+	ListenAddresses() []string
+}
+
 // Receiver is a Flow component shim which manages an OpenTelemetry Collector
 // receiver component.
 type Receiver struct {
@@ -55,6 +70,10 @@ type Receiver struct {
 
 	sched     *scheduler.Scheduler
 	collector *lazycollector.Collector
+
+	mut      sync.Mutex
+	args     Arguments
+	netState netwatch.State // State of the listen addresses when the receiver was started.
 }
 
 var (
@@ -96,15 +115,61 @@ func New(opts component.Options, f otelcomponent.ReceiverFactory, args Arguments
 // Run starts the Receiver component.
 func (r *Receiver) Run(ctx context.Context) error {
 	defer r.cancel()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go r.watchNetwork(ctx)
+
 	return r.sched.Run(ctx)
 }
 
+// watchNetwork restarts the receiver whenever one of its specific listen
+// addresses is added to or removed from the host.
+func (r *Receiver) watchNetwork(ctx context.Context) {
+	netChanges, unsubscribe := netwatch.Default().Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-netChanges:
+			r.mut.Lock()
+			args, netState := r.args, r.netState
+			r.mut.Unlock()
+
+			if listenState(args) == netState {
+				continue
+			}
+
+			level.Info(r.opts.Logger).Log("msg", "listen addresses changed, restarting receiver")
+			if err := r.Update(args); err != nil {
+				level.Error(r.opts.Logger).Log("msg", "failed to restart receiver", "err", err)
+			}
+		}
+	}
+}
+
+// listenState returns the netwatch.State of the addresses args listens on.
+func listenState(args Arguments) netwatch.State {
+	largs, ok := args.(ListenerArguments)
+	if !ok {
+		return ""
+	}
+	return netwatch.Default().State(largs.ListenAddresses())
+}
+
 // Update implements component.Component. It will convert the Arguments into
 // configuration for OpenTelemetry Collector receiver configuration and manage
 // the underlying OpenTelemetry Collector receiver.
 func (r *Receiver) Update(args component.Arguments) error {
 	rargs := args.(Arguments)
 
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.args = rargs
+	r.netState = listenState(rargs)
+
 	host := scheduler.NewHost(
 		r.opts.Logger,
 		scheduler.WithHostExtensions(rargs.Extensions()),
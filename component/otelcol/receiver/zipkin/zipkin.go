@@ -20,6 +20,8 @@ func init() {
 			fact := zipkinreceiver.NewFactory()
 			return receiver.New(opts, fact, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/zipkinreceiver"},
 	})
 }
 
@@ -34,8 +36,8 @@ type Arguments struct {
 }
 
 var (
-	_ receiver.Arguments = Arguments{}
-	_ river.Unmarshaler  = (*Arguments)(nil)
+	_ receiver.ListenerArguments = Arguments{}
+	_ river.Unmarshaler          = (*Arguments)(nil)
 )
 
 // DefaultArguments holds default settings for otelcol.receiver.zipkin.
@@ -77,3 +79,8 @@ func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.Compone
 func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
 	return args.Output
 }
+
+// ListenAddresses implements receiver.ListenerArguments.
+func (args Arguments) ListenAddresses() []string {
+	return []string{args.HTTPServer.Endpoint}
+}
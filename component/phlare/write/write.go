@@ -41,6 +41,8 @@ func init() {
 		Build: func(o component.Options, c component.Arguments) (component.Component, error) {
 			return NewComponent(o, c.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/grafana/phlare/api"},
 	})
 }
 
@@ -13,6 +13,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "apache"),
+
+		UpstreamModules: []string{"github.com/Lusitaniae/apache_exporter"},
 	})
 }
 
@@ -16,6 +16,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.NewMultiTarget(createExporter, "blackbox", buildBlackboxTargets),
+
+		UpstreamModules: []string{"github.com/prometheus/blackbox_exporter"},
 	})
 }
 
@@ -0,0 +1,152 @@
+package bmc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/bmc_exporter"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.bmc",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewMultiTarget(createExporter, "bmc", buildBMCTargets),
+
+		UpstreamModules: []string{"github.com/prometheus/common"},
+	})
+}
+
+// Labels of discovery targets which override the default settings for a
+// single BMC.
+const (
+	usernameLabel = "__bmc_username__"
+	passwordLabel = "__bmc_password__"
+	protocolLabel = "__bmc_protocol__"
+)
+
+// DefaultArguments holds the default arguments for the prometheus.exporter.bmc
+// component.
+var DefaultArguments = Arguments{
+	Protocol:     bmc_exporter.ProtocolRedfish,
+	Timeout:      bmc_exporter.DefaultConfig.Timeout,
+	IPMIToolPath: bmc_exporter.DefaultConfig.IPMIToolPath,
+}
+
+// Arguments configures the prometheus.exporter.bmc component.
+type Arguments struct {
+	Targets      []discovery.Target `river:"targets,attr"`
+	Username     string             `river:"username,attr,optional"`
+	Password     rivertypes.Secret  `river:"password,attr,optional"`
+	Protocol     string             `river:"protocol,attr,optional"`
+	Timeout      time.Duration      `river:"timeout,attr,optional"`
+	IPMIToolPath string             `river:"ipmitool_path,attr,optional"`
+	TLSConfig    config.TLSConfig   `river:"tls_config,block,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+
+	if err := validateProtocol(a.Protocol); err != nil {
+		return err
+	}
+	if a.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	for _, t := range a.Targets {
+		if t[model.AddressLabel] == "" {
+			return fmt.Errorf("every target must have a %s label", model.AddressLabel)
+		}
+		if p, ok := t[protocolLabel]; ok {
+			if err := validateProtocol(p); err != nil {
+				return fmt.Errorf("target %s: %w", t[model.AddressLabel], err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateProtocol(p string) error {
+	switch p {
+	case bmc_exporter.ProtocolRedfish, bmc_exporter.ProtocolIPMI:
+		return nil
+	default:
+		return fmt.Errorf("unsupported protocol %q, must be %q or %q", p, bmc_exporter.ProtocolRedfish, bmc_exporter.ProtocolIPMI)
+	}
+}
+
+// buildBMCTargets creates a target for every BMC. Credentials aren't copied
+// to the targets; they're looked up by address when the target is scraped.
+func buildBMCTargets(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+
+	targets := make([]discovery.Target, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		target := make(discovery.Target, len(baseTarget)+len(t))
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+		for k, v := range t {
+			if !strings.HasPrefix(k, model.ReservedLabelPrefix) {
+				target[k] = v
+			}
+		}
+		target["instance"] = t[model.AddressLabel]
+		target["__param_target"] = t[model.AddressLabel]
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+// Labels of a target override the default credentials and protocol for that
+// BMC.
+func (a *Arguments) Convert() *bmc_exporter.Config {
+	bmcs := make([]bmc_exporter.BMC, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		b := bmc_exporter.BMC{
+			Address:  t[model.AddressLabel],
+			Username: a.Username,
+			Password: config_util.Secret(a.Password),
+			Protocol: a.Protocol,
+		}
+		if v, ok := t[usernameLabel]; ok {
+			b.Username = v
+		}
+		if v, ok := t[passwordLabel]; ok {
+			b.Password = config_util.Secret(v)
+		}
+		if v, ok := t[protocolLabel]; ok {
+			b.Protocol = v
+		}
+		bmcs = append(bmcs, b)
+	}
+
+	return &bmc_exporter.Config{
+		BMCs:         bmcs,
+		Timeout:      a.Timeout,
+		IPMIToolPath: a.IPMIToolPath,
+		TLSConfig:    *a.TLSConfig.Convert(),
+	}
+}
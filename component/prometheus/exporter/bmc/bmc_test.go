@@ -0,0 +1,49 @@
+package bmc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/bmc_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		targets  = [{"__address__" = "10.0.0.1"}, {"__address__" = "10.0.0.2", "__bmc_protocol__" = "ipmi"}]
+		username = "admin"
+		password = "secret"
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, bmc_exporter.ProtocolRedfish, args.Protocol)
+	require.Equal(t, 10*time.Second, args.Timeout)
+	require.Equal(t, []bmc_exporter.BMC{
+		{Address: "10.0.0.1", Username: "admin", Password: "secret", Protocol: bmc_exporter.ProtocolRedfish},
+		{Address: "10.0.0.2", Username: "admin", Password: "secret", Protocol: bmc_exporter.ProtocolIPMI},
+	}, args.Convert().BMCs)
+
+	var invalid Arguments
+	err := river.Unmarshal([]byte(`
+		targets = [{"__address__" = "10.0.0.1", "__bmc_protocol__" = "snmp"}]
+	`), &invalid)
+	require.EqualError(t, err, `target 10.0.0.1: unsupported protocol "snmp", must be "redfish" or "ipmi"`)
+}
+
+func TestBuildBMCTargets(t *testing.T) {
+	base := discovery.Target{"job": "integrations/bmc", "instance": "prometheus.exporter.bmc.default"}
+	args := Arguments{Targets: []discovery.Target{{
+		"__address__":      "10.0.0.1",
+		"__bmc_password__": "secret",
+		"rack":             "r12",
+	}}}
+
+	require.Equal(t, []discovery.Target{{
+		"job":            "integrations/bmc",
+		"instance":       "10.0.0.1",
+		"rack":           "r12",
+		"__param_target": "10.0.0.1",
+	}}, buildBMCTargets(base, args))
+}
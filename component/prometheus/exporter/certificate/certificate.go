@@ -0,0 +1,128 @@
+package certificate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/certificate_exporter"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.certificate",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewMultiTarget(createExporter, "certificate", buildCertificateTargets),
+
+		UpstreamModules: []string{"github.com/prometheus/common"},
+	})
+}
+
+// Labels of discovery targets which override the default settings for a
+// single endpoint.
+const (
+	serverNameLabel = "__tls_server_name__"
+	startTLSLabel   = "__tls_starttls__"
+)
+
+// DefaultArguments holds the default arguments for the
+// prometheus.exporter.certificate component.
+var DefaultArguments = Arguments{
+	Timeout: certificate_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments configures the prometheus.exporter.certificate component.
+type Arguments struct {
+	Targets   []discovery.Target `river:"targets,attr"`
+	StartTLS  string             `river:"starttls,attr,optional"`
+	Timeout   time.Duration      `river:"timeout,attr,optional"`
+	TLSConfig config.TLSConfig   `river:"tls_config,block,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+
+	if err := certificate_exporter.ValidateStartTLS(a.StartTLS); err != nil {
+		return err
+	}
+	if a.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	for _, t := range a.Targets {
+		if t[model.AddressLabel] == "" {
+			return fmt.Errorf("every target must have a %s label", model.AddressLabel)
+		}
+		if p, ok := t[startTLSLabel]; ok {
+			if err := certificate_exporter.ValidateStartTLS(p); err != nil {
+				return fmt.Errorf("target %s: %w", t[model.AddressLabel], err)
+			}
+		}
+	}
+	return nil
+}
+
+// buildCertificateTargets creates a target for every endpoint.
+func buildCertificateTargets(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+
+	targets := make([]discovery.Target, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		target := make(discovery.Target, len(baseTarget)+len(t))
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+		for k, v := range t {
+			if !strings.HasPrefix(k, model.ReservedLabelPrefix) {
+				target[k] = v
+			}
+		}
+		target["instance"] = t[model.AddressLabel]
+		target["__param_target"] = t[model.AddressLabel]
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+// Labels of a target override the default server name and STARTTLS protocol
+// for that endpoint.
+func (a *Arguments) Convert() *certificate_exporter.Config {
+	endpoints := make([]certificate_exporter.Endpoint, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		e := certificate_exporter.Endpoint{
+			Address:  t[model.AddressLabel],
+			StartTLS: a.StartTLS,
+		}
+		if v, ok := t[serverNameLabel]; ok {
+			e.ServerName = v
+		}
+		if v, ok := t[startTLSLabel]; ok {
+			e.StartTLS = v
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	return &certificate_exporter.Config{
+		Endpoints: endpoints,
+		Timeout:   a.Timeout,
+		TLSConfig: *a.TLSConfig.Convert(),
+	}
+}
@@ -0,0 +1,57 @@
+package certificate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/certificate_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		targets = [
+			{"__address__" = "example.com"},
+			{"__address__" = "mail.example.com:25", "__tls_starttls__" = "smtp"},
+			{"__address__" = "10.0.0.1:443", "__tls_server_name__" = "internal.example.com"},
+		]
+
+		tls_config {
+			server_name = "www.example.com"
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, 10*time.Second, args.Timeout)
+	require.Equal(t, []certificate_exporter.Endpoint{
+		{Address: "example.com"},
+		{Address: "mail.example.com:25", StartTLS: certificate_exporter.StartTLSSMTP},
+		{Address: "10.0.0.1:443", ServerName: "internal.example.com"},
+	}, args.Convert().Endpoints)
+	require.Equal(t, "www.example.com", args.Convert().TLSConfig.ServerName)
+
+	var invalid Arguments
+	err := river.Unmarshal([]byte(`
+		targets  = [{"__address__" = "example.com:389"}]
+		starttls = "ldap"
+	`), &invalid)
+	require.EqualError(t, err, `unsupported starttls protocol "ldap", must be one of "smtp", "imap", "pop3", "ftp", or "postgres"`)
+}
+
+func TestBuildCertificateTargets(t *testing.T) {
+	base := discovery.Target{"job": "integrations/certificate", "instance": "prometheus.exporter.certificate.default"}
+	args := Arguments{Targets: []discovery.Target{{
+		"__address__":         "example.com:443",
+		"__tls_server_name__": "www.example.com",
+		"team":                "web",
+	}}}
+
+	require.Equal(t, []discovery.Target{{
+		"job":            "integrations/certificate",
+		"instance":       "example.com:443",
+		"team":           "web",
+		"__param_target": "example.com:443",
+	}}, buildCertificateTargets(base, args))
+}
@@ -15,6 +15,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "consul"),
+
+		UpstreamModules: []string{"github.com/prometheus/consul_exporter"},
 	})
 }
 
@@ -0,0 +1,39 @@
+package dcgm
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/dcgm_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "prometheus.exporter.dcgm",
+		Args:      Arguments{},
+		Exports:   exporter.Exports{},
+		Singleton: true,
+		Build:     exporter.New(createExporter, "dcgm"),
+
+		UpstreamModules: []string{"github.com/mindprince/gonvml"},
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// Arguments configures the prometheus.exporter.dcgm component.
+type Arguments struct {
+	// Devices limits the GPUs to collect metrics from, identified by index or
+	// UUID. All GPUs are used when empty.
+	Devices []string `river:"devices,attr,optional"`
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *dcgm_exporter.Config {
+	return &dcgm_exporter.Config{
+		Devices: a.Devices,
+	}
+}
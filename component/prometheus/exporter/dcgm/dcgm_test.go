@@ -0,0 +1,23 @@
+package dcgm
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/integrations/dcgm_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		devices = ["0", "GPU-6a2c"]
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, Arguments{Devices: []string{"0", "GPU-6a2c"}}, args)
+}
+
+func TestConvert(t *testing.T) {
+	args := Arguments{Devices: []string{"0"}}
+	require.Equal(t, &dcgm_exporter.Config{Devices: []string{"0"}}, args.Convert())
+}
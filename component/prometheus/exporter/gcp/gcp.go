@@ -0,0 +1,71 @@
+package gcp
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/gcp_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.gcp",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "gcp"),
+
+		UpstreamModules: []string{"github.com/prometheus-community/stackdriver_exporter"},
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds non-zero default options for Arguments when it is
+// unmarshaled from river.
+var DefaultArguments = Arguments{
+	ClientTimeout:         gcp_exporter.DefaultConfig.ClientTimeout,
+	RequestInterval:       gcp_exporter.DefaultConfig.RequestInterval,
+	RequestOffset:         gcp_exporter.DefaultConfig.RequestOffset,
+	IngestDelay:           gcp_exporter.DefaultConfig.IngestDelay,
+	DropDelegatedProjects: gcp_exporter.DefaultConfig.DropDelegatedProjects,
+}
+
+type Arguments struct {
+	ProjectIDs            []string      `river:"project_ids,attr"`
+	MetricPrefixes        []string      `river:"metrics_prefixes,attr"`
+	ExtraFilters          []string      `river:"extra_filters,attr,optional"`
+	RequestInterval       time.Duration `river:"request_interval,attr,optional"`
+	RequestOffset         time.Duration `river:"request_offset,attr,optional"`
+	IngestDelay           bool          `river:"ingest_delay,attr,optional"`
+	DropDelegatedProjects bool          `river:"drop_delegated_projects,attr,optional"`
+	ClientTimeout         time.Duration `river:"gcp_client_timeout,attr,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+	return a.Convert().Validate()
+}
+
+func (a *Arguments) Convert() *gcp_exporter.Config {
+	return &gcp_exporter.Config{
+		ProjectIDs:            a.ProjectIDs,
+		MetricPrefixes:        a.MetricPrefixes,
+		ExtraFilters:          a.ExtraFilters,
+		RequestInterval:       a.RequestInterval,
+		RequestOffset:         a.RequestOffset,
+		IngestDelay:           a.IngestDelay,
+		DropDelegatedProjects: a.DropDelegatedProjects,
+		ClientTimeout:         a.ClientTimeout,
+	}
+}
@@ -0,0 +1,80 @@
+package gcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/integrations/gcp_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	riverCfg := `
+		project_ids      = ["project-a", "project-b"]
+		metrics_prefixes = ["loadbalancing.googleapis.com/https/request_bytes_count", "pubsub.googleapis.com/subscription"]
+		extra_filters    = ["pubsub.googleapis.com/subscription:resource.labels.subscription_id=monitoring.regex.full_match(\"my-subs-prefix.*\")"]
+		request_offset   = "1m"
+		ingest_delay     = true
+`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverCfg), &args)
+	require.NoError(t, err)
+
+	expected := Arguments{
+		ProjectIDs:      []string{"project-a", "project-b"},
+		MetricPrefixes:  []string{"loadbalancing.googleapis.com/https/request_bytes_count", "pubsub.googleapis.com/subscription"},
+		ExtraFilters:    []string{`pubsub.googleapis.com/subscription:resource.labels.subscription_id=monitoring.regex.full_match("my-subs-prefix.*")`},
+		RequestInterval: 5 * time.Minute,
+		RequestOffset:   time.Minute,
+		IngestDelay:     true,
+		ClientTimeout:   15 * time.Second,
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestUnmarshalRiver_Invalid(t *testing.T) {
+	tt := []struct {
+		name     string
+		riverCfg string
+	}{
+		{
+			name:     "missing metrics_prefixes",
+			riverCfg: `project_ids = ["project-a"]`,
+		},
+		{
+			name: "extra_filters without matching prefix",
+			riverCfg: `
+				project_ids      = ["project-a"]
+				metrics_prefixes = ["pubsub.googleapis.com/subscription"]
+				extra_filters    = ["compute.googleapis.com/instance:resource.labels.zone=\"us-east1-b\""]
+			`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.Error(t, river.Unmarshal([]byte(tc.riverCfg), &args))
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	args := Arguments{
+		ProjectIDs:            []string{"project-a"},
+		MetricPrefixes:        []string{"pubsub.googleapis.com/subscription"},
+		RequestInterval:       time.Minute,
+		DropDelegatedProjects: true,
+		ClientTimeout:         time.Second,
+	}
+
+	expected := &gcp_exporter.Config{
+		ProjectIDs:            []string{"project-a"},
+		MetricPrefixes:        []string{"pubsub.googleapis.com/subscription"},
+		RequestInterval:       time.Minute,
+		DropDelegatedProjects: true,
+		ClientTimeout:         time.Second,
+	}
+	require.Equal(t, expected, args.Convert())
+}
@@ -15,6 +15,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "github"),
+
+		UpstreamModules: []string{"github.com/infinityworks/github-exporter"},
 	})
 }
 
@@ -0,0 +1,135 @@
+package jmx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/jmx_exporter"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.jmx",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewMultiTarget(createExporter, "jmx", buildJMXTargets),
+
+		UpstreamModules: []string{"github.com/prometheus/common"},
+	})
+}
+
+// Labels of discovery targets which override the default settings for a
+// single JVM.
+const (
+	usernameLabel = "__jmx_username__"
+	passwordLabel = "__jmx_password__"
+)
+
+// DefaultArguments holds the default arguments for the prometheus.exporter.jmx
+// component.
+var DefaultArguments = Arguments{
+	Scheme:      jmx_exporter.DefaultConfig.Scheme,
+	JolokiaPath: jmx_exporter.DefaultConfig.JolokiaPath,
+	Timeout:     jmx_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments configures the prometheus.exporter.jmx component.
+type Arguments struct {
+	Targets     []discovery.Target `river:"targets,attr"`
+	ConfigFile  string             `river:"config_file,attr,optional"`
+	Username    string             `river:"username,attr,optional"`
+	Password    rivertypes.Secret  `river:"password,attr,optional"`
+	Scheme      string             `river:"scheme,attr,optional"`
+	JolokiaPath string             `river:"jolokia_path,attr,optional"`
+	Timeout     time.Duration      `river:"timeout,attr,optional"`
+	TLSConfig   config.TLSConfig   `river:"tls_config,block,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+
+	if a.Scheme != "http" && a.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if a.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	for _, t := range a.Targets {
+		if t[model.AddressLabel] == "" {
+			return fmt.Errorf("every target must have a %s label", model.AddressLabel)
+		}
+	}
+	return nil
+}
+
+// buildJMXTargets creates a target for every JVM. Credentials aren't copied
+// to the targets; they're looked up by address when the target is scraped.
+func buildJMXTargets(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+
+	targets := make([]discovery.Target, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		target := make(discovery.Target, len(baseTarget)+len(t))
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+		for k, v := range t {
+			if !strings.HasPrefix(k, model.ReservedLabelPrefix) {
+				target[k] = v
+			}
+		}
+		target["instance"] = t[model.AddressLabel]
+		target["__param_target"] = t[model.AddressLabel]
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+// Labels of a target override the default credentials for that JVM.
+func (a *Arguments) Convert() *jmx_exporter.Config {
+	targets := make([]jmx_exporter.Target, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		tgt := jmx_exporter.Target{
+			Address:  t[model.AddressLabel],
+			Username: a.Username,
+			Password: config_util.Secret(a.Password),
+		}
+		if v, ok := t[usernameLabel]; ok {
+			tgt.Username = v
+		}
+		if v, ok := t[passwordLabel]; ok {
+			tgt.Password = config_util.Secret(v)
+		}
+		targets = append(targets, tgt)
+	}
+
+	return &jmx_exporter.Config{
+		Targets:     targets,
+		ConfigFile:  a.ConfigFile,
+		Scheme:      a.Scheme,
+		JolokiaPath: a.JolokiaPath,
+		Timeout:     a.Timeout,
+		TLSConfig:   *a.TLSConfig.Convert(),
+	}
+}
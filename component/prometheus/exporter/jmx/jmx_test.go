@@ -0,0 +1,50 @@
+package jmx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/jmx_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		targets     = [{"__address__" = "app1:8778"}, {"__address__" = "app2:8778", "__jmx_username__" = "other"}]
+		config_file = "/etc/agent/jmx.yaml"
+		username    = "jolokia"
+		password    = "secret"
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, 10*time.Second, args.Timeout)
+	require.Equal(t, []jmx_exporter.Target{
+		{Address: "app1:8778", Username: "jolokia", Password: "secret"},
+		{Address: "app2:8778", Username: "other", Password: "secret"},
+	}, args.Convert().Targets)
+
+	var invalid Arguments
+	err := river.Unmarshal([]byte(`
+		targets = [{"__address__" = "app1:8778"}]
+		scheme  = "rmi"
+	`), &invalid)
+	require.EqualError(t, err, "scheme must be http or https")
+}
+
+func TestBuildJMXTargets(t *testing.T) {
+	base := discovery.Target{"job": "integrations/jmx", "instance": "prometheus.exporter.jmx.default"}
+	args := Arguments{Targets: []discovery.Target{{
+		"__address__":      "app1:8778",
+		"__jmx_password__": "secret",
+		"service":          "orders",
+	}}}
+
+	require.Equal(t, []discovery.Target{{
+		"job":            "integrations/jmx",
+		"instance":       "app1:8778",
+		"service":        "orders",
+		"__param_target": "app1:8778",
+	}}, buildJMXTargets(base, args))
+}
@@ -15,6 +15,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "memcached"),
+
+		UpstreamModules: []string{"github.com/prometheus/memcached_exporter"},
 	})
 }
 
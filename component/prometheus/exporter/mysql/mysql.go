@@ -15,6 +15,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "mysql"),
+
+		UpstreamModules: []string{"github.com/prometheus/mysqld_exporter"},
 	})
 }
 
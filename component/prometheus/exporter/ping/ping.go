@@ -0,0 +1,120 @@
+package ping
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/ping_exporter"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.ping",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewMultiTarget(createExporter, "ping", buildPingTargets),
+
+		UpstreamModules: []string{"github.com/prometheus-community/pro-bing"},
+	})
+}
+
+// DefaultArguments holds the default arguments for the
+// prometheus.exporter.ping component.
+var DefaultArguments = Arguments{
+	Interval:   ping_exporter.DefaultConfig.Interval,
+	Count:      ping_exporter.DefaultConfig.Count,
+	Size:       ping_exporter.DefaultConfig.Size,
+	Timeout:    ping_exporter.DefaultConfig.Timeout,
+	RTTBuckets: ping_exporter.DefaultConfig.RTTBuckets,
+}
+
+// Arguments configures the prometheus.exporter.ping component.
+type Arguments struct {
+	Targets    []discovery.Target `river:"targets,attr"`
+	Interval   time.Duration      `river:"interval,attr,optional"`
+	Count      int                `river:"count,attr,optional"`
+	Size       int                `river:"size,attr,optional"`
+	Timeout    time.Duration      `river:"timeout,attr,optional"`
+	Privileged bool               `river:"privileged,attr,optional"`
+	RTTBuckets []float64          `river:"rtt_buckets,attr,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+
+	switch {
+	case a.Interval <= 0:
+		return fmt.Errorf("interval must be greater than 0")
+	case a.Timeout <= 0 || a.Timeout > a.Interval:
+		return fmt.Errorf("timeout must be greater than 0 and at most interval")
+	case a.Count <= 0:
+		return fmt.Errorf("count must be greater than 0")
+	case a.Size < ping_exporter.MinSize || a.Size > 65500:
+		return fmt.Errorf("size must be between %d and 65500", ping_exporter.MinSize)
+	case len(a.RTTBuckets) == 0:
+		return fmt.Errorf("rtt_buckets must not be empty")
+	}
+	for _, t := range a.Targets {
+		if t[model.AddressLabel] == "" {
+			return fmt.Errorf("every target must have a %s label", model.AddressLabel)
+		}
+	}
+	return nil
+}
+
+// buildPingTargets creates a target for every host to ping.
+func buildPingTargets(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+
+	targets := make([]discovery.Target, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		target := make(discovery.Target, len(baseTarget)+len(t))
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+		for k, v := range t {
+			if !strings.HasPrefix(k, model.ReservedLabelPrefix) {
+				target[k] = v
+			}
+		}
+		target["instance"] = t[model.AddressLabel]
+		target["__param_target"] = t[model.AddressLabel]
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *ping_exporter.Config {
+	targets := make([]string, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		targets = append(targets, t[model.AddressLabel])
+	}
+
+	return &ping_exporter.Config{
+		Targets:    targets,
+		Interval:   a.Interval,
+		Count:      a.Count,
+		Size:       a.Size,
+		Timeout:    a.Timeout,
+		Privileged: a.Privileged,
+		RTTBuckets: a.RTTBuckets,
+	}
+}
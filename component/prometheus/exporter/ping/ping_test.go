@@ -0,0 +1,55 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		targets = [{"__address__" = "example.com"}, {"__address__" = "10.0.0.1:9100"}]
+		count   = 3
+		timeout = "500ms"
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, time.Second, args.Interval)
+	require.Equal(t, 500*time.Millisecond, args.Timeout)
+	require.Equal(t, 3, args.Count)
+	require.Equal(t, 56, args.Size)
+	require.Equal(t, DefaultArguments.RTTBuckets, args.RTTBuckets)
+
+	var invalid Arguments
+	err := river.Unmarshal([]byte(`
+		targets  = [{"__address__" = "example.com"}]
+		interval = "1s"
+		timeout  = "2s"
+	`), &invalid)
+	require.EqualError(t, err, "timeout must be greater than 0 and at most interval")
+
+	err = river.Unmarshal([]byte(`
+		targets = [{"__address__" = "example.com"}]
+		size    = 8
+	`), &invalid)
+	require.EqualError(t, err, "size must be between 24 and 65500")
+}
+
+func TestBuildPingTargets(t *testing.T) {
+	base := discovery.Target{"job": "integrations/ping", "instance": "prometheus.exporter.ping.default"}
+	args := Arguments{Targets: []discovery.Target{{
+		"__address__":      "10.0.0.1",
+		"__meta_consul_dc": "dc1",
+		"datacenter":       "eu-west",
+	}}}
+
+	require.Equal(t, []discovery.Target{{
+		"job":            "integrations/ping",
+		"instance":       "10.0.0.1",
+		"datacenter":     "eu-west",
+		"__param_target": "10.0.0.1",
+	}}, buildPingTargets(base, args))
+}
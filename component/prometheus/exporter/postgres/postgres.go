@@ -15,6 +15,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "postgres"),
+
+		UpstreamModules: []string{"github.com/prometheus-community/postgres_exporter"},
 	})
 }
 
@@ -14,6 +14,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createIntegration, "process"),
+
+		UpstreamModules: []string{"github.com/ncabatoff/process-exporter"},
 	})
 }
 
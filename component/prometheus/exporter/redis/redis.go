@@ -19,6 +19,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "redis"),
+
+		UpstreamModules: []string{"github.com/oliver006/redis_exporter"},
 	})
 }
 
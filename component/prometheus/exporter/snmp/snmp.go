@@ -1,6 +1,7 @@
 package snmp
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/grafana/agent/component"
@@ -18,6 +19,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.NewMultiTarget(createExporter, "snmp", buildSNMPTargets),
+
+		UpstreamModules: []string{"github.com/prometheus/snmp_exporter"},
 	})
 }
 
@@ -54,10 +57,14 @@ func buildSNMPTargets(baseTarget discovery.Target, args component.Arguments) []d
 
 // SNMPTarget defines a target to be used by the exporter.
 type SNMPTarget struct {
-	Name       string `river:",label"`
-	Target     string `river:"address,attr"`
-	Module     string `river:"module,attr,optional"`
-	WalkParams string `river:"walk_params,attr,optional"`
+	Name        string        `river:",label"`
+	Target      string        `river:"address,attr"`
+	Module      string        `river:"module,attr,optional"`
+	WalkParams  string        `river:"walk_params,attr,optional"`
+	Concurrency int           `river:"concurrency,attr,optional"`
+	Timeout     time.Duration `river:"timeout,attr,optional"`
+	Retries     int           `river:"retries,attr,optional"`
+	Auth        *Auth         `river:"auth,block,optional"`
 }
 
 type TargetBlock []SNMPTarget
@@ -66,11 +73,20 @@ type TargetBlock []SNMPTarget
 func (t TargetBlock) Convert() []snmp_exporter.SNMPTarget {
 	targets := make([]snmp_exporter.SNMPTarget, 0, len(t))
 	for _, target := range t {
+		var auth *snmp_config.Auth
+		if target.Auth != nil {
+			converted := target.Auth.Convert()
+			auth = &converted
+		}
 		targets = append(targets, snmp_exporter.SNMPTarget{
-			Name:       target.Name,
-			Target:     target.Target,
-			Module:     target.Module,
-			WalkParams: target.WalkParams,
+			Name:        target.Name,
+			Target:      target.Target,
+			Module:      target.Module,
+			WalkParams:  target.WalkParams,
+			Concurrency: target.Concurrency,
+			Timeout:     target.Timeout,
+			Retries:     target.Retries,
+			Auth:        auth,
 		})
 	}
 	return targets
@@ -138,7 +154,22 @@ type Arguments struct {
 // UnmarshalRiver implements River unmarshalling for Arguments.
 func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	type args Arguments
-	return f((*args)(a))
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+
+	for _, t := range a.Targets {
+		if t.Concurrency < 0 {
+			return fmt.Errorf("target %q: concurrency must not be negative", t.Name)
+		}
+		if t.Timeout < 0 {
+			return fmt.Errorf("target %q: timeout must not be negative", t.Name)
+		}
+		if t.Retries < 0 {
+			return fmt.Errorf("target %q: retries must not be negative", t.Name)
+		}
+	}
+	return nil
 }
 
 // Convert converts the component's Arguments to the integration's Config.
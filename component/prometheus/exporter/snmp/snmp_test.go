@@ -154,3 +154,58 @@ func TestBuildSNMPTargets(t *testing.T) {
 	require.Equal(t, "if_mib", targets[0]["__param_module"])
 	require.Equal(t, "public", targets[0]["__param_walk_params"])
 }
+
+func TestUnmarshalRiver_TargetSettings(t *testing.T) {
+	riverCfg := `
+		config_file = "modules.yml"
+		target "network_switch_1" {
+			address     = "192.168.1.2"
+			module      = "if_mib,system"
+			concurrency = 2
+			timeout     = "10s"
+			retries     = 5
+
+			auth {
+				security_level = "authPriv"
+				username       = "monitor"
+				password       = "auth-secret"
+				auth_protocol  = "SHA"
+				priv_protocol  = "AES"
+				priv_password  = "priv-secret"
+			}
+		}
+		target "network_router_2" {
+			address = "192.168.1.3"
+		}
+`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+
+	res := args.Targets.Convert()
+	require.Equal(t, 2, len(res))
+	require.Equal(t, "if_mib,system", res[0].Module)
+	require.Equal(t, 2, res[0].Concurrency)
+	require.Equal(t, 10*time.Second, res[0].Timeout)
+	require.Equal(t, 5, res[0].Retries)
+	require.Equal(t, &snmp_config.Auth{
+		SecurityLevel: "authPriv",
+		Username:      "monitor",
+		Password:      "auth-secret",
+		AuthProtocol:  "SHA",
+		PrivProtocol:  "AES",
+		PrivPassword:  "priv-secret",
+	}, res[0].Auth)
+
+	require.Nil(t, res[1].Auth)
+	require.Equal(t, 0, res[1].Concurrency)
+
+	invalidCfg := `
+		config_file = "modules.yml"
+		target "network_switch_1" {
+			address     = "192.168.1.2"
+			concurrency = -1
+		}
+`
+	var invalid Arguments
+	require.EqualError(t, river.Unmarshal([]byte(invalidCfg), &invalid), `target "network_switch_1": concurrency must not be negative`)
+}
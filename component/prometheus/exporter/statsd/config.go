@@ -87,22 +87,20 @@ func (c *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 
 // function to read a yaml file from a path and convert it to a mapper.MappingConfig
 // this is used to convert the MappingConfig field in to a mapper.MappingConfig
-// which is used by the statsd_exporter
+// which is used by the statsd_exporter. No mapper is returned if path is empty.
 func readMappingFromYAML(path string) (*mapper.MetricMapper, error) {
-	yfile, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read mapping config file: %w", err)
+	if path == "" {
+		return nil, nil
 	}
 
-	yBytes := make([]byte, 0)
-	count, err := yfile.Read(yBytes)
+	yBytes, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mapping config file: %w", err)
 	}
 
 	statsdMapper := mapper.MetricMapper{}
 
-	err = statsdMapper.InitFromYAMLString(string(yBytes[:count]))
+	err = statsdMapper.InitFromYAMLString(string(yBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load mapping config: %w", err)
 	}
@@ -14,6 +14,8 @@ func init() {
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
 		Build:   exporter.New(createExporter, "statsd"),
+
+		UpstreamModules: []string{"github.com/prometheus/statsd_exporter"},
 	})
 }
 
@@ -70,4 +70,15 @@ func TestConvert(t *testing.T) {
 	require.Equal(t, false, configStatsd.ParseInfluxDB)
 	require.Equal(t, false, configStatsd.ParseLibrato)
 	require.Equal(t, false, configStatsd.ParseSignalFX)
+	require.NotNil(t, configStatsd.MappingConfig)
+	require.Len(t, configStatsd.MappingConfig.Mappings, 11)
+}
+
+func TestConvert_NoMappingConfig(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`listen_udp = "1010"`), &args))
+
+	configStatsd, err := args.Convert()
+	require.NoError(t, err)
+	require.Nil(t, configStatsd.MappingConfig)
 }
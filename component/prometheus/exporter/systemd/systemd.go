@@ -0,0 +1,64 @@
+package systemd
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/systemd_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "prometheus.exporter.systemd",
+		Args:      Arguments{},
+		Exports:   exporter.Exports{},
+		Singleton: true,
+		Build:     exporter.New(createExporter, "systemd"),
+
+		UpstreamModules: []string{"github.com/coreos/go-systemd/v22", "github.com/godbus/dbus/v5"},
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default arguments for the
+// prometheus.exporter.systemd component.
+var DefaultArguments = Arguments{
+	Timeout: systemd_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments configures the prometheus.exporter.systemd component.
+type Arguments struct {
+	// Units holds glob patterns of the units to collect metrics from. All
+	// loaded units are used when empty.
+	Units []string `river:"units,attr,optional"`
+
+	// ExcludeUnits holds glob patterns of units to ignore, even when they
+	// match Units.
+	ExcludeUnits []string `river:"exclude_units,attr,optional"`
+
+	// Timeout is the maximum time to spend querying systemd per scrape.
+	Timeout time.Duration `river:"timeout,attr,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	return f((*args)(a))
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *systemd_exporter.Config {
+	return &systemd_exporter.Config{
+		Units:        a.Units,
+		ExcludeUnits: a.ExcludeUnits,
+		Timeout:      a.Timeout,
+	}
+}
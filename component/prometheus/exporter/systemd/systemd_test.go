@@ -0,0 +1,37 @@
+package systemd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/integrations/systemd_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		units         = ["nginx.service", "backup.*"]
+		exclude_units = ["backup.socket"]
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, Arguments{
+		Units:        []string{"nginx.service", "backup.*"},
+		ExcludeUnits: []string{"backup.socket"},
+		Timeout:      5 * time.Second,
+	}, args)
+}
+
+func TestConvert(t *testing.T) {
+	args := Arguments{
+		Units:        []string{"nginx.service"},
+		ExcludeUnits: []string{"backup.socket"},
+		Timeout:      time.Second,
+	}
+	require.Equal(t, &systemd_exporter.Config{
+		Units:        []string{"nginx.service"},
+		ExcludeUnits: []string{"backup.socket"},
+		Timeout:      time.Second,
+	}, args.Convert())
+}
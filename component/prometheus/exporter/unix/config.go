@@ -183,7 +183,22 @@ type TapestatsConfig struct {
 
 // TextfileConfig contains config specific to the textfile collector.
 type TextfileConfig struct {
-	Directory string `river:"directory,attr,optional"`
+	Directory   string   `river:"directory,attr,optional"`
+	Directories []string `river:"directories,attr,optional"`
+}
+
+// directories returns every directory to read *.prom files from.
+func (c TextfileConfig) directories() []string {
+	var res []string
+	if c.Directory != "" {
+		res = append(res, c.Directory)
+	}
+	for _, dir := range c.Directories {
+		if dir != "" {
+			res = append(res, dir)
+		}
+	}
+	return res
 }
 
 // VMStatConfig contains config specific to the vmstat collector.
@@ -0,0 +1,220 @@
+package unix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// textfileGatherer reads metrics from *.prom files in a set of directories.
+// It replaces the textfile collector of node_exporter, which reads from a
+// single directory shared by the whole process.
+//
+// Files which can't be parsed, usually because they're being written to
+// without an atomic rename, are tolerated: the last successfully parsed
+// contents of the file are used instead.
+type textfileGatherer struct {
+	logger      log.Logger
+	directories []string
+
+	mut   sync.Mutex
+	files map[string]textfile // Last successfully parsed contents by path.
+}
+
+// textfile is the parsed contents of a file.
+type textfile struct {
+	mtime    time.Time
+	families map[string]*dto.MetricFamily
+}
+
+func newTextfileGatherer(l log.Logger, directories []string) *textfileGatherer {
+	return &textfileGatherer{
+		logger:      l,
+		directories: directories,
+		files:       make(map[string]textfile),
+	}
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *textfileGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	var (
+		errored bool
+		seen    = make(map[string]struct{})
+	)
+
+	for _, path := range g.paths() {
+		files, err := os.ReadDir(path)
+		if err != nil {
+			errored = true
+			level.Error(g.logger).Log("msg", "failed to read textfile collector directory", "path", path, "err", err)
+			continue
+		}
+
+		for _, f := range files {
+			if f.IsDir() || !strings.HasSuffix(f.Name(), ".prom") {
+				continue
+			}
+			filePath := filepath.Join(path, f.Name())
+
+			tf, err := readTextfile(filePath)
+			if err != nil {
+				if _, ok := g.files[filePath]; ok {
+					level.Debug(g.logger).Log("msg", "using previous contents of textfile", "file", filePath, "err", err)
+					seen[filePath] = struct{}{}
+					continue
+				}
+				errored = true
+				level.Error(g.logger).Log("msg", "failed to collect textfile data", "file", filePath, "err", err)
+				continue
+			}
+
+			g.files[filePath] = tf
+			seen[filePath] = struct{}{}
+		}
+	}
+
+	// Forget about files which have been removed.
+	for path := range g.files {
+		if _, ok := seen[path]; !ok {
+			delete(g.files, path)
+		}
+	}
+
+	return g.families(errored), nil
+}
+
+// paths returns the directories to read files from, expanding globs.
+func (g *textfileGatherer) paths() []string {
+	var res []string
+	for _, dir := range g.directories {
+		matches, err := filepath.Glob(dir)
+		if err != nil || len(matches) == 0 {
+			// Not a glob or not an accessible path; let os.ReadDir report
+			// the error.
+			matches = []string{dir}
+		}
+		res = append(res, matches...)
+	}
+	return res
+}
+
+// families merges the metric families of all files, along with the
+// node_textfile_mtime_seconds and node_textfile_scrape_error families.
+func (g *textfileGatherer) families(errored bool) []*dto.MetricFamily {
+	paths := make([]string, 0, len(g.files))
+	for path := range g.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var (
+		merged  = make(map[string]*dto.MetricFamily)
+		sources = make(map[string][]string)
+		mtimes  = &dto.MetricFamily{
+			Name: proto.String("node_textfile_mtime_seconds"),
+			Help: proto.String("Unixtime mtime of textfiles successfully read."),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+	)
+
+	for _, path := range paths {
+		tf := g.files[path]
+		for name, mf := range tf.families {
+			existing, ok := merged[name]
+			if !ok {
+				existing = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				merged[name] = existing
+			} else if existing.GetType() != mf.GetType() {
+				level.Error(g.logger).Log("msg", "ignoring metric family with conflicting type", "file", path, "metric", name)
+				continue
+			}
+			existing.Metric = append(existing.Metric, mf.Metric...)
+			sources[name] = append(sources[name], path)
+		}
+
+		mtimes.Metric = append(mtimes.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{{Name: proto.String("file"), Value: proto.String(path)}},
+			Gauge: &dto.Gauge{Value: proto.Float64(float64(tf.mtime.Unix()))},
+		})
+	}
+
+	res := make([]*dto.MetricFamily, 0, len(merged)+2)
+	for name, mf := range merged {
+		if mf.Help == nil {
+			mf.Help = proto.String(fmt.Sprintf("Metric read from %s", strings.Join(sources[name], ", ")))
+		}
+		res = append(res, mf)
+	}
+	if len(mtimes.Metric) > 0 {
+		res = append(res, mtimes)
+	}
+
+	var errVal float64
+	if errored {
+		errVal = 1
+	}
+	res = append(res, &dto.MetricFamily{
+		Name:   proto.String("node_textfile_scrape_error"),
+		Help:   proto.String("1 if there was an error opening or reading a file, 0 otherwise"),
+		Type:   dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(errVal)}}},
+	})
+	return res
+}
+
+// readTextfile reads and parses the file at path. The file is read in full
+// before parsing so a concurrent atomic rename can't mix old and new
+// contents.
+func readTextfile(path string) (textfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return textfile{}, fmt.Errorf("failed to open textfile data file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	bb, err := io.ReadAll(f)
+	if err != nil {
+		return textfile{}, fmt.Errorf("failed to read textfile data file %q: %w", path, err)
+	}
+	// The text format requires every line to end with a line feed, so a file
+	// without a trailing one is still being written.
+	if len(bb) > 0 && bb[len(bb)-1] != '\n' {
+		return textfile{}, fmt.Errorf("textfile %q is partially written", path)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(bb))
+	if err != nil {
+		return textfile{}, fmt.Errorf("failed to parse textfile data from %q: %w", path, err)
+	}
+	for _, mf := range families {
+		for _, m := range mf.Metric {
+			if m.TimestampMs != nil {
+				return textfile{}, fmt.Errorf("textfile %q contains unsupported client-side timestamps, skipping entire file", path)
+			}
+		}
+	}
+
+	// Only stat the file once it has been parsed and validated, so that a
+	// failure does not appear fresh.
+	stat, err := f.Stat()
+	if err != nil {
+		return textfile{}, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+	return textfile{mtime: stat.ModTime(), families: families}, nil
+}
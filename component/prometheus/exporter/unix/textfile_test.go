@@ -0,0 +1,74 @@
+package unix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextfileGatherer(t *testing.T) {
+	var (
+		dirA = t.TempDir()
+		dirB = t.TempDir()
+		g    = newTextfileGatherer(log.NewNopLogger(), []string{dirA, dirB})
+	)
+
+	mtime := time.Unix(1600000000, 0)
+	writeTextfile(t, filepath.Join(dirA, "a.prom"), "# TYPE cron_runs_total counter\ncron_runs_total{job=\"backup\"} 3\n", mtime)
+	writeTextfile(t, filepath.Join(dirB, "b.prom"), "# TYPE cron_runs_total counter\ncron_runs_total{job=\"cleanup\"} 1\n", mtime)
+	writeTextfile(t, filepath.Join(dirB, "ignored.txt"), "ignored 1\n", mtime)
+
+	expect := `
+# HELP cron_runs_total Metric read from ` + filepath.Join(dirA, "a.prom") + `, ` + filepath.Join(dirB, "b.prom") + `
+# TYPE cron_runs_total counter
+cron_runs_total{job="backup"} 3
+cron_runs_total{job="cleanup"} 1
+# HELP node_textfile_mtime_seconds Unixtime mtime of textfiles successfully read.
+# TYPE node_textfile_mtime_seconds gauge
+node_textfile_mtime_seconds{file="` + filepath.Join(dirA, "a.prom") + `"} 1.6e+09
+node_textfile_mtime_seconds{file="` + filepath.Join(dirB, "b.prom") + `"} 1.6e+09
+# HELP node_textfile_scrape_error 1 if there was an error opening or reading a file, 0 otherwise
+# TYPE node_textfile_scrape_error gauge
+node_textfile_scrape_error 0
+`
+	require.NoError(t, testutil.GatherAndCompare(g, strings.NewReader(expect)))
+
+	// A partially written file is replaced by its previous contents.
+	writeTextfile(t, filepath.Join(dirB, "b.prom"), "# TYPE cron_runs_total counter\ncron_runs_total{job=\"cleanup\"} 2", mtime.Add(time.Minute))
+	require.NoError(t, testutil.GatherAndCompare(g, strings.NewReader(expect)))
+
+	// A partially written file without previous contents is an error.
+	writeTextfile(t, filepath.Join(dirA, "new.prom"), "new_metric{", mtime)
+	require.NoError(t, testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP node_textfile_scrape_error 1 if there was an error opening or reading a file, 0 otherwise
+# TYPE node_textfile_scrape_error gauge
+node_textfile_scrape_error 1
+`), "node_textfile_scrape_error"))
+
+	// Removed files are forgotten.
+	require.NoError(t, os.Remove(filepath.Join(dirA, "new.prom")))
+	require.NoError(t, os.Remove(filepath.Join(dirB, "b.prom")))
+	require.NoError(t, testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP cron_runs_total Metric read from `+filepath.Join(dirA, "a.prom")+`
+# TYPE cron_runs_total counter
+cron_runs_total{job="backup"} 3
+# HELP node_textfile_mtime_seconds Unixtime mtime of textfiles successfully read.
+# TYPE node_textfile_mtime_seconds gauge
+node_textfile_mtime_seconds{file="`+filepath.Join(dirA, "a.prom")+`"} 1.6e+09
+# HELP node_textfile_scrape_error 1 if there was an error opening or reading a file, 0 otherwise
+# TYPE node_textfile_scrape_error gauge
+node_textfile_scrape_error 0
+`)))
+}
+
+func writeTextfile(t *testing.T, path, contents string, mtime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
@@ -14,10 +14,42 @@ func init() {
 		Exports:   exporter.Exports{},
 		Singleton: true,
 		Build:     exporter.New(createExporter, "unix"),
+
+		UpstreamModules: []string{"github.com/prometheus/node_exporter"},
 	})
 }
 
 func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
 	a := args.(Arguments)
-	return node_integration.New(opts.Logger, a.Convert())
+	cfg := a.Convert()
+
+	// The textfile collector of node_exporter reads from a single directory
+	// configured through a process-wide flag, so it's replaced by a gatherer
+	// owned by the component.
+	textfile := node_integration.EnabledCollectors(cfg)[node_integration.CollectorTextfile]
+	if textfile {
+		cfg.EnableCollectors = withoutCollector(cfg.EnableCollectors, node_integration.CollectorTextfile)
+		cfg.DisableCollectors = append(withoutCollector(cfg.DisableCollectors, node_integration.CollectorTextfile), node_integration.CollectorTextfile)
+		cfg.TextfileDirectory = ""
+	}
+
+	i, err := node_integration.New(opts.Logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if textfile {
+		i.AddGatherer(newTextfileGatherer(opts.Logger, a.Textfile.directories()))
+	}
+	return i, nil
+}
+
+// withoutCollector returns a copy of collectors without name.
+func withoutCollector(collectors []string, name string) []string {
+	res := make([]string, 0, len(collectors))
+	for _, c := range collectors {
+		if c != name {
+			res = append(res, c)
+		}
+	}
+	return res
 }
@@ -0,0 +1,94 @@
+package vsphere
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/vsphere_exporter"
+	config_util "github.com/prometheus/common/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.vsphere",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "vsphere"),
+
+		UpstreamModules: []string{"github.com/vmware/govmomi"},
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default arguments for the
+// prometheus.exporter.vsphere component.
+var DefaultArguments = Arguments{
+	EnableHostMetrics:        vsphere_exporter.DefaultConfig.EnableHostMetrics,
+	EnableVMMetrics:          vsphere_exporter.DefaultConfig.EnableVMMetrics,
+	EnableDatastoreMetrics:   vsphere_exporter.DefaultConfig.EnableDatastoreMetrics,
+	InventoryRefreshInterval: vsphere_exporter.DefaultConfig.InventoryRefreshInterval,
+}
+
+// Arguments configures the prometheus.exporter.vsphere component.
+type Arguments struct {
+	// URL is the URL of the vCenter SDK endpoint, such as
+	// https://vcenter.example.com/sdk.
+	URL                string            `river:"vsphere_url,attr"`
+	Username           string            `river:"username,attr"`
+	Password           rivertypes.Secret `river:"password,attr"`
+	InsecureSkipVerify bool              `river:"insecure_skip_verify,attr,optional"`
+
+	EnableHostMetrics      bool `river:"enable_host_metrics,attr,optional"`
+	EnableVMMetrics        bool `river:"enable_vm_metrics,attr,optional"`
+	EnableDatastoreMetrics bool `river:"enable_datastore_metrics,attr,optional"`
+
+	// InventoryRefreshInterval is how often the names and placement of hosts,
+	// VMs, and datastores are refreshed. Performance counters are queried on
+	// every scrape.
+	InventoryRefreshInterval time.Duration `river:"inventory_refresh_interval,attr,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return fmt.Errorf("invalid vsphere_url: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("invalid vsphere_url: scheme must be http or https")
+	}
+	if a.InventoryRefreshInterval <= 0 {
+		return fmt.Errorf("inventory_refresh_interval must be greater than 0")
+	}
+	return nil
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *vsphere_exporter.Config {
+	return &vsphere_exporter.Config{
+		URL:                      a.URL,
+		Username:                 a.Username,
+		Password:                 config_util.Secret(a.Password),
+		InsecureSkipVerify:       a.InsecureSkipVerify,
+		EnableHostMetrics:        a.EnableHostMetrics,
+		EnableVMMetrics:          a.EnableVMMetrics,
+		EnableDatastoreMetrics:   a.EnableDatastoreMetrics,
+		InventoryRefreshInterval: a.InventoryRefreshInterval,
+	}
+}
@@ -0,0 +1,38 @@
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		vsphere_url          = "https://vcenter.example.com/sdk"
+		username             = "monitoring@vsphere.local"
+		password             = "secret"
+		enable_vm_metrics    = false
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, Arguments{
+		URL:                      "https://vcenter.example.com/sdk",
+		Username:                 "monitoring@vsphere.local",
+		Password:                 rivertypes.Secret("secret"),
+		EnableHostMetrics:        true,
+		EnableVMMetrics:          false,
+		EnableDatastoreMetrics:   true,
+		InventoryRefreshInterval: 5 * time.Minute,
+	}, args)
+
+	var invalid Arguments
+	err := river.Unmarshal([]byte(`
+		vsphere_url = "vcenter.example.com"
+		username    = "monitoring@vsphere.local"
+		password    = "secret"
+	`), &invalid)
+	require.EqualError(t, err, "invalid vsphere_url: scheme must be http or https")
+}
@@ -29,6 +29,9 @@ import (
 // Generous timeout period for configuring all informers
 const informerSyncTimeout = 10 * time.Second
 
+// How often the status of PodMonitors is reported as events.
+const statusReportInterval = time.Minute
+
 // crdManager is all of the fields required to run the component.
 // on update, this entire thing will be recreated and restarted
 type crdManager struct {
@@ -43,6 +46,7 @@ type crdManager struct {
 	logger    log.Logger
 	args      *Arguments
 	configGen configgen.ConfigGenerator
+	events    *eventReporter // nil if events aren't reported.
 }
 
 func NewCRDManager(opts component.Options, logger log.Logger, args *Arguments) *crdManager {
@@ -70,6 +74,19 @@ func (c *crdManager) Run(ctx context.Context) error {
 		}
 	}()
 
+	if c.args.ReportEvents {
+		config, err := c.args.Client.BuildRESTConfig(c.logger)
+		if err != nil {
+			return fmt.Errorf("creating rest config: %w", err)
+		}
+		recorder, stop, err := newEventRecorder(config, c.opts.ID)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		c.events = newEventReporter(recorder)
+	}
+
 	if err := c.runInformers(ctx); err != nil {
 		return err
 	}
@@ -89,6 +106,9 @@ func (c *crdManager) Run(ctx context.Context) error {
 		}
 	}()
 
+	statusTicker := time.NewTicker(statusReportInterval)
+	defer statusTicker.Stop()
+
 	// Start the target discovery loop to update the scrape manager with new targets.
 	for {
 		select {
@@ -96,6 +116,10 @@ func (c *crdManager) Run(ctx context.Context) error {
 			return nil
 		case m := <-c.discoveryManager.SyncCh():
 			targetSetsChan <- m
+		case <-statusTicker.C:
+			if c.events != nil {
+				c.events.report(compscrape.BuildTargetStatuses(c.scrapeManager.TargetsActive()))
+			}
 		}
 	}
 }
@@ -240,7 +264,6 @@ func (c *crdManager) addPodMonitor(pm *promopv1.PodMonitor) {
 		var pmc *config.ScrapeConfig
 		pmc, err = c.configGen.GeneratePodMonitorConfig(pm, ep, i)
 		if err != nil {
-			// TODO(jcreixell): Generate Kubernetes event to inform of this error when running `kubectl get <podmonitor>`.
 			level.Error(c.logger).Log("name", pm.Name, "err", err, "msg", "error generating scrapeconfig from podmonitor")
 			break
 		}
@@ -249,6 +272,9 @@ func (c *crdManager) addPodMonitor(pm *promopv1.PodMonitor) {
 		c.scrapeConfigs[pmc.JobName] = pmc
 		c.mut.Unlock()
 	}
+	if c.events != nil {
+		c.events.addMonitor(pm, err)
+	}
 	if err != nil {
 		c.addDebugInfo(pm.Namespace, pm.Name, err)
 		return
@@ -272,6 +298,9 @@ func (c *crdManager) onUpdatePodMonitor(oldObj, newObj interface{}) {
 func (c *crdManager) onDeletePodMonitor(obj interface{}) {
 	pm := obj.(*promopv1.PodMonitor)
 	c.clearConfigs(pm.Namespace, pm.Name)
+	if c.events != nil {
+		c.events.removeMonitor(pm.Namespace, pm.Name)
+	}
 	if err := c.apply(); err != nil {
 		level.Error(c.logger).Log("name", pm.Name, "err", err, "msg", "error applying scrape configs after podmonitor deletion")
 	}
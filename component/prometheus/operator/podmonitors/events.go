@@ -0,0 +1,153 @@
+package podmonitors
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/grafana/agent/component/prometheus/scrape"
+	promopv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events reported on PodMonitors.
+const (
+	reasonInvalidConfig = "InvalidConfiguration"
+	reasonNoTargets     = "NoTargets"
+	reasonScrapeFailed  = "ScrapeFailed"
+	reasonScraping      = "Scraping"
+)
+
+// newEventRecorder returns a recorder which sends events to the Kubernetes
+// API, along with a function to stop it.
+func newEventRecorder(config *rest.Config, source string) (record.EventRecorder, func(), error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := promopv1.AddToScheme(scheme); err != nil {
+		return nil, nil, fmt.Errorf("unable to register scheme: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme, corev1.EventSource{Component: source, Host: host})
+	return recorder, broadcaster.Shutdown, nil
+}
+
+// monitorStatus summarizes the scrape status of the targets of a PodMonitor.
+type monitorStatus struct {
+	Targets   int
+	Down      int
+	LastError string // Error of the down target with the lowest URL.
+
+	lastErrorURL string
+}
+
+// monitorStatuses summarizes targets by PodMonitor, keyed by namespace/name.
+func monitorStatuses(targets []scrape.TargetStatus) map[string]monitorStatus {
+	res := make(map[string]monitorStatus)
+	for _, t := range targets {
+		// Job names are podMonitor/NAMESPACE/NAME/INDEX.
+		parts := strings.Split(t.JobName, "/")
+		if len(parts) != 4 {
+			continue
+		}
+		key := parts[1] + "/" + parts[2]
+
+		status := res[key]
+		status.Targets++
+		if t.LastError != "" {
+			if status.Down == 0 || t.URL < status.lastErrorURL {
+				status.LastError, status.lastErrorURL = t.LastError, t.URL
+			}
+			status.Down++
+		}
+		res[key] = status
+	}
+	return res
+}
+
+// eventReporter reports the status of PodMonitors as Kubernetes events. It
+// only reports statuses which changed since they were last reported, so
+// that monitors aren't flooded with events.
+type eventReporter struct {
+	recorder record.EventRecorder
+
+	mut      sync.Mutex
+	monitors map[string]*promopv1.PodMonitor // Known monitors by namespace/name.
+	reported map[string]monitorStatus        // Last reported statuses by namespace/name.
+}
+
+func newEventReporter(recorder record.EventRecorder) *eventReporter {
+	return &eventReporter{
+		recorder: recorder,
+		monitors: make(map[string]*promopv1.PodMonitor),
+		reported: make(map[string]monitorStatus),
+	}
+}
+
+// addMonitor starts reporting the status of pm. A non-nil err reports that
+// no scrape config could be generated from pm; the status of its targets
+// isn't reported until it's fixed.
+func (r *eventReporter) addMonitor(pm *promopv1.PodMonitor, err error) {
+	key := pm.Namespace + "/" + pm.Name
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	// Report the status of the updated monitor again.
+	delete(r.reported, key)
+
+	if err != nil {
+		delete(r.monitors, key)
+		r.recorder.Eventf(pm, corev1.EventTypeWarning, reasonInvalidConfig, "Failed to generate scrape config: %s", err)
+		return
+	}
+	r.monitors[key] = pm
+}
+
+// removeMonitor stops reporting the status of a monitor.
+func (r *eventReporter) removeMonitor(namespace, name string) {
+	key := namespace + "/" + name
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	delete(r.monitors, key)
+	delete(r.reported, key)
+}
+
+// report reports the status of every known monitor given the active
+// targets of the component.
+func (r *eventReporter) report(targets []scrape.TargetStatus) {
+	statuses := monitorStatuses(targets)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	for key, pm := range r.monitors {
+		status := statuses[key]
+		if prev, ok := r.reported[key]; ok && prev == status {
+			continue
+		}
+		r.reported[key] = status
+
+		switch {
+		case status.Targets == 0:
+			r.recorder.Event(pm, corev1.EventTypeWarning, reasonNoTargets, "No targets discovered; check the selectors and the ports of the endpoints")
+		case status.Down > 0:
+			r.recorder.Eventf(pm, corev1.EventTypeWarning, reasonScrapeFailed, "%d of %d targets are down: %s", status.Down, status.Targets, status.LastError)
+		default:
+			r.recorder.Eventf(pm, corev1.EventTypeNormal, reasonScraping, "Scraping %d targets", status.Targets)
+		}
+	}
+}
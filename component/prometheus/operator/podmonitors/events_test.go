@@ -0,0 +1,66 @@
+package podmonitors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grafana/agent/component/prometheus/scrape"
+	promopv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEventReporter(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := newEventReporter(recorder)
+
+	pm := &promopv1.PodMonitor{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}}
+	r.addMonitor(pm, nil)
+
+	target := func(url, lastError string) scrape.TargetStatus {
+		return scrape.TargetStatus{JobName: "podMonitor/ns/app/0", URL: url, LastError: lastError}
+	}
+
+	r.report(nil)
+	requireEvents(t, recorder, "Warning NoTargets No targets discovered; check the selectors and the ports of the endpoints")
+
+	targets := []scrape.TargetStatus{
+		target("http://10.0.0.2:8080/metrics", "connection refused"),
+		target("http://10.0.0.1:8080/metrics", ""),
+		{JobName: "podMonitor/ns/other/0", URL: "http://10.0.0.3:8080/metrics", LastError: "timeout"},
+	}
+	r.report(targets)
+	requireEvents(t, recorder, "Warning ScrapeFailed 1 of 2 targets are down: connection refused")
+
+	// Unchanged statuses aren't reported again.
+	r.report(targets)
+	requireEvents(t, recorder)
+
+	r.report(targets[1:2])
+	requireEvents(t, recorder, "Normal Scraping Scraping 1 targets")
+
+	// Monitors which fail to generate a config only report the failure.
+	r.addMonitor(pm, fmt.Errorf("invalid relabeling"))
+	r.report(targets)
+	requireEvents(t, recorder, "Warning InvalidConfiguration Failed to generate scrape config: invalid relabeling")
+
+	// Updated monitors report their status again.
+	r.addMonitor(pm, nil)
+	r.report(targets[1:2])
+	requireEvents(t, recorder, "Normal Scraping Scraping 1 targets")
+
+	r.removeMonitor("ns", "app")
+	r.report(nil)
+	requireEvents(t, recorder)
+}
+
+func requireEvents(t *testing.T, recorder *record.FakeRecorder, expect ...string) {
+	t.Helper()
+
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	require.Equal(t, expect, events)
+}
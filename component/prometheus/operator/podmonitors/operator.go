@@ -17,6 +17,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args)
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus", "github.com/prometheus-operator/prometheus-operator"},
 	})
 }
 
@@ -22,6 +22,10 @@ type Arguments struct {
 
 	// LabelSelector allows filtering discovered monitor resources by labels
 	LabelSelector *config.LabelSelector `river:"selector,block,optional"`
+
+	// ReportEvents reports the status of monitor resources as Kubernetes
+	// events on the resources.
+	ReportEvents bool `river:"report_events,attr,optional"`
 }
 
 var DefaultArguments = Arguments{
@@ -0,0 +1,201 @@
+package receive_collectd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"collectd.org/api"
+	"collectd.org/cdtime"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Data source types of collectd values.
+const (
+	dsTypeGauge   = "gauge"
+	dsTypeDerive  = "derive"
+	dsTypeCounter = "counter"
+)
+
+// sample is a single value of a collectd value list, converted into a
+// Prometheus sample.
+type sample struct {
+	labels labels.Labels
+	ts     int64
+	value  float64
+}
+
+// jsonValueList is a value list sent by the write_http plugin in the JSON
+// format. Values are decoded as floats rather than with api.ValueList, as
+// derive and counter values are floats when the plugin has StoreRates
+// enabled.
+type jsonValueList struct {
+	Values         []*float64  `json:"values"` // null for NaN.
+	DSTypes        []string    `json:"dstypes"`
+	DSNames        []string    `json:"dsnames"`
+	Time           cdtime.Time `json:"time"`
+	Host           string      `json:"host"`
+	Plugin         string      `json:"plugin"`
+	PluginInstance string      `json:"plugin_instance"`
+	Type           string      `json:"type"`
+	TypeInstance   string      `json:"type_instance"`
+}
+
+// decodeJSON decodes value lists in the JSON format and converts them into
+// samples. The number of values which couldn't be converted is returned
+// along with the samples.
+func decodeJSON(data []byte, now time.Time) ([]sample, int, error) {
+	var vls []jsonValueList
+	if err := json.Unmarshal(data, &vls); err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		samples []sample
+		invalid int
+	)
+	for _, vl := range vls {
+		if len(vl.DSTypes) != len(vl.Values) || len(vl.DSNames) != len(vl.Values) {
+			invalid += len(vl.Values)
+			continue
+		}
+
+		id := api.Identifier{
+			Host:           vl.Host,
+			Plugin:         vl.Plugin,
+			PluginInstance: vl.PluginInstance,
+			Type:           vl.Type,
+			TypeInstance:   vl.TypeInstance,
+		}
+		ts := timestampMillis(vl.Time.Time(), now)
+		for i, v := range vl.Values {
+			lbls, err := seriesLabels(id, vl.DSNames[i], vl.DSTypes[i])
+			if err != nil {
+				invalid++
+				continue
+			}
+			value := math.NaN()
+			if v != nil {
+				value = *v
+			}
+			samples = append(samples, sample{labels: lbls, ts: ts, value: value})
+		}
+	}
+	return samples, invalid, nil
+}
+
+// convertValueLists converts value lists decoded from the binary network
+// protocol into samples. The number of values which couldn't be converted is
+// returned along with the samples.
+func convertValueLists(vls []*api.ValueList, now time.Time) ([]sample, int) {
+	var (
+		samples []sample
+		invalid int
+	)
+	for _, vl := range vls {
+		if vl.DSNames != nil && len(vl.DSNames) != len(vl.Values) {
+			invalid += len(vl.Values)
+			continue
+		}
+
+		ts := timestampMillis(vl.Time, now)
+		for i, v := range vl.Values {
+			var value float64
+			switch v := v.(type) {
+			case api.Gauge:
+				value = float64(v)
+			case api.Derive:
+				value = float64(v)
+			case api.Counter:
+				value = float64(v)
+			}
+
+			lbls, err := seriesLabels(vl.Identifier, vl.DSName(i), v.Type())
+			if err != nil {
+				invalid++
+				continue
+			}
+			samples = append(samples, sample{labels: lbls, ts: ts, value: value})
+		}
+	}
+	return samples, invalid
+}
+
+// seriesLabels returns the labels of the series for a value of a collectd
+// value list, following the naming of collectd_exporter:
+//
+//   - The metric name is collectd_<plugin>_<type>, or collectd_<type> if the
+//     plugin and type are the same. The data source name is appended unless
+//     it's "value", and derive and counter metrics have a _total suffix.
+//   - The host is set as the instance label.
+//   - The plugin instance is set as a label named after the plugin, and the
+//     type instance as the type label. If there's no plugin instance, the
+//     type instance is set as the label named after the plugin instead.
+func seriesLabels(id api.Identifier, dsName, dsType string) (labels.Labels, error) {
+	if id.Plugin == "" || id.Type == "" {
+		return nil, fmt.Errorf("plugin and type must be set")
+	}
+
+	name := "collectd_" + id.Plugin
+	if id.Plugin != id.Type {
+		name += "_" + id.Type
+	}
+	if dsName != "value" {
+		name += "_" + dsName
+	}
+	switch dsType {
+	case dsTypeGauge:
+	case dsTypeDerive, dsTypeCounter:
+		name += "_total"
+	default:
+		return nil, fmt.Errorf("unsupported data source type %q", dsType)
+	}
+
+	lb := labels.NewBuilder(nil)
+	pluginLabel := sanitize(id.Plugin)
+	if id.PluginInstance != "" {
+		lb.Set(pluginLabel, id.PluginInstance)
+	}
+	if id.TypeInstance != "" {
+		if id.PluginInstance == "" {
+			lb.Set(pluginLabel, id.TypeInstance)
+		} else {
+			lb.Set("type", id.TypeInstance)
+		}
+	}
+	lb.Set("instance", id.Host)
+	lb.Set(model.MetricNameLabel, sanitize(name))
+	return lb.Labels(nil), nil
+}
+
+// sanitize replaces characters which aren't valid in metric and label names
+// with underscores.
+func sanitize(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// timestampMillis returns t in milliseconds, or now if t isn't set.
+func timestampMillis(t, now time.Time) int64 {
+	if t.IsZero() {
+		return now.UnixMilli()
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,416 @@
+// Package receive_collectd implements the prometheus.receive_collectd
+// component.
+package receive_collectd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"collectd.org/api"
+	"collectd.org/network"
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/storage"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "prometheus.receive_collectd",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus", "collectd.org"},
+	})
+}
+
+// writePath is the path write_http requests are received on.
+const writePath = "/collectd-post"
+
+// Content types of the supported formats.
+const (
+	contentTypeJSON   = "application/json"
+	contentTypeBinary = "application/octet-stream"
+)
+
+// Arguments holds values which are used to configure the
+// prometheus.receive_collectd component.
+type Arguments struct {
+	HTTP      ServerArguments      `river:"http,block"`
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	MaxRequestBodySize units.Base2Bytes `river:"max_request_body_size,attr,optional"`
+	TypesDB            []string         `river:"typesdb,attr,optional"`
+	SecurityLevel      string           `river:"security_level,attr,optional"`
+	AuthFile           string           `river:"auth_file,attr,optional"`
+}
+
+// ServerArguments configures the HTTP server write_http requests are
+// received on.
+type ServerArguments struct {
+	ListenAddress string `river:"listen_address,attr,optional"`
+	ListenPort    int    `river:"listen_port,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	HTTP: ServerArguments{
+		ListenAddress: "0.0.0.0",
+	},
+	MaxRequestBodySize: 10 * units.MiB,
+	SecurityLevel:      "none",
+}
+
+// securityLevels are the supported security levels of the binary protocol.
+var securityLevels = map[string]network.SecurityLevel{
+	"none":    network.None,
+	"sign":    network.Sign,
+	"encrypt": network.Encrypt,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.HTTP.ListenPort <= 0 || args.HTTP.ListenPort > 65535:
+		return fmt.Errorf("http listen_port must be between 1 and 65535")
+	case args.MaxRequestBodySize <= 0:
+		return fmt.Errorf("max_request_body_size must be greater than 0")
+	}
+
+	securityLevel, ok := securityLevels[args.SecurityLevel]
+	if !ok {
+		return fmt.Errorf("security_level must be one of none, sign or encrypt")
+	}
+	if securityLevel != network.None && args.AuthFile == "" {
+		return fmt.Errorf("auth_file must be set when security_level is %s", args.SecurityLevel)
+	}
+	return nil
+}
+
+// Component implements the prometheus.receive_collectd component.
+type Component struct {
+	opts    component.Options
+	fanout  *prometheus.Fanout
+	metrics *metrics
+
+	mut       sync.RWMutex
+	args      Arguments
+	parseOpts network.ParseOpts
+	server    *http.Server
+	listener  net.Listener
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new prometheus.receive_collectd component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := newMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		metrics: m,
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.stopServer()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	parseOpts, err := newParseOpts(newArgs)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.server == nil || c.args.HTTP != newArgs.HTTP {
+		c.stopServer()
+		if err := c.startServer(newArgs.HTTP); err != nil {
+			return err
+		}
+	}
+
+	c.args = newArgs
+	c.parseOpts = parseOpts
+	return nil
+}
+
+// newParseOpts returns the options used to parse the binary protocol.
+func newParseOpts(args Arguments) (network.ParseOpts, error) {
+	opts := network.ParseOpts{
+		SecurityLevel:  securityLevels[args.SecurityLevel],
+		PasswordLookup: noPasswords{},
+	}
+	if args.AuthFile != "" {
+		if _, err := os.Stat(args.AuthFile); err != nil {
+			return opts, fmt.Errorf("failed to read auth_file: %w", err)
+		}
+		opts.PasswordLookup = network.NewAuthFile(args.AuthFile)
+	}
+
+	for _, path := range args.TypesDB {
+		db, err := readTypesDB(path)
+		if err != nil {
+			return opts, err
+		}
+		if opts.TypesDB == nil {
+			opts.TypesDB = db
+		} else {
+			opts.TypesDB.Merge(db)
+		}
+	}
+	return opts, nil
+}
+
+func readTypesDB(path string) (*api.TypesDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read typesdb file: %w", err)
+	}
+	defer f.Close()
+
+	db, err := api.NewTypesDB(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse typesdb file %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// noPasswords is used to look up passwords when no auth_file is set, failing
+// to verify signed and encrypted data.
+type noPasswords struct{}
+
+func (noPasswords) Password(user string) (string, error) {
+	return "", fmt.Errorf("no auth_file set to look up password of user %q", user)
+}
+
+// startServer starts the HTTP server. c.mut must be held when calling.
+func (c *Component) startServer(cfg ServerArguments) error {
+	addr := net.JoinHostPort(cfg.ListenAddress, strconv.Itoa(cfg.ListenPort))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(writePath, c.handleWrite)
+
+	c.listener = lis
+	c.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(c.opts.Logger).Log("msg", "http server exited with error", "err", err)
+		}
+	}(c.server)
+
+	level.Info(c.opts.Logger).Log("msg", "receiving collectd write_http requests", "addr", lis.Addr().String(), "path", writePath)
+	return nil
+}
+
+// stopServer stops the HTTP server. c.mut must be held when calling.
+func (c *Component) stopServer() {
+	if c.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to gracefully stop http server", "err", err)
+	}
+	c.server, c.listener = nil, nil
+}
+
+func (c *Component) handleWrite(w http.ResponseWriter, r *http.Request) {
+	c.mut.RLock()
+	var (
+		args      = c.args
+		parseOpts = c.parseOpts
+	)
+	c.mut.RUnlock()
+
+	status, err := c.write(r, args, parseOpts)
+	c.metrics.requests.WithLabelValues(strconv.Itoa(status)).Inc()
+	if err != nil {
+		level.Debug(c.opts.Logger).Log("msg", "rejected collectd write_http request", "status", status, "err", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// write forwards the samples of a write_http request, returning the status
+// code to respond with.
+func (c *Component) write(r *http.Request, args Arguments, parseOpts network.ParseOpts) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		// write_http always sets a content type, so fall back to JSON, its
+		// default format.
+		contentType = contentTypeJSON
+	}
+	if contentType != contentTypeJSON && contentType != contentTypeBinary {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %s", contentType)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, int64(args.MaxRequestBodySize)))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %s", args.MaxRequestBodySize)
+		}
+		return http.StatusBadRequest, fmt.Errorf("reading request body: %w", err)
+	}
+
+	var (
+		now     = time.Now()
+		samples []sample
+		invalid int
+	)
+	switch contentType {
+	case contentTypeJSON:
+		samples, invalid, err = decodeJSON(body, now)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err)
+		}
+		c.metrics.samplesReceived.WithLabelValues("json").Add(float64(len(samples) + invalid))
+	case contentTypeBinary:
+		vls, err := network.Parse(body, parseOpts)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err)
+		}
+		samples, invalid = convertValueLists(vls, now)
+		c.metrics.samplesReceived.WithLabelValues("binary").Add(float64(len(samples) + invalid))
+	}
+	if invalid > 0 {
+		c.metrics.samplesDropped.WithLabelValues(reasonInvalid).Add(float64(invalid))
+	}
+
+	if err := c.forward(r.Context(), samples); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusNoContent, nil
+}
+
+// Reasons for samples not being forwarded.
+const (
+	reasonInvalid      = "invalid"
+	reasonAppendFailed = "append_failed"
+)
+
+// forward forwards samples to the components in forward_to.
+func (c *Component) forward(ctx context.Context, samples []sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	app := c.fanout.Appender(ctx)
+	for _, s := range samples {
+		if _, err := app.Append(0, s.labels, s.ts, s.value); err != nil {
+			_ = app.Rollback()
+			c.metrics.samplesDropped.WithLabelValues(reasonAppendFailed).Add(float64(len(samples)))
+			return fmt.Errorf("failed to forward sample for series %s: %w", s.labels, err)
+		}
+	}
+	if err := app.Commit(); err != nil {
+		c.metrics.samplesDropped.WithLabelValues(reasonAppendFailed).Add(float64(len(samples)))
+		return fmt.Errorf("failed to forward samples: %w", err)
+	}
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var info debugInfo
+	if c.listener != nil {
+		info.Address = c.listener.Addr().String()
+	}
+	return info
+}
+
+type debugInfo struct {
+	Address string `river:"address,attr,optional"`
+}
+
+type metrics struct {
+	requests        *prometheus_client.CounterVec
+	samplesReceived *prometheus_client.CounterVec
+	samplesDropped  *prometheus_client.CounterVec
+}
+
+func newMetrics(reg prometheus_client.Registerer) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_collectd_requests_total",
+			Help: "Total number of write_http requests received, by status code.",
+		}, []string{"status_code"}),
+		samplesReceived: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_collectd_samples_received_total",
+			Help: "Total number of samples received, by format.",
+		}, []string{"format"}),
+		samplesDropped: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_collectd_samples_dropped_total",
+			Help: "Total number of received samples which were not forwarded, by reason.",
+		}, []string{"reason"}),
+	}
+
+	for _, c := range []prometheus_client.Collector{m.requests, m.samplesReceived, m.samplesDropped} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
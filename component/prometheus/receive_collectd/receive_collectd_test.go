@@ -0,0 +1,219 @@
+package receive_collectd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"collectd.org/api"
+	"collectd.org/network"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		http {
+			listen_port = 9999
+		}
+		forward_to = []
+		typesdb    = ["testdata/types.db"]
+	`), &args))
+	require.Equal(t, "0.0.0.0", args.HTTP.ListenAddress)
+	require.EqualValues(t, 10<<20, args.MaxRequestBodySize)
+	require.Equal(t, "none", args.SecurityLevel)
+}
+
+func TestUnmarshalRiver_Invalid(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name:   "unknown security level",
+			cfg:    `security_level = "paranoid"`,
+			expect: "security_level must be one of none, sign or encrypt",
+		},
+		{
+			name:   "missing auth file",
+			cfg:    `security_level = "sign"`,
+			expect: "auth_file must be set when security_level is sign",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := "http { listen_port = 9999 }\nforward_to = []\n" + tc.cfg
+			var args Arguments
+			require.EqualError(t, river.Unmarshal([]byte(cfg), &args), tc.expect)
+		})
+	}
+}
+
+func TestSeriesLabels(t *testing.T) {
+	tt := []struct {
+		name   string
+		id     api.Identifier
+		dsName string
+		dsType string
+		expect labels.Labels
+	}{
+		{
+			name:   "plugin and type instances",
+			id:     api.Identifier{Host: "web1", Plugin: "cpu", PluginInstance: "0", Type: "cpu", TypeInstance: "idle"},
+			dsName: "value",
+			dsType: "derive",
+			expect: labels.FromStrings("__name__", "collectd_cpu_total", "cpu", "0", "type", "idle", "instance", "web1"),
+		},
+		{
+			name:   "type instance only",
+			id:     api.Identifier{Host: "web1", Plugin: "memory", Type: "memory", TypeInstance: "used"},
+			dsName: "value",
+			dsType: "gauge",
+			expect: labels.FromStrings("__name__", "collectd_memory", "memory", "used", "instance", "web1"),
+		},
+		{
+			name:   "data source name",
+			id:     api.Identifier{Host: "web1", Plugin: "interface", PluginInstance: "eth0", Type: "if_octets"},
+			dsName: "rx",
+			dsType: "counter",
+			expect: labels.FromStrings("__name__", "collectd_interface_if_octets_rx_total", "interface", "eth0", "instance", "web1"),
+		},
+		{
+			name:   "sanitized names",
+			id:     api.Identifier{Host: "web1", Plugin: "disk-io", PluginInstance: "sda", Type: "disk.ops"},
+			dsName: "read",
+			dsType: "gauge",
+			expect: labels.FromStrings("__name__", "collectd_disk_io_disk_ops_read", "disk_io", "sda", "instance", "web1"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lbls, err := seriesLabels(tc.id, tc.dsName, tc.dsType)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, lbls)
+		})
+	}
+
+	_, err := seriesLabels(api.Identifier{Plugin: "cpu", Type: "cpu"}, "value", "absolute")
+	require.EqualError(t, err, `unsupported data source type "absolute"`)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// A request of the write_http plugin with StoreRates enabled, so the
+	// derive value is a float, and a NaN gauge.
+	data := `[
+		{"values":[0.5],"dstypes":["derive"],"dsnames":["value"],"time":1600000000.5,"interval":10,"host":"web1","plugin":"cpu","plugin_instance":"0","type":"cpu","type_instance":"idle"},
+		{"values":[null,2],"dstypes":["gauge","gauge"],"dsnames":["shortterm","midterm"],"time":0,"interval":10,"host":"web1","plugin":"load","plugin_instance":"","type":"load","type_instance":""},
+		{"values":[1],"dstypes":["gauge","gauge"],"dsnames":["value"],"time":0,"interval":10,"host":"web1","plugin":"load","type":"load"}
+	]`
+
+	samples, invalid, err := decodeJSON([]byte(data), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, invalid)
+	require.Len(t, samples, 3)
+
+	require.Equal(t, sample{
+		labels: labels.FromStrings("__name__", "collectd_cpu_total", "cpu", "0", "type", "idle", "instance", "web1"),
+		ts:     1600000000500,
+		value:  0.5,
+	}, samples[0])
+
+	require.Equal(t, labels.FromStrings("__name__", "collectd_load_shortterm", "instance", "web1"), samples[1].labels)
+	require.Equal(t, now.UnixMilli(), samples[1].ts)
+	require.True(t, math.IsNaN(samples[1].value))
+
+	require.Equal(t, labels.FromStrings("__name__", "collectd_load_midterm", "instance", "web1"), samples[2].labels)
+	require.Equal(t, 2.0, samples[2].value)
+
+	_, _, err = decodeJSON([]byte(`{}`), now)
+	require.Error(t, err)
+}
+
+func TestComponent(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received = make(map[string]float64)
+	)
+	receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		received[l.String()] = v
+		return ref, nil
+	}))
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		http {
+			listen_address = "127.0.0.1"
+			listen_port    = 1
+		}
+		forward_to = []
+		typesdb    = ["testdata/types.db"]
+	`), &args))
+	args.HTTP.ListenPort = 0 // Pick a free port.
+	args.ForwardTo = []storage.Appendable{receiver}
+
+	c, err := New(component.Options{
+		ID:         "prometheus.receive_collectd.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	url := fmt.Sprintf("http://%s%s", c.DebugInfo().(debugInfo).Address, writePath)
+	send := func(contentType string, body []byte) int {
+		resp, err := http.Post(url, contentType, bytes.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// JSON format.
+	status := send("application/json", []byte(`[{"values":[42],"dstypes":["gauge"],"dsnames":["value"],"time":1700000000,"interval":10,"host":"web1","plugin":"memory","plugin_instance":"","type":"memory","type_instance":"used"}]`))
+	require.Equal(t, http.StatusNoContent, status)
+
+	// Binary format. The names of the data sources are looked up in the
+	// typesdb file.
+	buf := network.NewBuffer(0)
+	require.NoError(t, buf.Write(ctx, &api.ValueList{
+		Identifier: api.Identifier{Host: "web1", Plugin: "interface", PluginInstance: "eth0", Type: "if_octets"},
+		Time:       time.Unix(1700000000, 0),
+		Interval:   10 * time.Second,
+		Values:     []api.Value{api.Derive(100), api.Derive(200)},
+	}))
+	body, err := buf.Bytes()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, send("application/octet-stream", body))
+
+	require.Equal(t, http.StatusBadRequest, send("application/json", []byte(`not json`)))
+	require.Equal(t, http.StatusUnsupportedMediaType, send("text/plain", []byte(`PUTVAL`)))
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Equal(t, map[string]float64{
+		`{__name__="collectd_memory", instance="web1", memory="used"}`:                          42,
+		`{__name__="collectd_interface_if_octets_rx_total", instance="web1", interface="eth0"}`: 100,
+		`{__name__="collectd_interface_if_octets_tx_total", instance="web1", interface="eth0"}`: 200,
+	}, received)
+}
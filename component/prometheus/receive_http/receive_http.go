@@ -0,0 +1,496 @@
+// Package receive_http implements the prometheus.receive_http component.
+package receive_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/agent/component"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/util/memwatch"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	"golang.org/x/time/rate"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "prometheus.receive_http",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
+	})
+}
+
+// writePath is the path remote write requests are received on.
+const writePath = "/api/v1/metrics/write"
+
+// anonymousTenant is the tenant of requests without a tenant header.
+const anonymousTenant = "anonymous"
+
+// Arguments holds values which are used to configure the
+// prometheus.receive_http component.
+type Arguments struct {
+	HTTP      ServerArguments      `river:"http,block"`
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	MaxRequestBodySize units.Base2Bytes `river:"max_request_body_size,attr,optional"`
+	TenantHeader       string           `river:"tenant_header,attr,optional"`
+	Limits             Limits           `river:"limits,block,optional"`
+	TenantLimits       []TenantLimits   `river:"tenant_limits,block,optional"`
+
+	// The relabeling rules to apply to each received series before it's
+	// forwarded.
+	MetricRelabelConfigs []*flow_relabel.Config `river:"rule,block,optional"`
+}
+
+// ServerArguments configures the HTTP server remote write requests are
+// received on.
+type ServerArguments struct {
+	ListenAddress string `river:"listen_address,attr,optional"`
+	ListenPort    int    `river:"listen_port,attr"`
+}
+
+// Limits holds the ingestion limits of a tenant. Zero values disable the
+// limit.
+type Limits struct {
+	// Samples (including histogram samples) per second which can be received.
+	RateLimit float64 `river:"rate_limit,attr,optional"`
+	// Samples which can be received at once above RateLimit.
+	BurstSize int `river:"burst_size,attr,optional"`
+	// Series which can be sent in a single request.
+	MaxSeriesPerRequest int `river:"max_series_per_request,attr,optional"`
+}
+
+// TenantLimits overrides the limits of a single tenant.
+type TenantLimits struct {
+	Tenant string `river:"tenant,attr"`
+	Limits Limits `river:",squash"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	HTTP: ServerArguments{
+		ListenAddress: "0.0.0.0",
+	},
+	MaxRequestBodySize: 10 * units.MiB,
+	TenantHeader:       "X-Scope-OrgID",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.HTTP.ListenPort <= 0 || args.HTTP.ListenPort > 65535:
+		return fmt.Errorf("http listen_port must be between 1 and 65535")
+	case args.MaxRequestBodySize <= 0:
+		return fmt.Errorf("max_request_body_size must be greater than 0")
+	case args.TenantHeader == "":
+		return fmt.Errorf("tenant_header must not be empty")
+	}
+
+	if err := args.Limits.validate(); err != nil {
+		return fmt.Errorf("limits: %w", err)
+	}
+	seen := make(map[string]struct{}, len(args.TenantLimits))
+	for _, tl := range args.TenantLimits {
+		if _, ok := seen[tl.Tenant]; ok {
+			return fmt.Errorf("tenant_limits declared more than once for tenant %q", tl.Tenant)
+		}
+		seen[tl.Tenant] = struct{}{}
+
+		if err := tl.Limits.validate(); err != nil {
+			return fmt.Errorf("tenant_limits for tenant %q: %w", tl.Tenant, err)
+		}
+	}
+	return nil
+}
+
+func (l Limits) validate() error {
+	switch {
+	case l.RateLimit < 0:
+		return fmt.Errorf("rate_limit must not be negative")
+	case l.BurstSize < 0:
+		return fmt.Errorf("burst_size must not be negative")
+	case l.RateLimit > 0 && l.BurstSize == 0:
+		return fmt.Errorf("burst_size must be set when rate_limit is set")
+	case l.MaxSeriesPerRequest < 0:
+		return fmt.Errorf("max_series_per_request must not be negative")
+	}
+	return nil
+}
+
+// limitsFor returns the limits of tenant.
+func (args *Arguments) limitsFor(tenant string) Limits {
+	for _, tl := range args.TenantLimits {
+		if tl.Tenant == tenant {
+			return tl.Limits
+		}
+	}
+	return args.Limits
+}
+
+// errMemoryPressure is returned for requests refused due to high memory
+// pressure.
+var errMemoryPressure = errors.New("refusing request due to high memory pressure, retry later")
+
+// Component implements the prometheus.receive_http component.
+type Component struct {
+	opts    component.Options
+	fanout  *prometheus.Fanout
+	metrics *metrics
+
+	mut      sync.RWMutex
+	args     Arguments
+	mrc      []*relabel.Config
+	limiters map[string]*rate.Limiter // Rate limiters by tenant.
+	server   *http.Server
+	listener net.Listener
+
+	// memoryPressure is set while memory pressure is high, refusing requests
+	// until it's relieved.
+	memoryPressure atomic.Bool
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new prometheus.receive_http component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := newMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		metrics: m,
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer memwatch.Default().Register(func(l memwatch.Level) {
+		c.memoryPressure.Store(l >= memwatch.LevelHigh)
+	})()
+
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.stopServer()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.server == nil || c.args.HTTP != newArgs.HTTP {
+		c.stopServer()
+		if err := c.startServer(newArgs.HTTP); err != nil {
+			return err
+		}
+	}
+
+	if !reflect.DeepEqual(c.args.Limits, newArgs.Limits) || !reflect.DeepEqual(c.args.TenantLimits, newArgs.TenantLimits) {
+		// Limiters are recreated lazily with the new limits.
+		c.limiters = make(map[string]*rate.Limiter)
+	}
+
+	c.args = newArgs
+	c.mrc = flow_relabel.ComponentToPromRelabelConfigs(newArgs.MetricRelabelConfigs)
+	return nil
+}
+
+// startServer starts the HTTP server. c.mut must be held when calling.
+func (c *Component) startServer(cfg ServerArguments) error {
+	addr := net.JoinHostPort(cfg.ListenAddress, strconv.Itoa(cfg.ListenPort))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(writePath, c.handleWrite)
+
+	c.listener = lis
+	c.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(c.opts.Logger).Log("msg", "http server exited with error", "err", err)
+		}
+	}(c.server)
+
+	level.Info(c.opts.Logger).Log("msg", "receiving remote write requests", "addr", lis.Addr().String(), "path", writePath)
+	return nil
+}
+
+// stopServer stops the HTTP server. c.mut must be held when calling.
+func (c *Component) stopServer() {
+	if c.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to gracefully stop http server", "err", err)
+	}
+	c.server, c.listener = nil, nil
+}
+
+func (c *Component) handleWrite(w http.ResponseWriter, r *http.Request) {
+	c.mut.RLock()
+	var (
+		args = c.args
+		mrc  = c.mrc
+	)
+	c.mut.RUnlock()
+
+	tenant := r.Header.Get(args.TenantHeader)
+	if tenant == "" {
+		tenant = anonymousTenant
+	}
+
+	status, err := c.write(r, tenant, args, mrc)
+	c.metrics.requests.WithLabelValues(tenant, strconv.Itoa(status)).Inc()
+	if err != nil {
+		level.Debug(c.opts.Logger).Log("msg", "rejected remote write request", "tenant", tenant, "status", status, "err", err)
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", strconv.Itoa(int(memwatch.RetryAfter.Seconds())))
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// write decodes the remote write request in r and forwards its series. The
+// HTTP status code to respond with is returned.
+func (c *Component) write(r *http.Request, tenant string, args Arguments, mrc []*relabel.Config) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	if c.memoryPressure.Load() {
+		return http.StatusServiceUnavailable, errMemoryPressure
+	}
+
+	compressed, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, int64(args.MaxRequestBodySize)))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %s", args.MaxRequestBodySize)
+		}
+		return http.StatusBadRequest, fmt.Errorf("reading request body: %w", err)
+	}
+
+	// Limit the size of the decoded request to the same size, so a small
+	// request can't decompress into a huge one.
+	if n, err := snappy.DecodedLen(compressed); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err)
+	} else if n > int(args.MaxRequestBodySize) {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("decompressed request body larger than %s", args.MaxRequestBodySize)
+	}
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err)
+	}
+
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(buf, &req); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding request: %w", err)
+	}
+
+	var samples int
+	for _, ts := range req.Timeseries {
+		samples += len(ts.Samples) + len(ts.Histograms)
+	}
+	c.metrics.samplesReceived.WithLabelValues(tenant).Add(float64(samples))
+
+	limits := args.limitsFor(tenant)
+	if limits.MaxSeriesPerRequest > 0 && len(req.Timeseries) > limits.MaxSeriesPerRequest {
+		c.metrics.samplesDropped.WithLabelValues(tenant, "too_many_series").Add(float64(samples))
+		return http.StatusBadRequest, fmt.Errorf("request has %d series, more than the limit of %d", len(req.Timeseries), limits.MaxSeriesPerRequest)
+	}
+	if !c.limiter(tenant, limits).AllowN(time.Now(), samples) {
+		c.metrics.samplesDropped.WithLabelValues(tenant, "rate_limited").Add(float64(samples))
+		return http.StatusTooManyRequests, fmt.Errorf("ingestion rate limit of %g samples/s with burst %d exceeded", limits.RateLimit, limits.BurstSize)
+	}
+
+	if err := c.appendSeries(r.Context(), tenant, req.Timeseries, mrc); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusNoContent, nil
+}
+
+// limiter returns the rate limiter of tenant.
+func (c *Component) limiter(tenant string, limits Limits) *rate.Limiter {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	l, ok := c.limiters[tenant]
+	if !ok {
+		if limits.RateLimit > 0 {
+			l = rate.NewLimiter(rate.Limit(limits.RateLimit), limits.BurstSize)
+		} else {
+			l = rate.NewLimiter(rate.Inf, 0)
+		}
+		c.limiters[tenant] = l
+	}
+	return l
+}
+
+func (c *Component) appendSeries(ctx context.Context, tenant string, series []prompb.TimeSeries, mrc []*relabel.Config) (err error) {
+	app := c.fanout.Appender(ctx)
+	defer func() {
+		if err != nil {
+			_ = app.Rollback()
+			return
+		}
+		err = app.Commit()
+	}()
+
+	var dropped int
+	for _, ts := range series {
+		lbls := labelProtosToLabels(ts.Labels)
+		if len(mrc) > 0 {
+			var keep bool
+			lbls, keep = relabel.Process(lbls, mrc...)
+			if !keep || lbls.IsEmpty() {
+				dropped += len(ts.Samples) + len(ts.Histograms)
+				continue
+			}
+		}
+
+		for _, s := range ts.Samples {
+			if _, err := app.Append(0, lbls, s.Timestamp, s.Value); err != nil {
+				return err
+			}
+		}
+		for _, hp := range ts.Histograms {
+			if hp.GetCountFloat() > 0 || hp.GetZeroCountFloat() > 0 {
+				_, err = app.AppendHistogram(0, lbls, hp.Timestamp, nil, remote.HistogramProtoToFloatHistogram(hp))
+			} else {
+				_, err = app.AppendHistogram(0, lbls, hp.Timestamp, remote.HistogramProtoToHistogram(hp), nil)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, ep := range ts.Exemplars {
+			e := exemplar.Exemplar{
+				Labels: labelProtosToLabels(ep.Labels),
+				Value:  ep.Value,
+				Ts:     ep.Timestamp,
+				HasTs:  ep.Timestamp != 0,
+			}
+			// Exemplars are best-effort; failing to forward them doesn't fail the
+			// request.
+			_, _ = app.AppendExemplar(0, lbls, e)
+		}
+	}
+
+	if dropped > 0 {
+		c.metrics.samplesDropped.WithLabelValues(tenant, "relabeled").Add(float64(dropped))
+	}
+	return nil
+}
+
+func labelProtosToLabels(lps []prompb.Label) labels.Labels {
+	b := labels.NewScratchBuilder(len(lps))
+	for _, l := range lps {
+		b.Add(l.Name, l.Value)
+	}
+	b.Sort()
+	return b.Labels()
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var info debugInfo
+	if c.listener != nil {
+		info.Address = c.listener.Addr().String()
+	}
+	return info
+}
+
+type debugInfo struct {
+	Address string `river:"address,attr,optional"`
+}
+
+type metrics struct {
+	requests        *prometheus_client.CounterVec
+	samplesReceived *prometheus_client.CounterVec
+	samplesDropped  *prometheus_client.CounterVec
+}
+
+func newMetrics(reg prometheus_client.Registerer) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_http_requests_total",
+			Help: "Total number of remote write requests received, by tenant and status code.",
+		}, []string{"tenant", "status_code"}),
+		samplesReceived: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_http_samples_received_total",
+			Help: "Total number of samples received, by tenant.",
+		}, []string{"tenant"}),
+		samplesDropped: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_http_samples_dropped_total",
+			Help: "Total number of received samples which were not forwarded, by tenant and reason.",
+		}, []string{"tenant", "reason"}),
+	}
+
+	for _, c := range []prometheus_client.Collector{m.requests, m.samplesReceived, m.samplesDropped} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
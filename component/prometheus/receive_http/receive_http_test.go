@@ -0,0 +1,184 @@
+package receive_http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	cfg := `
+		http {
+			listen_port = 9999
+		}
+		forward_to            = []
+		max_request_body_size = "1MiB"
+
+		limits {
+			rate_limit             = 100
+			burst_size             = 200
+			max_series_per_request = 10
+		}
+
+		tenant_limits {
+			tenant     = "team-a"
+			rate_limit = 1000
+			burst_size = 2000
+		}
+
+		rule {
+			action = "labeldrop"
+			regex  = "pod"
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	require.Equal(t, "0.0.0.0", args.HTTP.ListenAddress)
+	require.Equal(t, 9999, args.HTTP.ListenPort)
+	require.Equal(t, "X-Scope-OrgID", args.TenantHeader)
+	require.EqualValues(t, 1<<20, args.MaxRequestBodySize)
+	require.Equal(t, Limits{RateLimit: 100, BurstSize: 200, MaxSeriesPerRequest: 10}, args.limitsFor("team-b"))
+	require.Equal(t, Limits{RateLimit: 1000, BurstSize: 2000}, args.limitsFor("team-a"))
+	require.Len(t, args.MetricRelabelConfigs, 1)
+}
+
+func TestUnmarshalRiver_Invalid(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name:   "missing burst",
+			cfg:    `limits { rate_limit = 10 }`,
+			expect: "limits: burst_size must be set when rate_limit is set",
+		},
+		{
+			name: "duplicate tenant",
+			cfg: `
+				tenant_limits { tenant = "a" }
+				tenant_limits { tenant = "a" }`,
+			expect: `tenant_limits declared more than once for tenant "a"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := "http { listen_port = 9999 }\nforward_to = []\n" + tc.cfg
+			var args Arguments
+			require.EqualError(t, river.Unmarshal([]byte(cfg), &args), tc.expect)
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received []labels.Labels
+	)
+	receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		received = append(received, l)
+		return ref, nil
+	}))
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		http {
+			listen_address = "127.0.0.1"
+			listen_port    = 1
+		}
+		forward_to            = []
+		max_request_body_size = "1KiB"
+
+		limits {
+			rate_limit             = 1
+			burst_size             = 2
+			max_series_per_request = 2
+		}
+
+		tenant_limits {
+			tenant = "team-a"
+		}
+
+		rule {
+			action        = "drop"
+			source_labels = ["__name__"]
+			regex         = "dropped"
+		}
+	`), &args))
+	args.HTTP.ListenPort = 0 // Pick a free port.
+	args.ForwardTo = []storage.Appendable{receiver}
+
+	c, err := New(component.Options{
+		ID:         "prometheus.receive_http.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	url := fmt.Sprintf("http://%s%s", c.DebugInfo().(debugInfo).Address, writePath)
+	send := func(tenant string, names ...string) int {
+		var req prompb.WriteRequest
+		for _, name := range names {
+			req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+				Labels:  []prompb.Label{{Name: "__name__", Value: name}},
+				Samples: []prompb.Sample{{Timestamp: 1, Value: 1}},
+			})
+		}
+		buf, err := proto.Marshal(&req)
+		require.NoError(t, err)
+
+		httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, buf)))
+		require.NoError(t, err)
+		if tenant != "" {
+			httpReq.Header.Set("X-Scope-OrgID", tenant)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Relabeling drops series before they're forwarded.
+	require.Equal(t, http.StatusNoContent, send("", "kept", "dropped"))
+	mut.Lock()
+	require.Equal(t, []labels.Labels{labels.FromStrings("__name__", "kept")}, received)
+	mut.Unlock()
+
+	// The burst of the anonymous tenant was used up by the first request.
+	require.Equal(t, http.StatusTooManyRequests, send("", "kept"))
+	require.Equal(t, http.StatusBadRequest, send("", "a", "b", "c"))
+
+	// team-a has no limits.
+	require.Equal(t, http.StatusNoContent, send("team-a", "a", "b", "c"))
+	require.Equal(t, http.StatusNoContent, send("team-a", "a", "b", "c"))
+
+	// The body size is limited.
+	names := make([]string, 200)
+	for i := range names {
+		names[i] = fmt.Sprintf("metric_%d", i)
+	}
+	require.Equal(t, http.StatusRequestEntityTooLarge, send("team-a", names...))
+}
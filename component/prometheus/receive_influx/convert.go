@@ -0,0 +1,212 @@
+package receive_influx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/influxdata/line-protocol/v2/lineprotocol"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// point is a point decoded from a line of the line protocol.
+type point struct {
+	measurement string
+	tags        []labels.Label
+	fields      []field
+	ts          time.Time
+}
+
+type field struct {
+	key   string
+	value lineprotocol.Value
+}
+
+// decodePoints decodes points sent in the line protocol with the given
+// timestamp precision. Points without a timestamp are set to now. Lines
+// which can't be decoded are skipped; their number and the error of the
+// first of them are returned along with the points of the other lines.
+func decodePoints(data []byte, precision lineprotocol.Precision, now time.Time) ([]point, int, error) {
+	var (
+		points   []point
+		invalid  int
+		firstErr error
+	)
+	dec := lineprotocol.NewDecoderWithBytes(data)
+	for dec.Next() {
+		p, err := decodePoint(dec, precision, now)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			invalid++
+			continue
+		}
+		points = append(points, p)
+	}
+	return points, invalid, firstErr
+}
+
+func decodePoint(dec *lineprotocol.Decoder, precision lineprotocol.Precision, now time.Time) (point, error) {
+	var p point
+
+	measurement, err := dec.Measurement()
+	if err != nil {
+		return p, err
+	}
+	p.measurement = string(measurement)
+
+	for {
+		key, value, err := dec.NextTag()
+		if err != nil {
+			return p, err
+		} else if key == nil {
+			break
+		}
+		p.tags = append(p.tags, labels.Label{Name: string(key), Value: string(value)})
+	}
+
+	for {
+		key, value, err := dec.NextField()
+		if err != nil {
+			return p, err
+		} else if key == nil {
+			break
+		}
+		p.fields = append(p.fields, field{key: string(key), value: value})
+	}
+
+	if p.ts, err = dec.Time(precision, now); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// sample is a Prometheus sample converted from a field of a point.
+type sample struct {
+	labels labels.Labels
+	ts     int64
+	value  float64
+}
+
+// converted holds the samples and log entries converted from points.
+type converted struct {
+	samples []sample
+	entries []loki.Entry
+	// Number of string fields which weren't converted as no log entries were
+	// wanted.
+	droppedStrings int
+}
+
+// convertPoints converts the fields of points into samples. String fields
+// are converted into log entries if withLogs is true.
+//
+// Numeric fields are named <measurement>_<field>, or just <measurement> if
+// the field is named "value", and boolean fields are converted into 1 or 0.
+// Tags are converted into labels. Log entries are labeled with the tags of
+// their point along with the measurement and field labels.
+func convertPoints(points []point, withLogs bool) converted {
+	var res converted
+	for _, p := range points {
+		for _, f := range p.fields {
+			var value float64
+			switch f.value.Kind() {
+			case lineprotocol.Float:
+				value = f.value.FloatV()
+			case lineprotocol.Int:
+				value = float64(f.value.IntV())
+			case lineprotocol.Uint:
+				value = float64(f.value.UintV())
+			case lineprotocol.Bool:
+				if f.value.BoolV() {
+					value = 1
+				}
+			case lineprotocol.String:
+				if !withLogs {
+					res.droppedStrings++
+					continue
+				}
+				res.entries = append(res.entries, logEntry(p, f))
+				continue
+			}
+
+			res.samples = append(res.samples, sample{
+				labels: seriesLabels(p, f),
+				ts:     p.ts.UnixMilli(),
+				value:  value,
+			})
+		}
+	}
+	return res
+}
+
+func seriesLabels(p point, f field) labels.Labels {
+	name := p.measurement
+	if f.key != "value" {
+		name += "_" + f.key
+	}
+
+	lb := labels.NewBuilder(nil)
+	for _, tag := range p.tags {
+		lb.Set(sanitize(tag.Name), tag.Value)
+	}
+	lb.Set(model.MetricNameLabel, sanitize(name))
+	return lb.Labels(nil)
+}
+
+func logEntry(p point, f field) loki.Entry {
+	lbls := make(model.LabelSet, len(p.tags)+2)
+	for _, tag := range p.tags {
+		lbls[model.LabelName(sanitize(tag.Name))] = model.LabelValue(tag.Value)
+	}
+	lbls["measurement"] = model.LabelValue(p.measurement)
+	lbls["field"] = model.LabelValue(f.key)
+
+	return loki.Entry{
+		Labels: lbls,
+		Entry: logproto.Entry{
+			Timestamp: p.ts,
+			Line:      f.value.StringV(),
+		},
+	}
+}
+
+// sanitize replaces characters which aren't valid in metric and label names
+// with underscores.
+func sanitize(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// parsePrecision parses the precision query parameter of a write request.
+func parsePrecision(s string) (lineprotocol.Precision, error) {
+	switch s {
+	case "", "ns":
+		return lineprotocol.Nanosecond, nil
+	case "us":
+		return lineprotocol.Microsecond, nil
+	case "ms":
+		return lineprotocol.Millisecond, nil
+	case "s":
+		return lineprotocol.Second, nil
+	default:
+		return 0, fmt.Errorf("invalid precision %q", s)
+	}
+}
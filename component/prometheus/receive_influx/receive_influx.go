@@ -0,0 +1,396 @@
+// Package receive_influx implements the prometheus.receive_influx component.
+package receive_influx
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/prometheus"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/storage"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "prometheus.receive_influx",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus", "github.com/influxdata/line-protocol/v2"},
+	})
+}
+
+// writePath is the path of the InfluxDB 2.x write API, which write requests
+// are received on.
+const writePath = "/api/v2/write"
+
+// Arguments holds values which are used to configure the
+// prometheus.receive_influx component.
+type Arguments struct {
+	HTTP          ServerArguments      `river:"http,block"`
+	ForwardTo     []storage.Appendable `river:"forward_to,attr"`
+	LogsForwardTo []loki.LogsReceiver  `river:"logs_forward_to,attr,optional"`
+
+	MaxRequestBodySize units.Base2Bytes `river:"max_request_body_size,attr,optional"`
+}
+
+// ServerArguments configures the HTTP server write requests are received
+// on.
+type ServerArguments struct {
+	ListenAddress string `river:"listen_address,attr,optional"`
+	ListenPort    int    `river:"listen_port,attr"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	HTTP: ServerArguments{
+		ListenAddress: "0.0.0.0",
+	},
+	MaxRequestBodySize: 10 * units.MiB,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.HTTP.ListenPort <= 0 || args.HTTP.ListenPort > 65535:
+		return fmt.Errorf("http listen_port must be between 1 and 65535")
+	case args.MaxRequestBodySize <= 0:
+		return fmt.Errorf("max_request_body_size must be greater than 0")
+	}
+	return nil
+}
+
+// Component implements the prometheus.receive_influx component.
+type Component struct {
+	opts    component.Options
+	fanout  *prometheus.Fanout
+	metrics *metrics
+
+	mut      sync.RWMutex
+	args     Arguments
+	server   *http.Server
+	listener net.Listener
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new prometheus.receive_influx component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := newMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		metrics: m,
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.stopServer()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.server == nil || c.args.HTTP != newArgs.HTTP {
+		c.stopServer()
+		if err := c.startServer(newArgs.HTTP); err != nil {
+			return err
+		}
+	}
+
+	c.args = newArgs
+	return nil
+}
+
+// startServer starts the HTTP server. c.mut must be held when calling.
+func (c *Component) startServer(cfg ServerArguments) error {
+	addr := net.JoinHostPort(cfg.ListenAddress, strconv.Itoa(cfg.ListenPort))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(writePath, c.handleWrite)
+
+	c.listener = lis
+	c.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(c.opts.Logger).Log("msg", "http server exited with error", "err", err)
+		}
+	}(c.server)
+
+	level.Info(c.opts.Logger).Log("msg", "receiving influx write requests", "addr", lis.Addr().String(), "path", writePath)
+	return nil
+}
+
+// stopServer stops the HTTP server. c.mut must be held when calling.
+func (c *Component) stopServer() {
+	if c.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to gracefully stop http server", "err", err)
+	}
+	c.server, c.listener = nil, nil
+}
+
+func (c *Component) handleWrite(w http.ResponseWriter, r *http.Request) {
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	status, err := c.write(r, args)
+	c.metrics.requests.WithLabelValues(strconv.Itoa(status)).Inc()
+	if err != nil {
+		level.Debug(c.opts.Logger).Log("msg", "rejected influx write request", "status", status, "err", err)
+		writeError(w, status, err)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// writeError responds with an error in the format of the InfluxDB 2.x API.
+func writeError(w http.ResponseWriter, status int, err error) {
+	code := "invalid"
+	if status >= http.StatusInternalServerError {
+		code = "internal error"
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{code, err.Error()})
+}
+
+// write forwards the points of a write request, returning the status code to
+// respond with. Like InfluxDB, the valid lines of a request are written even
+// if some of its lines are invalid.
+func (c *Component) write(r *http.Request, args Arguments) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	precision, err := parsePrecision(r.URL.Query().Get("precision"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	body, status, err := readBody(r, args.MaxRequestBodySize)
+	if err != nil {
+		return status, err
+	}
+
+	points, invalid, decodeErr := decodePoints(body, precision, time.Now())
+	if invalid > 0 {
+		c.metrics.pointsDropped.WithLabelValues(reasonInvalid).Add(float64(invalid))
+	}
+	c.metrics.pointsReceived.Add(float64(len(points)))
+
+	res := convertPoints(points, len(args.LogsForwardTo) > 0)
+	if res.droppedStrings > 0 {
+		c.metrics.fieldsDropped.WithLabelValues(reasonStringField).Add(float64(res.droppedStrings))
+	}
+
+	if err := c.forwardSamples(r.Context(), res.samples); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := c.forwardEntries(r.Context(), args.LogsForwardTo, res.entries); err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+
+	if decodeErr != nil {
+		return http.StatusBadRequest, fmt.Errorf("partial write: %w", decodeErr)
+	}
+	return http.StatusNoContent, nil
+}
+
+// readBody reads the body of r, decompressing it if it's gzipped.
+func readBody(r *http.Request, maxSize units.Base2Bytes) ([]byte, int, error) {
+	var body io.Reader = http.MaxBytesReader(nil, r.Body, int64(maxSize))
+	switch enc := r.Header.Get("Content-Encoding"); enc {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err)
+		}
+		defer gz.Close()
+		// Limit the decompressed size as well, reading one more byte to
+		// detect larger bodies.
+		body = io.LimitReader(gz, int64(maxSize)+1)
+	default:
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content encoding %s", enc)
+	}
+
+	bb, err := io.ReadAll(body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %s", maxSize)
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("reading request body: %w", err)
+	}
+	if len(bb) > int(maxSize) {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("decompressed request body larger than %s", maxSize)
+	}
+	return bb, 0, nil
+}
+
+// Reasons for points and fields not being forwarded.
+const (
+	reasonInvalid     = "invalid"
+	reasonStringField = "string_field"
+)
+
+// forwardSamples forwards samples to the components in forward_to.
+func (c *Component) forwardSamples(ctx context.Context, samples []sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	app := c.fanout.Appender(ctx)
+	for _, s := range samples {
+		if _, err := app.Append(0, s.labels, s.ts, s.value); err != nil {
+			_ = app.Rollback()
+			return fmt.Errorf("failed to forward sample for series %s: %w", s.labels, err)
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return fmt.Errorf("failed to forward samples: %w", err)
+	}
+	c.metrics.samplesForwarded.Add(float64(len(samples)))
+	return nil
+}
+
+// forwardEntries forwards log entries to the components in logs_forward_to.
+func (c *Component) forwardEntries(ctx context.Context, receivers []loki.LogsReceiver, entries []loki.Entry) error {
+	for _, entry := range entries {
+		for _, receiver := range receivers {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to forward log entries: %w", ctx.Err())
+			case receiver <- entry:
+			}
+		}
+	}
+	c.metrics.entriesForwarded.Add(float64(len(entries)))
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var info debugInfo
+	if c.listener != nil {
+		info.Address = c.listener.Addr().String()
+	}
+	return info
+}
+
+type debugInfo struct {
+	Address string `river:"address,attr,optional"`
+}
+
+type metrics struct {
+	requests         *prometheus_client.CounterVec
+	pointsReceived   prometheus_client.Counter
+	pointsDropped    *prometheus_client.CounterVec
+	fieldsDropped    *prometheus_client.CounterVec
+	samplesForwarded prometheus_client.Counter
+	entriesForwarded prometheus_client.Counter
+}
+
+func newMetrics(reg prometheus_client.Registerer) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_influx_requests_total",
+			Help: "Total number of write requests received, by status code.",
+		}, []string{"status_code"}),
+		pointsReceived: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_influx_points_received_total",
+			Help: "Total number of valid points received.",
+		}),
+		pointsDropped: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_influx_points_dropped_total",
+			Help: "Total number of received points which were not forwarded, by reason.",
+		}, []string{"reason"}),
+		fieldsDropped: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_influx_fields_dropped_total",
+			Help: "Total number of fields of received points which were not forwarded, by reason.",
+		}, []string{"reason"}),
+		samplesForwarded: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_influx_samples_forwarded_total",
+			Help: "Total number of samples converted from numeric and boolean fields which were forwarded.",
+		}),
+		entriesForwarded: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_receive_influx_log_entries_forwarded_total",
+			Help: "Total number of log entries converted from string fields which were forwarded.",
+		}),
+	}
+
+	for _, c := range []prometheus_client.Collector{
+		m.requests, m.pointsReceived, m.pointsDropped,
+		m.fieldsDropped, m.samplesForwarded, m.entriesForwarded,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,202 @@
+package receive_influx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/influxdata/line-protocol/v2/lineprotocol"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		http {
+			listen_port = 9999
+		}
+		forward_to = []
+	`), &args))
+	require.Equal(t, "0.0.0.0", args.HTTP.ListenAddress)
+	require.EqualValues(t, 10<<20, args.MaxRequestBodySize)
+
+	err := river.Unmarshal([]byte(`
+		http {
+			listen_port = 0
+		}
+		forward_to = []
+	`), &args)
+	require.EqualError(t, err, "http listen_port must be between 1 and 65535")
+}
+
+func TestDecodePoints(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	data := `cpu,host=web1,cpu=cpu0 usage_idle=98.5,usage_user=1i 1600000000
+mem,host=web1 used=1024u,available=true
+
+invalid line
+disk,host=web1 free="x" 1600000001
+`
+
+	points, invalid, err := decodePoints([]byte(data), lineprotocol.Second, now)
+	require.Error(t, err)
+	require.Equal(t, 1, invalid)
+	require.Equal(t, []point{
+		{
+			measurement: "cpu",
+			tags:        []labels.Label{{Name: "host", Value: "web1"}, {Name: "cpu", Value: "cpu0"}},
+			fields: []field{
+				{key: "usage_idle", value: lineprotocol.MustNewValue(98.5)},
+				{key: "usage_user", value: lineprotocol.MustNewValue(int64(1))},
+			},
+			ts: time.Unix(1600000000, 0),
+		},
+		{
+			measurement: "mem",
+			tags:        []labels.Label{{Name: "host", Value: "web1"}},
+			fields: []field{
+				{key: "used", value: lineprotocol.MustNewValue(uint64(1024))},
+				{key: "available", value: lineprotocol.MustNewValue(true)},
+			},
+			ts: now,
+		},
+		{
+			measurement: "disk",
+			tags:        []labels.Label{{Name: "host", Value: "web1"}},
+			fields:      []field{{key: "free", value: lineprotocol.MustNewValue("x")}},
+			ts:          time.Unix(1600000001, 0),
+		},
+	}, points)
+}
+
+func TestConvertPoints(t *testing.T) {
+	ts := time.Unix(1600000000, 0)
+	points := []point{{
+		measurement: "system",
+		tags:        []labels.Label{{Name: "host", Value: "web1"}, {Name: "data-center", Value: "eu"}},
+		fields: []field{
+			{key: "value", value: lineprotocol.MustNewValue(1.5)},
+			{key: "load1", value: lineprotocol.MustNewValue(int64(2))},
+			{key: "healthy", value: lineprotocol.MustNewValue(true)},
+			{key: "uptime_format", value: lineprotocol.MustNewValue("1 day")},
+		},
+		ts: ts,
+	}}
+
+	res := convertPoints(points, false)
+	require.Equal(t, 1, res.droppedStrings)
+	require.Empty(t, res.entries)
+	require.Equal(t, []sample{
+		{labels: labels.FromStrings("__name__", "system", "host", "web1", "data_center", "eu"), ts: ts.UnixMilli(), value: 1.5},
+		{labels: labels.FromStrings("__name__", "system_load1", "host", "web1", "data_center", "eu"), ts: ts.UnixMilli(), value: 2},
+		{labels: labels.FromStrings("__name__", "system_healthy", "host", "web1", "data_center", "eu"), ts: ts.UnixMilli(), value: 1},
+	}, res.samples)
+
+	res = convertPoints(points, true)
+	require.Zero(t, res.droppedStrings)
+	require.Len(t, res.samples, 3)
+	require.Len(t, res.entries, 1)
+	require.Equal(t, model.LabelSet{
+		"host":        "web1",
+		"data_center": "eu",
+		"measurement": "system",
+		"field":       "uptime_format",
+	}, res.entries[0].Labels)
+	require.Equal(t, "1 day", res.entries[0].Line)
+	require.Equal(t, ts, res.entries[0].Timestamp)
+}
+
+func TestComponent(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received = make(map[string]float64)
+	)
+	receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		received[l.String()] = v
+		return ref, nil
+	}))
+	logs := make(loki.LogsReceiver, 10)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		http {
+			listen_address = "127.0.0.1"
+			listen_port    = 1
+		}
+		forward_to = []
+	`), &args))
+	args.HTTP.ListenPort = 0 // Pick a free port.
+	args.ForwardTo = []storage.Appendable{receiver}
+	args.LogsForwardTo = []loki.LogsReceiver{logs}
+
+	c, err := New(component.Options{
+		ID:         "prometheus.receive_influx.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	addr := c.DebugInfo().(debugInfo).Address
+	send := func(query string, gzipped bool, body string) int {
+		url := fmt.Sprintf("http://%s%s?org=test&bucket=test&%s", addr, writePath, query)
+
+		var buf bytes.Buffer
+		if gzipped {
+			gz := gzip.NewWriter(&buf)
+			_, err := gz.Write([]byte(body))
+			require.NoError(t, err)
+			require.NoError(t, gz.Close())
+		} else {
+			buf.WriteString(body)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, &buf)
+		require.NoError(t, err)
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusNoContent, send("precision=s", true, "cpu,host=web1 usage_idle=98.5,state=\"ok\" 1600000000\n"))
+	require.Equal(t, http.StatusBadRequest, send("", false, "mem,host=web1 used=10i\ninvalid\n"))
+	require.Equal(t, http.StatusBadRequest, send("precision=h", false, "mem,host=web1 used=10i\n"))
+
+	mut.Lock()
+	require.Equal(t, map[string]float64{
+		`{__name__="cpu_usage_idle", host="web1"}`: 98.5,
+		`{__name__="mem_used", host="web1"}`:       10,
+	}, received)
+	mut.Unlock()
+
+	select {
+	case entry := <-logs:
+		require.Equal(t, "ok", entry.Line)
+		require.Equal(t, time.Unix(1600000000, 0), entry.Timestamp)
+	default:
+		t.Fatal("expected a log entry")
+	}
+}
@@ -0,0 +1,104 @@
+package receive_statsd
+
+import (
+	"math"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// appendFamilies appends a sample with timestamp ts for every series of
+// families. Histograms and summaries are split into the series of the
+// Prometheus text format.
+func appendFamilies(app storage.Appender, families []*dto.MetricFamily, ts int64) error {
+	for _, mf := range families {
+		name := mf.GetName()
+
+		for _, m := range mf.GetMetric() {
+			var err error
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				err = appendSample(app, name, m, nil, ts, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				err = appendSample(app, name, m, nil, ts, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				err = appendSample(app, name, m, nil, ts, m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				err = appendHistogram(app, name, m, ts)
+			case dto.MetricType_SUMMARY:
+				err = appendSummary(app, name, m, ts)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func appendHistogram(app storage.Appender, name string, m *dto.Metric, ts int64) error {
+	h := m.GetHistogram()
+
+	var sawInf bool
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), +1) {
+			sawInf = true
+		}
+		le := labels.Label{Name: labels.BucketLabel, Value: formatFloat(b.GetUpperBound())}
+		if err := appendSample(app, name+"_bucket", m, &le, ts, float64(b.GetCumulativeCount())); err != nil {
+			return err
+		}
+	}
+	if !sawInf {
+		le := labels.Label{Name: labels.BucketLabel, Value: "+Inf"}
+		if err := appendSample(app, name+"_bucket", m, &le, ts, float64(h.GetSampleCount())); err != nil {
+			return err
+		}
+	}
+
+	if err := appendSample(app, name+"_sum", m, nil, ts, h.GetSampleSum()); err != nil {
+		return err
+	}
+	return appendSample(app, name+"_count", m, nil, ts, float64(h.GetSampleCount()))
+}
+
+func appendSummary(app storage.Appender, name string, m *dto.Metric, ts int64) error {
+	s := m.GetSummary()
+
+	for _, q := range s.GetQuantile() {
+		quantile := labels.Label{Name: "quantile", Value: formatFloat(q.GetQuantile())}
+		if err := appendSample(app, name, m, &quantile, ts, q.GetValue()); err != nil {
+			return err
+		}
+	}
+
+	if err := appendSample(app, name+"_sum", m, nil, ts, s.GetSampleSum()); err != nil {
+		return err
+	}
+	return appendSample(app, name+"_count", m, nil, ts, float64(s.GetSampleCount()))
+}
+
+// appendSample appends a sample for the series with the given name and the
+// labels of m, along with extra if it's set.
+func appendSample(app storage.Appender, name string, m *dto.Metric, extra *labels.Label, ts int64, v float64) error {
+	lb := labels.NewBuilder(nil)
+	lb.Set(labels.MetricName, name)
+	for _, lp := range m.GetLabel() {
+		lb.Set(lp.GetName(), lp.GetValue())
+	}
+	if extra != nil {
+		lb.Set(extra.Name, extra.Value)
+	}
+
+	_, err := app.Append(0, lb.Labels(nil), ts, v)
+	return err
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
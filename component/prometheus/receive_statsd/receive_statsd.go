@@ -0,0 +1,245 @@
+// Package receive_statsd implements the prometheus.receive_statsd component.
+package receive_statsd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/component/prometheus/exporter/statsd"
+	"github.com/grafana/agent/pkg/integrations/statsd_exporter"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/statsd_exporter/pkg/mapper"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "prometheus.receive_statsd",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus", "github.com/prometheus/statsd_exporter"},
+	})
+}
+
+// Observer types which timers, histograms and distributions can be
+// translated to.
+const (
+	observerHistogram = "histogram"
+	observerSummary   = "summary"
+)
+
+// Arguments holds values which are used to configure the
+// prometheus.receive_statsd component.
+type Arguments struct {
+	Statsd statsd.Arguments `river:",squash"`
+
+	ForwardTo       []storage.Appendable `river:"forward_to,attr"`
+	ForwardInterval time.Duration        `river:"forward_interval,attr,optional"`
+
+	// Observer type and buckets to use for timers, histograms and
+	// distributions when there's no mapping config.
+	ObserverType     string    `river:"observer_type,attr,optional"`
+	HistogramBuckets []float64 `river:"histogram_buckets,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Statsd:          statsd.DefaultConfig,
+	ForwardInterval: 15 * time.Second,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Statsd.ListenUDP == "" && args.Statsd.ListenTCP == "" && args.Statsd.ListenUnixgram == "":
+		return fmt.Errorf("at least one of listen_udp, listen_tcp or listen_unixgram must be set")
+	case args.ForwardInterval <= 0:
+		return fmt.Errorf("forward_interval must be greater than 0")
+	case args.ObserverType != "" && args.ObserverType != observerHistogram && args.ObserverType != observerSummary:
+		return fmt.Errorf("observer_type must be %q or %q", observerHistogram, observerSummary)
+	case len(args.HistogramBuckets) > 0 && args.ObserverType != observerHistogram:
+		return fmt.Errorf("histogram_buckets requires observer_type to be %q", observerHistogram)
+	case args.ObserverType != "" && args.Statsd.MappingConfig != "":
+		return fmt.Errorf("observer_type can't be used with mapping_config_path; set defaults.observer_type in the mapping config instead")
+	}
+	for i := 1; i < len(args.HistogramBuckets); i++ {
+		if args.HistogramBuckets[i] <= args.HistogramBuckets[i-1] {
+			return fmt.Errorf("histogram_buckets must be in increasing order")
+		}
+	}
+	return nil
+}
+
+// exporterConfig converts args into a config for the statsd_exporter
+// integration.
+func (args *Arguments) exporterConfig() (*statsd_exporter.Config, error) {
+	cfg, err := args.Statsd.Convert()
+	if err != nil {
+		return nil, err
+	}
+	if args.ObserverType == "" {
+		return cfg, nil
+	}
+
+	// Use a mapping config with no mappings, so observer_type and
+	// histogram_buckets apply to every metric.
+	var m mapper.MetricMapper
+	if err := m.InitFromYAMLString(""); err != nil {
+		return nil, fmt.Errorf("failed to build mapping config: %w", err)
+	}
+	m.Defaults.ObserverType = mapper.ObserverType(args.ObserverType)
+	if len(args.HistogramBuckets) > 0 {
+		m.Defaults.HistogramOptions.Buckets = args.HistogramBuckets
+	}
+	cfg.MappingConfig = &m
+	return cfg, nil
+}
+
+// Component implements the prometheus.receive_statsd component.
+type Component struct {
+	opts   component.Options
+	fanout *prometheus.Fanout
+
+	updated chan struct{}
+
+	mut      sync.Mutex
+	args     Arguments
+	exporter *statsd_exporter.Exporter
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new prometheus.receive_statsd component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		updated: make(chan struct{}, 1),
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	// The exporter created by New is started below.
+	select {
+	case <-c.updated:
+	default:
+	}
+
+	for {
+		c.mut.Lock()
+		var (
+			exp      = c.exporter
+			interval = c.args.ForwardInterval
+		)
+		c.mut.Unlock()
+
+		if err := c.runExporter(ctx, exp, interval); err != nil {
+			return nil
+		}
+	}
+}
+
+// runExporter runs exp and forwards its metrics every interval until ctx is
+// canceled or the component is updated. The listeners of exp are closed
+// before returning, so a new exporter can bind to the same addresses.
+func (c *Component) runExporter(ctx context.Context, exp *statsd_exporter.Exporter, interval time.Duration) error {
+	exporterCtx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := exp.Run(exporterCtx); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "statsd listener exited with error", "err", err)
+		}
+	}()
+
+	return c.forwardLoop(ctx, exp.MappedMetrics(), interval)
+}
+
+// forwardLoop forwards the metrics of g every interval until ctx is canceled
+// or the component is updated. ctx.Err() is returned when ctx is canceled.
+func (c *Component) forwardLoop(ctx context.Context, g prometheus_client.Gatherer, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.updated:
+			return nil
+		case now := <-ticker.C:
+			if err := c.forward(ctx, g, now); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to forward statsd metrics", "err", err)
+			}
+		}
+	}
+}
+
+// forward appends the current values of the metrics in g with timestamp now.
+func (c *Component) forward(ctx context.Context, g prometheus_client.Gatherer, now time.Time) error {
+	families, err := g.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	app := c.fanout.Appender(ctx)
+	if err := appendFamilies(app, families, now.UnixMilli()); err != nil {
+		_ = app.Rollback()
+		return err
+	}
+	return app.Commit()
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	cfg, err := newArgs.exporterConfig()
+	if err != nil {
+		return err
+	}
+	integration, err := statsd_exporter.New(c.opts.Logger, cfg)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	c.args = newArgs
+	c.exporter = integration.(*statsd_exporter.Exporter)
+	c.mut.Unlock()
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
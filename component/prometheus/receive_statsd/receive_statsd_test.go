@@ -0,0 +1,222 @@
+package receive_statsd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/phayes/freeport"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "defaults",
+			config: `forward_to = []`,
+		},
+		{
+			name: "histograms",
+			config: `
+				forward_to        = []
+				observer_type     = "histogram"
+				histogram_buckets = [0.1, 1, 10]
+			`,
+		},
+		{
+			name: "no listeners",
+			config: `
+				forward_to = []
+				listen_udp = ""
+				listen_tcp = ""
+			`,
+			err: "at least one of listen_udp, listen_tcp or listen_unixgram must be set",
+		},
+		{
+			name: "bad observer type",
+			config: `
+				forward_to    = []
+				observer_type = "distribution"
+			`,
+			err: `observer_type must be "histogram" or "summary"`,
+		},
+		{
+			name: "buckets without histograms",
+			config: `
+				forward_to        = []
+				histogram_buckets = [1]
+			`,
+			err: `histogram_buckets requires observer_type to be "histogram"`,
+		},
+		{
+			name: "unordered buckets",
+			config: `
+				forward_to        = []
+				observer_type     = "histogram"
+				histogram_buckets = [1, 0.1]
+			`,
+			err: "histogram_buckets must be in increasing order",
+		},
+		{
+			name: "observer type with mapping config",
+			config: `
+				forward_to          = []
+				observer_type       = "summary"
+				mapping_config_path = "testdata/mapping.yaml"
+			`,
+			err: "observer_type can't be used with mapping_config_path; set defaults.observer_type in the mapping config instead",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, DefaultArguments.ForwardInterval, args.ForwardInterval)
+			require.Equal(t, ":9125", args.Statsd.ListenUDP)
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		lines  []string
+		expect map[string]float64
+	}{
+		{
+			name: "dogstatsd distributions as histograms",
+			config: `
+				observer_type     = "histogram"
+				histogram_buckets = [0.1, 1]
+			`,
+			lines: []string{
+				"request.duration:0.5|d|#env:prod",
+				"request.duration:2|d|#env:prod",
+				"hits:3|c|#env:prod",
+			},
+			expect: map[string]float64{
+				`{__name__="request_duration_bucket", env="prod", le="0.1"}`:  0,
+				`{__name__="request_duration_bucket", env="prod", le="1"}`:    1,
+				`{__name__="request_duration_bucket", env="prod", le="+Inf"}`: 2,
+				`{__name__="request_duration_sum", env="prod"}`:               2.5,
+				`{__name__="request_duration_count", env="prod"}`:             2,
+				`{__name__="hits", env="prod"}`:                               3,
+			},
+		},
+		{
+			name:   "mapping config",
+			config: `mapping_config_path = "testdata/mapping.yaml"`,
+			lines: []string{
+				"api.users.latency:500|ms",
+				"api.users.requests:1|c",
+				"api.users.requests:1|c",
+				"other.duration:1|ms",
+			},
+			expect: map[string]float64{
+				`{__name__="api_latency_seconds_bucket", endpoint="users", le="0.1"}`:  0,
+				`{__name__="api_latency_seconds_bucket", endpoint="users", le="1"}`:    1,
+				`{__name__="api_latency_seconds_bucket", endpoint="users", le="+Inf"}`: 1,
+				`{__name__="api_latency_seconds_sum", endpoint="users"}`:               0.5,
+				`{__name__="api_latency_seconds_count", endpoint="users"}`:             1,
+				`{__name__="api_requests_total", endpoint="users"}`:                    2,
+				`{__name__="other_duration", quantile="0.5"}`:                          0.001,
+				`{__name__="other_duration", quantile="0.9"}`:                          0.001,
+				`{__name__="other_duration", quantile="0.99"}`:                         0.001,
+				`{__name__="other_duration_sum"}`:                                      0.001,
+				`{__name__="other_duration_count"}`:                                    1,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mut      sync.Mutex
+				received = make(map[string]float64)
+			)
+			receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+				mut.Lock()
+				defer mut.Unlock()
+				received[l.String()] = v
+				return ref, nil
+			}))
+
+			port, err := freeport.GetFreePort()
+			require.NoError(t, err)
+			addr := fmt.Sprintf("127.0.0.1:%d", port)
+
+			var args Arguments
+			require.NoError(t, river.Unmarshal([]byte(fmt.Sprintf(`
+				forward_to       = []
+				forward_interval = "50ms"
+				listen_udp       = %q
+				listen_tcp       = ""
+				%s
+			`, addr, tc.config)), &args))
+			args.ForwardTo = []storage.Appendable{receiver}
+
+			c, err := New(component.Options{
+				ID:         "prometheus.receive_statsd.test",
+				Logger:     util.TestFlowLogger(t),
+				Registerer: prom.NewRegistry(),
+			}, args)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = c.Run(ctx) }()
+
+			conn, err := net.Dial("udp", addr)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			// Wait for the listener to be ready before sending the lines, since
+			// UDP packets sent before then are lost.
+			require.Eventually(t, func() bool {
+				_, _ = conn.Write([]byte("probe:1|g\n"))
+				mut.Lock()
+				defer mut.Unlock()
+				_, ok := received[`{__name__="probe"}`]
+				return ok
+			}, 5*time.Second, 50*time.Millisecond)
+
+			for _, line := range tc.lines {
+				_, err := conn.Write([]byte(line + "\n"))
+				require.NoError(t, err)
+			}
+
+			require.Eventually(t, func() bool {
+				mut.Lock()
+				defer mut.Unlock()
+				for series, value := range tc.expect {
+					if v, ok := received[series]; !ok || math.Abs(v-value) > 1e-9 {
+						return false
+					}
+				}
+				return true
+			}, 5*time.Second, 50*time.Millisecond)
+		})
+	}
+}
@@ -29,6 +29,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
@@ -140,7 +142,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 			if newLbl == nil {
 				return 0, nil
 			}
-			return next.UpdateMetadata(0, l, m)
+			return next.UpdateMetadata(0, newLbl, m)
 		}),
 	)
 
@@ -0,0 +1,346 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// failoverCheckFrequency is how often the endpoints of failover groups are
+// checked for whether they're healthy.
+var failoverCheckFrequency = time.Second
+
+// Endpoints of a failover group, as reported in metrics.
+const (
+	failoverPrimary   = "primary"
+	failoverSecondary = "secondary"
+)
+
+type failoverMetrics struct {
+	activeEndpoint  *prometheus.GaugeVec
+	switches        *prometheus.CounterVec
+	replayedSamples *prometheus.CounterVec
+}
+
+func newFailoverMetrics(reg prometheus.Registerer) (*failoverMetrics, error) {
+	m := &failoverMetrics{
+		activeEndpoint: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_prometheus_remote_write_failover_active_endpoint",
+			Help: "Whether the endpoint of a failover group is the one metrics are sent to.",
+		}, []string{"group", "endpoint"}),
+		switches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_prometheus_remote_write_failover_switches_total",
+			Help: "Total number of times a failover group switched to the endpoint.",
+		}, []string{"group", "endpoint"}),
+		replayedSamples: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_prometheus_remote_write_failover_replayed_samples_total",
+			Help: "Total number of samples replayed from the WAL to the endpoint of a failover group after switching to it.",
+		}, []string{"group", "endpoint"}),
+	}
+	for _, c := range []prometheus.Collector{m.activeEndpoint, m.switches, m.replayedSamples} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// failoverGroup tracks which endpoint of a failover block metrics are sent to.
+//
+// The primary endpoint is unhealthy once its delivery watermark hasn't moved
+// for FailoverAfter while newer samples are in the WAL. While metrics are sent
+// to the secondary endpoint, the primary is probed with empty write requests,
+// and metrics are sent to it again once the probes have been succeeding for
+// FailbackAfter.
+//
+// When switching endpoints, the endpoint which becomes active is caught up by
+// replaying the samples which are newer than the delivery watermark of the
+// other endpoint, up to when the queue of the active endpoint was started.
+type failoverGroup struct {
+	args    FailoverArguments
+	log     log.Logger
+	metrics *failoverMetrics
+	prober  remote.WriteClient
+
+	// The fields below are only used by the component while holding its mutex.
+
+	failedOver bool
+	// activeSince is the timestamp, in milliseconds, when the queue of the
+	// active endpoint was started. Samples older than that are never sent by
+	// the queue.
+	activeSince int64
+	// lastSent is the delivery watermark of the active endpoint when it was
+	// last checked, and stalledSince is when the active endpoint was first
+	// seen falling behind without making progress.
+	lastSent     int64
+	stalledSince time.Time
+	probeCancel  context.CancelFunc
+
+	health probeHealth
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newFailoverGroup(l log.Logger, metrics *failoverMetrics, args FailoverArguments) (*failoverGroup, error) {
+	// Rate limited probes must fail so that metrics aren't sent to the primary
+	// endpoint while it's rejecting requests.
+	probeEndpoint := args.Primary
+	probeEndpoint.QueueOptions = &QueueOptions{RetryOnHTTP429: true}
+	prober, err := newWriteClient("probe_"+args.Primary.Name, &probeEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &failoverGroup{
+		args:    args,
+		log:     log.With(l, "failover", args.Name),
+		metrics: metrics,
+		prober:  prober,
+
+		activeSince: timestamp.FromTime(time.Now()),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	return g, nil
+}
+
+// initMetrics exports the metrics of the group. It must be called once the
+// group replaced any other group with the same name, as stopping a group
+// removes its metrics.
+func (g *failoverGroup) initMetrics() {
+	// Initialize the counters to 0 so that they're exported before the first
+	// switch.
+	for _, endpoint := range []string{failoverPrimary, failoverSecondary} {
+		g.metrics.switches.WithLabelValues(g.args.Name, endpoint).Add(0)
+		g.metrics.replayedSamples.WithLabelValues(g.args.Name, endpoint).Add(0)
+	}
+	g.setActive(failoverPrimary, failoverSecondary)
+}
+
+// active returns the endpoint metrics are sent to.
+func (g *failoverGroup) active() *EndpointOptions {
+	if g.failedOver {
+		return &g.args.Secondary
+	}
+	return &g.args.Primary
+}
+
+// inactive returns the endpoint metrics are not sent to.
+func (g *failoverGroup) inactive() *EndpointOptions {
+	if g.failedOver {
+		return &g.args.Primary
+	}
+	return &g.args.Secondary
+}
+
+// check reports whether the group must switch to the secondary endpoint
+// because the primary is unhealthy, or back to the primary because it
+// recovered. newest is the timestamp of the newest sample in the WAL and sent
+// holds the delivery watermarks of the endpoints.
+//
+// If the group must switch endpoints, check returns true and the timestamp
+// after which the endpoint which becomes active must be caught up. The group
+// only switches once switchEndpoints is called.
+func (g *failoverGroup) check(now time.Time, newest int64, sent map[string]int64) (from int64, switching bool) {
+	// The queue of the active endpoint never sends samples older than when it
+	// was started. Watermarks only have a precision of seconds, so the samples
+	// in the second of the watermark are replayed even though some of them may
+	// already have been sent.
+	watermark := sent[g.active().Name]
+	from = watermark
+	if from < g.activeSince {
+		from = g.activeSince
+	}
+
+	if !g.failedOver {
+		// The newest timestamp has a precision of seconds too, so the start
+		// of the queue is rounded down to not consider the endpoint stalled
+		// because of samples which it doesn't send.
+		progress := g.activeSince / 1000 * 1000
+		if progress < watermark {
+			progress = watermark
+		}
+		if !g.stalled(now, newest, progress) {
+			return 0, false
+		}
+	} else if g.health.healthyFor(now) < g.args.FailbackAfter {
+		return 0, false
+	}
+	return from, true
+}
+
+// switchEndpoints switches the group to its inactive endpoint. It must be
+// called once the queue of the inactive endpoint replaced the queue of the
+// active one.
+func (g *failoverGroup) switchEndpoints() {
+	if !g.failedOver {
+		level.Warn(g.log).Log("msg", "primary endpoint is unhealthy, sending metrics to secondary endpoint", "failover_after", g.args.FailoverAfter)
+		g.failedOver = true
+		g.setActive(failoverSecondary, failoverPrimary)
+		g.metrics.switches.WithLabelValues(g.args.Name, failoverSecondary).Inc()
+		g.startProbing()
+	} else {
+		level.Info(g.log).Log("msg", "primary endpoint recovered, sending metrics to primary endpoint", "failback_after", g.args.FailbackAfter)
+		g.failedOver = false
+		g.setActive(failoverPrimary, failoverSecondary)
+		g.metrics.switches.WithLabelValues(g.args.Name, failoverPrimary).Inc()
+		g.stopProbing()
+	}
+
+	g.lastSent = 0
+	g.stalledSince = time.Time{}
+}
+
+// stalled reports whether the active endpoint, which has sent all samples up
+// to sent, has been falling behind newest without making progress for at
+// least FailoverAfter.
+func (g *failoverGroup) stalled(now time.Time, newest, sent int64) bool {
+	switch {
+	case sent >= newest:
+		g.stalledSince = time.Time{}
+	case sent > g.lastSent || g.stalledSince.IsZero():
+		g.stalledSince = now
+	}
+	g.lastSent = sent
+
+	return !g.stalledSince.IsZero() && now.Sub(g.stalledSince) >= g.args.FailoverAfter
+}
+
+func (g *failoverGroup) setActive(active, inactive string) {
+	g.metrics.activeEndpoint.WithLabelValues(g.args.Name, active).Set(1)
+	g.metrics.activeEndpoint.WithLabelValues(g.args.Name, inactive).Set(0)
+}
+
+// replay catches up the active endpoint, whose queue was started at to, on
+// the samples of the WAL at dir which are newer than from. A replay isn't
+// interrupted by switching endpoints again, so that the samples it sends
+// aren't missed by either endpoint.
+func (g *failoverGroup) replay(dir string, externalLabels labels.Labels, from, to int64) {
+	g.activeSince = to
+
+	endpoint := failoverPrimary
+	if g.failedOver {
+		endpoint = failoverSecondary
+	}
+	replayed := g.metrics.replayedSamples.WithLabelValues(g.args.Name, endpoint)
+
+	r, err := newWALReplay(g.log, dir, g.active(), externalLabels, from, to, func(n int) { replayed.Add(float64(n)) })
+	if err != nil {
+		level.Error(g.log).Log("msg", "failed to replay WAL", "endpoint", endpoint, "err", err)
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := r.Run(g.ctx); err != nil && g.ctx.Err() == nil {
+			level.Error(g.log).Log("msg", "failed to replay WAL", "endpoint", endpoint, "err", err)
+		}
+	}()
+}
+
+// startProbing starts sending empty write requests to the primary endpoint to
+// detect when it recovered, as no metrics are sent to it.
+func (g *failoverGroup) startProbing() {
+	g.health.reset()
+
+	ctx, cancel := context.WithCancel(g.ctx)
+	g.probeCancel = cancel
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		ticker := time.NewTicker(failoverCheckFrequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := storeMetadata(ctx, g.prober, nil)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					level.Debug(g.log).Log("msg", "primary endpoint probe failed", "err", err)
+				}
+				g.health.record(err)
+			}
+		}
+	}()
+}
+
+func (g *failoverGroup) stopProbing() {
+	if g.probeCancel != nil {
+		g.probeCancel()
+		g.probeCancel = nil
+	}
+}
+
+// stop stops probing and replaying, and removes the metrics of the group.
+func (g *failoverGroup) stop() {
+	g.cancel()
+	g.wg.Wait()
+
+	for _, endpoint := range []string{failoverPrimary, failoverSecondary} {
+		g.metrics.activeEndpoint.DeleteLabelValues(g.args.Name, endpoint)
+		g.metrics.switches.DeleteLabelValues(g.args.Name, endpoint)
+		g.metrics.replayedSamples.DeleteLabelValues(g.args.Name, endpoint)
+	}
+}
+
+// probeHealth records whether the probes of an endpoint succeed.
+type probeHealth struct {
+	mut          sync.Mutex
+	healthySince time.Time // Zero if the last probe failed.
+}
+
+// record records the outcome of a probe. Only the failures which the
+// remote_write queues retry, that is connection errors, 5xx and rate limited
+// requests, make the endpoint unhealthy; requests which are rejected because
+// of their content show that the endpoint is up.
+func (h *probeHealth) record(err error) {
+	var recoverable remote.RecoverableError
+	failed := errors.As(err, &recoverable)
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	switch {
+	case failed:
+		h.healthySince = time.Time{}
+	case h.healthySince.IsZero():
+		h.healthySince = time.Now()
+	}
+}
+
+// reset marks the endpoint as unhealthy until the next successful probe.
+func (h *probeHealth) reset() {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	h.healthySince = time.Time{}
+}
+
+// healthyFor returns how long probes have been succeeding, or 0 if the last
+// probe failed.
+func (h *probeHealth) healthyFor(now time.Time) time.Duration {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.healthySince.IsZero() {
+		return 0
+	}
+	return now.Sub(h.healthySince)
+}
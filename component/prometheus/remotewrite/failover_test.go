@@ -0,0 +1,289 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestFailoverGroupCheck(t *testing.T) {
+	args := FailoverArguments{
+		Name:          "main",
+		FailoverAfter: time.Minute,
+		FailbackAfter: time.Minute,
+		Primary:       GetDefaultEndpointOptions(),
+		Secondary:     GetDefaultEndpointOptions(),
+	}
+	args.Primary.Name, args.Primary.URL = "a", "http://a/api/v1/write"
+	args.Secondary.Name, args.Secondary.URL = "b", "http://b/api/v1/write"
+
+	metrics, err := newFailoverMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+	g, err := newFailoverGroup(log.NewNopLogger(), metrics, args)
+	require.NoError(t, err)
+	defer g.stop()
+	g.initMetrics()
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.activeEndpoint.WithLabelValues("main", failoverPrimary)))
+
+	var (
+		start = time.Now()
+		since = g.activeSince
+	)
+	check := func(after time.Duration, newest, sent int64) (int64, bool) {
+		return g.check(start.Add(after), since+newest, map[string]int64{"a": since + sent})
+	}
+
+	// The primary isn't unhealthy while it's caught up or making progress.
+	for i, tc := range []struct{ newest, sent int64 }{{0, 0}, {10, 10}, {20, 10}, {30, 20}} {
+		_, switched := check(time.Duration(i)*time.Minute, tc.newest, tc.sent)
+		require.False(t, switched)
+	}
+
+	// It's unhealthy once it hasn't made progress for failover_after while
+	// newer samples are in the WAL. The secondary must be caught up from the
+	// watermark of the primary.
+	_, switched := check(4*time.Minute-time.Second, 40, 20)
+	require.False(t, switched)
+	from, switched := check(4*time.Minute, 40, 20)
+	require.True(t, switched)
+	require.Equal(t, since+20, from)
+
+	// The group only switches once told to.
+	require.Equal(t, &args.Primary, g.active())
+	g.switchEndpoints()
+	require.Equal(t, &args.Secondary, g.active())
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.activeEndpoint.WithLabelValues("main", failoverSecondary)))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.switches.WithLabelValues("main", failoverSecondary)))
+
+	// Metrics are only sent to the primary again once its probes have been
+	// succeeding for failback_after.
+	g.health.record(nil)
+	_, switched = g.check(time.Now(), since+40, nil)
+	require.False(t, switched)
+	_, switched = g.check(time.Now().Add(time.Minute), since+40, nil)
+	require.True(t, switched)
+	g.switchEndpoints()
+	require.Equal(t, &args.Primary, g.active())
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.switches.WithLabelValues("main", failoverPrimary)))
+}
+
+func TestProbeHealth(t *testing.T) {
+	var h probeHealth
+	require.Zero(t, h.healthyFor(time.Now()))
+
+	// Rejected requests show that the endpoint is up.
+	h.record(fmt.Errorf("server returned HTTP status 400 Bad Request"))
+	require.NotZero(t, h.healthyFor(time.Now().Add(time.Second)))
+
+	h.record(remote.RecoverableError{})
+	require.Zero(t, h.healthyFor(time.Now().Add(time.Second)))
+
+	h.record(nil)
+	require.NotZero(t, h.healthyFor(time.Now().Add(time.Second)))
+	h.reset()
+	require.Zero(t, h.healthyFor(time.Now().Add(time.Second)))
+}
+
+// TestFailover ensures that the secondary endpoint of a failover group is
+// caught up on the samples which the primary endpoint failed to send, and that
+// the primary endpoint is sent metrics again once it recovers.
+func TestFailover(t *testing.T) {
+	defer func(check, flush time.Duration) {
+		failoverCheckFrequency, remoteFlushDeadline = check, flush
+	}(failoverCheckFrequency, remoteFlushDeadline)
+	failoverCheckFrequency = 10 * time.Millisecond
+	remoteFlushDeadline = 100 * time.Millisecond
+
+	type server struct {
+		*httptest.Server
+		healthy *atomic.Bool
+
+		mut     sync.Mutex
+		samples map[float64]int // Number of times each sample value was received.
+	}
+	newServer := func(healthy bool) *server {
+		s := &server{healthy: atomic.NewBool(healthy), samples: make(map[float64]int)}
+		s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			req, err := remote.DecodeWriteRequest(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			s.mut.Lock()
+			defer s.mut.Unlock()
+			for _, ts := range req.Timeseries {
+				for _, sample := range ts.Samples {
+					s.samples[sample.Value]++
+				}
+			}
+		}))
+		return s
+	}
+	received := func(s *server) map[float64]int {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		res := make(map[float64]int, len(s.samples))
+		for v, n := range s.samples {
+			res[v] = n
+		}
+		return res
+	}
+
+	primary := newServer(false)
+	defer primary.Close()
+	secondary := newServer(true)
+	defer secondary.Close()
+
+	cfg := fmt.Sprintf(`
+		failover {
+			name           = "main"
+			failover_after = "200ms"
+			failback_after = "200ms"
+
+			primary {
+				url            = "%s/api/v1/write"
+				remote_timeout = "100ms"
+
+				queue_config {
+					batch_send_deadline = "10ms"
+					max_backoff         = "10ms"
+				}
+			}
+			secondary {
+				url            = "%s/api/v1/write"
+				remote_timeout = "100ms"
+
+				queue_config {
+					batch_send_deadline = "10ms"
+				}
+			}
+		}
+	`, primary.URL, secondary.URL)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		err = tc.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(time.Second))
+
+	receiver := tc.Exports().(Exports).Receiver
+	appendSample := func(ts time.Time, v float64) {
+		app := receiver.Appender(context.Background())
+		_, err := app.Append(0, labels.FromStrings("__name__", "test"), ts.UnixMilli(), v)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+	}
+
+	// The primary fails to send the first sample. The secondary's queue only
+	// sends samples newer than when it started, so the sample must be replayed
+	// from the WAL. The timestamps used to detect that the primary is unhealthy
+	// have a precision of seconds, so the sample must be in a later second than
+	// when the component started.
+	time.Sleep(time.Second)
+	appendSample(time.Now(), 1)
+	require.Eventually(t, func() bool { return received(secondary)[1] == 1 }, 10*time.Second, 10*time.Millisecond)
+
+	// Newer samples are sent by the queue of the secondary.
+	appendSample(time.Now(), 2)
+	require.Eventually(t, func() bool { return received(secondary)[2] == 1 }, 10*time.Second, 10*time.Millisecond)
+
+	// Once the primary recovers, samples are sent to it again, and it's caught
+	// up on the samples which the secondary didn't send. Samples are sent to
+	// the secondary until the primary has been healthy for long enough; they
+	// are in a later second than the samples above, so these aren't replayed
+	// to the primary.
+	time.Sleep(time.Second)
+	primary.healthy.Store(true)
+	last := 2.0
+	require.Eventually(t, func() bool {
+		last++
+		appendSample(time.Now(), last)
+		return len(received(primary)) > 0
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		p, s := received(primary), received(secondary)
+		for v := 1.0; v <= last; v++ {
+			if p[v]+s[v] == 0 {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, 1, received(secondary)[1])
+	require.Equal(t, 1, received(secondary)[2])
+	require.NotContains(t, received(primary), 1.0)
+	require.NotContains(t, received(primary), 2.0)
+}
+
+// TestFailoverProbe ensures that probes sent to the primary endpoint are
+// valid, empty write requests.
+func TestFailoverProbe(t *testing.T) {
+	requests := make(chan *prompb.WriteRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := remote.DecodeWriteRequest(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		select {
+		case requests <- req:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	args := FailoverArguments{
+		Name:          "main",
+		FailoverAfter: time.Minute,
+		FailbackAfter: time.Minute,
+		Primary:       GetDefaultEndpointOptions(),
+		Secondary:     GetDefaultEndpointOptions(),
+	}
+	args.Primary.Name, args.Primary.URL = "a", srv.URL
+	args.Secondary.Name, args.Secondary.URL = "b", "http://b/api/v1/write"
+
+	metrics, err := newFailoverMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+	g, err := newFailoverGroup(log.NewNopLogger(), metrics, args)
+	require.NoError(t, err)
+	defer g.stop()
+
+	g.startProbing()
+	select {
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for probe")
+	case req := <-requests:
+		require.Empty(t, req.Timeseries)
+	}
+	require.Eventually(t, func() bool { return g.health.healthyFor(time.Now()) > 0 }, 5*time.Second, 10*time.Millisecond)
+}
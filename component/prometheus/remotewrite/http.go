@@ -0,0 +1,85 @@
+package remotewrite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/pkg/metrics/wal"
+	"github.com/prometheus/common/model"
+)
+
+// WALStatus describes the WAL of the component.
+type WALStatus struct {
+	TruncateFrequency string `json:"truncate_frequency"`
+	wal.Stats
+}
+
+// Handler implements component.HTTPComponent.
+func (c *Component) Handler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/wal", c.handleWAL).Methods(http.MethodGet)
+	r.HandleFunc("/wal/checkpoint", c.handleCheckpoint).Methods(http.MethodPost)
+	r.HandleFunc("/wal/truncate_frequency", c.handleTruncateFrequency).Methods(http.MethodPost)
+	return r
+}
+
+// handleWAL reports the segments and checkpoint of the WAL.
+func (c *Component) handleWAL(w http.ResponseWriter, _ *http.Request) {
+	c.writeWALStatus(w)
+}
+
+// handleCheckpoint immediately checkpoints the segments of the WAL older than
+// the one being written to, reclaiming disk space without waiting for the next
+// truncation.
+func (c *Component) handleCheckpoint(w http.ResponseWriter, _ *http.Request) {
+	ts := c.walTruncateTimestamp()
+	level.Info(c.log).Log("msg", "checkpointing the WAL on demand", "ts", ts)
+
+	if err := c.walStore.Checkpoint(ts); err != nil {
+		writeWALError(w, fmt.Errorf("failed to checkpoint WAL: %w", err))
+		return
+	}
+	c.writeWALStatus(w)
+}
+
+// handleTruncateFrequency overrides how often the WAL is truncated. The new
+// frequency is read from the "frequency" form value; "0s" reverts to the
+// configured frequency.
+func (c *Component) handleTruncateFrequency(w http.ResponseWriter, r *http.Request) {
+	frequency, err := model.ParseDuration(r.FormValue("frequency"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid frequency: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	c.setTruncateFrequency(time.Duration(frequency))
+	level.Info(c.log).Log("msg", "WAL truncate frequency overridden", "frequency", frequency)
+	c.writeWALStatus(w)
+}
+
+func (c *Component) writeWALStatus(w http.ResponseWriter) {
+	stats, err := c.walStore.Stats()
+	if err != nil {
+		writeWALError(w, fmt.Errorf("failed to read WAL: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(WALStatus{
+		TruncateFrequency: model.Duration(c.truncateFrequency()).String(),
+		Stats:             stats,
+	})
+}
+
+func writeWALError(w http.ResponseWriter, err error) {
+	if errors.Is(err, wal.ErrWALClosed) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
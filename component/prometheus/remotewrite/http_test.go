@@ -0,0 +1,86 @@
+package remotewrite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		wal {
+			truncate_frequency = "1h"
+		}
+	`), &args))
+
+	c, err := NewComponent(component.Options{
+		ID:            "prometheus.remote_write.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		DataPath:      t.TempDir(),
+		OnStateChange: func(e component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, c.storage.Close()) }()
+
+	app := c.receiver.Appender(context.Background())
+	_, err = app.Append(0, labels.FromStrings("foo", "bar"), time.Now().UnixMilli(), 1)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	handler := c.Handler()
+	request := func(method, path string) WALStatus {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var status WALStatus
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+		return status
+	}
+
+	status := request(http.MethodGet, "/wal")
+	require.Equal(t, "1h", status.TruncateFrequency)
+	require.Len(t, status.Segments, 1)
+	require.Positive(t, status.SizeBytes)
+	require.Nil(t, status.Checkpoint)
+
+	// Checkpoints start a new segment and never include the segment which
+	// was being written to, so the first segment is only checkpointed by the
+	// second checkpoint.
+	status = request(http.MethodPost, "/wal/checkpoint")
+	require.Nil(t, status.Checkpoint)
+	require.Len(t, status.Segments, 2)
+
+	status = request(http.MethodPost, "/wal/checkpoint")
+	require.NotNil(t, status.Checkpoint)
+	require.Equal(t, 0, status.Checkpoint.Index)
+	require.Equal(t, 1, status.Segments[0].Index)
+
+	status = request(http.MethodPost, "/wal/truncate_frequency?frequency=5m")
+	require.Equal(t, "5m", status.TruncateFrequency)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/wal/truncate_frequency?frequency=soon", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Updating the component keeps the override unless the configured
+	// frequency changes.
+	require.NoError(t, c.Update(args))
+	require.Equal(t, 5*time.Minute, c.truncateFrequency())
+
+	args.WALOptions.TruncateFrequency = 30 * time.Minute
+	require.NoError(t, c.Update(args))
+	require.Equal(t, 30*time.Minute, c.truncateFrequency())
+}
@@ -0,0 +1,216 @@
+package remotewrite
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// metadataStore holds the most recent metadata received for each metric
+// family.
+type metadataStore struct {
+	mut      sync.RWMutex
+	families map[string]prompb.MetricMetadata
+}
+
+func newMetadataStore() *metadataStore {
+	return &metadataStore{families: make(map[string]prompb.MetricMetadata)}
+}
+
+// Set records the metadata m for the metric family of the series l.
+func (s *metadataStore) Set(l labels.Labels, m metadata.Metadata) {
+	family := metricFamilyName(l.Get(labels.MetricName), m.Type)
+	if family == "" {
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.families[family] = prompb.MetricMetadata{
+		Type:             prompb.MetricMetadata_MetricType(prompb.MetricMetadata_MetricType_value[strings.ToUpper(string(m.Type))]),
+		MetricFamilyName: family,
+		Help:             m.Help,
+		Unit:             m.Unit,
+	}
+}
+
+// List returns the metadata of all known metric families, sorted by name.
+func (s *metadataStore) List() []prompb.MetricMetadata {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	res := make([]prompb.MetricMetadata, 0, len(s.families))
+	for _, m := range s.families {
+		res = append(res, m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].MetricFamilyName < res[j].MetricFamilyName })
+	return res
+}
+
+// metricFamilyName returns the name of the metric family which the series
+// name belongs to. Histograms and summaries are made of several series whose
+// names have a suffix appended to the family name.
+func metricFamilyName(name string, typ textparse.MetricType) string {
+	var suffixes []string
+	switch typ {
+	case textparse.MetricTypeHistogram:
+		suffixes = []string{"_bucket", "_sum", "_count"}
+	case textparse.MetricTypeGaugeHistogram:
+		suffixes = []string{"_bucket", "_gsum", "_gcount"}
+	case textparse.MetricTypeSummary:
+		suffixes = []string{"_sum", "_count"}
+	}
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// metadataSenders periodically sends the metadata in a metadataStore to each
+// endpoint which has sending metadata enabled.
+type metadataSenders struct {
+	log   log.Logger
+	store *metadataStore
+
+	sent   prometheus_client.Counter
+	failed prometheus_client.Counter
+
+	mut    sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newMetadataSenders(l log.Logger, store *metadataStore, reg prometheus_client.Registerer) (*metadataSenders, error) {
+	s := &metadataSenders{
+		log:   l,
+		store: store,
+		sent: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_remote_write_metadata_sent_total",
+			Help: "Total number of metric family metadata entries sent to remote_write endpoints.",
+		}),
+		failed: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_prometheus_remote_write_metadata_failed_total",
+			Help: "Total number of metric family metadata entries which failed to be sent to remote_write endpoints.",
+		}),
+	}
+	for _, c := range []prometheus_client.Collector{s.sent, s.failed} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Update stops all running senders and starts a new sender for each endpoint
+// in endpoints which has sending metadata enabled.
+func (s *metadataSenders) Update(endpoints []*EndpointOptions) error {
+	type sender struct {
+		client   remote.WriteClient
+		interval time.Duration
+		maxSend  int
+	}
+
+	var senders []sender
+	for _, ep := range endpoints {
+		opts := ep.MetadataOptions
+		if opts == nil {
+			defaults := DefaultMetadataOptions
+			opts = &defaults
+		}
+		if !opts.Send {
+			continue
+		}
+
+		client, err := newWriteClient("metadata_"+ep.Name, ep)
+		if err != nil {
+			return err
+		}
+		senders = append(senders, sender{client: client, interval: opts.SendInterval, maxSend: opts.MaxSamplesPerSend})
+	}
+
+	s.Stop()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, snd := range senders {
+		s.wg.Add(1)
+		go func(snd sender) {
+			defer s.wg.Done()
+			s.run(ctx, snd.client, snd.interval, snd.maxSend)
+		}(snd)
+	}
+	return nil
+}
+
+// Stop stops all running senders.
+func (s *metadataSenders) Stop() {
+	s.mut.Lock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.mut.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *metadataSenders) run(ctx context.Context, client remote.WriteClient, interval time.Duration, maxSend int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.send(ctx, client, maxSend)
+		}
+	}
+}
+
+// send sends all known metadata to client, in requests holding at most
+// maxSend entries.
+func (s *metadataSenders) send(ctx context.Context, client remote.WriteClient, maxSend int) {
+	all := s.store.List()
+	for len(all) > 0 {
+		n := len(all)
+		if maxSend > 0 && n > maxSend {
+			n = maxSend
+		}
+		batch := all[:n]
+		all = all[n:]
+
+		if err := storeMetadata(ctx, client, batch); err != nil {
+			level.Warn(s.log).Log("msg", "failed to send metadata", "endpoint", client.Endpoint(), "err", err)
+			s.failed.Add(float64(len(batch)))
+			continue
+		}
+		s.sent.Add(float64(len(batch)))
+	}
+}
+
+func storeMetadata(ctx context.Context, client remote.WriteClient, metadata []prompb.MetricMetadata) error {
+	req, err := proto.Marshal(&prompb.WriteRequest{Metadata: metadata})
+	if err != nil {
+		return err
+	}
+	return client.Store(ctx, snappy.Encode(nil, req))
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sync"
 	"time"
 
@@ -20,8 +21,10 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/diskbudget"
 	"github.com/grafana/agent/pkg/build"
 	"github.com/grafana/agent/pkg/metrics/wal"
+	promclient "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/timestamp"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/storage/remote"
@@ -32,6 +35,10 @@ import (
 // TODO(rfratto): This should be exposed. How do we want to expose this?
 var remoteFlushDeadline = 1 * time.Minute
 
+// watermarkFrequency is how often the delivery watermarks of the endpoints are
+// checked for changes and exported.
+var watermarkFrequency = 15 * time.Second
+
 func init() {
 	remote.UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
 
@@ -42,6 +49,8 @@ func init() {
 		Build: func(o component.Options, c component.Arguments) (component.Component, error) {
 			return NewComponent(o, c.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
@@ -52,13 +61,31 @@ type Component struct {
 
 	walStore    *wal.Storage
 	remoteStore *remote.Storage
+	remoteReg   *promclient.Registry
 	storage     storage.Storage
 	exited      atomic.Bool
 
+	// applyMut serializes applying configs to the remote_write queues. It's
+	// acquired before mut, which isn't held while queues are being replaced
+	// because of a failover.
+	applyMut sync.Mutex
+
 	mut sync.RWMutex
 	cfg Arguments
 
+	// truncateOverride overrides cfg.WALOptions.TruncateFrequency when
+	// non-zero. It's set through the HTTP API.
+	truncateOverride time.Duration
+	// truncateUpdated is written to whenever the truncate frequency changes.
+	truncateUpdated chan struct{}
+
 	receiver *prometheus.Interceptor
+
+	metadata        *metadataStore
+	metadataSenders *metadataSenders
+
+	failover        map[string]*failoverGroup
+	failoverMetrics *failoverMetrics
 }
 
 // NewComponent creates a new prometheus.remote_write component.
@@ -79,14 +106,32 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 	}
 
 	remoteLogger := log.With(o.Logger, "subcomponent", "rw")
-	remoteStore := remote.NewStorage(remoteLogger, o.Registerer, startTime, o.DataPath, remoteFlushDeadline, nil)
+	// The metrics of the remote_write queues are also registered to a private
+	// registry so the delivery watermarks of the endpoints can be exported.
+	remoteReg := promclient.NewRegistry()
+	remoteStore := remote.NewStorage(remoteLogger, teeRegisterer{primary: o.Registerer, secondary: remoteReg}, startTime, o.DataPath, remoteFlushDeadline, nil)
+
+	failoverMetrics, err := newFailoverMetrics(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
 
 	res := &Component{
 		log:         o.Logger,
 		opts:        o,
 		walStore:    walStorage,
 		remoteStore: remoteStore,
+		remoteReg:   remoteReg,
 		storage:     storage.NewFanout(o.Logger, walStorage, remoteStore),
+		metadata:    newMetadataStore(),
+
+		failoverMetrics: failoverMetrics,
+
+		truncateUpdated: make(chan struct{}, 1),
+	}
+	res.metadataSenders, err = newMetadataSenders(log.With(o.Logger, "subcomponent", "metadata"), res.metadata, o.Registerer)
+	if err != nil {
+		return nil, err
 	}
 	res.receiver = prometheus.NewInterceptor(
 		res.storage,
@@ -114,6 +159,8 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 				return 0, fmt.Errorf("%s has exited", o.ID)
 			}
 
+			res.metadata.Set(l, m)
+
 			localID := prometheus.GlobalRefMapping.GetLocalRefID(res.opts.ID, uint64(globalRef))
 			newRef, nextErr := next.UpdateMetadata(storage.SeriesRef(localID), l, m)
 			if localID == 0 {
@@ -136,8 +183,9 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 	)
 
 	// Immediately export the receiver which remains the same for the component
-	// lifetime.
-	o.OnStateChange(Exports{Receiver: res.receiver})
+	// lifetime. Delivery watermarks are exported once the endpoints are
+	// running.
+	o.OnStateChange(Exports{Receiver: res.receiver, DeliveryWatermarks: []DeliveryWatermark{}})
 
 	if err := res.Update(c); err != nil {
 		return nil, err
@@ -147,12 +195,22 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 
 func startTime() (int64, error) { return 0, nil }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
+)
 
 // Run implements Component.
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		c.exited.Store(true)
+		c.metadataSenders.Stop()
+
+		c.mut.Lock()
+		for _, g := range c.failover {
+			g.stop()
+		}
+		c.mut.Unlock()
 
 		level.Debug(c.log).Log("msg", "closing storage")
 		err := c.storage.Close()
@@ -162,45 +220,60 @@ func (c *Component) Run(ctx context.Context) error {
 		}
 	}()
 
+	unregister := c.opts.DiskBudget.Register(diskbudget.Consumer{
+		ID:        c.opts.ID,
+		Subsystem: diskbudget.SubsystemWAL,
+		Path:      c.opts.DataPath,
+		Evict:     c.evictWAL,
+	})
+	defer unregister()
+
 	// Track the last timestamp we truncated for to prevent segments from getting
 	// deleted until at least some new data has been sent.
 	var lastTs = int64(math.MinInt64)
 
+	// Track the last exported watermarks to only update exports when they
+	// change.
+	var lastWatermarks []DeliveryWatermark
+
+	truncateTimer := time.NewTimer(c.truncateFrequency())
+	defer truncateTimer.Stop()
+
+	watermarkTicker := time.NewTicker(watermarkFrequency)
+	defer watermarkTicker.Stop()
+
+	failoverTicker := time.NewTicker(failoverCheckFrequency)
+	defer failoverTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.After(c.truncateFrequency()):
-			// We retrieve the current min/max keepalive time at once, since
-			// retrieving them separately could lead to issues where we have an older
-			// value for min which is now larger than max.
-			c.mut.RLock()
-			var (
-				minWALTime = c.cfg.WALOptions.MinKeepaliveTime
-				maxWALTime = c.cfg.WALOptions.MaxKeepaliveTime
-			)
-			c.mut.RUnlock()
-
-			// The timestamp ts is used to determine which series are not receiving
-			// samples and may be deleted from the WAL. Their most recent append
-			// timestamp is compared to ts, and if that timestamp is older than ts,
-			// they are considered inactive and may be deleted.
-			//
-			// Subtracting a duration from ts will delay when it will be considered
-			// inactive and scheduled for deletion.
-			ts := c.remoteStore.LowestSentTimestamp() - minWALTime.Milliseconds()
-			if ts < 0 {
-				ts = 0
+		case <-failoverTicker.C:
+			c.checkFailover()
+		case <-watermarkTicker.C:
+			watermarks, err := gatherWatermarks(c.remoteReg)
+			if err != nil {
+				level.Warn(c.log).Log("msg", "could not gather delivery watermarks", "err", err)
+				continue
 			}
-
-			// Network issues can prevent the result of getRemoteWriteTimestamp from
-			// changing. We don't want data in the WAL to grow forever, so we set a cap
-			// on the maximum age data can be. If our ts is older than this cutoff point,
-			// we'll shift it forward to start deleting very stale data.
-			if maxTS := timestamp.FromTime(time.Now().Add(-maxWALTime)); ts < maxTS {
-				ts = maxTS
+			if watermarks == nil {
+				watermarks = []DeliveryWatermark{}
+			}
+			if lastWatermarks != nil && equalWatermarks(watermarks, lastWatermarks) {
+				continue
 			}
+			lastWatermarks = watermarks
+			c.opts.OnStateChange(Exports{Receiver: c.receiver, DeliveryWatermarks: watermarks})
+		case <-c.truncateUpdated:
+			if !truncateTimer.Stop() {
+				<-truncateTimer.C
+			}
+			truncateTimer.Reset(c.truncateFrequency())
+		case <-truncateTimer.C:
+			truncateTimer.Reset(c.truncateFrequency())
 
+			ts := c.walTruncateTimestamp()
 			if ts == lastTs {
 				level.Debug(c.log).Log("msg", "not truncating the WAL, remote_write timestamp is unchanged", "ts", ts)
 				continue
@@ -218,19 +291,152 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// evictWAL checkpoints every segment of the WAL older than the one being
+// written to when the disk budget is exceeded. Like the periodic truncation,
+// the checkpoint only drops data older than walTruncateTimestamp, so samples
+// which haven't been sent yet are kept until they exceed MaxKeepaliveTime.
+// The WAL serializes the checkpoint with the periodic truncation.
+func (c *Component) evictWAL(_ context.Context) error {
+	ts := c.walTruncateTimestamp()
+	level.Warn(c.log).Log("msg", "checkpointing the WAL to stay within the disk budget", "ts", ts)
+	return c.walStore.Checkpoint(ts)
+}
+
+// walTruncateTimestamp returns the timestamp before which data may be removed
+// from the WAL.
+func (c *Component) walTruncateTimestamp() int64 {
+	// We retrieve the current min/max keepalive time at once, since
+	// retrieving them separately could lead to issues where we have an older
+	// value for min which is now larger than max.
+	c.mut.RLock()
+	var (
+		minWALTime = c.cfg.WALOptions.MinKeepaliveTime
+		maxWALTime = c.cfg.WALOptions.MaxKeepaliveTime
+	)
+	c.mut.RUnlock()
+
+	// The timestamp ts is used to determine which series are not receiving
+	// samples and may be deleted from the WAL. Their most recent append
+	// timestamp is compared to ts, and if that timestamp is older than ts,
+	// they are considered inactive and may be deleted.
+	//
+	// Subtracting a duration from ts will delay when it will be considered
+	// inactive and scheduled for deletion.
+	ts := c.remoteStore.LowestSentTimestamp() - minWALTime.Milliseconds()
+	if ts < 0 {
+		ts = 0
+	}
+
+	// Network issues can prevent the result of getRemoteWriteTimestamp from
+	// changing. We don't want data in the WAL to grow forever, so we set a cap
+	// on the maximum age data can be. If our ts is older than this cutoff point,
+	// we'll shift it forward to start deleting very stale data.
+	if maxTS := timestamp.FromTime(time.Now().Add(-maxWALTime)); ts < maxTS {
+		ts = maxTS
+	}
+	return ts
+}
+
 func (c *Component) truncateFrequency() time.Duration {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
+
+	if c.truncateOverride > 0 {
+		return c.truncateOverride
+	}
 	return c.cfg.WALOptions.TruncateFrequency
 }
 
+// setTruncateFrequency overrides the configured truncate frequency until the
+// configured frequency changes. Setting it to 0 removes the override.
+func (c *Component) setTruncateFrequency(d time.Duration) {
+	c.mut.Lock()
+	c.truncateOverride = d
+	c.mut.Unlock()
+
+	select {
+	case c.truncateUpdated <- struct{}{}:
+	default:
+	}
+}
+
 // Update implements Component.
 func (c *Component) Update(newConfig component.Arguments) error {
 	cfg := newConfig.(Arguments)
 
+	c.applyMut.Lock()
+	defer c.applyMut.Unlock()
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
+	// Failover groups whose arguments didn't change keep their state.
+	groups := make(map[string]*failoverGroup, len(cfg.Failover))
+	for _, args := range cfg.Failover {
+		if g, ok := c.failover[args.Name]; ok && reflect.DeepEqual(g.args, args) {
+			groups[args.Name] = g
+			continue
+		}
+		g, err := newFailoverGroup(c.log, c.failoverMetrics, args)
+		if err != nil {
+			return err
+		}
+		groups[args.Name] = g
+	}
+
+	if err := c.applyConfig(cfg, activeEndpoints(cfg, groups, nil)); err != nil {
+		return err
+	}
+	for name, g := range c.failover {
+		if groups[name] != g {
+			g.stop()
+		}
+	}
+	for name, g := range groups {
+		if c.failover[name] != g {
+			g.initMetrics()
+		}
+	}
+	c.failover = groups
+
+	c.walStore.SetOutOfOrderTimeWindow(cfg.WALOptions.OutOfOrderTimeWindow)
+
+	// Changing the configured truncate frequency discards any frequency set
+	// through the HTTP API.
+	if c.truncateOverride > 0 && cfg.WALOptions.TruncateFrequency != c.cfg.WALOptions.TruncateFrequency {
+		c.truncateOverride = 0
+		select {
+		case c.truncateUpdated <- struct{}{}:
+		default:
+		}
+	}
+
+	c.cfg = cfg
+	return nil
+}
+
+// activeEndpoints returns the endpoints in cfg and the active endpoint of
+// every failover group. The groups in switching are about to switch, so their
+// inactive endpoint is returned instead.
+func activeEndpoints(cfg Arguments, groups map[string]*failoverGroup, switching map[*failoverGroup]int64) []*EndpointOptions {
+	endpoints := make([]*EndpointOptions, 0, len(cfg.Endpoints)+len(cfg.Failover))
+	endpoints = append(endpoints, cfg.Endpoints...)
+	for _, args := range cfg.Failover {
+		g := groups[args.Name]
+		if _, ok := switching[g]; ok {
+			endpoints = append(endpoints, g.inactive())
+		} else {
+			endpoints = append(endpoints, g.active())
+		}
+	}
+	return endpoints
+}
+
+// applyConfig configures the remote_write queues and the metadata senders to
+// send metrics to endpoints, using the other settings of cfg. c.applyMut must
+// be held.
+func (c *Component) applyConfig(cfg Arguments, endpoints []*EndpointOptions) error {
+	cfg.Endpoints = endpoints
+
 	convertedConfig, err := convertConfigs(cfg)
 	if err != nil {
 		return err
@@ -239,7 +445,66 @@ func (c *Component) Update(newConfig component.Arguments) error {
 	if err != nil {
 		return err
 	}
+	return c.metadataSenders.Update(cfg.Endpoints)
+}
 
-	c.cfg = cfg
-	return nil
+// checkFailover switches the failover groups whose primary endpoint became
+// unhealthy or recovered, and catches up the endpoints which became active.
+func (c *Component) checkFailover() {
+	c.applyMut.Lock()
+	defer c.applyMut.Unlock()
+
+	c.mut.Lock()
+	if len(c.failover) == 0 {
+		c.mut.Unlock()
+		return
+	}
+
+	newest, sent, err := gatherSendProgress(c.remoteReg)
+	if err != nil {
+		c.mut.Unlock()
+		level.Warn(c.log).Log("msg", "could not gather remote_write progress", "err", err)
+		return
+	}
+
+	var (
+		now       = time.Now()
+		switching = make(map[*failoverGroup]int64)
+	)
+	for _, g := range c.failover {
+		if from, ok := g.check(now, newest, sent); ok {
+			switching[g] = from
+		}
+	}
+	if len(switching) == 0 {
+		c.mut.Unlock()
+		return
+	}
+	cfg := c.cfg
+	endpoints := activeEndpoints(cfg, c.failover, switching)
+	c.mut.Unlock()
+
+	// Applying the config stops the queues of the endpoints which aren't
+	// active anymore, which waits for up to remoteFlushDeadline for their
+	// pending samples to be sent, and then starts the queues of the endpoints
+	// which became active. These only send samples newer than when they were
+	// started, so the samples up to then are replayed from the WAL.
+	//
+	// c.mut isn't held meanwhile so that the component isn't blocked on the
+	// flush. c.applyMut prevents Update from replacing the groups or the
+	// config until the switch is done.
+	if err := c.applyConfig(cfg, endpoints); err != nil {
+		// The groups didn't switch, so they're checked again next time.
+		level.Error(c.log).Log("msg", "failed to switch failover endpoints", "err", err)
+		return
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	to := timestamp.FromTime(time.Now())
+	for g, from := range switching {
+		g.switchEndpoints()
+		g.replay(wal.SubDirectory(c.opts.DataPath), toLabels(c.cfg.ExternalLabels), from, to)
+	}
 }
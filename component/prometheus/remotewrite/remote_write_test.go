@@ -13,6 +13,8 @@ import (
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/stretchr/testify/require"
@@ -114,3 +116,75 @@ func Test(t *testing.T) {
 		require.Equal(t, expect, res.Timeseries)
 	}
 }
+
+// TestMetadata ensures that metric metadata sent to a prometheus.remote_write
+// component is forwarded to remote_write-compatible servers.
+func TestMetadata(t *testing.T) {
+	metadataResult := make(chan []prompb.MetricMetadata, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := remote.DecodeWriteRequest(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Metadata) == 0 {
+			return
+		}
+
+		select {
+		case metadataResult <- req.Metadata:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	cfg := fmt.Sprintf(`
+		endpoint {
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+
+			metadata_config {
+				send_interval = "100ms"
+			}
+		}
+	`, srv.URL)
+
+	var args remotewrite.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(time.Second))
+
+	rwExports := tc.Exports().(remotewrite.Exports)
+	appender := rwExports.Receiver.Appender(context.Background())
+	lset := labels.FromStrings("__name__", "request_duration_seconds_bucket", "le", "0.5")
+	ref, err := appender.Append(0, lset, time.Now().UnixMilli(), 1)
+	require.NoError(t, err)
+	_, err = appender.UpdateMetadata(ref, lset, metadata.Metadata{
+		Type: textparse.MetricTypeHistogram,
+		Unit: "seconds",
+		Help: "Duration of requests.",
+	})
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	expect := []prompb.MetricMetadata{{
+		Type:             prompb.MetricMetadata_HISTOGRAM,
+		MetricFamilyName: "request_duration_seconds",
+		Help:             "Duration of requests.",
+		Unit:             "seconds",
+	}}
+
+	select {
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for metadata")
+	case res := <-metadataResult:
+		require.Equal(t, expect, res)
+	}
+}
@@ -0,0 +1,248 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+// walReplay sends the samples of a WAL whose timestamp falls within a time
+// range to an endpoint. It's used to catch up an endpoint on the samples which
+// were written to the WAL before its queue was started.
+//
+// Only float samples are replayed; exemplars and native histograms are not.
+type walReplay struct {
+	log            log.Logger
+	dir            string
+	client         remote.WriteClient
+	externalLabels labels.Labels
+	queue          QueueOptions
+
+	// Samples are replayed if their timestamp is in the range (from, to].
+	from, to int64
+
+	// sent is called with the number of samples of every request which was
+	// sent successfully.
+	sent func(n int)
+
+	readerMetrics *wlog.LiveReaderMetrics
+	dec           record.Decoder
+	series        map[chunks.HeadSeriesRef]labels.Labels
+	pending       []prompb.TimeSeries
+}
+
+// newWALReplay creates a walReplay which sends the samples of the WAL at dir
+// with a timestamp in the range (from, to] to the endpoint ep.
+func newWALReplay(l log.Logger, dir string, ep *EndpointOptions, externalLabels labels.Labels, from, to int64, sent func(n int)) (*walReplay, error) {
+	client, err := newWriteClient("replay_"+ep.Name, ep)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := DefaultQueueOptions
+	if ep.QueueOptions != nil {
+		queue = *ep.QueueOptions
+	}
+
+	return &walReplay{
+		log:            log.With(l, "endpoint", ep.Name),
+		dir:            dir,
+		client:         client,
+		externalLabels: externalLabels,
+		queue:          queue,
+
+		from: from,
+		to:   to,
+		sent: sent,
+
+		readerMetrics: wlog.NewLiveReaderMetrics(nil),
+		series:        make(map[chunks.HeadSeriesRef]labels.Labels),
+	}, nil
+}
+
+// Run reads the last checkpoint and all the segments after it, and sends the
+// samples in the time range. Run returns once all samples were sent or
+// dropped, or when ctx is canceled.
+func (r *walReplay) Run(ctx context.Context) error {
+	level.Info(r.log).Log("msg", "replaying WAL", "from", r.from, "to", r.to)
+
+	var segments []string
+
+	checkpoint, checkpointIndex, err := wlog.LastCheckpoint(r.dir)
+	switch {
+	case err == nil:
+		checkpointSegments, err := segmentNames(checkpoint, -1)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, checkpointSegments...)
+	case errors.Is(err, record.ErrNotFound):
+		checkpointIndex = -1
+	default:
+		return err
+	}
+
+	walSegments, err := segmentNames(r.dir, checkpointIndex)
+	if err != nil {
+		return err
+	}
+	segments = append(segments, walSegments...)
+
+	for _, name := range segments {
+		if err := r.readSegment(ctx, name); err != nil {
+			return err
+		}
+	}
+	if err := r.flush(ctx); err != nil {
+		return err
+	}
+
+	level.Info(r.log).Log("msg", "finished replaying WAL")
+	return nil
+}
+
+// segmentNames returns the file names of the segments in dir whose index is
+// greater than after.
+func segmentNames(dir string, after int) ([]string, error) {
+	first, last, err := wlog.Segments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if first <= after {
+		first = after + 1
+	}
+
+	var res []string
+	for i := first; first >= 0 && i <= last; i++ {
+		res = append(res, wlog.SegmentName(dir, i))
+	}
+	return res, nil
+}
+
+func (r *walReplay) readSegment(ctx context.Context, name string) error {
+	segment, err := wlog.OpenReadSegment(name)
+	if err != nil {
+		return err
+	}
+	defer segment.Close()
+
+	var (
+		// The last segment may still be written to, so segments are read with
+		// a live reader which stops at the last complete record.
+		reader  = wlog.NewLiveReader(r.log, r.readerMetrics, segment)
+		series  []record.RefSeries
+		samples []record.RefSample
+	)
+	for reader.Next() {
+		rec := reader.Record()
+
+		switch r.dec.Type(rec) {
+		case record.Series:
+			series, err = r.dec.Series(rec, series[:0])
+			if err != nil {
+				return err
+			}
+			for _, s := range series {
+				r.series[s.Ref] = s.Labels
+			}
+
+		case record.Samples:
+			samples, err = r.dec.Samples(rec, samples[:0])
+			if err != nil {
+				return err
+			}
+			for _, s := range samples {
+				if s.T <= r.from || s.T > r.to {
+					continue
+				}
+				lset, ok := r.series[s.Ref]
+				if !ok {
+					// The series was removed from the WAL.
+					continue
+				}
+
+				r.pending = append(r.pending, prompb.TimeSeries{
+					Labels:  r.labels(lset),
+					Samples: []prompb.Sample{{Value: s.V, Timestamp: s.T}},
+				})
+				if len(r.pending) >= r.queue.MaxSamplesPerSend {
+					if err := r.flush(ctx); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	if err := reader.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// labels returns lset with the external labels which it doesn't set itself.
+func (r *walReplay) labels(lset labels.Labels) []prompb.Label {
+	b := labels.NewBuilder(lset)
+	for _, l := range r.externalLabels {
+		if lset.Get(l.Name) == "" {
+			b.Set(l.Name, l.Value)
+		}
+	}
+
+	var res []prompb.Label
+	b.Labels(nil).Range(func(l labels.Label) {
+		res = append(res, prompb.Label{Name: l.Name, Value: l.Value})
+	})
+	return res
+}
+
+// flush sends the pending samples, retrying the request for as long as it
+// fails with a recoverable error.
+func (r *walReplay) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	data, err := proto.Marshal(&prompb.WriteRequest{Timeseries: r.pending})
+	if err != nil {
+		return err
+	}
+	req := snappy.Encode(nil, data)
+
+	b := backoff.New(ctx, backoff.Config{
+		MinBackoff: r.queue.MinBackoff,
+		MaxBackoff: r.queue.MaxBackoff,
+	})
+	for b.Ongoing() {
+		err = r.client.Store(ctx, req)
+		if err == nil {
+			r.sent(len(r.pending))
+			r.pending = r.pending[:0]
+			return nil
+		}
+
+		var recoverable remote.RecoverableError
+		if !errors.As(err, &recoverable) {
+			// Like the remote_write queues do, samples which the endpoint rejected
+			// are dropped.
+			level.Error(r.log).Log("msg", "endpoint rejected replayed samples, dropping them", "count", len(r.pending), "err", err)
+			r.pending = r.pending[:0]
+			return nil
+		}
+		level.Warn(r.log).Log("msg", "failed to send replayed samples, retrying", "err", err)
+		b.Wait()
+	}
+	return b.Err()
+}
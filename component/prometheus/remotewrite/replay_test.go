@@ -0,0 +1,90 @@
+package remotewrite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/metrics/wal"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWALReplay(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received []prompb.TimeSeries
+		requests int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := remote.DecodeWriteRequest(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		// Fail the first request to make sure that it's retried.
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, req.Timeseries...)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	walStorage, err := wal.NewStorage(log.NewNopLogger(), nil, dir)
+	require.NoError(t, err)
+	defer walStorage.Close()
+
+	app := walStorage.Appender(context.Background())
+	for ts := int64(1000); ts <= 5000; ts += 1000 {
+		_, err := app.Append(0, labels.FromStrings("__name__", "up", "job", "a"), ts, float64(ts))
+		require.NoError(t, err)
+		_, err = app.Append(0, labels.FromStrings("__name__", "up", "job", "b", "cluster", "override"), ts, float64(ts))
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	ep := GetDefaultEndpointOptions()
+	ep.Name = "test"
+	ep.URL = srv.URL
+	ep.QueueOptions = &QueueOptions{MaxSamplesPerSend: 3}
+
+	var sent int
+	r, err := newWALReplay(log.NewNopLogger(), wal.SubDirectory(dir), &ep, labels.FromStrings("cluster", "local"), 2000, 4000, func(n int) { sent += n })
+	require.NoError(t, err)
+	require.NoError(t, r.Run(context.Background()))
+
+	series := func(job, cluster string, ts int64) prompb.TimeSeries {
+		return prompb.TimeSeries{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "up"},
+				{Name: "cluster", Value: cluster},
+				{Name: "job", Value: job},
+			},
+			Samples: []prompb.Sample{{Timestamp: ts, Value: float64(ts)}},
+		}
+	}
+	expect := []prompb.TimeSeries{
+		series("a", "local", 3000),
+		series("b", "override", 3000),
+		series("a", "local", 4000),
+		series("b", "override", 4000),
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Equal(t, expect, received)
+	require.Equal(t, 4, sent)
+	require.Equal(t, 3, requests)
+}
@@ -8,13 +8,16 @@ import (
 
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
 
 	"github.com/prometheus/prometheus/config"
 
 	types "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/river"
 	common "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 )
 
 // Defaults for config blocks.
@@ -47,14 +50,16 @@ var (
 	}
 
 	_ river.Unmarshaler = (*QueueOptions)(nil)
+	_ river.Unmarshaler = (*SigV4Config)(nil)
 )
 
 // Arguments represents the input state of the prometheus.remote_write
 // component.
 type Arguments struct {
-	ExternalLabels map[string]string  `river:"external_labels,attr,optional"`
-	Endpoints      []*EndpointOptions `river:"endpoint,block,optional"`
-	WALOptions     WALOptions         `river:"wal,block,optional"`
+	ExternalLabels map[string]string   `river:"external_labels,attr,optional"`
+	Endpoints      []*EndpointOptions  `river:"endpoint,block,optional"`
+	Failover       []FailoverArguments `river:"failover,block,optional"`
+	WALOptions     WALOptions          `river:"wal,block,optional"`
 }
 
 // UnmarshalRiver implements river.Unmarshaler.
@@ -62,7 +67,18 @@ func (rc *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*rc = DefaultArguments
 
 	type config Arguments
-	return f((*config)(rc))
+	if err := f((*config)(rc)); err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{}, len(rc.Failover))
+	for _, fo := range rc.Failover {
+		if _, ok := names[fo.Name]; ok {
+			return fmt.Errorf("found multiple failover blocks named %q", fo.Name)
+		}
+		names[fo.Name] = struct{}{}
+	}
+	return nil
 }
 
 // EndpointOptions describes an individual location for where metrics in the WAL
@@ -77,6 +93,7 @@ type EndpointOptions struct {
 	HTTPClientConfig     *types.HTTPClientConfig `river:",squash"`
 	QueueOptions         *QueueOptions           `river:"queue_config,block,optional"`
 	MetadataOptions      *MetadataOptions        `river:"metadata_config,block,optional"`
+	SigV4                *SigV4Config            `river:"sigv4,block,optional"`
 }
 
 func GetDefaultEndpointOptions() EndpointOptions {
@@ -101,7 +118,15 @@ func (r *EndpointOptions) UnmarshalRiver(f func(v interface{}) error) error {
 
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
-		return r.HTTPClientConfig.Validate()
+		if err := r.HTTPClientConfig.Validate(); err != nil {
+			return err
+		}
+
+		httpClientAuthEnabled := r.HTTPClientConfig.BasicAuth != nil ||
+			r.HTTPClientConfig.Authorization != nil || r.HTTPClientConfig.OAuth2 != nil
+		if httpClientAuthEnabled && r.SigV4 != nil {
+			return fmt.Errorf("at most one of basic_auth, authorization, oauth2, & sigv4 must be configured")
+		}
 	}
 
 	return nil
@@ -172,11 +197,101 @@ func (o *MetadataOptions) toPrometheusType() config.MetadataConfig {
 	}
 }
 
+// SigV4Config configures signing requests with AWS Signature Version 4.
+// Empty values are retrieved using the AWS default credentials chain.
+type SigV4Config struct {
+	Region    string            `river:"region,attr,optional"`
+	AccessKey string            `river:"access_key,attr,optional"`
+	SecretKey rivertypes.Secret `river:"secret_key,attr,optional"`
+	Profile   string            `river:"profile,attr,optional"`
+	RoleARN   string            `river:"role_arn,attr,optional"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *SigV4Config) UnmarshalRiver(f func(v interface{}) error) error {
+	type config SigV4Config
+	if err := f((*config)(c)); err != nil {
+		return err
+	}
+
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return fmt.Errorf("access_key and secret_key must both be set if either is set")
+	}
+	return nil
+}
+
+func (c *SigV4Config) toPrometheusType() *sigv4.SigV4Config {
+	if c == nil {
+		return nil
+	}
+
+	return &sigv4.SigV4Config{
+		Region:    c.Region,
+		AccessKey: c.AccessKey,
+		SecretKey: common.Secret(c.SecretKey),
+		Profile:   c.Profile,
+		RoleARN:   c.RoleARN,
+	}
+}
+
+// FailoverArguments configures a group of two endpoints, where metrics are
+// only sent to the secondary endpoint while the primary endpoint is
+// unhealthy. When switching between the endpoints, the endpoint which becomes
+// active is caught up from the WAL starting at the delivery watermark of the
+// other endpoint.
+type FailoverArguments struct {
+	Name          string          `river:"name,attr"`
+	FailoverAfter time.Duration   `river:"failover_after,attr,optional"`
+	FailbackAfter time.Duration   `river:"failback_after,attr,optional"`
+	Primary       EndpointOptions `river:"primary,block"`
+	Secondary     EndpointOptions `river:"secondary,block"`
+}
+
+// DefaultFailoverArguments holds default settings for FailoverArguments.
+var DefaultFailoverArguments = FailoverArguments{
+	FailoverAfter: time.Minute,
+	FailbackAfter: 5 * time.Minute,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *FailoverArguments) UnmarshalRiver(f func(v interface{}) error) error {
+	*a = DefaultFailoverArguments
+
+	type arguments FailoverArguments
+	if err := f((*arguments)(a)); err != nil {
+		return err
+	}
+
+	switch {
+	case a.Name == "":
+		return fmt.Errorf("failover name must not be empty")
+	case a.FailoverAfter <= 0:
+		return fmt.Errorf("failover_after must be greater than 0")
+	case a.FailbackAfter <= 0:
+		return fmt.Errorf("failback_after must be greater than 0")
+	}
+
+	// The endpoints are identified by their name in the metrics of the
+	// remote_write queues, which are used to track their delivery watermarks.
+	if a.Primary.Name == "" {
+		a.Primary.Name = a.Name + "-primary"
+	}
+	if a.Secondary.Name == "" {
+		a.Secondary.Name = a.Name + "-secondary"
+	}
+	if a.Primary.Name == a.Secondary.Name {
+		return fmt.Errorf("primary and secondary endpoints must have different names")
+	}
+	return nil
+}
+
 // WALOptions configures behavior within the WAL.
 type WALOptions struct {
 	TruncateFrequency time.Duration `river:"truncate_frequency,attr,optional"`
 	MinKeepaliveTime  time.Duration `river:"min_keepalive_time,attr,optional"`
 	MaxKeepaliveTime  time.Duration `river:"max_keepalive_time,attr,optional"`
+
+	OutOfOrderTimeWindow time.Duration `river:"out_of_order_time_window,attr,optional"`
 }
 
 // UnmarshalRiver implements river.Unmarshaler.
@@ -193,6 +308,8 @@ func (o *WALOptions) UnmarshalRiver(f func(interface{}) error) error {
 		return fmt.Errorf("truncate_frequency must not be 0")
 	case o.MaxKeepaliveTime <= o.MinKeepaliveTime:
 		return fmt.Errorf("min_keepalive_time must be smaller than max_keepalive_time")
+	case o.OutOfOrderTimeWindow < 0:
+		return fmt.Errorf("out_of_order_time_window must not be negative")
 	}
 
 	return nil
@@ -201,7 +318,8 @@ func (o *WALOptions) UnmarshalRiver(f func(interface{}) error) error {
 // Exports are the set of fields exposed by the prometheus.remote_write
 // component.
 type Exports struct {
-	Receiver storage.Appendable `river:"receiver,attr"`
+	Receiver           storage.Appendable  `river:"receiver,attr"`
+	DeliveryWatermarks []DeliveryWatermark `river:"delivery_watermarks,attr"`
 }
 
 func convertConfigs(cfg Arguments) (*config.Config, error) {
@@ -224,7 +342,7 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 			HTTPClientConfig: *rw.HTTPClientConfig.Convert(),
 			QueueConfig:      rw.QueueOptions.toPrometheusType(),
 			MetadataConfig:   rw.MetadataOptions.toPrometheusType(),
-			// TODO(rfratto): SigV4Config
+			SigV4Config:      rw.SigV4.toPrometheusType(),
 		})
 	}
 
@@ -236,6 +354,22 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 	}, nil
 }
 
+// newWriteClient creates a client sending requests to the endpoint ep.
+func newWriteClient(name string, ep *EndpointOptions) (remote.WriteClient, error) {
+	parsedURL, err := url.Parse(ep.URL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse remote_write url %q: %w", ep.URL, err)
+	}
+	return remote.NewWriteClient(name, &remote.ClientConfig{
+		URL:              &common.URL{URL: parsedURL},
+		Timeout:          model.Duration(ep.RemoteTimeout),
+		HTTPClientConfig: *ep.HTTPClientConfig.Convert(),
+		SigV4Config:      ep.SigV4.toPrometheusType(),
+		Headers:          ep.Headers,
+		RetryOnRateLimit: ep.QueueOptions.toPrometheusType().RetryOnRateLimit,
+	})
+}
+
 func toLabels(in map[string]string) labels.Labels {
 	res := make(labels.Labels, 0, len(in))
 	for k, v := range in {
@@ -2,6 +2,7 @@ package remotewrite
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/pkg/river"
 	"github.com/stretchr/testify/require"
@@ -53,3 +54,182 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestSigV4Config(t *testing.T) {
+	var exampleRiverConfig = `
+		endpoint {
+			url = "https://aps-workspaces.us-east-1.amazonaws.com/workspaces/ws-XXX/api/v1/remote_write"
+
+			sigv4 {
+				region     = "us-east-1"
+				access_key = "access-key"
+				secret_key = "secret-key"
+				role_arn   = "arn:aws:iam::123456789012:role/agent"
+			}
+		}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+
+	cfg, err := convertConfigs(args)
+	require.NoError(t, err)
+	require.Len(t, cfg.RemoteWriteConfigs, 1)
+
+	sigv4 := cfg.RemoteWriteConfigs[0].SigV4Config
+	require.NotNil(t, sigv4)
+	require.Equal(t, "us-east-1", sigv4.Region)
+	require.Equal(t, "access-key", sigv4.AccessKey)
+	require.Equal(t, "secret-key", string(sigv4.SecretKey))
+	require.Equal(t, "arn:aws:iam::123456789012:role/agent", sigv4.RoleARN)
+}
+
+func TestBadSigV4Config(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "access key without secret key",
+			cfg: `
+				endpoint {
+					url = "http://0.0.0.0:11111/api/v1/write"
+
+					sigv4 {
+						access_key = "access-key"
+					}
+				}
+			`,
+			expectedErr: "access_key and secret_key must both be set if either is set",
+		},
+		{
+			name: "sigv4 with basic_auth",
+			cfg: `
+				endpoint {
+					url = "http://0.0.0.0:11111/api/v1/write"
+
+					basic_auth {
+						username = "user"
+						password = "pass"
+					}
+
+					sigv4 { }
+				}
+			`,
+			expectedErr: "at most one of basic_auth, authorization, oauth2, & sigv4 must be configured",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestWALOptions_OutOfOrderTimeWindow(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		wal {
+			out_of_order_time_window = "10m"
+		}
+`), &args))
+	require.Equal(t, 10*time.Minute, args.WALOptions.OutOfOrderTimeWindow)
+
+	err := river.Unmarshal([]byte(`
+		wal {
+			out_of_order_time_window = "-1m"
+		}
+`), &args)
+	require.ErrorContains(t, err, "out_of_order_time_window must not be negative")
+}
+
+func TestFailoverRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+		failover {
+			name           = "main"
+			failover_after = "30s"
+
+			primary {
+				url = "http://mimir-a:9009/api/v1/push"
+			}
+			secondary {
+				name = "backup"
+				url  = "http://mimir-b:9009/api/v1/push"
+			}
+		}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Len(t, args.Failover, 1)
+
+	fo := args.Failover[0]
+	require.Equal(t, 30*time.Second, fo.FailoverAfter)
+	require.Equal(t, 5*time.Minute, fo.FailbackAfter)
+	require.Equal(t, "main-primary", fo.Primary.Name)
+	require.Equal(t, "backup", fo.Secondary.Name)
+
+	// Endpoints of failover groups get the default endpoint settings.
+	require.Equal(t, 30*time.Second, fo.Primary.RemoteTimeout)
+
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "same endpoint names",
+			cfg: `
+			failover {
+				name = "main"
+				primary {
+					name = "mimir"
+					url  = "http://mimir-a:9009/api/v1/push"
+				}
+				secondary {
+					name = "mimir"
+					url  = "http://mimir-b:9009/api/v1/push"
+				}
+			}`,
+			expectedErr: "primary and secondary endpoints must have different names",
+		},
+		{
+			name: "same group names",
+			cfg: `
+			failover {
+				name = "main"
+				primary { url = "http://mimir-a:9009/api/v1/push" }
+				secondary { url = "http://mimir-b:9009/api/v1/push" }
+			}
+			failover {
+				name = "main"
+				primary { url = "http://mimir-c:9009/api/v1/push" }
+				secondary { url = "http://mimir-d:9009/api/v1/push" }
+			}`,
+			expectedErr: `found multiple failover blocks named "main"`,
+		},
+		{
+			name: "zero failback_after",
+			cfg: `
+			failover {
+				name           = "main"
+				failback_after = "0s"
+				primary { url = "http://mimir-a:9009/api/v1/push" }
+				secondary { url = "http://mimir-b:9009/api/v1/push" }
+			}`,
+			expectedErr: "failback_after must be greater than 0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
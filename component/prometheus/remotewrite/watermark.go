@@ -0,0 +1,145 @@
+package remotewrite
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// watermarkMetric is the metric used by the remote_write queues to record the
+// timestamp of the newest sample they sent successfully.
+const watermarkMetric = "prometheus_remote_storage_queue_highest_sent_timestamp_seconds"
+
+// highestTimestampMetric is the metric used by the remote storage to record
+// the timestamp of the newest sample written to the WAL.
+const highestTimestampMetric = "prometheus_remote_storage_highest_timestamp_in_seconds"
+
+// DeliveryWatermark reports how far an endpoint has caught up with the data
+// written to the WAL.
+type DeliveryWatermark struct {
+	Name string `river:"name,attr"`
+	URL  string `river:"url,attr"`
+
+	// Timestamp is the Unix timestamp, in seconds, of the newest sample which
+	// was successfully sent to the endpoint. It is 0 if no samples have been
+	// sent yet.
+	Timestamp int64 `river:"timestamp,attr"`
+}
+
+// teeRegisterer registers collectors to two registerers. It's used to collect
+// the metrics of the remote_write queues from a private registry while still
+// exposing them as metrics of the component.
+type teeRegisterer struct {
+	primary   prometheus.Registerer
+	secondary prometheus.Registerer
+}
+
+var _ prometheus.Registerer = teeRegisterer{}
+
+// Register implements prometheus.Registerer.
+func (t teeRegisterer) Register(c prometheus.Collector) error {
+	if err := t.primary.Register(c); err != nil {
+		return err
+	}
+	if err := t.secondary.Register(c); err != nil {
+		t.primary.Unregister(c)
+		return err
+	}
+	return nil
+}
+
+// MustRegister implements prometheus.Registerer.
+func (t teeRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := t.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister implements prometheus.Registerer.
+func (t teeRegisterer) Unregister(c prometheus.Collector) bool {
+	secondary := t.secondary.Unregister(c)
+	return t.primary.Unregister(c) && secondary
+}
+
+// gatherWatermarks returns the delivery watermark of every endpoint whose
+// queue metrics are registered to g, sorted by name.
+func gatherWatermarks(g prometheus.Gatherer) ([]DeliveryWatermark, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []DeliveryWatermark
+	for _, mf := range families {
+		if mf.GetName() != watermarkMetric {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			wm := DeliveryWatermark{Timestamp: int64(m.GetGauge().GetValue())}
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "remote_name":
+					wm.Name = l.GetValue()
+				case "url":
+					wm.URL = l.GetValue()
+				}
+			}
+			res = append(res, wm)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Name != res[j].Name {
+			return res[i].Name < res[j].Name
+		}
+		return res[i].URL < res[j].URL
+	})
+	return res, nil
+}
+
+// gatherSendProgress returns the timestamp of the newest sample written to the
+// WAL, and the timestamp of the newest sample sent by every endpoint whose
+// queue metrics are registered to g, keyed by the name of the endpoint. All
+// timestamps are in milliseconds.
+func gatherSendProgress(g prometheus.Gatherer) (newest int64, sent map[string]int64, err error) {
+	families, err := g.Gather()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sent = make(map[string]int64)
+	for _, mf := range families {
+		switch mf.GetName() {
+		case highestTimestampMetric:
+			for _, m := range mf.GetMetric() {
+				newest = int64(m.GetGauge().GetValue() * 1000)
+			}
+		case watermarkMetric:
+			for _, m := range mf.GetMetric() {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "remote_name" {
+						sent[l.GetValue()] = int64(m.GetGauge().GetValue() * 1000)
+					}
+				}
+			}
+		}
+	}
+	return newest, sent, nil
+}
+
+// equalWatermarks reports whether a and b contain the same watermarks in the
+// same order.
+func equalWatermarks(a, b []DeliveryWatermark) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
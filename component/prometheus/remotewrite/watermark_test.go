@@ -0,0 +1,43 @@
+package remotewrite
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherWatermarks(t *testing.T) {
+	var (
+		primary   = prometheus.NewRegistry()
+		secondary = prometheus.NewRegistry()
+		reg       = teeRegisterer{primary: primary, secondary: secondary}
+	)
+
+	highestSent := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: watermarkMetric,
+	}, []string{"remote_name", "url"})
+	reg.MustRegister(highestSent)
+
+	highestSent.WithLabelValues("b", "http://b/api/v1/write").Set(1680000000)
+	highestSent.WithLabelValues("a", "http://a/api/v1/write").Set(1690000000)
+
+	expect := []DeliveryWatermark{
+		{Name: "a", URL: "http://a/api/v1/write", Timestamp: 1690000000},
+		{Name: "b", URL: "http://b/api/v1/write", Timestamp: 1680000000},
+	}
+	actual, err := gatherWatermarks(secondary)
+	require.NoError(t, err)
+	require.Equal(t, expect, actual)
+
+	// The metric must still be exposed by the component.
+	actual, err = gatherWatermarks(primary)
+	require.NoError(t, err)
+	require.Equal(t, expect, actual)
+
+	// Unregistering removes the metric from both registries.
+	require.True(t, reg.Unregister(highestSent))
+	actual, err = gatherWatermarks(secondary)
+	require.NoError(t, err)
+	require.Empty(t, actual)
+}
@@ -0,0 +1,240 @@
+// Package rewrite_histograms provides a prometheus.rewrite_histograms
+// component.
+package rewrite_histograms
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.rewrite_histograms",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// prometheus.rewrite_histograms component.
+type Arguments struct {
+	// Where the rewritten metrics should be forwarded to.
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	// Rules to determine which buckets of which histograms are kept.
+	Rules []Rule `river:"rule,block,optional"`
+}
+
+// Rule configures the buckets kept for classic histograms whose names match
+// a regular expression.
+type Rule struct {
+	Match      string    `river:"match,attr"`
+	Boundaries []float64 `river:"boundaries,attr"`
+}
+
+// Exports holds values which are exported by the
+// prometheus.rewrite_histograms component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// compiledRule is a Rule ready to be applied to samples.
+type compiledRule struct {
+	match      *regexp.Regexp
+	boundaries map[float64]struct{}
+}
+
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	res := make([]compiledRule, 0, len(rules))
+	for i, r := range rules {
+		re, err := regexp.Compile("^(?:" + r.Match + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid match expression: %w", i, err)
+		}
+		if len(r.Boundaries) == 0 {
+			return nil, fmt.Errorf("rule %d: boundaries must not be empty", i)
+		}
+
+		boundaries := make(map[float64]struct{}, len(r.Boundaries))
+		for _, b := range r.Boundaries {
+			if math.IsNaN(b) {
+				return nil, fmt.Errorf("rule %d: boundaries must not contain NaN", i)
+			}
+			boundaries[b] = struct{}{}
+		}
+		res = append(res, compiledRule{match: re, boundaries: boundaries})
+	}
+	return res, nil
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	_, err := compileRules(args.Rules)
+	return err
+}
+
+// Component implements the prometheus.rewrite_histograms component.
+type Component struct {
+	opts     component.Options
+	receiver *prometheus.Interceptor
+	fanout   *prometheus.Fanout
+	exited   atomic.Bool
+
+	bucketsDropped prometheus_client.Counter
+
+	mut   sync.RWMutex
+	rules []compiledRule
+	// ruleCache caches the rule which applies to a histogram name. A nil rule
+	// means no rule applies.
+	ruleCache map[string]*compiledRule
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// New creates a new prometheus.rewrite_histograms component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{opts: o}
+
+	c.bucketsDropped = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_rewrite_histograms_buckets_dropped_total",
+		Help: "Total number of histogram bucket samples dropped because their boundary was not kept.",
+	})
+	if err := o.Registerer.Register(c.bucketsDropped); err != nil {
+		return nil, err
+	}
+
+	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	c.receiver = prometheus.NewInterceptor(
+		c.fanout,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			if !c.keep(l) {
+				c.bucketsDropped.Inc()
+				return 0, nil
+			}
+			return next.Append(ref, l, t, v)
+		}),
+		prometheus.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			if !c.keep(l) {
+				return 0, nil
+			}
+			return next.AppendExemplar(ref, l, e)
+		}),
+	)
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.exited.Store(true)
+
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	rules, err := compileRules(newArgs.Rules)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	c.rules = rules
+	c.ruleCache = make(map[string]*compiledRule)
+	c.mut.Unlock()
+
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	return nil
+}
+
+// keep reports whether the series with labels l should be forwarded. Only
+// bucket series of matching classic histograms whose le label isn't one of
+// the rule's boundaries are dropped. The +Inf bucket is always kept so that
+// the histogram stays valid.
+func (c *Component) keep(l labels.Labels) bool {
+	name := l.Get(labels.MetricName)
+	if !strings.HasSuffix(name, "_bucket") {
+		return true
+	}
+
+	le := l.Get(labels.BucketLabel)
+	if le == "" {
+		return true
+	}
+	boundary, err := strconv.ParseFloat(le, 64)
+	if err != nil || math.IsInf(boundary, +1) {
+		return true
+	}
+
+	rule := c.ruleFor(strings.TrimSuffix(name, "_bucket"))
+	if rule == nil {
+		return true
+	}
+	_, ok := rule.boundaries[boundary]
+	return ok
+}
+
+// ruleFor returns the first rule matching the histogram name, or nil if no
+// rule matches.
+func (c *Component) ruleFor(histogram string) *compiledRule {
+	c.mut.RLock()
+	rule, found := c.ruleCache[histogram]
+	c.mut.RUnlock()
+	if found {
+		return rule
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for i := range c.rules {
+		if c.rules[i].match.MatchString(histogram) {
+			rule = &c.rules[i]
+			break
+		}
+	}
+	c.ruleCache[histogram] = rule
+	return rule
+}
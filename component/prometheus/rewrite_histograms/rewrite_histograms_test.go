@@ -0,0 +1,95 @@
+package rewrite_histograms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteHistograms(t *testing.T) {
+	var received []string
+	next := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		received = append(received, l.String())
+		return ref, nil
+	}))
+
+	c, err := New(component.Options{
+		ID:            "1",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		ForwardTo: []storage.Appendable{next},
+		Rules: []Rule{
+			{Match: "http_.*_seconds", Boundaries: []float64{0.1, 1}},
+		},
+	})
+	require.NoError(t, err)
+
+	app := c.receiver.Appender(context.Background())
+	for _, l := range []labels.Labels{
+		labels.FromStrings("__name__", "http_request_duration_seconds_bucket", "le", "0.05"),
+		labels.FromStrings("__name__", "http_request_duration_seconds_bucket", "le", "0.1"),
+		labels.FromStrings("__name__", "http_request_duration_seconds_bucket", "le", "0.5"),
+		labels.FromStrings("__name__", "http_request_duration_seconds_bucket", "le", "1.0"),
+		labels.FromStrings("__name__", "http_request_duration_seconds_bucket", "le", "+Inf"),
+		labels.FromStrings("__name__", "http_request_duration_seconds_sum"),
+		labels.FromStrings("__name__", "http_request_duration_seconds_count"),
+		labels.FromStrings("__name__", "rpc_duration_seconds_bucket", "le", "0.5"),
+	} {
+		_, err := app.Append(0, l, 0, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	require.Equal(t, []string{
+		`{__name__="http_request_duration_seconds_bucket", le="0.1"}`,
+		`{__name__="http_request_duration_seconds_bucket", le="1.0"}`,
+		`{__name__="http_request_duration_seconds_bucket", le="+Inf"}`,
+		`{__name__="http_request_duration_seconds_sum"}`,
+		`{__name__="http_request_duration_seconds_count"}`,
+		`{__name__="rpc_duration_seconds_bucket", le="0.5"}`,
+	}, received)
+}
+
+func TestArguments(t *testing.T) {
+	cfg := `
+		forward_to = []
+
+		rule {
+			match      = "http_.*"
+			boundaries = [0.1, 0.5, 1]
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+	require.Equal(t, []Rule{{Match: "http_.*", Boundaries: []float64{0.1, 0.5, 1}}}, args.Rules)
+
+	badCfg := `
+		forward_to = []
+
+		rule {
+			match      = "http_("
+			boundaries = [0.1]
+		}
+	`
+	require.ErrorContains(t, river.Unmarshal([]byte(badCfg), &args), "rule 0: invalid match expression")
+
+	emptyCfg := `
+		forward_to = []
+
+		rule {
+			match      = "http_.*"
+			boundaries = []
+		}
+	`
+	require.EqualError(t, river.Unmarshal([]byte(emptyCfg), &args), "rule 0: boundaries must not be empty")
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/scrape"
 )
 
@@ -33,6 +34,8 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+
+		UpstreamModules: []string{"github.com/prometheus/prometheus"},
 	})
 }
 
@@ -81,6 +84,10 @@ type Arguments struct {
 
 	// Scrape Options
 	ExtraMetrics bool `river:"extra_metrics,attr,optional"`
+
+	// The maximum number of failed scrapes to log per scrape interval. Further
+	// failures are summarized in a single log line.
+	ScrapeFailureLogLimit int `river:"scrape_failure_log_limit,attr,optional"`
 }
 
 // DefaultArguments defines the default settings for a scrape job.
@@ -92,6 +99,8 @@ var DefaultArguments = Arguments{
 	HTTPClientConfig: component_config.DefaultHTTPClientConfig,
 	ScrapeInterval:   1 * time.Minute,  // From config.DefaultGlobalConfig
 	ScrapeTimeout:    10 * time.Second, // From config.DefaultGlobalConfig
+
+	ScrapeFailureLogLimit: 10,
 }
 
 // UnmarshalRiver implements river.Unmarshaler.
@@ -104,6 +113,10 @@ func (arg *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 		return err
 	}
 
+	if arg.ScrapeFailureLogLimit < 0 {
+		return fmt.Errorf("scrape_failure_log_limit must not be negative")
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	return arg.HTTPClientConfig.Validate()
 }
@@ -119,17 +132,28 @@ type Component struct {
 	scraper      *scrape.Manager
 	appendable   *prometheus.Fanout
 	targetsGauge client_prometheus.Gauge
+	targetStats  *targetStats
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.scrape component.
 func New(o component.Options, args Arguments) (*Component, error) {
 	flowAppendable := prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
-	scrapeOptions := &scrape.Options{ExtraMetrics: args.ExtraMetrics}
-	scraper := scrape.NewManager(scrapeOptions, o.Logger, flowAppendable)
+	stats := newTargetStats()
+	scrapeOptions := &scrape.Options{
+		ExtraMetrics: args.ExtraMetrics,
+		// Forward metric metadata to downstream components through
+		// UpdateMetadata.
+		EnableMetadataStorage: true,
+	}
+	scraper := scrape.NewManager(scrapeOptions, o.Logger, prometheus.NewInterceptor(
+		flowAppendable,
+		prometheus.WithAppendHook(stats.appendHook),
+	))
 
 	targetsGauge := client_prometheus.NewGauge(client_prometheus.GaugeOpts{
 		Name: "agent_prometheus_scrape_targets_gauge",
@@ -145,6 +169,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		scraper:       scraper,
 		appendable:    flowAppendable,
 		targetsGauge:  targetsGauge,
+		targetStats:   stats,
 	}
 
 	// Call to Update() to set the receivers and targets once at the start.
@@ -169,6 +194,8 @@ func (c *Component) Run(ctx context.Context) error {
 		}
 	}()
 
+	go c.watchScrapeFailures(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -194,6 +221,34 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// watchScrapeFailures periodically logs targets which failed to be scraped
+// and prunes sample counts of targets which are no longer active.
+func (c *Component) watchScrapeFailures(ctx context.Context) {
+	lastCheck := time.Now()
+
+	for {
+		c.mut.RLock()
+		var (
+			interval = c.args.ScrapeInterval
+			limit    = c.args.ScrapeFailureLogLimit
+		)
+		c.mut.RUnlock()
+		if interval <= 0 {
+			interval = DefaultArguments.ScrapeInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-time.After(interval):
+			active := c.scraper.TargetsActive()
+			logScrapeFailures(c.opts.Logger, active, lastCheck, limit)
+			c.targetStats.prune(active)
+			lastCheck = now
+		}
+	}
+}
+
 // Update implements component.Component.
 func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
@@ -269,6 +324,10 @@ type TargetStatus struct {
 	LastError          string            `river:"last_error,attr,optional"`
 	LastScrape         time.Time         `river:"last_scrape,attr"`
 	LastScrapeDuration time.Duration     `river:"last_scrape_duration,attr,optional"`
+
+	SamplesScraped     int `river:"samples_scraped,attr,optional"`
+	SamplesPostRelabel int `river:"samples_post_metric_relabeling,attr,optional"`
+	SeriesAdded        int `river:"series_added,attr,optional"`
 }
 
 // BuildTargetStatuses transforms the targets from a scrape manager into our internal status type for debug info.
@@ -299,9 +358,18 @@ func BuildTargetStatuses(targets map[string][]*scrape.Target) []TargetStatus {
 
 // DebugInfo implements component.DebugComponent
 func (c *Component) DebugInfo() interface{} {
-	return ScraperStatus{
-		TargetStatus: BuildTargetStatuses(c.scraper.TargetsActive()),
+	statuses := BuildTargetStatuses(c.scraper.TargetsActive())
+	for i, st := range statuses {
+		counts, ok := c.targetStats.get(labels.FromMap(st.Labels))
+		if !ok {
+			continue
+		}
+		statuses[i].SamplesScraped = counts.Scraped
+		statuses[i].SamplesPostRelabel = counts.PostRelabel
+		statuses[i].SeriesAdded = counts.SeriesAdded
 	}
+
+	return ScraperStatus{TargetStatus: statuses}
 }
 
 func (c *Component) componentTargetsToProm(jobName string, tgs []discovery.Target) map[string][]*targetgroup.Group {
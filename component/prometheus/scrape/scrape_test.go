@@ -2,10 +2,15 @@ package scrape
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
@@ -111,3 +116,54 @@ func TestForwardingToAppendable(t *testing.T) {
 	require.Len(t, receivedSamples, 1)
 	require.Equal(t, receivedSamples, sample)
 }
+
+func TestDebugInfoSampleCounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "a_metric 1")
+		fmt.Fprintln(w, "another_metric 2")
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	opts := component.Options{
+		ID:            "prometheus.scrape.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus_client.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := DefaultArguments
+	args.Targets = []discovery.Target{{"__address__": u.Host}}
+	args.ScrapeInterval = 100 * time.Millisecond
+	args.ScrapeTimeout = 50 * time.Millisecond
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		statuses := c.DebugInfo().(ScraperStatus).TargetStatus
+		return len(statuses) == 1 && statuses[0].SamplesScraped == 2
+	}, 15*time.Second, 50*time.Millisecond)
+
+	status := c.DebugInfo().(ScraperStatus).TargetStatus[0]
+	require.Equal(t, "up", status.Health)
+	require.Equal(t, 2, status.SamplesPostRelabel)
+}
+
+func TestNegativeScrapeFailureLogLimit(t *testing.T) {
+	var exampleRiverConfig = `
+	targets                  = [{ "target1" = "target1" }]
+	forward_to               = []
+	scrape_failure_log_limit = -1
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "scrape_failure_log_limit must not be negative")
+}
@@ -0,0 +1,138 @@
+package scrape
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// Names of the report series written by the scrape loop after every scrape.
+// See scrape.scrapeLoop.report for the upstream definitions.
+const (
+	samplesScrapedMetricName     = "scrape_samples_scraped"
+	samplesPostRelabelMetricName = "scrape_samples_post_metric_relabeling"
+	seriesAddedMetricName        = "scrape_series_added"
+)
+
+// sampleCounts holds the sample counts reported for the most recent scrape of
+// a target.
+type sampleCounts struct {
+	Scraped     int
+	PostRelabel int
+	SeriesAdded int
+}
+
+// targetStats tracks the sample counts of the most recent scrape of each
+// target. Counts are collected by observing the report series that the
+// scrape loop appends after every scrape, keyed by the hash of the target's
+// labels.
+type targetStats struct {
+	mut    sync.RWMutex
+	counts map[uint64]sampleCounts
+}
+
+func newTargetStats() *targetStats {
+	return &targetStats{counts: make(map[uint64]sampleCounts)}
+}
+
+// observe inspects an appended sample and records it if it is one of the
+// report series.
+func (ts *targetStats) observe(l labels.Labels, v float64) {
+	name := l.Get(labels.MetricName)
+	switch name {
+	case samplesScrapedMetricName, samplesPostRelabelMetricName, seriesAddedMetricName:
+	default:
+		return
+	}
+	if value.IsStaleNaN(v) {
+		return
+	}
+
+	// Report series are made of the target labels plus the metric name, so
+	// hashing without the metric name gives us the hash of the target labels.
+	hash, _ := l.HashWithoutLabels(nil)
+
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+
+	counts := ts.counts[hash]
+	switch name {
+	case samplesScrapedMetricName:
+		counts.Scraped = int(v)
+	case samplesPostRelabelMetricName:
+		counts.PostRelabel = int(v)
+	case seriesAddedMetricName:
+		counts.SeriesAdded = int(v)
+	}
+	ts.counts[hash] = counts
+}
+
+// get returns the sample counts for the target with the provided labels.
+func (ts *targetStats) get(targetLabels labels.Labels) (sampleCounts, bool) {
+	ts.mut.RLock()
+	defer ts.mut.RUnlock()
+	counts, ok := ts.counts[targetLabels.Hash()]
+	return counts, ok
+}
+
+// prune removes the sample counts of all targets not present in active.
+func (ts *targetStats) prune(active map[string][]*scrape.Target) {
+	keep := make(map[uint64]struct{})
+	for _, targets := range active {
+		for _, t := range targets {
+			keep[t.Labels().Hash()] = struct{}{}
+		}
+	}
+
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+	for hash := range ts.counts {
+		if _, ok := keep[hash]; !ok {
+			delete(ts.counts, hash)
+		}
+	}
+}
+
+// appendHook is used with prometheus.WithAppendHook to record sample counts
+// as they are sent through the pipeline.
+func (ts *targetStats) appendHook(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+	ts.observe(l, v)
+	return next.Append(ref, l, t, v)
+}
+
+// logScrapeFailures logs targets which failed a scrape after the since
+// timestamp. At most limit failures are logged individually; the remaining
+// failures are summarized into a single log line so that many flapping
+// targets do not flood the logs.
+func logScrapeFailures(logger log.Logger, active map[string][]*scrape.Target, since time.Time, limit int) {
+	var suppressed int
+
+	for job, targets := range active {
+		for _, t := range targets {
+			if t.Health() != scrape.HealthBad || !t.LastScrape().After(since) {
+				continue
+			}
+			if limit <= 0 {
+				suppressed++
+				continue
+			}
+			limit--
+
+			var lastError string
+			if err := t.LastError(); err != nil {
+				lastError = err.Error()
+			}
+			level.Warn(logger).Log("msg", "scrape failed", "job", job, "target", t.URL().String(), "err", lastError)
+		}
+	}
+
+	if suppressed > 0 {
+		level.Warn(logger).Log("msg", "additional scrape failures were not logged due to scrape_failure_log_limit", "count", suppressed)
+	}
+}
@@ -0,0 +1,199 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+// testScrapeAcceptHeader matches the Accept header used by the scrape loop
+// for text-based formats.
+const testScrapeAcceptHeader = `application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1`
+
+// TestScrapeResult is the result of a one-off scrape of a target.
+type TestScrapeResult struct {
+	Target       string             `json:"target"`
+	TargetLabels map[string]string  `json:"target_labels"`
+	Series       []TestScrapeSeries `json:"series"`
+}
+
+// TestScrapeSeries is a single series returned by a one-off scrape.
+type TestScrapeSeries struct {
+	// Before holds the series as exposed by the target.
+	Before string `json:"before"`
+	// After holds the series with the target labels applied, as it would be
+	// sent to the components in forward_to.
+	After string `json:"after"`
+	Value string `json:"value"`
+}
+
+// Handler implements component.HTTPComponent.
+func (c *Component) Handler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/scrape", c.handleTestScrape).Methods(http.MethodGet)
+	return r
+}
+
+// handleTestScrape performs a one-off scrape of the active target given by
+// the target query parameter without sending any samples to forward_to.
+func (c *Component) handleTestScrape(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("target")
+	if query == "" {
+		http.Error(w, "missing target query parameter", http.StatusBadRequest)
+		return
+	}
+
+	c.mut.RLock()
+	args := c.args
+	target := findActiveTarget(c.scraper.TargetsActive(), query)
+	c.mut.RUnlock()
+
+	if target == nil {
+		http.Error(w, fmt.Sprintf("no active target matches %q", query), http.StatusNotFound)
+		return
+	}
+
+	res, err := testScrape(r.Context(), c.opts.ID, args, target)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("scraping %s failed: %s", target.URL(), err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// findActiveTarget returns the active target whose URL, instance label, or
+// discovered address matches query.
+func findActiveTarget(active map[string][]*scrape.Target, query string) *scrape.Target {
+	for _, targets := range active {
+		for _, t := range targets {
+			if t.URL().String() == query ||
+				t.Labels().Get(model.InstanceLabel) == query ||
+				t.DiscoveredLabels().Get(model.AddressLabel) == query {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+func testScrape(ctx context.Context, name string, args Arguments, target *scrape.Target) (*TestScrapeResult, error) {
+	client, err := config_util.NewClientFromConfig(*args.HTTPClientConfig.Convert(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, args.ScrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL().String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", testScrapeAcceptHeader)
+	req.Header.Set("User-Agent", scrape.UserAgent)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(args.ScrapeTimeout.Seconds(), 'f', -1, 64))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+
+	var body io.Reader = resp.Body
+	if args.BodySizeLimit > 0 {
+		body = io.LimitReader(resp.Body, int64(args.BodySizeLimit))
+	}
+	bb, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := textparse.New(bb, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &TestScrapeResult{
+		Target:       target.URL().String(),
+		TargetLabels: target.Labels().Map(),
+	}
+	for {
+		entry, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if entry != textparse.EntrySeries {
+			continue
+		}
+
+		_, _, v := parser.Series()
+
+		var exposed labels.Labels
+		parser.Metric(&exposed)
+
+		res.Series = append(res.Series, TestScrapeSeries{
+			Before: exposed.String(),
+			After:  applyTargetLabels(exposed, target.Labels(), args.HonorLabels).String(),
+			Value:  strconv.FormatFloat(v, 'g', -1, 64),
+		})
+	}
+	return res, nil
+}
+
+// applyTargetLabels attaches targetLabels to the exposed labels of a series
+// in the same way as the scrape loop. When honor is false, exposed labels
+// which conflict with target labels are renamed with an exported_ prefix.
+func applyTargetLabels(exposed, targetLabels labels.Labels, honor bool) labels.Labels {
+	lb := labels.NewBuilder(exposed)
+
+	if honor {
+		targetLabels.Range(func(l labels.Label) {
+			if !exposed.Has(l.Name) {
+				lb.Set(l.Name, l.Value)
+			}
+		})
+		return lb.Labels(labels.EmptyLabels())
+	}
+
+	var conflicts []labels.Label
+	targetLabels.Range(func(l labels.Label) {
+		if existing := exposed.Get(l.Name); existing != "" {
+			conflicts = append(conflicts, labels.Label{Name: l.Name, Value: existing})
+		}
+		lb.Set(l.Name, l.Value)
+	})
+
+	renamed := make(map[string]struct{}, len(conflicts))
+	for _, l := range conflicts {
+		name := l.Name
+		for {
+			name = model.ExportedLabelPrefix + name
+			_, isRenamed := renamed[name]
+			if !exposed.Has(name) && !targetLabels.Has(name) && !isRenamed {
+				break
+			}
+		}
+		renamed[name] = struct{}{}
+		lb.Set(name, l.Value)
+	}
+	return lb.Labels(labels.EmptyLabels())
+}
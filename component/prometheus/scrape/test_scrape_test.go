@@ -0,0 +1,83 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/util"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTestScrape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `a_metric{instance="exposed"} 1`)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	opts := component.Options{
+		ID:            "prometheus.scrape.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus_client.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := DefaultArguments
+	args.Targets = []discovery.Target{{"__address__": u.Host}}
+	args.ScrapeInterval = time.Hour
+	args.ScrapeTimeout = 5 * time.Second
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	handler := c.Handler()
+	var rec *httptest.ResponseRecorder
+	require.Eventually(t, func() bool {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scrape?target="+u.Host, nil))
+		return rec.Code == http.StatusOK
+	}, 15*time.Second, 50*time.Millisecond)
+
+	var res TestScrapeResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, srv.URL+"/metrics", res.Target)
+	require.Equal(t, []TestScrapeSeries{{
+		Before: `{__name__="a_metric", instance="exposed"}`,
+		After:  fmt.Sprintf(`{__name__="a_metric", exported_instance="exposed", instance=%q, job="prometheus.scrape.test"}`, u.Host),
+		Value:  "1",
+	}}, res.Series)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scrape?target=unknown:1234", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestApplyTargetLabels(t *testing.T) {
+	exposed := labels.FromStrings("__name__", "up", "job", "exposed", "exported_job", "taken")
+	target := labels.FromStrings("job", "target", "instance", "localhost:9090")
+
+	require.Equal(t,
+		labels.FromStrings("__name__", "up", "job", "exposed", "exported_job", "taken", "instance", "localhost:9090"),
+		applyTargetLabels(exposed, target, true),
+	)
+	require.Equal(t,
+		labels.FromStrings("__name__", "up", "job", "target", "exported_job", "taken", "exported_exported_job", "exposed", "instance", "localhost:9090"),
+		applyTargetLabels(exposed, target, false),
+	)
+}
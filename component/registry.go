@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/grafana/agent/component/common/diskbudget"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/regexp"
 	"github.com/prometheus/client_golang/prometheus"
@@ -39,8 +41,11 @@ type Options struct {
 	// A path to a directory with this component may use for storage. The path is
 	// guaranteed to be unique across all running components.
 	//
-	// The directory may not exist when the component is created; components
-	// should create the directory if needed.
+	// The directory is created by the Flow controller before the component is
+	// built, and is kept across restarts so components can persist state such
+	// as positions files or WALs. Directories of components removed from the
+	// config are only deleted if the controller was given a data retention
+	// period, which is disabled by default; otherwise they're kept forever.
 	DataPath string
 
 	// OnStateChange may be invoked at any time by a component whose Export value
@@ -67,6 +72,15 @@ type Options struct {
 	// HTTPPath is the base path that requests need in order to route to this component.
 	// Requests received by a component handler will have this already trimmed off.
 	HTTPPath string
+
+	// AuditLog records the config changes of the Flow controllers run by
+	// module components. It is nil if the audit log is disabled.
+	AuditLog *auditlog.Log
+
+	// DiskBudget is the budget of the disk space used by the data of every
+	// component. Components storing data on disk register it as a consumer of
+	// the budget. It is nil if the data of components isn't budgeted.
+	DiskBudget *diskbudget.Budget
 }
 
 // Registration describes a single component.
@@ -117,6 +131,14 @@ type Registration struct {
 	// Build should construct a new component from an initial Arguments and set
 	// of options.
 	Build func(opts Options, args Arguments) (Component, error)
+
+	// UpstreamModules lists the Go modules of the upstream libraries which
+	// implement the component, such as the exporter wrapped by a
+	// prometheus.exporter component. The versions of these modules are
+	// reported in the component inventory so that vulnerable versions can be
+	// found. Components implemented entirely in this repository leave
+	// UpstreamModules empty.
+	UpstreamModules []string
 }
 
 // CloneArguments returns a new zero value of the registered Arguments type.
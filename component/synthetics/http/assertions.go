@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// JSONPathArgument asserts on a value selected from a JSON response body with
+// a JMESPath expression.
+type JSONPathArgument struct {
+	Expression string `river:"expression,attr"`
+	// Equals, if set, is compared to the string representation of the
+	// selected value. Otherwise, the assertion passes if the expression
+	// selects a value other than null.
+	Equals *string `river:"equals,attr,optional"`
+}
+
+// assertion is a compiled assertion checked against check results.
+type assertion struct {
+	name  string
+	check func(res checkResult) bool
+}
+
+// compileAssertions compiles the assertions of args. Assertions are named
+// after their argument, with the index of the value for arguments holding
+// several values.
+func compileAssertions(args AssertionsArguments) ([]assertion, error) {
+	var res []assertion
+
+	if len(args.StatusCodes) > 0 {
+		codes := args.StatusCodes
+		res = append(res, assertion{name: "status_code", check: func(r checkResult) bool {
+			for _, code := range codes {
+				if r.statusCode == code {
+					return true
+				}
+			}
+			return false
+		}})
+	} else {
+		res = append(res, assertion{name: "status_code", check: func(r checkResult) bool {
+			return r.statusCode >= 200 && r.statusCode < 300
+		}})
+	}
+
+	for i, expr := range args.BodyMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body_matches expression %q: %w", expr, err)
+		}
+		res = append(res, assertion{name: indexedName("body_matches", i), check: func(r checkResult) bool {
+			return re.Match(r.body)
+		}})
+	}
+	for i, expr := range args.BodyNotMatches {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body_not_matches expression %q: %w", expr, err)
+		}
+		res = append(res, assertion{name: indexedName("body_not_matches", i), check: func(r checkResult) bool {
+			return !re.Match(r.body)
+		}})
+	}
+
+	for i, jp := range args.JSONPath {
+		expr, err := jmespath.Compile(jp.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json_path expression %q: %w", jp.Expression, err)
+		}
+		equals := jp.Equals
+		res = append(res, assertion{name: indexedName("json_path", i), check: func(r checkResult) bool {
+			return checkJSONPath(expr, equals, r.body)
+		}})
+	}
+
+	if args.MaxLatency > 0 {
+		max := args.MaxLatency
+		res = append(res, assertion{name: "max_latency", check: func(r checkResult) bool {
+			return r.duration <= max
+		}})
+	}
+	return res, nil
+}
+
+func indexedName(name string, i int) string {
+	return name + "[" + strconv.Itoa(i) + "]"
+}
+
+// checkJSONPath evaluates expr against body. It returns false if body isn't
+// valid JSON.
+func checkJSONPath(expr *jmespath.JMESPath, equals *string, body []byte) bool {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return false
+	}
+	v, err := expr.Search(doc)
+	if err != nil || v == nil || isEmptyValue(v) && equals == nil {
+		return false
+	}
+	if equals == nil {
+		return true
+	}
+	return valueString(v) == *equals
+}
+
+// isEmptyValue reports whether v is a JSON value which JMESPath considers
+// false: an empty list, object, or string, or false.
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case []interface{}, map[string]interface{}:
+		return reflect.ValueOf(v).Len() == 0
+	}
+	return false
+}
+
+// valueString returns the string representation of a JSON value. Strings are
+// returned unquoted, and other values are encoded as JSON.
+func valueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	bb, _ := json.Marshal(v)
+	return string(bb)
+}
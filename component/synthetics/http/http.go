@@ -0,0 +1,485 @@
+// Package http implements the synthetics.http component.
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/loki/pkg/logproto"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "synthetics.http",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the synthetics.http
+// component.
+type Arguments struct {
+	URL     string            `river:"url,attr"`
+	Method  string            `river:"method,attr,optional"`
+	Headers map[string]string `river:"headers,attr,optional"`
+	Body    string            `river:"body,attr,optional"`
+
+	Interval time.Duration     `river:"interval,attr,optional"`
+	Timeout  time.Duration     `river:"timeout,attr,optional"`
+	Labels   map[string]string `river:"labels,attr,optional"`
+
+	ForwardTo     []storage.Appendable `river:"forward_to,attr"`
+	LogsForwardTo []loki.LogsReceiver  `river:"logs_forward_to,attr,optional"`
+	ExcerptSize   int                  `river:"response_excerpt_size,attr,optional"`
+
+	Assertions AssertionsArguments     `river:"assertions,block,optional"`
+	Client     config.HTTPClientConfig `river:"client,block,optional"`
+}
+
+// AssertionsArguments holds the assertions checked against every response.
+type AssertionsArguments struct {
+	StatusCodes    []int              `river:"status_codes,attr,optional"`
+	BodyMatches    []string           `river:"body_matches,attr,optional"`
+	BodyNotMatches []string           `river:"body_not_matches,attr,optional"`
+	MaxLatency     time.Duration      `river:"max_latency,attr,optional"`
+	JSONPath       []JSONPathArgument `river:"json_path,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Method:      http.MethodGet,
+	Interval:    1 * time.Minute,
+	Timeout:     10 * time.Second,
+	ExcerptSize: 1024,
+	Client:      config.DefaultHTTPClientConfig,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.URL == "":
+		return fmt.Errorf("url must not be empty")
+	case args.Interval <= 0:
+		return fmt.Errorf("interval must be greater than 0")
+	case args.Timeout <= 0:
+		return fmt.Errorf("timeout must be greater than 0")
+	case args.Timeout > args.Interval:
+		return fmt.Errorf("timeout must not be greater than interval")
+	case args.ExcerptSize < 0:
+		return fmt.Errorf("response_excerpt_size must not be negative")
+	case args.Assertions.MaxLatency < 0:
+		return fmt.Errorf("max_latency must not be negative")
+	}
+	for _, code := range args.Assertions.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid status code %d", code)
+		}
+	}
+	if _, err := http.NewRequest(args.Method, args.URL, nil); err != nil {
+		return err
+	}
+	if _, err := compileAssertions(args.Assertions); err != nil {
+		return err
+	}
+	return args.Client.Validate()
+}
+
+// Component implements the synthetics.http component.
+type Component struct {
+	log     log.Logger
+	opts    component.Options
+	fanout  *prometheus.Fanout
+	metrics *metrics
+
+	mut        sync.Mutex
+	args       Arguments
+	assertions []assertion
+	cli        *http.Client
+	lastCheck  time.Time
+
+	debugMut      sync.RWMutex
+	lastCheckInfo checkInfo
+
+	// Updated is written to whenever args updates.
+	updated chan struct{}
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new synthetics.http component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		log:     o.Logger,
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		metrics: newMetrics(o.Registerer),
+		updated: make(chan struct{}, 1),
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.nextCheck()):
+			c.check(ctx)
+		case <-c.updated:
+			// no-op; force the next wait to be reread.
+		}
+	}
+}
+
+// nextCheck returns how long to wait to check the URL given the last time it
+// was checked. nextCheck returns 0 if a check should occur immediately.
+func (c *Component) nextCheck() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	next := c.lastCheck.Add(c.args.Interval)
+	now := time.Now()
+	if now.After(next) {
+		return 0
+	}
+	return next.Sub(now)
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	assertions, err := compileAssertions(newArgs.Assertions)
+	if err != nil {
+		return err
+	}
+
+	cli, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		c.opts.ID,
+		prom_config.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return err
+	}
+
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	c.mut.Lock()
+	c.args = newArgs
+	c.assertions = assertions
+	c.cli = cli
+	c.mut.Unlock()
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// checkResult is the result of checking the URL once.
+type checkResult struct {
+	time       time.Time
+	duration   time.Duration
+	statusCode int
+	body       []byte
+	err        error // Error performing the request.
+	failed     []string
+	assertions []assertionResult
+}
+
+func (r checkResult) success() bool { return r.err == nil && len(r.failed) == 0 }
+
+type assertionResult struct {
+	name    string
+	success bool
+}
+
+// check performs a request against the configured URL, checks the response
+// against the assertions, and forwards the results.
+func (c *Component) check(ctx context.Context) {
+	c.mut.Lock()
+	c.lastCheck = time.Now()
+	args, assertions, cli := c.args, c.assertions, c.cli
+	c.mut.Unlock()
+
+	res := c.do(ctx, cli, args)
+	if res.err == nil {
+		for _, a := range assertions {
+			ok := a.check(res)
+			res.assertions = append(res.assertions, assertionResult{name: a.name, success: ok})
+			if !ok {
+				res.failed = append(res.failed, a.name)
+			}
+		}
+	}
+
+	c.metrics.checks.Inc()
+	if !res.success() {
+		c.metrics.failures.Inc()
+		level.Debug(c.log).Log("msg", "synthetic check failed", "url", args.URL, "failed", strings.Join(res.failed, ","), "err", res.err)
+	}
+
+	if err := c.forwardSamples(ctx, args, res); err != nil {
+		level.Error(c.log).Log("msg", "failed to forward check results", "err", err)
+	}
+	if !res.success() {
+		c.forwardFailure(ctx, args, res)
+	}
+
+	c.debugMut.Lock()
+	c.lastCheckInfo = newCheckInfo(res)
+	c.debugMut.Unlock()
+}
+
+// do performs the request of a check.
+func (c *Component) do(ctx context.Context, cli *http.Client, args Arguments) checkResult {
+	res := checkResult{time: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, args.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if args.Body != "" {
+		body = strings.NewReader(args.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, args.Method, args.URL, body)
+	if err != nil {
+		res.err = fmt.Errorf("building request: %w", err)
+		return res
+	}
+	for k, v := range args.Headers {
+		req.Header.Set(k, v)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	start := time.Now()
+	resp, err := cli.Do(req)
+	if err != nil {
+		res.duration = time.Since(start)
+		res.err = fmt.Errorf("performing request: %w", err)
+		return res
+	}
+	defer resp.Body.Close()
+
+	res.statusCode = resp.StatusCode
+	res.body, err = io.ReadAll(resp.Body)
+	res.duration = time.Since(start)
+	if err != nil {
+		res.err = fmt.Errorf("reading response: %w", err)
+	}
+	return res
+}
+
+// Names of the metrics of forwarded samples.
+const (
+	metricSuccess          = "synthetics_http_success"
+	metricDuration         = "synthetics_http_duration_seconds"
+	metricStatusCode       = "synthetics_http_status_code"
+	metricContentLength    = "synthetics_http_content_length_bytes"
+	metricAssertionSuccess = "synthetics_http_assertion_success"
+)
+
+// forwardSamples forwards the results of a check as samples.
+func (c *Component) forwardSamples(ctx context.Context, args Arguments, res checkResult) error {
+	base := labels.NewBuilder(nil)
+	for k, v := range args.Labels {
+		base.Set(k, v)
+	}
+	base.Set("url", args.URL)
+	base.Set("method", args.Method)
+
+	series := func(name string, extra ...string) labels.Labels {
+		b := labels.NewBuilder(base.Labels(nil))
+		b.Set(model.MetricNameLabel, name)
+		for i := 0; i+1 < len(extra); i += 2 {
+			b.Set(extra[i], extra[i+1])
+		}
+		return b.Labels(nil)
+	}
+
+	ts := res.time.UnixMilli()
+	samples := []struct {
+		lbls  labels.Labels
+		value float64
+	}{
+		{series(metricSuccess), boolToFloat(res.success())},
+		{series(metricDuration), res.duration.Seconds()},
+		{series(metricStatusCode), float64(res.statusCode)},
+		{series(metricContentLength), float64(len(res.body))},
+	}
+	for _, a := range res.assertions {
+		samples = append(samples, struct {
+			lbls  labels.Labels
+			value float64
+		}{series(metricAssertionSuccess, "assertion", a.name), boolToFloat(a.success)})
+	}
+
+	app := c.fanout.Appender(ctx)
+	for _, s := range samples {
+		if _, err := app.Append(0, s.lbls, ts, s.value); err != nil {
+			_ = app.Rollback()
+			return fmt.Errorf("failed to forward sample for series %s: %w", s.lbls, err)
+		}
+	}
+	return app.Commit()
+}
+
+// forwardFailure forwards a log entry describing a failed check, including
+// an excerpt of the response.
+func (c *Component) forwardFailure(ctx context.Context, args Arguments, res checkResult) {
+	if len(args.LogsForwardTo) == 0 {
+		return
+	}
+
+	kvs := []interface{}{
+		"msg", "synthetic check failed",
+		"url", args.URL,
+		"method", args.Method,
+		"duration", res.duration.String(),
+	}
+	if res.err != nil {
+		kvs = append(kvs, "err", res.err.Error())
+	} else {
+		kvs = append(kvs,
+			"status_code", res.statusCode,
+			"failed_assertions", strings.Join(res.failed, ","),
+			"response", excerpt(res.body, args.ExcerptSize),
+		)
+	}
+	line, err := logfmt.MarshalKeyvals(kvs...)
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to encode failure log entry", "err", err)
+		return
+	}
+
+	lbls := make(model.LabelSet, len(args.Labels))
+	for k, v := range args.Labels {
+		lbls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	entry := loki.Entry{
+		Labels: lbls,
+		Entry:  logproto.Entry{Timestamp: res.time, Line: string(line)},
+	}
+	for _, receiver := range args.LogsForwardTo {
+		select {
+		case <-ctx.Done():
+			return
+		case receiver <- entry:
+		}
+	}
+}
+
+// excerpt returns the first size bytes of body, marking truncated bodies with
+// an ellipsis.
+func excerpt(body []byte, size int) string {
+	if len(body) <= size {
+		return string(body)
+	}
+	return string(body[:size]) + "..."
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.debugMut.RLock()
+	defer c.debugMut.RUnlock()
+	return c.lastCheckInfo
+}
+
+type checkInfo struct {
+	LastCheck        time.Time     `river:"last_check,attr,optional"`
+	Success          bool          `river:"success,attr,optional"`
+	Duration         time.Duration `river:"duration,attr,optional"`
+	StatusCode       int           `river:"status_code,attr,optional"`
+	FailedAssertions []string      `river:"failed_assertions,attr,optional"`
+	Error            string        `river:"error,attr,optional"`
+}
+
+func newCheckInfo(res checkResult) checkInfo {
+	info := checkInfo{
+		LastCheck:        res.time,
+		Success:          res.success(),
+		Duration:         res.duration,
+		StatusCode:       res.statusCode,
+		FailedAssertions: res.failed,
+	}
+	if res.err != nil {
+		info.Error = res.err.Error()
+	}
+	return info
+}
+
+type metrics struct {
+	checks   prometheus_client.Counter
+	failures prometheus_client.Counter
+}
+
+func newMetrics(reg prometheus_client.Registerer) *metrics {
+	m := &metrics{
+		checks: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_synthetics_http_checks_total",
+			Help: "Total number of checks performed.",
+		}),
+		failures: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_synthetics_http_check_failures_total",
+			Help: "Total number of failed checks.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.checks, m.failures)
+	}
+	return m
+}
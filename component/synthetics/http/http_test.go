@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		url        = "http://localhost:8080/health"
+		forward_to = []
+		assertions {
+			status_codes = [200, 204]
+			json_path {
+				expression = "status"
+				equals     = "ok"
+			}
+		}
+	`), &args))
+	require.Equal(t, http.MethodGet, args.Method)
+	require.Equal(t, time.Minute, args.Interval)
+	require.Equal(t, 1024, args.ExcerptSize)
+
+	tt := []struct {
+		cfg    string
+		expect string
+	}{
+		{`timeout = "2m"`, "timeout must not be greater than interval"},
+		{`assertions { status_codes = [42] }`, "invalid status code 42"},
+		{`assertions { body_matches = ["("] }`, "invalid body_matches expression \"(\": error parsing regexp: missing closing ): `(`"},
+	}
+	for _, tc := range tt {
+		err := river.Unmarshal([]byte(`
+			url        = "http://localhost:8080/health"
+			forward_to = []
+		`+tc.cfg), &args)
+		require.EqualError(t, err, tc.expect)
+	}
+}
+
+func TestCompileAssertions(t *testing.T) {
+	ok := "ok"
+	assertions, err := compileAssertions(AssertionsArguments{
+		BodyMatches:    []string{`"status"`},
+		BodyNotMatches: []string{`error`},
+		MaxLatency:     time.Second,
+		JSONPath: []JSONPathArgument{
+			{Expression: "status", Equals: &ok},
+			{Expression: "checks[?name == 'db'].healthy | [0]"},
+		},
+	})
+	require.NoError(t, err)
+
+	check := func(res checkResult) []string {
+		var failed []string
+		for _, a := range assertions {
+			if !a.check(res) {
+				failed = append(failed, a.name)
+			}
+		}
+		return failed
+	}
+
+	require.Empty(t, check(checkResult{
+		statusCode: 200,
+		duration:   time.Millisecond,
+		body:       []byte(`{"status": "ok", "checks": [{"name": "db", "healthy": true}]}`),
+	}))
+	require.Equal(t, []string{"status_code", "body_not_matches[0]", "json_path[0]", "json_path[1]", "max_latency"}, check(checkResult{
+		statusCode: 503,
+		duration:   2 * time.Second,
+		body:       []byte(`{"status": "error", "checks": [{"name": "db", "healthy": false}]}`),
+	}))
+	require.Equal(t, []string{"body_matches[0]", "json_path[0]", "json_path[1]"}, check(checkResult{
+		statusCode: 200,
+		body:       []byte(`not json`),
+	}))
+}
+
+func TestComponent(t *testing.T) {
+	var healthy bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "token", r.Header.Get("X-Token"))
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, `{"ping": true}`, string(body))
+
+		if healthy {
+			_, _ = io.WriteString(w, `{"status": "ok"}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, `{"status": "down", "reason": "`+strings.Repeat("x", 100)+`"}`)
+	}))
+	defer srv.Close()
+
+	var (
+		mut      sync.Mutex
+		received = make(map[string]float64)
+	)
+	receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		received[l.Get("__name__")+l.Get("assertion")] = v
+		require.Equal(t, "api", l.Get("service"))
+		return ref, nil
+	}))
+	logs := make(loki.LogsReceiver, 10)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		url     = "`+srv.URL+`"
+		method  = "POST"
+		headers = {"X-Token" = "token"}
+		body    = "{\"ping\": true}"
+		labels  = {"service" = "api"}
+
+		forward_to            = []
+		response_excerpt_size = 20
+
+		assertions {
+			json_path {
+				expression = "status"
+				equals     = "ok"
+			}
+		}
+	`), &args))
+	args.ForwardTo = []storage.Appendable{receiver}
+	args.LogsForwardTo = []loki.LogsReceiver{logs}
+
+	c, err := New(component.Options{
+		ID:         "synthetics.http.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	c.check(context.Background())
+
+	mut.Lock()
+	require.Equal(t, float64(0), received[metricSuccess])
+	require.Equal(t, float64(503), received[metricStatusCode])
+	require.Equal(t, float64(0), received[metricAssertionSuccess+"status_code"])
+	require.Equal(t, float64(0), received[metricAssertionSuccess+"json_path[0]"])
+	mut.Unlock()
+
+	select {
+	case entry := <-logs:
+		require.Equal(t, "api", string(entry.Labels["service"]))
+		require.Contains(t, entry.Line, `status_code=503`)
+		require.Contains(t, entry.Line, `failed_assertions=status_code,json_path[0]`)
+		require.Contains(t, entry.Line, `response="{\"status\": \"down\", \"..."`)
+	default:
+		t.Fatal("expected a log entry")
+	}
+
+	info := c.DebugInfo().(checkInfo)
+	require.False(t, info.Success)
+	require.Equal(t, []string{"status_code", "json_path[0]"}, info.FailedAssertions)
+
+	healthy = true
+	c.check(context.Background())
+
+	mut.Lock()
+	require.Equal(t, float64(1), received[metricSuccess])
+	require.Equal(t, float64(200), received[metricStatusCode])
+	mut.Unlock()
+	require.Empty(t, logs, "successful checks must not be logged")
+}
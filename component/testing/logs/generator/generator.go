@@ -0,0 +1,252 @@
+// Package generator implements the testing.logs.generator component.
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "testing.logs.generator",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Supported formats of generated log lines.
+const (
+	FormatLogfmt = "logfmt"
+	FormatJSON   = "json"
+	FormatRaw    = "raw"
+)
+
+// tickInterval is how often log lines are generated. Lines which are due are
+// generated in a batch on every tick so that high rates don't depend on timer
+// granularity.
+const tickInterval = 100 * time.Millisecond
+
+// Arguments holds values which are used to configure the
+// testing.logs.generator component.
+type Arguments struct {
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+
+	// Rate is the total number of lines generated per second across all
+	// streams.
+	Rate     float64           `river:"rate,attr,optional"`
+	Streams  int               `river:"streams,attr,optional"`
+	Labels   map[string]string `river:"labels,attr,optional"`
+	LineSize int               `river:"line_size,attr,optional"`
+	Format   string            `river:"format,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Rate:     10,
+	Streams:  1,
+	LineSize: 100,
+	Format:   FormatLogfmt,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Rate <= 0:
+		return fmt.Errorf("rate must be greater than 0")
+	case args.Streams <= 0:
+		return fmt.Errorf("streams must be greater than 0")
+	case args.LineSize < 0:
+		return fmt.Errorf("line_size must not be negative")
+	}
+
+	switch args.Format {
+	case FormatLogfmt, FormatJSON, FormatRaw:
+	default:
+		return fmt.Errorf("unknown format %q, must be one of %q, %q, or %q", args.Format, FormatLogfmt, FormatJSON, FormatRaw)
+	}
+
+	for name := range args.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
+}
+
+// Component implements the testing.logs.generator component.
+type Component struct {
+	opts         component.Options
+	linesTotal   prometheus.Counter
+	droppedTotal prometheus.Counter
+
+	mut     sync.RWMutex
+	args    Arguments
+	streams []model.LabelSet
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new testing.logs.generator component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts: o,
+		linesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "testing_logs_generator_lines_total",
+			Help: "Total number of log lines generated.",
+		}),
+		droppedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "testing_logs_generator_lines_dropped_total",
+			Help: "Total number of log lines which were not generated because receivers couldn't keep up with the configured rate.",
+		}),
+	}
+	if err := o.Registerer.Register(c.linesTotal); err != nil {
+		return nil, err
+	}
+	if err := o.Registerer.Register(c.droppedTotal); err != nil {
+		return nil, err
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var (
+		rng  = rand.New(rand.NewSource(time.Now().UnixNano()))
+		last = time.Now()
+
+		// due accumulates fractional lines between ticks so that rates below
+		// one line per tick are honored.
+		due float64
+		seq uint64
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			c.mut.RLock()
+			args, streams := c.args, c.streams
+			c.mut.RUnlock()
+
+			due += args.Rate * now.Sub(last).Seconds()
+			last = now
+
+			// Never generate more than a second's worth of lines at once so
+			// that a slow receiver doesn't cause an unbounded burst.
+			if maxDue := args.Rate + 1; due > maxDue {
+				c.droppedTotal.Add(due - maxDue)
+				due = maxDue
+			}
+
+			for ; due >= 1; due-- {
+				entry := loki.Entry{
+					Labels: streams[seq%uint64(len(streams))].Clone(),
+					Entry: logproto.Entry{
+						Timestamp: time.Now(),
+						Line:      generateLine(rng, args.Format, args.LineSize, seq),
+					},
+				}
+				seq++
+
+				for _, r := range args.ForwardTo {
+					select {
+					case <-ctx.Done():
+						return nil
+					case r <- entry:
+					}
+				}
+				c.linesTotal.Inc()
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	streams := make([]model.LabelSet, newArgs.Streams)
+	for i := range streams {
+		ls := model.LabelSet{
+			"job":    model.LabelValue(c.opts.ID),
+			"stream": model.LabelValue(strconv.Itoa(i)),
+		}
+		for name, value := range newArgs.Labels {
+			ls[model.LabelName(name)] = model.LabelValue(value)
+		}
+		streams[i] = ls
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = newArgs
+	c.streams = streams
+	return nil
+}
+
+var (
+	levels = []string{"debug", "info", "info", "info", "warn", "error"}
+	words  = []string{
+		"request", "completed", "failed", "user", "session", "cache", "miss",
+		"hit", "timeout", "retry", "connection", "established", "closed",
+		"query", "upstream", "backend", "latency", "payload", "accepted",
+	}
+)
+
+// generateLine returns a synthetic log line in the given format. The message
+// is padded with random words until the line is roughly size bytes long.
+func generateLine(rng *rand.Rand, format string, size int, seq uint64) string {
+	level := levels[rng.Intn(len(levels))]
+
+	var msg strings.Builder
+	for msg.Len() < size {
+		if msg.Len() > 0 {
+			msg.WriteByte(' ')
+		}
+		msg.WriteString(words[rng.Intn(len(words))])
+	}
+
+	switch format {
+	case FormatJSON:
+		bb, _ := json.Marshal(struct {
+			Level string `json:"level"`
+			Seq   uint64 `json:"seq"`
+			Msg   string `json:"msg"`
+		}{level, seq, msg.String()})
+		return string(bb)
+	case FormatRaw:
+		return msg.String()
+	default:
+		return fmt.Sprintf("level=%s seq=%d msg=%q", level, seq, msg.String())
+	}
+}
@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	opts := component.Options{
+		ID:            "testing.logs.generator.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	ch := make(chan loki.Entry)
+	args := DefaultArguments
+	args.ForwardTo = []loki.LogsReceiver{ch}
+	args.Rate = 100
+	args.Streams = 3
+	args.Labels = map[string]string{"env": "test"}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go c.Run(ctx)
+
+	streams := map[model.LabelValue]struct{}{}
+	for i := 0; i < 6; i++ {
+		select {
+		case <-ctx.Done():
+			require.FailNow(t, "timed out waiting for generated lines")
+		case e := <-ch:
+			require.Equal(t, model.LabelValue("test"), e.Labels["env"])
+			require.Equal(t, model.LabelValue(opts.ID), e.Labels["job"])
+			require.NotEmpty(t, e.Line)
+			streams[e.Labels["stream"]] = struct{}{}
+		}
+	}
+	require.Len(t, streams, 3)
+}
+
+func TestGenerateLine(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	line := generateLine(rng, FormatJSON, 50, 7)
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &parsed))
+	require.Equal(t, float64(7), parsed["seq"])
+	require.GreaterOrEqual(t, len(parsed["msg"].(string)), 50)
+
+	require.Regexp(t, `^level=\w+ seq=7 msg=".+"$`, generateLine(rng, FormatLogfmt, 50, 7))
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "defaults",
+			cfg:  `forward_to = []`,
+		},
+		{
+			name:   "invalid rate",
+			cfg:    "forward_to = []\nrate = 0",
+			expect: "rate must be greater than 0",
+		},
+		{
+			name:   "invalid streams",
+			cfg:    "forward_to = []\nstreams = 0",
+			expect: "streams must be greater than 0",
+		},
+		{
+			name:   "invalid format",
+			cfg:    "forward_to = []\nformat = \"xml\"",
+			expect: `unknown format "xml"`,
+		},
+		{
+			name:   "invalid label",
+			cfg:    "forward_to = []\nlabels = { \"not-valid\" = \"x\" }",
+			expect: `invalid label name "not-valid"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expect == "" {
+				require.NoError(t, err)
+				require.Equal(t, DefaultArguments.Rate, args.Rate)
+				return
+			}
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package generator implements the testing.metrics.generator component.
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "testing.metrics.generator",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// testing.metrics.generator component.
+type Arguments struct {
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	Interval time.Duration     `river:"interval,attr,optional"`
+	Metrics  int               `river:"metrics,attr,optional"`
+	Series   int               `river:"series,attr,optional"`
+	Labels   map[string]string `river:"labels,attr,optional"`
+	Prefix   string            `river:"prefix,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Interval: 15 * time.Second,
+	Metrics:  1,
+	Series:   10,
+	Prefix:   "generated_metric",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch {
+	case args.Interval <= 0:
+		return fmt.Errorf("interval must be greater than 0")
+	case args.Metrics <= 0:
+		return fmt.Errorf("metrics must be greater than 0")
+	case args.Series <= 0:
+		return fmt.Errorf("series must be greater than 0")
+	case !model.IsValidMetricName(model.LabelValue(args.Prefix)):
+		return fmt.Errorf("invalid prefix %q", args.Prefix)
+	}
+
+	for name := range args.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
+}
+
+// Component implements the testing.metrics.generator component.
+type Component struct {
+	opts   component.Options
+	fanout *prometheus.Fanout
+
+	// updated is signaled when the interval changes so that the ticker can be
+	// reset.
+	updated chan struct{}
+
+	mut    sync.RWMutex
+	args   Arguments
+	series []labels.Labels
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new testing.metrics.generator component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		fanout:  prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+		updated: make(chan struct{}, 1),
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	c.mut.RLock()
+	ticker := time.NewTicker(c.args.Interval)
+	c.mut.RUnlock()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.updated:
+			c.mut.RLock()
+			ticker.Reset(c.args.Interval)
+			c.mut.RUnlock()
+		case now := <-ticker.C:
+			if err := c.generate(ctx, rng, now); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to send generated samples", "err", err)
+			}
+		}
+	}
+}
+
+// generate appends one sample for every configured series.
+func (c *Component) generate(ctx context.Context, rng *rand.Rand, now time.Time) error {
+	c.mut.RLock()
+	series := c.series
+	c.mut.RUnlock()
+
+	var (
+		app = c.fanout.Appender(ctx)
+		ts  = now.UnixMilli()
+	)
+	for _, lbls := range series {
+		if _, err := app.Append(0, lbls, ts, rng.Float64()*100); err != nil {
+			_ = app.Rollback()
+			return err
+		}
+	}
+	return app.Commit()
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	series := make([]labels.Labels, 0, newArgs.Metrics*newArgs.Series)
+	for m := 0; m < newArgs.Metrics; m++ {
+		name := newArgs.Prefix + "_" + strconv.Itoa(m)
+
+		for s := 0; s < newArgs.Series; s++ {
+			lb := labels.NewBuilder(labels.EmptyLabels())
+			for k, v := range newArgs.Labels {
+				lb.Set(k, v)
+			}
+			lb.Set(model.MetricNameLabel, name)
+			lb.Set(model.JobLabel, c.opts.ID)
+			lb.Set("series", strconv.Itoa(s))
+			series = append(series, lb.Labels(labels.EmptyLabels()))
+		}
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	if c.args.Interval != newArgs.Interval {
+		select {
+		case c.updated <- struct{}{}:
+		default:
+		}
+	}
+	c.args = newArgs
+	c.series = series
+	return nil
+}
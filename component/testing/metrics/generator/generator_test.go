@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	flowprometheus "github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		received = map[string]int{}
+	)
+	receiver := flowprometheus.NewInterceptor(nil, flowprometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		received[l.String()]++
+		return ref, nil
+	}))
+
+	opts := component.Options{
+		ID:            "testing.metrics.generator.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := DefaultArguments
+	args.ForwardTo = []storage.Appendable{receiver}
+	args.Interval = 10 * time.Millisecond
+	args.Metrics = 2
+	args.Series = 3
+	args.Labels = map[string]string{"env": "test"}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return len(received) == 6
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Contains(t, received, `{__name__="generated_metric_1", env="test", job="testing.metrics.generator.test", series="2"}`)
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "defaults",
+			cfg:  `forward_to = []`,
+		},
+		{
+			name:   "invalid interval",
+			cfg:    "forward_to = []\ninterval = \"0s\"",
+			expect: "interval must be greater than 0",
+		},
+		{
+			name:   "invalid series",
+			cfg:    "forward_to = []\nseries = 0",
+			expect: "series must be greater than 0",
+		},
+		{
+			name:   "invalid prefix",
+			cfg:    "forward_to = []\nprefix = \"not-valid\"",
+			expect: `invalid prefix "not-valid"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expect == "" {
+				require.NoError(t, err)
+				require.Equal(t, DefaultArguments.Interval, args.Interval)
+				return
+			}
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
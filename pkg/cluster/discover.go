@@ -0,0 +1,173 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-discover"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// dnsSRVProvider discovers peers from the targets of a DNS SRV record, such
+// as the record created for a named port of a headless Kubernetes service.
+type dnsSRVProvider struct {
+	// lookupSRV is used to resolve records. net.LookupSRV is used if nil.
+	lookupSRV func(service, proto, name string) (string, []*net.SRV, error)
+}
+
+var _ discover.Provider = (*dnsSRVProvider)(nil)
+
+func (p *dnsSRVProvider) Help() string {
+	return `DNS SRV:
+
+    provider: "dnssrv"
+    name:     Name of the SRV record to resolve, such as
+              "_gossip._tcp.agent.default.svc.cluster.local".
+
+    Every target of the record is returned with the port of the record.
+`
+}
+
+func (p *dnsSRVProvider) Addrs(args map[string]string, l *stdlog.Logger) ([]string, error) {
+	if args["provider"] != "dnssrv" {
+		return nil, fmt.Errorf("discover-dnssrv: invalid provider " + args["provider"])
+	}
+	name := args["name"]
+	if name == "" {
+		return nil, fmt.Errorf("discover-dnssrv: name must be set")
+	}
+
+	lookupSRV := p.lookupSRV
+	if lookupSRV == nil {
+		lookupSRV = net.LookupSRV
+	}
+	_, records, err := lookupSRV("", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("discover-dnssrv: error resolving %s: %w", name, err)
+	}
+
+	addrs := make([]string, 0, len(records))
+	for _, r := range records {
+		target := strings.TrimSuffix(r.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(target, strconv.Itoa(int(r.Port))))
+	}
+	return addrs, nil
+}
+
+// endpointSlicesProvider discovers peers from the ready endpoints of a
+// Kubernetes service, as listed by its EndpointSlices.
+type endpointSlicesProvider struct {
+	// newClient is used to build the Kubernetes client. newKubernetesClient is
+	// used if nil.
+	newClient func(kubeconfig string) (kubernetes.Interface, error)
+}
+
+var _ discover.Provider = (*endpointSlicesProvider)(nil)
+
+func (p *endpointSlicesProvider) Help() string {
+	return `Kubernetes EndpointSlices:
+
+    provider:   "k8s-endpointslices"
+    kubeconfig: Path to the kubeconfig file. The in-cluster config is used
+                when unset.
+    namespace:  Namespace of the service (defaults to "default").
+    service:    Name of the service whose endpoints are peers.
+    port:       Name of the port of the service to use. When unset, the
+                first port of the service is used.
+
+    Only ready endpoints are returned.
+`
+}
+
+func (p *endpointSlicesProvider) Addrs(args map[string]string, l *stdlog.Logger) ([]string, error) {
+	if args["provider"] != "k8s-endpointslices" {
+		return nil, fmt.Errorf("discover-k8s-endpointslices: invalid provider " + args["provider"])
+	}
+	service := args["service"]
+	if service == "" {
+		return nil, fmt.Errorf("discover-k8s-endpointslices: service must be set")
+	}
+	namespace := args["namespace"]
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	newClient := p.newClient
+	if newClient == nil {
+		newClient = newKubernetesClient
+	}
+	client, err := newClient(args["kubeconfig"])
+	if err != nil {
+		return nil, fmt.Errorf("discover-k8s-endpointslices: error initializing k8s client: %w", err)
+	}
+
+	slices, err := client.DiscoveryV1().EndpointSlices(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discover-k8s-endpointslices: error listing endpoint slices: %w", err)
+	}
+	return endpointSliceAddrs(slices.Items, args["port"], l), nil
+}
+
+// endpointSliceAddrs returns the addresses of the ready endpoints of slices
+// with the port named portName, or the first port if portName is empty.
+func endpointSliceAddrs(slices []discoveryv1.EndpointSlice, portName string, l *stdlog.Logger) []string {
+	var addrs []string
+	for _, slice := range slices {
+		port, ok := endpointSlicePort(slice, portName)
+		if !ok {
+			l.Printf("[DEBUG] discover-k8s-endpointslices: ignoring endpoint slice %q, port %q not found", slice.Name, portName)
+			continue
+		}
+
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			for _, addr := range ep.Addresses {
+				addrs = append(addrs, net.JoinHostPort(addr, strconv.Itoa(int(port))))
+			}
+		}
+	}
+
+	sort.Strings(addrs)
+	return addrs
+}
+
+func endpointSlicePort(slice discoveryv1.EndpointSlice, name string) (int32, bool) {
+	for _, p := range slice.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if name == "" || (p.Name != nil && *p.Name == name) {
+			return *p.Port, true
+		}
+	}
+	return 0, false
+}
+
+func newKubernetesClient(kubeconfig string) (kubernetes.Interface, error) {
+	var (
+		config *rest.Config
+		err    error
+	)
+	if kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
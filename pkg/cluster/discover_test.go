@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+	stdlog "log"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+var discardLogger = stdlog.New(io.Discard, "", 0)
+
+func TestDNSSRVProvider(t *testing.T) {
+	p := &dnsSRVProvider{
+		lookupSRV: func(service, proto, name string) (string, []*net.SRV, error) {
+			if name != "_gossip._tcp.agent.default.svc.cluster.local" {
+				return "", nil, fmt.Errorf("no such host")
+			}
+			return name, []*net.SRV{
+				{Target: "agent-0.agent.default.svc.cluster.local.", Port: 12345},
+				{Target: "agent-1.agent.default.svc.cluster.local.", Port: 12345},
+			}, nil
+		},
+	}
+
+	addrs, err := p.Addrs(map[string]string{
+		"provider": "dnssrv",
+		"name":     "_gossip._tcp.agent.default.svc.cluster.local",
+	}, discardLogger)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"agent-0.agent.default.svc.cluster.local:12345",
+		"agent-1.agent.default.svc.cluster.local:12345",
+	}, addrs)
+
+	_, err = p.Addrs(map[string]string{"provider": "dnssrv", "name": "missing"}, discardLogger)
+	require.EqualError(t, err, "discover-dnssrv: error resolving missing: no such host")
+
+	_, err = p.Addrs(map[string]string{"provider": "dnssrv"}, discardLogger)
+	require.EqualError(t, err, "discover-dnssrv: name must be set")
+}
+
+func TestEndpointSlicesProvider(t *testing.T) {
+	newSlice := func(name, service string, ports []discoveryv1.EndpointPort, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "monitoring",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       ports,
+			Endpoints:   endpoints,
+		}
+	}
+	ports := []discoveryv1.EndpointPort{
+		{Name: pointer.String("http-metrics"), Port: pointer.Int32(80), Protocol: protocolPtr(corev1.ProtocolTCP)},
+		{Name: pointer.String("gossip"), Port: pointer.Int32(12345), Protocol: protocolPtr(corev1.ProtocolTCP)},
+	}
+	ready := func(ready bool, addrs ...string) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  addrs,
+			Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(ready)},
+		}
+	}
+
+	client := fake.NewSimpleClientset(
+		newSlice("agent-abc", "agent", ports, ready(true, "10.0.0.2"), ready(false, "10.0.0.3")),
+		newSlice("agent-def", "agent", ports, ready(true, "10.0.0.1")),
+		newSlice("other-abc", "other", ports, ready(true, "10.0.1.1")),
+	)
+	p := &endpointSlicesProvider{
+		newClient: func(string) (kubernetes.Interface, error) { return client, nil },
+	}
+
+	addrs, err := p.Addrs(map[string]string{
+		"provider":  "k8s-endpointslices",
+		"namespace": "monitoring",
+		"service":   "agent",
+		"port":      "gossip",
+	}, discardLogger)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:12345", "10.0.0.2:12345"}, addrs)
+
+	// The first port is used by default.
+	addrs, err = p.Addrs(map[string]string{
+		"provider":  "k8s-endpointslices",
+		"namespace": "monitoring",
+		"service":   "agent",
+	}, discardLogger)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80"}, addrs)
+
+	// Slices without the requested port are ignored.
+	addrs, err = p.Addrs(map[string]string{
+		"provider":  "k8s-endpointslices",
+		"namespace": "monitoring",
+		"service":   "agent",
+		"port":      "grpc",
+	}, discardLogger)
+	require.NoError(t, err)
+	require.Empty(t, addrs)
+
+	_, err = p.Addrs(map[string]string{"provider": "k8s-endpointslices"}, discardLogger)
+	require.EqualError(t, err, "discover-k8s-endpointslices: service must be set")
+}
+
+func protocolPtr(p corev1.Protocol) *corev1.Protocol { return &p }
@@ -66,10 +66,26 @@ type GossipConfig struct {
 
 	// Discover peers to connect to using go-discover. Mutually exclusive with
 	// JoinPeers.
+	//
+	// In addition to the providers of go-discover, such as "aws" to look up
+	// EC2 instances by tag and "k8s" to list pods, the "dnssrv" provider
+	// resolves a DNS SRV record, such as the one of a headless service, and
+	// the "k8s-endpointslices" provider lists the ready endpoints of a
+	// Kubernetes service.
+	//
+	// Peers are only discovered when the node starts; the node learns about
+	// peers which join later through gossip.
 	DiscoverPeers string
 
-	// Client pool to use for connecting to peers.
+	// Client pool to use for connecting to peers. When unset, a pool is
+	// created which connects to peers over TLS if TLS is enabled, and in
+	// plaintext otherwise.
 	Pool *clientpool.Pool
+
+	// TLS settings for connecting to peers. The gRPC server given to the
+	// GossipNode must be created with TLS.ServerOption for peers to connect to
+	// it.
+	TLS GossipTLSConfig
 }
 
 // DefaultGossipConfig holds default GossipConfig options.
@@ -120,6 +136,8 @@ func (c *GossipConfig) ApplyDefaults(defaultPort int) error {
 
 		// Custom providers that aren't enabled by default
 		providers["k8s"] = &k8s.Provider{}
+		providers["k8s-endpointslices"] = &endpointSlicesProvider{}
+		providers["dnssrv"] = &dnsSRVProvider{}
 
 		d, err := discover.New(discover.WithProviders(providers))
 		if err != nil {
@@ -133,6 +151,10 @@ func (c *GossipConfig) ApplyDefaults(defaultPort int) error {
 		c.JoinPeers = addrs
 	}
 
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("invalid TLS settings: %w", err)
+	}
+
 	for i := range c.JoinPeers {
 		// Default to using the same advertise port as the local node. This may
 		// break in some cases, so the user should make sure the port numbers
@@ -178,12 +200,21 @@ func NewGossipNode(l log.Logger, srv *grpc.Server, c *GossipConfig) (*GossipNode
 
 	sharder := shard.Ring(tokensPerNode)
 
+	pool := c.Pool
+	if pool == nil && c.TLS.Enabled() {
+		var err error
+		pool, err = clientpool.New(clientpool.DefaultOptions, c.TLS.DialOption())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	ckitConfig := ckit.Config{
 		Name:          c.NodeName,
 		AdvertiseAddr: c.AdvertiseAddr,
 		Sharder:       sharder,
 		Log:           l,
-		Pool:          c.Pool,
+		Pool:          pool,
 	}
 
 	inner, err := ckit.NewNode(srv, ckitConfig)
@@ -0,0 +1,175 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// GossipTLSConfig configures TLS for the gRPC transport used to gossip with
+// peers. TLS is enabled when CertFile is set.
+//
+// Certificates and CAs are read from disk for every new connection, so they
+// can be rotated without restarting the node.
+type GossipTLSConfig struct {
+	// Certificate and key presented to peers. The same certificate is used as
+	// a client certificate when connecting to peers.
+	CertFile string
+	KeyFile  string
+
+	// CA used to verify the certificates of peers. The system roots are used
+	// when unset.
+	CAFile string
+
+	// Require peers connecting to the node to present a certificate signed by
+	// CAFile.
+	RequireClientCert bool
+
+	// Name to verify the certificates of peers against. When unset, the
+	// certificates of peers are verified against their addresses.
+	ServerName string
+
+	// Don't verify the certificates of peers.
+	InsecureSkipVerify bool
+}
+
+// Enabled reports whether TLS is enabled.
+func (c *GossipTLSConfig) Enabled() bool { return c.CertFile != "" }
+
+// Validate returns an error if c is invalid. Certificates are loaded to catch
+// errors early.
+func (c *GossipTLSConfig) Validate() error {
+	if !c.Enabled() {
+		if c.KeyFile != "" || c.CAFile != "" || c.RequireClientCert {
+			return fmt.Errorf("TLS certificate file must be set to enable TLS")
+		}
+		return nil
+	}
+
+	if c.KeyFile == "" {
+		return fmt.Errorf("missing TLS key file")
+	}
+	if c.RequireClientCert && c.CAFile == "" {
+		return fmt.Errorf("TLS CA file must be set to require client certificates")
+	}
+
+	if _, err := c.loadKeyPair(); err != nil {
+		return err
+	}
+	if _, err := c.loadCAs(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ServerOption returns the option to pass to the gRPC server a GossipNode
+// registers itself to, so that peers connect to it over TLS.
+func (c *GossipTLSConfig) ServerOption() grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) { return c.serverConfig() },
+	}))
+}
+
+// DialOption returns the option to use when connecting to peers over TLS,
+// such as the default dial option of the client pool of a GossipNode.
+func (c *GossipTLSConfig) DialOption() grpc.DialOption {
+	return grpc.WithTransportCredentials(credentials.NewTLS(c.clientConfig()))
+}
+
+// serverConfig builds the TLS config for a connection accepted from a peer.
+func (c *GossipTLSConfig) serverConfig() (*tls.Config, error) {
+	cert, err := c.loadKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if c.RequireClientCert {
+		cas, err := c.loadCAs()
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = cas
+	}
+	return cfg, nil
+}
+
+// clientConfig builds the TLS config for connections to peers.
+func (c *GossipTLSConfig) clientConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := c.loadKeyPair()
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+	if c.InsecureSkipVerify || c.CAFile == "" {
+		return cfg
+	}
+
+	// RootCAs can't be changed once the transport credentials are created, so
+	// the certificates of peers are verified manually against the current
+	// content of CAFile instead.
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		cas, err := c.loadCAs()
+		if err != nil {
+			return err
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("peer presented no certificate")
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         cas,
+			DNSName:       cs.ServerName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err = cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+	return cfg
+}
+
+func (c *GossipTLSConfig) loadKeyPair() (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return cert, fmt.Errorf("failed to load key pair: %w", err)
+	}
+	return cert, nil
+}
+
+// loadCAs loads the certificates of CAFile. It returns nil if CAFile is unset.
+func (c *GossipTLSConfig) loadCAs() (*x509.CertPool, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+
+	bb, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bb) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+	}
+	return pool, nil
+}
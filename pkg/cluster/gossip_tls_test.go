@@ -0,0 +1,226 @@
+package cluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGossipTLSConfig_Validate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	files := ca.writeCert(t, dir, "node", 1)
+
+	tt := []struct {
+		name   string
+		cfg    GossipTLSConfig
+		expect string
+	}{
+		{name: "disabled", cfg: GossipTLSConfig{}},
+		{name: "valid", cfg: files},
+		{
+			name:   "key without certificate",
+			cfg:    GossipTLSConfig{KeyFile: files.KeyFile},
+			expect: "TLS certificate file must be set to enable TLS",
+		},
+		{
+			name:   "missing key",
+			cfg:    GossipTLSConfig{CertFile: files.CertFile},
+			expect: "missing TLS key file",
+		},
+		{
+			name:   "client certificates without CA",
+			cfg:    GossipTLSConfig{CertFile: files.CertFile, KeyFile: files.KeyFile, RequireClientCert: true},
+			expect: "TLS CA file must be set to require client certificates",
+		},
+		{
+			name:   "invalid CA",
+			cfg:    GossipTLSConfig{CertFile: files.CertFile, KeyFile: files.KeyFile, CAFile: files.KeyFile},
+			expect: "no certificates found in CA file " + files.KeyFile,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expect)
+			}
+		})
+	}
+}
+
+func TestGossipTLS_MutualAuth(t *testing.T) {
+	ca := newTestCA(t)
+	serverCfg := ca.writeCert(t, t.TempDir(), "server", 1)
+	serverCfg.RequireClientCert = true
+	addr := newTestTLSServer(t, serverCfg)
+
+	// Peers with a certificate signed by the CA can connect.
+	clientCfg := ca.writeCert(t, t.TempDir(), "client", 2)
+	require.NoError(t, checkTestTLSServer(addr, clientCfg, nil))
+
+	// Peers with a certificate signed by another CA can't.
+	otherCfg := newTestCA(t).writeCert(t, t.TempDir(), "other", 3)
+	otherCfg.CAFile = clientCfg.CAFile
+	require.Error(t, checkTestTLSServer(addr, otherCfg, nil))
+
+	// Peers don't trust servers with a certificate signed by another CA.
+	otherCfg = ca.writeCert(t, t.TempDir(), "other", 4)
+	otherCfg.CAFile = newTestCA(t).writeCert(t, t.TempDir(), "ca", 5).CAFile
+	require.Error(t, checkTestTLSServer(addr, otherCfg, nil))
+}
+
+func TestGossipTLS_Rotation(t *testing.T) {
+	// Nodes typically share the same files, whose content is rotated.
+	dir := t.TempDir()
+	cfg := newTestCA(t).writeCert(t, dir, "node", 1)
+	cfg.RequireClientCert = true
+	addr := newTestTLSServer(t, cfg)
+
+	var p peer.Peer
+	require.NoError(t, checkTestTLSServer(addr, cfg, &p))
+	require.Equal(t, int64(1), peerSerial(t, p))
+
+	// Rotate the CA and the certificate.
+	oldCfg := cfg
+	oldCfg.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+	bb, err := os.ReadFile(cfg.CAFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(oldCfg.CAFile, bb, 0o600))
+
+	newTestCA(t).writeCert(t, dir, "node", 2)
+
+	require.NoError(t, checkTestTLSServer(addr, cfg, &p))
+	require.Equal(t, int64(2), peerSerial(t, p))
+
+	// Peers which still trust the old CA can't connect anymore.
+	require.Error(t, checkTestTLSServer(addr, oldCfg, nil))
+}
+
+func newTestTLSServer(t *testing.T, cfg GossipTLSConfig) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(cfg.ServerOption())
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+// checkTestTLSServer performs a health check against the server at addr,
+// storing the peer of the server in p if non-nil.
+func checkTestTLSServer(addr string, cfg GossipTLSConfig, p *peer.Peer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cc, err := grpc.DialContext(ctx, addr, cfg.DialOption())
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+
+	var opts []grpc.CallOption
+	if p != nil {
+		opts = append(opts, grpc.Peer(p))
+	}
+	_, err = grpc_health_v1.NewHealthClient(cc).Check(ctx, &grpc_health_v1.HealthCheckRequest{}, opts...)
+	return err
+}
+
+func peerSerial(t *testing.T, p peer.Peer) int64 {
+	t.Helper()
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	require.True(t, ok)
+	return info.State.PeerCertificates[0].SerialNumber.Int64()
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// writeCert writes the CA and a certificate for 127.0.0.1 signed by the CA
+// to dir, returning a config which uses them.
+func (ca *testCA) writeCert(t *testing.T, dir, name string, serial int64) GossipTLSConfig {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	cfg := GossipTLSConfig{
+		CertFile: filepath.Join(dir, name+".pem"),
+		KeyFile:  filepath.Join(dir, name+"-key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+	require.NoError(t, os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(cfg.CAFile, ca.pem, 0o600))
+	return cfg
+}
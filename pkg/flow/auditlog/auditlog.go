@@ -0,0 +1,194 @@
+// Package auditlog implements an append-only log of the changes made to the
+// configuration of Flow controllers: config loads, and the components they
+// add, remove, and update, along with what triggered them.
+//
+// Events are appended to a file as JSON lines, and the most recent events
+// are kept in memory so they can be served over HTTP.
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EventType is the type of an audit log event.
+type EventType string
+
+// Supported event types.
+const (
+	EventConfigLoad       EventType = "config_load"
+	EventComponentAdded   EventType = "component_added"
+	EventComponentRemoved EventType = "component_removed"
+	EventComponentUpdated EventType = "component_updated"
+)
+
+// Kinds of triggers.
+const (
+	// TriggerStartup is the initial load of the config file.
+	TriggerStartup = "startup"
+	// TriggerAPI is a reload requested through the HTTP API.
+	TriggerAPI = "api"
+	// TriggerSignal is a reload requested by sending SIGHUP.
+	TriggerSignal = "signal"
+	// TriggerFileChange is a load caused by a change of a watched file.
+	TriggerFileChange = "file_change"
+	// TriggerRemoteConfig is a load of a config retrieved from a remote API.
+	TriggerRemoteConfig = "remote_config"
+	// TriggerComponentUpdate is a load caused by the arguments of the
+	// component running a module being updated.
+	TriggerComponentUpdate = "component_update"
+)
+
+// Trigger describes what caused a config to be loaded.
+type Trigger struct {
+	// Kind of trigger, such as TriggerAPI.
+	Kind string `json:"kind"`
+	// Caller which requested the load, such as the address of an API client
+	// or the path of a changed file.
+	Caller string `json:"caller,omitempty"`
+	// Revision of the loaded config, such as the version of a remote config.
+	Revision string `json:"revision,omitempty"`
+}
+
+// Event is an entry of the audit log.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// Controller is the ID of the module whose config changed. Empty for the
+	// root controller.
+	Controller string `json:"controller,omitempty"`
+	// Component is the ID of the added, removed, or updated component.
+	Component string  `json:"component,omitempty"`
+	Trigger   Trigger `json:"trigger"`
+	// Error holds the error of a failed config load.
+	Error string `json:"error,omitempty"`
+}
+
+// DefaultCapacity is the default number of events kept in memory.
+const DefaultCapacity = 1000
+
+// Log is an audit log. A nil Log discards every event.
+type Log struct {
+	mut      sync.Mutex
+	file     *os.File // nil if events aren't written to disk.
+	events   []Event  // Ring buffer of recent events.
+	next     int      // Index of events to write the next event to.
+	full     bool     // Whether events wrapped around.
+	capacity int
+}
+
+// New creates a Log which keeps the last capacity events in memory. If path
+// isn't empty, every event is also appended to the file at path, which is
+// created if it doesn't exist.
+func New(path string, capacity int) (*Log, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("capacity must be greater than 0")
+	}
+
+	l := &Log{
+		events:   make([]Event, capacity),
+		capacity: capacity,
+	}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("opening audit log: %w", err)
+		}
+		l.file = f
+	}
+	return l, nil
+}
+
+// Record appends events to the log. Events with a zero Time are given the
+// current time. Record is a no-op on a nil Log.
+func (l *Log) Record(events ...Event) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	now := time.Now()
+	for _, e := range events {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+
+		l.events[l.next] = e
+		l.next = (l.next + 1) % l.capacity
+		if l.next == 0 {
+			l.full = true
+		}
+
+		if l.file == nil {
+			continue
+		}
+		bb, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := l.file.Write(append(bb, '\n')); err != nil {
+			return fmt.Errorf("writing audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Events returns the events kept in memory, from the oldest to the newest.
+func (l *Log) Events() []Event {
+	if l == nil {
+		return nil
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if !l.full {
+		return append([]Event(nil), l.events[:l.next]...)
+	}
+	res := make([]Event, 0, l.capacity)
+	res = append(res, l.events[l.next:]...)
+	return append(res, l.events[:l.next]...)
+}
+
+// Close closes the file of the log.
+func (l *Log) Close() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return l.file.Close()
+}
+
+// ServeHTTP serves the events kept in memory as JSON, from the oldest to the
+// newest. The "limit" query parameter returns only the most recent events.
+func (l *Log) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	events := l.Events()
+	if events == nil {
+		events = []Event{}
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		if limit < len(events) {
+			events = events[len(events)-limit:]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Events []Event `json:"events"`
+	}{events})
+}
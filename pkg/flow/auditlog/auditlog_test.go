@@ -0,0 +1,108 @@
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := New(path, 2)
+	require.NoError(t, err)
+
+	trigger := Trigger{Kind: TriggerAPI, Caller: "127.0.0.1:1234"}
+	require.NoError(t, l.Record(
+		Event{Type: EventConfigLoad, Trigger: trigger},
+		Event{Type: EventComponentAdded, Component: "local.file.a", Trigger: trigger},
+		Event{Type: EventComponentRemoved, Component: "local.file.b", Trigger: trigger},
+	))
+	require.NoError(t, l.Close())
+
+	// Only the most recent events are kept in memory.
+	events := l.Events()
+	require.Len(t, events, 2)
+	require.Equal(t, "local.file.a", events[0].Component)
+	require.Equal(t, "local.file.b", events[1].Component)
+	require.False(t, events[0].Time.IsZero())
+
+	// Every event is written to the file.
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var written []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		written = append(written, e)
+	}
+	require.Len(t, written, 3)
+	require.Equal(t, EventConfigLoad, written[0].Type)
+	require.Equal(t, trigger, written[0].Trigger)
+
+	// Reopening the log appends to the file.
+	l, err = New(path, 2)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(Event{Type: EventConfigLoad}))
+	require.NoError(t, l.Close())
+
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, countLines(bb))
+}
+
+func TestLog_Nil(t *testing.T) {
+	var l *Log
+	require.NoError(t, l.Record(Event{Type: EventConfigLoad}))
+	require.Empty(t, l.Events())
+	require.NoError(t, l.Close())
+}
+
+func TestLog_ServeHTTP(t *testing.T) {
+	l, err := New("", DefaultCapacity)
+	require.NoError(t, err)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, l.Record(Event{Type: EventComponentAdded, Component: id}))
+	}
+
+	request := func(url string) []Event {
+		rr := httptest.NewRecorder()
+		l.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Events []Event `json:"events"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		return resp.Events
+	}
+
+	require.Len(t, request("/-/audit-log"), 3)
+
+	events := request("/-/audit-log?limit=2")
+	require.Len(t, events, 2)
+	require.Equal(t, "b", events[0].Component)
+	require.Equal(t, "c", events[1].Component)
+
+	rr := httptest.NewRecorder()
+	l.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/-/audit-log?limit=many", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func countLines(bb []byte) int {
+	var n int
+	for _, b := range bb {
+		if b == '\n' {
+			n++
+		}
+	}
+	return n
+}
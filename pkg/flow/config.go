@@ -40,29 +40,67 @@ type File struct {
 
 // ReadFile parses the River file specified by bb into a File. name should be
 // the name of the file used for reporting errors.
+//
+// The contents of profile blocks are ignored; use ReadFileWithProfiles to
+// enable profiles.
 func ReadFile(name string, bb []byte) (*File, error) {
+	return ReadFileWithProfiles(name, bb, nil)
+}
+
+// ReadFileWithProfiles is like ReadFile, but also reads the contents of the
+// profile blocks whose label is listed in profiles, as if they were declared
+// at the top level of the file. Profile blocks which aren't enabled are
+// ignored.
+func ReadFileWithProfiles(name string, bb []byte, profiles []string) (*File, error) {
 	node, err := parser.ParseFile(name, bb)
 	if err != nil {
 		return nil, err
 	}
 
+	r := fileReader{
+		profiles:  make(map[string]struct{}, len(profiles)),
+		namedArgs: make(map[string]struct{}),
+	}
+	for _, p := range profiles {
+		r.profiles[p] = struct{}{}
+	}
+	if err := r.read(node.Body, false); err != nil {
+		return nil, err
+	}
+
+	return &File{
+		Name:         name,
+		Node:         node,
+		Arguments:    r.args,
+		Components:   r.components,
+		ConfigBlocks: r.configs,
+	}, nil
+}
+
+// fileReader sorts the statements of a File into components, config blocks,
+// and arguments.
+type fileReader struct {
+	profiles map[string]struct{} // Enabled profiles.
+
+	components []*ast.BlockStmt
+	configs    []*ast.BlockStmt
+	args       []Argument
+
+	namedArgs map[string]struct{}
+}
+
+// read reads the statements of body. inProfile is true when body is the body
+// of a profile block.
+func (r *fileReader) read(body ast.Body, inProfile bool) error {
 	// Look for predefined non-components blocks (i.e., logging), and store
 	// everything else into a list of components.
 	//
 	// TODO(rfratto): should this code be brought into a helper somewhere? Maybe
 	// in ast?
-	var (
-		components []*ast.BlockStmt
-		configs    []*ast.BlockStmt
-		args       []Argument
-
-		namedArgs = make(map[string]struct{})
-	)
-
-	for _, stmt := range node.Body {
+	for _, stmt := range body {
 		switch stmt := stmt.(type) {
 		case *ast.AttributeStmt:
-			return nil, diag.Diagnostic{
+			return diag.Diagnostic{
 				Severity: diag.SeverityLevelError,
 				StartPos: ast.StartPos(stmt.Name).Position(),
 				EndPos:   ast.EndPos(stmt.Name).Position(),
@@ -73,17 +111,21 @@ func ReadFile(name string, bb []byte) (*File, error) {
 			fullName := strings.Join(stmt.Name, ".")
 			switch fullName {
 			case "logging":
-				configs = append(configs, stmt)
+				r.configs = append(r.configs, stmt)
 			case "tracing":
-				configs = append(configs, stmt)
+				r.configs = append(r.configs, stmt)
+			case "http_defaults":
+				r.configs = append(r.configs, stmt)
+			case "disk_budget":
+				r.configs = append(r.configs, stmt)
 			case "argument":
 				var arg Argument
 				if err := vm.New(stmt).Evaluate(nil, &arg); err != nil {
-					return nil, err
+					return err
 				}
 
-				if _, exist := namedArgs[arg.Name]; exist {
-					return nil, diag.Diagnostic{
+				if _, exist := r.namedArgs[arg.Name]; exist {
+					return diag.Diagnostic{
 						Severity: diag.SeverityLevelError,
 						StartPos: ast.StartPos(stmt).Position(),
 						EndPos:   ast.EndPos(stmt).Position(),
@@ -91,16 +133,20 @@ func ReadFile(name string, bb []byte) (*File, error) {
 					}
 				}
 
-				args = append(args, arg)
-				namedArgs[arg.Name] = struct{}{}
+				r.args = append(r.args, arg)
+				r.namedArgs[arg.Name] = struct{}{}
 			case "export":
-				configs = append(configs, stmt)
+				r.configs = append(r.configs, stmt)
+			case "profile":
+				if err := r.readProfile(stmt, inProfile); err != nil {
+					return err
+				}
 			default:
-				components = append(components, stmt)
+				r.components = append(r.components, stmt)
 			}
 
 		default:
-			return nil, diag.Diagnostic{
+			return diag.Diagnostic{
 				Severity: diag.SeverityLevelError,
 				StartPos: ast.StartPos(stmt).Position(),
 				EndPos:   ast.EndPos(stmt).Position(),
@@ -109,11 +155,61 @@ func ReadFile(name string, bb []byte) (*File, error) {
 		}
 	}
 
-	return &File{
-		Name:         name,
-		Node:         node,
-		Arguments:    args,
-		Components:   components,
-		ConfigBlocks: configs,
-	}, nil
+	return nil
+}
+
+func (r *fileReader) readProfile(stmt *ast.BlockStmt, inProfile bool) error {
+	switch {
+	case inProfile:
+		return diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(stmt).Position(),
+			EndPos:   ast.EndPos(stmt).Position(),
+			Message:  "profile blocks cannot be nested",
+		}
+	case stmt.Label == "":
+		return diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(stmt).Position(),
+			EndPos:   ast.EndPos(stmt).Position(),
+			Message:  "profile block must have a label",
+		}
+	}
+
+	if _, enabled := r.profiles[stmt.Label]; !enabled {
+		return nil
+	}
+	return r.read(stmt.Body, true)
+}
+
+// MergeFiles merges files into a single File named name. The blocks of each
+// file are kept in the order of files, so that files can be merged in a stable
+// order. Components in one file may reference components from any other file.
+//
+// Declaring the same component or config block in more than one file is
+// reported as an error when the merged File is loaded, just like declaring it
+// twice in the same file.
+func MergeFiles(name string, files []*File) (*File, error) {
+	merged := &File{
+		Name: name,
+		Node: &ast.File{Name: name},
+	}
+
+	argFiles := make(map[string]string) // Argument name -> name of the declaring file
+	for _, f := range files {
+		for _, arg := range f.Arguments {
+			if other, exist := argFiles[arg.Name]; exist {
+				return nil, fmt.Errorf("argument %q declared in both %s and %s", arg.Name, other, f.Name)
+			}
+			argFiles[arg.Name] = f.Name
+			merged.Arguments = append(merged.Arguments, arg)
+		}
+
+		merged.Node.Body = append(merged.Node.Body, f.Node.Body...)
+		merged.Node.Comments = append(merged.Node.Comments, f.Node.Comments...)
+		merged.Components = append(merged.Components, f.Components...)
+		merged.ConfigBlocks = append(merged.ConfigBlocks, f.ConfigBlocks...)
+	}
+
+	return merged, nil
 }
@@ -68,3 +68,106 @@ func getBlockID(b *ast.BlockStmt) string {
 	}
 	return strings.Join(parts, ".")
 }
+
+func TestMergeFiles(t *testing.T) {
+	a, err := flow.ReadFile("a.river", []byte(`
+		argument "a" {}
+
+		logging {
+			log_format = "json"
+		}
+
+		testcomponents.tick "ticker_a" {
+			frequency = "1s"
+		}
+	`))
+	require.NoError(t, err)
+
+	b, err := flow.ReadFile("b.river", []byte(`
+		argument "b" {}
+
+		testcomponents.passthrough "static" {
+			input = testcomponents.tick.ticker_a.tick_time
+		}
+	`))
+	require.NoError(t, err)
+
+	f, err := flow.MergeFiles("dir", []*flow.File{a, b})
+	require.NoError(t, err)
+	require.Equal(t, "dir", f.Name)
+
+	require.Len(t, f.Components, 2)
+	require.Equal(t, "testcomponents.tick.ticker_a", getBlockID(f.Components[0]))
+	require.Equal(t, "testcomponents.passthrough.static", getBlockID(f.Components[1]))
+	require.Len(t, f.ConfigBlocks, 1)
+	require.Equal(t, "logging", getBlockID(f.ConfigBlocks[0]))
+	require.Len(t, f.Arguments, 2)
+	require.Len(t, f.Node.Body, 5)
+
+	_, err = flow.MergeFiles("dir", []*flow.File{a, a})
+	require.EqualError(t, err, `argument "a" declared in both a.river and a.river`)
+}
+
+func TestReadFileWithProfiles(t *testing.T) {
+	content := `
+		testcomponents.tick "ticker_a" {
+			frequency = "1s"
+		}
+
+		profile "dev" {
+			testcomponents.passthrough "static" {
+				input = "dev"
+			}
+		}
+
+		profile "prod" {
+			logging {
+				log_format = "json"
+			}
+
+			testcomponents.passthrough "static" {
+				input = "prod"
+			}
+		}
+	`
+
+	f, err := flow.ReadFile(t.Name(), []byte(content))
+	require.NoError(t, err)
+	require.Len(t, f.Components, 1)
+	require.Len(t, f.ConfigBlocks, 0)
+
+	f, err = flow.ReadFileWithProfiles(t.Name(), []byte(content), []string{"prod"})
+	require.NoError(t, err)
+	require.Len(t, f.Components, 2)
+	require.Equal(t, "testcomponents.tick.ticker_a", getBlockID(f.Components[0]))
+	require.Equal(t, "testcomponents.passthrough.static", getBlockID(f.Components[1]))
+	require.Equal(t, `"prod"`, f.Components[1].Body[0].(*ast.AttributeStmt).Value.(*ast.LiteralExpr).Value)
+	require.Len(t, f.ConfigBlocks, 1)
+	require.Equal(t, "logging", getBlockID(f.ConfigBlocks[0]))
+}
+
+func TestReadFileWithProfiles_Invalid(t *testing.T) {
+	tt := []struct {
+		name    string
+		content string
+		expect  string
+	}{
+		{
+			name:    "nested",
+			content: `profile "a" { profile "b" {} }`,
+			expect:  "profile blocks cannot be nested",
+		},
+		{
+			name:    "unlabeled",
+			content: `profile {}`,
+			expect:  "profile block must have a label",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := flow.ReadFileWithProfiles(t.Name(), []byte(tc.content), []string{"a"})
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
@@ -46,21 +46,27 @@
 package flow
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/diskbudget"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/stdlib"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/printer"
 	"github.com/grafana/agent/pkg/river/vm"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
+	"golang.org/x/exp/maps"
 )
 
 // Options holds static options for a flow controller.
@@ -89,6 +95,13 @@ type Options struct {
 	// different value for DataPath to prevent components from colliding.
 	DataPath string
 
+	// DataRetention is how long the data directory of a component is kept after
+	// the component is removed from the config. Data directories created by
+	// the controller which don't belong to a loaded component are deleted once
+	// they've been unused for DataRetention; other directories in DataPath are
+	// never deleted. Data directories are never deleted if DataRetention is 0.
+	DataRetention time.Duration
+
 	// Reg is the prometheus register to use
 	Reg prometheus.Registerer
 
@@ -112,6 +125,16 @@ type Options struct {
 	// OnExportsChange is nil, export configuration blocks are not allowed in the
 	// loaded config file.
 	OnExportsChange func(exports map[string]any)
+
+	// AuditLog records config loads and the components they add, remove, and
+	// update. Config changes aren't recorded if AuditLog is nil.
+	AuditLog *auditlog.Log
+
+	// DiskBudget is the budget of the disk space used by components, whose
+	// quotas are set by the disk_budget block. If nil, the controller creates
+	// its own budget, registering its metrics to Reg. Controllers of modules
+	// share the budget of their parent.
+	DiskBudget *diskbudget.Budget
 }
 
 // Flow is the Flow system.
@@ -125,6 +148,8 @@ type Flow struct {
 	loader      *controller.Loader
 
 	loadFinished chan struct{}
+	dataDirGC    *dataDirGC // nil if data directories aren't garbage collected.
+	diskBudget   *diskbudget.Budget
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
@@ -147,6 +172,11 @@ func New(o Options) *Flow {
 		}
 	}
 
+	diskBudget := o.DiskBudget
+	if diskBudget == nil {
+		diskBudget = diskbudget.NewBudget(o.Reg)
+	}
+
 	var (
 		queue  = controller.NewQueue()
 		sched  = controller.NewScheduler()
@@ -164,9 +194,16 @@ func New(o Options) *Flow {
 			HTTPPathPrefix:  o.HTTPPathPrefix,
 			HTTPListenAddr:  o.HTTPListenAddr,
 			ControllerID:    o.ControllerID,
+			AuditLog:        o.AuditLog,
+			DiskBudget:      diskBudget,
 		})
 	)
 
+	var gc *dataDirGC
+	if o.DataPath != "" && o.DataRetention > 0 {
+		gc = &dataDirGC{log: log, path: o.DataPath, retention: o.DataRetention}
+	}
+
 	return &Flow{
 		log:    log,
 		tracer: tracer,
@@ -177,20 +214,38 @@ func New(o Options) *Flow {
 		loader:      loader,
 
 		loadFinished: make(chan struct{}, 1),
+		dataDirGC:    gc,
+		diskBudget:   diskBudget,
 	}
 }
 
+// dataDirGCInterval is how often unused data directories are checked for
+// deletion between loads of the config.
+const dataDirGCInterval = time.Hour
+
 // Run starts the Flow controller, blocking until the provided context is
 // canceled. Run must only be called once.
 func (c *Flow) Run(ctx context.Context) {
 	defer c.sched.Close()
+	if c.opts.DiskBudget == nil {
+		// The budget was created by this controller.
+		defer c.diskBudget.Stop()
+	}
 	defer level.Debug(c.log).Log("msg", "flow controller exiting")
 
+	gcTicker := time.NewTicker(dataDirGCInterval)
+	defer gcTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
+		case <-gcTicker.C:
+			if c.loadedOnce.Load() {
+				c.collectDataDirs()
+			}
+
 		case <-c.updateQueue.Chan():
 			// We need to pop _everything_ from the queue and evaluate each of them.
 			// If we only pop a single element, other components may sit waiting for
@@ -217,10 +272,28 @@ func (c *Flow) Run(ctx context.Context) {
 			if err != nil {
 				level.Error(c.log).Log("msg", "failed to load components", "err", err)
 			}
+			c.collectDataDirs()
 		}
 	}
 }
 
+// collectDataDirs deletes the data directories of components which were
+// removed from the config longer than DataRetention ago.
+func (c *Flow) collectDataDirs() {
+	if c.dataDirGC == nil {
+		return
+	}
+
+	components := c.loader.Components()
+	used := make([]string, 0, len(components))
+	for _, cn := range components {
+		used = append(used, cn.NodeID())
+	}
+	if err := c.dataDirGC.collect(time.Now(), used); err != nil {
+		level.Warn(c.log).Log("msg", "failed to delete unused data directories", "err", err)
+	}
+}
+
 // LoadFile synchronizes the state of the controller with the current config
 // file. Components in the graph will be marked as unhealthy if there was an
 // error encountered during Load.
@@ -228,9 +301,20 @@ func (c *Flow) Run(ctx context.Context) {
 // The controller will only start running components after Load is called once
 // without any configuration errors.
 func (c *Flow) LoadFile(file *File, args map[string]any) error {
+	return c.LoadFileWithTrigger(file, args, auditlog.Trigger{})
+}
+
+// LoadFileWithTrigger is like LoadFile, but records trigger as the cause of
+// the load in the audit log.
+func (c *Flow) LoadFileWithTrigger(file *File, args map[string]any, trigger auditlog.Trigger) (err error) {
 	c.loadMut.Lock()
 	defer c.loadMut.Unlock()
 
+	if c.opts.AuditLog != nil {
+		before := c.componentBlocks()
+		defer func() { c.recordLoad(trigger, before, err) }()
+	}
+
 	// Fill out the values for the scope so that argument.NAME.value can be used
 	// to reference expressions.
 	evaluatedArgs := make(map[string]any, len(file.Arguments))
@@ -274,6 +358,74 @@ func (c *Flow) LoadFile(file *File, args map[string]any) error {
 	return diags.ErrorOrNil()
 }
 
+// componentBlocks returns the formatted block of every loaded component, by
+// component ID.
+func (c *Flow) componentBlocks() map[string]string {
+	components := c.loader.Components()
+	blocks := make(map[string]string, len(components))
+	for _, cn := range components {
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, cn.Block()); err != nil {
+			level.Warn(c.log).Log("msg", "failed to format component block", "node_id", cn.NodeID(), "err", err)
+		}
+		blocks[cn.NodeID()] = buf.String()
+	}
+	return blocks
+}
+
+// recordLoad records a config load and the components it added, removed, or
+// updated in the audit log. before holds the component blocks prior to the
+// load.
+func (c *Flow) recordLoad(trigger auditlog.Trigger, before map[string]string, loadErr error) {
+	var (
+		now    = time.Now()
+		after  = c.componentBlocks()
+		events = []auditlog.Event{{
+			Time:       now,
+			Type:       auditlog.EventConfigLoad,
+			Controller: c.opts.ControllerID,
+			Trigger:    trigger,
+		}}
+	)
+	if loadErr != nil {
+		events[0].Error = loadErr.Error()
+	}
+
+	newEvent := func(typ auditlog.EventType, id string) auditlog.Event {
+		return auditlog.Event{
+			Time:       now,
+			Type:       typ,
+			Controller: c.opts.ControllerID,
+			Component:  id,
+			Trigger:    trigger,
+		}
+	}
+	for _, id := range sortedKeys(after) {
+		prev, ok := before[id]
+		switch {
+		case !ok:
+			events = append(events, newEvent(auditlog.EventComponentAdded, id))
+		case prev != after[id]:
+			events = append(events, newEvent(auditlog.EventComponentUpdated, id))
+		}
+	}
+	for _, id := range sortedKeys(before) {
+		if _, ok := after[id]; !ok {
+			events = append(events, newEvent(auditlog.EventComponentRemoved, id))
+		}
+	}
+
+	if err := c.opts.AuditLog.Record(events...); err != nil {
+		level.Warn(c.log).Log("msg", "failed to record config load in the audit log", "err", err)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
+
 // Ready returns whether the Flow controller has finished its initial load.
 func (c *Flow) Ready() bool {
 	return c.loadedOnce.Load()
@@ -0,0 +1,121 @@
+package flow
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+)
+
+// unusedDataDirsFile is the name of the file in the data directory of the
+// controller which records when data directories stopped being used.
+const unusedDataDirsFile = ".unused-data-dirs.json"
+
+// dataDirGC deletes the data directories of components which were removed
+// from the config more than retention ago.
+//
+// Only directories found directly inside path which hold the marker file
+// written by the controller are considered to belong to components; other
+// files and directories are never deleted.
+type dataDirGC struct {
+	log       log.Logger
+	path      string
+	retention time.Duration
+}
+
+// collect deletes unused data directories. used holds the node IDs of the
+// loaded components, whose directories are in use.
+func (gc *dataDirGC) collect(now time.Time, used []string) error {
+	entries, err := os.ReadDir(gc.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	inUse := make(map[string]struct{}, len(used))
+	for _, id := range used {
+		inUse[id] = struct{}{}
+	}
+
+	unused, err := gc.readUnused()
+	if err != nil {
+		level.Warn(gc.log).Log("msg", "failed to read when data directories stopped being used; starting over", "err", err)
+		unused = make(map[string]time.Time)
+	}
+
+	next := make(map[string]time.Time)
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		} else if _, ok := inUse[e.Name()]; ok {
+			continue
+		} else if !gc.isComponentDir(e.Name()) {
+			continue
+		}
+
+		since, ok := unused[e.Name()]
+		if !ok || since.After(now) {
+			since = now
+		}
+		if now.Sub(since) < gc.retention {
+			next[e.Name()] = since
+			continue
+		}
+
+		dir := filepath.Join(gc.path, e.Name())
+		if err := os.RemoveAll(dir); err != nil {
+			level.Warn(gc.log).Log("msg", "failed to delete unused data directory", "path", dir, "err", err)
+			next[e.Name()] = since
+			continue
+		}
+		level.Info(gc.log).Log("msg", "deleted unused data directory", "path", dir, "unused_since", since)
+	}
+
+	return gc.writeUnused(next)
+}
+
+// isComponentDir reports whether the directory called name was created by the
+// controller for a component.
+func (gc *dataDirGC) isComponentDir(name string) bool {
+	_, err := os.Stat(filepath.Join(gc.path, name, controller.DataPathMarker))
+	return err == nil
+}
+
+func (gc *dataDirGC) readUnused() (map[string]time.Time, error) {
+	bb, err := os.ReadFile(filepath.Join(gc.path, unusedDataDirsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]time.Time), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	unused := make(map[string]time.Time)
+	if err := json.Unmarshal(bb, &unused); err != nil {
+		return nil, err
+	}
+	return unused, nil
+}
+
+func (gc *dataDirGC) writeUnused(unused map[string]time.Time) error {
+	path := filepath.Join(gc.path, unusedDataDirsFile)
+	if len(unused) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	bb, err := json.Marshal(unused)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bb, 0640)
+}
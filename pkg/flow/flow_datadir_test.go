@@ -0,0 +1,83 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataDirGC(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		gc  = &dataDirGC{log: log.NewNopLogger(), path: dir, retention: 24 * time.Hour}
+		now = time.Now()
+	)
+	for _, name := range []string{"local.file.a", "local.file.b", ".hidden"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, controller.DataPathMarker), nil, 0640))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0640))
+
+	// Unused directories are kept until the retention passes.
+	require.NoError(t, gc.collect(now, []string{"local.file.a"}))
+	require.DirExists(t, filepath.Join(dir, "local.file.b"))
+
+	require.NoError(t, gc.collect(now.Add(23*time.Hour), []string{"local.file.a"}))
+	require.DirExists(t, filepath.Join(dir, "local.file.b"))
+
+	// Using a directory again resets when it stopped being used.
+	require.NoError(t, gc.collect(now.Add(23*time.Hour), []string{"local.file.a", "local.file.b"}))
+	require.NoError(t, gc.collect(now.Add(25*time.Hour), []string{"local.file.a"}))
+	require.DirExists(t, filepath.Join(dir, "local.file.b"))
+
+	require.NoError(t, gc.collect(now.Add(49*time.Hour), []string{"local.file.a"}))
+	require.NoDirExists(t, filepath.Join(dir, "local.file.b"))
+	require.NoFileExists(t, filepath.Join(dir, unusedDataDirsFile))
+
+	// Directories of loaded components, hidden directories, and files are
+	// never deleted.
+	require.DirExists(t, filepath.Join(dir, "local.file.a"))
+	require.DirExists(t, filepath.Join(dir, ".hidden"))
+	require.FileExists(t, filepath.Join(dir, "file"))
+}
+
+func TestDataDirGC_ForeignDirectories(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		gc  = &dataDirGC{log: log.NewNopLogger(), path: dir, retention: time.Hour}
+		now = time.Now()
+	)
+
+	// Directories which weren't created by the controller, such as the data
+	// of another program sharing the storage path, are never deleted.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "wal"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wal", "00000001"), nil, 0640))
+
+	require.NoError(t, gc.collect(now, nil))
+	require.NoError(t, gc.collect(now.Add(2*time.Hour), nil))
+	require.FileExists(t, filepath.Join(dir, "wal", "00000001"))
+	require.NoFileExists(t, filepath.Join(dir, unusedDataDirsFile))
+}
+
+func TestDataDirGC_MissingDirectory(t *testing.T) {
+	gc := &dataDirGC{log: log.NewNopLogger(), path: filepath.Join(t.TempDir(), "missing"), retention: time.Hour}
+	require.NoError(t, gc.collect(time.Now(), nil))
+}
+
+func TestController_MarksDataDirs(t *testing.T) {
+	opts := testOptions(t)
+	ctrl := New(opts)
+
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	// The data directories created for components are marked so that only they
+	// can be deleted once unused.
+	require.FileExists(t, filepath.Join(opts.DataPath, "testcomponents.passthrough.static", controller.DataPathMarker))
+}
@@ -0,0 +1,309 @@
+package flow
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+)
+
+// ErrComponentNotFound is returned by WriteComponentProfile if no component
+// with the given ID exists.
+var ErrComponentNotFound = errors.New("component not found")
+
+// ModuleControllersComponent is implemented by components which load modules
+// into nested Flow controllers, so that the components of those modules can be
+// found from the root controller.
+type ModuleControllersComponent interface {
+	// ModuleControllers returns the controllers of the modules currently
+	// loaded by the component.
+	ModuleControllers() []*Flow
+}
+
+// profileMut enforces single-flight requests to WriteComponentProfile, as
+// only one CPU profile can be collected at a time.
+var profileMut sync.Mutex
+
+// ComponentProfileOptions configures WriteComponentProfile.
+type ComponentProfileOptions struct {
+	// Duration to collect a CPU profile and mutex contention for. If zero, no
+	// CPU profile is collected.
+	Duration time.Duration
+}
+
+// WriteComponentProfile writes a zip bundle of profiles for the component
+// with the given ID to w. The bundle contains:
+//
+//   - goroutine.pprof and goroutine.txt: goroutines running on behalf of the
+//     component.
+//   - cpu.pprof: CPU samples of the component's goroutines, if
+//     opts.Duration is set.
+//   - heap.pprof and mutex.pprof: process-wide profiles, as the Go runtime
+//     doesn't record pprof labels for allocations and mutex contention.
+//
+// The mutex profile only holds the contention recorded while the CPU profile
+// was collected if opts.Duration is set; otherwise it holds the contention
+// recorded since the process started.
+//
+// id is either the ID of a component of f, or the global ID of a component
+// defined in a module, such as "module.file.example/prometheus.scrape.default".
+// Goroutines are attributed to a component through the pprof labels set by
+// the controller when the component is built, updated, and run.
+func (f *Flow) WriteComponentProfile(ctx context.Context, w io.Writer, id string, opts ComponentProfileOptions) error {
+	node := f.findComponent(id)
+	if node == nil {
+		return fmt.Errorf("%w: %q", ErrComponentNotFound, id)
+	}
+	globalID := node.GlobalID()
+
+	profileMut.Lock()
+	defer profileMut.Unlock()
+
+	files := make(map[string][]byte)
+
+	if opts.Duration > 0 {
+		cpu, mutex, err := collectCPUProfile(ctx, opts.Duration)
+		if err != nil {
+			return err
+		}
+		if files["cpu.pprof"], err = filterProfile(cpu, globalID); err != nil {
+			return fmt.Errorf("filtering cpu profile: %w", err)
+		}
+		files["mutex.pprof"] = mutex
+	} else {
+		var buf bytes.Buffer
+		if err := pprof.Lookup("mutex").WriteTo(&buf, 0); err != nil {
+			return err
+		}
+		files["mutex.pprof"] = buf.Bytes()
+	}
+
+	var heap, goroutine, goroutineText bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return err
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutine, 0); err != nil {
+		return err
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutineText, 1); err != nil {
+		return err
+	}
+	files["heap.pprof"] = heap.Bytes()
+
+	var err error
+	if files["goroutine.pprof"], err = filterProfile(goroutine.Bytes(), globalID); err != nil {
+		return fmt.Errorf("filtering goroutine profile: %w", err)
+	}
+	files["goroutine.txt"] = filterGoroutineText(goroutineText.Bytes(), globalID)
+
+	zw := zip.NewWriter(w)
+	for _, name := range []string{"goroutine.pprof", "goroutine.txt", "cpu.pprof", "heap.pprof", "mutex.pprof"} {
+		bb, ok := files[name]
+		if !ok {
+			continue
+		}
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(bb); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to flush the zip writer: %w", err)
+	}
+	return nil
+}
+
+// findComponent returns the component of f with the given ID, or the
+// component with the given global ID from the modules loaded by the
+// components of f. nil is returned if no such component exists.
+func (f *Flow) findComponent(id string) *controller.ComponentNode {
+	var modules []*Flow
+
+	f.loadMut.RLock()
+	for _, c := range f.loader.Components() {
+		if c.ID().String() == id || c.GlobalID() == id {
+			f.loadMut.RUnlock()
+			return c
+		}
+		if !strings.HasPrefix(id, c.GlobalID()+"/") {
+			continue
+		}
+		if mc, ok := c.Component().(ModuleControllersComponent); ok {
+			modules = append(modules, mc.ModuleControllers()...)
+		}
+	}
+	f.loadMut.RUnlock()
+
+	// Modules are searched without holding loadMut, as they're loaded while
+	// the parent controller is being loaded.
+	for _, m := range modules {
+		if c := m.findComponent(id); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// collectCPUProfile collects a CPU profile for d. Mutex contention is
+// recorded for the same duration.
+func collectCPUProfile(ctx context.Context, d time.Duration) (cpu, mutex []byte, err error) {
+	old := runtime.SetMutexProfileFraction(1)
+	defer runtime.SetMutexProfileFraction(old)
+
+	// The mutex profile is cumulative, so the contention recorded during d is
+	// the difference between the profiles at the start and end of d.
+	var mutexStart bytes.Buffer
+	if err := pprof.Lookup("mutex").WriteTo(&mutexStart, 0); err != nil {
+		return nil, nil, err
+	}
+
+	var cpuBuf bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpuBuf); err != nil {
+		return nil, nil, err
+	}
+
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	t.Stop()
+	pprof.StopCPUProfile()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var mutexEnd bytes.Buffer
+	if err := pprof.Lookup("mutex").WriteTo(&mutexEnd, 0); err != nil {
+		return nil, nil, err
+	}
+	mutex, err = deltaProfile(mutexStart.Bytes(), mutexEnd.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("computing mutex profile delta: %w", err)
+	}
+	return cpuBuf.Bytes(), mutex, nil
+}
+
+// deltaProfile returns the encoded profile of the samples recorded between
+// the cumulative encoded profiles start and end.
+func deltaProfile(start, end []byte) ([]byte, error) {
+	p0, err := profile.ParseData(start)
+	if err != nil {
+		return nil, err
+	}
+	p1, err := profile.ParseData(end)
+	if err != nil {
+		return nil, err
+	}
+
+	p0.Scale(-1)
+	p, err := profile.Merge([]*profile.Profile{p1, p0})
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the samples of stacks which didn't record anything new.
+	samples := p.Sample[:0]
+	for _, s := range p.Sample {
+		for _, v := range s.Value {
+			if v != 0 {
+				samples = append(samples, s)
+				break
+			}
+		}
+	}
+	p.Sample = samples
+	p.TimeNanos = p1.TimeNanos
+	p.DurationNanos = p1.TimeNanos - p0.TimeNanos
+
+	var buf bytes.Buffer
+	if err := p.Compact().Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// filterProfile removes all samples from the encoded profile in bb which
+// weren't recorded on goroutines labeled with the given component ID.
+func filterProfile(bb []byte, globalID string) ([]byte, error) {
+	p, err := profile.ParseData(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := p.Sample[:0]
+	for _, s := range p.Sample {
+		if hasLabel(s.Label[controller.ProfileLabel], globalID) {
+			samples = append(samples, s)
+		}
+	}
+	p.Sample = samples
+
+	var buf bytes.Buffer
+	if err := p.Compact().Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func hasLabel(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// filterGoroutineText filters a goroutine profile written with debug=1 to
+// the groups of goroutines labeled with the given component ID.
+func filterGoroutineText(bb []byte, globalID string) []byte {
+	// Labels are written as a JSON-like map, e.g.:
+	//
+	//   # labels: {"component_id":"loki.source.file.default"}
+	want := strconv.Quote(controller.ProfileLabel) + ":" + strconv.Quote(globalID)
+
+	var (
+		out   bytes.Buffer
+		group []string
+	)
+	flush := func() {
+		for _, line := range group {
+			if strings.HasPrefix(line, "# labels: ") && strings.Contains(line, want) {
+				out.WriteString(strings.Join(group, "\n"))
+				out.WriteString("\n\n")
+				break
+			}
+		}
+		group = group[:0]
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(bb))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		group = append(group, line)
+	}
+	flush()
+	return out.Bytes()
+}
@@ -0,0 +1,204 @@
+package flow
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteComponentProfile(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	// Wait for the component to be running.
+	require.Eventually(t, func() bool {
+		for _, info := range ctrl.ComponentInfos() {
+			if info.ID == "testcomponents.tick.ticker" {
+				return info.Health.State == "healthy"
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var buf bytes.Buffer
+	err = ctrl.WriteComponentProfile(ctx, &buf, "testcomponents.tick.ticker", ComponentProfileOptions{})
+	require.NoError(t, err)
+
+	files := readZip(t, buf.Bytes())
+	require.Contains(t, files, "heap.pprof")
+	require.Contains(t, files, "mutex.pprof")
+	require.NotContains(t, files, "cpu.pprof")
+	require.Contains(t, string(files["goroutine.txt"]), `"component_id":"testcomponents.tick.ticker"`)
+	require.NotContains(t, string(files["goroutine.txt"]), `"component_id":"testcomponents.passthrough`)
+
+	p, err := profile.ParseData(files["goroutine.pprof"])
+	require.NoError(t, err)
+	require.NotEmpty(t, p.Sample)
+	for _, s := range p.Sample {
+		require.Equal(t, []string{"testcomponents.tick.ticker"}, s.Label[controller.ProfileLabel])
+	}
+
+	// Profiles collected over a duration include a CPU profile and the
+	// mutex contention recorded during that duration.
+	buf.Reset()
+	err = ctrl.WriteComponentProfile(ctx, &buf, "testcomponents.tick.ticker", ComponentProfileOptions{Duration: 50 * time.Millisecond})
+	require.NoError(t, err)
+	files = readZip(t, buf.Bytes())
+	require.Contains(t, files, "cpu.pprof")
+	mutex, err := profile.ParseData(files["mutex.pprof"])
+	require.NoError(t, err)
+	require.Greater(t, mutex.DurationNanos, int64(0))
+
+	err = ctrl.WriteComponentProfile(ctx, &buf, "testcomponents.tick.missing", ComponentProfileOptions{})
+	require.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func init() {
+	component.Register(component.Registration{
+		Name: "testcomponents.module",
+		Args: testModuleArguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			m := &testModule{ctrl: New(Options{
+				ControllerID: opts.ID,
+				LogSink:      logging.LoggerSink(opts.Logger),
+				DataPath:     opts.DataPath,
+
+				OnExportsChange: func(map[string]any) {},
+			})}
+			if err := m.Update(args); err != nil {
+				return nil, err
+			}
+			return m, nil
+		},
+	})
+}
+
+type testModuleArguments struct {
+	Content string `river:"content,attr"`
+}
+
+// testModule loads its content into a nested controller, like the module.*
+// components.
+type testModule struct{ ctrl *Flow }
+
+var _ ModuleControllersComponent = (*testModule)(nil)
+
+func (m *testModule) Run(ctx context.Context) error {
+	m.ctrl.Run(ctx)
+	return nil
+}
+
+func (m *testModule) Update(args component.Arguments) error {
+	content := args.(testModuleArguments).Content
+	f, err := ReadFile("module", []byte(content))
+	if err != nil {
+		return err
+	}
+	return m.ctrl.LoadFile(f, nil)
+}
+
+func (m *testModule) ModuleControllers() []*Flow { return []*Flow{m.ctrl} }
+
+func TestWriteComponentProfile_Module(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.module "example" {
+			content = "testcomponents.tick \"ticker\" { frequency = \"1s\" }"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	// Components of modules are found by their global ID.
+	const id = "testcomponents.module.example/testcomponents.tick.ticker"
+	var files map[string][]byte
+	require.Eventually(t, func() bool {
+		var buf bytes.Buffer
+		if err := ctrl.WriteComponentProfile(ctx, &buf, id, ComponentProfileOptions{}); err != nil {
+			return false
+		}
+		files = readZip(t, buf.Bytes())
+		return len(files["goroutine.txt"]) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, string(files["goroutine.txt"]), `"component_id":"`+id+`"`)
+
+	// The local ID of a module's component isn't a component of ctrl.
+	err = ctrl.WriteComponentProfile(ctx, io.Discard, "testcomponents.tick.ticker", ComponentProfileOptions{})
+	require.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func TestDeltaProfile(t *testing.T) {
+	fn := &profile.Function{ID: 1, Name: "main.lock"}
+	loc := []*profile.Location{
+		{ID: 1, Line: []profile.Line{{Function: fn, Line: 10}}},
+		{ID: 2, Line: []profile.Line{{Function: fn, Line: 20}}},
+	}
+	encode := func(timeNanos int64, first, second int64) []byte {
+		p := &profile.Profile{
+			SampleType: []*profile.ValueType{{Type: "contentions", Unit: "count"}},
+			PeriodType: &profile.ValueType{Type: "contentions", Unit: "count"},
+			Period:     1,
+			TimeNanos:  timeNanos,
+			Sample: []*profile.Sample{
+				{Location: []*profile.Location{loc[0]}, Value: []int64{first}},
+				{Location: []*profile.Location{loc[1]}, Value: []int64{second}},
+			},
+			Location: loc,
+			Function: []*profile.Function{fn},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, p.Write(&buf))
+		return buf.Bytes()
+	}
+
+	bb, err := deltaProfile(encode(100, 5, 2), encode(300, 8, 2))
+	require.NoError(t, err)
+
+	p, err := profile.ParseData(bb)
+	require.NoError(t, err)
+	require.Equal(t, int64(200), p.DurationNanos)
+
+	// Only the contention recorded between the profiles remains.
+	require.Len(t, p.Sample, 1)
+	require.Equal(t, []int64{3}, p.Sample[0].Value)
+	require.Equal(t, int64(10), p.Sample[0].Location[0].Line[0].Line)
+}
+
+func readZip(t *testing.T, bb []byte) map[string][]byte {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(bb), int64(len(bb)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+	}
+	return files
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
@@ -49,6 +50,69 @@ func TestController_LoadFile_Evaluation(t *testing.T) {
 	require.Equal(t, "hello, world!", out.(testcomponents.PassthroughExports).Output)
 }
 
+func TestController_LoadFile_AuditLog(t *testing.T) {
+	auditLog, err := auditlog.New("", auditlog.DefaultCapacity)
+	require.NoError(t, err)
+
+	opts := testOptions(t)
+	opts.AuditLog = auditLog
+	ctrl := New(opts)
+
+	load := func(content string, trigger auditlog.Trigger) []auditlog.Event {
+		t.Helper()
+
+		f, err := ReadFile(t.Name(), []byte(content))
+		require.NoError(t, err)
+
+		before := len(auditLog.Events())
+		_ = ctrl.LoadFileWithTrigger(f, nil, trigger)
+		return auditLog.Events()[before:]
+	}
+	eventTypes := func(events []auditlog.Event) map[string]auditlog.EventType {
+		res := make(map[string]auditlog.EventType, len(events))
+		for _, e := range events {
+			res[e.Component] = e.Type
+		}
+		return res
+	}
+
+	startup := auditlog.Trigger{Kind: auditlog.TriggerStartup}
+	events := load(testFile, startup)
+	require.Equal(t, map[string]auditlog.EventType{
+		"":                                     auditlog.EventConfigLoad,
+		"testcomponents.tick.ticker":           auditlog.EventComponentAdded,
+		"testcomponents.passthrough.static":    auditlog.EventComponentAdded,
+		"testcomponents.passthrough.ticker":    auditlog.EventComponentAdded,
+		"testcomponents.passthrough.forwarded": auditlog.EventComponentAdded,
+	}, eventTypes(events))
+	for _, e := range events {
+		require.Equal(t, startup, e.Trigger)
+	}
+
+	reload := auditlog.Trigger{Kind: auditlog.TriggerAPI, Caller: "127.0.0.1:1234"}
+	events = load(`
+		testcomponents.tick "ticker" {
+			frequency = "5s"
+		}
+
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+		}
+	`, reload)
+	require.Equal(t, map[string]auditlog.EventType{
+		"":                                     auditlog.EventConfigLoad,
+		"testcomponents.tick.ticker":           auditlog.EventComponentUpdated,
+		"testcomponents.passthrough.ticker":    auditlog.EventComponentRemoved,
+		"testcomponents.passthrough.forwarded": auditlog.EventComponentRemoved,
+	}, eventTypes(events))
+	require.Equal(t, reload, events[0].Trigger)
+
+	// Failed loads are recorded with their error.
+	events = load(`testcomponents.tick "ticker" { frequency = 5 }`, reload)
+	require.Equal(t, auditlog.EventConfigLoad, events[0].Type)
+	require.NotEmpty(t, events[0].Error)
+}
+
 func getFields(t *testing.T, g *dag.Graph, nodeID string) (component.Arguments, component.Exports) {
 	t.Helper()
 
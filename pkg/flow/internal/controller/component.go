@@ -5,15 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime/pprof"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/diskbudget"
+	"github.com/grafana/agent/pkg/flow/auditlog"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/vm"
@@ -61,13 +65,15 @@ type ComponentGlobals struct {
 	LogSink           *logging.Sink                // Sink used for Logging.
 	Logger            *logging.Logger              // Logger shared between all managed components.
 	TraceProvider     trace.TracerProvider         // Tracer shared between all managed components.
-	DataPath          string                       // Shared directory where component data may be stored
+	DataPath          string                       // Shared directory where component data may be stored; not created when empty
 	OnComponentUpdate func(cn *ComponentNode)      // Informs controller that we need to reevaluate
 	OnExportsChange   func(exports map[string]any) // Invoked when the managed component updated its exports
 	Registerer        prometheus.Registerer        // Registerer for serving agent and component metrics
 	HTTPPathPrefix    string                       // HTTP prefix for components.
 	HTTPListenAddr    string                       // Base address for server
 	ControllerID      string                       // ID of controller.
+	AuditLog          *auditlog.Log                // Log of config changes; nil if disabled.
+	DiskBudget        *diskbudget.Budget           // Budget of the disk space used by components.
 }
 
 // ComponentNode is a controller node which manages a user-defined component.
@@ -82,6 +88,7 @@ type ComponentNode struct {
 	nodeID            string // Cached from id.String() to avoid allocating new strings every time NodeID is called.
 	reg               component.Registration
 	managedOpts       component.Options
+	hasDataPath       bool // Whether the controller was given a DataPath.
 	register          *wrappedRegisterer
 	exportsType       reflect.Type
 	OnComponentUpdate func(cn *ComponentNode) // Informs controller that we need to reevaluate
@@ -150,6 +157,7 @@ func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode
 		runHealth:  initHealth,
 	}
 	cn.managedOpts = getManagedOptions(globals, cn)
+	cn.hasDataPath = globals.DataPath != ""
 
 	return cn
 }
@@ -186,6 +194,8 @@ func getManagedOptions(globals ComponentGlobals, cn *ComponentNode) component.Op
 		HTTPPath:       path.Join(prefix, cn.nodeID) + "/",
 
 		OnStateChange: cn.setExports,
+		AuditLog:      globals.AuditLog,
+		DiskBudget:    globals.DiskBudget,
 	}
 }
 
@@ -247,6 +257,25 @@ func (cn *ComponentNode) Evaluate(scope *vm.Scope) error {
 	return err
 }
 
+// DataPathMarker is the name of the file written to every data directory
+// created by the controller. Only directories holding it are known to belong
+// to components.
+const DataPathMarker = ".flow-component"
+
+// createDataPath creates the data directory of the managed component and
+// marks it as created by the controller. The directory is only created if the
+// controller was given a DataPath; otherwise the managed component's DataPath
+// is relative to the working directory.
+func (cn *ComponentNode) createDataPath() error {
+	if !cn.hasDataPath {
+		return nil
+	}
+	if err := os.MkdirAll(cn.managedOpts.DataPath, 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cn.managedOpts.DataPath, DataPathMarker), nil, 0640)
+}
+
 func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
@@ -265,7 +294,16 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 
 	if cn.managed == nil {
 		// We haven't built the managed component successfully yet.
-		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
+		var (
+			managed component.Component
+			err     error
+		)
+		if err := cn.createDataPath(); err != nil {
+			return fmt.Errorf("creating data directory: %w", err)
+		}
+		cn.doLabeled(context.Background(), func(context.Context) {
+			managed, err = cn.reg.Build(cn.managedOpts, argsCopyValue)
+		})
 		if err != nil {
 			return fmt.Errorf("building component: %w", err)
 		}
@@ -283,7 +321,11 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := cn.managed.Update(argsCopyValue); err != nil {
+	var err error
+	cn.doLabeled(context.Background(), func(context.Context) {
+		err = cn.managed.Update(argsCopyValue)
+	})
+	if err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
 
@@ -307,7 +349,11 @@ func (cn *ComponentNode) Run(ctx context.Context) error {
 	}
 
 	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+
+	var err error
+	cn.doLabeled(ctx, func(ctx context.Context) {
+		err = cn.managed.Run(ctx)
+	})
 
 	var exitMsg string
 	logger := cn.managedOpts.Logger
@@ -323,6 +369,29 @@ func (cn *ComponentNode) Run(ctx context.Context) error {
 	return err
 }
 
+// ProfileLabel is the pprof label set to the global ID of a component on the
+// goroutines running that component.
+const ProfileLabel = "component_id"
+
+// doLabeled calls f with ProfileLabel set on the calling goroutine. Goroutines
+// started by f inherit the label, so that goroutines spawned by the managed
+// component when it's built, updated, or run can be identified in profiles.
+func (cn *ComponentNode) doLabeled(ctx context.Context, f func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(ProfileLabel, cn.managedOpts.ID), f)
+}
+
+// GlobalID returns the globally unique ID of the managed component, which
+// includes the ID of the module the component is defined in.
+func (cn *ComponentNode) GlobalID() string { return cn.managedOpts.ID }
+
+// Component returns the managed component, or nil if it hasn't been built
+// yet.
+func (cn *ComponentNode) Component() component.Component {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.managed
+}
+
 // ErrUnevaluated is returned if ComponentNode.Run is called before a managed
 // component is built.
 var ErrUnevaluated = errors.New("managed component not built")
@@ -58,6 +58,12 @@ func ComponentReferences(parent *vm.Scope, cn dag.Node, g *dag.Graph) ([]Referen
 	return refs, diags
 }
 
+// TraversalsFromBody returns all variable references found within body
+// without resolving them against a graph.
+func TraversalsFromBody(body ast.Body) []Traversal {
+	return expressionsFromBody(body)
+}
+
 // expressionsFromSyntaxBody recurses through body and finds all variable
 // references.
 func expressionsFromBody(body ast.Body) []Traversal {
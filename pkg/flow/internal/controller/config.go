@@ -8,9 +8,11 @@ import (
 )
 
 const (
-	exportBlockID  = "export"
-	loggingBlockID = "logging"
-	tracingBlockID = "tracing"
+	exportBlockID       = "export"
+	loggingBlockID      = "logging"
+	tracingBlockID      = "tracing"
+	httpDefaultsBlockID = "http_defaults"
+	diskBudgetBlockID   = "disk_budget"
 )
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -23,6 +25,10 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, isInModule bo
 		return NewLoggingConfigNode(block, globals, isInModule)
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals, isInModule)
+	case httpDefaultsBlockID:
+		return NewHTTPDefaultsConfigNode(block, globals, isInModule)
+	case diskBudgetBlockID:
+		return NewDiskBudgetConfigNode(block, globals, isInModule)
 	default:
 		var diags diag.Diagnostics
 		diags.Add(diag.Diagnostic{
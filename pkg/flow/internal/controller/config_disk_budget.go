@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/component/common/diskbudget"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// DiskBudgetConfigNode manages the disk_budget block, which sets the quotas
+// enforced on the data stored on disk by all components.
+type DiskBudgetConfigNode struct {
+	nodeID        string
+	componentName string
+	globals       ComponentGlobals
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River blocks to derive config from
+	eval  *vm.Evaluator
+}
+
+// NewDiskBudgetConfigNode creates a new DiskBudgetConfigNode from an initial
+// ast.BlockStmt. The underlying config isn't applied until Evaluate is called.
+func NewDiskBudgetConfigNode(block *ast.BlockStmt, globals ComponentGlobals, isInModule bool) (*DiskBudgetConfigNode, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if isInModule {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  "disk_budget block not allowed inside a module",
+			StartPos: ast.StartPos(block).Position(),
+			EndPos:   ast.EndPos(block).Position(),
+		})
+
+		return nil, diags
+	}
+
+	return &DiskBudgetConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+		globals:       globals,
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}, diags
+}
+
+// NewDefaultDiskBudgetConfigNode creates a new DiskBudgetConfigNode with nil
+// block and eval. This will force evaluate to clear any previously set
+// quotas.
+func NewDefaultDiskBudgetConfigNode(globals ComponentGlobals) *DiskBudgetConfigNode {
+	return &DiskBudgetConfigNode{
+		nodeID:        diskBudgetBlockID,
+		componentName: diskBudgetBlockID,
+		globals:       globals,
+
+		block: nil,
+		eval:  nil,
+	}
+}
+
+// Evaluate implements BlockNode and updates the disk budget by re-evaluating
+// its River block with the provided scope.
+//
+// Evaluate will return an error if the River block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *DiskBudgetConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	args := diskbudget.DefaultArguments
+	if cn.eval != nil {
+		if err := cn.eval.Evaluate(scope, &args); err != nil {
+			return fmt.Errorf("decoding River: %w", err)
+		}
+	}
+
+	cn.globals.DiskBudget.Update(cn.globals.Logger, cn.globals.DataPath, args)
+	return nil
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *DiskBudgetConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *DiskBudgetConfigNode) NodeID() string { return cn.nodeID }
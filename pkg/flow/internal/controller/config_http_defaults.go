@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// HTTPDefaultsConfigNode manages the http_defaults block, which sets the
+// defaults inherited by the HTTP clients of all components.
+type HTTPDefaultsConfigNode struct {
+	nodeID        string
+	componentName string
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River blocks to derive config from
+	eval  *vm.Evaluator
+}
+
+// NewHTTPDefaultsConfigNode creates a new HTTPDefaultsConfigNode from an
+// initial ast.BlockStmt. The underlying config isn't applied until Evaluate
+// is called.
+func NewHTTPDefaultsConfigNode(block *ast.BlockStmt, globals ComponentGlobals, isInModule bool) (*HTTPDefaultsConfigNode, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if isInModule {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  "http_defaults block not allowed inside a module",
+			StartPos: ast.StartPos(block).Position(),
+			EndPos:   ast.EndPos(block).Position(),
+		})
+
+		return nil, diags
+	}
+
+	return &HTTPDefaultsConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}, diags
+}
+
+// NewDefaultHTTPDefaultsConfigNode creates a new HTTPDefaultsConfigNode with
+// nil block and eval. This will force evaluate to clear any previously set
+// HTTP client defaults.
+func NewDefaultHTTPDefaultsConfigNode(globals ComponentGlobals) *HTTPDefaultsConfigNode {
+	return &HTTPDefaultsConfigNode{
+		nodeID:        httpDefaultsBlockID,
+		componentName: httpDefaultsBlockID,
+
+		block: nil,
+		eval:  nil,
+	}
+}
+
+// Evaluate implements BlockNode and updates the HTTP client defaults by
+// re-evaluating its River block with the provided scope.
+//
+// Evaluate will return an error if the River block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *HTTPDefaultsConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	var args config.HTTPClientDefaults
+	if cn.eval != nil {
+		if err := cn.eval.Evaluate(scope, &args); err != nil {
+			return fmt.Errorf("decoding River: %w", err)
+		}
+	}
+
+	config.SetHTTPClientDefaults(args)
+	return nil
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *HTTPDefaultsConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *HTTPDefaultsConfigNode) NodeID() string { return cn.nodeID }
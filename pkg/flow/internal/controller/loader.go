@@ -184,6 +184,7 @@ func (l *Loader) loadNewGraph(parentScope *vm.Scope, componentBlocks []*ast.Bloc
 	// Write up the edges of the graph
 	wireDiags := l.wireGraphEdges(parentScope, &g)
 	diags = append(diags, wireDiags...)
+	wireHTTPDefaultsEdges(&g)
 
 	// Validate graph to detect cycles
 	err := dag.Validate(&g)
@@ -239,6 +240,20 @@ func (l *Loader) populateConfigBlockNodes(g *dag.Graph, configBlocks []*ast.Bloc
 		g.Add(c)
 	}
 
+	// If an http_defaults config block is not provided, we create an empty node
+	// which clears any previously set defaults.
+	if _, ok := blockMap[httpDefaultsBlockID]; !ok && !l.isModule() {
+		c := NewDefaultHTTPDefaultsConfigNode(l.globals)
+		g.Add(c)
+	}
+
+	// If a disk_budget config block is not provided, we create an empty node
+	// which clears any previously set quotas.
+	if _, ok := blockMap[diskBudgetBlockID]; !ok && !l.isModule() {
+		c := NewDefaultDiskBudgetConfigNode(l.globals)
+		g.Add(c)
+	}
+
 	return diags
 }
 
@@ -335,6 +350,33 @@ func (l *Loader) wireGraphEdges(parent *vm.Scope, g *dag.Graph) diag.Diagnostics
 	return diags
 }
 
+// wireHTTPDefaultsEdges makes every component depend on the http_defaults
+// node so that components are evaluated with the latest HTTP client defaults.
+// Components which the http_defaults node itself depends on are skipped to
+// avoid introducing cycles.
+func wireHTTPDefaultsEdges(g *dag.Graph) {
+	defaults := g.GetByID(httpDefaultsBlockID)
+	if defaults == nil {
+		return
+	}
+
+	dependencies := make(map[dag.Node]struct{})
+	_ = dag.Walk(g, []dag.Node{defaults}, func(n dag.Node) error {
+		dependencies[n] = struct{}{}
+		return nil
+	})
+
+	for _, n := range g.Nodes() {
+		if _, ok := n.(*ComponentNode); !ok {
+			continue
+		}
+		if _, ok := dependencies[n]; ok {
+			continue
+		}
+		g.AddEdge(dag.Edge{From: n, To: defaults})
+	}
+}
+
 // Variables returns the Variables the Loader exposes for other Flow components
 // to reference.
 func (l *Loader) Variables() map[string]interface{} {
@@ -56,10 +56,14 @@ func TestLoader(t *testing.T) {
 			"testcomponents.passthrough.forwarded",
 			"logging",
 			"tracing",
+			"http_defaults",
+			"disk_budget",
 		},
 		OutEdges: []edge{
 			{From: "testcomponents.passthrough.ticker", To: "testcomponents.tick.ticker"},
 			{From: "testcomponents.passthrough.forwarded", To: "testcomponents.passthrough.ticker"},
+			{From: "testcomponents.tick.ticker", To: "http_defaults"},
+			{From: "testcomponents.passthrough.static", To: "http_defaults"},
 		},
 	}
 
@@ -88,6 +92,26 @@ func TestLoader(t *testing.T) {
 		requireGraph(t, l.Graph(), testGraphDefinition)
 	})
 
+	t.Run("HTTP defaults referencing a component", func(t *testing.T) {
+		httpDefaultsConfig := `
+			http_defaults {
+				proxy_url = testcomponents.passthrough.static.output
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(testFile), []byte(httpDefaultsConfig))
+		require.NoError(t, diags.ErrorOrNil())
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: testGraphDefinition.Nodes,
+			OutEdges: []edge{
+				{From: "testcomponents.passthrough.ticker", To: "testcomponents.tick.ticker"},
+				{From: "testcomponents.passthrough.forwarded", To: "testcomponents.passthrough.ticker"},
+				{From: "testcomponents.tick.ticker", To: "http_defaults"},
+				{From: "http_defaults", To: "testcomponents.passthrough.static"},
+			},
+		})
+	})
+
 	t.Run("Copy existing components and delete stale ones", func(t *testing.T) {
 		startFile := `
 			// Component that should be copied over to the new graph
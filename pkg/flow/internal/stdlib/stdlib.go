@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
 )
 
@@ -43,4 +44,10 @@ var Identifiers = map[string]interface{}{
 
 		return res, nil
 	},
+
+	// nonsensitive deliberately converts a secret into a string. Strings
+	// passed to nonsensitive are returned unmodified.
+	"nonsensitive": func(secret rivertypes.Secret) string {
+		return string(secret)
+	},
 }
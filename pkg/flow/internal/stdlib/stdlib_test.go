@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/river/vm"
 	"github.com/prometheus/common/model"
@@ -63,6 +64,34 @@ func TestVM_Stdlib_Scoped(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "nonsensitive secret",
+			input: `nonsensitive(input)`,
+			scope: &vm.Scope{
+				Parent: rootScope,
+				Variables: map[string]interface{}{
+					"input": rivertypes.Secret("foobar"),
+				},
+			},
+			expect: "foobar",
+		},
+		{
+			name:  "nonsensitive concatenated secret",
+			input: `nonsensitive("user:" + input)`,
+			scope: &vm.Scope{
+				Parent: rootScope,
+				Variables: map[string]interface{}{
+					"input": rivertypes.OptionalSecret{IsSecret: true, Value: "foobar"},
+				},
+			},
+			expect: "user:foobar",
+		},
+		{
+			name:   "nonsensitive string",
+			input:  `nonsensitive("foobar")`,
+			scope:  &vm.Scope{Parent: rootScope},
+			expect: "foobar",
+		},
 	}
 
 	for _, tc := range tt {
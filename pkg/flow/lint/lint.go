@@ -0,0 +1,371 @@
+// Package lint implements semantic checks for Grafana Agent Flow
+// configuration files which go beyond what is required for a file to load.
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// Severity is the severity of a Finding.
+type Severity string
+
+// Supported severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Rule describes a check performed by the linter.
+type Rule struct {
+	ID          string
+	Description string
+}
+
+// Rules performed by the linter.
+var (
+	RuleUnknownComponent = Rule{
+		ID:          "unknown-component",
+		Description: "Blocks must be a known component or config block.",
+	}
+	RuleNoConsumers = Rule{
+		ID:          "no-consumers",
+		Description: "Components with exports should be referenced by another block.",
+	}
+	RuleUnusedExports = Rule{
+		ID:          "unused-exports",
+		Description: "Exported fields of a component which are never referenced.",
+	}
+	RuleMissingForwardTo = Rule{
+		ID:          "missing-forward-to",
+		Description: "Components which send data should forward it to at least one receiver.",
+	}
+	RuleSuspiciousRelabelRegex = Rule{
+		ID:          "suspicious-relabel-regex",
+		Description: "Relabeling regexes which are invalid or likely don't behave as intended.",
+	}
+)
+
+// AllRules is the list of every rule performed by the linter.
+var AllRules = []Rule{
+	RuleUnknownComponent,
+	RuleNoConsumers,
+	RuleUnusedExports,
+	RuleMissingForwardTo,
+	RuleSuspiciousRelabelRegex,
+}
+
+// Finding is an individual problem found by the linter.
+type Finding struct {
+	Rule     Rule
+	Severity Severity
+	Message  string
+
+	StartPos token.Position
+	EndPos   token.Position
+}
+
+// configBlocks are top-level blocks which are not components.
+var configBlocks = map[string]struct{}{
+	"argument":      {},
+	"disk_budget":   {},
+	"export":        {},
+	"http_defaults": {},
+	"logging":       {},
+	"tracing":       {},
+}
+
+// Lint checks the components in f. Components must be registered before
+// calling Lint for their arguments and exports to be checked. Findings are
+// returned in the order they appear in the file.
+func Lint(f *ast.File) []Finding {
+	l := linter{
+		components: make(map[string]*lintComponent),
+	}
+	body := withProfiles(f.Body)
+	l.collect(body)
+	l.resolveReferences(body)
+
+	for _, c := range l.order {
+		l.checkConsumers(c)
+		l.checkForwardTo(c)
+	}
+	l.checkRelabelRules(body)
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].StartPos.Offset < l.findings[j].StartPos.Offset
+	})
+	return l.findings
+}
+
+// withProfiles returns body with the contents of every profile block in place
+// of the profile block, so that the blocks of all profiles are checked.
+func withProfiles(body ast.Body) ast.Body {
+	res := make(ast.Body, 0, len(body))
+	for _, stmt := range body {
+		if block, ok := stmt.(*ast.BlockStmt); ok && block.GetBlockName() == "profile" {
+			res = append(res, block.Body...)
+			continue
+		}
+		res = append(res, stmt)
+	}
+	return res
+}
+
+type linter struct {
+	components map[string]*lintComponent // Components by ID.
+	order      []*lintComponent          // Components in file order.
+	findings   []Finding
+}
+
+type lintComponent struct {
+	id    string
+	block *ast.BlockStmt
+	reg   component.Registration
+
+	referenced     bool                // Whether any block references the component.
+	referencedAll  bool                // Whether the component is referenced as a whole.
+	referencedKeys map[string]struct{} // Exported fields which are referenced.
+}
+
+func (l *linter) collect(body ast.Body) {
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+
+		name := block.GetBlockName()
+		if _, ok := configBlocks[name]; ok {
+			continue
+		}
+
+		reg, ok := component.Get(name)
+		if !ok {
+			l.add(RuleUnknownComponent, SeverityError, block, "unrecognized block name %q", name)
+			continue
+		}
+
+		c := &lintComponent{
+			id:             controller.BlockComponentID(block).String(),
+			block:          block,
+			reg:            reg,
+			referencedKeys: make(map[string]struct{}),
+		}
+		l.components[c.id] = c
+		l.order = append(l.order, c)
+	}
+}
+
+// resolveReferences marks every component referenced from another block.
+func (l *linter) resolveReferences(body ast.Body) {
+	for _, stmt := range body {
+		var (
+			from  string
+			inner ast.Body
+		)
+		switch stmt := stmt.(type) {
+		case *ast.BlockStmt:
+			from = controller.BlockComponentID(stmt).String()
+			inner = stmt.Body
+		case *ast.AttributeStmt:
+			inner = ast.Body{stmt}
+		}
+
+		for _, t := range controller.TraversalsFromBody(inner) {
+			l.resolveTraversal(from, t)
+		}
+	}
+}
+
+func (l *linter) resolveTraversal(from string, t controller.Traversal) {
+	id := t[0].Name
+	for i := 1; ; i++ {
+		if c, ok := l.components[id]; ok {
+			if c.id == from {
+				return
+			}
+			c.referenced = true
+			if i < len(t) {
+				c.referencedKeys[t[i].Name] = struct{}{}
+			} else {
+				c.referencedAll = true
+			}
+			return
+		}
+		if i >= len(t) {
+			return
+		}
+		id += "." + t[i].Name
+	}
+}
+
+func (l *linter) checkConsumers(c *lintComponent) {
+	exports := riverAttrNames(c.reg.Exports)
+	if len(exports) == 0 {
+		return
+	}
+
+	if !c.referenced {
+		l.add(RuleNoConsumers, SeverityWarning, c.block, "component %q has exports but is never referenced", c.id)
+		return
+	}
+	if c.referencedAll {
+		return
+	}
+
+	var unused []string
+	for _, name := range exports {
+		if _, ok := c.referencedKeys[name]; !ok {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		l.add(RuleUnusedExports, SeverityInfo, c.block, "exports %s of component %q are never referenced", strings.Join(unused, ", "), c.id)
+	}
+}
+
+func (l *linter) checkForwardTo(c *lintComponent) {
+	const forwardTo = "forward_to"
+
+	if !hasRiverAttr(c.reg.Args, forwardTo) {
+		return
+	}
+
+	attr := findAttr(c.block.Body, forwardTo)
+	if attr == nil {
+		l.add(RuleMissingForwardTo, SeverityWarning, c.block, "component %q does not set %s and will drop all data it receives", c.id, forwardTo)
+		return
+	}
+	if arr, ok := attr.Value.(*ast.ArrayExpr); ok && len(arr.Elements) == 0 {
+		l.add(RuleMissingForwardTo, SeverityWarning, attr, "component %q forwards to an empty list and will drop all data it receives", c.id)
+	}
+}
+
+// checkRelabelRules checks every rule block in body, no matter how deeply
+// nested.
+func (l *linter) checkRelabelRules(body ast.Body) {
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+		if block.GetBlockName() == "rule" {
+			l.checkRelabelRule(block)
+		}
+		l.checkRelabelRules(block.Body)
+	}
+}
+
+func (l *linter) checkRelabelRule(block *ast.BlockStmt) {
+	regexAttr := findAttr(block.Body, "regex")
+	if regexAttr == nil {
+		return
+	}
+	regex, ok := stringLiteral(regexAttr.Value)
+	if !ok {
+		return
+	}
+
+	if _, err := regexp.Compile("^(?:" + regex + ")$"); err != nil {
+		l.add(RuleSuspiciousRelabelRegex, SeverityError, regexAttr, "invalid regex %q: %s", regex, err)
+		return
+	}
+
+	if strings.HasPrefix(regex, "^") || (strings.HasSuffix(regex, "$") && !strings.HasSuffix(regex, `\$`)) {
+		l.add(RuleSuspiciousRelabelRegex, SeverityWarning, regexAttr, "regex %q contains anchors, but relabeling regexes are always fully anchored", regex)
+	}
+
+	var action string
+	if actionAttr := findAttr(block.Body, "action"); actionAttr != nil {
+		action, _ = stringLiteral(actionAttr.Value)
+	}
+	switch action {
+	case "keep", "drop", "keepequal", "dropequal":
+		if regex == ".*" || regex == "(.*)" {
+			l.add(RuleSuspiciousRelabelRegex, SeverityWarning, regexAttr, "regex %q matches every value, so the %s action always applies", regex, action)
+		}
+	}
+}
+
+func (l *linter) add(rule Rule, severity Severity, n ast.Node, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		StartPos: ast.StartPos(n).Position(),
+		EndPos:   ast.EndPos(n).Position(),
+	})
+}
+
+func findAttr(body ast.Body, name string) *ast.AttributeStmt {
+	for _, stmt := range body {
+		if attr, ok := stmt.(*ast.AttributeStmt); ok && attr.Name.Name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+func stringLiteral(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.LiteralExpr)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// riverAttrNames returns the names of the attributes of the River struct v.
+func riverAttrNames(v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("river")
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name, opts := parts[0], parts[1:]
+		for _, opt := range opts {
+			switch opt {
+			case "attr":
+				names = append(names, name)
+			case "squash":
+				names = append(names, riverAttrNames(reflect.Zero(field.Type).Interface())...)
+			}
+		}
+	}
+	return names
+}
+
+func hasRiverAttr(v interface{}, name string) bool {
+	for _, attr := range riverAttrNames(v) {
+		if attr == name {
+			return true
+		}
+	}
+	return false
+}
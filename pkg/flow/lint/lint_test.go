@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	_ "github.com/grafana/agent/component/discovery/relabel"
+	_ "github.com/grafana/agent/component/local/file"
+	_ "github.com/grafana/agent/component/prometheus/relabel"
+	_ "github.com/grafana/agent/component/prometheus/scrape"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+local.file "unused" {
+  filename = "/etc/password"
+}
+
+discovery.relabel "targets" {
+  targets = []
+
+  rule {
+    source_labels = ["app"]
+    regex         = "^foo$"
+    action        = "keep"
+  }
+
+  rule {
+    source_labels = ["app"]
+    regex         = ".*"
+    action        = "drop"
+  }
+
+  rule {
+    source_labels = ["app"]
+    regex         = "("
+  }
+}
+
+prometheus.scrape "default" {
+  targets    = discovery.relabel.targets.output
+  forward_to = []
+}
+
+prometheus.relabel "default" { }
+
+unknown.component "default" { }
+`
+
+type result struct {
+	Rule     string
+	Severity Severity
+	Line     int
+}
+
+func lintString(t *testing.T, config string) []Finding {
+	t.Helper()
+
+	f, err := parser.ParseFile("config.river", []byte(config))
+	require.NoError(t, err)
+	return Lint(f)
+}
+
+func TestLint(t *testing.T) {
+	var results []result
+	for _, f := range lintString(t, testConfig) {
+		results = append(results, result{f.Rule.ID, f.Severity, f.StartPos.Line})
+	}
+
+	expect := []result{
+		{"no-consumers", SeverityWarning, 2},
+		{"unused-exports", SeverityInfo, 6},
+		{"suspicious-relabel-regex", SeverityWarning, 11},
+		{"suspicious-relabel-regex", SeverityWarning, 17},
+		{"suspicious-relabel-regex", SeverityError, 23},
+		{"missing-forward-to", SeverityWarning, 29},
+		{"no-consumers", SeverityWarning, 32},
+		{"missing-forward-to", SeverityWarning, 32},
+		{"unknown-component", SeverityError, 34},
+	}
+	require.Equal(t, expect, results)
+}
+
+func TestLint_References(t *testing.T) {
+	// Components referenced from export blocks or referenced as a whole are
+	// considered consumed.
+	findings := lintString(t, `
+		discovery.relabel "a" {
+			targets = []
+		}
+
+		discovery.relabel "b" {
+			targets = discovery.relabel.a.output
+		}
+
+		export "b" {
+			value = discovery.relabel.b
+		}
+
+		export "a_rules" {
+			value = discovery.relabel.a.rules
+		}
+	`)
+	require.Empty(t, findings)
+}
+
+func TestLint_Profiles(t *testing.T) {
+	// Components of every profile are checked.
+	findings := lintString(t, `
+		discovery.relabel "a" {
+			targets = []
+		}
+
+		profile "dev" {
+			discovery.relabel "b" {
+				targets = discovery.relabel.a.output
+			}
+
+			unknown.component "c" {}
+		}
+
+		export "b" {
+			value = discovery.relabel.b
+		}
+
+		export "a_rules" {
+			value = discovery.relabel.a.rules
+		}
+	`)
+	require.Len(t, findings, 1)
+	require.Equal(t, RuleUnknownComponent, findings[0].Rule)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, lintString(t, testConfig)))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Tool.Driver.Rules, len(AllRules))
+	require.Len(t, log.Runs[0].Results, 9)
+
+	first := log.Runs[0].Results[0]
+	require.Equal(t, "no-consumers", first.RuleID)
+	require.Equal(t, "warning", first.Level)
+	require.Equal(t, "config.river", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, 2, first.Locations[0].PhysicalLocation.Region.StartLine)
+}
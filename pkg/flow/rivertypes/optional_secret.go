@@ -18,6 +18,9 @@ import (
 // Additionally, OptionalSecrets may be converted into the Secret type
 // regardless of the value of IsSecret. OptionalSecret can be converted into a
 // string as long as IsSecret is false.
+//
+// Concatenating an OptionalSecret using the + operator produces a Secret if
+// either operand is sensitive, and a string otherwise.
 type OptionalSecret struct {
 	IsSecret bool
 	Value    string
@@ -27,6 +30,7 @@ var (
 	_ river.Capsule                = OptionalSecret{}
 	_ river.ConvertibleIntoCapsule = OptionalSecret{}
 	_ river.ConvertibleFromCapsule = (*OptionalSecret)(nil)
+	_ river.ConcatenatableCapsule  = OptionalSecret{}
 
 	_ builder.Tokenizer = OptionalSecret{}
 )
@@ -71,6 +75,36 @@ func (s *OptionalSecret) ConvertFrom(src interface{}) error {
 	return river.ErrNoConversion
 }
 
+// Concat concatenates the OptionalSecret with other, which may be a string, a
+// Secret, or an OptionalSecret. The result is a Secret if either value is
+// sensitive, and a string otherwise. In other cases, this method will return
+// river.ErrNoConversion.
+func (s OptionalSecret) Concat(other interface{}, reverse bool) (interface{}, error) {
+	if s.IsSecret {
+		return Secret(s.Value).Concat(other, reverse)
+	}
+
+	var otherValue string
+	switch other := other.(type) {
+	case string:
+		otherValue = other
+	case Secret:
+		return other.Concat(s.Value, !reverse)
+	case OptionalSecret:
+		if other.IsSecret {
+			return Secret(other.Value).Concat(s.Value, !reverse)
+		}
+		otherValue = other.Value
+	default:
+		return nil, river.ErrNoConversion
+	}
+
+	if reverse {
+		return otherValue + s.Value, nil
+	}
+	return s.Value + otherValue, nil
+}
+
 // RiverTokenize returns a set of custom tokens to represent this value in
 // River text.
 func (s OptionalSecret) RiverTokenize() []builder.Token {
@@ -68,6 +68,24 @@ func TestOptionalSecret(t *testing.T) {
 		}
 		require.Equal(t, expect, s)
 	})
+
+	t.Run("non-sensitive concatenation produces a string", func(t *testing.T) {
+		input := rivertypes.OptionalSecret{IsSecret: false, Value: "testval"}
+
+		var s string
+		err := evaluateTo(t, `val + "-suffix"`, input, &s)
+		require.NoError(t, err)
+		require.Equal(t, "testval-suffix", s)
+	})
+
+	t.Run("sensitive concatenation produces a secret", func(t *testing.T) {
+		input := rivertypes.OptionalSecret{IsSecret: true, Value: "testval"}
+
+		var s rivertypes.OptionalSecret
+		err := evaluateTo(t, `"prefix-" + val`, input, &s)
+		require.NoError(t, err)
+		require.Equal(t, rivertypes.OptionalSecret{IsSecret: true, Value: "prefix-testval"}, s)
+	})
 }
 
 func TestOptionalSecret_Write(t *testing.T) {
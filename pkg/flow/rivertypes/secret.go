@@ -20,6 +20,7 @@ var (
 	_ river.Capsule                = Secret("")
 	_ river.ConvertibleIntoCapsule = Secret("")
 	_ river.ConvertibleFromCapsule = (*Secret)(nil)
+	_ river.ConcatenatableCapsule  = Secret("")
 
 	_ builder.Tokenizer = Secret("")
 )
@@ -58,6 +59,28 @@ func (s *Secret) ConvertFrom(src interface{}) error {
 	return river.ErrNoConversion
 }
 
+// Concat concatenates the Secret with other, which may be a string, a Secret,
+// or an OptionalSecret. The result is always a Secret. In other cases, this
+// method will return river.ErrNoConversion.
+func (s Secret) Concat(other interface{}, reverse bool) (interface{}, error) {
+	var otherValue string
+	switch other := other.(type) {
+	case string:
+		otherValue = other
+	case Secret:
+		otherValue = string(other)
+	case OptionalSecret:
+		otherValue = other.Value
+	default:
+		return nil, river.ErrNoConversion
+	}
+
+	if reverse {
+		return Secret(otherValue + string(s)), nil
+	}
+	return Secret(string(s) + otherValue), nil
+}
+
 // RiverTokenize returns a set of custom tokens to represent this value in
 // River text.
 func (s Secret) RiverTokenize() []builder.Token {
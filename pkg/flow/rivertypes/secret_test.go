@@ -30,18 +30,43 @@ func TestSecret(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, rivertypes.Secret("Hello, world!"), s)
 	})
+
+	t.Run("concatenating secrets with strings produces secrets", func(t *testing.T) {
+		var s rivertypes.Secret
+		err := evaluateTo(t, `"Bearer " + val + "!"`, rivertypes.Secret("token"), &s)
+		require.NoError(t, err)
+		require.Equal(t, rivertypes.Secret("Bearer token!"), s)
+	})
+
+	t.Run("concatenated secrets cannot be converted to strings", func(t *testing.T) {
+		var s string
+		err := evaluateTo(t, `"prefix-" + val`, rivertypes.Secret("token"), &s)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "secrets may not be converted into strings")
+	})
+
+	t.Run("secrets cannot be concatenated with numbers", func(t *testing.T) {
+		var s rivertypes.Secret
+		err := evaluateTo(t, `val + 5`, rivertypes.Secret("token"), &s)
+		require.NotNil(t, err)
+	})
 }
 
 func decodeTo(t *testing.T, input interface{}, target interface{}) error {
 	t.Helper()
+	return evaluateTo(t, "val", input, target)
+}
+
+func evaluateTo(t *testing.T, input string, val interface{}, target interface{}) error {
+	t.Helper()
 
-	expr, err := parser.ParseExpression("val")
+	expr, err := parser.ParseExpression(input)
 	require.NoError(t, err)
 
 	eval := vm.New(expr)
 	return eval.Evaluate(&vm.Scope{
 		Variables: map[string]interface{}{
-			"val": input,
+			"val": val,
 		},
 	}, target)
 }
@@ -0,0 +1,149 @@
+// Package flowconvert converts the config of a static mode agent into an
+// equivalent Grafana Agent Flow configuration file.
+//
+// Not every static mode feature has a Flow equivalent. Parts of the config
+// which can't be converted, or which behave differently once converted, are
+// reported as diagnostics so that migrations can be audited.
+package flowconvert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/agent/pkg/logs"
+	"github.com/grafana/agent/pkg/metrics"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	"github.com/grafana/agent/pkg/traces"
+)
+
+// Input holds the parts of a static mode config to convert.
+type Input struct {
+	Metrics metrics.Config
+	Logs    *logs.Config
+	Traces  traces.Config
+
+	// Integrations holds the names of the enabled integrations.
+	Integrations []string
+}
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+// Supported severities.
+const (
+	// SeverityInfo is used for behavioral differences which don't require any
+	// action.
+	SeverityInfo Severity = iota
+	// SeverityWarn is used when converted settings behave differently.
+	SeverityWarn
+	// SeverityError is used when settings could not be converted at all.
+	SeverityError
+)
+
+// String returns the name of s.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic reports a part of the config which could not be converted or
+// which behaves differently once converted.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Summary  string   `json:"summary"`
+}
+
+// Diagnostics is a list of Diagnostic.
+type Diagnostics []Diagnostic
+
+func (ds *Diagnostics) add(s Severity, format string, args ...interface{}) {
+	*ds = append(*ds, Diagnostic{Severity: s, Summary: fmt.Sprintf(format, args...)})
+}
+
+// HasErrors returns true if any diagnostic has SeverityError.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is the result of a conversion.
+type Result struct {
+	// River holds the converted Flow configuration file.
+	River []byte
+	// Diagnostics holds the parts of the config which could not be converted
+	// or which behave differently once converted.
+	Diagnostics Diagnostics
+}
+
+// Bytes returns the converted configuration file prefixed by a comment
+// reporting the diagnostics of the conversion.
+func (r *Result) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("// Converted from a static mode configuration.\n")
+	if len(r.Diagnostics) == 0 {
+		buf.WriteString("// The configuration was fully converted.\n")
+	} else {
+		buf.WriteString("//\n// Review the following before running the converted configuration:\n//\n")
+		for _, d := range r.Diagnostics {
+			fmt.Fprintf(&buf, "//   (%s) %s\n", d.Severity, strings.ReplaceAll(d.Summary, "\n", " "))
+		}
+	}
+	buf.WriteString("\n")
+	buf.Write(r.River)
+	return buf.Bytes()
+}
+
+// Convert converts in into a Flow configuration file. Convert never fails;
+// settings which can't be converted are reported in the Diagnostics of the
+// Result instead.
+func Convert(in Input) *Result {
+	c := &converter{
+		file:   builder.NewFile(),
+		labels: make(map[string]map[string]struct{}),
+	}
+
+	c.convertMetrics(in.Metrics)
+	if in.Logs != nil {
+		c.convertLogs(in.Logs)
+	}
+	c.convertIntegrations(in.Integrations)
+	if len(in.Traces.Configs) > 0 {
+		c.diags.add(SeverityError, "traces configs were not converted; use otelcol components to receive, process, and export traces")
+	}
+
+	return &Result{
+		River:       c.file.Bytes(),
+		Diagnostics: c.diags,
+	}
+}
+
+// converter holds the state of a conversion.
+type converter struct {
+	file   *builder.File
+	blocks int
+	diags  Diagnostics
+
+	// redacted is set once a secret has been redacted.
+	redacted bool
+
+	// labels holds the labels used per component name.
+	labels map[string]map[string]struct{}
+}
@@ -0,0 +1,89 @@
+package flowconvert
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/agent/pkg/logs"
+	"github.com/grafana/agent/pkg/metrics"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/traces"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
+// staticConfig mirrors the parts of the static mode config which are
+// converted.
+type staticConfig struct {
+	Metrics metrics.Config `yaml:"metrics,omitempty"`
+	Logs    *logs.Config   `yaml:"logs,omitempty"`
+	Traces  traces.Config  `yaml:"traces,omitempty"`
+}
+
+func loadInput(t *testing.T, path string) Input {
+	t.Helper()
+
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var cfg staticConfig
+	require.NoError(t, yaml.UnmarshalStrict(bb, &cfg))
+	require.NoError(t, cfg.Metrics.ApplyDefaults())
+	if cfg.Logs != nil {
+		require.NoError(t, cfg.Logs.ApplyDefaults())
+	}
+
+	return Input{
+		Metrics: cfg.Metrics,
+		Logs:    cfg.Logs,
+		Traces:  cfg.Traces,
+	}
+}
+
+func TestConvert(t *testing.T) {
+	in := loadInput(t, "testdata/full.yaml")
+	in.Integrations = []string{"node_exporter", "agent", "vsphere"}
+
+	res := Convert(in)
+
+	// The converted file must be valid River.
+	_, err := parser.ParseFile("converted.river", res.River)
+	require.NoError(t, err)
+
+	golden := filepath.Join("testdata", "full.river")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, res.Bytes(), 0644))
+	}
+	expect, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(expect), string(res.Bytes()))
+	require.True(t, res.Diagnostics.HasErrors())
+}
+
+func TestConvert_Secrets(t *testing.T) {
+	in := loadInput(t, "testdata/full.yaml")
+	in.Metrics.Global.RemoteWrite[0].HTTPClientConfig.BasicAuth.PasswordFile = ""
+	in.Metrics.Global.RemoteWrite[0].HTTPClientConfig.BasicAuth.Password = "hunter2"
+
+	res := Convert(in)
+	require.NotContains(t, string(res.River), "hunter2")
+	require.Contains(t, string(res.River), "password = (secret)")
+	require.Contains(t, res.Diagnostics, Diagnostic{
+		Severity: SeverityWarn,
+		Summary:  "secrets were redacted and are written as (secret); replace them with their values, for example by reading them with local.file",
+	})
+}
+
+func TestConverter_Label(t *testing.T) {
+	c := &converter{labels: make(map[string]map[string]struct{})}
+
+	require.Equal(t, "default_local_agent", c.label("prometheus.scrape", "default", "local/agent"))
+	require.Equal(t, "default_local_agent_2", c.label("prometheus.scrape", "default", "local-agent"))
+	require.Equal(t, "default_local_agent", c.label("discovery.relabel", "default", "local/agent"))
+	require.Equal(t, "_1_job", c.label("prometheus.scrape", "1", "job"))
+	require.Equal(t, "default", c.label("loki.write", ""))
+}
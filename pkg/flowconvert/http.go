@@ -0,0 +1,80 @@
+package flowconvert
+
+import (
+	types "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/prometheus/common/config"
+)
+
+// httpClientConfig converts in to the Flow HTTP client settings. what
+// describes the owner of the settings in diagnostics.
+func (c *converter) httpClientConfig(what string, in config.HTTPClientConfig) *types.HTTPClientConfig {
+	res := &types.HTTPClientConfig{
+		BearerToken:     c.secret(in.BearerToken),
+		BearerTokenFile: in.BearerTokenFile,
+		ProxyURL:        types.URL{URL: in.ProxyURL.URL},
+		TLSConfig:       c.tlsConfig(what, in.TLSConfig),
+		FollowRedirects: in.FollowRedirects,
+		EnableHTTP2:     in.EnableHTTP2,
+	}
+
+	if in.BasicAuth != nil {
+		res.BasicAuth = &types.BasicAuth{
+			Username:     in.BasicAuth.Username,
+			Password:     c.secret(in.BasicAuth.Password),
+			PasswordFile: in.BasicAuth.PasswordFile,
+		}
+	}
+	if in.Authorization != nil {
+		res.Authorization = &types.Authorization{
+			Type:            in.Authorization.Type,
+			Credentials:     c.secret(in.Authorization.Credentials),
+			CredentialsFile: in.Authorization.CredentialsFile,
+		}
+	}
+	if in.OAuth2 != nil {
+		tls := c.tlsConfig(what, in.OAuth2.TLSConfig)
+		res.OAuth2 = &types.OAuth2Config{
+			ClientID:         in.OAuth2.ClientID,
+			ClientSecret:     c.secret(in.OAuth2.ClientSecret),
+			ClientSecretFile: in.OAuth2.ClientSecretFile,
+			Scopes:           in.OAuth2.Scopes,
+			TokenURL:         in.OAuth2.TokenURL,
+			EndpointParams:   in.OAuth2.EndpointParams,
+			ProxyURL:         types.URL{URL: in.OAuth2.ProxyURL.URL},
+		}
+		if tls != (types.TLSConfig{}) {
+			res.OAuth2.TLSConfig = &tls
+		}
+	}
+
+	if len(in.ProxyConnectHeader) > 0 {
+		c.diags.add(SeverityError, "%s: proxy_connect_header was not converted because it is not supported in Flow", what)
+	}
+	return res
+}
+
+func (c *converter) tlsConfig(what string, in config.TLSConfig) types.TLSConfig {
+	if in.MaxVersion != 0 {
+		c.diags.add(SeverityWarn, "%s: tls_config max_version was dropped because it is not supported in Flow", what)
+	}
+	return types.TLSConfig{
+		CAFile:             in.CAFile,
+		CertFile:           in.CertFile,
+		KeyFile:            in.KeyFile,
+		ServerName:         in.ServerName,
+		InsecureSkipVerify: in.InsecureSkipVerify,
+		MinVersion:         types.TLSVersion(in.MinVersion),
+	}
+}
+
+// secret converts s to a River secret. Secrets are never written in the
+// converted file, so a diagnostic is reported the first time one is
+// encountered.
+func (c *converter) secret(s config.Secret) rivertypes.Secret {
+	if s != "" && !c.redacted {
+		c.redacted = true
+		c.diags.add(SeverityWarn, "secrets were redacted and are written as (secret); replace them with their values, for example by reading them with local.file")
+	}
+	return rivertypes.Secret(s)
+}
@@ -0,0 +1,42 @@
+package flowconvert
+
+import "sort"
+
+// integrationComponents maps the names of integrations to the Flow
+// components embedding the same exporter.
+var integrationComponents = map[string]string{
+	"apache_http":        "prometheus.exporter.apache",
+	"blackbox":           "prometheus.exporter.blackbox",
+	"consul_exporter":    "prometheus.exporter.consul",
+	"gcp_exporter":       "prometheus.exporter.gcp",
+	"github_exporter":    "prometheus.exporter.github",
+	"memcached_exporter": "prometheus.exporter.memcached",
+	"mysqld_exporter":    "prometheus.exporter.mysql",
+	"node_exporter":      "prometheus.exporter.unix",
+	"postgres_exporter":  "prometheus.exporter.postgres",
+	"process_exporter":   "prometheus.exporter.process",
+	"redis_exporter":     "prometheus.exporter.redis",
+	"snmp":               "prometheus.exporter.snmp",
+	"statsd_exporter":    "prometheus.exporter.statsd",
+}
+
+// convertIntegrations reports the enabled integrations, which aren't
+// converted.
+func (c *converter) convertIntegrations(names []string) {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch component, ok := integrationComponents[name]; {
+		case name == "agent":
+			c.diags.add(SeverityWarn, "integration %q was not converted; Flow exposes its own metrics at /metrics, which can be collected with prometheus.scrape", name)
+		case ok:
+			c.diags.add(SeverityError, "integration %q was not converted; use a %s component with a prometheus.scrape component instead", name, component)
+		default:
+			c.diags.add(SeverityError, "integration %q was not converted because Flow has no equivalent component", name)
+		}
+	}
+	if len(names) > 0 {
+		c.diags.add(SeverityInfo, "metrics collected by Flow exporter components have an instance label set to the component ID rather than the agent hostname")
+	}
+}
@@ -0,0 +1,196 @@
+package flowconvert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component/discovery/file"
+	"github.com/grafana/agent/component/loki/write"
+	"github.com/grafana/agent/pkg/logs"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	"github.com/grafana/loki/clients/pkg/promtail/client"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultFileSyncPeriod is the default sync_period of target_config in
+// static mode, which is also the default of discovery.file.
+const defaultFileSyncPeriod = 10 * time.Second
+
+// convertedScrapeKeys are the settings of a logs scrape config which are
+// converted.
+var convertedScrapeKeys = map[string]struct{}{
+	"job_name":        {},
+	"static_configs":  {},
+	"relabel_configs": {},
+}
+
+func (c *converter) convertLogs(cfg *logs.Config) {
+	for _, ic := range cfg.Configs {
+		c.convertLogsInstance(cfg, ic)
+	}
+}
+
+func (c *converter) convertLogsInstance(cfg *logs.Config, ic *logs.InstanceConfig) {
+	what := fmt.Sprintf("logs instance %q", ic.Name)
+
+	clients := ic.ClientConfigs
+	if len(clients) == 0 {
+		clients = cfg.Global.ClientConfigs
+	}
+	receivers := c.convertLokiClients(what, ic.Name, clients, ic.LimitsConfig.MaxStreams)
+
+	if ic.LimitsConfig.ReadlineRateEnabled {
+		c.diags.add(SeverityWarn, "%s: readline_rate_enabled was dropped because rate limiting is not supported in Flow", what)
+	}
+	if ic.TargetConfig.Stdin {
+		c.diags.add(SeverityError, "%s: reading logs from stdin was not converted", what)
+	}
+	if len(ic.ScrapeConfig) > 0 {
+		c.diags.add(SeverityInfo, "%s: the positions file %q is not reused; loki.source.file components keep their own positions file, so files are read again from the start", what, ic.PositionsConfig.PositionsFile)
+	}
+
+	for _, sc := range ic.ScrapeConfig {
+		c.convertLogsScrapeConfig(ic, sc, receivers)
+	}
+}
+
+// convertLokiClients converts clients into loki.write components and returns
+// their receivers. Flow configures external labels per component, so clients
+// with different external labels are converted into separate components.
+func (c *converter) convertLokiClients(what, instanceName string, clients []client.Config, maxStreams int) exprList {
+	if len(clients) == 0 {
+		c.diags.add(SeverityWarn, "%s: no clients are configured, so collected logs are dropped", what)
+		return exprList{}
+	}
+
+	var (
+		groups [][]client.Config
+		keys   = make(map[string]int)
+	)
+	for _, cc := range clients {
+		key := cc.ExternalLabels.String()
+		idx, ok := keys[key]
+		if !ok {
+			idx = len(groups)
+			keys[key] = idx
+			groups = append(groups, nil)
+		}
+		groups[idx] = append(groups[idx], cc)
+	}
+
+	var receivers exprList
+	for i, group := range groups {
+		labelParts := []string{instanceName}
+		if len(groups) > 1 {
+			labelParts = append(labelParts, fmt.Sprint(i))
+		}
+		label := c.label("loki.write", labelParts...)
+		b := builder.NewBlock([]string{"loki", "write"}, label)
+
+		args := write.Arguments{
+			ExternalLabels: labelSetMap(group[0].ExternalLabels.LabelSet),
+			MaxStreams:     maxStreams,
+		}
+		appendArgs(b.Body(), &args, write.Arguments{})
+
+		for _, cc := range group {
+			endpoint := builder.NewBlock([]string{"endpoint"}, "")
+			appendArgs(endpoint.Body(), c.lokiEndpoint(what, cc), write.GetDefaultEndpointOptions())
+			b.Body().AppendBlock(endpoint)
+		}
+
+		c.appendBlock(b)
+		receivers = append(receivers, export("loki.write", label, "receiver"))
+	}
+	return receivers
+}
+
+func (c *converter) lokiEndpoint(what string, cc client.Config) *write.EndpointOptions {
+	if len(cc.Headers) > 0 {
+		c.diags.add(SeverityWarn, "%s: client headers were dropped because they are not supported by loki.write", what)
+	}
+	if cc.DropRateLimitedBatches {
+		c.diags.add(SeverityWarn, "%s: drop_rate_limited_batches was dropped because it is not supported by loki.write", what)
+	}
+
+	res := &write.EndpointOptions{
+		Name:              cc.Name,
+		BatchWait:         cc.BatchWait,
+		BatchSize:         units.Base2Bytes(cc.BatchSize),
+		RemoteTimeout:     cc.Timeout,
+		MinBackoff:        cc.BackoffConfig.MinBackoff,
+		MaxBackoff:        cc.BackoffConfig.MaxBackoff,
+		MaxBackoffRetries: cc.BackoffConfig.MaxRetries,
+		TenantID:          cc.TenantID,
+		HTTPClientConfig:  c.httpClientConfig(what, cc.Client),
+	}
+	if cc.URL.URL != nil {
+		res.URL = cc.URL.URL.Redacted()
+	}
+	return res
+}
+
+func (c *converter) convertLogsScrapeConfig(ic *logs.InstanceConfig, sc scrapeconfig.Config, receivers exprList) {
+	what := fmt.Sprintf("logs instance %q job %q", ic.Name, sc.JobName)
+
+	if unconverted := unconvertedScrapeKeys(sc); len(unconverted) > 0 {
+		c.diags.add(SeverityError, "%s: the %s settings were not converted", what, strings.Join(unconverted, ", "))
+	}
+	if len(sc.PipelineStages) > 0 {
+		c.diags.add(SeverityError, "%s: pipeline_stages were not converted; add a loki.process component between loki.source.file and loki.write to process the log lines", what)
+	}
+
+	targets := staticTargets(sc.ServiceDiscoveryConfig.StaticConfigs)
+	if len(targets) == 0 {
+		return
+	}
+
+	fileLabel := c.label("discovery.file", ic.Name, sc.JobName)
+	args := file.Arguments{SyncPeriod: ic.TargetConfig.SyncPeriod}
+	b := builder.NewBlock([]string{"discovery", "file"}, fileLabel)
+	appendArgs(b.Body(), &args, file.Arguments{SyncPeriod: defaultFileSyncPeriod})
+	b.Body().SetAttributeValue("path_targets", targets)
+	c.appendBlock(b)
+
+	targetsExpr := export("discovery.file", fileLabel, "targets")
+	if len(sc.RelabelConfigs) > 0 {
+		label := c.label("discovery.relabel", ic.Name, sc.JobName)
+		b := builder.NewBlock([]string{"discovery", "relabel"}, label)
+		b.Body().SetAttributeValue("targets", targetsExpr)
+		appendRules(b.Body(), sc.RelabelConfigs)
+		c.appendBlock(b)
+
+		targetsExpr = export("discovery.relabel", label, "output")
+	}
+
+	b = builder.NewBlock([]string{"loki", "source", "file"}, c.label("loki.source.file", ic.Name, sc.JobName))
+	b.Body().SetAttributeValue("targets", targetsExpr)
+	b.Body().SetAttributeValue("forward_to", receivers)
+	c.appendBlock(b)
+}
+
+// unconvertedScrapeKeys returns the sorted names of the settings of sc which
+// are set but not converted.
+func unconvertedScrapeKeys(sc scrapeconfig.Config) []string {
+	bb, err := yaml.Marshal(sc)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(bb, &m); err != nil {
+		return nil
+	}
+
+	var res []string
+	for k := range m {
+		if _, ok := convertedScrapeKeys[k]; !ok && k != "pipeline_stages" {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,383 @@
+package flowconvert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/units"
+	types "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery/kubernetes"
+	"github.com/grafana/agent/component/prometheus/remotewrite"
+	"github.com/grafana/agent/component/prometheus/scrape"
+	"github.com/grafana/agent/pkg/metrics"
+	"github.com/grafana/agent/pkg/metrics/instance"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/discovery"
+	promk8s "github.com/prometheus/prometheus/discovery/kubernetes"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+func (c *converter) convertMetrics(cfg metrics.Config) {
+	if cfg.ServiceConfig.Enabled {
+		c.diags.add(SeverityError, "scraping service mode was not converted; instance configs stored in the KV store must be converted separately")
+	}
+	if len(cfg.Configs) > 0 {
+		c.diags.add(SeverityInfo, "the metrics WALs in %q are not reused; prometheus.remote_write components start with an empty WAL in their data directory", cfg.WALDir)
+	}
+
+	for _, ic := range cfg.Configs {
+		c.convertMetricsInstance(cfg.Global, ic)
+	}
+}
+
+func (c *converter) convertMetricsInstance(global instance.GlobalConfig, ic instance.Config) {
+	what := fmt.Sprintf("metrics instance %q", ic.Name)
+
+	if ic.HostFilter {
+		c.diags.add(SeverityError, "%s: host_filter was not converted; use a discovery.relabel rule which keeps the targets of the local node instead", what)
+	}
+	if ic.RemoteFlushDeadline != instance.DefaultConfig.RemoteFlushDeadline {
+		c.diags.add(SeverityWarn, "%s: remote_flush_deadline was dropped because it is not supported in Flow", what)
+	}
+	if ic.WriteStaleOnShutdown {
+		c.diags.add(SeverityWarn, "%s: write_stale_on_shutdown was dropped because it is not supported in Flow", what)
+	}
+
+	receivers := c.convertRemoteWrite(what, global, ic)
+	for _, sc := range ic.ScrapeConfigs {
+		c.convertScrapeConfig(ic.Name, sc, receivers)
+	}
+}
+
+// convertRemoteWrite converts the remote_write endpoints of ic and returns
+// the receivers scraped metrics must be forwarded to.
+func (c *converter) convertRemoteWrite(what string, global instance.GlobalConfig, ic instance.Config) exprList {
+	endpoints := ic.RemoteWrite
+	if len(endpoints) == 0 {
+		endpoints = global.RemoteWrite
+	}
+	if len(endpoints) == 0 {
+		c.diags.add(SeverityWarn, "%s: no remote_write endpoints are configured, so scraped metrics are dropped", what)
+		return exprList{}
+	}
+
+	wal := remotewrite.WALOptions{
+		TruncateFrequency: ic.WALTruncateFrequency,
+		MinKeepaliveTime:  ic.MinWALTime,
+		MaxKeepaliveTime:  ic.MaxWALTime,
+	}
+
+	// Flow doesn't support write_relabel_configs, so endpoints which set them
+	// get their own prometheus.remote_write component, fed by a
+	// prometheus.relabel component.
+	var (
+		shared    []*config.RemoteWriteConfig
+		receivers exprList
+	)
+	for i, rw := range endpoints {
+		if len(rw.WriteRelabelConfigs) == 0 {
+			shared = append(shared, rw)
+			continue
+		}
+
+		name := rw.Name
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		rwLabel := c.appendRemoteWrite(what, global, wal, []*config.RemoteWriteConfig{rw}, ic.Name, name)
+
+		relabelLabel := c.label("prometheus.relabel", ic.Name, name)
+		b := builder.NewBlock([]string{"prometheus", "relabel"}, relabelLabel)
+		b.Body().SetAttributeValue("forward_to", exprList{export("prometheus.remote_write", rwLabel, "receiver")})
+		appendRules(b.Body(), rw.WriteRelabelConfigs)
+		c.appendBlock(b)
+
+		receivers = append(receivers, export("prometheus.relabel", relabelLabel, "receiver"))
+		c.diags.add(SeverityInfo, "%s: remote_write endpoint %q uses write_relabel_configs, so it was converted into a separate prometheus.remote_write component with its own WAL", what, name)
+	}
+	if len(shared) > 0 {
+		rwLabel := c.appendRemoteWrite(what, global, wal, shared, ic.Name)
+		receivers = append(exprList{export("prometheus.remote_write", rwLabel, "receiver")}, receivers...)
+	}
+	return receivers
+}
+
+func (c *converter) appendRemoteWrite(what string, global instance.GlobalConfig, wal remotewrite.WALOptions, endpoints []*config.RemoteWriteConfig, labelParts ...string) string {
+	label := c.label("prometheus.remote_write", labelParts...)
+	b := builder.NewBlock([]string{"prometheus", "remote_write"}, label)
+
+	args := remotewrite.Arguments{
+		ExternalLabels: global.Prometheus.ExternalLabels.Map(),
+		WALOptions:     remotewrite.DefaultWALOptions,
+	}
+	appendArgs(b.Body(), &args, remotewrite.DefaultArguments)
+
+	for _, rw := range endpoints {
+		endpoint := builder.NewBlock([]string{"endpoint"}, "")
+		appendArgs(endpoint.Body(), c.remoteWriteEndpoint(what, rw), remotewrite.GetDefaultEndpointOptions())
+		b.Body().AppendBlock(endpoint)
+	}
+
+	if wal != remotewrite.DefaultWALOptions {
+		walBlock := builder.NewBlock([]string{"wal"}, "")
+		appendArgs(walBlock.Body(), &wal, remotewrite.DefaultWALOptions)
+		b.Body().AppendBlock(walBlock)
+	}
+
+	c.appendBlock(b)
+	return label
+}
+
+func (c *converter) remoteWriteEndpoint(what string, rw *config.RemoteWriteConfig) *remotewrite.EndpointOptions {
+	res := &remotewrite.EndpointOptions{
+		Name:                 rw.Name,
+		RemoteTimeout:        time.Duration(rw.RemoteTimeout),
+		Headers:              rw.Headers,
+		SendExemplars:        rw.SendExemplars,
+		SendNativeHistograms: rw.SendNativeHistograms,
+		HTTPClientConfig:     c.httpClientConfig(what, rw.HTTPClientConfig),
+	}
+	if rw.URL != nil {
+		res.URL = rw.URL.Redacted()
+	}
+
+	queue := remotewrite.QueueOptions{
+		Capacity:          rw.QueueConfig.Capacity,
+		MaxShards:         rw.QueueConfig.MaxShards,
+		MinShards:         rw.QueueConfig.MinShards,
+		MaxSamplesPerSend: rw.QueueConfig.MaxSamplesPerSend,
+		BatchSendDeadline: time.Duration(rw.QueueConfig.BatchSendDeadline),
+		MinBackoff:        time.Duration(rw.QueueConfig.MinBackoff),
+		MaxBackoff:        time.Duration(rw.QueueConfig.MaxBackoff),
+		RetryOnHTTP429:    rw.QueueConfig.RetryOnRateLimit,
+	}
+	if queue != remotewrite.DefaultQueueOptions {
+		res.QueueOptions = &queue
+	}
+
+	metadata := remotewrite.MetadataOptions{
+		Send:              rw.MetadataConfig.Send,
+		SendInterval:      time.Duration(rw.MetadataConfig.SendInterval),
+		MaxSamplesPerSend: rw.MetadataConfig.MaxSamplesPerSend,
+	}
+	if metadata != remotewrite.DefaultMetadataOptions {
+		res.MetadataOptions = &metadata
+	}
+
+	if rw.SigV4Config != nil {
+		res.SigV4 = &remotewrite.SigV4Config{
+			Region:    rw.SigV4Config.Region,
+			AccessKey: rw.SigV4Config.AccessKey,
+			SecretKey: c.secret(rw.SigV4Config.SecretKey),
+			Profile:   rw.SigV4Config.Profile,
+			RoleARN:   rw.SigV4Config.RoleARN,
+		}
+	}
+	return res
+}
+
+func (c *converter) convertScrapeConfig(instanceName string, sc *config.ScrapeConfig, receivers exprList) {
+	what := fmt.Sprintf("metrics instance %q job %q", instanceName, sc.JobName)
+
+	var (
+		static  []map[string]string
+		sources exprList
+	)
+	for _, sd := range sc.ServiceDiscoveryConfigs {
+		switch sd := sd.(type) {
+		case discovery.StaticConfig:
+			static = append(static, staticTargets(sd)...)
+		case *promk8s.SDConfig:
+			sources = append(sources, c.convertKubernetesSD(what, instanceName, sc.JobName, sd))
+		default:
+			c.diags.add(SeverityError, "%s: %s service discovery was not converted", what, sd.Name())
+		}
+	}
+	targets := targetsTokens(static, sources)
+
+	if len(sc.RelabelConfigs) > 0 {
+		label := c.label("discovery.relabel", instanceName, sc.JobName)
+		b := builder.NewBlock([]string{"discovery", "relabel"}, label)
+		b.Body().SetAttributeTokens("targets", targets)
+		appendRules(b.Body(), sc.RelabelConfigs)
+		c.appendBlock(b)
+
+		targets = export("discovery.relabel", label, "output").RiverTokenize()
+	}
+
+	forwardTo := receivers
+	if len(sc.MetricRelabelConfigs) > 0 {
+		label := c.label("prometheus.relabel", instanceName, sc.JobName)
+		b := builder.NewBlock([]string{"prometheus", "relabel"}, label)
+		b.Body().SetAttributeValue("forward_to", receivers)
+		appendRules(b.Body(), sc.MetricRelabelConfigs)
+		c.appendBlock(b)
+
+		forwardTo = exprList{export("prometheus.relabel", label, "receiver")}
+	}
+
+	args := scrape.Arguments{
+		JobName:               sc.JobName,
+		HonorLabels:           sc.HonorLabels,
+		HonorTimestamps:       sc.HonorTimestamps,
+		Params:                sc.Params,
+		ScrapeInterval:        time.Duration(sc.ScrapeInterval),
+		ScrapeTimeout:         time.Duration(sc.ScrapeTimeout),
+		MetricsPath:           sc.MetricsPath,
+		Scheme:                sc.Scheme,
+		BodySizeLimit:         units.Base2Bytes(sc.BodySizeLimit),
+		SampleLimit:           sc.SampleLimit,
+		TargetLimit:           sc.TargetLimit,
+		LabelLimit:            sc.LabelLimit,
+		LabelNameLengthLimit:  sc.LabelNameLengthLimit,
+		LabelValueLengthLimit: sc.LabelValueLengthLimit,
+		HTTPClientConfig:      *c.httpClientConfig(what, sc.HTTPClientConfig),
+		ScrapeFailureLogLimit: scrape.DefaultArguments.ScrapeFailureLogLimit,
+	}
+
+	b := builder.NewBlock([]string{"prometheus", "scrape"}, c.label("prometheus.scrape", instanceName, sc.JobName))
+	appendArgs(b.Body(), &args, scrape.DefaultArguments)
+	b.Body().SetAttributeTokens("targets", targets)
+	b.Body().SetAttributeValue("forward_to", forwardTo)
+	c.appendBlock(b)
+}
+
+func (c *converter) convertKubernetesSD(what, instanceName, jobName string, sd *promk8s.SDConfig) expr {
+	if sd.AttachMetadata.Node {
+		c.diags.add(SeverityWarn, "%s: kubernetes_sd_configs attach_metadata was dropped because it is not supported in Flow", what)
+	}
+
+	args := kubernetes.Arguments{
+		APIServer:        types.URL{URL: sd.APIServer.URL},
+		Role:             string(sd.Role),
+		KubeConfig:       sd.KubeConfig,
+		HTTPClientConfig: *c.httpClientConfig(what, sd.HTTPClientConfig),
+		NamespaceDiscovery: kubernetes.NamespaceDiscovery{
+			IncludeOwnNamespace: sd.NamespaceDiscovery.IncludeOwnNamespace,
+			Names:               sd.NamespaceDiscovery.Names,
+		},
+	}
+	for _, s := range sd.Selectors {
+		args.Selectors = append(args.Selectors, kubernetes.SelectorConfig{
+			Role:  string(s.Role),
+			Label: s.Label,
+			Field: s.Field,
+		})
+	}
+
+	label := c.label("discovery.kubernetes", instanceName, jobName)
+	b := builder.NewBlock([]string{"discovery", "kubernetes"}, label)
+	appendArgs(b.Body(), &args, kubernetes.DefaultConfig)
+	c.appendBlock(b)
+	return export("discovery.kubernetes", label, "targets")
+}
+
+// staticTargets flattens the groups of a static config into a list of
+// targets. Labels of the target take precedence over labels of the group.
+func staticTargets(groups discovery.StaticConfig) []map[string]string {
+	var res []map[string]string
+	for _, g := range groups {
+		for _, t := range g.Targets {
+			target := make(map[string]string, len(g.Labels)+len(t))
+			for k, v := range g.Labels {
+				target[string(k)] = string(v)
+			}
+			for k, v := range t {
+				target[string(k)] = string(v)
+			}
+			res = append(res, target)
+		}
+	}
+	return res
+}
+
+// targetsTokens returns the tokens of an expression combining static targets
+// with the targets exported by sources.
+func targetsTokens(static []map[string]string, sources exprList) []builder.Token {
+	var staticToks []builder.Token
+	if len(static) > 0 || len(sources) == 0 {
+		if static == nil {
+			static = []map[string]string{}
+		}
+		e := builder.NewExpr()
+		e.SetValue(static)
+		staticToks = e.Tokens()
+	}
+
+	switch {
+	case len(sources) == 0:
+		return staticToks
+	case len(sources) == 1 && staticToks == nil:
+		return sources[0].RiverTokenize()
+	}
+
+	toks := []builder.Token{{Tok: token.IDENT, Lit: "concat"}, {Tok: token.LPAREN}}
+	for i, s := range sources {
+		if i > 0 {
+			toks = append(toks, builder.Token{Tok: token.COMMA}, builder.Token{Tok: token.LITERAL, Lit: " "})
+		}
+		toks = append(toks, s.RiverTokenize()...)
+	}
+	if staticToks != nil {
+		toks = append(toks, builder.Token{Tok: token.COMMA}, builder.Token{Tok: token.LITERAL, Lit: " "})
+		toks = append(toks, staticToks...)
+	}
+	return append(toks, builder.Token{Tok: token.RPAREN})
+}
+
+// relabelRule mirrors the rule block of the relabel components. The regular
+// expression is kept as the string it was configured with.
+type relabelRule struct {
+	SourceLabels []string `river:"source_labels,attr,optional"`
+	Separator    string   `river:"separator,attr,optional"`
+	Regex        string   `river:"regex,attr,optional"`
+	Modulus      uint64   `river:"modulus,attr,optional"`
+	TargetLabel  string   `river:"target_label,attr,optional"`
+	Replacement  string   `river:"replacement,attr,optional"`
+	Action       string   `river:"action,attr,optional"`
+}
+
+var defaultRelabelRule = relabelRule{
+	Separator:   relabel.DefaultRelabelConfig.Separator,
+	Regex:       relabel.DefaultRelabelConfig.Regex.String(),
+	Replacement: relabel.DefaultRelabelConfig.Replacement,
+	Action:      string(relabel.DefaultRelabelConfig.Action),
+}
+
+// appendRules appends a rule block to body for each of rules.
+func appendRules(body *builder.Body, rules []*relabel.Config) {
+	for _, r := range rules {
+		rule := relabelRule{
+			Separator:   r.Separator,
+			Modulus:     r.Modulus,
+			TargetLabel: r.TargetLabel,
+			Replacement: r.Replacement,
+			Action:      string(r.Action),
+		}
+		if r.Regex.Regexp != nil {
+			rule.Regex = r.Regex.String()
+		}
+		for _, l := range r.SourceLabels {
+			rule.SourceLabels = append(rule.SourceLabels, string(l))
+		}
+
+		b := builder.NewBlock([]string{"rule"}, "")
+		appendArgs(b.Body(), &rule, defaultRelabelRule)
+		body.AppendBlock(b)
+	}
+}
+
+// labelSetMap converts ls to a map.
+func labelSetMap(ls model.LabelSet) map[string]string {
+	if len(ls) == 0 {
+		return nil
+	}
+	res := make(map[string]string, len(ls))
+	for k, v := range ls {
+		res[string(k)] = string(v)
+	}
+	return res
+}
@@ -0,0 +1,160 @@
+package flowconvert
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/grafana/agent/pkg/river/token/builder"
+)
+
+// expr is a raw River expression, such as a reference to the export of
+// another component.
+type expr string
+
+var _ builder.Tokenizer = expr("")
+
+// RiverTokenize implements builder.Tokenizer.
+func (e expr) RiverTokenize() []builder.Token {
+	return []builder.Token{{Tok: token.LITERAL, Lit: string(e)}}
+}
+
+// exprList is a list of raw River expressions.
+type exprList []expr
+
+var _ builder.Tokenizer = exprList(nil)
+
+// RiverTokenize implements builder.Tokenizer.
+func (l exprList) RiverTokenize() []builder.Token {
+	toks := []builder.Token{{Tok: token.LBRACK}}
+	for i, e := range l {
+		if i > 0 {
+			toks = append(toks, builder.Token{Tok: token.COMMA}, builder.Token{Tok: token.LITERAL, Lit: " "})
+		}
+		toks = append(toks, e.RiverTokenize()...)
+	}
+	return append(toks, builder.Token{Tok: token.RBRACK})
+}
+
+// export returns an expression referencing the export of a component.
+func export(name, label, field string) expr {
+	return expr(fmt.Sprintf("%s.%s.%s", name, label, field))
+}
+
+// label returns a unique label for a component with the given name, built
+// from parts.
+func (c *converter) label(name string, parts ...string) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('_')
+		}
+		for _, r := range part {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				sb.WriteRune(r)
+			default:
+				sb.WriteByte('_')
+			}
+		}
+	}
+
+	base := sb.String()
+	if base == "" {
+		base = "default"
+	} else if base[0] >= '0' && base[0] <= '9' {
+		base = "_" + base
+	}
+
+	used := c.labels[name]
+	if used == nil {
+		used = make(map[string]struct{})
+		c.labels[name] = used
+	}
+	label := base
+	for i := 2; ; i++ {
+		if _, ok := used[label]; !ok {
+			break
+		}
+		label = fmt.Sprintf("%s_%d", base, i)
+	}
+	used[label] = struct{}{}
+	return label
+}
+
+// appendBlock appends a component block to the converted file.
+func (c *converter) appendBlock(b *builder.Block) {
+	if c.blocks > 0 {
+		c.file.Body().AppendTokens([]builder.Token{{Tok: token.LITERAL, Lit: "\n"}})
+	}
+	c.file.Body().AppendBlock(b)
+	c.blocks++
+}
+
+// appendArgs encodes args, a pointer to a struct with River tags, into body.
+// Optional attributes and blocks which are equal to the same field of
+// defaults are omitted. Attributes which are set to their zero value while
+// their default isn't are always written, so that the default doesn't take
+// over.
+//
+// args is modified by appendArgs.
+func appendArgs(body *builder.Body, args, defaults interface{}) {
+	forced := clearDefaults(reflect.ValueOf(args).Elem(), reflect.ValueOf(defaults))
+	body.AppendFrom(args)
+	for _, attr := range forced {
+		body.SetAttributeValue(attr.name, attr.value)
+	}
+}
+
+type forcedAttr struct {
+	name  string
+	value interface{}
+}
+
+func clearDefaults(rv, dv reflect.Value) []forcedAttr {
+	for dv.Kind() == reflect.Pointer {
+		if dv.IsNil() {
+			dv = reflect.Zero(dv.Type().Elem())
+			break
+		}
+		dv = dv.Elem()
+	}
+
+	var forced []forcedAttr
+	for i := 0; i < rv.NumField(); i++ {
+		name, flags, ok := strings.Cut(rv.Type().Field(i).Tag.Get("river"), ",")
+		if !ok {
+			continue
+		}
+		field, def := rv.Field(i), dv.Field(i)
+
+		switch {
+		case flags == "squash":
+			if field.Kind() == reflect.Pointer {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			forced = append(forced, clearDefaults(field, def)...)
+
+		case flags == "block,optional":
+			if reflect.DeepEqual(field.Interface(), def.Interface()) {
+				field.Set(reflect.Zero(field.Type()))
+			}
+
+		case flags == "attr,optional":
+			switch {
+			case reflect.DeepEqual(field.Interface(), def.Interface()):
+				field.Set(reflect.Zero(field.Type()))
+			case field.IsZero() && !def.IsZero():
+				forced = append(forced, forcedAttr{name: name, value: field.Interface()})
+			}
+		}
+	}
+	return forced
+}
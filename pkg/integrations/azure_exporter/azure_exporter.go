@@ -30,7 +30,6 @@ func (e Exporter) MetricsHandler() (http.Handler, error) {
 	}
 
 	h := http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-		reg := prometheus.NewRegistry()
 		ctx := context.Background()
 
 		params := req.URL.Query()
@@ -51,27 +50,45 @@ func (e Exporter) MetricsHandler() (http.Handler, error) {
 			"metrics":                     strings.Join(mergedConfig.Metrics, ","),
 		})
 
-		settings, err := mergedConfig.ToScrapeSettings()
-		if err != nil {
-			e.logger.Error(fmt.Errorf("unexpected error mapping config to scrape settings, %v", err))
-			http.Error(resp, "unexpected scrape error", http.StatusInternalServerError)
-			return
+		configs := []Config{mergedConfig}
+		if mergedConfig.includesAllDimensions() {
+			dimensions, err := fetchMetricDimensions(ctx, client, mergedConfig)
+			if err != nil {
+				e.logger.Error(fmt.Errorf("failed to discover metric dimensions, %v", err))
+				http.Error(resp, "Failed to discover metric dimensions", http.StatusInternalServerError)
+				return
+			}
+			configs = mergedConfig.SplitByDimensions(dimensions)
 		}
 
-		prober := metrics.NewMetricProber(ctx, logEntry, nil, settings, e.ConcurrencyConfig)
-		prober.SetAzureClient(client)
-		prober.SetPrometheusRegistry(reg)
-
-		err = prober.ServiceDiscovery.FindResourceGraph(ctx, settings.Subscriptions, settings.ResourceType, settings.Filter)
-		if err != nil {
-			e.logger.Error(fmt.Errorf("service discovery failed, %v", err))
-			http.Error(resp, "Failed to discovery azure resources", http.StatusInternalServerError)
-			return
+		// Metrics with different dimensions are scraped separately, each into
+		// their own registry.
+		var gatherers prometheus.Gatherers
+		for _, cfg := range configs {
+			settings, err := cfg.ToScrapeSettings()
+			if err != nil {
+				e.logger.Error(fmt.Errorf("unexpected error mapping config to scrape settings, %v", err))
+				http.Error(resp, "unexpected scrape error", http.StatusInternalServerError)
+				return
+			}
+
+			reg := prometheus.NewRegistry()
+			prober := metrics.NewMetricProber(ctx, logEntry, nil, settings, e.ConcurrencyConfig)
+			prober.SetAzureClient(client)
+			prober.SetPrometheusRegistry(reg)
+
+			err = prober.ServiceDiscovery.FindResourceGraph(ctx, settings.Subscriptions, settings.ResourceType, settings.Filter)
+			if err != nil {
+				e.logger.Error(fmt.Errorf("service discovery failed, %v", err))
+				http.Error(resp, "Failed to discovery azure resources", http.StatusInternalServerError)
+				return
+			}
+
+			prober.Run()
+			gatherers = append(gatherers, reg)
 		}
 
-		prober.Run()
-
-		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(resp, req)
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(resp, req)
 	})
 	return h, nil
 }
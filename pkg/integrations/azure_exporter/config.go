@@ -135,6 +135,13 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, dimension := range c.IncludedDimensions {
+		if dimension == allDimensions && len(c.IncludedDimensions) > 1 {
+			configErrors = append(configErrors, fmt.Sprintf("included_dimensions cannot contain other dimensions when it contains %q", allDimensions))
+			break
+		}
+	}
+
 	if _, err := cloudconfig.NewCloudConfig(c.AzureCloudEnvironment); err != nil {
 		configErrors = append(configErrors, fmt.Errorf("failed to create an azure cloud configuration from azure cloud environment %s, %v", c.AzureCloudEnvironment, err).Error())
 	}
@@ -175,6 +182,9 @@ func (c *Config) ToScrapeSettings() (*metrics.RequestMetricSettings, error) {
 
 	// Dimensions can only be retrieved via an obscure manner of including a "metric filter" on the query
 	// This isn't documented in the Azure API only the exporter: https://github.com/webdevops/azure-metrics-exporter#virtualnetworkgateway-connections-dimension-support
+	if c.includesAllDimensions() {
+		return nil, fmt.Errorf("included_dimensions must be split by metric dimensions before scraping")
+	}
 	if len(c.IncludedDimensions) > 0 {
 		builder := strings.Builder{}
 		for index, dimension := range c.IncludedDimensions {
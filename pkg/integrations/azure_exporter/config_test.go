@@ -108,6 +108,43 @@ func TestConfig_ToScrapeSettings(t *testing.T) {
 	}
 }
 
+func TestConfig_SplitByDimensions(t *testing.T) {
+	config := azure_exporter.Config{
+		Subscriptions:      []string{"subscriptionA"},
+		ResourceType:       "resourceType",
+		Metrics:            []string{"MetricA", "MetricB", "MetricC", "MetricD"},
+		IncludedDimensions: []string{"*"},
+	}
+
+	_, err := config.ToScrapeSettings()
+	require.Error(t, err, "all dimensions must be resolved before scraping")
+
+	configs := config.SplitByDimensions(map[string][]string{
+		"metrica": {"Port", "ShardId"},
+		"metricb": {"ShardId", "Port"},
+		"metricc": {},
+		// Dimensions of MetricD are unknown.
+	})
+
+	expectedA := config
+	expectedA.Metrics = []string{"MetricA", "MetricB"}
+	expectedA.IncludedDimensions = []string{"Port", "ShardId"}
+
+	expectedC := config
+	expectedC.Metrics = []string{"MetricC", "MetricD"}
+	expectedC.IncludedDimensions = []string{}
+
+	require.Equal(t, []azure_exporter.Config{expectedA, expectedC}, configs)
+
+	settings, err := configs[0].ToScrapeSettings()
+	require.NoError(t, err)
+	require.Equal(t, "Port eq '*' and ShardId eq '*'", settings.MetricFilter)
+
+	settings, err = configs[1].ToScrapeSettings()
+	require.NoError(t, err)
+	require.Empty(t, settings.MetricFilter)
+}
+
 func TestConfig_Validate(t *testing.T) {
 	baseConfig := azure_exporter.Config{
 		Subscriptions:         []string{"subscriptionA"},
@@ -170,6 +207,13 @@ func TestConfig_Validate(t *testing.T) {
 				return config
 			},
 		},
+		{
+			name: "all dimensions with other dimensions",
+			toInvalidConfig: func(config azure_exporter.Config) azure_exporter.Config {
+				config.IncludedDimensions = []string{"*", "dimension1"}
+				return config
+			},
+		},
 		{
 			name: "invalid azure_cloud_environment",
 			toInvalidConfig: func(config azure_exporter.Config) azure_exporter.Config {
@@ -0,0 +1,159 @@
+package azure_exporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/webdevops/go-common/azuresdk/armclient"
+)
+
+// allDimensions can be given as the only value of included_dimensions to
+// include all dimensions of each metric.
+const allDimensions = "*"
+
+// includesAllDimensions returns true if metrics should be split by all of
+// their dimensions.
+func (c *Config) includesAllDimensions() bool {
+	return len(c.IncludedDimensions) == 1 && c.IncludedDimensions[0] == allDimensions
+}
+
+// SplitByDimensions splits c into one config per set of metrics which have
+// the same dimensions, given the dimensions of each metric. The metric filter
+// of a request must only reference dimensions which all of the requested
+// metrics have, so metrics with different dimensions have to be requested
+// separately. Metrics without known dimensions are requested without
+// dimensions.
+func (c Config) SplitByDimensions(dimensions map[string][]string) []Config {
+	var (
+		configs []Config
+		byKey   = make(map[string]int) // Dimension set -> index in configs.
+	)
+	for _, metric := range c.Metrics {
+		dims := append([]string{}, dimensions[strings.ToLower(metric)]...)
+		sort.Strings(dims)
+		key := strings.Join(dims, ",")
+
+		index, ok := byKey[key]
+		if !ok {
+			split := c
+			split.Metrics = nil
+			split.IncludedDimensions = dims
+			configs = append(configs, split)
+
+			index = len(configs) - 1
+			byKey[key] = index
+		}
+		configs[index].Metrics = append(configs[index].Metrics, metric)
+	}
+	return configs
+}
+
+// fetchMetricDimensions returns the dimensions of each metric of c, keyed by
+// lowercase metric name. Dimensions are the same for all resources of a
+// type, so they're read from the metric definitions of a single resource
+// matching c. An empty map is returned if there is no such resource.
+func fetchMetricDimensions(ctx context.Context, client *armclient.ArmClient, c Config) (map[string][]string, error) {
+	resourceID, err := findResource(ctx, client, c)
+	if err != nil || resourceID == "" {
+		return map[string][]string{}, err
+	}
+
+	definitions, err := armmonitor.NewMetricDefinitionsClient(client.GetCred(), client.NewArmClientOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var opts armmonitor.MetricDefinitionsClientListOptions
+	if c.MetricNamespace != "" {
+		opts.Metricnamespace = to.Ptr(c.MetricNamespace)
+	}
+
+	wanted := make(map[string]struct{}, len(c.Metrics))
+	for _, metric := range c.Metrics {
+		wanted[strings.ToLower(metric)] = struct{}{}
+	}
+
+	res := make(map[string][]string, len(c.Metrics))
+	pager := definitions.NewListPager(metricResourceURI(resourceID, c.MetricNamespace), &opts)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing metric definitions of %s: %w", resourceID, err)
+		}
+
+		for _, def := range page.Value {
+			if def == nil || def.Name == nil || def.Name.Value == nil {
+				continue
+			}
+			name := strings.ToLower(*def.Name.Value)
+			if _, ok := wanted[name]; !ok {
+				continue
+			}
+
+			var dims []string
+			for _, dim := range def.Dimensions {
+				if dim != nil && dim.Value != nil {
+					dims = append(dims, *dim.Value)
+				}
+			}
+			res[name] = dims
+		}
+	}
+	return res, nil
+}
+
+// findResource returns the ID of a resource matching the resource type and
+// Resource Graph filter of c, or an empty string if there is none.
+func findResource(ctx context.Context, client *armclient.ArmClient, c Config) (string, error) {
+	graph, err := armresourcegraph.NewClient(client.GetCred(), client.NewArmClientOptions())
+	if err != nil {
+		return "", err
+	}
+
+	// Matches the query used by the exporter to discover resources.
+	filter := c.ResourceGraphQueryFilter
+	if filter != "" {
+		filter = "| " + filter
+	}
+	query := strings.TrimSpace(fmt.Sprintf(
+		`Resources | where type =~ "%s" %s | project id | limit 1`,
+		strings.ReplaceAll(c.ResourceType, "'", "\\'"),
+		filter,
+	))
+
+	format := armresourcegraph.ResultFormatObjectArray
+	result, err := graph.Resources(ctx, armresourcegraph.QueryRequest{
+		Query:         to.Ptr(query),
+		Options:       &armresourcegraph.QueryRequestOptions{ResultFormat: &format},
+		Subscriptions: to.SliceOfPtrs(c.Subscriptions...),
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("finding a resource of type %s: %w", c.ResourceType, err)
+	}
+
+	rows, _ := result.Data.([]interface{})
+	for _, row := range rows {
+		if fields, ok := row.(map[string]interface{}); ok {
+			if id, ok := fields["id"].(string); ok && id != "" {
+				return id, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// metricResourceURI returns the URI of the metrics of resourceID in
+// namespace. Storage accounts have an extra requirement that the URI of their
+// services' metrics includes <service>/default.
+func metricResourceURI(resourceID, namespace string) string {
+	if strings.HasPrefix(strings.ToLower(namespace), "microsoft.storage/storageaccounts/") {
+		parts := strings.Split(namespace, "/")
+		return resourceID + fmt.Sprintf("/%s/default", parts[len(parts)-1])
+	}
+	return resourceID
+}
@@ -0,0 +1,219 @@
+// Package bmc_exporter collects hardware health from baseboard management
+// controllers over Redfish or IPMI, using metric names shared by both
+// protocols.
+//
+// The upstream Redfish and IPMI exporters aren't embedded:
+// github.com/jenningsloy318/redfish_exporter, its client library
+// github.com/stmcginnis/gofish and
+// github.com/prometheus-community/ipmi_exporter aren't available from the
+// module proxy used to build the agent. Redfish is queried directly over HTTP
+// and IPMI through ipmitool. The integration backs the prometheus.exporter.bmc
+// Flow component and isn't registered as a static mode integration.
+package bmc_exporter //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/integrations"
+	integrations_config "github.com/grafana/agent/pkg/integrations/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	config_util "github.com/prometheus/common/config"
+)
+
+// Supported protocols to query BMCs with.
+const (
+	ProtocolRedfish = "redfish"
+	ProtocolIPMI    = "ipmi"
+)
+
+// DefaultConfig holds the default settings for the bmc integration.
+var DefaultConfig = Config{
+	Timeout:      10 * time.Second,
+	IPMIToolPath: "ipmitool",
+}
+
+// Config controls the bmc integration.
+type Config struct {
+	// BMCs holds the BMCs which can be scraped, selected by the target query
+	// parameter of the metrics endpoint.
+	BMCs []BMC
+
+	Timeout      time.Duration
+	IPMIToolPath string
+	TLSConfig    config_util.TLSConfig
+}
+
+// BMC holds the settings used to query a single BMC.
+type BMC struct {
+	Address  string
+	Username string
+	Password config_util.Secret
+	Protocol string
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "bmc"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
+
+// New creates a new bmc integration, which serves the metrics of the BMC
+// given by the target query parameter.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	tlsConfig, err := config_util.NewTLSConfig(&c.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout:   c.Timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+
+	bmcs := make(map[string]BMC, len(c.BMCs))
+	for _, b := range c.BMCs {
+		bmcs[b.Address] = b
+	}
+
+	return &integration{
+		logger:  logger,
+		timeout: c.Timeout,
+		bmcs:    bmcs,
+		redfish: &redfishClient{client: client},
+		ipmi:    &ipmiTool{path: c.IPMIToolPath, run: runCommand},
+	}, nil
+}
+
+// integration serves the metrics of the BMC given by the target query
+// parameter.
+type integration struct {
+	logger  log.Logger
+	timeout time.Duration
+	bmcs    map[string]BMC
+	redfish *redfishClient
+	ipmi    *ipmiTool
+
+	// Concurrent scrapes of the same BMC are serialized, since BMCs are
+	// easily overwhelmed.
+	locks sync.Map // map[string]*sync.Mutex
+}
+
+// MetricsHandler implements integrations.Integration.
+func (i *integration) MetricsHandler() (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := r.URL.Query().Get("target")
+		b, ok := i.bmcs[address]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", address), http.StatusBadRequest)
+			return
+		}
+
+		lock, _ := i.locks.LoadOrStore(address, &sync.Mutex{})
+		lock.(*sync.Mutex).Lock()
+		defer lock.(*sync.Mutex).Unlock()
+
+		ctx, cancel := context.WithTimeout(r.Context(), i.timeout)
+		defer cancel()
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(&bmcCollector{ctx: ctx, logger: i.logger, bmc: b, redfish: i.redfish, ipmi: i.ipmi})
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
+	}), nil
+}
+
+// ScrapeConfigs implements integrations.Integration.
+func (i *integration) ScrapeConfigs() []integrations_config.ScrapeConfig {
+	return nil
+}
+
+// Run implements integrations.Integration.
+func (i *integration) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+var (
+	upDesc = prometheus.NewDesc(
+		"bmc_up",
+		"1 if the BMC could be queried, 0 otherwise.",
+		nil, nil,
+	)
+	healthDesc = prometheus.NewDesc(
+		"bmc_health",
+		"Health of a hardware component; 0 for OK, 1 for warning, 2 for critical.",
+		[]string{"type", "parent", "name"}, nil,
+	)
+	temperatureDesc = prometheus.NewDesc(
+		"bmc_temperature_celsius",
+		"Reading of a temperature sensor.",
+		[]string{"parent", "name"}, nil,
+	)
+	fanSpeedDesc = prometheus.NewDesc(
+		"bmc_fan_speed",
+		"Speed of a fan, in the unit given by the unit label.",
+		[]string{"parent", "name", "unit"}, nil,
+	)
+	powerSupplyInputDesc = prometheus.NewDesc(
+		"bmc_power_supply_input_watts",
+		"Input power of a power supply.",
+		[]string{"parent", "name"}, nil,
+	)
+	sensorDesc = prometheus.NewDesc(
+		"bmc_sensor_value",
+		"Reading of a sensor which isn't a temperature or fan sensor, in the unit given by the unit label.",
+		[]string{"name", "unit"}, nil,
+	)
+)
+
+// Health values of hardware components.
+const (
+	healthOK       = 0
+	healthWarning  = 1
+	healthCritical = 2
+)
+
+// bmcCollector collects the metrics of a single BMC.
+type bmcCollector struct {
+	ctx     context.Context
+	logger  log.Logger
+	bmc     BMC
+	redfish *redfishClient
+	ipmi    *ipmiTool
+}
+
+// Describe implements prometheus.Collector.
+func (c *bmcCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		upDesc, healthDesc, temperatureDesc, fanSpeedDesc, powerSupplyInputDesc, sensorDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *bmcCollector) Collect(ch chan<- prometheus.Metric) {
+	var err error
+	switch c.bmc.Protocol {
+	case ProtocolIPMI:
+		err = c.ipmi.collect(c.ctx, c.bmc, ch)
+	default:
+		err = c.redfish.collect(c.ctx, c.bmc, ch)
+	}
+
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to query BMC", "target", c.bmc.Address, "protocol", c.bmc.Protocol, "err", err)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+}
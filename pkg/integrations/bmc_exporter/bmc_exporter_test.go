@@ -0,0 +1,172 @@
+package bmc_exporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedfish(t *testing.T) {
+	resources := map[string]interface{}{
+		"/redfish/v1/Chassis": map[string]interface{}{
+			"Members": []map[string]string{{"@odata.id": "/redfish/v1/Chassis/1"}},
+		},
+		"/redfish/v1/Chassis/1": map[string]interface{}{
+			"Id":      "1",
+			"Status":  map[string]string{"State": "Enabled", "Health": "Warning"},
+			"Thermal": map[string]string{"@odata.id": "/redfish/v1/Chassis/1/Thermal"},
+			"Power":   map[string]string{"@odata.id": "/redfish/v1/Chassis/1/Power"},
+		},
+		"/redfish/v1/Chassis/1/Thermal": map[string]interface{}{
+			"Temperatures": []map[string]interface{}{
+				{"Name": "CPU1 Temp", "ReadingCelsius": 41, "Status": map[string]string{"State": "Enabled", "Health": "OK"}},
+				{"Name": "CPU2 Temp", "Status": map[string]string{"State": "Absent"}},
+			},
+			"Fans": []map[string]interface{}{
+				{"Name": "Fan1", "Reading": 5400, "ReadingUnits": "RPM", "Status": map[string]string{"State": "Enabled", "Health": "OK"}},
+			},
+		},
+		"/redfish/v1/Chassis/1/Power": map[string]interface{}{
+			"PowerSupplies": []map[string]interface{}{
+				{"Name": "PSU1", "PowerInputWatts": 212, "Status": map[string]string{"State": "Enabled", "Health": "Critical"}},
+			},
+		},
+		"/redfish/v1/Systems": map[string]interface{}{
+			"Members": []map[string]string{{"@odata.id": "/redfish/v1/Systems/1"}},
+		},
+		"/redfish/v1/Systems/1": map[string]interface{}{
+			"Id":      "1",
+			"Storage": map[string]string{"@odata.id": "/redfish/v1/Systems/1/Storage"},
+		},
+		"/redfish/v1/Systems/1/Storage": map[string]interface{}{
+			"Members": []map[string]string{{"@odata.id": "/redfish/v1/Systems/1/Storage/RAID.1"}},
+		},
+		"/redfish/v1/Systems/1/Storage/RAID.1": map[string]interface{}{
+			"Id":                 "RAID.1",
+			"StorageControllers": []map[string]interface{}{{"Name": "PERC H730P", "Status": map[string]string{"Health": "OK"}}},
+			"Drives":             []map[string]string{{"@odata.id": "/redfish/v1/Systems/1/Storage/Drives/0"}},
+		},
+		"/redfish/v1/Systems/1/Storage/Drives/0": map[string]interface{}{
+			"Name":   "Disk 0",
+			"Status": map[string]string{"State": "Enabled", "Health": "OK"},
+		},
+	}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "root" || pass != "calvin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	i, err := New(util.TestLogger(t), &Config{
+		BMCs: []BMC{
+			{Address: srv.URL, Username: "root", Password: "calvin", Protocol: ProtocolRedfish},
+			{Address: srv.URL + "/", Username: "root", Password: "wrong", Protocol: ProtocolRedfish},
+		},
+		Timeout:   5 * time.Second,
+		TLSConfig: config_util.TLSConfig{InsecureSkipVerify: true},
+	})
+	require.NoError(t, err)
+	h, err := i.MetricsHandler()
+	require.NoError(t, err)
+
+	body := scrape(t, h, srv.URL)
+	for _, line := range []string{
+		`bmc_up 1`,
+		`bmc_health{name="1",parent="",type="chassis"} 1`,
+		`bmc_health{name="CPU1 Temp",parent="1",type="temperature"} 0`,
+		`bmc_health{name="PSU1",parent="1",type="power_supply"} 2`,
+		`bmc_health{name="PERC H730P",parent="RAID.1",type="storage_controller"} 0`,
+		`bmc_health{name="Disk 0",parent="RAID.1",type="drive"} 0`,
+		`bmc_temperature_celsius{name="CPU1 Temp",parent="1"} 41`,
+		`bmc_fan_speed{name="Fan1",parent="1",unit="RPM"} 5400`,
+		`bmc_power_supply_input_watts{name="PSU1",parent="1"} 212`,
+	} {
+		require.Contains(t, body, line)
+	}
+	require.NotContains(t, body, "CPU2 Temp")
+
+	// Every target uses its own credentials.
+	require.Contains(t, scrape(t, h, srv.URL+"/"), "bmc_up 0")
+}
+
+func scrape(t *testing.T, h http.Handler, target string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?target="+target, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	bb, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(bb)
+}
+
+func TestIPMI(t *testing.T) {
+	const out = `CPU Temp         | 45.000     | degrees C  | ok    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 95.000
+FAN1             | 1200.000   | RPM        | cr    | 300.000   | 500.000   | 700.000   | na        | na        | na
+12V              | 12.192     | Volts      | nc    | 10.173    | 10.299    | 10.740    | 13.260    | 13.701    | 13.827
+PS1 Status       | 0x1        | discrete   | 0x0100| na        | na        | na        | na        | na        | na
+Vcore            | na         | Volts      | na    | na        | na        | na        | na        | na        | na
+`
+	var (
+		gotArgs []string
+		gotEnv  []string
+	)
+	tool := &ipmiTool{path: "ipmitool", run: func(_ context.Context, _ string, args []string, env []string) ([]byte, error) {
+		gotArgs, gotEnv = args, env
+		return []byte(out), nil
+	}}
+
+	c := &bmcCollector{
+		ctx:    context.Background(),
+		logger: log.NewNopLogger(),
+		bmc:    BMC{Address: "10.0.0.2:623", Username: "admin", Password: "secret", Protocol: ProtocolIPMI},
+		ipmi:   tool,
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP bmc_fan_speed Speed of a fan, in the unit given by the unit label.
+# TYPE bmc_fan_speed gauge
+bmc_fan_speed{name="FAN1",parent="",unit="RPM"} 1200
+# HELP bmc_health Health of a hardware component; 0 for OK, 1 for warning, 2 for critical.
+# TYPE bmc_health gauge
+bmc_health{name="12V",parent="",type="sensor"} 1
+bmc_health{name="CPU Temp",parent="",type="sensor"} 0
+bmc_health{name="FAN1",parent="",type="sensor"} 2
+# HELP bmc_sensor_value Reading of a sensor which isn't a temperature or fan sensor, in the unit given by the unit label.
+# TYPE bmc_sensor_value gauge
+bmc_sensor_value{name="12V",unit="Volts"} 12.192
+# HELP bmc_temperature_celsius Reading of a temperature sensor.
+# TYPE bmc_temperature_celsius gauge
+bmc_temperature_celsius{name="CPU Temp",parent=""} 45
+# HELP bmc_up 1 if the BMC could be queried, 0 otherwise.
+# TYPE bmc_up gauge
+bmc_up 1
+`)))
+	require.Equal(t, []string{"-I", "lanplus", "-H", "10.0.0.2", "-p", "623", "-U", "admin", "-E", "sensor"}, gotArgs)
+	require.Equal(t, []string{"IPMI_PASSWORD=secret"}, gotEnv)
+}
@@ -0,0 +1,101 @@
+package bmc_exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ipmiTool collects hardware health over IPMI by running ipmitool, in the
+// same way the Prometheus IPMI exporter relies on FreeIPMI.
+type ipmiTool struct {
+	path string
+	run  func(ctx context.Context, path string, args []string, env []string) ([]byte, error)
+}
+
+// runCommand runs the program at path, returning its standard output.
+func runCommand(ctx context.Context, path string, args []string, env []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (t *ipmiTool) collect(ctx context.Context, b BMC, ch chan<- prometheus.Metric) error {
+	args := []string{"-I", "lanplus"}
+	if host, port, err := net.SplitHostPort(b.Address); err == nil {
+		args = append(args, "-H", host, "-p", port)
+	} else {
+		args = append(args, "-H", b.Address)
+	}
+	if b.Username != "" {
+		args = append(args, "-U", b.Username)
+	}
+	// The password is passed through the environment with -E so it doesn't
+	// show up in the process list.
+	args = append(args, "-E", "sensor")
+
+	out, err := t.run(ctx, t.path, args, []string{"IPMI_PASSWORD=" + string(b.Password)})
+	if err != nil {
+		return err
+	}
+	return parseIPMISensors(out, ch)
+}
+
+// parseIPMISensors parses the output of "ipmitool sensor". Every line
+// describes a sensor as pipe-separated fields: name, reading, unit, status,
+// and thresholds. Discrete sensors and sensors without a reading are skipped.
+func parseIPMISensors(out []byte, ch chan<- prometheus.Metric) error {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 4 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		name, reading, unit, status := fields[0], fields[1], fields[2], fields[3]
+		if unit == "discrete" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(reading, 64)
+		if err != nil {
+			continue
+		}
+
+		switch status {
+		case "ok":
+			ch <- prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, healthOK, "sensor", "", name)
+		case "nc":
+			ch <- prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, healthWarning, "sensor", "", name)
+		case "cr", "nr":
+			ch <- prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, healthCritical, "sensor", "", name)
+		}
+
+		switch unit {
+		case "degrees C":
+			ch <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, value, "", name)
+		case "RPM":
+			ch <- prometheus.MustNewConstMetric(fanSpeedDesc, prometheus.GaugeValue, value, "", name, unit)
+		default:
+			ch <- prometheus.MustNewConstMetric(sensorDesc, prometheus.GaugeValue, value, name, unit)
+		}
+	}
+	return scanner.Err()
+}
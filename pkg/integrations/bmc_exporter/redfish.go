@@ -0,0 +1,238 @@
+package bmc_exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// redfishClient collects hardware health from the Redfish API of a BMC.
+type redfishClient struct {
+	client *http.Client
+}
+
+// Subsets of the Redfish schemas used by the collector.
+type (
+	redfishLink struct {
+		ID string `json:"@odata.id"`
+	}
+
+	redfishCollection struct {
+		Members []redfishLink `json:"Members"`
+	}
+
+	redfishStatus struct {
+		State  string `json:"State"`
+		Health string `json:"Health"`
+	}
+
+	redfishChassis struct {
+		ID      string        `json:"Id"`
+		Status  redfishStatus `json:"Status"`
+		Thermal *redfishLink  `json:"Thermal"`
+		Power   *redfishLink  `json:"Power"`
+	}
+
+	redfishThermal struct {
+		Temperatures []struct {
+			Name           string        `json:"Name"`
+			ReadingCelsius *float64      `json:"ReadingCelsius"`
+			Status         redfishStatus `json:"Status"`
+		} `json:"Temperatures"`
+		Fans []struct {
+			Name         string        `json:"Name"`
+			FanName      string        `json:"FanName"`
+			Reading      *float64      `json:"Reading"`
+			ReadingUnits string        `json:"ReadingUnits"`
+			Status       redfishStatus `json:"Status"`
+		} `json:"Fans"`
+	}
+
+	redfishPower struct {
+		PowerSupplies []struct {
+			Name            string        `json:"Name"`
+			PowerInputWatts *float64      `json:"PowerInputWatts"`
+			Status          redfishStatus `json:"Status"`
+		} `json:"PowerSupplies"`
+	}
+
+	redfishSystem struct {
+		ID      string       `json:"Id"`
+		Storage *redfishLink `json:"Storage"`
+	}
+
+	redfishStorage struct {
+		ID                 string `json:"Id"`
+		StorageControllers []struct {
+			Name   string        `json:"Name"`
+			Status redfishStatus `json:"Status"`
+		} `json:"StorageControllers"`
+		Drives []redfishLink `json:"Drives"`
+	}
+
+	redfishDrive struct {
+		Name   string        `json:"Name"`
+		Status redfishStatus `json:"Status"`
+	}
+)
+
+func (c *redfishClient) collect(ctx context.Context, b BMC, ch chan<- prometheus.Metric) error {
+	var chassisList redfishCollection
+	if err := c.get(ctx, b, "/redfish/v1/Chassis", &chassisList); err != nil {
+		return err
+	}
+	for _, link := range chassisList.Members {
+		if err := c.collectChassis(ctx, b, link.ID, ch); err != nil {
+			return err
+		}
+	}
+
+	var systems redfishCollection
+	if err := c.get(ctx, b, "/redfish/v1/Systems", &systems); err != nil {
+		return err
+	}
+	for _, link := range systems.Members {
+		if err := c.collectSystem(ctx, b, link.ID, ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *redfishClient) collectChassis(ctx context.Context, b BMC, path string, ch chan<- prometheus.Metric) error {
+	var chassis redfishChassis
+	if err := c.get(ctx, b, path, &chassis); err != nil {
+		return err
+	}
+	emitHealth(ch, "chassis", "", chassis.ID, chassis.Status)
+
+	if chassis.Thermal != nil {
+		var thermal redfishThermal
+		if err := c.get(ctx, b, chassis.Thermal.ID, &thermal); err != nil {
+			return err
+		}
+		for _, t := range thermal.Temperatures {
+			if !emitHealth(ch, "temperature", chassis.ID, t.Name, t.Status) || t.ReadingCelsius == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, *t.ReadingCelsius, chassis.ID, t.Name)
+		}
+		for _, f := range thermal.Fans {
+			name := f.Name
+			if name == "" {
+				// Redfish versions before 2017.1 use FanName.
+				name = f.FanName
+			}
+			if !emitHealth(ch, "fan", chassis.ID, name, f.Status) || f.Reading == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(fanSpeedDesc, prometheus.GaugeValue, *f.Reading, chassis.ID, name, f.ReadingUnits)
+		}
+	}
+
+	if chassis.Power != nil {
+		var power redfishPower
+		if err := c.get(ctx, b, chassis.Power.ID, &power); err != nil {
+			return err
+		}
+		for _, psu := range power.PowerSupplies {
+			if !emitHealth(ch, "power_supply", chassis.ID, psu.Name, psu.Status) || psu.PowerInputWatts == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(powerSupplyInputDesc, prometheus.GaugeValue, *psu.PowerInputWatts, chassis.ID, psu.Name)
+		}
+	}
+	return nil
+}
+
+func (c *redfishClient) collectSystem(ctx context.Context, b BMC, path string, ch chan<- prometheus.Metric) error {
+	var system redfishSystem
+	if err := c.get(ctx, b, path, &system); err != nil {
+		return err
+	}
+	if system.Storage == nil {
+		return nil
+	}
+
+	var storageList redfishCollection
+	if err := c.get(ctx, b, system.Storage.ID, &storageList); err != nil {
+		return err
+	}
+	for _, link := range storageList.Members {
+		var storage redfishStorage
+		if err := c.get(ctx, b, link.ID, &storage); err != nil {
+			return err
+		}
+		for _, ctrl := range storage.StorageControllers {
+			emitHealth(ch, "storage_controller", storage.ID, ctrl.Name, ctrl.Status)
+		}
+		for _, driveLink := range storage.Drives {
+			var drive redfishDrive
+			if err := c.get(ctx, b, driveLink.ID, &drive); err != nil {
+				return err
+			}
+			emitHealth(ch, "drive", storage.ID, drive.Name, drive.Status)
+		}
+	}
+	return nil
+}
+
+// get decodes the Redfish resource at path into v.
+func (c *redfishClient) get(ctx context.Context, b BMC, path string, v interface{}) error {
+	base := b.Address
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if b.Username != "" {
+		req.SetBasicAuth(b.Username, string(b.Password))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("GET %s: unexpected status %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("GET %s: %w", path, err)
+	}
+	return nil
+}
+
+// emitHealth emits the health of a component, reporting whether the
+// component is present. Absent and disabled components are skipped.
+func emitHealth(ch chan<- prometheus.Metric, typ, parent, name string, status redfishStatus) bool {
+	if status.State == "Absent" || status.State == "Disabled" {
+		return false
+	}
+
+	var v float64
+	switch status.Health {
+	case "OK":
+		v = healthOK
+	case "Warning":
+		v = healthWarning
+	case "Critical":
+		v = healthCritical
+	default:
+		// Health isn't known, but readings may still be available.
+		return true
+	}
+	ch <- prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, v, typ, parent, name)
+	return true
+}
@@ -0,0 +1,331 @@
+// Package certificate_exporter probes TLS endpoints, optionally after
+// negotiating STARTTLS, and exposes the expiry, SAN count, and validity of
+// their certificate chains. The metric names follow
+// https://github.com/ribbybibby/ssl_exporter so that its dashboards can be
+// reused.
+//
+// ssl_exporter itself isn't embedded because
+// github.com/ribbybibby/ssl_exporter isn't available from the module proxy
+// used to build the agent. The integration backs the
+// prometheus.exporter.certificate Flow component and isn't registered as a
+// static mode integration.
+package certificate_exporter //nolint:golint
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/integrations"
+	integrations_config "github.com/grafana/agent/pkg/integrations/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	config_util "github.com/prometheus/common/config"
+)
+
+// defaultPort is used for endpoints without a port.
+const defaultPort = "443"
+
+// DefaultConfig holds the default settings for the certificate integration.
+var DefaultConfig = Config{
+	Timeout: 10 * time.Second,
+}
+
+// Config controls the certificate integration.
+type Config struct {
+	// Endpoints holds the endpoints which can be probed, selected by the
+	// target query parameter of the metrics endpoint.
+	Endpoints []Endpoint
+
+	Timeout   time.Duration
+	TLSConfig config_util.TLSConfig
+}
+
+// Endpoint holds the settings used to probe a single endpoint.
+type Endpoint struct {
+	// Address is the host and port of the endpoint. The port defaults to 443.
+	Address string
+
+	// ServerName is the name used to verify the certificate chain. It
+	// defaults to the server name of the TLS config, or the host of Address.
+	ServerName string
+
+	// StartTLS is the protocol used to upgrade the connection to TLS. TLS is
+	// used from the start when empty.
+	StartTLS string
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "certificate"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
+
+// New creates a new certificate integration, which serves the metrics of the
+// endpoint given by the target query parameter. Endpoints are probed when
+// they're scraped.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	tlsConfig, err := config_util.NewTLSConfig(&c.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &integration{
+		logger:    logger,
+		timeout:   c.Timeout,
+		endpoints: c.endpoints(),
+		tlsConfig: tlsConfig,
+	}, nil
+}
+
+// endpoint holds the resolved settings used to probe a single endpoint.
+type endpoint struct {
+	address    string
+	serverName string
+	startTLS   string
+}
+
+// endpoints returns the settings of every endpoint, keyed by address.
+func (c *Config) endpoints() map[string]endpoint {
+	res := make(map[string]endpoint, len(c.Endpoints))
+	for _, ep := range c.Endpoints {
+		e := endpoint{
+			address:    ep.Address,
+			serverName: ep.ServerName,
+			startTLS:   ep.StartTLS,
+		}
+
+		host, _, err := net.SplitHostPort(ep.Address)
+		if err != nil {
+			host = ep.Address
+			e.address = net.JoinHostPort(ep.Address, defaultPort)
+		}
+		if e.serverName == "" {
+			e.serverName = c.TLSConfig.ServerName
+		}
+		if e.serverName == "" {
+			e.serverName = host
+		}
+		res[ep.Address] = e
+	}
+	return res
+}
+
+// integration serves the metrics of the endpoint given by the target query
+// parameter. Endpoints are probed when they're scraped.
+type integration struct {
+	logger    log.Logger
+	timeout   time.Duration
+	endpoints map[string]endpoint
+	tlsConfig *tls.Config
+}
+
+// MetricsHandler implements integrations.Integration.
+func (i *integration) MetricsHandler() (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := r.URL.Query().Get("target")
+		e, ok := i.endpoints[address]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", address), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), i.timeout)
+		defer cancel()
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(&certificateCollector{ctx: ctx, logger: i.logger, endpoint: e, tlsConfig: i.tlsConfig})
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
+	}), nil
+}
+
+// ScrapeConfigs implements integrations.Integration.
+func (i *integration) ScrapeConfigs() []integrations_config.ScrapeConfig {
+	return nil
+}
+
+// Run implements integrations.Integration.
+func (i *integration) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+var (
+	certLabels = []string{"position", "subject_cn", "issuer_cn", "serial"}
+
+	probeSuccessDesc = prometheus.NewDesc(
+		"tls_probe_success",
+		"1 if the TLS handshake with the endpoint succeeded, 0 otherwise.",
+		nil, nil,
+	)
+	probeDurationDesc = prometheus.NewDesc(
+		"tls_probe_duration_seconds",
+		"Time taken to connect to the endpoint and complete the TLS handshake.",
+		nil, nil,
+	)
+	versionDesc = prometheus.NewDesc(
+		"tls_version_info",
+		"TLS version negotiated with the endpoint.",
+		[]string{"version"}, nil,
+	)
+	chainValidDesc = prometheus.NewDesc(
+		"tls_chain_valid",
+		"1 if the certificate chain presented by the endpoint is trusted and matches the server name, 0 otherwise.",
+		nil, nil,
+	)
+	chainNotAfterDesc = prometheus.NewDesc(
+		"tls_chain_not_after_seconds",
+		"Earliest expiry of the certificates presented by the endpoint, as a Unix timestamp.",
+		nil, nil,
+	)
+	certNotAfterDesc = prometheus.NewDesc(
+		"tls_cert_not_after_seconds",
+		"Expiry of a certificate presented by the endpoint, as a Unix timestamp.",
+		certLabels, nil,
+	)
+	certNotBeforeDesc = prometheus.NewDesc(
+		"tls_cert_not_before_seconds",
+		"Start of the validity period of a certificate presented by the endpoint, as a Unix timestamp.",
+		certLabels, nil,
+	)
+	certSANCountDesc = prometheus.NewDesc(
+		"tls_cert_san_count",
+		"Number of subject alternative names of a certificate presented by the endpoint.",
+		certLabels, nil,
+	)
+)
+
+// certificateCollector probes a single endpoint.
+type certificateCollector struct {
+	ctx       context.Context
+	logger    log.Logger
+	endpoint  endpoint
+	tlsConfig *tls.Config
+}
+
+// Describe implements prometheus.Collector.
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		probeSuccessDesc, probeDurationDesc, versionDesc, chainValidDesc,
+		chainNotAfterDesc, certNotAfterDesc, certNotBeforeDesc, certSANCountDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *certificateCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	state, err := c.handshake()
+	ch <- prometheus.MustNewConstMetric(probeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds())
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to probe TLS endpoint", "target", c.endpoint.address, "err", err)
+		ch <- prometheus.MustNewConstMetric(probeSuccessDesc, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(probeSuccessDesc, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, 1, tlsVersionName(state.Version))
+
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		return
+	}
+
+	valid := 1.0
+	if err := c.verify(certs); err != nil {
+		level.Debug(c.logger).Log("msg", "certificate chain is invalid", "target", c.endpoint.address, "err", err)
+		valid = 0
+	}
+	ch <- prometheus.MustNewConstMetric(chainValidDesc, prometheus.GaugeValue, valid)
+
+	notAfter := certs[0].NotAfter
+	for i, cert := range certs {
+		if cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+
+		labels := []string{
+			fmt.Sprint(i),
+			cert.Subject.CommonName,
+			cert.Issuer.CommonName,
+			cert.SerialNumber.String(),
+		}
+		sans := len(cert.DNSNames) + len(cert.IPAddresses) + len(cert.EmailAddresses) + len(cert.URIs)
+		ch <- prometheus.MustNewConstMetric(certNotAfterDesc, prometheus.GaugeValue, float64(cert.NotAfter.Unix()), labels...)
+		ch <- prometheus.MustNewConstMetric(certNotBeforeDesc, prometheus.GaugeValue, float64(cert.NotBefore.Unix()), labels...)
+		ch <- prometheus.MustNewConstMetric(certSANCountDesc, prometheus.GaugeValue, float64(sans), labels...)
+	}
+	ch <- prometheus.MustNewConstMetric(chainNotAfterDesc, prometheus.GaugeValue, float64(notAfter.Unix()))
+}
+
+// handshake connects to the endpoint and completes a TLS handshake without
+// verifying the certificate chain, so that the certificates of endpoints
+// with invalid chains can still be reported.
+func (c *certificateCollector) handshake() (tls.ConnectionState, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(c.ctx, "tcp", c.endpoint.address)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := c.ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return tls.ConnectionState{}, err
+		}
+	}
+
+	if err := startTLS(conn, c.endpoint.startTLS, c.endpoint.serverName); err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("STARTTLS negotiation failed: %w", err)
+	}
+
+	cfg := c.tlsConfig.Clone()
+	cfg.ServerName = c.endpoint.serverName
+	cfg.InsecureSkipVerify = true
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(c.ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return tlsConn.ConnectionState(), nil
+}
+
+// verify verifies certs against the configured CA, or the system roots when
+// no CA is configured.
+func (c *certificateCollector) verify(certs []*x509.Certificate) error {
+	opts := x509.VerifyOptions{
+		Roots:         c.tlsConfig.RootCAs,
+		DNSName:       c.endpoint.serverName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04x", v)
+	}
+}
@@ -0,0 +1,259 @@
+package certificate_exporter
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoints(t *testing.T) {
+	cfg := Config{
+		Endpoints: []Endpoint{
+			{Address: "example.com"},
+			{Address: "mail.example.com:25", StartTLS: StartTLSSMTP},
+			{Address: "10.0.0.1:443", ServerName: "internal.example.com"},
+		},
+		TLSConfig: config_util.TLSConfig{ServerName: "www.example.com"},
+	}
+	require.Equal(t, map[string]endpoint{
+		"example.com":         {address: "example.com:443", serverName: "www.example.com"},
+		"mail.example.com:25": {address: "mail.example.com:25", serverName: "www.example.com", startTLS: StartTLSSMTP},
+		"10.0.0.1:443":        {address: "10.0.0.1:443", serverName: "internal.example.com"},
+	}, cfg.endpoints())
+
+	cfg.TLSConfig.ServerName = ""
+	require.Equal(t, "example.com", cfg.endpoints()["example.com"].serverName)
+}
+
+func TestCollector(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	address := srv.Listener.Addr().String()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0644))
+
+	t.Run("trusted", func(t *testing.T) {
+		cfg := DefaultConfig
+		cfg.Endpoints = []Endpoint{{Address: address}}
+		cfg.TLSConfig = config_util.TLSConfig{CAFile: caFile}
+
+		expect := expectedMetrics(srv, 1)
+		require.NoError(t, scrapeAndCompare(t, cfg, address, expect))
+	})
+
+	t.Run("wrong server name", func(t *testing.T) {
+		cfg := DefaultConfig
+		cfg.Endpoints = []Endpoint{{Address: address, ServerName: "example.org"}}
+		cfg.TLSConfig = config_util.TLSConfig{CAFile: caFile}
+
+		expect := expectedMetrics(srv, 0)
+		require.NoError(t, scrapeAndCompare(t, cfg, address, expect))
+	})
+
+	t.Run("unknown CA", func(t *testing.T) {
+		cfg := DefaultConfig
+		cfg.Endpoints = []Endpoint{{Address: address}}
+
+		expect := expectedMetrics(srv, 0)
+		require.NoError(t, scrapeAndCompare(t, cfg, address, expect))
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closed := l.Addr().String()
+		l.Close()
+
+		cfg := DefaultConfig
+		cfg.Endpoints = []Endpoint{{Address: closed}}
+
+		expect := `
+			# HELP tls_probe_success 1 if the TLS handshake with the endpoint succeeded, 0 otherwise.
+			# TYPE tls_probe_success gauge
+			tls_probe_success 0
+		`
+		require.NoError(t, scrapeAndCompare(t, cfg, closed, expect))
+	})
+}
+
+func TestStartTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	tt := []struct {
+		protocol string
+		server   func(rw *bufio.ReadWriter) error
+	}{
+		{
+			protocol: StartTLSSMTP,
+			server: func(rw *bufio.ReadWriter) error {
+				return converse(rw, "220 mail.example.com ESMTP\r\n",
+					"EHLO 127.0.0.1", "250-mail.example.com\r\n250 STARTTLS\r\n",
+					"STARTTLS", "220 Ready to start TLS\r\n",
+				)
+			},
+		},
+		{
+			protocol: StartTLSIMAP,
+			server: func(rw *bufio.ReadWriter) error {
+				return converse(rw, "* OK IMAP4rev1 ready\r\n",
+					"a1 STARTTLS", "* CAPABILITY IMAP4rev1\r\na1 OK Begin TLS negotiation now\r\n",
+				)
+			},
+		},
+		{
+			protocol: StartTLSPOP3,
+			server: func(rw *bufio.ReadWriter) error {
+				return converse(rw, "+OK POP3 ready\r\n", "STLS", "+OK Begin TLS negotiation\r\n")
+			},
+		},
+		{
+			protocol: StartTLSFTP,
+			server: func(rw *bufio.ReadWriter) error {
+				return converse(rw, "220 FTP ready\r\n", "AUTH TLS", "234 AUTH TLS successful\r\n")
+			},
+		},
+		{
+			protocol: StartTLSPostgres,
+			server: func(rw *bufio.ReadWriter) error {
+				req := make([]byte, len(postgresSSLRequest))
+				if _, err := rw.Read(req); err != nil {
+					return err
+				}
+				if string(req) != string(postgresSSLRequest) {
+					return fmt.Errorf("unexpected request %v", req)
+				}
+				return converse(rw, "S")
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.protocol, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer l.Close()
+
+			errCh := make(chan error, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					errCh <- err
+					return
+				}
+				defer conn.Close()
+
+				if err := tc.server(bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))); err != nil {
+					errCh <- err
+					return
+				}
+				errCh <- tls.Server(conn, &tls.Config{Certificates: srv.TLS.Certificates}).Handshake()
+			}()
+
+			address := l.Addr().String()
+			cfg := DefaultConfig
+			cfg.Endpoints = []Endpoint{{Address: address, StartTLS: tc.protocol}}
+
+			expect := `
+				# HELP tls_probe_success 1 if the TLS handshake with the endpoint succeeded, 0 otherwise.
+				# TYPE tls_probe_success gauge
+				tls_probe_success 1
+			`
+			require.NoError(t, scrapeAndCompare(t, cfg, address, expect))
+			require.NoError(t, <-errCh)
+		})
+	}
+}
+
+// converse writes greeting, and then expects each request line in turn to be
+// followed by the given response.
+func converse(rw *bufio.ReadWriter, greeting string, requestResponses ...string) error {
+	if _, err := rw.WriteString(greeting); err != nil {
+		return err
+	}
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(requestResponses); i += 2 {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if got := strings.TrimRight(line, "\r\n"); got != requestResponses[i] {
+			return fmt.Errorf("expected %q, got %q", requestResponses[i], got)
+		}
+		if _, err := rw.WriteString(requestResponses[i+1]); err != nil {
+			return err
+		}
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expectedMetrics returns the expected certificate metrics of srv.
+func expectedMetrics(srv *httptest.Server, valid int) string {
+	cert := srv.Certificate()
+	labels := fmt.Sprintf(`issuer_cn="%s",position="0",serial="%s",subject_cn="%s"`,
+		cert.Issuer.CommonName, cert.SerialNumber, cert.Subject.CommonName)
+
+	return fmt.Sprintf(`
+		# HELP tls_cert_not_after_seconds Expiry of a certificate presented by the endpoint, as a Unix timestamp.
+		# TYPE tls_cert_not_after_seconds gauge
+		tls_cert_not_after_seconds{%[1]s} %[2]d
+		# HELP tls_cert_san_count Number of subject alternative names of a certificate presented by the endpoint.
+		# TYPE tls_cert_san_count gauge
+		tls_cert_san_count{%[1]s} %[4]d
+		# HELP tls_chain_not_after_seconds Earliest expiry of the certificates presented by the endpoint, as a Unix timestamp.
+		# TYPE tls_chain_not_after_seconds gauge
+		tls_chain_not_after_seconds %[2]d
+		# HELP tls_chain_valid 1 if the certificate chain presented by the endpoint is trusted and matches the server name, 0 otherwise.
+		# TYPE tls_chain_valid gauge
+		tls_chain_valid %[3]d
+		# HELP tls_probe_success 1 if the TLS handshake with the endpoint succeeded, 0 otherwise.
+		# TYPE tls_probe_success gauge
+		tls_probe_success 1
+	`, labels, cert.NotAfter.Unix(), valid, len(cert.DNSNames)+len(cert.IPAddresses))
+}
+
+func scrapeAndCompare(t *testing.T, cfg Config, address string, expect string) error {
+	t.Helper()
+
+	tlsConfig, err := config_util.NewTLSConfig(&cfg.TLSConfig)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := &certificateCollector{
+		ctx:       ctx,
+		logger:    log.NewNopLogger(),
+		endpoint:  cfg.endpoints()[address],
+		tlsConfig: tlsConfig,
+	}
+
+	names := []string{"tls_probe_success"}
+	if strings.Contains(expect, "tls_chain_valid") {
+		names = append(names, "tls_cert_not_after_seconds", "tls_cert_san_count", "tls_chain_not_after_seconds", "tls_chain_valid")
+	}
+	return testutil.CollectAndCompare(c, strings.NewReader(expect), names...)
+}
@@ -0,0 +1,134 @@
+package certificate_exporter
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+// Supported protocols to upgrade connections to TLS with.
+const (
+	StartTLSSMTP     = "smtp"
+	StartTLSIMAP     = "imap"
+	StartTLSPOP3     = "pop3"
+	StartTLSFTP      = "ftp"
+	StartTLSPostgres = "postgres"
+)
+
+// ValidateStartTLS returns an error if p isn't a supported STARTTLS protocol.
+// An empty protocol is valid and disables STARTTLS.
+func ValidateStartTLS(p string) error {
+	switch p {
+	case "", StartTLSSMTP, StartTLSIMAP, StartTLSPOP3, StartTLSFTP, StartTLSPostgres:
+		return nil
+	default:
+		return fmt.Errorf("unsupported starttls protocol %q, must be one of %q, %q, %q, %q, or %q",
+			p, StartTLSSMTP, StartTLSIMAP, StartTLSPOP3, StartTLSFTP, StartTLSPostgres)
+	}
+}
+
+// startTLS asks the server on conn to start a TLS handshake using protocol.
+// Nothing is done if protocol is empty.
+func startTLS(conn net.Conn, protocol string, serverName string) error {
+	// Servers don't send anything after agreeing to start TLS, so buffering
+	// reads from conn doesn't consume any of the handshake.
+	tp := textproto.NewConn(conn)
+
+	switch protocol {
+	case "":
+		return nil
+
+	case StartTLSSMTP:
+		if _, _, err := tp.ReadResponse(220); err != nil {
+			return err
+		}
+		if err := tp.PrintfLine("EHLO %s", serverName); err != nil {
+			return err
+		}
+		if _, _, err := tp.ReadResponse(250); err != nil {
+			return err
+		}
+		if err := tp.PrintfLine("STARTTLS"); err != nil {
+			return err
+		}
+		_, _, err := tp.ReadResponse(220)
+		return err
+
+	case StartTLSFTP:
+		if _, _, err := tp.ReadResponse(220); err != nil {
+			return err
+		}
+		if err := tp.PrintfLine("AUTH TLS"); err != nil {
+			return err
+		}
+		_, _, err := tp.ReadResponse(234)
+		return err
+
+	case StartTLSIMAP:
+		if err := expectPrefix(tp, "* OK"); err != nil {
+			return err
+		}
+		if err := tp.PrintfLine("a1 STARTTLS"); err != nil {
+			return err
+		}
+		// Skip untagged responses until the tagged response to STARTTLS.
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(line, "a1 ") {
+				if !strings.HasPrefix(line, "a1 OK") {
+					return fmt.Errorf("unexpected response %q", line)
+				}
+				return nil
+			}
+		}
+
+	case StartTLSPOP3:
+		if err := expectPrefix(tp, "+OK"); err != nil {
+			return err
+		}
+		if err := tp.PrintfLine("STLS"); err != nil {
+			return err
+		}
+		return expectPrefix(tp, "+OK")
+
+	case StartTLSPostgres:
+		return startTLSPostgres(conn)
+
+	default:
+		return ValidateStartTLS(protocol)
+	}
+}
+
+func expectPrefix(tp *textproto.Conn, prefix string) error {
+	line, err := tp.ReadLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, prefix) {
+		return fmt.Errorf("unexpected response %q", line)
+	}
+	return nil
+}
+
+// postgresSSLRequest is the SSLRequest message of the PostgreSQL protocol: its
+// length followed by the SSLRequest code.
+var postgresSSLRequest = []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}
+
+func startTLSPostgres(conn net.Conn) error {
+	if _, err := conn.Write(postgresSSLRequest); err != nil {
+		return err
+	}
+	resp := make([]byte, 1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return err
+	}
+	if resp[0] != 'S' {
+		return fmt.Errorf("server does not support SSL")
+	}
+	return nil
+}
@@ -22,10 +22,11 @@ type exporter struct {
 	logger       yaceLoggerWrapper
 	sessionCache yaceSess.SessionCache
 	scrapeConf   yaceConf.ScrapeConf
+	opts         scrapeOptions
 }
 
 // newCloudwatchExporter creates a new YACE wrapper, that implements Integration
-func newCloudwatchExporter(name string, logger log.Logger, conf yaceConf.ScrapeConf, fipsEnabled bool) *exporter {
+func newCloudwatchExporter(name string, logger log.Logger, conf yaceConf.ScrapeConf, fipsEnabled bool, opts scrapeOptions) *exporter {
 	loggerWrapper := yaceLoggerWrapper{
 		debug: false,
 		log:   logger,
@@ -35,6 +36,7 @@ func newCloudwatchExporter(name string, logger log.Logger, conf yaceConf.ScrapeC
 		logger:       loggerWrapper,
 		sessionCache: yaceSess.NewSessionCache(conf, fipsEnabled, loggerWrapper),
 		scrapeConf:   conf,
+		opts:         opts,
 	}
 }
 
@@ -44,14 +46,14 @@ func (e *exporter) MetricsHandler() (http.Handler, error) {
 		e.logger.Debug("Running collect in cloudwatch_exporter")
 
 		reg := prometheus.NewRegistry()
-		cwSemaphore := make(chan struct{}, cloudWatchConcurrency)
-		tagSemaphore := make(chan struct{}, tagConcurrency)
+		cwSemaphore := make(chan struct{}, e.opts.cloudWatchConcurrency)
+		tagSemaphore := make(chan struct{}, e.opts.tagConcurrency)
 		observedMetricLabels := map[string]yaceModel.LabelSet{}
 		yace.UpdateMetrics(
 			context.Background(),
 			e.scrapeConf,
 			reg,
-			metricsPerQuery,
+			e.opts.metricsPerQuery,
 			labelsSnakeCase,
 			cwSemaphore,
 			tagSemaphore,
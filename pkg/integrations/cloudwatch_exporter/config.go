@@ -17,10 +17,14 @@ import (
 )
 
 const (
-	metricsPerQuery       = 500
-	cloudWatchConcurrency = 5
-	tagConcurrency        = 5
-	labelsSnakeCase       = false
+	// maxMetricsPerQuery is the maximum number of metrics the GetMetricData API
+	// accepts in a single request.
+	maxMetricsPerQuery = 500
+
+	defaultMetricsPerQuery       = maxMetricsPerQuery
+	defaultCloudWatchConcurrency = 5
+	defaultTagConcurrency        = 5
+	labelsSnakeCase              = false
 )
 
 // Since we are gathering metrics from CloudWatch and writing them in prometheus during each scrape, the timestamp
@@ -41,6 +45,49 @@ type Config struct {
 	FIPSDisabled bool            `yaml:"fips_disabled"`
 	Discovery    DiscoveryConfig `yaml:"discovery"`
 	Static       []StaticJob     `yaml:"static"`
+
+	// Settings controlling how requests to AWS APIs are batched. Zero values
+	// use the defaults. They're omitted when empty so that the instance key of
+	// existing configs doesn't change.
+	MetricsPerQuery       int `yaml:"metrics_per_query,omitempty"`
+	CloudWatchConcurrency int `yaml:"cloudwatch_concurrency,omitempty"`
+	TagConcurrency        int `yaml:"tag_concurrency,omitempty"`
+}
+
+// scrapeOptions holds the settings of how YACE requests data from AWS, which
+// aren't part of its scrape configuration.
+type scrapeOptions struct {
+	metricsPerQuery       int
+	cloudWatchConcurrency int
+	tagConcurrency        int
+}
+
+// toScrapeOptions validates the batching settings of c and applies defaults.
+func (c *Config) toScrapeOptions() (scrapeOptions, error) {
+	switch {
+	case c.MetricsPerQuery < 0 || c.MetricsPerQuery > maxMetricsPerQuery:
+		return scrapeOptions{}, fmt.Errorf("metrics_per_query must be between 1 and %d", maxMetricsPerQuery)
+	case c.CloudWatchConcurrency < 0:
+		return scrapeOptions{}, fmt.Errorf("cloudwatch_concurrency must be greater than 0")
+	case c.TagConcurrency < 0:
+		return scrapeOptions{}, fmt.Errorf("tag_concurrency must be greater than 0")
+	}
+
+	opts := scrapeOptions{
+		metricsPerQuery:       defaultMetricsPerQuery,
+		cloudWatchConcurrency: defaultCloudWatchConcurrency,
+		tagConcurrency:        defaultTagConcurrency,
+	}
+	if c.MetricsPerQuery > 0 {
+		opts.metricsPerQuery = c.MetricsPerQuery
+	}
+	if c.CloudWatchConcurrency > 0 {
+		opts.cloudWatchConcurrency = c.CloudWatchConcurrency
+	}
+	if c.TagConcurrency > 0 {
+		opts.tagConcurrency = c.TagConcurrency
+	}
+	return opts, nil
 }
 
 // DiscoveryConfig configures scraping jobs that will auto-discover metrics dimensions for a given service.
@@ -118,7 +165,11 @@ func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error)
 	if err != nil {
 		return nil, fmt.Errorf("invalid cloudwatch exporter configuration: %w", err)
 	}
-	return newCloudwatchExporter(c.Name(), l, exporterConfig, fipsEnabled), nil
+	opts, err := c.toScrapeOptions()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cloudwatch exporter configuration: %w", err)
+	}
+	return newCloudwatchExporter(c.Name(), l, exporterConfig, fipsEnabled, opts), nil
 }
 
 // getHash calculates the MD5 hash of the yaml representation of the config
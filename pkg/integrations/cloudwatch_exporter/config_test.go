@@ -225,3 +225,32 @@ func TestCloudwatchExporterConfigInstanceKey(t *testing.T) {
 
 	assert.Equal(t, cfg1Hash, cfg2Hash)
 }
+
+func TestScrapeOptions(t *testing.T) {
+	// Unset batching settings use the defaults.
+	opts, err := (&Config{}).toScrapeOptions()
+	require.NoError(t, err)
+	require.Equal(t, scrapeOptions{metricsPerQuery: 500, cloudWatchConcurrency: 5, tagConcurrency: 5}, opts)
+
+	c := Config{}
+	err = yaml.Unmarshal([]byte(`
+sts_region: us-east-2
+metrics_per_query: 100
+cloudwatch_concurrency: 2
+tag_concurrency: 1
+`), &c)
+	require.NoError(t, err)
+	opts, err = c.toScrapeOptions()
+	require.NoError(t, err)
+	require.Equal(t, scrapeOptions{metricsPerQuery: 100, cloudWatchConcurrency: 2, tagConcurrency: 1}, opts)
+
+	_, err = (&Config{MetricsPerQuery: 501}).toScrapeOptions()
+	require.EqualError(t, err, "metrics_per_query must be between 1 and 500")
+
+	// Unset batching settings are omitted so they don't change the instance
+	// key of existing configs.
+	bb, err := yaml.Marshal(&Config{STSRegion: "us-east-2"})
+	require.NoError(t, err)
+	require.NotContains(t, string(bb), "concurrency")
+	require.NotContains(t, string(bb), "metrics_per_query")
+}
@@ -0,0 +1,246 @@
+package dcgm_exporter
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mindprince/gonvml"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// library is the subset of NVML used by the collector.
+type library interface {
+	Initialize() error
+	Shutdown() error
+	DeviceCount() (uint, error)
+	DeviceHandleByIndex(idx uint) (device, error)
+}
+
+// device is a GPU exposed by NVML.
+type device interface {
+	UUID() (string, error)
+	Name() (string, error)
+	MinorNumber() (uint, error)
+	MemoryInfo() (total uint64, used uint64, err error)
+	UtilizationRates() (gpu uint, memory uint, err error)
+	PowerUsage() (milliwatts uint, err error)
+	Temperature() (celsius uint, err error)
+	FanSpeed() (percent uint, err error)
+	EncoderUtilization() (percent uint, samplePeriod uint, err error)
+	DecoderUtilization() (percent uint, samplePeriod uint, err error)
+}
+
+// nvml implements library with the NVML library installed with the NVIDIA
+// driver. The library is loaded at runtime, so the agent runs on hosts
+// without the driver.
+type nvml struct{}
+
+func (nvml) Initialize() error          { return gonvml.Initialize() }
+func (nvml) Shutdown() error            { return gonvml.Shutdown() }
+func (nvml) DeviceCount() (uint, error) { return gonvml.DeviceCount() }
+
+func (nvml) DeviceHandleByIndex(idx uint) (device, error) {
+	return gonvml.DeviceHandleByIndex(idx)
+}
+
+var (
+	// Metric names follow the field names of NVIDIA's dcgm-exporter so that
+	// existing dashboards can be reused.
+	gpuLabels = []string{"gpu", "UUID", "device", "modelName"}
+
+	upDesc = prometheus.NewDesc(
+		"dcgm_nvml_up",
+		"1 if the NVML library was loaded and initialized, 0 otherwise.",
+		nil, nil,
+	)
+	gpuUtilDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_GPU_UTIL",
+		"GPU utilization (in %).",
+		gpuLabels, nil,
+	)
+	memCopyUtilDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_MEM_COPY_UTIL",
+		"Memory utilization (in %).",
+		gpuLabels, nil,
+	)
+	encUtilDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_ENC_UTIL",
+		"Encoder utilization (in %).",
+		gpuLabels, nil,
+	)
+	decUtilDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_DEC_UTIL",
+		"Decoder utilization (in %).",
+		gpuLabels, nil,
+	)
+	fbFreeDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_FB_FREE",
+		"Framebuffer memory free (in MiB).",
+		gpuLabels, nil,
+	)
+	fbUsedDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_FB_USED",
+		"Framebuffer memory used (in MiB).",
+		gpuLabels, nil,
+	)
+	powerDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_POWER_USAGE",
+		"Power draw (in W).",
+		gpuLabels, nil,
+	)
+	tempDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_GPU_TEMP",
+		"GPU temperature (in C).",
+		gpuLabels, nil,
+	)
+	fanDesc = prometheus.NewDesc(
+		"DCGM_FI_DEV_FAN_SPEED",
+		"Fan speed (in %).",
+		gpuLabels, nil,
+	)
+)
+
+// collector collects metrics from the GPUs exposed by NVML. When NVML can't
+// be initialized, such as on hosts without the NVIDIA driver, only
+// dcgm_nvml_up is collected and initialization is retried on every scrape.
+type collector struct {
+	logger  log.Logger
+	lib     library
+	devices map[string]struct{}
+
+	mut         sync.Mutex
+	initialized bool
+	lastErr     string // Last initialization error, to avoid repeating logs.
+}
+
+func newCollector(l log.Logger, lib library, devices []string) *collector {
+	c := &collector{logger: l, lib: lib}
+	if len(devices) > 0 {
+		c.devices = make(map[string]struct{}, len(devices))
+		for _, d := range devices {
+			c.devices[d] = struct{}{}
+		}
+	}
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		upDesc, gpuUtilDesc, memCopyUtilDesc, encUtilDesc, decUtilDesc,
+		fbFreeDesc, fbUsedDesc, powerDesc, tempDesc, fanDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if !c.initialize() {
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+
+	count, err := c.lib.DeviceCount()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to get GPU count", "err", err)
+		return
+	}
+	for i := uint(0); i < count; i++ {
+		dev, err := c.lib.DeviceHandleByIndex(i)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "failed to get GPU", "gpu", i, "err", err)
+			continue
+		}
+		c.collectDevice(ch, i, dev)
+	}
+}
+
+// initialize initializes NVML if it isn't already, reporting whether it's
+// ready to use.
+func (c *collector) initialize() bool {
+	if c.initialized {
+		return true
+	}
+	if err := c.lib.Initialize(); err != nil {
+		if err.Error() != c.lastErr {
+			level.Warn(c.logger).Log("msg", "NVML is unavailable, GPU metrics won't be collected", "err", err)
+			c.lastErr = err.Error()
+		}
+		return false
+	}
+	level.Info(c.logger).Log("msg", "NVML initialized")
+	c.initialized, c.lastErr = true, ""
+	return true
+}
+
+func (c *collector) collectDevice(ch chan<- prometheus.Metric, idx uint, dev device) {
+	index := strconv.FormatUint(uint64(idx), 10)
+	uuid, err := dev.UUID()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to get GPU UUID", "gpu", index, "err", err)
+		return
+	}
+	if c.devices != nil {
+		_, byIndex := c.devices[index]
+		_, byUUID := c.devices[uuid]
+		if !byIndex && !byUUID {
+			return
+		}
+	}
+
+	// Some fields aren't supported by every GPU, such as the fan speed of
+	// passively cooled GPUs. Those fields are skipped.
+	var (
+		name, _  = dev.Name()
+		minor, _ = dev.MinorNumber()
+		labels   = []string{index, uuid, "nvidia" + strconv.FormatUint(uint64(minor), 10), name}
+	)
+	emit := func(desc *prometheus.Desc, v float64, err error) {
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "failed to collect GPU field", "gpu", index, "metric", desc.String(), "err", err)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labels...)
+	}
+
+	gpuUtil, memUtil, err := dev.UtilizationRates()
+	emit(gpuUtilDesc, float64(gpuUtil), err)
+	emit(memCopyUtilDesc, float64(memUtil), err)
+
+	encUtil, _, err := dev.EncoderUtilization()
+	emit(encUtilDesc, float64(encUtil), err)
+	decUtil, _, err := dev.DecoderUtilization()
+	emit(decUtilDesc, float64(decUtil), err)
+
+	total, used, err := dev.MemoryInfo()
+	emit(fbFreeDesc, float64(total-used)/(1<<20), err)
+	emit(fbUsedDesc, float64(used)/(1<<20), err)
+
+	power, err := dev.PowerUsage()
+	emit(powerDesc, float64(power)/1000, err)
+	temp, err := dev.Temperature()
+	emit(tempDesc, float64(temp), err)
+	fan, err := dev.FanSpeed()
+	emit(fanDesc, float64(fan), err)
+}
+
+// Close shuts down NVML if it was initialized.
+func (c *collector) Close() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if !c.initialized {
+		return
+	}
+	if err := c.lib.Shutdown(); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to shut down NVML", "err", err)
+	}
+	c.initialized = false
+}
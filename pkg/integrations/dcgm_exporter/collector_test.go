@@ -0,0 +1,83 @@
+package dcgm_exporter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	lib := &fakeLibrary{
+		initErr: errors.New("could not load NVML library"),
+		devices: []device{
+			fakeDevice{uuid: "GPU-aaaa", minor: 0, fanErr: errors.New("nvml: Not Supported")},
+			fakeDevice{uuid: "GPU-bbbb", minor: 1, fanErr: errors.New("nvml: Not Supported")},
+		},
+	}
+	c := newCollector(log.NewNopLogger(), lib, []string{"GPU-bbbb"})
+
+	// Without the driver, only dcgm_nvml_up is collected.
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP dcgm_nvml_up 1 if the NVML library was loaded and initialized, 0 otherwise.
+# TYPE dcgm_nvml_up gauge
+dcgm_nvml_up 0
+`)))
+
+	// Initialization is retried on the next scrape.
+	lib.initErr = nil
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP DCGM_FI_DEV_FB_USED Framebuffer memory used (in MiB).
+# TYPE DCGM_FI_DEV_FB_USED gauge
+DCGM_FI_DEV_FB_USED{UUID="GPU-bbbb",device="nvidia1",gpu="1",modelName="NVIDIA A100-SXM4-40GB"} 1024
+# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{UUID="GPU-bbbb",device="nvidia1",gpu="1",modelName="NVIDIA A100-SXM4-40GB"} 87
+# HELP DCGM_FI_DEV_POWER_USAGE Power draw (in W).
+# TYPE DCGM_FI_DEV_POWER_USAGE gauge
+DCGM_FI_DEV_POWER_USAGE{UUID="GPU-bbbb",device="nvidia1",gpu="1",modelName="NVIDIA A100-SXM4-40GB"} 250.5
+# HELP dcgm_nvml_up 1 if the NVML library was loaded and initialized, 0 otherwise.
+# TYPE dcgm_nvml_up gauge
+dcgm_nvml_up 1
+`), "DCGM_FI_DEV_FB_USED", "DCGM_FI_DEV_GPU_UTIL", "DCGM_FI_DEV_POWER_USAGE", "dcgm_nvml_up"))
+
+	// Unsupported fields are skipped.
+	require.Equal(t, 0, testutil.CollectAndCount(c, "DCGM_FI_DEV_FAN_SPEED"))
+
+	c.Close()
+	require.True(t, lib.shutdown)
+}
+
+type fakeLibrary struct {
+	initErr  error
+	devices  []device
+	shutdown bool
+}
+
+func (l *fakeLibrary) Initialize() error          { return l.initErr }
+func (l *fakeLibrary) Shutdown() error            { l.shutdown = true; return nil }
+func (l *fakeLibrary) DeviceCount() (uint, error) { return uint(len(l.devices)), nil }
+
+func (l *fakeLibrary) DeviceHandleByIndex(idx uint) (device, error) {
+	return l.devices[idx], nil
+}
+
+type fakeDevice struct {
+	uuid   string
+	minor  uint
+	fanErr error
+}
+
+func (d fakeDevice) UUID() (string, error)                   { return d.uuid, nil }
+func (d fakeDevice) Name() (string, error)                   { return "NVIDIA A100-SXM4-40GB", nil }
+func (d fakeDevice) MinorNumber() (uint, error)              { return d.minor, nil }
+func (d fakeDevice) MemoryInfo() (uint64, uint64, error)     { return 40 << 30, 1 << 30, nil }
+func (d fakeDevice) UtilizationRates() (uint, uint, error)   { return 87, 40, nil }
+func (d fakeDevice) PowerUsage() (uint, error)               { return 250500, nil }
+func (d fakeDevice) Temperature() (uint, error)              { return 61, nil }
+func (d fakeDevice) FanSpeed() (uint, error)                 { return 0, d.fanErr }
+func (d fakeDevice) EncoderUtilization() (uint, uint, error) { return 0, 167000, nil }
+func (d fakeDevice) DecoderUtilization() (uint, uint, error) { return 0, 167000, nil }
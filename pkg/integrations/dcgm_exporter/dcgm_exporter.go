@@ -0,0 +1,50 @@
+// Package dcgm_exporter collects NVIDIA GPU metrics through NVML, using the
+// metric names of https://github.com/NVIDIA/dcgm-exporter so that its
+// dashboards can be reused.
+//
+// dcgm-exporter itself isn't embedded: it links against NVIDIA's DCGM library
+// with cgo, and github.com/NVIDIA/dcgm-exporter isn't available from the module
+// proxy used to build the agent. The integration backs the
+// prometheus.exporter.dcgm Flow component and isn't registered as a static
+// mode integration.
+package dcgm_exporter //nolint:golint
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+)
+
+// Config controls the dcgm integration.
+type Config struct {
+	// Devices limits the GPUs to collect metrics from, identified by index or
+	// UUID. All GPUs are used when empty.
+	Devices []string
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "dcgm"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
+
+// New creates a new dcgm integration. NVML is loaded when metrics are first
+// collected and shut down when the integration stops.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	col := newCollector(logger, nvml{}, c.Devices)
+
+	return integrations.NewCollectorIntegration(
+		c.Name(),
+		integrations.WithCollectors(col),
+		integrations.WithRunner(func(ctx context.Context) error {
+			<-ctx.Done()
+			col.Close()
+			return ctx.Err()
+		}),
+	), nil
+}
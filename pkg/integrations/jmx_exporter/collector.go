@@ -0,0 +1,389 @@
+package jmx_exporter
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	scrapeDurationDesc = prometheus.NewDesc(
+		"jmx_scrape_duration_seconds",
+		"Time taken to fetch the MBeans of the JVM.",
+		nil, nil,
+	)
+	scrapeErrorDesc = prometheus.NewDesc(
+		"jmx_scrape_error",
+		"1 if fetching the MBeans of the JVM failed, 0 otherwise.",
+		nil, nil,
+	)
+)
+
+// jmxCollector collects the MBeans of a single JVM.
+type jmxCollector struct {
+	ctx     context.Context
+	logger  log.Logger
+	target  target
+	rules   *rules
+	jolokia *jolokiaClient
+}
+
+// Describe implements prometheus.Collector. The collector is unchecked, as
+// the metrics depend on the MBeans of the JVM.
+func (c *jmxCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *jmxCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	beans, err := c.jolokia.fetch(c.ctx, c.target, c.rules.include)
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds())
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to fetch MBeans", "target", c.target.address, "err", err)
+		ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, 1)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, 0)
+
+	conv := newConverter(c.rules)
+	for _, b := range beans {
+		if !c.rules.included(b.name) {
+			continue
+		}
+		attrs := make([]string, 0, len(b.attributes))
+		for attr := range b.attributes {
+			attrs = append(attrs, attr)
+		}
+		sort.Strings(attrs)
+		for _, attr := range attrs {
+			conv.processValue(b.name, nil, attr, b.attributes[attr])
+		}
+	}
+
+	for _, s := range conv.samples {
+		desc := prometheus.NewDesc(s.name, conv.help[s.name], s.labelNames, nil)
+		m, err := prometheus.NewConstMetric(desc, valueType(conv.types[s.name]), s.value, s.labelValues...)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "dropping invalid metric", "name", s.name, "err", err)
+			continue
+		}
+		ch <- m
+	}
+}
+
+func valueType(metricType string) prometheus.ValueType {
+	switch metricType {
+	case typeGauge:
+		return prometheus.GaugeValue
+	case typeCounter:
+		return prometheus.CounterValue
+	default:
+		return prometheus.UntypedValue
+	}
+}
+
+// sample is a metric converted from an MBean attribute.
+type sample struct {
+	name        string
+	labelNames  []string
+	labelValues []string
+	value       float64
+}
+
+// converter converts MBean attributes into samples with the rules of a
+// jmx_exporter configuration file.
+type converter struct {
+	rules   *rules
+	samples []sample
+
+	// The help and type of a metric are set by the first sample with its
+	// name, so that metric families are consistent.
+	help  map[string]string
+	types map[string]string
+	seen  map[string]struct{} // Series which were already converted.
+}
+
+func newConverter(r *rules) *converter {
+	return &converter{
+		rules: r,
+		help:  make(map[string]string),
+		types: make(map[string]string),
+		seen:  make(map[string]struct{}),
+	}
+}
+
+// processValue converts the value of an attribute. Composite values, which
+// are objects in JSON, are converted recursively with the name of the
+// attribute appended to attrKeys.
+func (c *converter) processValue(bean objectName, attrKeys []string, attrName string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		nestedKeys := append(attrKeys[:len(attrKeys):len(attrKeys)], attrName)
+		for _, k := range keys {
+			c.processValue(bean, nestedKeys, k, v[k])
+		}
+	case json.Number, bool, string:
+		c.recordValue(bean, attrKeys, attrName, v)
+	}
+}
+
+// recordValue converts a single value with the first matching rule.
+func (c *converter) recordValue(bean objectName, attrKeys []string, attrName string, value interface{}) {
+	beanName := beanString(bean, attrKeys)
+	valueString := formatValue(value)
+
+	for _, r := range c.rules.rules {
+		name := attrName
+		if r.attrNameSnakeCase {
+			name = toSnakeAndLowerCase(attrName)
+		}
+		matchName := beanName + name + ": " + valueString
+
+		var match []int
+		if r.pattern != nil {
+			if match = r.pattern.FindStringSubmatchIndex(matchName); match == nil {
+				continue
+			}
+		}
+		expand := func(template string) string {
+			if match == nil {
+				return template
+			}
+			return string(r.pattern.ExpandString(nil, template, matchName, match))
+		}
+
+		var v float64
+		if r.value != "" {
+			parsed, err := strconv.ParseFloat(expand(r.value), 64)
+			if err != nil {
+				return
+			}
+			v = parsed
+		} else {
+			switch value := value.(type) {
+			case json.Number:
+				parsed, err := value.Float64()
+				if err != nil {
+					return
+				}
+				v = parsed
+			case bool:
+				if value {
+					v = 1
+				}
+			default:
+				return
+			}
+		}
+		v *= r.valueFactor
+
+		help := beanName + name
+		if r.name == "" {
+			c.defaultExport(bean, attrKeys, name, help, r.metricType, v)
+			return
+		}
+
+		metricName := safeName(expand(r.name))
+		if metricName == "" {
+			return
+		}
+		if c.rules.lowercaseOutputName {
+			metricName = strings.ToLower(metricName)
+		}
+		if r.help != "" {
+			help = expand(r.help)
+		}
+
+		var labelNames, labelValues []string
+		for _, l := range r.labels {
+			labelName, labelValue := safeName(expand(l.name)), expand(l.value)
+			if c.rules.lowercaseOutputLabelName {
+				labelName = strings.ToLower(labelName)
+			}
+			if labelName != "" && labelValue != "" {
+				labelNames = append(labelNames, labelName)
+				labelValues = append(labelValues, labelValue)
+			}
+		}
+		c.add(metricName, help, r.metricType, labelNames, labelValues, v)
+		return
+	}
+}
+
+// defaultExport converts a value into a metric named after its MBean and
+// attribute, as done by jmx_exporter for rules without a name. The metric is
+// named domain_firstProperty_attrKeys_attrName, and the other key properties
+// of the MBean are set as labels.
+func (c *converter) defaultExport(bean objectName, attrKeys []string, attrName, help, metricType string, value float64) {
+	var sb strings.Builder
+	sb.WriteString(bean.domain)
+	if len(bean.props) > 0 {
+		sb.WriteString("_")
+		sb.WriteString(bean.props[0].value)
+	}
+	for _, k := range attrKeys {
+		sb.WriteString("_")
+		sb.WriteString(k)
+	}
+	sb.WriteString("_")
+	sb.WriteString(attrName)
+
+	name := safeName(sb.String())
+	if c.rules.lowercaseOutputName {
+		name = strings.ToLower(name)
+	}
+
+	var labelNames, labelValues []string
+	for i, p := range bean.props {
+		if i == 0 {
+			continue
+		}
+		labelName := safeName(p.key)
+		if c.rules.lowercaseOutputLabelName {
+			labelName = strings.ToLower(labelName)
+		}
+		labelNames = append(labelNames, labelName)
+		labelValues = append(labelValues, p.value)
+	}
+	c.add(name, help, metricType, labelNames, labelValues, value)
+}
+
+// add adds a sample, unless a sample with the same name and labels was
+// already added.
+func (c *converter) add(name, help, metricType string, labelNames, labelValues []string, value float64) {
+	labelNames, labelValues = dedupeLabels(labelNames, labelValues)
+
+	key := seriesKey(name, labelNames, labelValues)
+	if _, ok := c.seen[key]; ok {
+		return
+	}
+	c.seen[key] = struct{}{}
+
+	if _, ok := c.help[name]; !ok {
+		c.help[name] = help
+		c.types[name] = metricType
+	}
+	c.samples = append(c.samples, sample{
+		name:        name,
+		labelNames:  labelNames,
+		labelValues: labelValues,
+		value:       value,
+	})
+}
+
+// dedupeLabels removes labels with the same name as earlier labels.
+func dedupeLabels(names, values []string) ([]string, []string) {
+	seen := make(map[string]struct{}, len(names))
+	var outNames, outValues []string
+	for i, n := range names {
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		outNames = append(outNames, n)
+		outValues = append(outValues, values[i])
+	}
+	return outNames, outValues
+}
+
+func seriesKey(name string, labelNames, labelValues []string) string {
+	pairs := make([]string, len(labelNames))
+	for i := range labelNames {
+		pairs[i] = labelNames[i] + "\xff" + labelValues[i]
+	}
+	sort.Strings(pairs)
+	return name + "\xfe" + strings.Join(pairs, "\xfe")
+}
+
+// beanString returns the form of an MBean name which rule patterns are
+// matched against, such as java.lang<type=Memory><HeapMemoryUsage>.
+func beanString(bean objectName, attrKeys []string) string {
+	var sb strings.Builder
+	sb.WriteString(bean.domain)
+	sb.WriteString("<")
+	for i, p := range bean.props {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.key)
+		sb.WriteString("=")
+		sb.WriteString(p.value)
+	}
+	sb.WriteString("><")
+	sb.WriteString(strings.Join(attrKeys, ", "))
+	sb.WriteString(">")
+	return sb.String()
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+// safeName replaces characters which aren't valid in metric and label names
+// with underscores, merging consecutive underscores.
+func safeName(s string) string {
+	var (
+		sb             strings.Builder
+		prevUnderscore bool
+	)
+	sb.Grow(len(s) + 1)
+	if len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+		sb.WriteByte('_')
+		prevUnderscore = true
+	}
+	for _, r := range s {
+		valid := r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+		if !valid {
+			if !prevUnderscore {
+				sb.WriteByte('_')
+				prevUnderscore = true
+			}
+			continue
+		}
+		sb.WriteRune(r)
+		prevUnderscore = false
+	}
+	return sb.String()
+}
+
+// toSnakeAndLowerCase converts a camel case attribute name into snake case,
+// such as HeapMemoryUsage into heap_memory_usage.
+func toSnakeAndLowerCase(s string) string {
+	if s == "" {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s) + 4)
+	prevUpperOrUnderscore := true
+	for i, r := range s {
+		upper := r >= 'A' && r <= 'Z'
+		if i > 0 && upper && !prevUpperOrUnderscore {
+			sb.WriteByte('_')
+		}
+		sb.WriteString(strings.ToLower(string(r)))
+		prevUpperOrUnderscore = upper || r == '_'
+	}
+	return sb.String()
+}
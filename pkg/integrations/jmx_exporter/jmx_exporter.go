@@ -0,0 +1,161 @@
+// Package jmx_exporter collects the MBeans of JVMs through the HTTP bridge of
+// a Jolokia agent, and converts them into metrics with the rules of a
+// https://github.com/prometheus/jmx_exporter configuration file.
+//
+// jmx_exporter itself isn't embedded: it's written in Java and runs as an
+// agent inside the JVM or as a separate Java process, so there's no Go module
+// to wrap. The integration backs the prometheus.exporter.jmx Flow component
+// and isn't registered as a static mode integration.
+package jmx_exporter //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	integrations_config "github.com/grafana/agent/pkg/integrations/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	config_util "github.com/prometheus/common/config"
+)
+
+// DefaultConfig holds the default settings for the jmx integration.
+var DefaultConfig = Config{
+	Scheme:      "http",
+	JolokiaPath: "/jolokia/",
+	Timeout:     10 * time.Second,
+}
+
+// Config controls the jmx integration.
+type Config struct {
+	// Targets holds the JVMs which can be scraped, selected by the target
+	// query parameter of the metrics endpoint.
+	Targets []Target
+
+	// ConfigFile is the path to a jmx_exporter configuration file. Every
+	// MBean is collected with the default conversion when empty.
+	ConfigFile string
+
+	Scheme      string
+	JolokiaPath string
+	Timeout     time.Duration
+	TLSConfig   config_util.TLSConfig
+}
+
+// Target holds the settings used to query the Jolokia agent of a single JVM.
+type Target struct {
+	// Address is the host and port of the Jolokia agent.
+	Address  string
+	Username string
+	Password config_util.Secret
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "jmx"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
+
+// New creates a new jmx integration, which serves the metrics of the JVM
+// given by the target query parameter.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	r, err := loadRules(c.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := config_util.NewTLSConfig(&c.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout:   c.Timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+
+	return &integration{
+		logger:  logger,
+		timeout: c.Timeout,
+		targets: c.targets(),
+		rules:   r,
+		jolokia: &jolokiaClient{client: client},
+	}, nil
+}
+
+// target holds the resolved settings used to query a single JVM.
+type target struct {
+	address  string
+	url      string
+	username string
+	password string
+}
+
+// targets returns the settings of every target, keyed by address.
+func (c *Config) targets() map[string]target {
+	res := make(map[string]target, len(c.Targets))
+	for _, t := range c.Targets {
+		res[t.Address] = target{
+			address:  t.Address,
+			url:      (&url.URL{Scheme: c.Scheme, Host: t.Address, Path: c.JolokiaPath}).String(),
+			username: t.Username,
+			password: string(t.Password),
+		}
+	}
+	return res
+}
+
+// integration serves the metrics of the JVM given by the target query
+// parameter.
+type integration struct {
+	logger  log.Logger
+	timeout time.Duration
+	targets map[string]target
+	rules   *rules
+	jolokia *jolokiaClient
+
+	// Concurrent scrapes of the same JVM are serialized, since reading every
+	// MBean can be expensive.
+	locks sync.Map // map[string]*sync.Mutex
+}
+
+// MetricsHandler implements integrations.Integration.
+func (i *integration) MetricsHandler() (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := r.URL.Query().Get("target")
+		t, ok := i.targets[address]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", address), http.StatusBadRequest)
+			return
+		}
+
+		lock, _ := i.locks.LoadOrStore(address, &sync.Mutex{})
+		lock.(*sync.Mutex).Lock()
+		defer lock.(*sync.Mutex).Unlock()
+
+		ctx, cancel := context.WithTimeout(r.Context(), i.timeout)
+		defer cancel()
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(&jmxCollector{ctx: ctx, logger: i.logger, target: t, rules: i.rules, jolokia: i.jolokia})
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
+	}), nil
+}
+
+// ScrapeConfigs implements integrations.Integration.
+func (i *integration) ScrapeConfigs() []integrations_config.ScrapeConfig {
+	return nil
+}
+
+// Run implements integrations.Integration.
+func (i *integration) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,226 @@
+package jmx_exporter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargets(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Targets = []Target{
+		{Address: "app1:8778", Username: "jolokia", Password: "secret"},
+		{Address: "app2:8778"},
+	}
+	cfg.Scheme = "https"
+	require.Equal(t, map[string]target{
+		"app1:8778": {address: "app1:8778", url: "https://app1:8778/jolokia/", username: "jolokia", password: "secret"},
+		"app2:8778": {address: "app2:8778", url: "https://app2:8778/jolokia/"},
+	}, cfg.targets())
+}
+
+func TestObjectNamePattern(t *testing.T) {
+	tt := []struct {
+		pattern string
+		name    string
+		expect  bool
+	}{
+		{"*:*", "java.lang:type=Memory", true},
+		{"java.lang:*", "java.lang:type=Memory", true},
+		{"java.*:*", "java.nio:type=BufferPool,name=direct", true},
+		{"java.lang:type=Memory", "java.lang:type=Memory", true},
+		{"java.lang:type=Memory", "java.lang:type=MemoryPool,name=Metaspace", false},
+		{"java.lang:type=MemoryPool", "java.lang:type=MemoryPool,name=Metaspace", false},
+		{"java.lang:type=MemoryPool,*", "java.lang:name=Metaspace,type=MemoryPool", true},
+		{"java.lang:type=MemoryPool,name=G1*,*", "java.lang:type=MemoryPool,name=G1 Eden Space", true},
+		{"java.lang:type=MemoryPool,name=G1?Old*", "java.lang:type=MemoryPool,name=G1 Old Gen", true},
+		{"Catalina:type=Manager,*", "Catalina:type=Manager,context=/app,host=localhost", true},
+		{"Catalina:context=*,*", "Catalina:type=Manager,context=/app,host=localhost", true},
+	}
+
+	for _, tc := range tt {
+		p, err := parseObjectNamePattern(tc.pattern)
+		require.NoError(t, err)
+		n, err := parseObjectName(tc.name)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, p.matches(n), "%s matching %s", tc.pattern, tc.name)
+	}
+
+	n, err := parseObjectName(`kafka.server:type=x,name="a,b",topic=t`)
+	require.NoError(t, err)
+	require.Equal(t, []property{{"type", "x"}, {"name", `"a,b"`}, {"topic", "t"}}, n.props)
+
+	_, err = parseObjectNamePattern("java.lang")
+	require.Error(t, err)
+}
+
+func TestConverter_DefaultRules(t *testing.T) {
+	conv := newConverter(defaultRules)
+	memory := mustParseObjectName(t, "java.lang:type=Memory")
+	pool := mustParseObjectName(t, "java.lang:type=MemoryPool,name=G1 Eden Space")
+
+	conv.processValue(memory, nil, "HeapMemoryUsage", map[string]interface{}{
+		"used": json.Number("1024"),
+		"max":  json.Number("4096"),
+	})
+	conv.processValue(memory, nil, "Verbose", true)
+	conv.processValue(memory, nil, "ObjectName", "java.lang:type=Memory")
+	conv.processValue(pool, nil, "CollectionUsageThresholdCount", json.Number("0"))
+	conv.processValue(pool, nil, "Valid", nil)
+
+	require.Equal(t, []sample{
+		{name: "java_lang_Memory_HeapMemoryUsage_max", value: 4096},
+		{name: "java_lang_Memory_HeapMemoryUsage_used", value: 1024},
+		{name: "java_lang_Memory_Verbose", value: 1},
+		{
+			name:        "java_lang_MemoryPool_CollectionUsageThresholdCount",
+			labelNames:  []string{"name"},
+			labelValues: []string{"G1 Eden Space"},
+			value:       0,
+		},
+	}, conv.samples)
+	require.Equal(t, "java.lang<type=Memory><HeapMemoryUsage>used", conv.help["java_lang_Memory_HeapMemoryUsage_used"])
+	require.Equal(t, typeUntyped, conv.types["java_lang_Memory_Verbose"])
+}
+
+func TestConverter_Rules(t *testing.T) {
+	r, err := loadRules("testdata/config.yaml")
+	require.NoError(t, err)
+
+	conv := newConverter(r)
+	memory := mustParseObjectName(t, "java.lang:type=Memory")
+	topic := mustParseObjectName(t, "kafka.server:type=BrokerTopicMetrics,name=MessagesInPerSec,topic=orders")
+
+	conv.processValue(memory, nil, "HeapMemoryUsage", map[string]interface{}{"used": json.Number("1024")})
+	conv.processValue(memory, nil, "ObjectPendingFinalizationCount", json.Number("3"))
+	conv.processValue(topic, nil, "Count", json.Number("42"))
+	conv.processValue(topic, nil, "MeanRate", json.Number("1.5"))
+	// Duplicate series are dropped.
+	conv.processValue(topic, nil, "Count", json.Number("43"))
+
+	require.Equal(t, []sample{
+		{name: "jvm_memory_heap_used_bytes", value: 1024},
+		{name: "java_lang_memory_object_pending_finalization_count", value: 3},
+		{
+			name:        "kafka_server_brokertopicmetrics_messagesin_total",
+			labelNames:  []string{"topic"},
+			labelValues: []string{"orders"},
+			value:       42,
+		},
+	}, conv.samples)
+	require.Equal(t, "JVM heap memory used.", conv.help["jvm_memory_heap_used_bytes"])
+	require.Equal(t, typeCounter, conv.types["kafka_server_brokertopicmetrics_messagesin_total"])
+
+	require.True(t, r.included(memory))
+	require.True(t, r.included(topic))
+	require.False(t, r.included(mustParseObjectName(t, "kafka.server:type=BrokerTopicMetrics,name=IgnoredPerSec")))
+	require.False(t, r.included(mustParseObjectName(t, "java.lang:type=Threading")))
+}
+
+func TestCompileRules_Invalid(t *testing.T) {
+	_, err := compileRules(rulesConfig{Rules: []ruleConfig{{Name: "foo"}}})
+	require.EqualError(t, err, "rule 0: pattern must be set if name is set")
+
+	_, err = compileRules(rulesConfig{Rules: []ruleConfig{{Pattern: ".*", Type: "GAUGE"}}})
+	require.EqualError(t, err, "rule 0: name must be set if help, labels or type are set")
+
+	_, err = compileRules(rulesConfig{Rules: []ruleConfig{{Pattern: ".*", Name: "foo", Type: "SUMMARY"}}})
+	require.EqualError(t, err, `rule 0: unsupported type "SUMMARY"`)
+}
+
+func TestSafeName(t *testing.T) {
+	require.Equal(t, "kafka_server_Brokers_Count", safeName("kafka.server__Brokers -Count"))
+	require.Equal(t, "_1xx", safeName("1xx"))
+	require.Equal(t, "heap_memory_usage", toSnakeAndLowerCase("HeapMemoryUsage"))
+	require.Equal(t, "cpuload", toSnakeAndLowerCase("CPULoad"))
+	require.Equal(t, "process_cpu_load", toSnakeAndLowerCase("ProcessCpuLoad"))
+}
+
+func TestCollect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "jolokia" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "/jolokia/", r.URL.Path)
+		require.Equal(t, "false", r.URL.Query().Get("canonicalNaming"))
+
+		var reqs []jolokiaRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reqs))
+		require.Equal(t, []jolokiaRequest{
+			{Type: "read", MBean: "java.lang:type=Memory"},
+			{Type: "read", MBean: "kafka.server:type=BrokerTopicMetrics,*"},
+		}, reqs)
+
+		_, _ = io.WriteString(w, `[
+			{"status": 200, "value": {"HeapMemoryUsage": {"used": 1024, "max": 4096}, "Verbose": false}},
+			{"status": 200, "value": {
+				"kafka.server:type=BrokerTopicMetrics,name=MessagesInPerSec,topic=orders": {"Count": 42},
+				"kafka.server:type=BrokerTopicMetrics,name=IgnoredPerSec,topic=orders": {"Count": 1}
+			}}
+		]`)
+	}))
+	defer srv.Close()
+
+	// The second target has a different address for the same server.
+	address := strings.TrimPrefix(srv.URL, "http://")
+	otherAddress := strings.Replace(address, "127.0.0.1", "localhost", 1)
+	i, err := New(util.TestLogger(t), &Config{
+		Targets: []Target{
+			{Address: address, Username: "jolokia", Password: "secret"},
+			{Address: otherAddress, Username: "jolokia", Password: "wrong"},
+		},
+		ConfigFile:  "testdata/config.yaml",
+		Scheme:      "http",
+		JolokiaPath: "/jolokia/",
+		Timeout:     5 * time.Second,
+	})
+	require.NoError(t, err)
+	h, err := i.MetricsHandler()
+	require.NoError(t, err)
+
+	body := scrape(t, h, address)
+	for _, line := range []string{
+		`jmx_scrape_error 0`,
+		`# HELP jvm_memory_heap_used_bytes JVM heap memory used.`,
+		`# TYPE jvm_memory_heap_used_bytes gauge`,
+		`jvm_memory_heap_used_bytes 1024`,
+		`jvm_memory_heap_max_bytes 4096`,
+		`# TYPE kafka_server_brokertopicmetrics_messagesin_total counter`,
+		`kafka_server_brokertopicmetrics_messagesin_total{topic="orders"} 42`,
+	} {
+		require.Contains(t, body, line)
+	}
+	require.Contains(t, body, "java_lang_memory_verbose 0")
+	require.NotContains(t, body, "ignored")
+
+	// Every target uses its own credentials.
+	require.Contains(t, scrape(t, h, otherAddress), "jmx_scrape_error 1")
+}
+
+func scrape(t *testing.T, h http.Handler, target string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?target="+target, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	bb, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(bb)
+}
+
+func mustParseObjectName(t *testing.T, s string) objectName {
+	t.Helper()
+	n, err := parseObjectName(s)
+	require.NoError(t, err)
+	return n
+}
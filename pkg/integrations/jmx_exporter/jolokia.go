@@ -0,0 +1,167 @@
+package jmx_exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// jolokiaClient fetches MBeans from the HTTP bridge of a Jolokia agent, which
+// exposes the MBeans of the JVM it's attached to as JSON.
+type jolokiaClient struct {
+	client *http.Client
+}
+
+// mbean is an MBean and the values of its attributes.
+type mbean struct {
+	name       objectName
+	attributes map[string]interface{}
+}
+
+type jolokiaRequest struct {
+	Type  string `json:"type"`
+	MBean string `json:"mbean"`
+}
+
+type jolokiaResponse struct {
+	Status int             `json:"status"`
+	Error  string          `json:"error"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// fetch reads the attributes of every MBean matching one of patterns. The
+// patterns are sent in a single bulk request.
+func (c *jolokiaClient) fetch(ctx context.Context, t target, patterns []objectNamePattern) ([]mbean, error) {
+	reqs := make([]jolokiaRequest, 0, len(patterns))
+	for _, p := range patterns {
+		reqs = append(reqs, jolokiaRequest{Type: "read", MBean: p.String()})
+	}
+	body, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(t.url)
+	if err != nil {
+		return nil, err
+	}
+	// Errors reading single attributes are returned in place of their values
+	// rather than failing the whole request, and key properties are kept in
+	// the order they were registered in, which rules may depend on.
+	u.RawQuery = url.Values{
+		"ignoreErrors":    []string{"true"},
+		"canonicalNaming": []string{"false"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var resps []jolokiaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&resps); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(resps) != len(patterns) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(patterns), len(resps))
+	}
+
+	var beans []mbean
+	for i, r := range resps {
+		switch r.Status {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			// No MBean matches the pattern.
+			continue
+		default:
+			return nil, fmt.Errorf("reading %s failed with status %d: %s", patterns[i], r.Status, r.Error)
+		}
+
+		res, err := decodeReadValue(patterns[i], r.Value)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", patterns[i], err)
+		}
+		beans = append(beans, res...)
+	}
+	sort.Slice(beans, func(i, j int) bool { return beans[i].name.String() < beans[j].name.String() })
+	return beans, nil
+}
+
+// decodeReadValue decodes the value of a read response. Reading a pattern
+// returns the attributes of each matching MBean keyed by its name, while
+// reading the name of a single MBean returns its attributes directly.
+func decodeReadValue(p objectNamePattern, value json.RawMessage) ([]mbean, error) {
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+
+	if !p.isPattern() {
+		var attrs map[string]interface{}
+		if err := dec.Decode(&attrs); err != nil {
+			return nil, err
+		}
+		return []mbean{{name: p.objectName(), attributes: attrs}}, nil
+	}
+
+	var byName map[string]map[string]interface{}
+	if err := dec.Decode(&byName); err != nil {
+		return nil, err
+	}
+	beans := make([]mbean, 0, len(byName))
+	for name, attrs := range byName {
+		n, err := parseObjectName(name)
+		if err != nil {
+			return nil, err
+		}
+		beans = append(beans, mbean{name: n, attributes: attrs})
+	}
+	return beans, nil
+}
+
+// isPattern returns whether p may match more than one MBean.
+func (p objectNamePattern) isPattern() bool {
+	if p.propertyList || strings.ContainsAny(p.domain, "*?") {
+		return true
+	}
+	for _, prop := range p.props {
+		if strings.ContainsAny(prop.value, "*?") {
+			return true
+		}
+	}
+	return false
+}
+
+// objectName returns the name matched by p, which must not be a pattern.
+func (p objectNamePattern) objectName() objectName {
+	return objectName{domain: p.domain, props: p.props}
+}
+
+func (p objectNamePattern) String() string {
+	s := p.objectName().String()
+	if p.propertyList {
+		if len(p.props) > 0 {
+			s += ","
+		}
+		s += "*"
+	}
+	return s
+}
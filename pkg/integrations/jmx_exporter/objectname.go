@@ -0,0 +1,187 @@
+package jmx_exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// objectName is the name of an MBean, such as java.lang:type=Memory. The key
+// properties are kept in the order they were given in.
+type objectName struct {
+	domain string
+	props  []property
+}
+
+type property struct {
+	key, value string
+}
+
+// parseObjectName parses the string form of an MBean name.
+func parseObjectName(s string) (objectName, error) {
+	domain, props, ok := strings.Cut(s, ":")
+	if !ok {
+		return objectName{}, fmt.Errorf("invalid object name %q: missing domain", s)
+	}
+
+	name := objectName{domain: domain}
+	for _, p := range splitProperties(props) {
+		key, value, ok := strings.Cut(p, "=")
+		if !ok || key == "" {
+			return objectName{}, fmt.Errorf("invalid object name %q: invalid key property %q", s, p)
+		}
+		name.props = append(name.props, property{key: key, value: value})
+	}
+	if len(name.props) == 0 {
+		return objectName{}, fmt.Errorf("invalid object name %q: missing key properties", s)
+	}
+	return name, nil
+}
+
+// splitProperties splits a list of key properties on commas which aren't in
+// quoted values.
+func splitProperties(s string) []string {
+	var (
+		res     []string
+		start   int
+		quoted  bool
+		escaped bool
+	)
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			res = append(res, s[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		res = append(res, s[start:])
+	}
+	return res
+}
+
+func (n objectName) String() string {
+	var sb strings.Builder
+	sb.WriteString(n.domain)
+	sb.WriteByte(':')
+	for i, p := range n.props {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(p.key)
+		sb.WriteByte('=')
+		sb.WriteString(p.value)
+	}
+	return sb.String()
+}
+
+// objectNamePattern is a pattern matching object names, such as java.lang:*
+// or kafka.server:type=BrokerTopicMetrics,name=*,*. Domains and property
+// values may contain the * and ? wildcards, and a * in place of a key
+// property allows names to have other key properties.
+type objectNamePattern struct {
+	domain       string
+	props        []property
+	propertyList bool // Names may have key properties which aren't in props.
+}
+
+func parseObjectNamePattern(s string) (objectNamePattern, error) {
+	domain, props, ok := strings.Cut(s, ":")
+	if !ok {
+		return objectNamePattern{}, fmt.Errorf("invalid object name pattern %q: missing domain", s)
+	}
+
+	p := objectNamePattern{domain: domain}
+	for _, prop := range splitProperties(props) {
+		if prop == "*" {
+			p.propertyList = true
+			continue
+		}
+		key, value, ok := strings.Cut(prop, "=")
+		if !ok || key == "" {
+			return objectNamePattern{}, fmt.Errorf("invalid object name pattern %q: invalid key property %q", s, prop)
+		}
+		p.props = append(p.props, property{key: key, value: value})
+	}
+	if len(p.props) == 0 && !p.propertyList {
+		return objectNamePattern{}, fmt.Errorf("invalid object name pattern %q: missing key properties", s)
+	}
+	return p, nil
+}
+
+func mustParseObjectNamePattern(s string) objectNamePattern {
+	p, err := parseObjectNamePattern(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// matches returns whether n matches the pattern.
+func (p objectNamePattern) matches(n objectName) bool {
+	if !matchWildcard(p.domain, n.domain) {
+		return false
+	}
+	if !p.propertyList && len(p.props) != len(n.props) {
+		return false
+	}
+
+	for _, want := range p.props {
+		var found bool
+		for _, got := range n.props {
+			if got.key != want.key {
+				continue
+			}
+			found = matchWildcard(want.value, got.value)
+			break
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchWildcard returns whether s matches pattern, in which * matches any
+// sequence of characters and ? matches any single character.
+func matchWildcard(pattern, s string) bool {
+	var (
+		px, sx         int
+		nextPx, nextSx = -1, -1
+	)
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				// Try to match the rest of the pattern at sx, and restart at
+				// sx+1 if that fails.
+				nextPx, nextSx = px, sx+1
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					px++
+					sx++
+					continue
+				}
+			default:
+				if sx < len(s) && s[sx] == c {
+					px++
+					sx++
+					continue
+				}
+			}
+		}
+		if nextSx > 0 && nextSx <= len(s) {
+			px, sx = nextPx, nextSx
+			continue
+		}
+		return false
+	}
+	return true
+}
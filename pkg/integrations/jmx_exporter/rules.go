@@ -0,0 +1,200 @@
+package jmx_exporter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// rulesConfig is the subset of the jmx_exporter configuration file which
+// controls which MBeans are collected and how their attributes are converted
+// into metrics. Settings of jmx_exporter which configure the connection to
+// the JVM, such as hostPort, are ignored.
+type rulesConfig struct {
+	LowercaseOutputName      bool         `yaml:"lowercaseOutputName"`
+	LowercaseOutputLabelName bool         `yaml:"lowercaseOutputLabelNames"`
+	IncludeObjectNames       []string     `yaml:"includeObjectNames"`
+	ExcludeObjectNames       []string     `yaml:"excludeObjectNames"`
+	WhitelistObjectNames     []string     `yaml:"whitelistObjectNames"`
+	BlacklistObjectNames     []string     `yaml:"blacklistObjectNames"`
+	Rules                    []ruleConfig `yaml:"rules"`
+}
+
+type ruleConfig struct {
+	Pattern           string            `yaml:"pattern"`
+	Name              string            `yaml:"name"`
+	Value             string            `yaml:"value"`
+	ValueFactor       *float64          `yaml:"valueFactor"`
+	Help              string            `yaml:"help"`
+	Labels            map[string]string `yaml:"labels"`
+	Type              string            `yaml:"type"`
+	AttrNameSnakeCase bool              `yaml:"attrNameSnakeCase"`
+}
+
+// rules are the compiled rules of a configuration file.
+type rules struct {
+	lowercaseOutputName      bool
+	lowercaseOutputLabelName bool
+	include                  []objectNamePattern
+	exclude                  []objectNamePattern
+	rules                    []rule
+}
+
+// rule converts matching MBean attributes into metrics.
+type rule struct {
+	pattern           *regexp.Regexp // nil matches every attribute.
+	name              string
+	value             string
+	valueFactor       float64
+	help              string
+	labels            []ruleLabel
+	metricType        string
+	attrNameSnakeCase bool
+}
+
+type ruleLabel struct {
+	name, value string
+}
+
+// Types of metrics which can be set by rules.
+const (
+	typeGauge   = "GAUGE"
+	typeCounter = "COUNTER"
+	typeUntyped = "UNTYPED"
+)
+
+// defaultRules are used when no configuration file is set, exporting every
+// attribute in the default format.
+var defaultRules = &rules{
+	include: []objectNamePattern{mustParseObjectNamePattern("*:*")},
+	rules:   []rule{{valueFactor: 1, metricType: typeUntyped}},
+}
+
+// loadRules reads and compiles the configuration file at path.
+func loadRules(path string) (*rules, error) {
+	if path == "" {
+		return defaultRules, nil
+	}
+
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var cfg rulesConfig
+	if err := yaml.Unmarshal(bb, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return compileRules(cfg)
+}
+
+func compileRules(cfg rulesConfig) (*rules, error) {
+	res := &rules{
+		lowercaseOutputName:      cfg.LowercaseOutputName,
+		lowercaseOutputLabelName: cfg.LowercaseOutputLabelName,
+	}
+
+	// whitelistObjectNames and blacklistObjectNames are the names used by
+	// older versions of jmx_exporter.
+	include := append(cfg.IncludeObjectNames, cfg.WhitelistObjectNames...)
+	if len(include) == 0 {
+		include = []string{"*:*"}
+	}
+	for _, s := range include {
+		p, err := parseObjectNamePattern(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid includeObjectNames: %w", err)
+		}
+		res.include = append(res.include, p)
+	}
+	for _, s := range append(cfg.ExcludeObjectNames, cfg.BlacklistObjectNames...) {
+		p, err := parseObjectNamePattern(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid excludeObjectNames: %w", err)
+		}
+		res.exclude = append(res.exclude, p)
+	}
+
+	for i, rc := range cfg.Rules {
+		r, err := compileRule(rc)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		res.rules = append(res.rules, r)
+	}
+	if len(res.rules) == 0 {
+		res.rules = defaultRules.rules
+	}
+	return res, nil
+}
+
+func compileRule(rc ruleConfig) (rule, error) {
+	r := rule{
+		name:              expandGroups(rc.Name),
+		value:             expandGroups(rc.Value),
+		valueFactor:       1,
+		help:              expandGroups(rc.Help),
+		metricType:        typeUntyped,
+		attrNameSnakeCase: rc.AttrNameSnakeCase,
+	}
+
+	if rc.Name == "" && (rc.Help != "" || len(rc.Labels) > 0 || rc.Type != "") {
+		return r, fmt.Errorf("name must be set if help, labels or type are set")
+	}
+	if rc.Name != "" && rc.Pattern == "" {
+		return r, fmt.Errorf("pattern must be set if name is set")
+	}
+
+	if rc.Pattern != "" {
+		// Like jmx_exporter, patterns match anywhere in the attribute.
+		pattern, err := regexp.Compile("^.*(?:" + rc.Pattern + ").*$")
+		if err != nil {
+			return r, fmt.Errorf("invalid pattern: %w", err)
+		}
+		r.pattern = pattern
+	}
+	if rc.ValueFactor != nil {
+		r.valueFactor = *rc.ValueFactor
+	}
+	if rc.Type != "" {
+		r.metricType = strings.ToUpper(rc.Type)
+		switch r.metricType {
+		case typeGauge, typeCounter, typeUntyped:
+		default:
+			return r, fmt.Errorf("unsupported type %q", rc.Type)
+		}
+	}
+
+	for name, value := range rc.Labels {
+		r.labels = append(r.labels, ruleLabel{name: expandGroups(name), value: expandGroups(value)})
+	}
+	sort.Slice(r.labels, func(i, j int) bool { return r.labels[i].name < r.labels[j].name })
+	return r, nil
+}
+
+var groupRefRegexp = regexp.MustCompile(`\$(\d+)`)
+
+// expandGroups converts references to groups from the $1 syntax of Java into
+// the ${1} syntax of regexp.Expand, as $1abc refers to the group named 1abc
+// in Go.
+func expandGroups(s string) string {
+	return groupRefRegexp.ReplaceAllString(s, "$${$1}")
+}
+
+// included returns whether an MBean should be collected.
+func (r *rules) included(name objectName) bool {
+	for _, p := range r.exclude {
+		if p.matches(name) {
+			return false
+		}
+	}
+	for _, p := range r.include {
+		if p.matches(name) {
+			return true
+		}
+	}
+	return false
+}
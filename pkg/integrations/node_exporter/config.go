@@ -261,38 +261,7 @@ func init() {
 // it to the set of flags that node_exporter usually expects when running as a
 // separate binary.
 func MapConfigToNodeExporterFlags(c *Config) (accepted []string, ignored []string) {
-	collectors := make(map[string]CollectorState, len(Collectors))
-	for k, v := range Collectors {
-		collectors[k] = v
-	}
-
-	// Override the set of defaults with the provided set of collectors if
-	// set_collectors has at least one element in it.
-	if len(c.SetCollectors) != 0 {
-		customDefaults := map[string]struct{}{}
-		for _, c := range c.SetCollectors {
-			customDefaults[c] = struct{}{}
-		}
-
-		for k := range collectors {
-			_, shouldEnable := customDefaults[k]
-			if shouldEnable {
-				collectors[k] = CollectorStateEnabled
-			} else {
-				collectors[k] = CollectorStateDisabled
-			}
-		}
-	}
-
-	// Explicitly disable/enable specific collectors
-	for _, c := range c.DisableCollectors {
-		collectors[c] = CollectorStateDisabled
-	}
-	for _, c := range c.EnableCollectors {
-		collectors[c] = CollectorStateEnabled
-	}
-
-	DisableUnavailableCollectors(collectors)
+	collectors := EnabledCollectors(c)
 
 	var flags flags
 	flags.accepted = append(flags.accepted, MapCollectorsToFlags(collectors)...)
@@ -436,6 +405,45 @@ func MapConfigToNodeExporterFlags(c *Config) (accepted []string, ignored []strin
 	return flags.accepted, flags.ignored
 }
 
+// EnabledCollectors returns the state of every known collector after applying
+// the set_collectors, enable_collectors, and disable_collectors settings of c.
+// Collectors which are unavailable on the host system are disabled.
+func EnabledCollectors(c *Config) map[string]CollectorState {
+	collectors := make(map[string]CollectorState, len(Collectors))
+	for k, v := range Collectors {
+		collectors[k] = v
+	}
+
+	// Override the set of defaults with the provided set of collectors if
+	// set_collectors has at least one element in it.
+	if len(c.SetCollectors) != 0 {
+		customDefaults := map[string]struct{}{}
+		for _, c := range c.SetCollectors {
+			customDefaults[c] = struct{}{}
+		}
+
+		for k := range collectors {
+			_, shouldEnable := customDefaults[k]
+			if shouldEnable {
+				collectors[k] = CollectorStateEnabled
+			} else {
+				collectors[k] = CollectorStateDisabled
+			}
+		}
+	}
+
+	// Explicitly disable/enable specific collectors
+	for _, c := range c.DisableCollectors {
+		collectors[c] = CollectorStateDisabled
+	}
+	for _, c := range c.EnableCollectors {
+		collectors[c] = CollectorStateEnabled
+	}
+
+	DisableUnavailableCollectors(collectors)
+	return collectors
+}
+
 type flags struct {
 	accepted []string
 	ignored  []string
@@ -24,6 +24,10 @@ type Integration struct {
 	logger log.Logger
 	nc     *collector.NodeCollector
 
+	// gatherers are additional sources of metrics exposed alongside the
+	// node_exporter collectors.
+	gatherers []prometheus.Gatherer
+
 	exporterMetricsRegistry *prometheus.Registry
 }
 
@@ -69,14 +73,21 @@ func New(log log.Logger, c *Config) (*Integration, error) {
 	}, nil
 }
 
+// AddGatherer exposes the metrics of g alongside the node_exporter collectors.
+// It must be called before MetricsHandler.
+func (i *Integration) AddGatherer(g prometheus.Gatherer) {
+	i.gatherers = append(i.gatherers, g)
+}
+
 // MetricsHandler implements Integration.
 func (i *Integration) MetricsHandler() (http.Handler, error) {
 	r := prometheus.NewRegistry()
 	if err := r.Register(i.nc); err != nil {
 		return nil, fmt.Errorf("couldn't register node_exporter node collector: %w", err)
 	}
+	gatherers := append(prometheus.Gatherers{i.exporterMetricsRegistry, r}, i.gatherers...)
 	handler := promhttp.HandlerFor(
-		prometheus.Gatherers{i.exporterMetricsRegistry, r},
+		gatherers,
 		promhttp.HandlerOpts{
 			ErrorHandling:       promhttp.ContinueOnError,
 			MaxRequestsInFlight: 0,
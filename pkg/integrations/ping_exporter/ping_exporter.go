@@ -0,0 +1,130 @@
+// Package ping_exporter continuously pings hosts over ICMP with
+// github.com/prometheus-community/pro-bing, and exposes round-trip time
+// histograms and packet loss per host.
+//
+// The metric names differ from https://github.com/czerwonk/ping_exporter,
+// which isn't embedded: its collector lives in the main package and can't be
+// imported, and the module isn't available from the module proxy used to
+// build the agent. The integration backs the prometheus.exporter.ping Flow
+// component and isn't registered as a static mode integration.
+package ping_exporter //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultConfig holds the default settings for the ping integration.
+var DefaultConfig = Config{
+	Interval: time.Second,
+	Count:    1,
+	Size:     56,
+	Timeout:  time.Second,
+	RTTBuckets: []float64{
+		0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1,
+	},
+}
+
+// MinSize is the smallest payload size of echo requests, which holds the
+// timestamp and tracker used to match replies.
+const MinSize = 24
+
+// Config controls the ping integration.
+type Config struct {
+	// Targets holds the addresses of the hosts to ping. A port in an address
+	// is ignored.
+	Targets []string
+
+	// Interval is how often to send a round of Count echo requests.
+	Interval time.Duration
+	Count    int
+	Size     int
+
+	// Timeout is how long to wait for the replies of a round.
+	Timeout    time.Duration
+	Privileged bool
+	RTTBuckets []float64
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "ping"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
+
+// New creates a new ping integration, which serves the metrics of the host
+// given by the target query parameter.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	return newIntegration(logger, *c, &probingPinger{logger: logger, privileged: c.Privileged}), nil
+}
+
+// integration continuously pings every target in the background, and serves
+// the metrics of the target given by the target query parameter.
+type integration struct {
+	logger  log.Logger
+	probers map[string]*prober
+}
+
+func newIntegration(l log.Logger, c Config, p pinger) *integration {
+	i := &integration{
+		logger:  l,
+		probers: make(map[string]*prober, len(c.Targets)),
+	}
+	for _, address := range c.Targets {
+		i.probers[address] = newProber(l, c, p, hostOf(address))
+	}
+	return i
+}
+
+// hostOf returns the host of address, removing the port if there's one.
+func hostOf(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// MetricsHandler implements integrations.Integration.
+func (i *integration) MetricsHandler() (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := r.URL.Query().Get("target")
+		p, ok := i.probers[address]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", address), http.StatusBadRequest)
+			return
+		}
+		promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}), nil
+}
+
+// ScrapeConfigs implements integrations.Integration.
+func (i *integration) ScrapeConfigs() []config.ScrapeConfig {
+	return nil
+}
+
+// Run implements integrations.Integration.
+func (i *integration) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, p := range i.probers {
+		wg.Add(1)
+		go func(p *prober) {
+			defer wg.Done()
+			p.run(ctx)
+		}(p)
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,111 @@
+package ping_exporter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePinger replies to every other echo request after rtt.
+type fakePinger struct {
+	rtt time.Duration
+
+	sent int
+	ips  []net.IP
+}
+
+func (p *fakePinger) Ping(ctx context.Context, ip net.IP, count, size int, onReply func(time.Duration)) (int, error) {
+	p.ips = append(p.ips, ip)
+	for i := 0; i < count; i++ {
+		p.sent++
+		if p.sent%2 == 1 {
+			onReply(p.rtt)
+		}
+	}
+	<-ctx.Done()
+	return count, nil
+}
+
+func TestIntegration(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Targets = []string{"10.0.0.1:9100"}
+	cfg.Count = 4
+	cfg.Timeout = 10 * time.Millisecond
+
+	p := &fakePinger{rtt: 3 * time.Millisecond}
+	i := newIntegration(log.NewNopLogger(), cfg, p)
+
+	// Send a single round of pings rather than running in the background.
+	i.probers["10.0.0.1:9100"].round(context.Background())
+
+	require.Equal(t, 4, p.sent)
+	for _, ip := range p.ips {
+		require.Equal(t, "10.0.0.1", ip.String())
+	}
+
+	h, err := i.MetricsHandler()
+	require.NoError(t, err)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?target=10.0.0.2")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	expect := `
+		# HELP ping_loss_ratio Ratio of echo requests without a reply in the last round.
+		# TYPE ping_loss_ratio gauge
+		ping_loss_ratio 0.5
+		# HELP ping_packets_received_total Total number of echo replies received before the timeout.
+		# TYPE ping_packets_received_total counter
+		ping_packets_received_total 2
+		# HELP ping_packets_sent_total Total number of echo requests sent.
+		# TYPE ping_packets_sent_total counter
+		ping_packets_sent_total 4
+		# HELP ping_rtt_seconds Round-trip time of echo replies.
+		# TYPE ping_rtt_seconds histogram
+		ping_rtt_seconds_bucket{le="0.0005"} 0
+		ping_rtt_seconds_bucket{le="0.001"} 0
+		ping_rtt_seconds_bucket{le="0.0025"} 0
+		ping_rtt_seconds_bucket{le="0.005"} 2
+		ping_rtt_seconds_bucket{le="0.01"} 2
+		ping_rtt_seconds_bucket{le="0.025"} 2
+		ping_rtt_seconds_bucket{le="0.05"} 2
+		ping_rtt_seconds_bucket{le="0.1"} 2
+		ping_rtt_seconds_bucket{le="0.25"} 2
+		ping_rtt_seconds_bucket{le="0.5"} 2
+		ping_rtt_seconds_bucket{le="1"} 2
+		ping_rtt_seconds_bucket{le="+Inf"} 2
+		ping_rtt_seconds_sum 0.006
+		ping_rtt_seconds_count 2
+	`
+	require.NoError(t, testutil.ScrapeAndCompare(srv.URL+"?target=10.0.0.1:9100", strings.NewReader(expect),
+		"ping_loss_ratio", "ping_packets_received_total", "ping_packets_sent_total", "ping_rtt_seconds"))
+}
+
+func TestProbingPinger(t *testing.T) {
+	p := &probingPinger{logger: log.NewNopLogger(), privileged: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var replies int
+	sent, err := p.Ping(ctx, net.ParseIP("127.0.0.1"), 2, DefaultConfig.Size, func(time.Duration) { replies++ })
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "listen" {
+		t.Skipf("raw ICMP sockets are not available: %s", err)
+	}
+	require.NoError(t, err)
+	require.Equal(t, 2, sent)
+	require.Equal(t, 2, replies)
+}
@@ -0,0 +1,84 @@
+package ping_exporter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	probing "github.com/prometheus-community/pro-bing"
+)
+
+// packetInterval is the wait time between echo requests of a round. It's
+// short so that every request gets nearly the full timeout for its reply.
+const packetInterval = 10 * time.Millisecond
+
+// probingPinger sends echo requests with pro-bing, opening a socket for every
+// round.
+//
+// Unprivileged pingers use datagram ICMP sockets, which must be allowed by
+// the net.ipv4.ping_group_range sysctl on Linux. Privileged pingers use raw
+// sockets, which require CAP_NET_RAW.
+type probingPinger struct {
+	logger     log.Logger
+	privileged bool
+}
+
+var _ pinger = (*probingPinger)(nil)
+
+// Ping implements pinger.
+func (p *probingPinger) Ping(ctx context.Context, ip net.IP, count, size int, onReply func(rtt time.Duration)) (int, error) {
+	pp := probing.New("")
+	pp.SetIPAddr(&net.IPAddr{IP: ip})
+	pp.SetPrivileged(p.privileged)
+	pp.SetLogger(probingLogger{p.logger})
+	pp.Count = count
+	pp.Size = size
+	pp.Interval = packetInterval
+	pp.RecordRtts = false
+	pp.OnRecv = func(pkt *probing.Packet) { onReply(pkt.Rtt) }
+	if deadline, ok := ctx.Deadline(); ok {
+		pp.Timeout = time.Until(deadline)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pp.Stop()
+		case <-done:
+		}
+	}()
+
+	err := pp.Run()
+	return pp.PacketsSent, err
+}
+
+// probingLogger logs the messages of pro-bing to a go-kit logger.
+type probingLogger struct {
+	logger log.Logger
+}
+
+func (l probingLogger) Fatalf(format string, v ...interface{}) {
+	// pro-bing keeps running after "fatal" errors for single packets.
+	level.Error(l.logger).Log("msg", fmt.Sprintf(format, v...))
+}
+
+func (l probingLogger) Errorf(format string, v ...interface{}) {
+	level.Error(l.logger).Log("msg", fmt.Sprintf(format, v...))
+}
+
+func (l probingLogger) Warnf(format string, v ...interface{}) {
+	level.Warn(l.logger).Log("msg", fmt.Sprintf(format, v...))
+}
+
+func (l probingLogger) Infof(format string, v ...interface{}) {
+	level.Info(l.logger).Log("msg", fmt.Sprintf(format, v...))
+}
+
+func (l probingLogger) Debugf(format string, v ...interface{}) {
+	level.Debug(l.logger).Log("msg", fmt.Sprintf(format, v...))
+}
@@ -0,0 +1,135 @@
+package ping_exporter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pinger sends rounds of ICMP echo requests.
+type pinger interface {
+	// Ping sends count echo requests with size bytes of payload to ip, and
+	// calls onReply with the round-trip time of every reply received before
+	// ctx is canceled. onReply isn't called concurrently. It returns the
+	// number of requests sent.
+	Ping(ctx context.Context, ip net.IP, count, size int, onReply func(rtt time.Duration)) (int, error)
+}
+
+// prober pings a single host every interval and records the results.
+type prober struct {
+	logger log.Logger
+	cfg    Config
+	pinger pinger
+	host   string
+
+	registry      *prometheus.Registry
+	rtt           prometheus.Histogram
+	sent          prometheus.Counter
+	received      prometheus.Counter
+	loss          prometheus.Gauge
+	resolveErrors prometheus.Counter
+}
+
+func newProber(l log.Logger, c Config, p pinger, host string) *prober {
+	pr := &prober{
+		logger: l,
+		cfg:    c,
+		pinger: p,
+		host:   host,
+
+		registry: prometheus.NewRegistry(),
+		rtt: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ping_rtt_seconds",
+			Help:    "Round-trip time of echo replies.",
+			Buckets: c.RTTBuckets,
+		}),
+		sent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ping_packets_sent_total",
+			Help: "Total number of echo requests sent.",
+		}),
+		received: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ping_packets_received_total",
+			Help: "Total number of echo replies received before the timeout.",
+		}),
+		loss: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ping_loss_ratio",
+			Help: "Ratio of echo requests without a reply in the last round.",
+		}),
+		resolveErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ping_resolve_errors_total",
+			Help: "Total number of failures to resolve the target.",
+		}),
+	}
+	pr.registry.MustRegister(pr.rtt, pr.sent, pr.received, pr.loss, pr.resolveErrors)
+	return pr
+}
+
+// run sends a round of pings every interval until ctx is canceled.
+func (p *prober) run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.round(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// round sends count echo requests to the host and waits for their replies.
+func (p *prober) round(ctx context.Context) {
+	ip, err := p.resolve(ctx)
+	if err != nil {
+		level.Debug(p.logger).Log("msg", "failed to resolve ping target", "target", p.host, "err", err)
+		p.resolveErrors.Inc()
+		return
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	var received int
+	sent, err := p.pinger.Ping(pingCtx, ip, p.cfg.Count, p.cfg.Size, func(rtt time.Duration) {
+		p.rtt.Observe(rtt.Seconds())
+		p.received.Inc()
+		received++
+	})
+	p.sent.Add(float64(sent))
+	if err != nil {
+		level.Debug(p.logger).Log("msg", "ping failed", "target", p.host, "err", err)
+	}
+
+	if ctx.Err() == nil {
+		p.loss.Set(1 - float64(received)/float64(p.cfg.Count))
+	}
+}
+
+// resolve returns the IP address of the host, preferring IPv4.
+func (p *prober) resolve(ctx context.Context) (net.IP, error) {
+	if ip := net.ParseIP(p.host); ip != nil {
+		return ip, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, p.host)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", p.host)
+	}
+	return addrs[0].IP, nil
+}
@@ -3,6 +3,7 @@ package snmp_exporter
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -24,11 +25,6 @@ func (sh *snmpHandler) handler(w http.ResponseWriter, r *http.Request) {
 
 	query := r.URL.Query()
 
-	snmpTargets := make(map[string]SNMPTarget)
-	for _, target := range sh.cfg.SnmpTargets {
-		snmpTargets[target.Name] = target
-	}
-
 	var target string
 	targetName := query.Get("target")
 	if len(query["target"]) != 1 || targetName == "" {
@@ -36,26 +32,44 @@ func (sh *snmpHandler) handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, ok := snmpTargets[targetName]
-	if ok {
+	// The target can either be given by its name or by its address, which is
+	// what the generated scrape configs use.
+	t, found := sh.findTarget(targetName)
+	if found {
 		target = t.Target
 	} else {
 		target = targetName
 	}
 
-	moduleName := query.Get("module")
+	moduleParam := query.Get("module")
 	if len(query["module"]) > 1 {
 		http.Error(w, "'module' parameter must only be specified once", 400)
 		return
 	}
-	if moduleName == "" {
-		moduleName = "if_mib"
+	if moduleParam == "" {
+		moduleParam = "if_mib"
 	}
 
-	module, ok := (*sh.modules)[moduleName]
-	if !ok {
-		http.Error(w, fmt.Sprintf("Unknown module '%s'", moduleName), 400)
-		return
+	// Several modules may be given as a comma-separated list.
+	var (
+		moduleNames []string
+		modules     []*snmp_config.Module
+		seen        = make(map[string]struct{})
+	)
+	for _, moduleName := range strings.Split(moduleParam, ",") {
+		module, ok := (*sh.modules)[moduleName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown module '%s'", moduleName), 400)
+			return
+		}
+		if _, ok := seen[moduleName]; ok {
+			continue
+		}
+		seen[moduleName] = struct{}{}
+		// Copy the module so that overrides don't leak into other scrapes.
+		m := *module
+		moduleNames = append(moduleNames, moduleName)
+		modules = append(modules, &m)
 	}
 
 	// override module connection details with custom walk params if provided
@@ -66,8 +80,12 @@ func (sh *snmpHandler) handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if walkParams != "" {
-		if wp, ok := sh.cfg.WalkParams[walkParams]; ok {
-			// module.WalkParams = wp
+		wp, ok := sh.cfg.WalkParams[walkParams]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown walk_params '%s'", walkParams), 400)
+			return
+		}
+		for _, module := range modules {
 			if wp.Version != 0 {
 				module.WalkParams.Version = wp.Version
 			}
@@ -81,20 +99,50 @@ func (sh *snmpHandler) handler(w http.ResponseWriter, r *http.Request) {
 				module.WalkParams.Timeout = wp.Timeout
 			}
 			module.WalkParams.Auth = wp.Auth
-		} else {
-			http.Error(w, fmt.Sprintf("Unknown walk_params '%s'", walkParams), 400)
-			return
 		}
-		logger = log.With(logger, "module", moduleName, "target", target, "walk_params", walkParams)
+		logger = log.With(logger, "module", moduleParam, "target", target, "walk_params", walkParams)
 	} else {
-		logger = log.With(logger, "module", moduleName, "target", target)
+		logger = log.With(logger, "module", moduleParam, "target", target)
+	}
+
+	// Settings of the target take precedence over both the modules and the
+	// walk params.
+	concurrency := 1
+	if found {
+		for _, module := range modules {
+			if t.Timeout != 0 {
+				module.WalkParams.Timeout = t.Timeout
+			}
+			if t.Retries != 0 {
+				module.WalkParams.Retries = t.Retries
+			}
+			if t.Auth != nil {
+				module.WalkParams.Auth = *t.Auth
+			}
+		}
+		if t.Concurrency > 0 {
+			concurrency = t.Concurrency
+		}
 	}
 	level.Debug(logger).Log("msg", "Starting scrape")
 
 	start := time.Now()
 	registry := prometheus.NewRegistry()
-	c := collector.New(r.Context(), target, module, logger)
-	registry.MustRegister(c)
+	// The registry collects every module at the same time; sem limits how many
+	// of them walk the target at once.
+	sem := make(chan struct{}, concurrency)
+	for i, module := range modules {
+		var reg prometheus.Registerer = registry
+		if len(modules) > 1 {
+			// Each module reports its own scrape metrics, which must be told
+			// apart.
+			reg = prometheus.WrapRegistererWith(prometheus.Labels{"module": moduleNames[i]}, registry)
+		}
+		reg.MustRegister(&limitedCollector{
+			Collector: collector.New(r.Context(), target, module, logger),
+			sem:       sem,
+		})
+	}
 	// Delegate http serving to Prometheus client library, which will call collector.Collect.
 	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
@@ -102,6 +150,35 @@ func (sh *snmpHandler) handler(w http.ResponseWriter, r *http.Request) {
 	level.Debug(logger).Log("msg", "Finished scrape", "duration_seconds", duration)
 }
 
+// findTarget returns the configured target with the given name or address.
+func (sh *snmpHandler) findTarget(nameOrAddress string) (SNMPTarget, bool) {
+	for _, target := range sh.cfg.SnmpTargets {
+		if target.Name == nameOrAddress {
+			return target, true
+		}
+	}
+	for _, target := range sh.cfg.SnmpTargets {
+		if target.Target == nameOrAddress {
+			return target, true
+		}
+	}
+	return SNMPTarget{}, false
+}
+
+// limitedCollector is a prometheus.Collector which holds a slot of sem while
+// collecting.
+type limitedCollector struct {
+	prometheus.Collector
+	sem chan struct{}
+}
+
+// Collect implements prometheus.Collector.
+func (c *limitedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+	c.Collector.Collect(ch)
+}
+
 func (sh snmpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	sh.handler(w, r)
 }
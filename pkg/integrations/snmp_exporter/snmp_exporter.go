@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/integrations"
@@ -27,6 +28,13 @@ type SNMPTarget struct {
 	Target     string `yaml:"address"`
 	Module     string `yaml:"module"`
 	WalkParams string `yaml:"walk_params,omitempty"`
+
+	// Settings which override the modules and walk params used for this
+	// target. Zero values keep the settings of the modules.
+	Concurrency int               `yaml:"concurrency,omitempty"`
+	Timeout     time.Duration     `yaml:"timeout,omitempty"`
+	Retries     int               `yaml:"retries,omitempty"`
+	Auth        *snmp_config.Auth `yaml:"auth,omitempty"`
 }
 
 // Config configures the SNMP integration.
@@ -85,6 +93,9 @@ func New(log log.Logger, c *Config) (integrations.Integration, error) {
 		if target.Name == "" || target.Target == "" {
 			return nil, fmt.Errorf("failed to load snmp_targets; the `name` and `address` fields are mandatory")
 		}
+		if target.Concurrency < 0 || target.Timeout < 0 || target.Retries < 0 {
+			return nil, fmt.Errorf("failed to load snmp_target %s; concurrency, timeout and retries must not be negative", target.Name)
+		}
 	}
 
 	sh := &snmpHandler{
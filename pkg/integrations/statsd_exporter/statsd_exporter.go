@@ -99,17 +99,19 @@ func init() {
 
 // Exporter defines the statsd_exporter integration.
 type Exporter struct {
-	cfg      *Config
-	reg      *prometheus.Registry
-	metrics  *Metrics
-	exporter *exporter.Exporter
-	log      log.Logger
+	cfg       *Config
+	reg       *prometheus.Registry // Metrics about the exporter itself.
+	mappedReg *prometheus.Registry // Metrics translated from statsd events.
+	metrics   *Metrics
+	exporter  *exporter.Exporter
+	log       log.Logger
 }
 
 // New creates a new statsd_exporter integration. The integration scrapes
 // metrics from a statsd process.
 func New(log log.Logger, c *Config) (integrations.Integration, error) {
 	reg := prometheus.NewRegistry()
+	mappedReg := prometheus.NewRegistry()
 
 	m, err := NewMetrics(reg)
 	if err != nil {
@@ -126,7 +128,7 @@ func New(log log.Logger, c *Config) (integrations.Integration, error) {
 	}
 
 	if c.MappingConfig != nil {
-		cfgBytes, err := yaml.Marshal(c.MappingConfig)
+		cfgBytes, err := marshalMappingConfig(c.MappingConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize mapping config: %w", err)
 		}
@@ -155,28 +157,76 @@ func New(log log.Logger, c *Config) (integrations.Integration, error) {
 		statsdMapper.UseCache(cache)
 	}
 
-	e := exporter.NewExporter(reg, statsdMapper, log, m.EventsActions, m.EventsUnmapped, m.ErrorEventStats, m.EventStats, m.ConflictingEventStats, m.MetricsCount)
+	e := exporter.NewExporter(mappedReg, statsdMapper, log, m.EventsActions, m.EventsUnmapped, m.ErrorEventStats, m.EventStats, m.ConflictingEventStats, m.MetricsCount)
 
 	if err := reg.Register(version.NewCollector("statsd_exporter")); err != nil {
 		return nil, fmt.Errorf("couldn't register version metrics: %w", err)
 	}
 
 	return &Exporter{
-		cfg:      c,
-		metrics:  m,
-		exporter: e,
-		reg:      reg,
-		log:      log,
+		cfg:       c,
+		metrics:   m,
+		exporter:  e,
+		reg:       reg,
+		mappedReg: mappedReg,
+		log:       log,
 	}, nil
 }
 
+// marshalMappingConfig serializes the mappings of m so they can be loaded by
+// another mapper. Fields with empty values are left out, since some of them
+// are rejected or conflict with other fields when unmarshaling a mapping.
+func marshalMappingConfig(m *mapper.MetricMapper) ([]byte, error) {
+	mappings := make([]map[string]interface{}, 0, len(m.Mappings))
+	for _, mapping := range m.Mappings {
+		bb, err := yaml.Marshal(mapping)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]interface{}
+		if err := yaml.Unmarshal(bb, &fields); err != nil {
+			return nil, err
+		}
+		for name, value := range fields {
+			if isEmptyYAML(value) {
+				delete(fields, name)
+			}
+		}
+		mappings = append(mappings, fields)
+	}
+
+	return yaml.Marshal(map[string]interface{}{
+		"defaults": m.Defaults,
+		"mappings": mappings,
+	})
+}
+
+func isEmptyYAML(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
 // MetricsHandler returns the HTTP handler for the integration.
 func (e *Exporter) MetricsHandler() (http.Handler, error) {
-	return promhttp.HandlerFor(e.reg, promhttp.HandlerOpts{
+	return promhttp.HandlerFor(prometheus.Gatherers{e.reg, e.mappedReg}, promhttp.HandlerOpts{
 		ErrorHandling: promhttp.ContinueOnError,
 	}), nil
 }
 
+// MappedMetrics returns a gatherer for the metrics translated from received
+// statsd events, excluding metrics about the exporter itself.
+func (e *Exporter) MappedMetrics() prometheus.Gatherer {
+	return e.mappedReg
+}
+
 // ScrapeConfigs satisfies Integration.ScrapeConfigs.
 func (e *Exporter) ScrapeConfigs() []config.ScrapeConfig {
 	return []config.ScrapeConfig{{JobName: e.cfg.Name(), MetricsPath: "/metrics"}}
@@ -198,10 +248,29 @@ func (e *Exporter) Run(ctx context.Context) error {
 		parser.EnableSignalFXParsing()
 	}
 
+	// The event queue flushes to events on a timer which can't be stopped, so
+	// events is never closed. Events are forwarded to the exporter through a
+	// separate channel which is closed once ctx is canceled, stopping it.
 	events := make(chan event.Events, e.cfg.EventQueueSize)
-	defer close(events)
 	eventQueue := event.NewEventQueue(events, e.cfg.EventFlushThreshold, e.cfg.EventFlushInterval, e.metrics.EventsFlushed)
 
+	exporterEvents := make(chan event.Events)
+	go func() {
+		defer close(exporterEvents)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-events:
+				select {
+				case exporterEvents <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
 	if e.cfg.ListenUDP != "" {
 		addr, err := address.UDPAddrFromString(e.cfg.ListenUDP)
 		if err != nil {
@@ -337,7 +406,7 @@ func (e *Exporter) Run(ctx context.Context) error {
 		}
 	}
 
-	go e.exporter.Listen(events)
+	go e.exporter.Listen(exporterEvents)
 
 	<-ctx.Done()
 	return nil
@@ -0,0 +1,176 @@
+package systemd_exporter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// client is the subset of the systemd D-Bus API used by the collector.
+type client interface {
+	ListUnitsByPatternsContext(ctx context.Context, states []string, patterns []string) ([]dbus.UnitStatus, error)
+	GetUnitTypePropertyContext(ctx context.Context, unit string, unitType string, propertyName string) (*dbus.Property, error)
+	Close()
+}
+
+// unitStates are the possible active states of a unit.
+var unitStates = []string{"active", "activating", "deactivating", "inactive", "failed"}
+
+var (
+	upDesc = prometheus.NewDesc(
+		"systemd_up",
+		"1 if systemd could be queried, 0 otherwise.",
+		nil, nil,
+	)
+	unitStateDesc = prometheus.NewDesc(
+		"systemd_unit_state",
+		"Systemd unit active state; 1 for the current state, 0 otherwise.",
+		[]string{"name", "state", "type"}, nil,
+	)
+	serviceRestartsDesc = prometheus.NewDesc(
+		"systemd_service_restart_total",
+		"Number of times the service was restarted automatically.",
+		[]string{"name"}, nil,
+	)
+	socketAcceptedDesc = prometheus.NewDesc(
+		"systemd_socket_accepted_connections_total",
+		"Total number of connections accepted by the socket.",
+		[]string{"name"}, nil,
+	)
+	socketCurrentDesc = prometheus.NewDesc(
+		"systemd_socket_current_connections",
+		"Number of connections currently open on the socket.",
+		[]string{"name"}, nil,
+	)
+	socketRefusedDesc = prometheus.NewDesc(
+		"systemd_socket_refused_connections_total",
+		"Total number of connections refused by the socket.",
+		[]string{"name"}, nil,
+	)
+	timerLastTriggerDesc = prometheus.NewDesc(
+		"systemd_timer_last_trigger_seconds",
+		"Unix timestamp of the last time the timer was triggered.",
+		[]string{"name"}, nil,
+	)
+)
+
+// collector collects metrics about systemd units over D-Bus. A new connection
+// is made for every scrape, so that the collector recovers from restarts of
+// systemd or the D-Bus daemon.
+type collector struct {
+	logger  log.Logger
+	connect func(context.Context) (client, error)
+	cfg     Config
+}
+
+func newCollector(l log.Logger, connect func(context.Context) (client, error), cfg Config) *collector {
+	return &collector{logger: l, connect: connect, cfg: cfg}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		upDesc, unitStateDesc, serviceRestartsDesc, socketAcceptedDesc,
+		socketCurrentDesc, socketRefusedDesc, timerLastTriggerDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	if err := c.collect(ctx, ch); err != nil {
+		level.Error(c.logger).Log("msg", "failed to collect systemd metrics", "err", err)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+}
+
+func (c *collector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd: %w", err)
+	}
+	defer conn.Close()
+
+	units, err := conn.ListUnitsByPatternsContext(ctx, nil, c.cfg.Units)
+	if err != nil {
+		return fmt.Errorf("failed to list units: %w", err)
+	}
+
+	for _, unit := range units {
+		if unit.LoadState != "loaded" || c.excluded(unit.Name) {
+			continue
+		}
+		unitType := unitType(unit.Name)
+
+		for _, state := range unitStates {
+			var v float64
+			if unit.ActiveState == state {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(unitStateDesc, prometheus.GaugeValue, v, unit.Name, state, unitType)
+		}
+
+		switch unitType {
+		case "service":
+			c.collectProperty(ctx, ch, conn, unit.Name, "Service", "NRestarts", serviceRestartsDesc, prometheus.CounterValue, 1)
+		case "socket":
+			c.collectProperty(ctx, ch, conn, unit.Name, "Socket", "NAccepted", socketAcceptedDesc, prometheus.CounterValue, 1)
+			c.collectProperty(ctx, ch, conn, unit.Name, "Socket", "NConnections", socketCurrentDesc, prometheus.GaugeValue, 1)
+			c.collectProperty(ctx, ch, conn, unit.Name, "Socket", "NRefused", socketRefusedDesc, prometheus.CounterValue, 1)
+		case "timer":
+			c.collectProperty(ctx, ch, conn, unit.Name, "Timer", "LastTriggerUSec", timerLastTriggerDesc, prometheus.GaugeValue, 1e-6)
+		}
+	}
+	return nil
+}
+
+// collectProperty emits the numeric property of a unit multiplied by scale.
+// Properties which can't be retrieved are skipped, since older versions of
+// systemd don't expose all of them.
+func (c *collector) collectProperty(ctx context.Context, ch chan<- prometheus.Metric, conn client, unit, unitType, name string, desc *prometheus.Desc, valueType prometheus.ValueType, scale float64) {
+	prop, err := conn.GetUnitTypePropertyContext(ctx, unit, unitType, name)
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "failed to get unit property", "unit", unit, "property", name, "err", err)
+		return
+	}
+
+	var v float64
+	switch val := prop.Value.Value().(type) {
+	case uint32:
+		v = float64(val)
+	case uint64:
+		v = float64(val)
+	default:
+		level.Debug(c.logger).Log("msg", "unexpected unit property type", "unit", unit, "property", name, "type", fmt.Sprintf("%T", val))
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, valueType, v*scale, unit)
+}
+
+// excluded reports whether the unit matches any of the exclude patterns.
+func (c *collector) excluded(name string) bool {
+	for _, pattern := range c.cfg.ExcludeUnits {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unitType returns the type of the unit from its name, such as "service" for
+// "nginx.service".
+func unitType(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,99 @@
+package systemd_exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/go-kit/log"
+	godbus "github.com/godbus/dbus/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	fc := &fakeClient{
+		units: []dbus.UnitStatus{
+			{Name: "nginx.service", LoadState: "loaded", ActiveState: "failed"},
+			{Name: "backup.timer", LoadState: "loaded", ActiveState: "active"},
+			{Name: "backup.socket", LoadState: "loaded", ActiveState: "active"},
+			{Name: "missing.service", LoadState: "not-found", ActiveState: "inactive"},
+		},
+		properties: map[string]interface{}{
+			"nginx.service/NRestarts":      uint32(4),
+			"backup.timer/LastTriggerUSec": uint64(1600000000000000),
+		},
+	}
+	c := newCollector(log.NewNopLogger(), func(context.Context) (client, error) {
+		return fc, nil
+	}, Config{
+		Units:        []string{"nginx.service", "backup.*"},
+		ExcludeUnits: []string{"backup.socket"},
+		Timeout:      time.Second,
+	})
+
+	expect := `
+# HELP systemd_service_restart_total Number of times the service was restarted automatically.
+# TYPE systemd_service_restart_total counter
+systemd_service_restart_total{name="nginx.service"} 4
+# HELP systemd_timer_last_trigger_seconds Unix timestamp of the last time the timer was triggered.
+# TYPE systemd_timer_last_trigger_seconds gauge
+systemd_timer_last_trigger_seconds{name="backup.timer"} 1.6e+09
+# HELP systemd_unit_state Systemd unit active state; 1 for the current state, 0 otherwise.
+# TYPE systemd_unit_state gauge
+systemd_unit_state{name="backup.timer",state="activating",type="timer"} 0
+systemd_unit_state{name="backup.timer",state="active",type="timer"} 1
+systemd_unit_state{name="backup.timer",state="deactivating",type="timer"} 0
+systemd_unit_state{name="backup.timer",state="failed",type="timer"} 0
+systemd_unit_state{name="backup.timer",state="inactive",type="timer"} 0
+systemd_unit_state{name="nginx.service",state="activating",type="service"} 0
+systemd_unit_state{name="nginx.service",state="active",type="service"} 0
+systemd_unit_state{name="nginx.service",state="deactivating",type="service"} 0
+systemd_unit_state{name="nginx.service",state="failed",type="service"} 1
+systemd_unit_state{name="nginx.service",state="inactive",type="service"} 0
+# HELP systemd_up 1 if systemd could be queried, 0 otherwise.
+# TYPE systemd_up gauge
+systemd_up 1
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect)))
+	require.Equal(t, []string{"nginx.service", "backup.*"}, fc.patterns)
+	require.True(t, fc.closed)
+}
+
+func TestCollector_ConnectError(t *testing.T) {
+	c := newCollector(log.NewNopLogger(), func(context.Context) (client, error) {
+		return nil, errors.New("no such file or directory")
+	}, DefaultConfig)
+
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP systemd_up 1 if systemd could be queried, 0 otherwise.
+# TYPE systemd_up gauge
+systemd_up 0
+`)))
+}
+
+type fakeClient struct {
+	units      []dbus.UnitStatus
+	properties map[string]interface{}
+
+	patterns []string
+	closed   bool
+}
+
+func (c *fakeClient) ListUnitsByPatternsContext(_ context.Context, _ []string, patterns []string) ([]dbus.UnitStatus, error) {
+	c.patterns = patterns
+	return c.units, nil
+}
+
+func (c *fakeClient) GetUnitTypePropertyContext(_ context.Context, unit string, _ string, name string) (*dbus.Property, error) {
+	v, ok := c.properties[unit+"/"+name]
+	if !ok {
+		return nil, errors.New("unknown property")
+	}
+	return &dbus.Property{Name: name, Value: godbus.MakeVariant(v)}, nil
+}
+
+func (c *fakeClient) Close() { c.closed = true }
@@ -0,0 +1,47 @@
+// Package systemd_exporter collects metrics about systemd units over D-Bus,
+// using the metric names of
+// https://github.com/prometheus-community/systemd_exporter so that its
+// dashboards can be reused.
+//
+// systemd_exporter itself isn't embedded: its collector lives in the main
+// package and can't be imported, and the module isn't available from the
+// module proxy used to build the agent. The integration backs the
+// prometheus.exporter.systemd Flow component and isn't registered as a static
+// mode integration.
+package systemd_exporter //nolint:golint
+
+import (
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+)
+
+// DefaultConfig holds the default settings for the systemd integration.
+var DefaultConfig = Config{
+	Timeout: 5 * time.Second,
+}
+
+// Config controls the systemd integration.
+type Config struct {
+	// Units holds glob patterns of the units to collect metrics from. All
+	// loaded units are used when empty.
+	Units []string
+
+	// ExcludeUnits holds glob patterns of units to ignore, even when they
+	// match Units.
+	ExcludeUnits []string
+
+	// Timeout is the maximum time to spend querying systemd per scrape.
+	Timeout time.Duration
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "systemd"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
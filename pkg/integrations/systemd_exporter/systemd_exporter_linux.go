@@ -0,0 +1,20 @@
+package systemd_exporter
+
+import (
+	"context"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+)
+
+// New creates a new systemd integration.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	connect := func(ctx context.Context) (client, error) {
+		return dbus.NewWithContext(ctx)
+	}
+	return integrations.NewCollectorIntegration(
+		c.Name(),
+		integrations.WithCollectors(newCollector(logger, connect, *c)),
+	), nil
+}
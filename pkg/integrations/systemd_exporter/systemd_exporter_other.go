@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package systemd_exporter
+
+import (
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/integrations"
+)
+
+// New creates an integration which collects nothing, since systemd only runs on
+// Linux.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	level.Warn(logger).Log("msg", "the systemd exporter only works on Linux; enabling it otherwise will do nothing")
+	return integrations.NewCollectorIntegration(c.Name()), nil
+}
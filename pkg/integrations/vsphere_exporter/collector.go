@@ -0,0 +1,398 @@
+package vsphere_exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Performance counters queried for hosts and VMs.
+var (
+	hostCounters = []string{
+		"cpu.usage.average",
+		"cpu.usagemhz.average",
+		"mem.usage.average",
+		"mem.consumed.average",
+		"net.usage.average",
+		"disk.usage.average",
+	}
+	vmCounters = []string{
+		"cpu.usage.average",
+		"cpu.ready.summation",
+		"mem.usage.average",
+		"mem.active.average",
+		"net.usage.average",
+		"disk.usage.average",
+	}
+)
+
+// collectTimeout bounds the time spent querying vCenter per scrape.
+const collectTimeout = time.Minute
+
+// perfBatchSize is the number of entities queried at once, to stay below the
+// vpxd.stats.maxQueryMetrics limit of vCenter.
+const perfBatchSize = 32
+
+var (
+	hostLabels      = []string{"host", "cluster", "datacenter"}
+	vmLabels        = []string{"vm", "host", "cluster", "datacenter"}
+	datastoreLabels = []string{"datastore", "datacenter"}
+
+	upDesc = prometheus.NewDesc(
+		"vsphere_up",
+		"1 if vCenter could be queried, 0 otherwise.",
+		nil, nil,
+	)
+	vmPowerStateDesc = prometheus.NewDesc(
+		"vsphere_vm_power_state",
+		"1 if the VM is powered on, 0 otherwise.",
+		vmLabels, nil,
+	)
+	datastoreCapacityDesc = prometheus.NewDesc(
+		"vsphere_datastore_capacity_bytes",
+		"Capacity of the datastore.",
+		datastoreLabels, nil,
+	)
+	datastoreFreeDesc = prometheus.NewDesc(
+		"vsphere_datastore_free_bytes",
+		"Free space of the datastore.",
+		datastoreLabels, nil,
+	)
+)
+
+// collector collects metrics from vCenter. The session with vCenter is kept
+// between scrapes and only renewed when it expires, and the inventory of
+// hosts, VMs, and datastores is cached for InventoryRefreshInterval.
+type collector struct {
+	logger   log.Logger
+	cfg      Config
+	url      *url.URL
+	userinfo *url.Userinfo
+
+	mut       sync.Mutex
+	client    *govmomi.Client
+	counters  map[string]*types.PerfCounterInfo
+	inventory *inventory
+}
+
+// inventory holds the entities of vCenter along with their labels.
+type inventory struct {
+	fetched    time.Time
+	hosts      []entity
+	vms        []entity
+	datastores []entity
+}
+
+// entity is a host, VM, or datastore.
+type entity struct {
+	ref       types.ManagedObjectReference
+	labels    []string
+	poweredOn bool // Only set for VMs.
+}
+
+func newCollector(l log.Logger, cfg Config) (*collector, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vSphere URL: %w", err)
+	}
+	u.User = nil
+
+	return &collector{
+		logger:   l,
+		cfg:      cfg,
+		url:      u,
+		userinfo: url.UserPassword(cfg.Username, string(cfg.Password)),
+	}, nil
+}
+
+// Describe implements prometheus.Collector. The collector is unchecked, since
+// the metrics of performance counters are only known once vCenter is queried.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	if err := c.collect(ctx, ch); err != nil {
+		level.Error(c.logger).Log("msg", "failed to collect vSphere metrics", "err", err)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+}
+
+func (c *collector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	client, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.inventory == nil || time.Since(c.inventory.fetched) >= c.cfg.InventoryRefreshInterval {
+		inv, err := fetchInventory(ctx, client)
+		if err != nil {
+			return fmt.Errorf("failed to fetch inventory: %w", err)
+		}
+		c.inventory = inv
+	}
+
+	if c.cfg.EnableHostMetrics {
+		if err := c.collectPerf(ctx, client, ch, "host", hostLabels, hostCounters, c.inventory.hosts); err != nil {
+			return err
+		}
+	}
+	if c.cfg.EnableVMMetrics {
+		var poweredOn []entity
+		for _, vm := range c.inventory.vms {
+			var v float64
+			if vm.poweredOn {
+				v = 1
+				poweredOn = append(poweredOn, vm)
+			}
+			ch <- prometheus.MustNewConstMetric(vmPowerStateDesc, prometheus.GaugeValue, v, vm.labels...)
+		}
+		// Powered off VMs don't have performance counters.
+		if err := c.collectPerf(ctx, client, ch, "vm", vmLabels, vmCounters, poweredOn); err != nil {
+			return err
+		}
+	}
+	if c.cfg.EnableDatastoreMetrics {
+		if err := collectDatastores(ctx, client, ch, c.inventory.datastores); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// session returns a logged in client, reusing the session of previous
+// scrapes when it's still valid.
+func (c *collector) session(ctx context.Context) (*govmomi.Client, error) {
+	if c.client != nil {
+		if s, err := c.client.SessionManager.UserSession(ctx); err == nil && s != nil {
+			return c.client, nil
+		}
+		level.Debug(c.logger).Log("msg", "vCenter session expired, logging in again")
+		if err := c.client.Login(ctx, c.userinfo); err == nil {
+			return c.client, nil
+		}
+		c.client, c.counters = nil, nil
+	}
+
+	client, err := govmomi.NewClient(ctx, c.url, c.cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to vCenter: %w", err)
+	}
+	if err := client.Login(ctx, c.userinfo); err != nil {
+		return nil, fmt.Errorf("failed to log in to vCenter: %w", err)
+	}
+	c.client = client
+	return client, nil
+}
+
+// fetchInventory retrieves the hosts, VMs, and datastores of vCenter, and
+// resolves the cluster and datacenter each of them belongs to.
+func fetchInventory(ctx context.Context, client *govmomi.Client) (*inventory, error) {
+	m := view.NewManager(client.Client)
+	v, err := m.CreateContainerView(ctx, client.ServiceContent.RootFolder, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = v.Destroy(context.Background()) }()
+
+	var entities []mo.ManagedEntity
+	if err := v.Retrieve(ctx, []string{"ManagedEntity"}, []string{"name", "parent"}, &entities); err != nil {
+		return nil, err
+	}
+	byRef := make(map[types.ManagedObjectReference]mo.ManagedEntity, len(entities))
+	for _, e := range entities {
+		byRef[e.Self] = e
+	}
+
+	// ancestor returns the name of the closest ancestor of ref with the given
+	// type.
+	ancestor := func(ref types.ManagedObjectReference, typ string) string {
+		for {
+			e, ok := byRef[ref]
+			if !ok || e.Parent == nil {
+				return ""
+			}
+			ref = *e.Parent
+			if ref.Type == typ {
+				return byRef[ref].Name
+			}
+		}
+	}
+
+	inv := &inventory{fetched: time.Now()}
+	for _, e := range entities {
+		switch e.Self.Type {
+		case "HostSystem":
+			inv.hosts = append(inv.hosts, entity{
+				ref:    e.Self,
+				labels: []string{e.Name, ancestor(e.Self, "ClusterComputeResource"), ancestor(e.Self, "Datacenter")},
+			})
+		case "Datastore":
+			inv.datastores = append(inv.datastores, entity{
+				ref:    e.Self,
+				labels: []string{e.Name, ancestor(e.Self, "Datacenter")},
+			})
+		}
+	}
+
+	var vms []mo.VirtualMachine
+	if err := v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"name", "runtime.host", "runtime.powerState"}, &vms); err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		var host, cluster, datacenter string
+		if vm.Runtime.Host != nil {
+			host = byRef[*vm.Runtime.Host].Name
+			cluster = ancestor(*vm.Runtime.Host, "ClusterComputeResource")
+			datacenter = ancestor(*vm.Runtime.Host, "Datacenter")
+		}
+		inv.vms = append(inv.vms, entity{
+			ref:       vm.Self,
+			labels:    []string{vm.Name, host, cluster, datacenter},
+			poweredOn: vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn,
+		})
+	}
+	return inv, nil
+}
+
+// collectPerf emits the latest real-time sample of counters for entities.
+func (c *collector) collectPerf(ctx context.Context, client *govmomi.Client, ch chan<- prometheus.Metric, kind string, labels []string, counters []string, entities []entity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	pm := performance.NewManager(client.Client)
+	if c.counters == nil {
+		info, err := pm.CounterInfoByName(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get performance counters: %w", err)
+		}
+		c.counters = info
+	}
+
+	descs := make(map[string]*prometheus.Desc, len(counters))
+	for _, name := range counters {
+		info, ok := c.counters[name]
+		if !ok {
+			continue
+		}
+		descs[name] = prometheus.NewDesc(
+			"vsphere_"+kind+"_"+strings.ReplaceAll(name, ".", "_"),
+			info.NameInfo.GetElementDescription().Summary,
+			labels, nil,
+		)
+	}
+
+	byRef := make(map[types.ManagedObjectReference]entity, len(entities))
+	for _, e := range entities {
+		byRef[e.ref] = e
+	}
+
+	spec := types.PerfQuerySpec{
+		IntervalId: 20, // Real-time statistics.
+		MaxSample:  1,
+		MetricId:   []types.PerfMetricId{{Instance: ""}},
+	}
+	for start := 0; start < len(entities); start += perfBatchSize {
+		end := start + perfBatchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+		refs := make([]types.ManagedObjectReference, 0, end-start)
+		for _, e := range entities[start:end] {
+			refs = append(refs, e.ref)
+		}
+
+		samples, err := pm.SampleByName(ctx, spec, counters, refs)
+		if err != nil {
+			return fmt.Errorf("failed to query %s performance counters: %w", kind, err)
+		}
+		series, err := pm.ToMetricSeries(ctx, samples)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range series {
+			e, ok := byRef[s.Entity]
+			if !ok {
+				continue
+			}
+			for _, v := range s.Value {
+				desc, ok := descs[v.Name]
+				if !ok || len(v.Value) == 0 {
+					continue
+				}
+				value := float64(v.Value[len(v.Value)-1])
+				if c.counters[v.Name].UnitInfo.GetElementDescription().Key == string(types.PerformanceManagerUnitPercent) {
+					// Percentages are reported in hundredths of a percent.
+					value /= 100
+				}
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, e.labels...)
+			}
+		}
+	}
+	return nil
+}
+
+// collectDatastores emits the capacity and free space of datastores.
+func collectDatastores(ctx context.Context, client *govmomi.Client, ch chan<- prometheus.Metric, datastores []entity) error {
+	if len(datastores) == 0 {
+		return nil
+	}
+
+	refs := make([]types.ManagedObjectReference, 0, len(datastores))
+	byRef := make(map[types.ManagedObjectReference]entity, len(datastores))
+	for _, ds := range datastores {
+		refs = append(refs, ds.ref)
+		byRef[ds.ref] = ds
+	}
+
+	var res []mo.Datastore
+	if err := client.Retrieve(ctx, refs, []string{"summary"}, &res); err != nil {
+		return fmt.Errorf("failed to retrieve datastores: %w", err)
+	}
+	for _, ds := range res {
+		e, ok := byRef[ds.Self]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(datastoreCapacityDesc, prometheus.GaugeValue, float64(ds.Summary.Capacity), e.labels...)
+		ch <- prometheus.MustNewConstMetric(datastoreFreeDesc, prometheus.GaugeValue, float64(ds.Summary.FreeSpace), e.labels...)
+	}
+	return nil
+}
+
+// Close logs out of vCenter.
+func (c *collector) Close() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.client == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.client.Logout(ctx); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to log out of vCenter", "err", err)
+	}
+	c.client = nil
+}
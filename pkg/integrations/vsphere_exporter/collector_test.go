@@ -0,0 +1,73 @@
+package vsphere_exporter
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/simulator"
+)
+
+func TestCollector(t *testing.T) {
+	model := simulator.VPX()
+	require.NoError(t, model.Create())
+	t.Cleanup(model.Remove)
+
+	srv := model.Service.NewServer()
+	t.Cleanup(srv.Close)
+
+	password, _ := simulator.DefaultLogin.Password()
+	cfg := DefaultConfig
+	cfg.URL = srv.URL.String()
+	cfg.Username = simulator.DefaultLogin.Username()
+	cfg.Password = config_util.Secret(password)
+	cfg.InsecureSkipVerify = true
+
+	c, err := newCollector(log.NewNopLogger(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families := gather(t, reg)
+	require.Equal(t, 1.0, families["vsphere_up"].Metric[0].GetGauge().GetValue())
+	require.NotEmpty(t, families["vsphere_host_cpu_usage_average"].GetMetric())
+	require.NotEmpty(t, families["vsphere_vm_cpu_usage_average"].GetMetric())
+	require.NotEmpty(t, families["vsphere_vm_power_state"].GetMetric())
+	require.NotEmpty(t, families["vsphere_datastore_capacity_bytes"].GetMetric())
+
+	// Hosts in clusters are labeled with their cluster and datacenter.
+	var clustered bool
+	for _, m := range families["vsphere_host_cpu_usage_average"].Metric {
+		labels := map[string]string{}
+		for _, l := range m.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		require.Equal(t, "DC0", labels["datacenter"])
+		clustered = clustered || labels["cluster"] == "DC0_C0"
+	}
+	require.True(t, clustered)
+
+	// The session and inventory are reused by later scrapes.
+	client, inv := c.client, c.inventory
+	gather(t, reg)
+	require.Same(t, client, c.client)
+	require.Same(t, inv, c.inventory)
+}
+
+func gather(t *testing.T, g prometheus.Gatherer) map[string]*dto.MetricFamily {
+	t.Helper()
+
+	mfs, err := g.Gather()
+	require.NoError(t, err)
+
+	res := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		res[mf.GetName()] = mf
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+// Package vsphere_exporter collects host, VM, and datastore metrics from
+// vCenter with govmomi, using the metric names of
+// https://github.com/pryorda/vsphere_exporter so that its dashboards can be
+// reused.
+//
+// vsphere_exporter itself isn't embedded: its collectors live in the main
+// package and can't be imported, and the module isn't available from the
+// module proxy used to build the agent. The integration backs the
+// prometheus.exporter.vsphere Flow component and isn't registered as a static
+// mode integration.
+package vsphere_exporter //nolint:golint
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	config_util "github.com/prometheus/common/config"
+)
+
+// DefaultConfig holds the default settings for the vsphere integration.
+var DefaultConfig = Config{
+	EnableHostMetrics:        true,
+	EnableVMMetrics:          true,
+	EnableDatastoreMetrics:   true,
+	InventoryRefreshInterval: 5 * time.Minute,
+}
+
+// Config controls the vsphere integration.
+type Config struct {
+	// URL is the URL of the vCenter SDK endpoint, such as
+	// https://vcenter.example.com/sdk.
+	URL                string
+	Username           string
+	Password           config_util.Secret
+	InsecureSkipVerify bool
+
+	EnableHostMetrics      bool
+	EnableVMMetrics        bool
+	EnableDatastoreMetrics bool
+
+	// InventoryRefreshInterval is how often the names and placement of hosts,
+	// VMs, and datastores are refreshed. Performance counters are queried on
+	// every scrape.
+	InventoryRefreshInterval time.Duration
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "vsphere"
+}
+
+// NewIntegration converts the config into an integration instance.
+func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	return New(logger, c)
+}
+
+// New creates a new vsphere integration. The session with vCenter is logged
+// out when the integration stops.
+func New(logger log.Logger, c *Config) (integrations.Integration, error) {
+	col, err := newCollector(logger, *c)
+	if err != nil {
+		return nil, err
+	}
+
+	return integrations.NewCollectorIntegration(
+		c.Name(),
+		integrations.WithCollectors(col),
+		integrations.WithRunner(func(ctx context.Context) error {
+			<-ctx.Done()
+			col.Close()
+			return ctx.Err()
+		}),
+	), nil
+}
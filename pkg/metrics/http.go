@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,6 +11,8 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/pkg/metrics/cluster/configapi"
+	"github.com/grafana/agent/pkg/metrics/instance"
+	"github.com/grafana/agent/pkg/metrics/wal"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/scrape"
@@ -27,6 +30,11 @@ func (a *Agent) WireAPI(r *mux.Router) {
 	r.HandleFunc("/agent/api/v1/metrics/instances", a.ListInstancesHandler).Methods("GET")
 	r.HandleFunc("/agent/api/v1/metrics/targets", a.ListTargetsHandler).Methods("GET")
 	r.HandleFunc("/agent/api/v1/metrics/instance/{instance}/write", a.PushMetricsHandler).Methods("POST")
+
+	r.HandleFunc("/agent/api/v1/metrics/wal", a.ListWALHandler).Methods("GET")
+	r.HandleFunc("/agent/api/v1/metrics/instance/{instance}/wal", a.WALHandler).Methods("GET")
+	r.HandleFunc("/agent/api/v1/metrics/instance/{instance}/wal/checkpoint", a.CheckpointWALHandler).Methods("POST")
+	r.HandleFunc("/agent/api/v1/metrics/instance/{instance}/wal/truncate_frequency", a.WALTruncateFrequencyHandler).Methods("POST")
 }
 
 // ListInstancesHandler writes the set of currently running instances to the http.ResponseWriter.
@@ -166,3 +174,137 @@ func getInstanceName(r *http.Request) (string, error) {
 	}
 	return name, nil
 }
+
+// WALStatus describes the WAL of an instance.
+type WALStatus struct {
+	InstanceName      string `json:"instance"`
+	TruncateFrequency string `json:"truncate_frequency"`
+	wal.Stats
+}
+
+// ListWALHandler writes the status of the WAL of every running instance to
+// the http.ResponseWriter.
+func (a *Agent) ListWALHandler(w http.ResponseWriter, _ *http.Request) {
+	instances := a.mm.ListInstances()
+	resp := make([]WALStatus, 0, len(instances))
+	for name, inst := range instances {
+		wm, ok := inst.(instance.WALManager)
+		if !ok {
+			continue
+		}
+		status, err := getWALStatus(name, wm)
+		if errors.Is(err, instance.ErrWALNotReady) {
+			continue
+		} else if err != nil {
+			_ = configapi.WriteError(w, http.StatusInternalServerError, fmt.Errorf("instance %s: %w", name, err))
+			return
+		}
+		resp = append(resp, status)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].InstanceName < resp[j].InstanceName })
+
+	err := configapi.WriteResponse(w, http.StatusOK, resp)
+	if err != nil {
+		level.Error(a.logger).Log("msg", "failed to write response", "err", err)
+	}
+}
+
+// WALHandler writes the status of the WAL of an instance to the
+// http.ResponseWriter.
+func (a *Agent) WALHandler(w http.ResponseWriter, r *http.Request) {
+	name, wm, ok := a.getWALManager(w, r)
+	if !ok {
+		return
+	}
+	a.writeWALStatus(w, name, wm)
+}
+
+// CheckpointWALHandler immediately checkpoints the WAL of an instance,
+// reclaiming disk space without waiting for the next truncation.
+func (a *Agent) CheckpointWALHandler(w http.ResponseWriter, r *http.Request) {
+	name, wm, ok := a.getWALManager(w, r)
+	if !ok {
+		return
+	}
+	if err := wm.CheckpointWAL(); err != nil {
+		writeWALError(w, err)
+		return
+	}
+	a.writeWALStatus(w, name, wm)
+}
+
+// WALTruncateFrequencyHandler overrides how often the WAL of an instance is
+// truncated until the instance is restarted. The new frequency is read from
+// the "frequency" form value; "0s" reverts to the configured frequency.
+func (a *Agent) WALTruncateFrequencyHandler(w http.ResponseWriter, r *http.Request) {
+	name, wm, ok := a.getWALManager(w, r)
+	if !ok {
+		return
+	}
+
+	frequency, err := model.ParseDuration(r.FormValue("frequency"))
+	if err != nil {
+		_ = configapi.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid frequency: %w", err))
+		return
+	}
+	if err := wm.SetWALTruncateFrequency(time.Duration(frequency)); err != nil {
+		_ = configapi.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	level.Info(a.logger).Log("msg", "WAL truncate frequency overridden", "instance", name, "frequency", frequency)
+	a.writeWALStatus(w, name, wm)
+}
+
+// getWALManager returns the WALManager of the instance requested by r. If
+// it can't be found, an error is written to w and ok is false.
+func (a *Agent) getWALManager(w http.ResponseWriter, r *http.Request) (name string, wm instance.WALManager, ok bool) {
+	name, err := getInstanceName(r)
+	if err != nil {
+		_ = configapi.WriteError(w, http.StatusBadRequest, err)
+		return "", nil, false
+	}
+
+	inst, err := a.InstanceManager().GetInstance(name)
+	if err != nil || inst == nil {
+		_ = configapi.WriteError(w, http.StatusNotFound, fmt.Errorf("instance %s not found", name))
+		return "", nil, false
+	}
+	wm, ok = inst.(instance.WALManager)
+	if !ok {
+		_ = configapi.WriteError(w, http.StatusNotImplemented, fmt.Errorf("instance %s does not support managing its WAL", name))
+		return "", nil, false
+	}
+	return name, wm, true
+}
+
+func (a *Agent) writeWALStatus(w http.ResponseWriter, name string, wm instance.WALManager) {
+	status, err := getWALStatus(name, wm)
+	if err != nil {
+		writeWALError(w, err)
+		return
+	}
+	err = configapi.WriteResponse(w, http.StatusOK, status)
+	if err != nil {
+		level.Error(a.logger).Log("msg", "failed to write response", "err", err)
+	}
+}
+
+func getWALStatus(name string, wm instance.WALManager) (WALStatus, error) {
+	stats, err := wm.WALStats()
+	if err != nil {
+		return WALStatus{}, err
+	}
+	return WALStatus{
+		InstanceName:      name,
+		TruncateFrequency: model.Duration(wm.WALTruncateFrequency()).String(),
+		Stats:             stats,
+	}, nil
+}
+
+func writeWALError(w http.ResponseWriter, err error) {
+	if errors.Is(err, instance.ErrWALNotReady) || errors.Is(err, wal.ErrWALClosed) {
+		_ = configapi.WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	_ = configapi.WriteError(w, http.StatusInternalServerError, err)
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/util/test"
 	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
 	"github.com/grafana/agent/pkg/metrics/instance"
+	"github.com/grafana/agent/pkg/metrics/wal"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -140,3 +142,131 @@ type mockInstanceScrape struct {
 func (i *mockInstanceScrape) TargetsActive() map[string][]*scrape.Target {
 	return i.tgts
 }
+
+func TestAgent_WALHandlers(t *testing.T) {
+	fact := newFakeInstanceFactory()
+	a, err := newAgent(prometheus.NewRegistry(), Config{
+		WALDir: "/tmp/agent",
+	}, log.NewNopLogger(), fact.factory)
+	require.NoError(t, err)
+
+	inst := &mockInstanceWAL{frequency: time.Minute}
+	mockManager := &instance.MockManager{
+		GetInstanceFunc: func(name string) (instance.ManagedInstance, error) {
+			switch name {
+			case "wal_instance":
+				return inst, nil
+			case "noop_instance":
+				return instance.NoOpInstance{}, nil
+			}
+			return nil, fmt.Errorf("instance %s does not exist", name)
+		},
+		ListInstancesFunc: func() map[string]instance.ManagedInstance {
+			return map[string]instance.ManagedInstance{
+				"wal_instance":  inst,
+				"noop_instance": instance.NoOpInstance{},
+			}
+		},
+		ListConfigsFunc:  func() map[string]instance.Config { return nil },
+		ApplyConfigFunc:  func(_ instance.Config) error { return nil },
+		DeleteConfigFunc: func(name string) error { return nil },
+		StopFunc:         func() {},
+	}
+	a.mm, err = instance.NewModalManager(prometheus.NewRegistry(), a.logger, mockManager, instance.ModeDistinct)
+	require.NoError(t, err)
+
+	request := func(handler http.HandlerFunc, method, name, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/agent/api/v1/metrics/instance/"+name+"/wal"+query, nil)
+		r = mux.SetURLVars(r, map[string]string{"instance": name})
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		return rr
+	}
+	expect := func(frequency string) string {
+		return `{
+			"status": "success",
+			"data": {
+				"instance": "wal_instance",
+				"truncate_frequency": "` + frequency + `",
+				"directory": "/tmp/agent/wal_instance/wal",
+				"size_bytes": 2048,
+				"segments": [{"index": 3, "size_bytes": 2048, "last_modified": "1994-01-12T00:00:00Z", "age_seconds": 60}]
+			}
+		}`
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		a.ListWALHandler(rr, httptest.NewRequest("GET", "/agent/api/v1/metrics/wal", nil))
+		require.Equal(t, http.StatusOK, rr.Result().StatusCode)
+		require.JSONEq(t, `{
+			"status": "success",
+			"data": [{
+				"instance": "wal_instance",
+				"truncate_frequency": "1m",
+				"directory": "/tmp/agent/wal_instance/wal",
+				"size_bytes": 2048,
+				"segments": [{"index": 3, "size_bytes": 2048, "last_modified": "1994-01-12T00:00:00Z", "age_seconds": 60}]
+			}]
+		}`, rr.Body.String())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rr := request(a.WALHandler, "GET", "wal_instance", "")
+		require.Equal(t, http.StatusOK, rr.Result().StatusCode)
+		require.JSONEq(t, expect("1m"), rr.Body.String())
+
+		rr = request(a.WALHandler, "GET", "missing_instance", "")
+		require.Equal(t, http.StatusNotFound, rr.Result().StatusCode)
+
+		rr = request(a.WALHandler, "GET", "noop_instance", "")
+		require.Equal(t, http.StatusNotImplemented, rr.Result().StatusCode)
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		rr := request(a.CheckpointWALHandler, "POST", "wal_instance", "/checkpoint")
+		require.Equal(t, http.StatusOK, rr.Result().StatusCode)
+		require.Equal(t, 1, inst.checkpoints)
+	})
+
+	t.Run("truncate frequency", func(t *testing.T) {
+		rr := request(a.WALTruncateFrequencyHandler, "POST", "wal_instance", "/truncate_frequency?frequency=5m")
+		require.Equal(t, http.StatusOK, rr.Result().StatusCode)
+		require.JSONEq(t, expect("5m"), rr.Body.String())
+
+		rr = request(a.WALTruncateFrequencyHandler, "POST", "wal_instance", "/truncate_frequency?frequency=soon")
+		require.Equal(t, http.StatusBadRequest, rr.Result().StatusCode)
+		require.Equal(t, 5*time.Minute, inst.frequency)
+	})
+}
+
+type mockInstanceWAL struct {
+	instance.NoOpInstance
+	frequency   time.Duration
+	checkpoints int
+}
+
+func (i *mockInstanceWAL) WALStats() (wal.Stats, error) {
+	return wal.Stats{
+		Directory: "/tmp/agent/wal_instance/wal",
+		SizeBytes: 2048,
+		Segments: []wal.SegmentStats{{
+			Index:        3,
+			SizeBytes:    2048,
+			LastModified: time.Date(1994, time.January, 12, 0, 0, 0, 0, time.UTC),
+			AgeSeconds:   60,
+		}},
+	}, nil
+}
+
+func (i *mockInstanceWAL) CheckpointWAL() error {
+	i.checkpoints++
+	return nil
+}
+
+func (i *mockInstanceWAL) WALTruncateFrequency() time.Duration { return i.frequency }
+
+func (i *mockInstanceWAL) SetWALTruncateFrequency(d time.Duration) error {
+	i.frequency = d
+	return nil
+}
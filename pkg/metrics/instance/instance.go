@@ -236,6 +236,11 @@ type Instance struct {
 	remoteStore        *remote.Storage
 	storage            storage.Storage
 
+	// truncateFrequency overrides cfg.WALTruncateFrequency when non-zero.
+	truncateFrequency time.Duration
+	// truncateUpdated is written to whenever truncateFrequency changes.
+	truncateUpdated chan struct{}
+
 	// ready is set to true after the initialization process finishes
 	ready atomic.Bool
 
@@ -276,6 +281,7 @@ func newInstance(cfg Config, reg prometheus.Registerer, logger log.Logger, newWa
 		newWal: newWal,
 
 		readyScrapeManager: &readyScrapeManager{},
+		truncateUpdated:    make(chan struct{}, 1),
 	}
 
 	return i, nil
@@ -580,6 +586,70 @@ func (i *Instance) Appender(ctx context.Context) storage.Appender {
 	return i.wal.Appender(ctx)
 }
 
+// WALStats returns the current state of the files of the instance's WAL.
+func (i *Instance) WALStats() (wal.Stats, error) {
+	w, err := i.getWAL()
+	if err != nil {
+		return wal.Stats{}, err
+	}
+	return w.Stats()
+}
+
+// CheckpointWAL immediately checkpoints the segments of the instance's WAL
+// older than the one being written to, removing data which has already been
+// sent to every remote_write endpoint or which is older than max_wal_time.
+func (i *Instance) CheckpointWAL() error {
+	w, err := i.getWAL()
+	if err != nil {
+		return err
+	}
+
+	i.mut.Lock()
+	cfg := i.cfg
+	i.mut.Unlock()
+
+	ts := i.walTruncateTimestamp(&cfg)
+	level.Info(i.logger).Log("msg", "checkpointing the WAL on demand", "ts", ts)
+	return w.Checkpoint(ts)
+}
+
+// WALTruncateFrequency returns how often the instance's WAL is truncated.
+func (i *Instance) WALTruncateFrequency() time.Duration {
+	i.mut.Lock()
+	cfg := i.cfg
+	i.mut.Unlock()
+	return i.walTruncateFrequency(&cfg)
+}
+
+// SetWALTruncateFrequency overrides how often the instance's WAL is
+// truncated, taking effect immediately. Setting it to 0 reverts to the
+// configured wal_truncate_frequency.
+func (i *Instance) SetWALTruncateFrequency(d time.Duration) error {
+	if d < 0 {
+		return errors.New("WAL truncate frequency must not be negative")
+	}
+
+	i.mut.Lock()
+	i.truncateFrequency = d
+	i.mut.Unlock()
+
+	select {
+	case i.truncateUpdated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (i *Instance) getWAL() (walStorage, error) {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+
+	if i.wal == nil {
+		return nil, ErrWALNotReady
+	}
+	return i.wal, nil
+}
+
 type discoveryService struct {
 	Manager *discovery.Manager
 
@@ -662,27 +732,11 @@ func (i *Instance) truncateLoop(ctx context.Context, wal walStorage, cfg *Config
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(cfg.WALTruncateFrequency):
-			// The timestamp ts is used to determine which series are not receiving
-			// samples and may be deleted from the WAL. Their most recent append
-			// timestamp is compared to ts, and if that timestamp is older than ts,
-			// they are considered inactive and may be deleted.
-			//
-			// Subtracting a duration from ts will delay when it will be considered
-			// inactive and scheduled for deletion.
-			ts := i.getRemoteWriteTimestamp() - i.cfg.MinWALTime.Milliseconds()
-			if ts < 0 {
-				ts = 0
-			}
-
-			// Network issues can prevent the result of getRemoteWriteTimestamp from
-			// changing. We don't want data in the WAL to grow forever, so we set a cap
-			// on the maximum age data can be. If our ts is older than this cutoff point,
-			// we'll shift it forward to start deleting very stale data.
-			if maxTS := timestamp.FromTime(time.Now().Add(-i.cfg.MaxWALTime)); ts < maxTS {
-				ts = maxTS
-			}
-
+		case <-i.truncateUpdated:
+			// Restart the wait with the new truncation frequency.
+			continue
+		case <-time.After(i.walTruncateFrequency(cfg)):
+			ts := i.walTruncateTimestamp(cfg)
 			if ts == lastTs {
 				level.Debug(i.logger).Log("msg", "not truncating the WAL, remote_write timestamp is unchanged", "ts", ts)
 				continue
@@ -700,6 +754,41 @@ func (i *Instance) truncateLoop(ctx context.Context, wal walStorage, cfg *Config
 	}
 }
 
+// walTruncateTimestamp returns the timestamp before which data may be removed
+// from the WAL.
+func (i *Instance) walTruncateTimestamp(cfg *Config) int64 {
+	// The timestamp ts is used to determine which series are not receiving
+	// samples and may be deleted from the WAL. Their most recent append
+	// timestamp is compared to ts, and if that timestamp is older than ts,
+	// they are considered inactive and may be deleted.
+	//
+	// Subtracting a duration from ts will delay when it will be considered
+	// inactive and scheduled for deletion.
+	ts := i.getRemoteWriteTimestamp() - cfg.MinWALTime.Milliseconds()
+	if ts < 0 {
+		ts = 0
+	}
+
+	// Network issues can prevent the result of getRemoteWriteTimestamp from
+	// changing. We don't want data in the WAL to grow forever, so we set a cap
+	// on the maximum age data can be. If our ts is older than this cutoff point,
+	// we'll shift it forward to start deleting very stale data.
+	if maxTS := timestamp.FromTime(time.Now().Add(-cfg.MaxWALTime)); ts < maxTS {
+		ts = maxTS
+	}
+	return ts
+}
+
+func (i *Instance) walTruncateFrequency(cfg *Config) time.Duration {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+
+	if i.truncateFrequency > 0 {
+		return i.truncateFrequency
+	}
+	return cfg.WALTruncateFrequency
+}
+
 // getRemoteWriteTimestamp looks up the last successful remote write timestamp.
 // This is passed to wal.Storage for its truncation. If no remote write sections
 // are configured, getRemoteWriteTimestamp returns the current time.
@@ -730,6 +819,8 @@ type walStorage interface {
 	WriteStalenessMarkers(remoteTsFunc func() int64) error
 	Appender(context.Context) storage.Appender
 	Truncate(mint int64) error
+	Checkpoint(mint int64) error
+	Stats() (wal.Stats, error)
 
 	Close() error
 }
@@ -803,6 +894,10 @@ func (rg *runGroupContext) Stop(_ error) { rg.cancel() }
 // initialized yet.
 var ErrNotReady = errors.New("Scrape manager not ready")
 
+// ErrWALNotReady is returned when the WAL is used but has not been
+// initialized yet.
+var ErrWALNotReady = errors.New("WAL not ready")
+
 // readyScrapeManager allows a scrape manager to be retrieved. Even if it's set at a later point in time.
 type readyScrapeManager struct {
 	mtx sync.RWMutex
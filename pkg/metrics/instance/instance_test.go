@@ -13,6 +13,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/util/test"
 	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/metrics/wal"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/model"
@@ -242,6 +243,59 @@ func TestInstance(t *testing.T) {
 	})
 }
 
+func TestInstance_WALManagement(t *testing.T) {
+	scrapeAddr, closeSrv := getTestServer(t)
+	defer closeSrv()
+
+	walDir := t.TempDir()
+
+	globalConfig := getTestGlobalConfig(t)
+	cfg := getTestConfig(t, &globalConfig, scrapeAddr)
+	cfg.WALTruncateFrequency = time.Hour
+	cfg.RemoteFlushDeadline = time.Hour
+
+	mockStorage := mockWalStorage{
+		series:    make(map[storage.SeriesRef]int),
+		directory: walDir,
+	}
+	newWal := func(_ prometheus.Registerer) (walStorage, error) { return &mockStorage, nil }
+
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	inst, err := newInstance(cfg, nil, logger, newWal)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, inst.CheckpointWAL(), ErrWALNotReady)
+	_, err = inst.WALStats()
+	require.ErrorIs(t, err, ErrWALNotReady)
+
+	runInstance(t, inst)
+	test.Poll(t, 30*time.Second, true, func() interface{} {
+		return inst.Ready()
+	})
+
+	stats, err := inst.WALStats()
+	require.NoError(t, err)
+	require.Equal(t, walDir, stats.Directory)
+
+	require.NoError(t, inst.CheckpointWAL())
+	mockStorage.mut.Lock()
+	require.Equal(t, 1, mockStorage.checkpoints)
+	mockStorage.mut.Unlock()
+
+	// Lowering the truncate frequency takes effect immediately.
+	require.Error(t, inst.SetWALTruncateFrequency(-time.Second))
+	require.NoError(t, inst.SetWALTruncateFrequency(10*time.Millisecond))
+	require.Equal(t, 10*time.Millisecond, inst.WALTruncateFrequency())
+	test.Poll(t, 5*time.Second, true, func() interface{} {
+		mockStorage.mut.Lock()
+		defer mockStorage.mut.Unlock()
+		return mockStorage.truncations > 0
+	})
+
+	require.NoError(t, inst.SetWALTruncateFrequency(0))
+	require.Equal(t, time.Hour, inst.WALTruncateFrequency())
+}
+
 // TestInstance_Recreate ensures that creating an instance with the same name twice
 // does not cause any duplicate metrics registration that leads to a panic.
 func TestInstance_Recreate(t *testing.T) {
@@ -345,13 +399,32 @@ type mockWalStorage struct {
 	directory string
 	mut       sync.Mutex
 	series    map[storage.SeriesRef]int
+
+	truncations, checkpoints int
 }
 
 func (s *mockWalStorage) Directory() string                          { return s.directory }
 func (s *mockWalStorage) StartTime() (int64, error)                  { return 0, nil }
 func (s *mockWalStorage) WriteStalenessMarkers(f func() int64) error { return nil }
 func (s *mockWalStorage) Close() error                               { return nil }
-func (s *mockWalStorage) Truncate(mint int64) error                  { return nil }
+
+func (s *mockWalStorage) Truncate(mint int64) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.truncations++
+	return nil
+}
+
+func (s *mockWalStorage) Checkpoint(mint int64) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.checkpoints++
+	return nil
+}
+
+func (s *mockWalStorage) Stats() (wal.Stats, error) {
+	return wal.Stats{Directory: s.directory}, nil
+}
 
 func (s *mockWalStorage) Appender(context.Context) storage.Appender {
 	return &mockAppender{s: s}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/metrics/wal"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/scrape"
@@ -68,6 +69,17 @@ type ManagedInstance interface {
 	Appender(ctx context.Context) storage.Appender
 }
 
+// WALManager is implemented by Instance. It allows operators to inspect and
+// reclaim the disk space used by the WAL of a ManagedInstance at runtime.
+type WALManager interface {
+	WALStats() (wal.Stats, error)
+	CheckpointWAL() error
+	WALTruncateFrequency() time.Duration
+	SetWALTruncateFrequency(d time.Duration) error
+}
+
+var _ WALManager = (*Instance)(nil)
+
 // BasicManagerConfig controls the operations of a BasicManager.
 type BasicManagerConfig struct {
 	InstanceRestartBackoff time.Duration
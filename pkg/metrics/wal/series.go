@@ -35,7 +35,11 @@ type memSeries struct {
 }
 
 func (s *memSeries) updateTs(ts int64) {
-	s.lastTs = ts
+	// Out-of-order samples must not move lastTs backwards, otherwise the
+	// series could be garbage collected while it's still receiving samples.
+	if ts > s.lastTs {
+		s.lastTs = ts
+	}
 	s.willDelete = false
 	s.pendingCommit = true
 }
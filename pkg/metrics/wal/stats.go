@@ -0,0 +1,124 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+// Stats describes the files of the WAL on disk.
+type Stats struct {
+	// Directory holding the segments and checkpoints of the WAL.
+	Directory string `json:"directory"`
+	// Total size of the WAL, including checkpoints.
+	SizeBytes int64 `json:"size_bytes"`
+	// Segments of the WAL, from the oldest to the newest.
+	Segments []SegmentStats `json:"segments"`
+	// Latest checkpoint of the WAL, if any.
+	Checkpoint *CheckpointStats `json:"checkpoint,omitempty"`
+}
+
+// SegmentStats describes a segment of the WAL.
+type SegmentStats struct {
+	Index        int       `json:"index"`
+	SizeBytes    int64     `json:"size_bytes"`
+	LastModified time.Time `json:"last_modified"`
+	// AgeSeconds is the time elapsed since the segment was last written to.
+	AgeSeconds float64 `json:"age_seconds"`
+}
+
+// CheckpointStats describes a checkpoint of the WAL.
+type CheckpointStats struct {
+	// Index of the last segment included in the checkpoint.
+	Index        int       `json:"index"`
+	SizeBytes    int64     `json:"size_bytes"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Stats returns the current state of the files of the WAL.
+func (w *Storage) Stats() (Stats, error) {
+	w.walMtx.RLock()
+	defer w.walMtx.RUnlock()
+
+	if w.walClosed {
+		return Stats{}, ErrWALClosed
+	}
+	return readStats(w.wal.Dir(), time.Now())
+}
+
+func readStats(dir string, now time.Time) (Stats, error) {
+	stats := Stats{Directory: dir, Segments: []SegmentStats{}}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return stats, err
+	}
+	for _, f := range files {
+		index, err := strconv.Atoi(f.Name())
+		if err != nil {
+			// Not a segment.
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return stats, err
+		}
+		stats.Segments = append(stats.Segments, SegmentStats{
+			Index:        index,
+			SizeBytes:    info.Size(),
+			LastModified: info.ModTime(),
+			AgeSeconds:   now.Sub(info.ModTime()).Seconds(),
+		})
+		stats.SizeBytes += info.Size()
+	}
+	sort.Slice(stats.Segments, func(i, j int) bool {
+		return stats.Segments[i].Index < stats.Segments[j].Index
+	})
+
+	checkpointDir, index, err := wlog.LastCheckpoint(dir)
+	switch {
+	case errors.Is(err, record.ErrNotFound):
+		return stats, nil
+	case err != nil:
+		return stats, err
+	}
+
+	info, err := os.Stat(checkpointDir)
+	if err != nil {
+		return stats, err
+	}
+	size, err := fileutil.DirSize(checkpointDir)
+	if err != nil {
+		return stats, err
+	}
+	stats.Checkpoint = &CheckpointStats{
+		Index:        index,
+		SizeBytes:    size,
+		LastModified: info.ModTime(),
+	}
+	stats.SizeBytes += size
+
+	// Older checkpoints may not have been deleted yet.
+	olderCheckpoints, err := filepath.Glob(filepath.Join(dir, "checkpoint.*"))
+	if err != nil {
+		return stats, err
+	}
+	for _, cp := range olderCheckpoints {
+		if cp == checkpointDir {
+			continue
+		}
+		size, err := fileutil.DirSize(cp)
+		if err != nil {
+			return stats, err
+		}
+		stats.SizeBytes += size
+	}
+	return stats, nil
+}
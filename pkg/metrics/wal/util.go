@@ -12,6 +12,12 @@ type walReplayer struct {
 	w wlog.WriteTo
 }
 
+// metadataWriteTo is implemented by a wlog.WriteTo which also wants to
+// receive metadata records.
+type metadataWriteTo interface {
+	StoreMetadata(metadata []record.RefMetadata)
+}
+
 func (r walReplayer) Replay(dir string) error {
 	w, err := wlog.Open(nil, dir)
 	if err != nil {
@@ -88,6 +94,16 @@ func (r walReplayer) replayWAL(reader *wlog.Reader) error {
 				return err
 			}
 			r.w.AppendExemplars(exemplars)
+		case record.Metadata:
+			mw, ok := r.w.(metadataWriteTo)
+			if !ok {
+				continue
+			}
+			metadata, err := dec.Metadata(rec, nil)
+			if err != nil {
+				return err
+			}
+			mw.StoreMetadata(metadata)
 		}
 	}
 
@@ -99,10 +115,18 @@ type walDataCollector struct {
 	samples         []record.RefSample
 	series          []record.RefSeries
 	exemplars       []record.RefExemplar
+	metadata        []record.RefMetadata
 	histograms      []record.RefHistogramSample
 	floatHistograms []record.RefFloatHistogramSample
 }
 
+func (c *walDataCollector) StoreMetadata(metadata []record.RefMetadata) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.metadata = append(c.metadata, metadata...)
+}
+
 func (c *walDataCollector) AppendExemplars(exemplars []record.RefExemplar) bool {
 	c.mut.Lock()
 	defer c.mut.Unlock()
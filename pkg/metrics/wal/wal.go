@@ -39,6 +39,7 @@ type storageMetrics struct {
 	totalRemovedSeries     prometheus.Counter
 	totalAppendedSamples   prometheus.Counter
 	totalAppendedExemplars prometheus.Counter
+	totalOutOfOrderSamples prometheus.Counter
 }
 
 func newStorageMetrics(r prometheus.Registerer) *storageMetrics {
@@ -73,6 +74,11 @@ func newStorageMetrics(r prometheus.Registerer) *storageMetrics {
 		Help: "Total number of exemplars appended to the WAL",
 	})
 
+	m.totalOutOfOrderSamples = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_wal_out_of_order_samples_total",
+		Help: "Total number of samples dropped for being older than the out-of-order time window",
+	})
+
 	if r != nil {
 		r.MustRegister(
 			m.numActiveSeries,
@@ -81,6 +87,7 @@ func newStorageMetrics(r prometheus.Registerer) *storageMetrics {
 			m.totalRemovedSeries,
 			m.totalAppendedSamples,
 			m.totalAppendedExemplars,
+			m.totalOutOfOrderSamples,
 		)
 	}
 
@@ -98,6 +105,7 @@ func (m *storageMetrics) Unregister() {
 		m.totalRemovedSeries,
 		m.totalAppendedSamples,
 		m.totalAppendedExemplars,
+		m.totalOutOfOrderSamples,
 	}
 	for _, c := range cs {
 		m.r.Unregister(c)
@@ -117,6 +125,11 @@ type Storage struct {
 	walMtx    sync.RWMutex
 	walClosed bool
 
+	// truncateMtx serializes truncations, which may be requested concurrently
+	// by the periodic truncate loop and on-demand checkpoints. Only one
+	// checkpoint may be written at a time.
+	truncateMtx sync.Mutex
+
 	path   string
 	wal    *wlog.WL
 	logger log.Logger
@@ -130,6 +143,10 @@ type Storage struct {
 	deletedMtx sync.Mutex
 	deleted    map[chunks.HeadSeriesRef]int // Deleted series, and what WAL segment they must be kept until.
 
+	// Samples older than the newest sample of their series by more than
+	// oooTimeWindow milliseconds are dropped. 0 disables the limit.
+	oooTimeWindow atomic.Int64
+
 	metrics *storageMetrics
 }
 
@@ -297,8 +314,8 @@ func (w *Storage) loadWAL(r *wlog.Reader) (err error) {
 					}
 				}
 				decoded <- samples
-			case record.Tombstones, record.Exemplars:
-				// We don't care about decoding tombstones or exemplars
+			case record.Tombstones, record.Exemplars, record.Metadata:
+				// We don't care about decoding tombstones, exemplars, or metadata
 				// TODO: If decide to decode exemplars, we should make sure to prepopulate
 				// stripeSeries.exemplars in the next block by using setLatestExemplar.
 				continue
@@ -392,9 +409,39 @@ func (*Storage) StartTime() (int64, error) {
 	return 0, nil
 }
 
+// SetOutOfOrderTimeWindow sets how far behind the newest sample of a series
+// a sample may be before it is dropped. Samples inside the window are written
+// to the WAL as-is so that they can be sent to remote_write endpoints which
+// accept out-of-order samples. A window of 0 accepts samples of any age.
+func (w *Storage) SetOutOfOrderTimeWindow(window time.Duration) {
+	w.oooTimeWindow.Store(window.Milliseconds())
+}
+
+// tooOld reports whether a sample at timestamp t is too far behind the newest
+// sample of series to be appended. series must be locked.
+func (w *Storage) tooOld(series *memSeries, t int64) bool {
+	window := w.oooTimeWindow.Load()
+	return window > 0 && t < series.lastTs-window
+}
+
 // Truncate removes all data from the WAL prior to the timestamp specified by
 // mint.
 func (w *Storage) Truncate(mint int64) error {
+	return w.truncate(mint, false)
+}
+
+// Checkpoint removes all data from the WAL prior to the timestamp specified
+// by mint, like Truncate. Unlike Truncate, which only checkpoints the lower two
+// thirds of the segments, Checkpoint checkpoints every segment older than the
+// one which was being written to, reclaiming as much disk space as possible.
+func (w *Storage) Checkpoint(mint int64) error {
+	return w.truncate(mint, true)
+}
+
+func (w *Storage) truncate(mint int64, full bool) error {
+	w.truncateMtx.Lock()
+	defer w.truncateMtx.Unlock()
+
 	w.walMtx.RLock()
 	defer w.walMtx.RUnlock()
 
@@ -425,11 +472,17 @@ func (w *Storage) Truncate(mint int64) error {
 		return nil // no segments yet.
 	}
 
-	// The lower two thirds of segments should contain mostly obsolete samples.
-	// If we have less than two segments, it's not worth checkpointing yet.
-	last = first + (last-first)*2/3
-	if last <= first {
-		return nil
+	if full {
+		if last < first {
+			return nil
+		}
+	} else {
+		// The lower two thirds of segments should contain mostly obsolete samples.
+		// If we have less than two segments, it's not worth checkpointing yet.
+		last = first + (last-first)*2/3
+		if last <= first {
+			return nil
+		}
 	}
 
 	keep := func(id chunks.HeadSeriesRef) bool {
@@ -581,6 +634,7 @@ type appender struct {
 	series          []record.RefSeries
 	samples         []record.RefSample
 	exemplars       []record.RefExemplar
+	metadata        []record.RefMetadata
 	histograms      []record.RefHistogramSample
 	floatHistograms []record.RefFloatHistogramSample
 }
@@ -617,6 +671,11 @@ func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v flo
 	series.Lock()
 	defer series.Unlock()
 
+	if a.w.tooOld(series, t) {
+		a.w.metrics.totalOutOfOrderSamples.Inc()
+		return storage.SeriesRef(series.ref), nil
+	}
+
 	// Update last recorded timestamp. Used by Storage.gc to determine if a
 	// series is stale.
 	series.updateTs(t)
@@ -661,6 +720,11 @@ func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int
 	series.Lock()
 	defer series.Unlock()
 
+	if a.w.tooOld(series, t) {
+		a.w.metrics.totalOutOfOrderSamples.Inc()
+		return storage.SeriesRef(series.ref), nil
+	}
+
 	// Update last recorded timestamp. Used by Storage.gc to determine if a
 	// series is stale.
 	series.updateTs(t)
@@ -746,8 +810,20 @@ func (a *appender) AppendExemplar(ref storage.SeriesRef, _ labels.Labels, e exem
 }
 
 func (a *appender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
-	// TODO(rfratto): implement pushing metadata to WAL
-	return 0, nil
+	// Metadata is only written for series which already exist in the WAL, since
+	// the metadata record refers to the series by its ref.
+	s := a.w.series.getByID(chunks.HeadSeriesRef(ref))
+	if s == nil {
+		return 0, nil
+	}
+
+	a.metadata = append(a.metadata, record.RefMetadata{
+		Ref:  s.ref,
+		Type: record.GetMetricType(m.Type),
+		Unit: m.Unit,
+		Help: m.Help,
+	})
+	return storage.SeriesRef(s.ref), nil
 }
 
 // Commit submits the collected samples and purges the batch.
@@ -786,6 +862,14 @@ func (a *appender) Commit() error {
 		buf = buf[:0]
 	}
 
+	if len(a.metadata) > 0 {
+		buf = encoder.Metadata(a.metadata, buf)
+		if err := a.w.wal.Log(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+
 	if len(a.histograms) > 0 {
 		buf = encoder.HistogramSamples(a.histograms, buf)
 		if err := a.w.wal.Log(buf); err != nil {
@@ -821,6 +905,7 @@ func (a *appender) Rollback() error {
 	a.series = a.series[:0]
 	a.samples = a.samples[:0]
 	a.exemplars = a.exemplars[:0]
+	a.metadata = a.metadata[:0]
 	a.histograms = a.histograms[:0]
 	a.floatHistograms = a.floatHistograms[:0]
 	a.w.appenderPool.Put(a)
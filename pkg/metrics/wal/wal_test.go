@@ -4,13 +4,17 @@ import (
 	"context"
 	"math"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
@@ -141,6 +145,89 @@ func TestStorage_DuplicateExemplarsIgnored(t *testing.T) {
 	require.Equal(t, 4, len(collector.exemplars))
 }
 
+func TestStorage_Metadata(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	app := s.Appender(context.Background())
+
+	sRef, err := app.Append(0, labels.FromStrings("__name__", "requests_total"), 0, 1)
+	require.NoError(t, err)
+
+	ref, err := app.UpdateMetadata(sRef, nil, metadata.Metadata{Type: textparse.MetricTypeCounter, Unit: "requests", Help: "Total requests."})
+	require.NoError(t, err)
+	require.Equal(t, sRef, ref)
+
+	// Metadata for unknown series is ignored.
+	ref, err = app.UpdateMetadata(sRef+1000, nil, metadata.Metadata{Type: textparse.MetricTypeGauge})
+	require.NoError(t, err)
+	require.Zero(t, ref)
+
+	require.NoError(t, app.Commit())
+
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+
+	require.Equal(t, []record.RefMetadata{{
+		Ref:  chunks.HeadSeriesRef(sRef),
+		Type: record.GetMetricType(textparse.MetricTypeCounter),
+		Unit: "requests",
+		Help: "Total requests.",
+	}}, collector.metadata)
+
+	// Metadata records must not prevent the WAL from being loaded again.
+	require.NoError(t, s.Close())
+	s, err = NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+}
+
+func TestStorage_OutOfOrderTimeWindow(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	lbls := labels.FromStrings("__name__", "requests_total")
+
+	// Without a window, samples of any age are accepted.
+	app := s.Appender(context.Background())
+	ref, err := app.Append(0, lbls, 100_000, 1)
+	require.NoError(t, err)
+	_, err = app.Append(ref, lbls, 1_000, 2)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	// With a window, only samples within the window are accepted. The out of
+	// order sample above must not have moved the series' timestamp back.
+	s.SetOutOfOrderTimeWindow(10 * time.Second)
+	app = s.Appender(context.Background())
+	_, err = app.Append(ref, lbls, 95_000, 3)
+	require.NoError(t, err)
+	_, err = app.Append(ref, lbls, 80_000, 4)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+
+	var values []float64
+	for _, sample := range collector.samples {
+		values = append(values, sample.V)
+	}
+	require.Equal(t, []float64{1, 2, 3}, values)
+	require.Equal(t, float64(1), testutil.ToFloat64(s.metrics.totalOutOfOrderSamples))
+}
+
 func TestStorage_ExistingWAL(t *testing.T) {
 	walDir := t.TempDir()
 
@@ -297,6 +384,111 @@ func TestStorage_Truncate(t *testing.T) {
 	require.Equal(t, expectedExemplars, actualExemplars)
 }
 
+func TestStorage_Checkpoint(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	app := s.Appender(context.Background())
+	payload := buildSeries([]string{"foo", "bar", "baz", "blerg"})
+	for _, metric := range payload {
+		metric.Write(t, app)
+	}
+	require.NoError(t, app.Commit())
+
+	_, err = s.wal.NextSegmentSync()
+	require.NoError(t, err)
+
+	stats, err := s.Stats()
+	require.NoError(t, err)
+	require.Len(t, stats.Segments, 2)
+	require.Nil(t, stats.Checkpoint)
+
+	// There are too few segments for Truncate to create a checkpoint, but
+	// Checkpoint always checkpoints every segment but the last one.
+	keepTs := payload[len(payload)-1].samples[0].ts + 1
+	require.NoError(t, s.Truncate(keepTs))
+	stats, err = s.Stats()
+	require.NoError(t, err)
+	require.Nil(t, stats.Checkpoint)
+
+	require.NoError(t, s.Checkpoint(keepTs))
+	stats, err = s.Stats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Checkpoint)
+	require.Equal(t, 1, stats.Checkpoint.Index)
+	require.Equal(t, 2, stats.Segments[0].Index)
+	require.Equal(t, 3, stats.Segments[len(stats.Segments)-1].Index)
+
+	// Data after keepTs must survive the checkpoint.
+	payload = payload.Filter(func(s sample) bool {
+		return s.ts >= keepTs
+	}, func(e exemplar.Exemplar) bool {
+		return e.HasTs && e.Ts >= keepTs
+	})
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+
+	actualSamples := collector.samples
+	sort.Sort(byRefSample(actualSamples))
+	require.Equal(t, payload.ExpectedSamples(), actualSamples)
+}
+
+func TestStorage_ConcurrentTruncate(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	payload := buildSeries([]string{"foo", "bar", "baz", "blerg"})
+	keepTs := payload[len(payload)-1].samples[0].ts + 1
+
+	// Truncations racing each other must not fail or corrupt the checkpoint,
+	// which happens when the periodic truncate loop runs while a checkpoint
+	// was requested on demand.
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		app := s.Appender(context.Background())
+		for _, metric := range payload {
+			metric.Write(t, app)
+		}
+		require.NoError(t, app.Commit())
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- s.Truncate(keepTs)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- s.Checkpoint(keepTs)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	stats, err := s.Stats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Checkpoint)
+
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+}
+
 func TestStorage_WriteStalenessMarkers(t *testing.T) {
 	walDir := t.TempDir()
 
@@ -357,6 +549,10 @@ func TestStorage_TruncateAfterClose(t *testing.T) {
 
 	require.NoError(t, s.Close())
 	require.Error(t, ErrWALClosed, s.Truncate(0))
+	require.Error(t, ErrWALClosed, s.Checkpoint(0))
+
+	_, err = s.Stats()
+	require.ErrorIs(t, err, ErrWALClosed)
 }
 
 func TestGlobalReferenceID_Normal(t *testing.T) {
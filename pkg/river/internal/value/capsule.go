@@ -51,3 +51,17 @@ type ConvertibleIntoCapsule interface {
 	// available.
 	ConvertInto(dst interface{}) error
 }
+
+// ConcatenatableCapsule is a Capsule which supports being concatenated with
+// other values using the + operator.
+type ConcatenatableCapsule interface {
+	Capsule
+
+	// Concat should return the result of concatenating the capsule with
+	// other. If reverse is true, other is the left-hand operand of the
+	// concatenation.
+	//
+	// Concat should return ErrNoConversion if the capsule cannot be
+	// concatenated with other.
+	Concat(other interface{}, reverse bool) (interface{}, error)
+}
@@ -17,6 +17,10 @@ import (
 func TestPrinter(t *testing.T) {
 	filepath.WalkDir("testdata", func(path string, d fs.DirEntry, _ error) error {
 		if d.IsDir() {
+			// Sorting tests are handled by TestSort.
+			if path == filepath.Join("testdata", "sort") {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -41,6 +45,28 @@ func TestPrinter(t *testing.T) {
 	})
 }
 
+func TestSort(t *testing.T) {
+	filepath.WalkDir(filepath.Join("testdata", "sort"), func(path string, d fs.DirEntry, _ error) error {
+		if d.IsDir() || !strings.HasSuffix(path, ".in") {
+			return nil
+		}
+
+		inputBB, err := os.ReadFile(path)
+		require.NoError(t, err)
+		expectBB, err := os.ReadFile(strings.TrimSuffix(path, ".in") + ".expect")
+		require.NoError(t, err)
+
+		caseName := strings.TrimSuffix(filepath.Base(path), ".in")
+		t.Run(caseName, func(t *testing.T) {
+			f, err := parser.ParseFile(t.Name()+".rvr", inputBB)
+			require.NoError(t, err)
+
+			testPrinter(t, printer.Sort(f, inputBB), expectBB)
+		})
+		return nil
+	})
+}
+
 func testPrinter(t *testing.T, input, expect []byte) {
 	f, err := parser.ParseFile(t.Name()+".rvr", input)
 	require.NoError(t, err)
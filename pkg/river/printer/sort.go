@@ -0,0 +1,208 @@
+package printer
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/ast"
+)
+
+// Sort reorders the statements of f into a canonical order, returning the
+// reordered source. src must be the source f was parsed from. The returned
+// source is not formatted and should be parsed again and passed to Fprint.
+//
+// Attributes are placed before blocks and sorted by name. Blocks at the top
+// level of the file are sorted by name and label. Blocks nested inside of
+// other blocks keep their relative order, as the order of blocks such as rule
+// or stage blocks is significant.
+//
+// Comments directly above a statement or on the same line as its end are
+// moved along with the statement. Statements are only reordered within a
+// group: attributes separated by a blank line and statements separated by a
+// comment which does not belong to either of them are in different groups.
+func Sort(f *ast.File, src []byte) []byte {
+	s := sorter{src: src}
+	for _, cg := range f.Comments {
+		s.comments = append(s.comments, cg...)
+	}
+	return s.sortBody(f.Body, 0, len(src), true)
+}
+
+type sorter struct {
+	src      []byte
+	comments []*ast.Comment // All comments in the file, in source order.
+}
+
+// chunk is the source range of a statement and the comments attached to it.
+type chunk struct {
+	stmt       ast.Stmt
+	start, end int // Byte offsets of the chunk; end is exclusive.
+	text       []byte
+}
+
+// sortBody returns src[start:end] with stmts reordered. stmts must be the
+// statements found within that range.
+func (s *sorter) sortBody(stmts []ast.Stmt, start, end int, topLevel bool) []byte {
+	if len(stmts) == 0 {
+		return s.src[start:end]
+	}
+
+	chunks := make([]chunk, len(stmts))
+	prevEnd := start
+	for i, stmt := range stmts {
+		cs, ce := s.chunkRange(stmt, prevEnd)
+		if cs < prevEnd {
+			// Multiple statements share a line, which is unusual enough that
+			// the body is left untouched.
+			return s.src[start:end]
+		}
+		chunks[i] = chunk{stmt: stmt, start: cs, end: ce, text: s.chunkText(stmt, cs, ce)}
+		prevEnd = ce
+	}
+
+	// Split the chunks into groups and sort each of them.
+	sorted := make([]chunk, 0, len(chunks))
+	groupStart := 0
+	for i := 1; i <= len(chunks); i++ {
+		if i < len(chunks) && !s.groupBoundary(chunks[i-1], chunks[i]) {
+			continue
+		}
+		sorted = append(sorted, sortGroup(chunks[groupStart:i], topLevel)...)
+		groupStart = i
+	}
+
+	// Rebuild the body, keeping the text between statements in place.
+	var buf bytes.Buffer
+	buf.Write(s.src[start:chunks[0].start])
+	for i := range chunks {
+		if i > 0 {
+			buf.Write(s.src[chunks[i-1].end:chunks[i].start])
+		}
+		buf.Write(sorted[i].text)
+	}
+	buf.Write(s.src[chunks[len(chunks)-1].end:end])
+	return buf.Bytes()
+}
+
+// chunkRange returns the range of stmt, extended to include comments directly
+// above it and comments following it on the same line. Leading comments
+// before the prevEnd offset are never included.
+func (s *sorter) chunkRange(stmt ast.Stmt, prevEnd int) (start, end int) {
+	start = ast.StartPos(stmt).Offset()
+	end = ast.EndPos(stmt).Offset() + 1
+
+	startLine := ast.StartPos(stmt).Position().Line
+	for i := len(s.comments) - 1; i >= 0; i-- {
+		c := s.comments[i]
+		if c.StartPos.Offset() >= start {
+			continue
+		} else if c.StartPos.Offset() < prevEnd {
+			break
+		}
+		if ast.EndPos(c).Position().Line != startLine-1 || !s.startsLine(c.StartPos.Offset()) {
+			break
+		}
+		start = c.StartPos.Offset()
+		startLine = c.StartPos.Position().Line
+	}
+
+	endLine := ast.EndPos(stmt).Position().Line
+	for _, c := range s.comments {
+		if c.StartPos.Offset() < end {
+			continue
+		}
+		if c.StartPos.Position().Line != endLine {
+			break
+		}
+		end = ast.EndPos(c).Offset() + 1
+		endLine = ast.EndPos(c).Position().Line
+	}
+
+	return start, end
+}
+
+// startsLine reports whether the byte at off is the first non-whitespace byte
+// on its line.
+func (s *sorter) startsLine(off int) bool {
+	lineStart := bytes.LastIndexByte(s.src[:off], '\n') + 1
+	return len(bytes.TrimSpace(s.src[lineStart:off])) == 0
+}
+
+// chunkText returns the text of the chunk, with the body of stmt sorted if
+// it is a block.
+func (s *sorter) chunkText(stmt ast.Stmt, start, end int) []byte {
+	block, ok := stmt.(*ast.BlockStmt)
+	if !ok {
+		return s.src[start:end]
+	}
+
+	var (
+		lcurly = block.LCurlyPos.Offset()
+		rcurly = block.RCurlyPos.Offset()
+	)
+
+	var buf bytes.Buffer
+	buf.Write(s.src[start : lcurly+1])
+	buf.Write(s.sortBody(block.Body, lcurly+1, rcurly, false))
+	buf.Write(s.src[rcurly:end])
+	return buf.Bytes()
+}
+
+// groupBoundary reports whether prev and next belong to different groups.
+func (s *sorter) groupBoundary(prev, next chunk) bool {
+	between := s.src[prev.end:next.start]
+
+	// Comments which aren't attached to either statement separate groups.
+	if len(bytes.TrimSpace(between)) > 0 {
+		return true
+	}
+
+	// Blank lines separate groups of attributes. Blocks are always separated
+	// by blank lines when formatted, so blank lines around them are ignored.
+	_, prevAttr := prev.stmt.(*ast.AttributeStmt)
+	_, nextAttr := next.stmt.(*ast.AttributeStmt)
+	return prevAttr && nextAttr && bytes.Count(between, []byte{'\n'}) > 1
+}
+
+// sortGroup returns the chunks of a group in sorted order.
+func sortGroup(group []chunk, topLevel bool) []chunk {
+	res := make([]chunk, len(group))
+	copy(res, group)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return stmtLess(res[i].stmt, res[j].stmt, topLevel)
+	})
+	return res
+}
+
+// stmtLess orders attributes before blocks and attributes by name. At the top
+// level, blocks are ordered by name and then label; elsewhere, blocks are
+// considered equal so that they keep their relative order.
+func stmtLess(a, b ast.Stmt, topLevel bool) bool {
+	aKind, aName, aLabel := stmtKey(a)
+	bKind, bName, bLabel := stmtKey(b)
+
+	switch {
+	case aKind != bKind:
+		return aKind < bKind
+	case aKind == kindBlock && !topLevel:
+		return false
+	case aName != bName:
+		return aName < bName
+	default:
+		return aLabel < bLabel
+	}
+}
+
+const (
+	kindAttribute = iota
+	kindBlock
+)
+
+func stmtKey(s ast.Stmt) (kind int, name, label string) {
+	if block, ok := s.(*ast.BlockStmt); ok {
+		return kindBlock, strings.Join(block.Name, "."), block.Label
+	}
+	return kindAttribute, s.(*ast.AttributeStmt).Name.Name, ""
+}
@@ -14,6 +14,7 @@ var (
 	_ value.Capsule                = (Capsule)(nil)
 	_ value.ConvertibleFromCapsule = (ConvertibleFromCapsule)(nil)
 	_ value.ConvertibleIntoCapsule = (ConvertibleIntoCapsule)(nil)
+	_ value.ConcatenatableCapsule  = (ConcatenatableCapsule)(nil)
 )
 
 // The Unmarshaler interface allows a type to hook into River decoding and
@@ -75,3 +76,20 @@ type ConvertibleIntoCapsule interface {
 	// available. Other errors are treated as a River decoding error.
 	ConvertInto(dst interface{}) error
 }
+
+// ConcatenatableCapsule is a Capsule which supports being concatenated with
+// other values using the + operator. Concatenation is attempted whenever at
+// least one operand of + is a ConcatenatableCapsule.
+type ConcatenatableCapsule interface {
+	Capsule
+
+	// Concat returns the result of concatenating the capsule with other, which
+	// may be any Go value. If reverse is true, other is the left-hand operand
+	// of the concatenation. The result may be any Go value, including another
+	// capsule.
+	//
+	// Concat should return ErrNoConversion if the capsule cannot be
+	// concatenated with other. Other errors are treated as a River evaluation
+	// error.
+	Concat(other interface{}, reverse bool) (interface{}, error)
+}
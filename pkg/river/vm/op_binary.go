@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -21,6 +22,14 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 		return value.Bool(!valuesEqual(lhs, rhs)), nil
 	}
 
+	// Capsules may opt in to being concatenated with other values, such as a
+	// secret producing a new secret when concatenated with a string.
+	if op == token.ADD {
+		if res, ok, err := concatCapsule(lhs, rhs); ok {
+			return res, err
+		}
+	}
+
 	// The type of lhs and rhs must be acceptable for the binary operator.
 	if !acceptableBinopType(lhs, op) {
 		return value.Null, value.Error{
@@ -324,3 +333,35 @@ func intPow[Number int64 | uint64](n, m Number) Number {
 	}
 	return result
 }
+
+// concatCapsule concatenates lhs and rhs if either of them is a
+// value.ConcatenatableCapsule. ok is false if neither value supports
+// concatenation with the other.
+func concatCapsule(lhs, rhs value.Value) (res value.Value, ok bool, err error) {
+	if lhs.Type() != value.TypeCapsule && rhs.Type() != value.TypeCapsule {
+		return value.Null, false, nil
+	}
+
+	tryConcat := func(capsule, other value.Value, reverse bool) (value.Value, bool, error) {
+		if capsule.Type() != value.TypeCapsule {
+			return value.Null, false, nil
+		}
+		cc, ok := capsule.Interface().(value.ConcatenatableCapsule)
+		if !ok {
+			return value.Null, false, nil
+		}
+
+		out, err := cc.Concat(other.Interface(), reverse)
+		if errors.Is(err, value.ErrNoConversion) {
+			return value.Null, false, nil
+		} else if err != nil {
+			return value.Null, true, value.Error{Value: capsule, Inner: err}
+		}
+		return value.Encode(out), true, nil
+	}
+
+	if res, ok, err := tryConcat(lhs, rhs, false); ok {
+		return res, ok, err
+	}
+	return tryConcat(rhs, lhs, true)
+}
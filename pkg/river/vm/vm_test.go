@@ -6,6 +6,7 @@ import (
 	"testing"
 	"unicode"
 
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/river/scanner"
 	"github.com/grafana/agent/pkg/river/token"
@@ -166,6 +167,48 @@ func TestVM_Evaluate_Null(t *testing.T) {
 	require.Nil(t, v)
 }
 
+// upperString is a capsule which upper-cases strings concatenated with it.
+type upperString string
+
+func (upperString) RiverCapsule() {}
+
+func (s upperString) Concat(other interface{}, reverse bool) (interface{}, error) {
+	str, ok := other.(string)
+	if !ok {
+		return nil, river.ErrNoConversion
+	}
+	if reverse {
+		return upperString(strings.ToUpper(str) + string(s)), nil
+	}
+	return upperString(string(s) + strings.ToUpper(str)), nil
+}
+
+func TestVM_Evaluate_ConcatenatableCapsule(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]interface{}{
+			"capsule": upperString("-"),
+		},
+	}
+
+	t.Run("Concatenation", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`"a" + capsule + "b"`)
+		require.NoError(t, err)
+
+		var actual upperString
+		require.NoError(t, vm.New(expr).Evaluate(scope, &actual))
+		require.Equal(t, upperString("A-B"), actual)
+	})
+
+	t.Run("Unsupported operand", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`capsule + 5`)
+		require.NoError(t, err)
+
+		var actual interface{}
+		err = vm.New(expr).Evaluate(scope, &actual)
+		require.EqualError(t, err, `1:1: capsule should be one of [number string] for binop +, got capsule`)
+	})
+}
+
 func TestVM_Evaluate_IdentifierExpr(t *testing.T) {
 	t.Run("Valid lookup", func(t *testing.T) {
 		scope := &vm.Scope{
@@ -20,20 +20,28 @@ import (
 	"github.com/weaveworks/common/user"
 )
 
-const (
-	rulerAPIPath  = "/prometheus/config/v1/rules"
-	legacyAPIPath = "/api/v1/rules"
-)
-
 var (
 	ErrNoConfig         = errors.New("No config exists for this user")
 	ErrResourceNotFound = errors.New("requested resource not found")
 )
 
-// Config is used to configure a MimirClient.
+// API describes the paths of the ruler API of a backend.
+type API struct {
+	Path       string // Path of the ruler API.
+	LegacyPath string // Path of the ruler API when UseLegacyRoutes is set.
+}
+
+// APIs of the supported rulers.
+var (
+	MimirAPI = API{Path: "/prometheus/config/v1/rules", LegacyPath: "/api/v1/rules"}
+	LokiAPI  = API{Path: "/loki/api/v1/rules", LegacyPath: "/api/prom/rules"}
+)
+
+// Config is used to configure a RulerClient.
 type Config struct {
 	ID               string
 	Address          string
+	API              API
 	UseLegacyRoutes  bool
 	HTTPClientConfig config.HTTPClientConfig
 }
@@ -44,8 +52,8 @@ type Interface interface {
 	ListRules(ctx context.Context, namespace string) (map[string][]rulefmt.RuleGroup, error)
 }
 
-// MimirClient is a client to the Mimir API.
-type MimirClient struct {
+// RulerClient is a client to the ruler API of Mimir or Loki.
+type RulerClient struct {
 	id string
 
 	endpoint *url.URL
@@ -54,8 +62,8 @@ type MimirClient struct {
 	logger   log.Logger
 }
 
-// New returns a new MimirClient.
-func New(logger log.Logger, cfg Config, timingHistogram *prometheus.HistogramVec) (*MimirClient, error) {
+// New returns a new RulerClient.
+func New(logger log.Logger, cfg Config, timingHistogram *prometheus.HistogramVec) (*RulerClient, error) {
 	endpoint, err := url.Parse(cfg.Address)
 	if err != nil {
 		return nil, err
@@ -65,15 +73,15 @@ func New(logger log.Logger, cfg Config, timingHistogram *prometheus.HistogramVec
 		return nil, err
 	}
 
-	path := rulerAPIPath
+	path := cfg.API.Path
 	if cfg.UseLegacyRoutes {
-		path = legacyAPIPath
+		path = cfg.API.LegacyPath
 	}
 
 	collector := instrument.NewHistogramCollector(timingHistogram)
 	timedClient := weaveworksClient.NewTimedClient(client, collector)
 
-	return &MimirClient{
+	return &RulerClient{
 		id:       cfg.ID,
 		endpoint: endpoint,
 		client:   timedClient,
@@ -82,7 +90,7 @@ func New(logger log.Logger, cfg Config, timingHistogram *prometheus.HistogramVec
 	}, nil
 }
 
-func (r *MimirClient) doRequest(operation, path, method string, payload []byte) (*http.Response, error) {
+func (r *RulerClient) doRequest(operation, path, method string, payload []byte) (*http.Response, error) {
 	req, err := buildRequest(operation, path, method, *r.endpoint, payload)
 	if err != nil {
 		return nil, err
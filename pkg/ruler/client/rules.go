@@ -15,7 +15,7 @@ type RemoteWriteConfig struct {
 }
 
 // CreateRuleGroup creates a new rule group
-func (r *MimirClient) CreateRuleGroup(ctx context.Context, namespace string, rg rulefmt.RuleGroup) error {
+func (r *RulerClient) CreateRuleGroup(ctx context.Context, namespace string, rg rulefmt.RuleGroup) error {
 	payload, err := yaml.Marshal(&rg)
 	if err != nil {
 		return err
@@ -36,7 +36,7 @@ func (r *MimirClient) CreateRuleGroup(ctx context.Context, namespace string, rg
 }
 
 // DeleteRuleGroup deletes a rule group
-func (r *MimirClient) DeleteRuleGroup(ctx context.Context, namespace, groupName string) error {
+func (r *RulerClient) DeleteRuleGroup(ctx context.Context, namespace, groupName string) error {
 	escapedNamespace := url.PathEscape(namespace)
 	escapedGroupName := url.PathEscape(groupName)
 	path := r.apiPath + "/" + escapedNamespace + "/" + escapedGroupName
@@ -53,7 +53,7 @@ func (r *MimirClient) DeleteRuleGroup(ctx context.Context, namespace, groupName
 }
 
 // ListRules retrieves a rule group
-func (r *MimirClient) ListRules(ctx context.Context, namespace string) (map[string][]rulefmt.RuleGroup, error) {
+func (r *RulerClient) ListRules(ctx context.Context, namespace string) (map[string][]rulefmt.RuleGroup, error) {
 	path := r.apiPath
 	op := r.apiPath
 	if namespace != "" {
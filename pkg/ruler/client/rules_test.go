@@ -13,7 +13,7 @@ import (
 	"github.com/weaveworks/common/instrument"
 )
 
-func TestMimirClient_X(t *testing.T) {
+func TestRulerClient_X(t *testing.T) {
 	requestCh := make(chan *http.Request, 1)
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -24,6 +24,7 @@ func TestMimirClient_X(t *testing.T) {
 
 	client, err := New(log.NewNopLogger(), Config{
 		Address: ts.URL,
+		API:     MimirAPI,
 	}, prometheus.NewHistogramVec(prometheus.HistogramOpts{}, instrument.HistogramCollectorBuckets))
 	require.NoError(t, err)
 
@@ -73,3 +74,39 @@ func TestMimirClient_X(t *testing.T) {
 		})
 	}
 }
+
+func TestRulerClient_API(t *testing.T) {
+	requestCh := make(chan *http.Request, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCh <- r
+		fmt.Fprintln(w, "hello")
+	}))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		test       string
+		api        API
+		legacy     bool
+		expURLPath string
+	}{
+		{test: "mimir", api: MimirAPI, expURLPath: "/prometheus/config/v1/rules/ns/group"},
+		{test: "mimir-legacy", api: MimirAPI, legacy: true, expURLPath: "/api/v1/rules/ns/group"},
+		{test: "loki", api: LokiAPI, expURLPath: "/loki/api/v1/rules/ns/group"},
+		{test: "loki-legacy", api: LokiAPI, legacy: true, expURLPath: "/api/prom/rules/ns/group"},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			client, err := New(log.NewNopLogger(), Config{
+				Address:         ts.URL,
+				API:             tc.api,
+				UseLegacyRoutes: tc.legacy,
+			}, prometheus.NewHistogramVec(prometheus.HistogramOpts{}, instrument.HistogramCollectorBuckets))
+			require.NoError(t, err)
+
+			require.NoError(t, client.DeleteRuleGroup(context.Background(), "ns", "group"))
+
+			req := <-requestCh
+			require.Equal(t, tc.expURLPath, req.URL.EscapedPath())
+		})
+	}
+}
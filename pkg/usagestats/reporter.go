@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -23,10 +24,15 @@ var (
 	reportInterval      = 4 * time.Hour
 )
 
+// ErrNotInitialized is returned by Preview if the reporter hasn't been
+// started yet.
+var ErrNotInitialized = errors.New("usage stats reporter not initialized")
+
 // Reporter holds the agent seed information and sends report of usage
 type Reporter struct {
 	logger log.Logger
 
+	seedMut    sync.RWMutex
 	agentSeed  *AgentSeed
 	lastReport time.Time
 }
@@ -49,6 +55,9 @@ func NewReporter(logger log.Logger) (*Reporter, error) {
 func (rep *Reporter) init(ctx context.Context) error {
 	path := agentSeedFileName()
 
+	rep.seedMut.Lock()
+	defer rep.seedMut.Unlock()
+
 	if fileExists(path) {
 		seed, err := rep.readSeedFile(path)
 		rep.agentSeed = seed
@@ -62,6 +71,20 @@ func (rep *Reporter) init(ctx context.Context) error {
 	return rep.writeSeedFile(*rep.agentSeed, path)
 }
 
+// Preview returns the report which would be sent next for the given metrics
+// without sending it. Preview returns ErrNotInitialized if Start hasn't
+// initialized the agent seed yet.
+func (rep *Reporter) Preview(metrics map[string]interface{}) (Report, error) {
+	rep.seedMut.RLock()
+	defer rep.seedMut.RUnlock()
+
+	if rep.agentSeed == nil {
+		return Report{}, ErrNotInitialized
+	}
+	next := nextReport(reportInterval, rep.agentSeed.CreatedAt, time.Now())
+	return newReport(rep.agentSeed, next, metrics), nil
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return !errors.Is(err, os.ErrNotExist)
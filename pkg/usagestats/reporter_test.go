@@ -98,3 +98,21 @@ func Test_NextReport(t *testing.T) {
 		})
 	}
 }
+
+func Test_Preview(t *testing.T) {
+	r, err := NewReporter(log.NewNopLogger())
+	require.NoError(t, err)
+
+	metrics := map[string]interface{}{"enabled-components": []string{"prometheus.scrape"}}
+
+	_, err = r.Preview(metrics)
+	require.ErrorIs(t, err, ErrNotInitialized)
+
+	r.agentSeed = &AgentSeed{UID: "test-uid", CreatedAt: time.Now().Add(-time.Minute)}
+
+	report, err := r.Preview(metrics)
+	require.NoError(t, err)
+	require.Equal(t, "test-uid", report.UsageStatsID)
+	require.Equal(t, metrics, report.Metrics)
+	require.True(t, report.Interval.After(time.Now()), "preview should be for the next report")
+}
@@ -29,8 +29,8 @@ type Report struct {
 	Arch         string                 `json:"arch"`
 }
 
-func sendReport(ctx context.Context, seed *AgentSeed, interval time.Time, metrics map[string]interface{}) error {
-	report := Report{
+func newReport(seed *AgentSeed, interval time.Time, metrics map[string]interface{}) Report {
+	return Report{
 		UsageStatsID: seed.UID,
 		CreatedAt:    seed.CreatedAt,
 		Version:      version.Version,
@@ -39,6 +39,10 @@ func sendReport(ctx context.Context, seed *AgentSeed, interval time.Time, metric
 		Interval:     interval,
 		Metrics:      metrics,
 	}
+}
+
+func sendReport(ctx context.Context, seed *AgentSeed, interval time.Time, metrics map[string]interface{}) error {
+	report := newReport(seed, interval, metrics)
 	out, err := json.MarshalIndent(report, "", " ")
 	if err != nil {
 		return err
@@ -0,0 +1,289 @@
+// Package memwatch watches the memory usage of the process against a ceiling,
+// so that components can apply backpressure, such as pausing reads or
+// refusing requests, before the process is killed for running out of memory.
+package memwatch
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+	"go.uber.org/atomic"
+)
+
+// DefaultInterval is how often the default Watcher checks memory usage.
+const DefaultInterval = time.Second
+
+// DefaultThreshold is the default fraction of the ceiling at which memory
+// pressure is considered high.
+const DefaultThreshold = 0.8
+
+// RetryAfter is how long clients are asked to wait before retrying requests
+// refused due to high memory pressure.
+const RetryAfter = 5 * time.Second
+
+// Level is a level of memory pressure.
+type Level int32
+
+// Supported levels of memory pressure.
+const (
+	// LevelNormal means memory usage is below the threshold, or no ceiling is
+	// set.
+	LevelNormal Level = iota
+
+	// LevelHigh means memory usage is above the threshold. Components should
+	// apply backpressure to stop memory usage from growing further.
+	LevelHigh
+
+	// LevelCritical means memory usage is above the ceiling. Components keep
+	// applying backpressure, and the Watcher forces memory to be returned to
+	// the operating system.
+	LevelCritical
+)
+
+// String returns the name of l.
+func (l Level) String() string {
+	switch l {
+	case LevelNormal:
+		return "normal"
+	case LevelHigh:
+		return "high"
+	case LevelCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// Watcher polls the memory usage of the process and notifies registered
+// callbacks when the level of memory pressure changes. Memory usage is the
+// larger of the Go heap and the resident set size of the process, as the
+// latter is what the kernel OOM killer looks at.
+type Watcher struct {
+	period       time.Duration
+	usageFunc    func() uint64
+	freeOSMemory func()
+
+	level *atomic.Int32
+	usage *atomic.Uint64
+
+	mut       sync.Mutex
+	ceiling   uint64
+	threshold float64
+	callbacks map[*callback]struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+type callback struct {
+	fn func(Level)
+}
+
+// New creates a new Watcher which checks memory usage every interval. The
+// Watcher has no ceiling until SetCeiling is called, and only polls while it
+// has registered callbacks.
+func New(interval time.Duration) *Watcher {
+	return &Watcher{
+		period:       interval,
+		usageFunc:    processMemory,
+		freeOSMemory: debug.FreeOSMemory,
+
+		level: atomic.NewInt32(int32(LevelNormal)),
+		usage: atomic.NewUint64(0),
+
+		threshold: DefaultThreshold,
+		callbacks: make(map[*callback]struct{}),
+	}
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultWatcher *Watcher
+)
+
+// Default returns a Watcher shared by the whole process.
+func Default() *Watcher {
+	defaultOnce.Do(func() {
+		defaultWatcher = New(DefaultInterval)
+	})
+	return defaultWatcher
+}
+
+// SetCeiling sets the memory usage in bytes which the process must stay below,
+// and the fraction of it at which memory pressure is considered high. A
+// ceiling of 0 disables the Watcher.
+func (w *Watcher) SetCeiling(ceiling uint64, threshold float64) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	w.ceiling = ceiling
+	w.threshold = threshold
+}
+
+// Ceiling returns the memory usage in bytes which the process must stay below.
+// 0 is returned if no ceiling is set.
+func (w *Watcher) Ceiling() uint64 {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.ceiling
+}
+
+// Level returns the current level of memory pressure.
+func (w *Watcher) Level() Level {
+	return Level(w.level.Load())
+}
+
+// Register registers fn to be called with the current level of memory
+// pressure, and then again whenever the level changes. fn must not block. The
+// returned function must be called to unregister fn once it's no longer
+// needed.
+func (w *Watcher) Register(fn func(Level)) func() {
+	cb := &callback{fn: fn}
+
+	w.mut.Lock()
+	w.callbacks[cb] = struct{}{}
+	if len(w.callbacks) == 1 {
+		w.start()
+	}
+	w.mut.Unlock()
+
+	fn(w.Level())
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.mut.Lock()
+			delete(w.callbacks, cb)
+			var done chan struct{}
+			if len(w.callbacks) == 0 {
+				close(w.stop)
+				done = w.done
+			}
+			w.mut.Unlock()
+
+			// Wait for polling to stop outside of the lock so it can finish
+			// notifying.
+			if done != nil {
+				<-done
+			}
+		})
+	}
+}
+
+// start starts polling. mut must be held when calling.
+func (w *Watcher) start() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(w.period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}(w.stop, w.done)
+}
+
+// check reads memory usage and notifies callbacks if the level of memory
+// pressure changed.
+func (w *Watcher) check() {
+	w.mut.Lock()
+	ceiling, threshold := w.ceiling, w.threshold
+	w.mut.Unlock()
+
+	level := LevelNormal
+	if ceiling > 0 {
+		usage := w.usageFunc()
+		w.usage.Store(usage)
+
+		switch {
+		case usage >= ceiling:
+			level = LevelCritical
+		case float64(usage) >= float64(ceiling)*threshold:
+			level = LevelHigh
+		}
+	}
+
+	if Level(w.level.Swap(int32(level))) == level {
+		return
+	}
+
+	if level == LevelCritical {
+		// Return as much memory as possible to the operating system while
+		// components relieve the pressure. FreeOSMemory forces a full GC, so
+		// it's only done when entering the critical level rather than on every
+		// check while usage stays critical.
+		w.freeOSMemory()
+	}
+	w.notify(level)
+}
+
+func (w *Watcher) notify(level Level) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for cb := range w.callbacks {
+		cb.fn(level)
+	}
+}
+
+// processMemory returns the memory usage of the process.
+func processMemory() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	usage := ms.HeapAlloc
+
+	// Reading the resident set size is only supported on Linux.
+	if p, err := procfs.Self(); err == nil {
+		if stat, err := p.Stat(); err == nil && uint64(stat.ResidentMemory()) > usage {
+			usage = uint64(stat.ResidentMemory())
+		}
+	}
+	return usage
+}
+
+// Describe implements prometheus.Collector.
+func (w *Watcher) Describe(ch chan<- *prometheus.Desc) {
+	ch <- levelDesc
+	ch <- usageDesc
+	ch <- ceilingDesc
+}
+
+// Collect implements prometheus.Collector.
+func (w *Watcher) Collect(ch chan<- prometheus.Metric) {
+	w.mut.Lock()
+	ceiling := w.ceiling
+	w.mut.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(levelDesc, prometheus.GaugeValue, float64(w.Level()))
+	ch <- prometheus.MustNewConstMetric(usageDesc, prometheus.GaugeValue, float64(w.usage.Load()))
+	ch <- prometheus.MustNewConstMetric(ceilingDesc, prometheus.GaugeValue, float64(ceiling))
+}
+
+var (
+	levelDesc = prometheus.NewDesc(
+		"agent_memory_pressure_level",
+		"Current level of memory pressure: 0 for normal, 1 for high, and 2 for critical.",
+		nil, nil,
+	)
+	usageDesc = prometheus.NewDesc(
+		"agent_memory_watchdog_usage_bytes",
+		"Memory usage of the process as of the last check.",
+		nil, nil,
+	)
+	ceilingDesc = prometheus.NewDesc(
+		"agent_memory_watchdog_ceiling_bytes",
+		"Memory usage the process must stay below. 0 if no ceiling is set.",
+		nil, nil,
+	)
+)
@@ -0,0 +1,60 @@
+package memwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestWatcher(t *testing.T) {
+	usage := atomic.NewUint64(0)
+
+	frees := atomic.NewInt32(0)
+
+	w := New(10 * time.Millisecond)
+	w.usageFunc = usage.Load
+	w.freeOSMemory = func() { frees.Inc() }
+	w.SetCeiling(1000, 0.8)
+
+	levels := make(chan Level, 10)
+	unregister := w.Register(func(l Level) { levels <- l })
+	defer unregister()
+
+	// Callbacks are called with the current level when registered.
+	require.Equal(t, LevelNormal, <-levels)
+
+	expectLevel := func(expect Level) {
+		t.Helper()
+		select {
+		case l := <-levels:
+			require.Equal(t, expect, l)
+			require.Equal(t, expect, w.Level())
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected level change", "expected %s", expect)
+		}
+	}
+
+	usage.Store(800)
+	expectLevel(LevelHigh)
+	usage.Store(1000)
+	expectLevel(LevelCritical)
+
+	// Memory is only freed when entering the critical level, not on every
+	// check while usage stays critical.
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), frees.Load())
+
+	usage.Store(100)
+	expectLevel(LevelNormal)
+
+	// Removing the ceiling disables the watcher.
+	usage.Store(5000)
+	w.SetCeiling(0, 0.8)
+	select {
+	case l := <-levels:
+		require.FailNow(t, "unexpected level change", "got %s", l)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
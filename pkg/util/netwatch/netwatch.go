@@ -0,0 +1,242 @@
+// Package netwatch detects changes to the network addresses of the host, such
+// as when a DHCP lease is renewed or a VPN connects, so that listeners bound
+// to specific addresses can be restarted.
+package netwatch
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often the default Watcher checks for changes.
+const DefaultInterval = 5 * time.Second
+
+// Watcher polls the addresses of the host's network interfaces and notifies
+// subscribers when they change. Polling is used on every platform rather than
+// OS-specific notification APIs so that behavior is consistent between
+// Linux, Windows, and macOS.
+type Watcher struct {
+	period    time.Duration
+	addrsFunc func() ([]net.Addr, error)
+
+	mut         sync.Mutex
+	subscribers map[chan struct{}]struct{}
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// New creates a new Watcher which checks for changes every interval. The
+// Watcher only polls while it has subscribers.
+func New(interval time.Duration) *Watcher {
+	return &Watcher{
+		period:      interval,
+		addrsFunc:   net.InterfaceAddrs,
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultWatcher *Watcher
+)
+
+// Default returns a Watcher shared by the whole process.
+func Default() *Watcher {
+	defaultOnce.Do(func() {
+		defaultWatcher = New(DefaultInterval)
+	})
+	return defaultWatcher
+}
+
+// Subscribe returns a channel which is written to whenever the addresses of
+// the host change. Notifications are coalesced: a notification is dropped if
+// the previous one hasn't been read yet. The returned function must be called
+// to unsubscribe once notifications are no longer needed.
+func (w *Watcher) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.subscribers[ch] = struct{}{}
+	if len(w.subscribers) == 1 {
+		w.start()
+	}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			w.mut.Lock()
+			delete(w.subscribers, ch)
+			var done chan struct{}
+			if len(w.subscribers) == 0 {
+				close(w.stop)
+				done = w.done
+			}
+			w.mut.Unlock()
+
+			// Wait for polling to stop outside of the lock so it can finish
+			// notifying.
+			if done != nil {
+				<-done
+			}
+		})
+	}
+	return ch, unsubscribe
+}
+
+// start starts polling. mut must be held when calling.
+func (w *Watcher) start() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	// Take the initial snapshot synchronously so that changes made right after
+	// subscribing aren't missed.
+	last := w.snapshot()
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(w.period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current := w.snapshot()
+				if current == last {
+					continue
+				}
+				last = current
+				w.notify()
+			}
+		}
+	}(w.stop, w.done)
+}
+
+func (w *Watcher) notify() {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for ch := range w.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// Subscriber already has a pending notification.
+		}
+	}
+}
+
+// snapshot returns a string representation of the current set of addresses.
+// An error is represented as an empty set, so that listeners are restarted
+// once addresses can be read again.
+func (w *Watcher) snapshot() string {
+	addrs, err := w.addrsFunc()
+	if err != nil {
+		return ""
+	}
+
+	res := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		res = append(res, addr.String())
+	}
+	sort.Strings(res)
+	return strings.Join(res, ",")
+}
+
+// IsSpecificAddress reports whether listening on addr binds to a specific
+// address of the host. Listeners bound to specific addresses may stop working
+// when the host's addresses change, while listeners bound to all addresses
+// (such as 0.0.0.0:80 or :80) keep working.
+func IsSpecificAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		// addr may be a host without a port.
+		host = addr
+	}
+	if host == "" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsUnspecified()
+}
+
+// State describes which of a set of listen addresses are currently assigned
+// to the host. States are compared with == to decide whether a listener needs
+// to be restarted.
+type State string
+
+// State returns the current State of addrs. Only specific addresses, as
+// reported by IsSpecificAddress, are part of the State, so listeners bound to
+// all addresses always have the same State. Listeners should record the State
+// when they start and only restart after a notification if it changed, which
+// happens when one of their own addresses appears on or disappears from the
+// host.
+func (w *Watcher) State(addrs []string) State {
+	var specific []string
+	for _, addr := range addrs {
+		if IsSpecificAddress(addr) {
+			specific = append(specific, addr)
+		}
+	}
+	if len(specific) == 0 {
+		return ""
+	}
+
+	// An error reading the addresses is treated as none being assigned, so
+	// that listeners are restarted once addresses can be read again.
+	assigned := make(map[string]struct{})
+	if hostAddrs, err := w.addrsFunc(); err == nil {
+		for _, addr := range hostAddrs {
+			if ip := addrIP(addr); ip != nil {
+				assigned[ip.String()] = struct{}{}
+			}
+		}
+	}
+
+	res := make([]string, 0, len(specific))
+	for _, addr := range specific {
+		present := false
+		for _, ip := range lookupHost(addr) {
+			if _, ok := assigned[ip.String()]; ok {
+				present = true
+				break
+			}
+		}
+		res = append(res, fmt.Sprintf("%s=%t", addr, present))
+	}
+	sort.Strings(res)
+	return State(strings.Join(res, ","))
+}
+
+// addrIP returns the IP of an interface address.
+func addrIP(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.IPNet:
+		return addr.IP
+	case *net.IPAddr:
+		return addr.IP
+	default:
+		return nil
+	}
+}
+
+// lookupHost returns the IPs the host of addr resolves to. Hostnames which
+// can't be resolved have no IPs.
+func lookupHost(addr string) []net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+	ips, _ := net.LookupIP(host)
+	return ips
+}
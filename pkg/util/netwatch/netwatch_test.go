@@ -0,0 +1,104 @@
+package netwatch
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	var (
+		mut   sync.Mutex
+		addrs = []net.Addr{&net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(24, 32)}}
+	)
+
+	w := New(10 * time.Millisecond)
+	w.addrsFunc = func() ([]net.Addr, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		return addrs, nil
+	}
+
+	ch, unsubscribe := w.Subscribe()
+	defer unsubscribe()
+
+	select {
+	case <-ch:
+		require.FailNow(t, "unexpected notification without address change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mut.Lock()
+	addrs = []net.Addr{&net.IPNet{IP: net.ParseIP("10.0.1.1"), Mask: net.CIDRMask(24, 32)}}
+	mut.Unlock()
+
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "expected notification after address change")
+	}
+}
+
+func TestIsSpecificAddress(t *testing.T) {
+	tt := map[string]bool{
+		":4317":            false,
+		"0.0.0.0:4317":     false,
+		"[::]:4317":        false,
+		"127.0.0.1:4317":   true,
+		"10.0.0.1:4317":    true,
+		"[fe80::1]:4317":   true,
+		"localhost:4317":   true,
+		"agent.local:4317": true,
+		"":                 false,
+	}
+	for addr, expect := range tt {
+		require.Equal(t, expect, IsSpecificAddress(addr), addr)
+	}
+}
+
+func TestWatcher_State(t *testing.T) {
+	var (
+		mut   sync.Mutex
+		addrs = []net.Addr{&net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(24, 32)}}
+	)
+
+	w := New(10 * time.Millisecond)
+	w.addrsFunc = func() ([]net.Addr, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		return addrs, nil
+	}
+	setAddrs := func(ips ...string) {
+		mut.Lock()
+		defer mut.Unlock()
+		addrs = nil
+		for _, ip := range ips {
+			addrs = append(addrs, &net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(24, 32)})
+		}
+	}
+
+	var (
+		listener  = []string{"10.0.0.1:514"}
+		wildcard  = []string{"0.0.0.0:514", ":515"}
+		initial   = w.State(listener)
+		wildState = w.State(wildcard)
+	)
+	require.Equal(t, State(""), wildState)
+
+	// Unrelated addresses coming and going don't affect the listener.
+	setAddrs("10.0.0.1", "192.168.1.10")
+	require.Equal(t, initial, w.State(listener))
+	require.Equal(t, wildState, w.State(wildcard))
+
+	// The listener's own address disappearing and reappearing does.
+	setAddrs("192.168.1.10")
+	removed := w.State(listener)
+	require.NotEqual(t, initial, removed)
+
+	setAddrs("192.168.1.10", "10.0.0.1")
+	require.Equal(t, initial, w.State(listener))
+	require.Equal(t, wildState, w.State(wildcard))
+}
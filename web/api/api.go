@@ -7,8 +7,12 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/prometheus/prometheus/util/httputil"
 
@@ -18,7 +22,8 @@ import (
 
 // FlowAPI is a wrapper around the component API.
 type FlowAPI struct {
-	flow *flow.Flow
+	flow    *flow.Flow
+	sampler *MetricsSampler
 }
 
 // NewFlowAPI instantiates a new Flow API.
@@ -26,10 +31,19 @@ func NewFlowAPI(flow *flow.Flow, r *mux.Router) *FlowAPI {
 	return &FlowAPI{flow: flow}
 }
 
+// SetMetricsSampler sets the sampler used to serve the recent metrics of
+// components. Metrics of components aren't served if no sampler is set.
+func (f *FlowAPI) SetMetricsSampler(s *MetricsSampler) {
+	f.sampler = s
+}
+
 // RegisterRoutes registers all the API's routes.
 func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components/{id}"), httputil.CompressionHandler{Handler: f.listComponentHandler()})
+	r.Handle(path.Join(urlPrefix, "/components/{id}/profile"), f.componentProfileHandler()).Methods(http.MethodGet)
+	r.Handle(path.Join(urlPrefix, "/components/{id}/metrics"), f.componentMetricsHandler()).Methods(http.MethodGet)
+	r.Handle(path.Join(urlPrefix, "/inventory"), f.inventoryHandler()).Methods(http.MethodGet)
 }
 
 func (f *FlowAPI) listComponentsHandler() http.HandlerFunc {
@@ -66,6 +80,60 @@ func (f *FlowAPI) listComponentHandler() http.HandlerFunc {
 	}
 }
 
+// componentProfileHandler serves a zip bundle of profiles for the goroutines
+// of a single component. The optional seconds query parameter sets how long to
+// collect a CPU profile for.
+func (f *FlowAPI) componentProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		var opts flow.ComponentProfileOptions
+		if s := r.URL.Query().Get("seconds"); s != "" {
+			seconds, err := strconv.Atoi(s)
+			if err != nil || seconds < 0 {
+				http.Error(w, fmt.Sprintf("invalid seconds %q", s), http.StatusBadRequest)
+				return
+			}
+			opts.Duration = time.Duration(seconds) * time.Second
+		}
+
+		var buf bytes.Buffer
+		if err := f.flow.WriteComponentProfile(r.Context(), &buf, id, opts); err != nil {
+			if errors.Is(err, flow.ErrComponentNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+"-profile.zip"))
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// componentMetricsHandler serves the recently sampled metrics of a single
+// component, such as the queue depth of write components.
+func (f *FlowAPI) componentMetricsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		series := []SampledSeries{}
+		if f.sampler != nil {
+			series = f.sampler.ComponentSeries(id)
+		}
+
+		bb, err := json.Marshal(series)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
+
 // json returns the JSON representation of c.
 func (f *FlowAPI) json(c *flow.ComponentInfo) ([]byte, error) {
 	var buf bytes.Buffer
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/flow"
+)
+
+// readBuildInfo returns the build information of the binary. It's a variable
+// so tests can replace it.
+var readBuildInfo = debug.ReadBuildInfo
+
+// Inventory lists the running components and the versions of the upstream
+// libraries backing them, so that vulnerable versions can be found across a
+// fleet of agents.
+type Inventory struct {
+	Build      InventoryBuild       `json:"build"`
+	Components []InventoryComponent `json:"components"`
+}
+
+// InventoryBuild holds the build information of the agent.
+type InventoryBuild struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// InventoryComponent is a running component in an Inventory.
+type InventoryComponent struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Modules []InventoryModule `json:"modules"`
+}
+
+// InventoryModule is a Go module backing a component.
+type InventoryModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+
+	// Replacement is the module, in path@version form, which replaced the
+	// module at build time, if any. The replacement holds the code which is
+	// actually running.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// newInventory builds an Inventory of the given components.
+func newInventory(infos []*flow.ComponentInfo) Inventory {
+	modules := make(map[string]InventoryModule)
+	if bi, ok := readBuildInfo(); ok {
+		for _, dep := range bi.Deps {
+			m := InventoryModule{Path: dep.Path, Version: dep.Version}
+			if dep.Replace != nil {
+				m.Replacement = dep.Replace.Path + "@" + dep.Replace.Version
+			}
+			modules[dep.Path] = m
+		}
+	}
+
+	inv := Inventory{
+		Build: InventoryBuild{
+			Version:   build.Version,
+			Revision:  build.Revision,
+			Branch:    build.Branch,
+			BuildUser: build.BuildUser,
+			BuildDate: build.BuildDate,
+			GoVersion: runtime.Version(),
+		},
+		Components: make([]InventoryComponent, 0, len(infos)),
+	}
+
+	for _, info := range infos {
+		c := InventoryComponent{
+			ID:      info.ID,
+			Name:    info.Name,
+			Modules: []InventoryModule{},
+		}
+		reg, _ := component.Get(info.Name)
+		for _, path := range reg.UpstreamModules {
+			m, ok := modules[path]
+			if !ok {
+				// The module isn't part of the build information, such as when
+				// running tests.
+				m = InventoryModule{Path: path, Version: "unknown"}
+			}
+			c.Modules = append(c.Modules, m)
+		}
+		inv.Components = append(inv.Components, c)
+	}
+
+	sort.Slice(inv.Components, func(i, j int) bool {
+		return inv.Components[i].ID < inv.Components[j].ID
+	})
+	return inv
+}
+
+// inventoryHandler serves the Inventory of the running components.
+func (f *FlowAPI) inventoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		bb, err := json.Marshal(newInventory(f.flow.ComponentInfos()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
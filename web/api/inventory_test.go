@@ -0,0 +1,87 @@
+package api
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "inventory_test.local",
+		Args: struct{}{},
+		Build: func(component.Options, component.Arguments) (component.Component, error) {
+			return nil, nil
+		},
+	})
+	component.Register(component.Registration{
+		Name: "inventory_test.exporter",
+		Args: struct{}{},
+		Build: func(component.Options, component.Arguments) (component.Component, error) {
+			return nil, nil
+		},
+
+		UpstreamModules: []string{"github.com/prometheus/node_exporter"},
+	})
+	component.Register(component.Registration{
+		Name: "inventory_test.receiver",
+		Args: struct{}{},
+		Build: func(component.Options, component.Arguments) (component.Component, error) {
+			return nil, nil
+		},
+
+		UpstreamModules: []string{"go.opentelemetry.io/collector", "github.com/prometheus/prometheus"},
+	})
+}
+
+func TestInventory(t *testing.T) {
+	prev := readBuildInfo
+	t.Cleanup(func() { readBuildInfo = prev })
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Deps: []*debug.Module{
+			{Path: "github.com/prometheus/prometheus", Version: "v0.40.5"},
+			{Path: "github.com/prometheus/node_exporter", Version: "v1.5.0", Replace: &debug.Module{
+				Path:    "github.com/grafana/node_exporter",
+				Version: "v0.18.1-grafana-r01.0.20221213",
+			}},
+		}}, true
+	}
+
+	inv := newInventory([]*flow.ComponentInfo{
+		{ID: "inventory_test.receiver.default", Name: "inventory_test.receiver"},
+		{ID: "inventory_test.exporter.default", Name: "inventory_test.exporter"},
+		{ID: "inventory_test.local.token", Name: "inventory_test.local"},
+	})
+
+	require.Equal(t, []InventoryComponent{
+		{
+			ID:   "inventory_test.exporter.default",
+			Name: "inventory_test.exporter",
+			Modules: []InventoryModule{{
+				Path:        "github.com/prometheus/node_exporter",
+				Version:     "v1.5.0",
+				Replacement: "github.com/grafana/node_exporter@v0.18.1-grafana-r01.0.20221213",
+			}},
+		},
+		{
+			ID:      "inventory_test.local.token",
+			Name:    "inventory_test.local",
+			Modules: []InventoryModule{},
+		},
+		{
+			ID:   "inventory_test.receiver.default",
+			Name: "inventory_test.receiver",
+			Modules: []InventoryModule{
+				// Modules missing from the build information have an unknown
+				// version.
+				{Path: "go.opentelemetry.io/collector", Version: "unknown"},
+				{Path: "github.com/prometheus/prometheus", Version: "v0.40.5"},
+			},
+		},
+	}, inv.Components)
+	require.NotEmpty(t, inv.Build.GoVersion)
+}
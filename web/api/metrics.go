@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// componentIDLabel is the label added to the metrics of every component.
+const componentIDLabel = "component_id"
+
+// sampledMetric is a metric of write components which is sampled so the UI
+// can graph it on component pages.
+type sampledMetric struct {
+	Name  string // Name of the Prometheus metric.
+	Title string // Title of the graph.
+	Rate  bool   // Whether the metric is a counter to graph as a per-second rate.
+}
+
+// sampledMetrics are the metrics sampled by MetricsSampler. Series of the
+// same metric for a component, such as one per endpoint, are summed.
+var sampledMetrics = []sampledMetric{
+	// prometheus.remote_write
+	{Name: "prometheus_remote_storage_samples_pending", Title: "Queue depth (samples)"},
+	{Name: "prometheus_remote_storage_bytes_total", Title: "Sent bytes/s", Rate: true},
+	{Name: "prometheus_remote_storage_samples_retried_total", Title: "Retried samples/s", Rate: true},
+	{Name: "prometheus_remote_storage_samples_failed_total", Title: "Failed samples/s", Rate: true},
+
+	// loki.write
+	{Name: "loki_write_sent_entries_total", Title: "Sent entries/s", Rate: true},
+	{Name: "loki_write_sent_bytes_total", Title: "Sent bytes/s", Rate: true},
+	{Name: "loki_write_batch_retries_total", Title: "Batch retries/s", Rate: true},
+	{Name: "loki_write_dropped_entries_total", Title: "Dropped entries/s", Rate: true},
+}
+
+// MetricsSampler periodically samples the metrics of write components, such
+// as their queue depth and failure rates, so that recent history can be shown
+// on component pages without an external metrics database.
+type MetricsSampler struct {
+	gatherer prometheus.Gatherer
+	interval time.Duration
+	size     int
+
+	mut    sync.RWMutex
+	series map[string]map[string]*sampledSeries // Component ID -> metric name -> series
+}
+
+type sampledSeries struct {
+	points []SamplePoint
+
+	// Last raw value, used to compute rates.
+	lastValue float64
+	lastTime  time.Time
+}
+
+// SamplePoint is a single sample of a metric.
+type SamplePoint struct {
+	Timestamp int64   `json:"t"` // Unix timestamp in milliseconds.
+	Value     float64 `json:"v"`
+}
+
+// SampledSeries is the recent history of a metric of a component.
+type SampledSeries struct {
+	Name   string        `json:"name"`
+	Title  string        `json:"title"`
+	Points []SamplePoint `json:"points"`
+}
+
+// NewMetricsSampler creates a new MetricsSampler which gathers metrics from g
+// every interval, keeping up to size samples per series.
+func NewMetricsSampler(g prometheus.Gatherer, interval time.Duration, size int) *MetricsSampler {
+	return &MetricsSampler{
+		gatherer: g,
+		interval: interval,
+		size:     size,
+		series:   make(map[string]map[string]*sampledSeries),
+	}
+}
+
+// Run samples metrics until ctx is canceled.
+func (s *MetricsSampler) Run(ctx context.Context) {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			s.sample(now)
+		}
+	}
+}
+
+// sample gathers the current value of every sampled metric.
+func (s *MetricsSampler) sample(now time.Time) {
+	families, err := s.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return
+	}
+
+	// Sum the values of each metric per component.
+	values := make(map[string]map[string]float64)
+	for _, mf := range families {
+		if !isSampledMetric(mf.GetName()) {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			id := labelValue(m, componentIDLabel)
+			if id == "" {
+				continue
+			}
+			if values[id] == nil {
+				values[id] = make(map[string]float64)
+			}
+			values[id][mf.GetName()] += metricValue(m)
+		}
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// Forget about components which no longer exist.
+	for id := range s.series {
+		if _, ok := values[id]; !ok {
+			delete(s.series, id)
+		}
+	}
+
+	for id, metrics := range values {
+		if s.series[id] == nil {
+			s.series[id] = make(map[string]*sampledSeries)
+		}
+
+		for _, sm := range sampledMetrics {
+			value, ok := metrics[sm.Name]
+			if !ok {
+				continue
+			}
+
+			series := s.series[id][sm.Name]
+			if series == nil {
+				series = &sampledSeries{}
+				s.series[id][sm.Name] = series
+			}
+			s.appendSample(series, sm, now, value)
+		}
+	}
+}
+
+func (s *MetricsSampler) appendSample(series *sampledSeries, sm sampledMetric, now time.Time, value float64) {
+	defer func() {
+		series.lastValue = value
+		series.lastTime = now
+	}()
+
+	point := SamplePoint{Timestamp: now.UnixMilli(), Value: value}
+	if sm.Rate {
+		if series.lastTime.IsZero() {
+			// A rate needs two samples.
+			return
+		}
+
+		increase := value - series.lastValue
+		if increase < 0 {
+			// The counter was reset.
+			increase = value
+		}
+		point.Value = increase / now.Sub(series.lastTime).Seconds()
+	}
+
+	series.points = append(series.points, point)
+	if len(series.points) > s.size {
+		series.points = series.points[len(series.points)-s.size:]
+	}
+}
+
+// ComponentSeries returns the sampled series of the component with the given
+// ID.
+func (s *MetricsSampler) ComponentSeries(id string) []SampledSeries {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	res := []SampledSeries{}
+	for _, sm := range sampledMetrics {
+		series, ok := s.series[id][sm.Name]
+		if !ok {
+			continue
+		}
+		res = append(res, SampledSeries{
+			Name:   sm.Name,
+			Title:  sm.Title,
+			Points: append([]SamplePoint{}, series.points...),
+		})
+	}
+	return res
+}
+
+func isSampledMetric(name string) bool {
+	for _, sm := range sampledMetrics {
+		if sm.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+func metricValue(m *dto.Metric) float64 {
+	switch {
+	case m.Counter != nil:
+		return m.Counter.GetValue()
+	case m.Gauge != nil:
+		return m.Gauge.GetValue()
+	case m.Untyped != nil:
+		return m.Untyped.GetValue()
+	default:
+		return 0
+	}
+}
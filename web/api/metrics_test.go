@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsSampler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	pending := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prometheus_remote_storage_samples_pending",
+	}, []string{"component_id", "url"})
+	sentBytes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_sent_bytes_total",
+	}, []string{"component_id", "host"})
+	other := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "unrelated_total",
+	}, []string{"component_id"})
+	reg.MustRegister(pending, sentBytes, other)
+
+	var (
+		s     = NewMetricsSampler(reg, time.Second, 2)
+		start = time.Unix(1000, 0)
+	)
+
+	// Series of the same component are summed.
+	pending.WithLabelValues("prometheus.remote_write.default", "http://a").Set(10)
+	pending.WithLabelValues("prometheus.remote_write.default", "http://b").Set(5)
+	sentBytes.WithLabelValues("loki.write.default", "a").Add(100)
+	other.WithLabelValues("loki.write.default").Inc()
+	s.sample(start)
+
+	require.Equal(t, []SampledSeries{{
+		Name:   "prometheus_remote_storage_samples_pending",
+		Title:  "Queue depth (samples)",
+		Points: []SamplePoint{{Timestamp: 1000000, Value: 15}},
+	}}, s.ComponentSeries("prometheus.remote_write.default"))
+
+	// Counters need two samples to compute a rate.
+	require.Equal(t, []SampledSeries{{
+		Name:   "loki_write_sent_bytes_total",
+		Title:  "Sent bytes/s",
+		Points: []SamplePoint{},
+	}}, s.ComponentSeries("loki.write.default"))
+
+	sentBytes.WithLabelValues("loki.write.default", "a").Add(300)
+	s.sample(start.Add(10 * time.Second))
+	sentBytes.WithLabelValues("loki.write.default", "a").Add(100)
+	s.sample(start.Add(20 * time.Second))
+	sentBytes.WithLabelValues("loki.write.default", "a").Add(200)
+	s.sample(start.Add(30 * time.Second))
+
+	// Only the most recent samples are kept.
+	require.Equal(t, []SampledSeries{{
+		Name:  "loki_write_sent_bytes_total",
+		Title: "Sent bytes/s",
+		Points: []SamplePoint{
+			{Timestamp: 1020000, Value: 10},
+			{Timestamp: 1030000, Value: 20},
+		},
+	}}, s.ComponentSeries("loki.write.default"))
+
+	// Components which no longer expose metrics are forgotten.
+	pending.Reset()
+	s.sample(start.Add(40 * time.Second))
+	require.Empty(t, s.ComponentSeries("prometheus.remote_write.default"))
+}
@@ -0,0 +1,88 @@
+import { FC } from 'react';
+
+import { useComponentMetrics } from '../../hooks/componentMetrics';
+
+import { SampledSeries } from './types';
+
+import styles from './ComponentMetrics.module.css';
+
+/**
+ * metricsComponents are the names of components whose metrics are sampled by
+ * the agent.
+ */
+export const metricsComponents = ['prometheus.remote_write', 'loki.write'];
+
+interface ComponentMetricsProps {
+  id: string;
+}
+
+/**
+ * ComponentMetrics renders sparklines of the recently sampled metrics of a
+ * component, such as the queue depth and failure rates of write components.
+ */
+const ComponentMetrics: FC<ComponentMetricsProps> = ({ id }) => {
+  const series = useComponentMetrics(id);
+
+  if (series.length === 0) {
+    return <em className={styles.empty}>No metrics have been sampled for this component yet.</em>;
+  }
+
+  return (
+    <div className={styles.grid}>
+      {series.map((s) => (
+        <Sparkline key={s.name} series={s} />
+      ))}
+    </div>
+  );
+};
+
+const WIDTH = 240;
+const HEIGHT = 48;
+
+interface SparklineProps {
+  series: SampledSeries;
+}
+
+const Sparkline: FC<SparklineProps> = ({ series }) => {
+  const points = series.points;
+  const latest = points.length > 0 ? points[points.length - 1].v : undefined;
+
+  let path = '';
+  if (points.length > 1) {
+    const minT = points[0].t;
+    const maxT = points[points.length - 1].t;
+    const maxV = Math.max(...points.map((p) => p.v), 0);
+
+    path = points
+      .map((p) => {
+        const x = ((p.t - minT) / (maxT - minT)) * WIDTH;
+        const y = maxV === 0 ? HEIGHT : HEIGHT - (p.v / maxV) * HEIGHT;
+        return `${x.toFixed(1)},${y.toFixed(1)}`;
+      })
+      .join(' ');
+  }
+
+  return (
+    <div className={styles.sparkline} title={series.name}>
+      <div className={styles.header}>
+        <span className={styles.title}>{series.title}</span>
+        <span className={styles.value}>{latest !== undefined ? formatValue(latest) : '-'}</span>
+      </div>
+      <svg viewBox={`0 0 ${WIDTH} ${HEIGHT}`} preserveAspectRatio="none">
+        {path && <polyline points={path} />}
+      </svg>
+    </div>
+  );
+};
+
+function formatValue(v: number): string {
+  const units = ['', 'k', 'M', 'G', 'T'];
+  let i = 0;
+  while (Math.abs(v) >= 1000 && i < units.length - 1) {
+    v /= 1000;
+    i++;
+  }
+  return `${Number(v.toFixed(2))}${units[i]}`;
+}
+
+export default ComponentMetrics;
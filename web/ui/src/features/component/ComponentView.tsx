@@ -7,6 +7,7 @@ import { partitionBody } from '../../utils/partition';
 
 import ComponentBody from './ComponentBody';
 import ComponentList from './ComponentList';
+import ComponentMetrics, { metricsComponents } from './ComponentMetrics';
 import { HealthLabel } from './HealthLabel';
 import { ComponentDetail, ComponentInfo, PartitionedBody } from './types';
 
@@ -27,6 +28,9 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
   const exportsPartition = props.component.exports && partitionBody(props.component.exports, 'Exports');
   const debugPartition = props.component.debugInfo && partitionBody(props.component.debugInfo, 'Debug info');
 
+  // Metrics are only sampled for components outside of modules.
+  const showMetrics = !props.component.parent && metricsComponents.includes(props.component.name);
+
   function partitionTOC(partition: PartitionedBody): ReactElement {
     return (
       <li>
@@ -55,6 +59,13 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
               {props.component.id}
             </Link>
           </li>
+          {showMetrics && (
+            <li>
+              <Link to="#metrics" target="_top">
+                Metrics
+              </Link>
+            </li>
+          )}
           {argsPartition && partitionTOC(argsPartition)}
           {exportsPartition && partitionTOC(exportsPartition)}
           {debugPartition && partitionTOC(debugPartition)}
@@ -112,6 +123,15 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           </blockquote>
         )}
 
+        {showMetrics && (
+          <section id="metrics">
+            <h2>Metrics</h2>
+            <div className={styles.sectionContent}>
+              <ComponentMetrics id={props.component.id} />
+            </div>
+          </section>
+        )}
+
         <ComponentBody partition={argsPartition} />
         {exportsPartition && <ComponentBody partition={exportsPartition} />}
         {debugPartition && <ComponentBody partition={debugPartition} />}
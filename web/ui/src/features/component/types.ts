@@ -118,3 +118,29 @@ export interface PartitionedBody {
   attrs: AttrStmt[];
   inner: PartitionedBody[];
 }
+
+/**
+ * SampledSeries is the recent history of a metric of a component, sampled by
+ * the agent.
+ */
+export interface SampledSeries {
+  /** Name of the Prometheus metric. */
+  name: string;
+
+  /** Title to display for the metric. */
+  title: string;
+
+  /** Samples of the metric, ordered from oldest to newest. */
+  points: SamplePoint[];
+}
+
+/**
+ * SamplePoint is a single sample of a metric.
+ */
+export interface SamplePoint {
+  /** Unix timestamp of the sample in milliseconds. */
+  t: number;
+
+  /** Value of the sample. */
+  v: number;
+}
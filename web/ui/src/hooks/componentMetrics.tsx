@@ -0,0 +1,34 @@
+import { useEffect, useState } from 'react';
+
+import { SampledSeries } from '../features/component/types';
+
+/**
+ * useComponentMetrics retrieves the recently sampled metrics of a component
+ * from the API, refreshing them every refreshInterval milliseconds.
+ *
+ * @param id The ID of the component to retrieve metrics for.
+ * @param refreshInterval How often to refresh metrics in milliseconds.
+ */
+export const useComponentMetrics = (id: string, refreshInterval = 15000): SampledSeries[] => {
+  const [series, setSeries] = useState<SampledSeries[]>([]);
+
+  useEffect(
+    function () {
+      const worker = async () => {
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch(`./api/v0/web/components/${id}/metrics`, {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+        });
+        setSeries(await resp.json());
+      };
+
+      worker().catch(console.error);
+      const interval = setInterval(() => worker().catch(console.error), refreshInterval);
+      return () => clearInterval(interval);
+    },
+    [id, refreshInterval]
+  );
+
+  return series;
+};